@@ -8,20 +8,29 @@ import (
 	"os"
 	"os/signal"
 	"strings"
+	"syscall"
 
 	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/convertcmd"
 	"github.com/acronis/go-cti/cmd/cti/internal/commands/deploycmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/doccmd"
 	"github.com/acronis/go-cti/cmd/cti/internal/commands/envcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/explaincmd"
 	"github.com/acronis/go-cti/cmd/cti/internal/commands/fmtcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/importcmd"
 	"github.com/acronis/go-cti/cmd/cti/internal/commands/infocmd"
 	"github.com/acronis/go-cti/cmd/cti/internal/commands/initcmd"
 	"github.com/acronis/go-cti/cmd/cti/internal/commands/lintcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/listcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/mockcmd"
 	"github.com/acronis/go-cti/cmd/cti/internal/commands/packcmd"
 	"github.com/acronis/go-cti/cmd/cti/internal/commands/pkgcmd"
 	"github.com/acronis/go-cti/cmd/cti/internal/commands/restcmd"
 	"github.com/acronis/go-cti/cmd/cti/internal/commands/synccmd"
 	"github.com/acronis/go-cti/cmd/cti/internal/commands/testcmd"
 	"github.com/acronis/go-cti/cmd/cti/internal/commands/validatecmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
 	"github.com/acronis/go-stacktrace"
 	slogex "github.com/acronis/go-stacktrace/slogex"
 	"github.com/mattn/go-isatty"
@@ -31,7 +40,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-func initLogging(verbose bool) {
+func initLogging(verbose bool, noColor bool) {
 	logLvl := func() slog.Level {
 		if verbose {
 			return slog.LevelDebug
@@ -51,7 +60,7 @@ func initLogging(verbose bool) {
 			prettylog.New(&slog.HandlerOptions{Level: logLvl},
 				prettylog.WithDestinationWriter(w),
 				func() prettylog.Option {
-					if isatty.IsTerminal(w.Fd()) {
+					if !noColor && isatty.IsTerminal(w.Fd()) {
 						return prettylog.WithColor()
 					}
 					return func(_ *prettylog.Handler) {}
@@ -72,7 +81,7 @@ func main() {
 
 func mainFn() int {
 	var ensureDuplicates bool
-	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, os.Kill, syscall.SIGTERM)
 	defer stop()
 
 	rootCmd := func() *cobra.Command {
@@ -88,7 +97,19 @@ func mainFn() int {
 					os.Exit(1)
 				}
 
-				initLogging(verbose)
+				// Settings are applied before commands run, so the package manager, storages and the HTTP client honor
+				// them. Commands that read the configuration report its errors themselves.
+				cfg := &cti.Config{}
+				baseDir, err := command.GetWorkingDir(cmd)
+				if err == nil {
+					if loaded, err := cti.LoadConfig(baseDir); err == nil {
+						cfg = loaded
+					}
+				}
+				initLogging(verbose, cfg.ColorDisabled())
+				if err := cti.ApplySettings(cfg); err != nil {
+					slog.Warn("Failed to apply settings", slog.String("error", err.Error()))
+				}
 			},
 			CompletionOptions: cobra.CompletionOptions{
 				DisableDefaultCmd: true,
@@ -102,10 +123,17 @@ func mainFn() int {
 
 		cmd.AddCommand(
 			initcmd.New(ctx),
+			listcmd.New(ctx),
 			packcmd.New(ctx),
 			pkgcmd.New(ctx),
 			synccmd.New(ctx),
 			validatecmd.New(ctx),
+			explaincmd.New(ctx),
+			doccmd.New(ctx),
+			importcmd.New(ctx),
+			convertcmd.New(ctx),
+			gencmd.New(ctx),
+			mockcmd.New(ctx),
 			// TODO implement
 			deploycmd.New(ctx),
 			envcmd.New(ctx),
@@ -127,7 +155,12 @@ func mainFn() int {
 		return cmd
 	}()
 
+	rootCmd.SetArgs(testcmd.NormalizeArgs(rootCmd, os.Args[1:]))
 	if err := rootCmd.Execute(); err != nil {
+		var exitErr *command.ExitError
+		if errors.As(err, &exitErr) {
+			return exitErr.Code
+		}
 		var cmdErr *command.Error
 		if errors.As(err, &cmdErr) && cmdErr.Inner != nil {
 			stOpts := func() []stacktrace.TracesOpt {
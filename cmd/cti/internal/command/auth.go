@@ -0,0 +1,62 @@
+package command
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// requestTimeout limits requests to registries, including uploads of artifacts.
+const requestTimeout = 5 * time.Minute
+
+// clientCredentials configures the OAuth 2.0 client credentials flow with an OpenID Connect provider.
+type clientCredentials struct {
+	// Issuer is the URL of the provider, its token endpoint is discovered.
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+	// Audience is requested if set, as some providers issue tokens for the audience only.
+	Audience string
+}
+
+// staticToken returns the source of the bearer token.
+func staticToken(token string) oauth2.TokenSource {
+	return oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token, TokenType: "Bearer"})
+}
+
+// clientCredentialsToken discovers the token endpoint of the provider and returns the source of tokens issued
+// to the client. Tokens are refreshed when they expire.
+func clientCredentialsToken(ctx context.Context, cfg clientCredentials) (oauth2.TokenSource, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("discover provider %s: %w", cfg.Issuer, err)
+	}
+	cc := &clientcredentials.Config{
+		ClientID:     cfg.ClientID,
+		ClientSecret: cfg.ClientSecret,
+		TokenURL:     provider.Endpoint().TokenURL,
+		Scopes:       cfg.Scopes,
+	}
+	if cfg.Audience != "" {
+		cc.EndpointParams = url.Values{"audience": {cfg.Audience}}
+	}
+	return cc.TokenSource(ctx), nil
+}
+
+// newRegistryClient creates the client of registries authenticating requests with tokens of the source,
+// requests are not authenticated if it is nil.
+func newRegistryClient(ctx context.Context, ts oauth2.TokenSource) *http.Client {
+	if ts == nil {
+		return &http.Client{Timeout: requestTimeout}
+	}
+	client := oauth2.NewClient(ctx, oauth2.ReuseTokenSource(nil, ts))
+	client.Timeout = requestTimeout
+	return client
+}
@@ -0,0 +1,59 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ClientCredentialsToken(t *testing.T) {
+	mux := http.NewServeMux()
+	provider := httptest.NewServer(mux)
+	t.Cleanup(provider.Close)
+	mux.HandleFunc("GET /.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                 provider.URL,
+			"token_endpoint":         provider.URL + "/token",
+			"authorization_endpoint": provider.URL + "/authorize",
+			"jwks_uri":               provider.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("POST /token", func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if id != "deployer" || secret != "secret" || r.FormValue("grant_type") != "client_credentials" ||
+			r.FormValue("audience") != "registry" || r.FormValue("scope") != "deploy" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"access_token": "issued", "token_type": "Bearer", "expires_in": 3600}`))
+	})
+
+	ctx := context.Background()
+	ts, err := clientCredentialsToken(ctx, clientCredentials{
+		Issuer:       provider.URL,
+		ClientID:     "deployer",
+		ClientSecret: "secret",
+		Scopes:       []string{"deploy"},
+		Audience:     "registry",
+	})
+	require.NoError(t, err)
+
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer issued" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	t.Cleanup(registry.Close)
+	res, err := newRegistryClient(ctx, ts).Get(registry.URL)
+	require.NoError(t, err)
+	require.NoError(t, res.Body.Close())
+	require.Equal(t, http.StatusOK, res.StatusCode)
+
+	_, err = clientCredentialsToken(ctx, clientCredentials{Issuer: registry.URL})
+	require.ErrorContains(t, err, "discover provider "+registry.URL)
+}
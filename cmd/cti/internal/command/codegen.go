@@ -0,0 +1,109 @@
+package command
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/acronis/go-cti"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/registry"
+	"github.com/spf13/cobra"
+)
+
+// GenOptions are options shared by gen commands.
+type GenOptions struct {
+	Output              string
+	IncludeDependencies bool
+	Filter              string
+}
+
+func AddGenFlags(cmd *cobra.Command, opts *GenOptions, defaultOutput string) {
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", defaultOutput, "Output directory, relative to the package.")
+	cmd.Flags().BoolVarP(&opts.IncludeDependencies, "all", "a", false, "Generate types of dependencies.")
+	cmd.Flags().StringVar(&opts.Filter, "filter", "", "Generate only types matching the CTI pattern, e.g. cti.x.y.* or cti.x.y.event.v1.0.")
+}
+
+// OutputDir returns the output directory resolved against the package directory.
+func (o GenOptions) OutputDir(baseDir string) string {
+	if filepath.IsAbs(o.Output) {
+		return o.Output
+	}
+	return filepath.Join(baseDir, o.Output)
+}
+
+// RunGen parses the package in the directory and writes files generated from its types to the output directory.
+// Ancestors of generated types are resolved from dependencies of the package.
+func RunGen(baseDir string, opts GenOptions, run func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error)) error {
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+	if err := pkg.Parse(); err != nil {
+		return fmt.Errorf("parse package: %w", err)
+	}
+
+	entities := pkg.Registry.Entities()
+	if !opts.IncludeDependencies {
+		local, err := registry.New(pkg.LocalRegistry.Index)
+		if err != nil {
+			return fmt.Errorf("index local entities: %w", err)
+		}
+		entities = local.Entities()
+	}
+
+	if opts.Filter != "" {
+		if entities, err = FilterEntities(entities, opts.Filter); err != nil {
+			return err
+		}
+	}
+
+	files, err := run(codegen.New(pkg.Registry), entities)
+	if err != nil {
+		return fmt.Errorf("generate: %w", err)
+	}
+
+	outDir := opts.OutputDir(baseDir)
+	for _, f := range files {
+		fPath := filepath.Join(outDir, f.Name)
+		if err := os.MkdirAll(filepath.Dir(fPath), os.ModePerm); err != nil {
+			return fmt.Errorf("create directory: %w", err)
+		}
+		if err := os.WriteFile(fPath, f.Data, 0600); err != nil {
+			return fmt.Errorf("write %s: %w", fPath, err)
+		}
+		slog.Debug("Generated", slog.String("path", fPath))
+	}
+	slog.Info("Generation has been completed", slog.String("path", outDir), slog.Int("files", len(files)))
+	return nil
+}
+
+// FilterEntities returns entities matching the CTI pattern. The pattern matches descendants of the types as well.
+func FilterEntities(entities metadata.Entities, pattern string) (metadata.Entities, error) {
+	p := cti.NewParser()
+	expr, err := p.Parse(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("parse filter: %w", err)
+	}
+	var res metadata.Entities
+	for _, entity := range entities {
+		id, err := p.Parse(entity.Cti)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", entity.Cti, err)
+		}
+		ok, err := expr.Match(id)
+		if err != nil {
+			return nil, fmt.Errorf("match %s: %w", entity.Cti, err)
+		}
+		if ok {
+			res = append(res, entity)
+		}
+	}
+	return res, nil
+}
@@ -0,0 +1,87 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/importer"
+)
+
+// convertEntitiesDir is a directory of RAML files in the temporary package used to check conversions.
+const convertEntitiesDir = "entities"
+
+// CheckConversion converts the entities into RAML, parses them in a temporary package and returns differences
+// between parsed and original entities. Dependencies of the package in the directory are used to parse the RAML.
+func CheckConversion(baseDir, packageID string, entities metadata.Entities) ([]importer.Issue, error) {
+	imp, err := importer.New(packageID, importer.WithRamlxPath("../"+ctipackage.RamlxDirName+"/cti.raml"))
+	if err != nil {
+		return nil, fmt.Errorf("new importer: %w", err)
+	}
+	files, err := imp.ImportMetadata(entities)
+	if err != nil {
+		return nil, fmt.Errorf("convert entities: %w", err)
+	}
+
+	tmpDir, err := os.MkdirTemp("", "cti-convert-")
+	if err != nil {
+		return nil, fmt.Errorf("create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	paths := make([]string, 0, len(files))
+	if err := os.MkdirAll(filepath.Join(tmpDir, convertEntitiesDir), os.ModePerm); err != nil {
+		return nil, fmt.Errorf("create directory: %w", err)
+	}
+	for _, f := range files {
+		if err := os.WriteFile(filepath.Join(tmpDir, convertEntitiesDir, f.Name), f.Data, 0600); err != nil {
+			return nil, fmt.Errorf("write %s: %w", f.Name, err)
+		}
+		paths = append(paths, convertEntitiesDir+"/"+f.Name)
+	}
+
+	pkg, err := ctipackage.New(tmpDir, ctipackage.WithID(packageID), ctipackage.WithEntities(paths))
+	if err != nil {
+		return nil, fmt.Errorf("new package: %w", err)
+	}
+	lock, err := ctipackage.ReadIndexLock(baseDir)
+	switch {
+	case err == nil && len(lock.SourceInfo) > 0:
+		pkg.IndexLock = lock
+		if err := os.Symlink(filepath.Join(baseDir, ctipackage.DependencyDirName), filepath.Join(tmpDir, ctipackage.DependencyDirName)); err != nil {
+			return nil, fmt.Errorf("link dependencies: %w", err)
+		}
+	case err != nil && !errors.Is(err, os.ErrNotExist):
+		return nil, fmt.Errorf("read index lock: %w", err)
+	}
+	if err := pkg.Initialize(); err != nil {
+		return nil, fmt.Errorf("initialize package: %w", err)
+	}
+	if err := pkg.Parse(); err != nil {
+		return nil, fmt.Errorf("parse converted RAML: %w", err)
+	}
+
+	actual := make(metadata.Entities, 0, len(pkg.LocalRegistry.Index))
+	for _, entity := range pkg.LocalRegistry.Index {
+		actual = append(actual, entity)
+	}
+	return importer.CompareEntities(entities, actual), nil
+}
+
+// ReportConversion logs differences found by CheckConversion. Differences are an error in strict mode.
+func ReportConversion(issues []importer.Issue, strict bool) error {
+	for _, issue := range issues {
+		slog.Warn("Conversion is lossy", slog.String("entity", issue.Location), slog.String("issue", issue.Message))
+	}
+	if len(issues) > 0 && strict {
+		return fmt.Errorf("conversion is lossy: %d differences found", len(issues))
+	}
+	if len(issues) == 0 {
+		slog.Info("Conversion is lossless")
+	}
+	return nil
+}
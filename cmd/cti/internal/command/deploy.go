@@ -0,0 +1,132 @@
+package command
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/user"
+	"path/filepath"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata/archiver/tgzwriter"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/deploy"
+	"github.com/acronis/go-cti/metadata/packer"
+
+	"golang.org/x/oauth2"
+)
+
+// DeployTarget creates the deployment target of the environment with the client authenticated as configured.
+func DeployTarget(ctx context.Context, name string, env cti.Environment) (deploy.Target, error) {
+	if env.Registry == "" {
+		return nil, fmt.Errorf("environment %s has no registry", name)
+	}
+	ts, err := tokenSource(ctx, env)
+	if err != nil {
+		return nil, fmt.Errorf("environment %s: %w", name, err)
+	}
+	opts := []deploy.TargetOption{deploy.WithUser(deployUser())}
+	if env.SigningKeyEnv != "" {
+		key := os.Getenv(env.SigningKeyEnv)
+		if key == "" {
+			return nil, fmt.Errorf("environment %s: signing key %s: environment variable is not set", name, env.SigningKeyEnv)
+		}
+		signer, err := deploy.ParseSigningKey([]byte(key))
+		if err != nil {
+			return nil, fmt.Errorf("environment %s: %w", name, err)
+		}
+		opts = append(opts, deploy.WithSigner(signer))
+	}
+	target, err := deploy.NewTarget(env.Registry, newRegistryClient(ctx, ts), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("environment %s: %w", name, err)
+	}
+	return target, nil
+}
+
+// deployUser identifies the user in deployments as user@host.
+func deployUser() string {
+	name := "unknown"
+	if u, err := user.Current(); err == nil {
+		name = u.Username
+	}
+	if host, err := os.Hostname(); err == nil {
+		return name + "@" + host
+	}
+	return name
+}
+
+// tokenSource returns the source of tokens of the registry, nil if requests are not authenticated.
+// Secrets are read from environment variables, or from the keyring if they were stored with cti env login.
+func tokenSource(ctx context.Context, env cti.Environment) (oauth2.TokenSource, error) {
+	auth := env.Auth
+	switch {
+	case auth.TokenEnv != "" && auth.OIDC != nil:
+		return nil, errors.New("auth: token_env and oidc are mutually exclusive")
+	case auth.OIDC != nil:
+		secret, err := loadSecret(env, auth.OIDC.ClientSecretEnv, true)
+		if err != nil {
+			return nil, fmt.Errorf("client secret: %w", err)
+		}
+		return clientCredentialsToken(ctx, clientCredentials{
+			Issuer:       auth.OIDC.Issuer,
+			ClientID:     auth.OIDC.ClientID,
+			ClientSecret: secret,
+			Scopes:       auth.OIDC.Scopes,
+			Audience:     auth.OIDC.Audience,
+		})
+	default:
+		token, err := loadSecret(env, auth.TokenEnv, auth.TokenEnv != "")
+		if err != nil {
+			return nil, fmt.Errorf("token: %w", err)
+		}
+		if token == "" {
+			return nil, nil
+		}
+		return staticToken(token), nil
+	}
+}
+
+// loadSecret returns the secret of the environment variable, or the secret of the registry stored in the keyring.
+// Requests without a required secret are not authenticated, so the keyring being unavailable is not an error then.
+func loadSecret(env cti.Environment, name string, required bool) (string, error) {
+	if name != "" {
+		if secret := os.Getenv(name); secret != "" {
+			return secret, nil
+		}
+	}
+	secret, err := cti.LoadSecret(env)
+	switch {
+	case err != nil && !required:
+		slog.Debug("Keyring is not available", slog.String("error", err.Error()))
+		return "", nil
+	case err != nil && name != "":
+		return "", fmt.Errorf("%s: environment variable is not set: %w", name, err)
+	case err != nil:
+		return "", err
+	case secret == "" && name != "":
+		return "", fmt.Errorf("%s: environment variable is not set and no secret is stored, run cti env login", name)
+	case secret == "" && required:
+		return "", errors.New("no secret is stored, run cti env login")
+	}
+	return secret, nil
+}
+
+// PackBundle packs the package into a bundle in the directory, e.g. to compute the digest of its contents.
+func PackBundle(baseDir, dir string) (string, error) {
+	p, err := packer.New(packer.WithArchiver(tgzwriter.New()))
+	if err != nil {
+		return "", fmt.Errorf("new packer: %w", err)
+	}
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return "", fmt.Errorf("new package: %w", err)
+	}
+	archive := filepath.Join(dir, "package"+packer.ArchiveExtension)
+	if err := p.Pack(pkg, archive); err != nil {
+		return "", fmt.Errorf("pack the package: %w", err)
+	}
+	return archive, nil
+}
@@ -27,3 +27,12 @@ func WrapError(err error) error {
 		Msg:   "command failed",
 	}
 }
+
+// ExitError exits cti with the code without reporting the error, e.g. the exit code of a command run by cti.
+type ExitError struct {
+	Code int
+}
+
+func (e *ExitError) Error() string {
+	return fmt.Sprintf("exit status %d", e.Code)
+}
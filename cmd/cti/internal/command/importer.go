@@ -0,0 +1,106 @@
+package command
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/importer"
+	"github.com/spf13/cobra"
+)
+
+// ImportOptions are options shared by import commands.
+type ImportOptions struct {
+	Output    string
+	PackageID string
+	Force     bool
+}
+
+func AddImportFlags(cmd *cobra.Command, opts *ImportOptions) {
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "entities", "Output directory for generated RAML files, relative to the package.")
+	cmd.Flags().StringVar(&opts.PackageID, "package-id", "", "Package ID used in generated identifiers. Read from index.json by default.")
+	cmd.Flags().BoolVar(&opts.Force, "force", false, "Overwrite existing files.")
+}
+
+// RunImport runs the import into the package in the directory. Generated RAML files are written to the output
+// directory and registered in the package index if the package is initialized.
+func RunImport(baseDir string, opts ImportOptions, run func(imp *importer.Importer) ([]importer.File, error)) error {
+	idx, err := ctipackage.ReadIndex(baseDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read index: %w", err)
+	}
+
+	packageID := opts.PackageID
+	if packageID == "" && idx != nil {
+		packageID = idx.PackageID
+	}
+	if packageID == "" {
+		return errors.New("package id is not set, initialize the package or use --package-id")
+	}
+
+	outDir := opts.Output
+	if !filepath.IsAbs(outDir) {
+		outDir = filepath.Join(baseDir, outDir)
+	}
+	ramlxPath, err := filepath.Rel(outDir, filepath.Join(baseDir, ctipackage.RamlxDirName, "cti.raml"))
+	if err != nil {
+		return fmt.Errorf("get path to ramlx: %w", err)
+	}
+
+	imp, err := importer.New(packageID, importer.WithRamlxPath(filepath.ToSlash(ramlxPath)))
+	if err != nil {
+		return fmt.Errorf("new importer: %w", err)
+	}
+	files, err := run(imp)
+	if err != nil {
+		return fmt.Errorf("import: %w", err)
+	}
+
+	if !opts.Force {
+		for _, f := range files {
+			if _, err := os.Stat(filepath.Join(outDir, f.Name)); err == nil {
+				return fmt.Errorf("%s already exists, use --force to overwrite", filepath.Join(outDir, f.Name))
+			}
+		}
+	}
+	if err := os.MkdirAll(outDir, os.ModePerm); err != nil {
+		return fmt.Errorf("create output directory: %w", err)
+	}
+	for _, f := range files {
+		fPath := filepath.Join(outDir, f.Name)
+		if err := os.WriteFile(fPath, f.Data, 0600); err != nil {
+			return fmt.Errorf("write %s: %w", fPath, err)
+		}
+		slog.Info("Generated", slog.String("path", fPath))
+
+		if idx == nil {
+			continue
+		}
+		if rel, err := filepath.Rel(baseDir, fPath); err == nil {
+			addEntity(idx, filepath.ToSlash(rel))
+		}
+	}
+	if idx != nil {
+		if err := idx.Save(baseDir); err != nil {
+			return fmt.Errorf("save index: %w", err)
+		}
+	}
+
+	for _, issue := range imp.Issues() {
+		slog.Warn("Needs manual attention", slog.String("location", issue.Location), slog.String("issue", issue.Message))
+	}
+	slog.Info("Import has been completed", slog.Int("files", len(files)), slog.Int("issues", len(imp.Issues())))
+	return nil
+}
+
+func addEntity(idx *ctipackage.Index, p string) {
+	for _, e := range idx.Entities {
+		if e == p {
+			return
+		}
+	}
+	idx.Entities = append(idx.Entities, p)
+}
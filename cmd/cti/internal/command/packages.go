@@ -3,19 +3,26 @@ package command
 import (
 	"fmt"
 	"strings"
+
+	"github.com/acronis/go-cti/metadata/pacman"
 )
 
+// ParsePackages parses <source>@<version> arguments. The version may be a query, e.g. latest, v1 or a commit hash,
+// and defaults to latest if it is omitted.
 func ParsePackages(args []string) (map[string]string, error) {
 	pkgs := map[string]string{}
 	for _, pkg := range args {
-		chunks := strings.Split(pkg, "@")
-		if len(chunks) != 2 {
+		source, version, found := strings.Cut(pkg, "@")
+		if !found {
+			version = pacman.QueryLatest
+		}
+		if source == "" || version == "" || strings.Contains(version, "@") {
 			return nil, fmt.Errorf("invalid package format: %s, should be `<source>@<version>`", pkg)
 		}
-		if _, ok := pkgs[chunks[0]]; ok {
-			return nil, fmt.Errorf("duplicate package: %s", chunks[0])
+		if _, ok := pkgs[source]; ok {
+			return nil, fmt.Errorf("duplicate package: %s", source)
 		}
-		pkgs[chunks[0]] = chunks[1]
+		pkgs[source] = version
 	}
 
 	return pkgs, nil
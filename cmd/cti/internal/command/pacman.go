@@ -1,13 +1,52 @@
 package command
 
 import (
+	"fmt"
+
 	"github.com/acronis/go-cti/metadata/pacman"
 	"github.com/acronis/go-cti/metadata/storage/gitstorage"
 	"github.com/spf13/cobra"
 )
 
-func InitializePackageManager(_ *cobra.Command) (pacman.PackageManager, error) { // get option from command
-	return pacman.New(
-		pacman.WithStorage(gitstorage.New()),
-	)
+const (
+	depthFlag        = "depth"
+	noTransitiveFlag = "no-transitive"
+)
+
+// AddDepthFlags adds flags that limit levels of dependencies installed by the package manager of the command.
+func AddDepthFlags(cmd *cobra.Command) {
+	cmd.Flags().Int(depthFlag, 0, "Install dependencies up to the depth, 1 installs direct dependencies only. 0 installs all.")
+	cmd.Flags().Bool(noTransitiveFlag, false, "Install direct dependencies only, same as --depth 1.")
+	cmd.MarkFlagsMutuallyExclusive(depthFlag, noTransitiveFlag)
+}
+
+func InitializePackageManager(cmd *cobra.Command) (pacman.PackageManager, error) {
+	opts := []pacman.Option{pacman.WithStorage(gitstorage.New())}
+	if cmd.Flags().Lookup(depthFlag) != nil {
+		depth, err := getDepth(cmd)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, pacman.WithDepth(depth))
+	}
+	return pacman.New(opts...)
+}
+
+// getDepth returns the depth of dependencies set by flags of AddDepthFlags.
+func getDepth(cmd *cobra.Command) (int, error) {
+	depth, err := cmd.Flags().GetInt(depthFlag)
+	if err != nil {
+		return 0, fmt.Errorf("get depth flag: %w", err)
+	}
+	if depth < 0 {
+		return 0, fmt.Errorf("invalid depth %d: should not be negative", depth)
+	}
+	noTransitive, err := cmd.Flags().GetBool(noTransitiveFlag)
+	if err != nil {
+		return 0, fmt.Errorf("get no-transitive flag: %w", err)
+	}
+	if noTransitive {
+		return 1, nil
+	}
+	return depth, nil
 }
@@ -0,0 +1,45 @@
+package command
+
+import (
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GetDepth(t *testing.T) {
+	testCases := []struct {
+		name  string
+		args  []string
+		depth int
+		err   string
+	}{
+		{name: "unlimited"},
+		{name: "depth", args: []string{"--depth", "1"}, depth: 1},
+		{name: "no transitive", args: []string{"--no-transitive"}, depth: 1},
+		{name: "negative", args: []string{"--depth", "-1"}, err: "invalid depth -1: should not be negative"},
+		{
+			name: "both", args: []string{"--depth", "2", "--no-transitive"},
+			err: "if any flags in the group [depth no-transitive] are set none of the others can be",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := &cobra.Command{Use: "get"}
+			AddDepthFlags(cmd)
+			require.NoError(t, cmd.ParseFlags(tc.args))
+			err := cmd.ValidateFlagGroups()
+			var depth int
+			if err == nil {
+				depth, err = getDepth(cmd)
+			}
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.depth, depth)
+		})
+	}
+}
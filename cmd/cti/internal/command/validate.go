@@ -0,0 +1,102 @@
+package command
+
+import (
+	"fmt"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/pacman"
+	"github.com/acronis/go-cti/metadata/schemaref"
+	"github.com/acronis/go-cti/metadata/storage"
+	"github.com/acronis/go-cti/metadata/validator"
+)
+
+// ProfileSeverity parses severities of diagnostic codes overridden by the validation profile.
+func ProfileSeverity(name string, profile cti.ValidateProfile) (map[validator.Code]validator.Severity, error) {
+	severities := make(map[validator.Code]validator.Severity, len(profile.Severity))
+	for code, severity := range profile.Severity {
+		if _, ok := validator.Explain(validator.Code(code)); !ok {
+			return nil, fmt.Errorf("profile %s: unknown diagnostic code: %s", name, code)
+		}
+		switch validator.Severity(severity) {
+		case validator.SeverityError, validator.SeverityWarning:
+			severities[validator.Code(code)] = validator.Severity(severity)
+		default:
+			return nil, fmt.Errorf("profile %s: invalid severity %s of %s, must be one of %s,%s",
+				name, severity, code, validator.SeverityError, validator.SeverityWarning)
+		}
+	}
+	return severities, nil
+}
+
+// ProfileValidatorOptions returns options of the validator of the package in the directory that apply the
+// validation profile with the name. Unset fields of the profile keep defaults of the validator.
+func ProfileValidatorOptions(baseDir string, cfg *cti.Config, name string) ([]validator.Option, error) {
+	profile, err := cfg.Validate.Profile(name)
+	if err != nil {
+		return nil, err
+	}
+	severities, err := ProfileSeverity(name, profile)
+	if err != nil {
+		return nil, err
+	}
+	var codes []validator.Code
+	for _, code := range profile.Suppress {
+		if _, ok := validator.Explain(validator.Code(code)); !ok {
+			return nil, fmt.Errorf("profile %s: unknown diagnostic code: %s", name, code)
+		}
+		codes = append(codes, validator.Code(code))
+	}
+
+	mode := validator.ModePermissive
+	if profile.Strict != nil && *profile.Strict {
+		mode = validator.ModeStrict
+	}
+	limits := validator.DefaultLimits()
+	setInt := func(dst *int, val *int) {
+		if val != nil {
+			*dst = *val
+		}
+	}
+	setInt(&limits.MaxSchemaDepth, profile.Limits.MaxSchemaDepth)
+	setInt(&limits.MaxSchemaSize, profile.Limits.MaxSchemaSize)
+	setInt(&limits.MaxInstanceDepth, profile.Limits.MaxInstanceDepth)
+
+	cacheDir, err := pacman.GetSchemaCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("get schema cache dir: %w", err)
+	}
+	resolver := schemaref.New(baseDir, schemaref.WithCacheDir(cacheDir),
+		schemaref.WithOffline((profile.Offline != nil && *profile.Offline) || storage.IsOffline()),
+		schemaref.WithLimits(limits.MaxSchemaDepth, limits.MaxSchemaSize))
+
+	opts := []validator.Option{
+		validator.WithSuppressed(codes...),
+		validator.WithMode(mode),
+		validator.WithRefResolver(resolver),
+		validator.WithLimits(limits),
+	}
+	for code, severity := range severities {
+		opts = append(opts, validator.WithSeverity(code, severity))
+	}
+	return opts, nil
+}
+
+// ValidateWithProfile validates the package in the directory with the validation profile with the name.
+func ValidateWithProfile(baseDir string, cfg *cti.Config, name string) error {
+	validatorOpts, err := ProfileValidatorOptions(baseDir, cfg, name)
+	if err != nil {
+		return err
+	}
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+	if err := pkg.Validate(validatorOpts...); err != nil {
+		return fmt.Errorf("validate package with profile %s: %w", name, err)
+	}
+	return nil
+}
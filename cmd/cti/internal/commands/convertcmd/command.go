@@ -0,0 +1,21 @@
+package convertcmd
+
+import (
+	"context"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/convertcmd/jsoncmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/convertcmd/ramlcmd"
+	"github.com/spf13/cobra"
+)
+
+func New(ctx context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "convert",
+		Short: "convert entities between RAML and serialized metadata",
+	}
+	cmd.AddCommand(
+		jsoncmd.New(ctx),
+		ramlcmd.New(ctx),
+	)
+	return cmd
+}
@@ -0,0 +1,90 @@
+package jsoncmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/spf13/cobra"
+)
+
+type JSONOptions struct {
+	Output string
+	Strict bool
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := JSONOptions{}
+	cmd := &cobra.Command{
+		Use:   "json",
+		Short: "convert RAML entities of the package into serialized metadata",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "Output file, relative to the package. Printed if not set.")
+	cmd.Flags().BoolVar(&opts.Strict, "strict", false, "Fail if converting the metadata back into RAML is lossy.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts JSONOptions) error {
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+	if err := pkg.Parse(); err != nil {
+		return fmt.Errorf("parse package: %w", err)
+	}
+
+	entities := make(metadata.Entities, 0, len(pkg.LocalRegistry.Index))
+	for _, entity := range pkg.LocalRegistry.Index {
+		entities = append(entities, entity)
+	}
+	sort.Slice(entities, func(a, b int) bool {
+		return entities[a].Cti < entities[b].Cti
+	})
+
+	data, err := json.MarshalIndent(entities, "", "  ")
+	if err != nil {
+		return fmt.Errorf("serialize entities: %w", err)
+	}
+	data = append(data, '\n')
+	if opts.Output == "" {
+		if _, err := os.Stdout.Write(data); err != nil {
+			return fmt.Errorf("write entities: %w", err)
+		}
+	} else {
+		fPath := opts.Output
+		if !filepath.IsAbs(fPath) {
+			fPath = filepath.Join(baseDir, fPath)
+		}
+		if err := os.WriteFile(fPath, data, 0600); err != nil {
+			return fmt.Errorf("write %s: %w", fPath, err)
+		}
+		slog.Info("Generated", slog.String("path", fPath), slog.Int("entities", len(entities)))
+	}
+
+	issues, err := command.CheckConversion(baseDir, pkg.Index.PackageID, entities)
+	if err != nil {
+		return fmt.Errorf("check conversion: %w", err)
+	}
+	return command.ReportConversion(issues, opts.Strict)
+}
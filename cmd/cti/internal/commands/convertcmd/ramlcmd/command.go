@@ -0,0 +1,92 @@
+package ramlcmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/importer"
+	"github.com/spf13/cobra"
+)
+
+type RAMLOptions struct {
+	command.ImportOptions
+	Strict bool
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := RAMLOptions{}
+	cmd := &cobra.Command{
+		Use:   "raml <file>",
+		Short: "convert serialized metadata or a package archive into RAML entities",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, args[0], opts))
+		},
+	}
+
+	command.AddImportFlags(cmd, &opts.ImportOptions)
+	cmd.Flags().BoolVar(&opts.Strict, "strict", false, "Fail if the RAML does not reproduce the metadata exactly.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir, source string, opts RAMLOptions) error {
+	slog.Info("Converting metadata", slog.String("source", source))
+
+	entities, err := readEntities(source)
+	if err != nil {
+		return err
+	}
+	if len(entities) == 0 {
+		return errors.New("no entities found")
+	}
+	packageID := opts.PackageID
+	err = command.RunImport(baseDir, opts.ImportOptions, func(imp *importer.Importer) ([]importer.File, error) {
+		if packageID == "" {
+			packageID = imp.PackageID()
+		}
+		return imp.ImportMetadata(entities)
+	})
+	if err != nil {
+		return err
+	}
+
+	issues, err := command.CheckConversion(baseDir, packageID, entities)
+	if err != nil {
+		return fmt.Errorf("check conversion: %w", err)
+	}
+	return command.ReportConversion(issues, opts.Strict)
+}
+
+// readEntities reads entities serialized as a JSON array, e.g. .cache.json, or from the package archive.
+func readEntities(source string) (metadata.Entities, error) {
+	if filepath.Ext(source) != ".json" {
+		bundle, err := ctipackage.ReadBundle(source)
+		if err != nil {
+			return nil, fmt.Errorf("read bundle: %w", err)
+		}
+		return bundle.Entities, nil
+	}
+	data, err := os.ReadFile(source)
+	if err != nil {
+		return nil, fmt.Errorf("read entities: %w", err)
+	}
+	var entities metadata.Entities
+	if err := json.Unmarshal(data, &entities); err != nil {
+		return nil, fmt.Errorf("decode entities: %w", err)
+	}
+	return entities, nil
+}
@@ -3,17 +3,382 @@ package deploycmd
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/deploycmd/historycmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/deploycmd/promotecmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/deploycmd/rollbackcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/deploycmd/statuscmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata/deploy"
 
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 )
 
-func New(_ context.Context) *cobra.Command {
-	return &cobra.Command{
+type DeployOptions struct {
+	// Env is a comma separated list of names of deployment targets in the configuration file.
+	Env string
+	// Artifact is a packed bundle to deploy, the package is packed if empty.
+	Artifact string
+	// Version labels the deployment, defaults to the short digest of the artifact.
+	Version string
+	// DryRun prints changes of the deployment instead of deploying.
+	DryRun bool
+	// Stage labels the subset of clients to deploy to, all clients if empty and Percent is zero.
+	Stage string
+	// Percent is the share of clients to deploy to.
+	Percent int
+	// RequireCompat refuses to deploy changes that break clients of the deployed version.
+	RequireCompat bool
+	// AllowBreaking justifies breaking changes, so they are deployed.
+	AllowBreaking string
+	// Annotations are added to manifests of OCI artifacts.
+	Annotations map[string]string
+	// AllOrNothing rolls back deployments to environments if the deployment to any of them fails.
+	AllOrNothing bool
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := DeployOptions{}
+	cmd := &cobra.Command{
 		Use:   "deploy",
 		Short: "build and deploy cti package and dependencies to testing stand or production",
-		Args:  cobra.MinimumNArgs(0),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return errors.New("not implemented")
+		Long: "Pack the package and deploy the bundle to the registry of the environment. Environments are defined\n" +
+			"in " + cti.ConfigFileName + " or in the global configuration ~/.cti/config.yaml, e.g.:\n\n" +
+			"  environments:\n" +
+			"    dev:\n" +
+			"      registry: https://registry.dev.example.com\n" +
+			"      auth:\n" +
+			"        token_env: CTI_DEV_TOKEN\n" +
+			"    prod:\n" +
+			"      registry: oci://registry.example.com/cti/x.y:prod\n" +
+			"      auth:\n" +
+			"        oidc:\n" +
+			"          issuer: https://login.example.com\n" +
+			"          client_id: cti-deployer\n" +
+			"          client_secret_env: CTI_CLIENT_SECRET\n" +
+			"      profile: release\n" +
+			"      require_compat: true\n\n" +
+			"If the environment has a validation profile of validate.profiles, the package is validated with it\n" +
+			"before packing.\n\n" +
+			"Deployments are keyed by the digest of contents of the bundle: if the same contents are already\n" +
+			"deployed, the registry is not changed.\n\n" +
+			"With --dry-run, the deployed bundle is downloaded and compared with the new one: added, changed and\n" +
+			"removed entities are printed with a verdict whether the deployment is compatible with clients of the\n" +
+			"deployed version. Removed entities and narrowed schemas of types are breaking. Nothing is uploaded.\n\n" +
+			"With --require-compat or require_compat of the environment, the same comparison gates the deployment:\n" +
+			"breaking changes are refused unless --allow-breaking justifies them. The justification is recorded in\n" +
+			"the history of deployments.\n\n" +
+			"With --stage or --percent, the bundle is deployed to a subset of clients chosen by the registry, while\n" +
+			"the active bundle is served to the rest of them. The staged deployment is advanced or served to all\n" +
+			"clients with cti deploy promote, deploying to a stage again replaces it.\n\n" +
+			"Bundles are pushed to OCI registries as artifacts with the archive, a CycloneDX SBOM and, with\n" +
+			"signing_key_env of the environment, a signature of both. Manifests are annotated with the standard\n" +
+			"org.opencontainers.image annotations, --annotation adds more, e.g. the source and the revision.\n\n" +
+			"--env accepts several environments separated by commas, the package is deployed to them in parallel\n" +
+			"and failures are reported for each of them. With --all-or-nothing, if the deployment to any of them\n" +
+			"fails, environments the package has been deployed to are rolled back.\n\n" +
+			"Hooks of deploy.hooks and of the environment run shell commands in the package directory before and\n" +
+			"after the deployment to each environment, e.g.:\n\n" +
+			"  deploy:\n" +
+			"    hooks:\n" +
+			"      pre: [./scripts/check-freeze.sh]\n" +
+			"      post: ['curl -fsS -X POST \"$CACHE_URL/flush?package=$CTI_PACKAGE\"']\n\n" +
+			"Hooks get CTI_ENV, CTI_REGISTRY, CTI_PACKAGE, CTI_VERSION, CTI_DIGEST, CTI_ARTIFACT and CTI_STAGE,\n" +
+			"post-deploy hooks also get CTI_DEPLOY_STATUS (deployed, unchanged, failed or rolled_back) and\n" +
+			"CTI_DEPLOY_ERROR. A failing pre-deploy hook cancels the deployment to the environment.\n\n" +
+			"Registries keep the history of deployments of each environment, see cti deploy status, cti deploy history\n" +
+			"and cti deploy rollback.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
 		},
 	}
+
+	cmd.Flags().StringVar(&opts.Env, "env", "", "Environments to deploy to separated by commas, may be omitted if a single target is configured.")
+	cmd.Flags().StringVar(&opts.Artifact, "artifact", "", "Deploy the packed bundle instead of packing the package.")
+	cmd.Flags().StringVar(&opts.Version, "version", "", "Version of the deployment, defaults to the short digest of the bundle.")
+	cmd.Flags().BoolVar(&opts.DryRun, "dry-run", false, "Print changes of the deployment without deploying.")
+	cmd.Flags().StringVar(&opts.Stage, "stage", "", "Deploy to the stage with the label only, e.g. canary.")
+	cmd.Flags().IntVar(&opts.Percent, "percent", 0, "Deploy to the percent of clients only.")
+	cmd.Flags().BoolVar(&opts.RequireCompat, "require-compat", false, "Refuse to deploy changes that break clients of the deployed version.")
+	cmd.Flags().StringVar(&opts.AllowBreaking, "allow-breaking", "", "Deploy breaking changes with the justification recorded in the history.")
+	cmd.Flags().BoolVar(&opts.AllOrNothing, "all-or-nothing", false, "Roll back deployments to environments if the deployment to any of them fails.")
+	cmd.Flags().StringToStringVar(&opts.Annotations, "annotation", nil, "Annotation of OCI artifacts, e.g. org.opencontainers.image.source=https://github.com/acme/x-y.")
+
+	cmd.AddCommand(historycmd.New(ctx))
+	cmd.AddCommand(promotecmd.New(ctx))
+	cmd.AddCommand(rollbackcmd.New(ctx))
+	cmd.AddCommand(statuscmd.New(ctx))
+
+	return cmd
+}
+
+// environment is an environment the package is deployed to.
+type environment struct {
+	name   string
+	cfg    cti.Environment
+	target deploy.Target
+	// hooks are hooks of all environments followed by hooks of the environment.
+	hooks cti.DeployHooks
+}
+
+// result is a result of the deployment to an environment.
+type result struct {
+	res *deploy.Result
+	// previous is the active deployment before the deployment, it is known with --all-or-nothing only.
+	previous *deploy.Deployment
+	err      error
+	// rolledBack is set if the deployment was rolled back with --all-or-nothing.
+	rolledBack bool
+}
+
+func execute(ctx context.Context, baseDir string, opts DeployOptions) error {
+	if opts.AllOrNothing && (opts.Stage != "" || opts.Percent != 0) {
+		return errors.New("--all-or-nothing is not supported by staged deployments")
+	}
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	envs, err := resolveEnvironments(ctx, cfg, opts.Env)
+	if err != nil {
+		return err
+	}
+
+	archive := opts.Artifact
+	validated := map[string]bool{}
+	for _, env := range envs {
+		switch {
+		case env.cfg.Profile == "" || validated[env.cfg.Profile]:
+		case archive != "":
+			slog.Warn("Artifact is not validated with the profile of the environment",
+				slog.String("env", env.name), slog.String("profile", env.cfg.Profile))
+		default:
+			slog.Info("Validating package", slog.String("env", env.name), slog.String("profile", env.cfg.Profile))
+			if err := command.ValidateWithProfile(baseDir, cfg, env.cfg.Profile); err != nil {
+				return fmt.Errorf("environment %s: %w", env.name, err)
+			}
+			validated[env.cfg.Profile] = true
+		}
+	}
+	if archive == "" {
+		tmpDir, err := os.MkdirTemp("", "cti-deploy-")
+		if err != nil {
+			return fmt.Errorf("create temporary directory: %w", err)
+		}
+		defer os.RemoveAll(tmpDir)
+		if archive, err = command.PackBundle(baseDir, tmpDir); err != nil {
+			return err
+		}
+	}
+	artifact, err := deploy.OpenArtifact(archive, opts.Version)
+	if err != nil {
+		return fmt.Errorf("open artifact: %w", err)
+	}
+	artifact.Annotations = opts.Annotations
+
+	if opts.DryRun {
+		return dryRun(ctx, envs, artifact)
+	}
+	return deployAll(ctx, baseDir, envs, artifact, opts)
+}
+
+// deployAll deploys the artifact to the environments in parallel and reports results in order of environments.
+// With --all-or-nothing, environments are rolled back if the deployment to any of them fails.
+func deployAll(ctx context.Context, baseDir string, envs []environment, artifact *deploy.Artifact, opts DeployOptions) error {
+	results := make([]result, len(envs))
+	var g errgroup.Group
+	for i, env := range envs {
+		g.Go(func() error {
+			if err := runHooks(ctx, baseDir, "pre-deploy", env.name, env.hooks.Pre, hookEnv(env, artifact, opts)); err != nil {
+				results[i] = result{err: err}
+				return nil
+			}
+			results[i] = deployEnvironment(ctx, env, artifact, opts)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var errs []error
+	failed := map[string]bool{}
+	for i, env := range envs {
+		if err := report(env, artifact, results[i]); err != nil {
+			errs = append(errs, envError(envs, env, err))
+			failed[env.name] = true
+		}
+	}
+	if len(errs) != 0 && opts.AllOrNothing {
+		for i, env := range envs {
+			if err := rollbackEnvironment(ctx, env, artifact, &results[i]); err != nil {
+				errs = append(errs, envError(envs, env, err))
+				failed[env.name] = true
+			}
+		}
+	}
+	// Post-deploy hooks run when results of all environments are final, i.e. after rollbacks.
+	for i, env := range envs {
+		vars := postHookEnv(hookEnv(env, artifact, opts), results[i])
+		if err := runHooks(ctx, baseDir, "post-deploy", env.name, env.hooks.Post, vars); err != nil {
+			errs = append(errs, envError(envs, env, err))
+			failed[env.name] = true
+		}
+	}
+
+	switch {
+	case len(errs) == 0:
+		return nil
+	case len(envs) == 1:
+		return errors.Join(errs...)
+	default:
+		return fmt.Errorf("deployment to %d of %d environments failed:\n%w", len(failed), len(envs), errors.Join(errs...))
+	}
+}
+
+// resolveEnvironments resolves environments of the comma separated list and creates their targets.
+func resolveEnvironments(ctx context.Context, cfg *cti.Config, list string) ([]environment, error) {
+	var envs []environment
+	seen := map[string]bool{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if seen[name] {
+			continue
+		}
+		seen[name] = true
+		env, envCfg, err := cfg.Environment(name)
+		if err != nil {
+			return nil, err
+		}
+		target, err := command.DeployTarget(ctx, env, envCfg)
+		if err != nil {
+			return nil, err
+		}
+		envs = append(envs, environment{name: env, cfg: envCfg, target: target, hooks: cti.DeployHooks{
+			Pre:  append(slices.Clone(cfg.Deploy.Hooks.Pre), envCfg.Hooks.Pre...),
+			Post: append(slices.Clone(cfg.Deploy.Hooks.Post), envCfg.Hooks.Post...),
+		}})
+	}
+	return envs, nil
+}
+
+// envError prefixes the error with the name of the environment if the package is deployed to several of them.
+func envError(envs []environment, env environment, err error) error {
+	if len(envs) == 1 {
+		return err
+	}
+	return fmt.Errorf("environment %s: %w", env.name, err)
+}
+
+// dryRun prints changes of deployments to the environments.
+func dryRun(ctx context.Context, envs []environment, artifact *deploy.Artifact) error {
+	plans := make([]*deploy.Plan, len(envs))
+	errs := make([]error, len(envs))
+	var g errgroup.Group
+	for i, env := range envs {
+		g.Go(func() error {
+			plans[i], errs[i] = deploy.DryRun(ctx, env.target, artifact)
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	for i, env := range envs {
+		if errs[i] != nil {
+			return envError(envs, env, errs[i])
+		}
+		if i > 0 {
+			fmt.Fprintln(os.Stdout)
+		}
+		if err := writePlan(os.Stdout, env.name, artifact, plans[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func deployEnvironment(ctx context.Context, env environment, artifact *deploy.Artifact, opts DeployOptions) result {
+	attrs := []any{slog.String("env", env.name), slog.String("package", artifact.PackageID),
+		slog.String("version", artifact.Version), slog.String("digest", artifact.Digest)}
+	var deployOpts []deploy.DeployOption
+	if opts.Stage != "" || opts.Percent != 0 {
+		stage := &deploy.Stage{Label: opts.Stage, Percent: opts.Percent}
+		attrs = append(attrs, slog.String("stage", stage.String()))
+		deployOpts = append(deployOpts, deploy.WithStage(stage))
+	}
+	switch {
+	case opts.AllowBreaking != "":
+		deployOpts = append(deployOpts, deploy.WithAllowBreaking(opts.AllowBreaking))
+	case opts.RequireCompat || env.cfg.RequireCompat:
+		deployOpts = append(deployOpts, deploy.WithRequireCompat())
+	}
+
+	var r result
+	if opts.AllOrNothing {
+		if r.previous, r.err = env.target.Current(ctx, artifact.PackageID); r.err != nil {
+			r.err = fmt.Errorf("get current deployment: %w", r.err)
+			return r
+		}
+	}
+	slog.Info("Deploying package", attrs...)
+	r.res, r.err = deploy.Deploy(ctx, env.target, artifact, deployOpts...)
+	return r
+}
+
+// report logs the result of the deployment to the environment and records the deployment locally.
+func report(env environment, artifact *deploy.Artifact, r result) error {
+	var breakingErr *deploy.BreakingError
+	if errors.As(r.err, &breakingErr) {
+		if err := writePlan(os.Stdout, env.name, artifact, breakingErr.Plan); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w, deploy it with --allow-breaking and a justification", r.err)
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if r.res.Unchanged {
+		slog.Info("Package is already deployed", slog.String("env", env.name), slog.String("version", r.res.Deployment.Version))
+		return nil
+	}
+	if err := cti.RecordDeployment(env.name, env.cfg, r.res.Deployment); err != nil {
+		slog.Warn("Deployment is not recorded locally", slog.String("error", err.Error()))
+	}
+	if r.res.Deployment.Justification != "" {
+		slog.Warn("Breaking changes have been deployed", slog.String("env", env.name),
+			slog.String("justification", r.res.Deployment.Justification))
+	}
+	slog.Info("Package has been deployed", slog.String("env", env.name), slog.String("version", r.res.Deployment.Version))
+	return nil
+}
+
+// rollbackEnvironment activates the previous deployment of the environment again if the package was deployed to it.
+func rollbackEnvironment(ctx context.Context, env environment, artifact *deploy.Artifact, r *result) error {
+	if r.err != nil || r.res.Unchanged {
+		return nil
+	}
+	if r.previous == nil {
+		return fmt.Errorf("package %s was not deployed before, so it is not rolled back", artifact.PackageID)
+	}
+	res, err := deploy.Rollback(ctx, env.target, artifact.PackageID, r.previous.Version)
+	if err != nil {
+		return fmt.Errorf("roll back: %w", err)
+	}
+	if err := cti.RecordDeployment(env.name, env.cfg, res.Deployment); err != nil {
+		slog.Warn("Deployment is not recorded locally", slog.String("error", err.Error()))
+	}
+	r.rolledBack = true
+	slog.Warn("Package has been rolled back", slog.String("env", env.name), slog.String("version", res.Deployment.Version))
+	return nil
 }
@@ -0,0 +1,149 @@
+package deploycmd
+
+import (
+	"context"
+	"errors"
+	"io"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata/deploy"
+)
+
+// fakeTarget implements the target in memory. Deployments and activations fail with the errors if they are set.
+type fakeTarget struct {
+	mu          sync.Mutex
+	history     []*deploy.Deployment
+	deployErr   error
+	activateErr error
+}
+
+func (f *fakeTarget) Current(_ context.Context, _ string) (*deploy.Deployment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.history) == 0 {
+		return nil, nil
+	}
+	return f.history[0], nil
+}
+
+func (f *fakeTarget) Staged(_ context.Context, _ string) (*deploy.Deployment, error) {
+	return nil, nil
+}
+
+func (f *fakeTarget) History(_ context.Context, _ string) ([]*deploy.Deployment, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]*deploy.Deployment(nil), f.history...), nil
+}
+
+func (f *fakeTarget) Deploy(_ context.Context, _ *deploy.Artifact, d *deploy.Deployment) (*deploy.Deployment, error) {
+	if f.deployErr != nil {
+		return nil, f.deployErr
+	}
+	return f.activate(d), nil
+}
+
+func (f *fakeTarget) Activate(_ context.Context, d *deploy.Deployment) (*deploy.Deployment, error) {
+	if f.activateErr != nil {
+		return nil, f.activateErr
+	}
+	return f.activate(d), nil
+}
+
+func (f *fakeTarget) Download(_ context.Context, _ *deploy.Deployment, _ io.Writer) error {
+	return errors.New("not supported")
+}
+
+func (f *fakeTarget) activate(d *deploy.Deployment) *deploy.Deployment {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.history = append([]*deploy.Deployment{d}, f.history...)
+	return d
+}
+
+// deployed returns the target with the deployment of version 1.0.0 of the package.
+func deployed() *fakeTarget {
+	return &fakeTarget{history: []*deploy.Deployment{{Package: "x.y", Version: "1.0.0", Digest: "sha256:1"}}}
+}
+
+func Test_DeployAll(t *testing.T) {
+	t.Setenv(cti.ConfigEnvVar, filepath.Join(t.TempDir(), "config.yaml"))
+	artifact := &deploy.Artifact{PackageID: "x.y", Version: "2.0.0", Digest: "sha256:2"}
+	unavailable := errors.New("registry is unavailable")
+
+	testCases := []struct {
+		name         string
+		targets      map[string]*fakeTarget
+		allOrNothing bool
+		err          string
+		// versions are versions of active deployments of environments after the deployment.
+		versions map[string]string
+	}{
+		{
+			name:     "deployed",
+			targets:  map[string]*fakeTarget{"dev": deployed(), "prod": deployed()},
+			versions: map[string]string{"dev": "2.0.0", "prod": "2.0.0"},
+		},
+		{
+			name:     "failed without rollback",
+			targets:  map[string]*fakeTarget{"dev": deployed(), "prod": {deployErr: unavailable}},
+			err:      "deployment to 1 of 2 environments failed:\nenvironment prod: deploy x.y 2.0.0: registry is unavailable",
+			versions: map[string]string{"dev": "2.0.0"},
+		},
+		{
+			name:         "others rolled back",
+			targets:      map[string]*fakeTarget{"dev": deployed(), "stage": deployed(), "prod": {deployErr: unavailable}},
+			allOrNothing: true,
+			err:          "deployment to 1 of 3 environments failed:\nenvironment prod: deploy x.y 2.0.0: registry is unavailable",
+			versions:     map[string]string{"dev": "1.0.0", "stage": "1.0.0"},
+		},
+		{
+			name:         "never deployed",
+			targets:      map[string]*fakeTarget{"dev": {}, "prod": {deployErr: unavailable}},
+			allOrNothing: true,
+			err: "deployment to 2 of 2 environments failed:\nenvironment prod: deploy x.y 2.0.0: registry is unavailable\n" +
+				"environment dev: package x.y was not deployed before, so it is not rolled back",
+			versions: map[string]string{"dev": "2.0.0"},
+		},
+		{
+			name: "rollback failed",
+			targets: map[string]*fakeTarget{
+				"dev":  {history: deployed().history, activateErr: errors.New("registry is read-only")},
+				"prod": {deployErr: unavailable},
+			},
+			allOrNothing: true,
+			err: "deployment to 2 of 2 environments failed:\nenvironment prod: deploy x.y 2.0.0: registry is unavailable\n" +
+				"environment dev: roll back: activate x.y 1.0.0: registry is read-only",
+			versions: map[string]string{"dev": "2.0.0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var envs []environment
+			for _, name := range []string{"dev", "stage", "prod"} {
+				if target, ok := tc.targets[name]; ok {
+					envs = append(envs, environment{name: name, target: target})
+				}
+			}
+
+			err := deployAll(context.Background(), t.TempDir(), envs, artifact, DeployOptions{AllOrNothing: tc.allOrNothing})
+			if tc.err == "" {
+				require.NoError(t, err)
+			} else {
+				require.EqualError(t, err, tc.err)
+			}
+			for name, version := range tc.versions {
+				current, err := tc.targets[name].Current(context.Background(), "x.y")
+				require.NoError(t, err)
+				require.Equal(t, version, current.Version, name)
+				require.Equal(t, tc.allOrNothing && version == "1.0.0", current.Rollback, name)
+			}
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package historycmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/deploy"
+
+	"github.com/spf13/cobra"
+)
+
+type HistoryOptions struct {
+	// Env is the name of the deployment target in the configuration file.
+	Env string
+	// Limit is the maximum number of listed deployments, all if zero.
+	Limit int
+	// Local reads the local history of deployments instead of the registry.
+	Local  bool
+	Format OutputFormat
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := HistoryOptions{Limit: 20, Format: OutputFormatTable}
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "list recent deployments of the package to the environment",
+		Long: "List deployments of the package to the environment, the latest first: when and by whom versions were\n" +
+			"deployed, rolled back, staged or deployed with breaking changes. If the registry is not available, or\n" +
+			"with --local, deployments made by the user are read from the local history ~/.cti/" + cti.DeploymentsFileName + ".",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Env, "env", "", "Environment to list, may be omitted if a single target is configured.")
+	cmd.Flags().IntVarP(&opts.Limit, "limit", "n", opts.Limit, "Maximum number of deployments to list, 0 lists all of them.")
+	cmd.Flags().BoolVar(&opts.Local, "local", false, "Read the local history of deployments instead of the registry.")
+	cmd.Flags().VarP(&opts.Format, "format", "f", `Output format. allowed: `+strings.Join(ListOutputFormats, ","))
+
+	return cmd
+}
+
+func execute(ctx context.Context, baseDir string, opts HistoryOptions) error {
+	idx, err := ctipackage.ReadIndex(baseDir)
+	if err != nil {
+		return fmt.Errorf("read index file: %w", err)
+	}
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	env, envCfg, err := cfg.Environment(opts.Env)
+	if err != nil {
+		return err
+	}
+
+	var history []*deploy.Deployment
+	local := opts.Local
+	if !local {
+		target, err := command.DeployTarget(ctx, env, envCfg)
+		if err != nil {
+			return err
+		}
+		if history, err = target.History(ctx, idx.PackageID); err != nil {
+			slog.Warn("Registry is not available, reading the local history", slog.String("env", env),
+				slog.String("error", err.Error()))
+			local = true
+		}
+	}
+	if local {
+		if history, err = cti.ReadDeployments(env, envCfg, idx.PackageID); err != nil {
+			return fmt.Errorf("read local history: %w", err)
+		}
+	}
+	if opts.Limit > 0 && len(history) > opts.Limit {
+		history = history[:opts.Limit]
+	}
+	return writeHistory(os.Stdout, history, opts.Format)
+}
+
+func writeHistory(w io.Writer, history []*deploy.Deployment, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		if history == nil {
+			history = []*deploy.Deployment{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(history)
+	case OutputFormatTable:
+		fallthrough
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "TIME\tVERSION\tDIGEST\tBY\tNOTE")
+		for _, d := range history {
+			deployed, by := "-", "-"
+			if !d.Time.IsZero() {
+				deployed = d.Time.Format(time.RFC3339)
+			}
+			if d.User != "" {
+				by = d.User
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", deployed, d.Version, deploy.ShortDigest(d.Digest), by, note(d))
+		}
+		return tw.Flush()
+	}
+}
+
+// note describes how the deployment was made.
+func note(d *deploy.Deployment) string {
+	var notes []string
+	if d.Rollback {
+		notes = append(notes, "rollback")
+	}
+	if d.Stage != nil {
+		notes = append(notes, "staged to "+d.Stage.String())
+	}
+	if d.Justification != "" {
+		notes = append(notes, "breaking: "+d.Justification)
+	}
+	return strings.Join(notes, ", ")
+}
@@ -0,0 +1,72 @@
+package deploycmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+
+	"github.com/acronis/go-cti/metadata/deploy"
+)
+
+// Statuses of deployments passed to post-deploy hooks in CTI_DEPLOY_STATUS.
+const (
+	statusDeployed   = "deployed"
+	statusUnchanged  = "unchanged"
+	statusFailed     = "failed"
+	statusRolledBack = "rolled_back"
+)
+
+// hookEnv returns environment variables describing the artifact and the environment to hooks.
+func hookEnv(env environment, artifact *deploy.Artifact, opts DeployOptions) []string {
+	vars := append(os.Environ(),
+		"CTI_ENV="+env.name,
+		"CTI_REGISTRY="+env.cfg.Registry,
+		"CTI_PACKAGE="+artifact.PackageID,
+		"CTI_VERSION="+artifact.Version,
+		"CTI_DIGEST="+artifact.Digest,
+		"CTI_ARTIFACT="+artifact.Path,
+	)
+	if opts.Stage != "" || opts.Percent != 0 {
+		vars = append(vars, "CTI_STAGE="+deploy.Stage{Label: opts.Stage, Percent: opts.Percent}.String())
+	}
+	return vars
+}
+
+// postHookEnv adds the status of the deployment to environment variables of hooks.
+func postHookEnv(vars []string, r result) []string {
+	switch {
+	case r.err != nil:
+		return append(vars, "CTI_DEPLOY_STATUS="+statusFailed, "CTI_DEPLOY_ERROR="+r.err.Error())
+	case r.rolledBack:
+		return append(vars, "CTI_DEPLOY_STATUS="+statusRolledBack)
+	case r.res.Unchanged:
+		return append(vars, "CTI_DEPLOY_STATUS="+statusUnchanged)
+	default:
+		return append(vars, "CTI_DEPLOY_STATUS="+statusDeployed)
+	}
+}
+
+// runHooks runs the shell commands in the directory one by one and stops at the first failing one.
+// Output of commands is passed through.
+func runHooks(ctx context.Context, dir, kind, env string, commands []string, vars []string) error {
+	for _, command := range commands {
+		slog.Info("Running hook", slog.String("env", env), slog.String("hook", kind), slog.String("command", command))
+		var cmd *exec.Cmd
+		if runtime.GOOS == "windows" {
+			cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+		} else {
+			cmd = exec.CommandContext(ctx, "sh", "-c", command)
+		}
+		cmd.Dir = dir
+		cmd.Env = vars
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s hook %q: %w", kind, command, err)
+		}
+	}
+	return nil
+}
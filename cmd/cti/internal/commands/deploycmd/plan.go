@@ -0,0 +1,54 @@
+package deploycmd
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/acronis/go-cti/metadata/deploy"
+)
+
+var actionSigns = map[deploy.Action]string{
+	deploy.ActionAdded:   "+",
+	deploy.ActionChanged: "~",
+	deploy.ActionRemoved: "-",
+}
+
+// writePlan writes changes of the dry run with reasons of breaking changes and the verdict.
+func writePlan(w io.Writer, env string, a *deploy.Artifact, plan *deploy.Plan) error {
+	var sb strings.Builder
+	if plan.Current == nil {
+		fmt.Fprintf(&sb, "Environment %s has no deployment of %s\n", env, a.PackageID)
+	} else {
+		fmt.Fprintf(&sb, "Environment %s has %s %s (%s)", env, plan.Current.Package, plan.Current.Version, plan.Current.Digest)
+		if !plan.Current.Time.IsZero() {
+			fmt.Fprintf(&sb, " deployed at %s", plan.Current.Time.Format(time.RFC3339))
+		}
+		sb.WriteString("\n")
+	}
+	fmt.Fprintf(&sb, "Deploying %s %s (%s)\n\n", a.PackageID, a.Version, a.Digest)
+
+	if plan.Verdict == deploy.VerdictUnchanged {
+		sb.WriteString("No changes, the package is already deployed\n")
+	} else {
+		counts := map[deploy.Action]int{}
+		for _, c := range plan.Changes {
+			counts[c.Action]++
+			fmt.Fprintf(&sb, "%s %s (%s)\n", actionSigns[c.Action], c.Cti, c.Kind)
+			for _, reason := range c.Breaking {
+				fmt.Fprintf(&sb, "    breaking: %s\n", reason)
+			}
+		}
+		if len(plan.Changes) == 0 {
+			sb.WriteString("No changes of entities\n")
+		}
+		fmt.Fprintf(&sb, "\nVerdict: %s (%d added, %d changed, %d removed)\n", plan.Verdict,
+			counts[deploy.ActionAdded], counts[deploy.ActionChanged], counts[deploy.ActionRemoved])
+	}
+
+	if _, err := io.WriteString(w, sb.String()); err != nil {
+		return fmt.Errorf("write plan: %w", err)
+	}
+	return nil
+}
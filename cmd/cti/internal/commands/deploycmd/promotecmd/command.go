@@ -0,0 +1,93 @@
+package promotecmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/deploy"
+
+	"github.com/spf13/cobra"
+)
+
+type PromoteOptions struct {
+	// Env is the name of the deployment target in the configuration file.
+	Env string
+	// Stage labels the subset of clients to advance to, all clients if empty and Percent is zero.
+	Stage string
+	// Percent is the share of clients to advance to.
+	Percent int
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := PromoteOptions{}
+	cmd := &cobra.Command{
+		Use:   "promote",
+		Short: "advance the staged deployment of the package to more clients",
+		Long: "Advance the staged deployment of the package, see cti deploy --stage. With --stage or --percent, the\n" +
+			"staged bundle is served to the new stage, percents only grow. Without them, the staged bundle is\n" +
+			"activated for all clients and the staged deployment ends.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Env, "env", "", "Environment to promote in, may be omitted if a single target is configured.")
+	cmd.Flags().StringVar(&opts.Stage, "stage", "", "Advance to the stage with the label, e.g. beta.")
+	cmd.Flags().IntVar(&opts.Percent, "percent", 0, "Advance to the percent of clients.")
+
+	return cmd
+}
+
+func execute(ctx context.Context, baseDir string, opts PromoteOptions) error {
+	idx, err := ctipackage.ReadIndex(baseDir)
+	if err != nil {
+		return fmt.Errorf("read index file: %w", err)
+	}
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	env, envCfg, err := cfg.Environment(opts.Env)
+	if err != nil {
+		return err
+	}
+	target, err := command.DeployTarget(ctx, env, envCfg)
+	if err != nil {
+		return err
+	}
+
+	var stage *deploy.Stage
+	if opts.Stage != "" || opts.Percent != 0 {
+		stage = &deploy.Stage{Label: opts.Stage, Percent: opts.Percent}
+	}
+	res, err := deploy.Promote(ctx, target, idx.PackageID, stage)
+	if err != nil {
+		return err
+	}
+	if res.Unchanged {
+		slog.Info("Version is already staged", slog.String("env", env), slog.String("version", res.Deployment.Version),
+			slog.String("stage", stage.String()))
+		return nil
+	}
+	if err := cti.RecordDeployment(env, envCfg, res.Deployment); err != nil {
+		slog.Warn("Deployment is not recorded locally", slog.String("error", err.Error()))
+	}
+	if stage != nil {
+		slog.Info("Package has been promoted", slog.String("env", env), slog.String("version", res.Deployment.Version),
+			slog.String("stage", stage.String()))
+		return nil
+	}
+	slog.Info("Package has been deployed to all clients", slog.String("env", env),
+		slog.String("version", res.Deployment.Version))
+	return nil
+}
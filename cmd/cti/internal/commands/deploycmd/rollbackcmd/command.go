@@ -0,0 +1,80 @@
+package rollbackcmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/deploy"
+
+	"github.com/spf13/cobra"
+)
+
+type RollbackOptions struct {
+	// Env is the name of the deployment target in the configuration file.
+	Env string
+	// To is the version to roll back to, the previous artifact if empty.
+	To string
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := RollbackOptions{}
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "activate a previously deployed version of the package again",
+		Long: "Activate the artifact of a previous deployment of the package again. Registries keep the history of\n" +
+			"deployments of each environment, rollbacks are recorded in the history too. Without --to, the artifact\n" +
+			"deployed before the active one is activated, so repeated rollbacks step back through the history.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Env, "env", "", "Environment to roll back, may be omitted if a single target is configured.")
+	cmd.Flags().StringVar(&opts.To, "to", "", "Version to roll back to, defaults to the previous version.")
+
+	return cmd
+}
+
+func execute(ctx context.Context, baseDir string, opts RollbackOptions) error {
+	idx, err := ctipackage.ReadIndex(baseDir)
+	if err != nil {
+		return fmt.Errorf("read index file: %w", err)
+	}
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	env, envCfg, err := cfg.Environment(opts.Env)
+	if err != nil {
+		return err
+	}
+	target, err := command.DeployTarget(ctx, env, envCfg)
+	if err != nil {
+		return err
+	}
+
+	res, err := deploy.Rollback(ctx, target, idx.PackageID, opts.To)
+	if err != nil {
+		return err
+	}
+	if res.Unchanged {
+		slog.Info("Version is already active", slog.String("env", env), slog.String("version", res.Deployment.Version))
+		return nil
+	}
+	if err := cti.RecordDeployment(env, envCfg, res.Deployment); err != nil {
+		slog.Warn("Deployment is not recorded locally", slog.String("error", err.Error()))
+	}
+	slog.Info("Package has been rolled back", slog.String("env", env), slog.String("version", res.Deployment.Version),
+		slog.String("digest", res.Deployment.Digest))
+	return nil
+}
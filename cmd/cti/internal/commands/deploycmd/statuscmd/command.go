@@ -0,0 +1,162 @@
+package statuscmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/deploy"
+
+	"github.com/spf13/cobra"
+)
+
+type StatusOptions struct {
+	// Env is the name of the environment to query, all environments if empty.
+	Env string
+	// Local reads the local history of deployments instead of registries.
+	Local  bool
+	Format OutputFormat
+}
+
+type entry struct {
+	Env    string             `json:"env"`
+	Active *deploy.Deployment `json:"active,omitempty"`
+	Staged *deploy.Deployment `json:"staged,omitempty"`
+	// Source is where deployments were read from: registry or local.
+	Source string `json:"source"`
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := StatusOptions{Format: OutputFormatTable}
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "show versions of the package deployed to environments",
+		Long: "Show the active and the staged deployments of the package in each environment: versions, digests,\n" +
+			"times of activation and users that deployed them. If a registry is not available, or with --local,\n" +
+			"deployments made by the user are read from the local history ~/.cti/" + cti.DeploymentsFileName + ".",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Env, "env", "", "Environment to query, defaults to all environments.")
+	cmd.Flags().BoolVar(&opts.Local, "local", false, "Read the local history of deployments instead of registries.")
+	cmd.Flags().VarP(&opts.Format, "format", "f", `Output format. allowed: `+strings.Join(ListOutputFormats, ","))
+
+	return cmd
+}
+
+func execute(ctx context.Context, baseDir string, opts StatusOptions) error {
+	idx, err := ctipackage.ReadIndex(baseDir)
+	if err != nil {
+		return fmt.Errorf("read index file: %w", err)
+	}
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	envs := []string{opts.Env}
+	if opts.Env == "" && len(cfg.Environments) > 0 {
+		envs = envs[:0]
+		for name := range cfg.Environments {
+			envs = append(envs, name)
+		}
+		sort.Strings(envs)
+	}
+
+	entries := make([]entry, 0, len(envs))
+	for _, name := range envs {
+		env, envCfg, err := cfg.Environment(name)
+		if err != nil {
+			return err
+		}
+		e, err := status(ctx, env, envCfg, idx.PackageID, opts.Local)
+		if err != nil {
+			return err
+		}
+		entries = append(entries, e)
+	}
+	return writeEntries(os.Stdout, entries, opts.Format)
+}
+
+// status returns the status of the package in the environment from the registry, or from the local history
+// if the registry is not available.
+func status(ctx context.Context, env string, envCfg cti.Environment, packageID string, local bool) (entry, error) {
+	if !local {
+		target, err := command.DeployTarget(ctx, env, envCfg)
+		if err != nil {
+			return entry{}, err
+		}
+		s, err := deploy.GetStatus(ctx, target, packageID)
+		if err == nil {
+			return entry{Env: env, Active: s.Active, Staged: s.Staged, Source: "registry"}, nil
+		}
+		slog.Warn("Registry is not available, reading the local history", slog.String("env", env),
+			slog.String("error", err.Error()))
+	}
+
+	history, err := cti.ReadDeployments(env, envCfg, packageID)
+	if err != nil {
+		return entry{}, fmt.Errorf("read local history: %w", err)
+	}
+	e := entry{Env: env, Source: "local"}
+	// Staged deployments are listed only if they were not followed by a full one.
+	for _, d := range history {
+		if d.Stage == nil {
+			e.Active = d
+			break
+		}
+		if e.Staged == nil {
+			e.Staged = d
+		}
+	}
+	return e, nil
+}
+
+func writeEntries(w io.Writer, entries []entry, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case OutputFormatTable:
+		fallthrough
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ENV\tVERSION\tDIGEST\tDEPLOYED\tBY\tSTAGED\tSOURCE")
+		for _, e := range entries {
+			version, digest, deployed, by := "-", "-", "-", "-"
+			if d := e.Active; d != nil {
+				version, digest = d.Version, deploy.ShortDigest(d.Digest)
+				if d.User != "" {
+					by = d.User
+				}
+				if !d.Time.IsZero() {
+					deployed = d.Time.Format(time.RFC3339)
+				}
+			}
+			staged := "-"
+			if d := e.Staged; d != nil {
+				staged = fmt.Sprintf("%s (%s)", d.Version, d.Stage)
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n", e.Env, version, digest, deployed, by, staged, e.Source)
+		}
+		return tw.Flush()
+	}
+}
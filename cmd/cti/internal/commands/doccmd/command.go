@@ -0,0 +1,174 @@
+package doccmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/docgen"
+	"github.com/acronis/go-cti/metadata/registry"
+
+	"github.com/spf13/cobra"
+)
+
+type DocOptions struct {
+	Output              string
+	Title               string
+	IncludeDependencies bool
+	Format              OutputFormat
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := DocOptions{Format: OutputFormatHTML}
+	cmd := &cobra.Command{
+		Use:   "doc [bundle|directory]...",
+		Short: "generate documentation for cti packages and bundles",
+		Args:  cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, args, opts))
+		},
+	}
+
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "docs", "Output directory.")
+	cmd.Flags().StringVar(&opts.Title, "title", "", "Title of the documentation. Package ID is used by default for a single source.")
+	cmd.Flags().BoolVarP(&opts.IncludeDependencies, "all", "a", false, "Document entities of dependencies.")
+	cmd.Flags().VarP(&opts.Format, "format", "f", `Output format. allowed: `+strings.Join(ListOutputFormats, ","))
+
+	return cmd
+}
+
+// source holds entities of the bundle or the package to document and known entities used to resolve links.
+type source struct {
+	packageID  string
+	documented metadata.Entities
+	known      metadata.Entities
+}
+
+func execute(_ context.Context, baseDir string, sources []string, opts DocOptions) error {
+	if len(sources) == 0 {
+		sources = []string{baseDir}
+	}
+
+	var loaded []source
+	for _, src := range sources {
+		if !filepath.IsAbs(src) {
+			src = filepath.Join(baseDir, src)
+		}
+		slog.Info("Loading entities", slog.String("path", src))
+		s, err := load(src, opts.IncludeDependencies)
+		if err != nil {
+			return fmt.Errorf("load %s: %w", src, err)
+		}
+		loaded = append(loaded, s)
+	}
+
+	r, entities, err := combine(loaded)
+	if err != nil {
+		return err
+	}
+
+	title := opts.Title
+	if title == "" && len(loaded) == 1 {
+		title = loaded[0].packageID
+	}
+	genOpts := []docgen.Option{docgen.WithFormat(docgen.Format(opts.Format))}
+	if title != "" {
+		genOpts = append(genOpts, docgen.WithTitle(title))
+	}
+	gen, err := docgen.New(r, genOpts...)
+	if err != nil {
+		return fmt.Errorf("new generator: %w", err)
+	}
+
+	output := opts.Output
+	if !filepath.IsAbs(output) {
+		output = filepath.Join(baseDir, output)
+	}
+	if err := gen.Generate(output, entities); err != nil {
+		return fmt.Errorf("generate documentation: %w", err)
+	}
+
+	slog.Info("Documentation has been generated", slog.String("path", output))
+	return nil
+}
+
+// combine indexes entities of all sources. Entities of dependencies may be shared by sources,
+// so the first occurrence of the entity is used.
+func combine(sources []source) (*registry.Registry, metadata.Entities, error) {
+	known := make(metadata.EntitiesMap)
+	documented := make(metadata.EntitiesMap)
+	for _, s := range sources {
+		for _, entity := range s.documented {
+			if _, ok := documented[entity.Cti]; ok {
+				slog.Warn("Entity is documented by multiple sources, the first one is used", slog.String("cti", entity.Cti))
+				continue
+			}
+			documented[entity.Cti] = entity
+			known[entity.Cti] = entity
+		}
+	}
+	for _, s := range sources {
+		for _, entity := range s.known {
+			if _, ok := known[entity.Cti]; !ok {
+				known[entity.Cti] = entity
+			}
+		}
+	}
+
+	r, err := registry.New(known)
+	if err != nil {
+		return nil, nil, fmt.Errorf("index entities: %w", err)
+	}
+	entities := make(metadata.Entities, 0, len(documented))
+	for _, entity := range documented {
+		entities = append(entities, entity)
+	}
+	return r, entities, nil
+}
+
+// load reads the bundle file or parses the package in the directory.
+func load(src string, includeDependencies bool) (source, error) {
+	info, err := os.Stat(src)
+	if err != nil {
+		return source{}, err
+	}
+	if !info.IsDir() {
+		b, err := ctipackage.ReadBundle(src)
+		if err != nil {
+			return source{}, fmt.Errorf("read bundle: %w", err)
+		}
+		return source{packageID: b.Index.PackageID, documented: b.Entities}, nil
+	}
+
+	pkg, err := ctipackage.New(src)
+	if err != nil {
+		return source{}, fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return source{}, fmt.Errorf("read package: %w", err)
+	}
+	if err := pkg.Parse(); err != nil {
+		return source{}, fmt.Errorf("parse package: %w", err)
+	}
+
+	s := source{packageID: pkg.Index.PackageID, known: pkg.Registry.Entities()}
+	if includeDependencies {
+		s.documented = s.known
+		return s, nil
+	}
+	for _, entity := range pkg.LocalRegistry.Index {
+		s.documented = append(s.documented, entity)
+	}
+	return s, nil
+}
@@ -2,18 +2,38 @@ package envcmd
 
 import (
 	"context"
-	"errors"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/envcmd/doctorcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/envcmd/execcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/envcmd/getcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/envcmd/listcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/envcmd/logincmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/envcmd/logoutcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/envcmd/setcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/envcmd/unsetcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
 
 	"github.com/spf13/cobra"
 )
 
-func New(_ context.Context) *cobra.Command {
-	return &cobra.Command{
-		Use:   "env",
-		Short: "print cti environment information",
-		Args:  cobra.MinimumNArgs(0),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return errors.New("not implemented")
-		},
-	}
+func New(ctx context.Context) *cobra.Command {
+	// cti env lists the configuration like cti env list.
+	cmd := listcmd.New(ctx)
+	cmd.Use = "env"
+	cmd.Short = "manage the layered configuration of cti"
+	cmd.Long = "Manages the configuration of cti. Keys of " + cti.ConfigFileName + " of the package override keys of the\n" +
+		"global configuration ~/" + cti.GlobalConfigDirName + "/" + cti.GlobalConfigFileName + " (or " + cti.ConfigEnvVar + "), and " + cti.EnvVarPrefix +
+		"<KEY> environment variables override both,\ne.g. CTI_FMT_INDENT overrides fmt.indent. " +
+		"Without a subcommand the effective configuration is listed."
+
+	cmd.AddCommand(doctorcmd.New(ctx))
+	cmd.AddCommand(execcmd.New(ctx))
+	cmd.AddCommand(getcmd.New(ctx))
+	cmd.AddCommand(listcmd.New(ctx))
+	cmd.AddCommand(logincmd.New(ctx))
+	cmd.AddCommand(logoutcmd.New(ctx))
+	cmd.AddCommand(setcmd.New(ctx))
+	cmd.AddCommand(unsetcmd.New(ctx))
+
+	return cmd
 }
@@ -0,0 +1,339 @@
+package doctorcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/filesys"
+	"github.com/acronis/go-cti/metadata/pacman"
+)
+
+// maxCacheSize is the size of the cache above which cleaning it is suggested.
+const maxCacheSize = 2 << 30
+
+// probeTimeout limits checks of each registry and proxy.
+const probeTimeout = 10 * time.Second
+
+type status string
+
+const (
+	statusOK   status = "ok"
+	statusWarn status = "warn"
+	statusFail status = "fail"
+)
+
+// check is a result of a diagnostic with the suggested fix of a problem.
+type check struct {
+	Check   string `json:"check"`
+	Status  status `json:"status"`
+	Message string `json:"message"`
+	Fix     string `json:"fix,omitempty"`
+}
+
+func ok(name, format string, args ...any) check {
+	return check{Check: name, Status: statusOK, Message: fmt.Sprintf(format, args...)}
+}
+
+func warn(name, fix, format string, args ...any) check {
+	return check{Check: name, Status: statusWarn, Message: fmt.Sprintf(format, args...), Fix: fix}
+}
+
+func fail(name, fix, format string, args ...any) check {
+	return check{Check: name, Status: statusFail, Message: fmt.Sprintf(format, args...), Fix: fix}
+}
+
+// checkConfig checks that configuration files are valid and definitions refer to each other consistently.
+func checkConfig(layers *cti.Layers, err error) []check {
+	const name = "config"
+	if err != nil {
+		return []check{fail(name, "fix the file, or remove the key with cti env unset", "%v", err)}
+	}
+	cfg, err := layers.Config()
+	if err != nil {
+		return []check{fail(name, "fix the environment variable or unset it", "%v", err)}
+	}
+
+	var files []string
+	for _, path := range []string{layers.Global.Path, layers.Project.Path} {
+		if _, err := os.Stat(path); err == nil {
+			files = append(files, path)
+		}
+	}
+	var checks []check
+	switch len(files) {
+	case 0:
+		checks = append(checks, ok(name, "no configuration files, defaults are used"))
+	case 1:
+		checks = append(checks, ok(name, "%s is valid", files[0]))
+	default:
+		checks = append(checks, ok(name, "%s are valid", strings.Join(files, " and ")))
+	}
+	if cfg.DefaultEnv != "" {
+		if _, ok := cfg.Environments[cfg.DefaultEnv]; !ok {
+			checks = append(checks, fail(name, "define the environment, or run cti env set default_env <name>",
+				"default environment %s is not defined", cfg.DefaultEnv))
+		}
+	}
+	for _, env := range envNames(cfg) {
+		envCfg := cfg.Environments[env]
+		if envCfg.Registry == "" {
+			checks = append(checks, fail(name, fmt.Sprintf("run cti env set environments.%s.registry <url>", env),
+				"environment %s has no registry", env))
+		}
+		if envCfg.Profile != "" {
+			if _, err := cfg.Validate.Profile(envCfg.Profile); err != nil {
+				checks = append(checks, fail(name, fmt.Sprintf("define validate.profiles.%s, or run cti env unset "+
+					"environments.%s.profile", envCfg.Profile, env), "environment %s: %v", env, err))
+			}
+		}
+		if envCfg.SigningKeyEnv != "" && os.Getenv(envCfg.SigningKeyEnv) == "" {
+			checks = append(checks, warn(name, fmt.Sprintf("set %s to deploy to the environment", envCfg.SigningKeyEnv),
+				"environment %s: signing key %s is not set", env, envCfg.SigningKeyEnv))
+		}
+	}
+	return checks
+}
+
+// checkCache checks that the cache directory is writable and suggests cleaning it if it is too large.
+func checkCache() []check {
+	const name = "cache"
+	const setDir = "cti env set --global cache_dir <dir>"
+	dir, err := pacman.CacheDirPath()
+	if err != nil {
+		return []check{fail(name, "run "+setDir, "get cache directory: %v", err)}
+	}
+
+	info, err := os.Stat(dir)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return []check{ok(name, "%s does not exist yet, it is created on the first download", dir)}
+	case err != nil:
+		return []check{fail(name, "fix permissions of the directory, or run "+setDir, "%v", err)}
+	case !info.IsDir():
+		return []check{fail(name, "remove the file, or run "+setDir, "%s is not a directory", dir)}
+	}
+	f, err := os.CreateTemp(dir, ".doctor-")
+	if err != nil {
+		return []check{fail(name, fmt.Sprintf("run chmod u+rwx %s, or run %s", dir, setDir),
+			"%s is not writable: %v", dir, err)}
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	if size > maxCacheSize {
+		return []check{warn(name, fmt.Sprintf("remove %s, downloads are cached again when needed",
+			filepath.Join(dir, ".cache")), "%s takes %s", dir, formatSize(size))}
+	}
+	return []check{ok(name, "%s is writable, takes %s", dir, formatSize(size))}
+}
+
+// checkGit checks that git is available to download dependencies.
+func checkGit(ctx context.Context) []check {
+	const name = "git"
+	path, err := exec.LookPath("git")
+	if err != nil {
+		return []check{fail(name, "install git, it downloads dependencies of packages", "git is not found in PATH")}
+	}
+	out, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		return []check{fail(name, "reinstall git", "%s --version: %v", path, err)}
+	}
+	return []check{ok(name, "%s", strings.TrimSpace(string(out)))}
+}
+
+// checkRegistries checks that proxies of registries of environments are reachable, and that registries are reachable
+// and accept secrets of environments. Registries are only queried in a package, about the package.
+func checkRegistries(ctx context.Context, cfg *cti.Config, packageID string) []check {
+	const name = "registry"
+	var checks []check
+	for _, env := range envNames(cfg) {
+		envCfg := cfg.Environments[env]
+		if envCfg.Registry == "" {
+			continue
+		}
+		c, reachable := checkProxy(ctx, env, envCfg.Registry)
+		if c.Message != "" {
+			checks = append(checks, c)
+		}
+		if !reachable {
+			continue
+		}
+
+		target, err := command.DeployTarget(ctx, env, envCfg)
+		if err != nil {
+			checks = append(checks, fail(name, secretFix(env, envCfg), "%v", err))
+			continue
+		}
+		if packageID == "" {
+			checks = append(checks, warn(name, "run cti env doctor in a package to query the registry",
+				"environment %s: %s is not queried outside of a package", env, envCfg.Registry))
+			continue
+		}
+		probeCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+		_, err = target.Current(probeCtx, packageID)
+		cancel()
+		if err != nil {
+			checks = append(checks, fail(name, "check the registry URL, the network and the proxy, and "+secretFix(env, envCfg),
+				"environment %s: %s: %v", env, envCfg.Registry, err))
+			continue
+		}
+		checks = append(checks, ok(name, "environment %s: %s is reachable", env, envCfg.Registry))
+	}
+	return checks
+}
+
+// checkProxy checks that the proxy of the registry configured with HTTPS_PROXY, HTTP_PROXY and NO_PROXY accepts
+// connections. It returns an empty check if the registry is not accessed via a proxy.
+func checkProxy(ctx context.Context, env, registry string) (check, bool) {
+	const name = "proxy"
+	u, err := url.Parse(registry)
+	if err != nil {
+		return fail(name, "fix the registry URL", "environment %s: %v", env, err), false
+	}
+	switch u.Scheme {
+	case "oci":
+		u.Scheme = "https"
+	case "oci+http":
+		u.Scheme = "http"
+	}
+	proxy, err := http.ProxyFromEnvironment(&http.Request{URL: u})
+	if err != nil {
+		return fail(name, "fix HTTPS_PROXY or HTTP_PROXY", "environment %s: %v", env, err), false
+	}
+	if proxy == nil {
+		return check{}, true
+	}
+	host := proxy.Host
+	if proxy.Port() == "" {
+		port := "80"
+		if proxy.Scheme == "https" {
+			port = "443"
+		}
+		host = net.JoinHostPort(proxy.Hostname(), port)
+	}
+	dialCtx, cancel := context.WithTimeout(ctx, probeTimeout)
+	defer cancel()
+	conn, err := (&net.Dialer{}).DialContext(dialCtx, "tcp", host)
+	if err != nil {
+		return fail(name, "check HTTPS_PROXY and HTTP_PROXY, or add the registry host to NO_PROXY",
+			"environment %s: proxy %s is not reachable: %v", env, proxy.Redacted(), err), false
+	}
+	conn.Close()
+	return ok(name, "environment %s: %s is accessed via %s", env, u.Host, proxy.Redacted()), true
+}
+
+// checkWorkspace checks that dependencies of the package are installed as locked and the RAMLx specification
+// is in place. It returns the ID of the package, empty outside of a package.
+func checkWorkspace(baseDir string) ([]check, string) {
+	const name = "workspace"
+	idx, err := ctipackage.ReadIndex(baseDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return []check{warn(name, "run cti init to create a package", "%s is not a package", baseDir)}, ""
+		}
+		return []check{fail(name, "fix "+ctipackage.IndexFileName+", or run cti init to recreate it", "%v", err)}, ""
+	}
+
+	var checks []check
+	if _, err := os.Stat(filepath.Join(baseDir, ctipackage.RamlxDirName)); err != nil {
+		checks = append(checks, fail(name, "run cti sync", "RAMLx specification is not found in %s", ctipackage.RamlxDirName))
+	}
+	if len(idx.Depends) == 0 {
+		return append(checks, ok(name, "package %s has no dependencies", idx.PackageID)), idx.PackageID
+	}
+	lock, err := ctipackage.ReadIndexLock(baseDir)
+	if err != nil {
+		return append(checks, fail(name, "run cti pkg get", "%v", err)), idx.PackageID
+	}
+
+	sources := make([]string, 0, len(idx.Depends))
+	for source := range idx.Depends {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	installed := 0
+	for _, source := range sources {
+		info, ok := lock.SourceInfo[source]
+		if !ok {
+			checks = append(checks, fail(name, "run cti pkg get", "dependency %s is not locked in %s", source, ctipackage.IndexLockFileName))
+			continue
+		}
+		if version := idx.Depends[source]; info.Version != version {
+			checks = append(checks, fail(name, "run cti pkg get",
+				"dependency %s is locked at %s, but %s requires %s", source, info.Version, ctipackage.IndexFileName, version))
+			continue
+		}
+		depDir := filepath.Join(baseDir, ctipackage.DependencyDirName, info.PackageID)
+		if _, err := os.Stat(depDir); err != nil {
+			checks = append(checks, fail(name, "run cti pkg get", "dependency %s is not installed in %s", source, depDir))
+			continue
+		}
+		if hash, err := filesys.ComputeDirectoryHash(depDir); err == nil && info.Integrity != "" && hash != info.Integrity {
+			checks = append(checks, warn(name, "revert changes, or run cti pkg get to reinstall the dependency",
+				"dependency %s in %s is modified", source, depDir))
+			continue
+		}
+		installed++
+	}
+	if installed == len(sources) {
+		checks = append(checks, ok(name, "package %s has %d dependencies installed as locked", idx.PackageID, installed))
+	}
+	return checks, idx.PackageID
+}
+
+// secretFix suggests how to provide the secret of the environment.
+func secretFix(env string, envCfg cti.Environment) string {
+	varName := envCfg.Auth.TokenEnv
+	if envCfg.Auth.OIDC != nil {
+		varName = envCfg.Auth.OIDC.ClientSecretEnv
+	}
+	if varName == "" {
+		return "run cti env login --env " + env
+	}
+	return fmt.Sprintf("set %s or run cti env login --env %s", varName, env)
+}
+
+func envNames(cfg *cti.Config) []string {
+	names := make([]string, 0, len(cfg.Environments))
+	for name := range cfg.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func formatSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
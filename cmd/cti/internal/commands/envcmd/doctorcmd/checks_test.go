@@ -0,0 +1,238 @@
+package doctorcmd
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/filesys"
+	"github.com/acronis/go-cti/metadata/pacman"
+)
+
+// proxy accepts connections as the proxy of registries. Proxies are read from environment variables once per
+// process, so they are set before any test runs.
+var proxy net.Listener
+
+func TestMain(m *testing.M) {
+	var err error
+	if proxy, err = net.Listen("tcp", "127.0.0.1:0"); err != nil {
+		panic(err)
+	}
+	os.Setenv("HTTP_PROXY", "http://"+proxy.Addr().String())
+	os.Setenv("HTTPS_PROXY", "http://"+proxy.Addr().String())
+	os.Exit(m.Run())
+}
+
+// statuses returns statuses of checks with messages, so failures show what was reported.
+func statuses(checks []check) map[string]status {
+	res := map[string]status{}
+	for _, c := range checks {
+		res[c.Message] = c.Status
+	}
+	return res
+}
+
+func Test_CheckConfig(t *testing.T) {
+	testCases := []struct {
+		name     string
+		project  string
+		expected map[string]status
+	}{
+		{
+			name:     "no files",
+			expected: map[string]status{"no configuration files, defaults are used": statusOK},
+		},
+		{
+			name:     "valid",
+			project:  "default_env: dev\nenvironments:\n  dev:\n    registry: https://registry.example.com\n",
+			expected: map[string]status{"{dir}/" + cti.ConfigFileName + " is valid": statusOK},
+		},
+		{
+			name:    "inconsistent",
+			project: "default_env: prod\nenvironments:\n  dev:\n    profile: release\n    signing_key_env: DOCTOR_SIGNING_KEY\n",
+			expected: map[string]status{
+				"{dir}/" + cti.ConfigFileName + " is valid":                                      statusOK,
+				"default environment prod is not defined":                                        statusFail,
+				"environment dev has no registry":                                                statusFail,
+				"environment dev: signing key DOCTOR_SIGNING_KEY is not set":                     statusWarn,
+				"environment dev: unknown profile release: no profiles are defined in .cti.yaml": statusFail,
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			t.Setenv(cti.ConfigEnvVar, filepath.Join(t.TempDir(), "config.yaml"))
+			dir := t.TempDir()
+			if tc.project != "" {
+				require.NoError(t, os.WriteFile(filepath.Join(dir, cti.ConfigFileName), []byte(tc.project), 0600))
+			}
+			expected := map[string]status{}
+			for msg, s := range tc.expected {
+				expected[strings.ReplaceAll(msg, "{dir}", dir)] = s
+			}
+			layers, err := cti.LoadLayers(dir)
+			require.NoError(t, err)
+			require.Equal(t, expected, statuses(checkConfig(layers, nil)))
+		})
+	}
+
+	checks := checkConfig(nil, errors.New("decode .cti.yaml: yaml: line 1"))
+	require.Equal(t, map[string]status{"decode .cti.yaml: yaml: line 1": statusFail}, statuses(checks))
+}
+
+func Test_CheckCache(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(pacman.CacheDirEnvVar, dir)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bundle"), make([]byte, 2048), 0600))
+	require.Equal(t, map[string]status{dir + " is writable, takes 2.0 KiB": statusOK}, statuses(checkCache()))
+
+	missing := filepath.Join(dir, "missing")
+	t.Setenv(pacman.CacheDirEnvVar, missing)
+	require.Equal(t, map[string]status{missing + " does not exist yet, it is created on the first download": statusOK},
+		statuses(checkCache()))
+
+	file := filepath.Join(dir, "bundle")
+	t.Setenv(pacman.CacheDirEnvVar, file)
+	require.Equal(t, map[string]status{file + " is not a directory": statusFail}, statuses(checkCache()))
+}
+
+func Test_CheckGit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not available")
+	}
+	checks := checkGit(context.Background())
+	require.Len(t, checks, 1)
+	require.Equal(t, statusOK, checks[0].Status)
+	require.Contains(t, checks[0].Message, "git version")
+
+	t.Setenv("PATH", t.TempDir())
+	require.Equal(t, map[string]status{"git is not found in PATH": statusFail}, statuses(checkGit(context.Background())))
+}
+
+func Test_CheckRegistries(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"error": "invalid token"}`))
+			return
+		}
+		// The package was never deployed.
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	t.Cleanup(srv.Close)
+	cfg := &cti.Config{Environments: map[string]cti.Environment{
+		"dev": {Registry: srv.URL, Auth: cti.ClientAuthConfig{TokenEnv: "DOCTOR_TOKEN"}},
+	}}
+
+	t.Setenv("DOCTOR_TOKEN", "secret")
+	require.Equal(t, map[string]status{"environment dev: " + srv.URL + " is reachable": statusOK},
+		statuses(checkRegistries(context.Background(), cfg, "x.y")))
+	require.Equal(t, map[string]status{"environment dev: " + srv.URL + " is not queried outside of a package": statusWarn},
+		statuses(checkRegistries(context.Background(), cfg, "")))
+
+	t.Setenv("DOCTOR_TOKEN", "expired")
+	checks := checkRegistries(context.Background(), cfg, "x.y")
+	require.Len(t, checks, 1)
+	require.Equal(t, statusFail, checks[0].Status)
+	require.Contains(t, checks[0].Message, "environment dev: "+srv.URL+": ")
+	require.Contains(t, checks[0].Message, "invalid token")
+	require.Equal(t, "check the registry URL, the network and the proxy, and set DOCTOR_TOKEN or run cti env login --env dev",
+		checks[0].Fix)
+}
+
+func Test_CheckProxy(t *testing.T) {
+	// Registries on loopback addresses are never accessed via proxies.
+	c, reachable := checkProxy(context.Background(), "dev", "http://127.0.0.1:8080")
+	require.Equal(t, check{}, c)
+	require.True(t, reachable)
+
+	c, reachable = checkProxy(context.Background(), "prod", "oci://registry.example.com/cti/x.y")
+	require.Equal(t, statusOK, c.Status, c.Message)
+	require.Equal(t, "environment prod: registry.example.com is accessed via http://"+proxy.Addr().String(), c.Message)
+	require.True(t, reachable)
+
+	require.NoError(t, proxy.Close())
+	c, reachable = checkProxy(context.Background(), "prod", "oci://registry.example.com/cti/x.y")
+	require.Equal(t, statusFail, c.Status)
+	require.Contains(t, c.Message, "environment prod: proxy http://"+proxy.Addr().String()+" is not reachable")
+	require.False(t, reachable)
+}
+
+func Test_CheckWorkspace(t *testing.T) {
+	const source = "github.com/acme/lib"
+	// writePackage writes the package depending on the library locked at the version, installed if installed is set.
+	writePackage := func(t *testing.T, lockedVersion string, installed bool) string {
+		dir := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ctipackage.IndexFileName),
+			[]byte(`{"package_id": "x.y", "depends": {"`+source+`": "v1.0.0"}}`), 0600))
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, ctipackage.RamlxDirName), 0755))
+		depDir := filepath.Join(dir, ctipackage.DependencyDirName, "acme.lib")
+		info := ctipackage.Info{PackageID: "acme.lib", Version: lockedVersion, Source: source}
+		if installed {
+			require.NoError(t, os.MkdirAll(depDir, 0755))
+			require.NoError(t, os.WriteFile(filepath.Join(depDir, ctipackage.IndexFileName), []byte(`{"package_id": "acme.lib"}`), 0600))
+			hash, err := filesys.ComputeDirectoryHash(depDir)
+			require.NoError(t, err)
+			info.Integrity = hash
+		}
+		lock := &ctipackage.IndexLock{SourceInfo: map[string]ctipackage.Info{source: info}}
+		require.NoError(t, lock.Save(dir))
+		return dir
+	}
+
+	dir := t.TempDir()
+	checks, id := checkWorkspace(dir)
+	require.Empty(t, id)
+	require.Equal(t, map[string]status{dir + " is not a package": statusWarn}, statuses(checks))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ctipackage.IndexFileName), []byte(`{"package_id": "x.y"}`), 0600))
+	checks, id = checkWorkspace(dir)
+	require.Equal(t, "x.y", id)
+	require.Equal(t, map[string]status{
+		"RAMLx specification is not found in .ramlx": statusFail,
+		"package x.y has no dependencies":            statusOK,
+	}, statuses(checks))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ctipackage.IndexFileName), []byte(`{"package_id": `), 0600))
+	checks, _ = checkWorkspace(dir)
+	require.Len(t, checks, 1)
+	require.Equal(t, statusFail, checks[0].Status)
+
+	dir = writePackage(t, "v1.0.0", true)
+	checks, _ = checkWorkspace(dir)
+	require.Equal(t, map[string]status{"package x.y has 1 dependencies installed as locked": statusOK}, statuses(checks))
+
+	depDir := filepath.Join(dir, ctipackage.DependencyDirName, "acme.lib")
+	require.NoError(t, os.WriteFile(filepath.Join(depDir, "extra.raml"), []byte("#%RAML 1.0\n"), 0600))
+	checks, _ = checkWorkspace(dir)
+	require.Equal(t, map[string]status{"dependency " + source + " in " + depDir + " is modified": statusWarn}, statuses(checks))
+
+	checks, _ = checkWorkspace(writePackage(t, "v0.9.0", true))
+	require.Equal(t, map[string]status{
+		"dependency " + source + " is locked at v0.9.0, but index.json requires v1.0.0": statusFail,
+	}, statuses(checks))
+
+	dir = writePackage(t, "v1.0.0", false)
+	checks, _ = checkWorkspace(dir)
+	require.Equal(t, map[string]status{
+		"dependency " + source + " is not installed in " + filepath.Join(dir, ctipackage.DependencyDirName, "acme.lib"): statusFail,
+	}, statuses(checks))
+
+	require.NoError(t, os.Remove(filepath.Join(dir, ctipackage.IndexLockFileName)))
+	checks, _ = checkWorkspace(dir)
+	require.Len(t, checks, 1)
+	require.Equal(t, statusFail, checks[0].Status)
+	require.Equal(t, "run cti pkg get", checks[0].Fix)
+}
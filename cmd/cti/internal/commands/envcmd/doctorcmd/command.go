@@ -0,0 +1,95 @@
+package doctorcmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+
+	"github.com/spf13/cobra"
+)
+
+type DoctorOptions struct {
+	Format OutputFormat
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := DoctorOptions{Format: OutputFormatTable}
+	cmd := &cobra.Command{
+		Use:   "doctor",
+		Short: "diagnose problems of the setup of cti",
+		Long: "Checks the setup of cti and suggests fixes of problems: validity of the configuration, permissions\n" +
+			"and size of the cache directory, availability of git, reachability of proxies and registries of\n" +
+			"environments with their secrets, and dependencies of the package installed as locked.\n" +
+			"Fails if any check fails, warnings do not fail.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	cmd.Flags().VarP(&opts.Format, "format", "f", `Output format. allowed: `+strings.Join(ListOutputFormats, ","))
+
+	return cmd
+}
+
+func execute(ctx context.Context, baseDir string, opts DoctorOptions) error {
+	layers, err := cti.LoadLayers(baseDir)
+	checks := checkConfig(layers, err)
+	checks = append(checks, checkCache()...)
+	checks = append(checks, checkGit(ctx)...)
+	workspace, packageID := checkWorkspace(baseDir)
+	checks = append(checks, workspace...)
+	// Registries are not checked if the configuration is not valid.
+	if err == nil {
+		if cfg, err := layers.Config(); err == nil {
+			checks = append(checks, checkRegistries(ctx, cfg, packageID)...)
+		}
+	}
+
+	if err := writeChecks(os.Stdout, checks, opts.Format); err != nil {
+		return err
+	}
+	failed := 0
+	for _, c := range checks {
+		if c.Status == statusFail {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return fmt.Errorf("%d of %d checks failed", failed, len(checks))
+	}
+	return nil
+}
+
+func writeChecks(w io.Writer, checks []check, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(checks)
+	case OutputFormatTable:
+		fallthrough
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "CHECK\tSTATUS\tMESSAGE")
+		for _, c := range checks {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", c.Check, c.Status, c.Message)
+			if c.Fix != "" {
+				fmt.Fprintf(tw, "\t\tfix: %s\n", c.Fix)
+			}
+		}
+		return tw.Flush()
+	}
+}
@@ -0,0 +1,116 @@
+package execcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"os/exec"
+	"sort"
+	"time"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+
+	"github.com/spf13/cobra"
+)
+
+// waitDelay is the time the command is given to exit after cti is interrupted before it is killed.
+const waitDelay = 10 * time.Second
+
+type ExecOptions struct {
+	// Env is the environment whose registry is exported as CTI_REGISTRY, the default environment if empty.
+	Env string
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := ExecOptions{}
+	cmd := &cobra.Command{
+		Use:   "exec [--env <name>] [--] <command> [<arg>...]",
+		Short: "run a command with the resolved environment of the package",
+		Long: "Runs the command in the package directory with CTI_* environment variables of the resolved environment,\n" +
+			"so code generators and build scripts do not resolve it themselves: the cache directory, the package and\n" +
+			"directories of its installed dependencies, registries of environments and names of variables that hold\n" +
+			"their tokens. Secrets themselves are not exported. Without a command the variables are printed.\n" +
+			"cti exits with the exit code of the command.",
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			err = execute(ctx, baseDir, args, opts)
+			var exitErr *command.ExitError
+			if errors.As(err, &exitErr) {
+				return err
+			}
+			return command.WrapError(err)
+		},
+	}
+
+	// Flags after the command are arguments of the command.
+	cmd.Flags().SetInterspersed(false)
+	cmd.Flags().StringVar(&opts.Env, "env", "", "Environment whose registry is exported as CTI_REGISTRY, the default environment if omitted.")
+
+	return cmd
+}
+
+func execute(ctx context.Context, baseDir string, args []string, opts ExecOptions) error {
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	vars, err := resolveVars(baseDir, cfg, opts.Env)
+	if err != nil {
+		return fmt.Errorf("resolve environment: %w", err)
+	}
+	if len(args) == 0 {
+		return writeVars(os.Stdout, vars)
+	}
+
+	slog.Debug("Run command", slog.Any("command", args), slog.String("path", baseDir))
+	cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+	// Interrupts of the terminal reach the command too, so it is given time to exit gracefully.
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = waitDelay
+	cmd.Dir = baseDir
+	cmd.Env = os.Environ()
+	for _, name := range sortedNames(vars) {
+		cmd.Env = append(cmd.Env, name+"="+vars[name])
+	}
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	err = cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+		return &command.ExitError{Code: exitErr.ExitCode()}
+	}
+	if err != nil {
+		return fmt.Errorf("run %s: %w", args[0], err)
+	}
+	return nil
+}
+
+// writeVars prints the variables sorted by names in the format of env.
+func writeVars(w io.Writer, vars map[string]string) error {
+	for _, name := range sortedNames(vars) {
+		if _, err := fmt.Fprintf(w, "%s=%s\n", name, vars[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func sortedNames(vars map[string]string) []string {
+	names := make([]string, 0, len(vars))
+	for name := range vars {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,133 @@
+package execcmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/pacman"
+)
+
+// Variables of the package and of the selected environment. Names of the package and the environment match
+// variables passed to deploy hooks. Variables of the environment are exported for each environment too, suffixed
+// with its name, e.g. CTI_REGISTRY_PROD.
+const (
+	packageVar    = "CTI_PACKAGE"
+	versionVar    = "CTI_VERSION"
+	packageDirVar = "CTI_PACKAGE_DIR"
+	ramlxDirVar   = "CTI_RAMLX_DIR"
+	depDirVar     = "CTI_DEP_DIR"
+	depPathVar    = "CTI_DEP_PATH"
+	// depVarPrefix prefixes directories of installed dependencies, e.g. CTI_DEP_ACME_LIB for acme.lib.
+	depVarPrefix = "CTI_DEP_"
+	envVar       = "CTI_ENV"
+	registryVar  = "CTI_REGISTRY"
+	tokenEnvVar  = "CTI_TOKEN_ENV"
+	secretEnvVar = "CTI_CLIENT_SECRET_ENV"
+)
+
+// resolveVars returns variables of the resolved environment of the package directory: the cache directory, the
+// package and its installed dependencies if the directory is a package, and registries of environments. Secrets
+// are never exported, only names of variables that hold them. The environment is optional unless it is named.
+func resolveVars(baseDir string, cfg *cti.Config, env string) (map[string]string, error) {
+	vars := map[string]string{}
+
+	cacheDir, err := pacman.CacheDirPath()
+	if err != nil {
+		return nil, fmt.Errorf("get cache dir: %w", err)
+	}
+	vars[pacman.CacheDirEnvVar] = cacheDir
+
+	if err := packageVars(vars, baseDir); err != nil {
+		return nil, err
+	}
+
+	for name, envCfg := range cfg.Environments {
+		for key, value := range authVars(envCfg) {
+			vars[key+"_"+varSuffix(name)] = value
+		}
+	}
+	name, envCfg, err := cfg.Environment(env)
+	switch {
+	case err != nil && env != "":
+		return nil, err
+	case err == nil:
+		vars[envVar] = name
+		for key, value := range authVars(envCfg) {
+			vars[key] = value
+		}
+	}
+	return vars, nil
+}
+
+// packageVars adds variables of the package and of its installed dependencies. Directories without index.json are
+// not packages, so no variables are added.
+func packageVars(vars map[string]string, baseDir string) error {
+	absDir, err := filepath.Abs(baseDir)
+	if err != nil {
+		return fmt.Errorf("get absolute path of %s: %w", baseDir, err)
+	}
+	if _, err := os.Stat(filepath.Join(absDir, ctipackage.IndexFileName)); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	pkg, err := ctipackage.New(absDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+
+	vars[packageVar] = pkg.Index.PackageID
+	if pkg.Index.Version != "" {
+		vars[versionVar] = pkg.Index.Version
+	}
+	vars[packageDirVar] = absDir
+	vars[ramlxDirVar] = filepath.Join(absDir, ctipackage.RamlxDirName)
+	depDir := filepath.Join(absDir, ctipackage.DependencyDirName)
+	vars[depDirVar] = depDir
+
+	// Dependencies are listed by package ids, like directories of the dependency directory.
+	ids := make([]string, 0, len(pkg.IndexLock.SourceInfo))
+	for _, info := range pkg.IndexLock.SourceInfo {
+		ids = append(ids, info.PackageID)
+	}
+	sort.Strings(ids)
+	paths := make([]string, 0, len(ids))
+	for _, id := range ids {
+		p := filepath.Join(depDir, id)
+		if _, err := os.Stat(p); err != nil {
+			continue
+		}
+		vars[depVarPrefix+varSuffix(id)] = p
+		paths = append(paths, p)
+	}
+	vars[depPathVar] = strings.Join(paths, string(os.PathListSeparator))
+	return nil
+}
+
+// authVars returns variables of the registry of the environment and of names of variables that hold its secrets.
+func authVars(env cti.Environment) map[string]string {
+	vars := map[string]string{}
+	if env.Registry != "" {
+		vars[registryVar] = env.Registry
+	}
+	if env.Auth.TokenEnv != "" {
+		vars[tokenEnvVar] = env.Auth.TokenEnv
+	}
+	if env.Auth.OIDC != nil && env.Auth.OIDC.ClientSecretEnv != "" {
+		vars[secretEnvVar] = env.Auth.OIDC.ClientSecretEnv
+	}
+	return vars
+}
+
+// varSuffix returns the name in upper case with characters other than letters and digits replaced with underscores,
+// e.g. ACME_LIB for acme.lib.
+func varSuffix(name string) string {
+	return strings.TrimPrefix(cti.EnvVarName(name), cti.EnvVarPrefix)
+}
@@ -0,0 +1,55 @@
+package getcmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+func New(ctx context.Context) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <key>",
+		Short: "print the effective value of a configuration key",
+		Long: "Prints the effective value of the dotted key, e.g. fmt.indent or environments.prod.registry.\n" +
+			"Lists and sections are printed as YAML. Fails if the key is not set.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, args[0]))
+		},
+	}
+}
+
+func execute(_ context.Context, baseDir string, key string) error {
+	layers, err := cti.LoadLayers(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	v, err := layers.Get(key)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return fmt.Errorf("key %s is not set", key)
+	}
+	if v.Node.Kind == yaml.ScalarNode {
+		_, err = fmt.Fprintln(os.Stdout, v.Value)
+		return err
+	}
+	enc := yaml.NewEncoder(os.Stdout)
+	enc.SetIndent(2)
+	if err := enc.Encode(v.Node); err != nil {
+		return fmt.Errorf("encode %s: %w", key, err)
+	}
+	return enc.Close()
+}
@@ -0,0 +1,77 @@
+package listcmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+
+	"github.com/spf13/cobra"
+)
+
+type ListOptions struct {
+	Format OutputFormat
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := ListOptions{Format: OutputFormatTable}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list effective configuration values and their sources",
+		Long: "Lists keys set in the global configuration ~/" + cti.GlobalConfigDirName + "/" + cti.GlobalConfigFileName +
+			", in " + cti.ConfigFileName + " of the package or by " + cti.EnvVarPrefix + "* environment variables,\n" +
+			"with effective values and the layer each value comes from.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	cmd.Flags().VarP(&opts.Format, "format", "f", `Output format. allowed: `+strings.Join(ListOutputFormats, ","))
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts ListOptions) error {
+	layers, err := cti.LoadLayers(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	return writeValues(os.Stdout, layers.Values(), opts.Format)
+}
+
+func writeValues(w io.Writer, values []cti.ConfigValue, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		if values == nil {
+			values = []cti.ConfigValue{}
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(values)
+	case OutputFormatTable:
+		fallthrough
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "KEY\tVALUE\tSOURCE")
+		for _, v := range values {
+			source := string(v.Source)
+			if v.Source == cti.LayerEnv {
+				source += " " + v.Origin
+			}
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", v.Key, v.Value, source)
+		}
+		return tw.Flush()
+	}
+}
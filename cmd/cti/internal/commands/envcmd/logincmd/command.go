@@ -0,0 +1,106 @@
+package logincmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+type LoginOptions struct {
+	// Env is the name of the environment whose registry the secret is stored for.
+	Env string
+	// Stdin reads the secret from stdin instead of prompting for it.
+	Stdin bool
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := LoginOptions{}
+	cmd := &cobra.Command{
+		Use:   "login",
+		Short: "store the secret of the registry of an environment in the OS keyring",
+		Long: "Stores the token of the registry of the environment, or the client secret if the environment\n" +
+			"authenticates with an OpenID Connect provider, in the keyring of the OS: the Keychain on macOS, the\n" +
+			"Credential Manager on Windows or the Secret Service on Linux. Environment variables of token_env and\n" +
+			"client_secret_env take precedence over stored secrets. The secret is prompted for, or read from stdin\n" +
+			"with --stdin or if stdin is not a terminal.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Env, "env", "", "Environment to log in to, may be omitted if a single target is configured.")
+	cmd.Flags().BoolVar(&opts.Stdin, "stdin", false, "Read the secret from stdin.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts LoginOptions) error {
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	env, envCfg, err := cfg.Environment(opts.Env)
+	if err != nil {
+		return err
+	}
+	if envCfg.Registry == "" {
+		return fmt.Errorf("environment %s has no registry", env)
+	}
+
+	kind, varName := "token", envCfg.Auth.TokenEnv
+	if envCfg.Auth.OIDC != nil {
+		kind, varName = "client secret", envCfg.Auth.OIDC.ClientSecretEnv
+	}
+	secret, err := readSecret(fmt.Sprintf("%s of %s: ", strings.ToUpper(kind[:1])+kind[1:], envCfg.Registry), opts.Stdin)
+	if err != nil {
+		return fmt.Errorf("read %s: %w", kind, err)
+	}
+	if secret == "" {
+		return fmt.Errorf("%s is empty", kind)
+	}
+	if err := cti.StoreSecret(envCfg, secret); err != nil {
+		return err
+	}
+	slog.Info("Secret has been stored in the keyring", slog.String("env", env), slog.String("registry", envCfg.Registry))
+	if varName != "" && os.Getenv(varName) != "" {
+		slog.Warn("Environment variable takes precedence over the stored secret", slog.String("variable", varName))
+	}
+	return nil
+}
+
+// readSecret prompts for the secret without echoing it, or reads it from stdin if it is not a terminal.
+func readSecret(prompt string, stdin bool) (string, error) {
+	fd := int(os.Stdin.Fd())
+	if stdin || !term.IsTerminal(fd) {
+		data, err := io.ReadAll(os.Stdin)
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	if _, err := fmt.Fprint(os.Stderr, prompt); err != nil {
+		return "", err
+	}
+	data, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", errors.Join(errors.New("prompt is not available, use --stdin"), err)
+	}
+	return strings.TrimSpace(string(data)), nil
+}
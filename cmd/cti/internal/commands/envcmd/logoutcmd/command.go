@@ -0,0 +1,64 @@
+package logoutcmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+
+	"github.com/spf13/cobra"
+)
+
+type LogoutOptions struct {
+	// Env is the name of the environment whose stored secret is deleted.
+	Env string
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := LogoutOptions{}
+	cmd := &cobra.Command{
+		Use:   "logout",
+		Short: "delete the secret of the registry of an environment from the OS keyring",
+		Long: "Deletes the secret stored with cti env login. Environments with the same registry share the secret,\n" +
+			"so they are logged out too.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	cmd.Flags().StringVar(&opts.Env, "env", "", "Environment to log out of, may be omitted if a single target is configured.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts LogoutOptions) error {
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	env, envCfg, err := cfg.Environment(opts.Env)
+	if err != nil {
+		return err
+	}
+	if envCfg.Registry == "" {
+		return fmt.Errorf("environment %s has no registry", env)
+	}
+	ok, err := cti.DeleteSecret(envCfg)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		slog.Warn("No secret is stored", slog.String("env", env), slog.String("registry", envCfg.Registry))
+		return nil
+	}
+	slog.Info("Secret has been deleted from the keyring", slog.String("env", env), slog.String("registry", envCfg.Registry))
+	return nil
+}
@@ -0,0 +1,70 @@
+package setcmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+
+	"github.com/spf13/cobra"
+)
+
+type SetOptions struct {
+	// Global sets the key in the global configuration instead of the configuration of the package.
+	Global bool
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := SetOptions{}
+	cmd := &cobra.Command{
+		Use:   "set <key> <value>",
+		Short: "set a configuration key",
+		Long: "Sets the dotted key in " + cti.ConfigFileName + " of the package, or in the global configuration\n" +
+			"~/" + cti.GlobalConfigDirName + "/" + cti.GlobalConfigFileName + " with --global. The value is parsed as YAML, " +
+			"e.g. true, 4 or [a, b].\nComments and other keys of the file are kept.",
+		Args: cobra.ExactArgs(2),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, args[0], args[1], opts))
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Global, "global", false, "Set the key in the global configuration shared by packages of the user.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, key, value string, opts SetOptions) error {
+	path, err := cti.ConfigPath(baseDir, opts.Global)
+	if err != nil {
+		return err
+	}
+	f, err := cti.ReadConfigFile(path)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if err := f.Set(key, value); err != nil {
+		return err
+	}
+	if err := f.Save(); err != nil {
+		return err
+	}
+	slog.Info("Configuration has been updated", slog.String("key", key), slog.String("path", path))
+
+	// The value may not take effect if a layer of higher precedence sets the key too.
+	layers, err := cti.LoadLayers(baseDir)
+	if err != nil {
+		return nil
+	}
+	if v, err := layers.Get(key); err == nil && v != nil && v.Origin != path {
+		slog.Warn("Key is overridden", slog.String("key", key), slog.String("source", string(v.Source)),
+			slog.String("origin", v.Origin))
+	}
+	return nil
+}
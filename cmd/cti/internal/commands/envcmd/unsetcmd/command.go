@@ -0,0 +1,63 @@
+package unsetcmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+
+	"github.com/spf13/cobra"
+)
+
+type UnsetOptions struct {
+	// Global removes the key from the global configuration instead of the configuration of the package.
+	Global bool
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := UnsetOptions{}
+	cmd := &cobra.Command{
+		Use:   "unset <key>",
+		Short: "remove a configuration key",
+		Long: "Removes the dotted key from " + cti.ConfigFileName + " of the package, or from the global configuration\n" +
+			"~/" + cti.GlobalConfigDirName + "/" + cti.GlobalConfigFileName + " with --global. Sections left empty are removed too.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, args[0], opts))
+		},
+	}
+
+	cmd.Flags().BoolVar(&opts.Global, "global", false, "Remove the key from the global configuration shared by packages of the user.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, key string, opts UnsetOptions) error {
+	path, err := cti.ConfigPath(baseDir, opts.Global)
+	if err != nil {
+		return err
+	}
+	f, err := cti.ReadConfigFile(path)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	ok, err := f.Unset(key)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return fmt.Errorf("key %s is not set in %s", key, path)
+	}
+	if err := f.Save(); err != nil {
+		return err
+	}
+	slog.Info("Configuration has been updated", slog.String("key", key), slog.String("path", path))
+	return nil
+}
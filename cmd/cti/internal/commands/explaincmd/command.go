@@ -0,0 +1,56 @@
+package explaincmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata/validator"
+
+	"github.com/spf13/cobra"
+)
+
+func New(_ context.Context) *cobra.Command {
+	return &cobra.Command{
+		Use:   "explain [code]",
+		Short: "explain diagnostic code or list all known diagnostics",
+		Args:  cobra.MaximumNArgs(1),
+		RunE: func(_ *cobra.Command, args []string) error {
+			if len(args) == 0 {
+				return command.WrapError(listRules(os.Stdout))
+			}
+			return command.WrapError(explainRule(os.Stdout, args[0]))
+		},
+	}
+}
+
+func listRules(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, rule := range validator.Rules() {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", rule.Code, rule.Severity, rule.Name, rule.Title)
+	}
+	return tw.Flush()
+}
+
+func explainRule(w io.Writer, code string) error {
+	rule, ok := validator.Explain(validator.Code(strings.ToUpper(code)))
+	if !ok {
+		return fmt.Errorf("unknown diagnostic code: %s", code)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s (%s, %s %s): %s\n\n", rule.Code, rule.Name, rule.Severity, rule.Category, rule.Title)
+	fmt.Fprintf(&sb, "%s\n", rule.Description)
+	if rule.Example != "" {
+		fmt.Fprintf(&sb, "\nExample:\n\n    %s\n", rule.Example)
+	}
+	fmt.Fprintf(&sb, "\nRemediation:\n\n    %s\n", rule.Remediation)
+	fmt.Fprintf(&sb, "\nThe diagnostic can be suppressed with 'cti validate --suppress %s'.\n", rule.Code)
+
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -1,19 +1,327 @@
 package fmtcmd
 
 import (
+	"bytes"
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
 
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata/formatter"
 	"github.com/spf13/cobra"
+	"golang.org/x/sync/errgroup"
 )
 
-func New(_ context.Context) *cobra.Command {
-	return &cobra.Command{
-		Use:   "fmt",
-		Short: "cti fmt (reformat) cti sources",
-		Args:  cobra.MinimumNArgs(0),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return errors.New("not implemented")
+type FmtOptions struct {
+	Check  bool
+	Verify bool
+	Jobs   int
+	// Changed restricts formatting to files changed relative to Base, or to the merge base of HEAD
+	// and the default branch if Base is not set.
+	Changed bool
+	Base    string
+}
+
+// stdinPath is a path argument to format stdin.
+const stdinPath = "-"
+
+func New(ctx context.Context) *cobra.Command {
+	opts := FmtOptions{Jobs: runtime.GOMAXPROCS(0)}
+	cmd := &cobra.Command{
+		Use:   "fmt [path...|-]",
+		Short: "reformat RAML files of the package into the canonical style",
+		Long: "Reformats RAML files into the canonical style. Paths are files or directories relative to the package, " +
+			"the whole package is formatted if no paths are specified. JSON files with serialized metadata are formatted " +
+			"if specified explicitly. With - a single file is read from stdin and the formatted content is written " +
+			"to stdout. The style is configured in the fmt section of " + cti.ConfigFileName + ".",
+		Args: cobra.ArbitraryArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, args, opts))
 		},
 	}
+
+	cmd.Flags().BoolVar(&opts.Check, "check", false, "Print unified diffs of files that are not formatted instead of rewriting them and fail if there are any.")
+	cmd.Flags().BoolVar(&opts.Verify, "verify", false, "Format formatted content again and fail if it is changed, i.e. formatting is not idempotent.")
+	cmd.Flags().IntVarP(&opts.Jobs, "jobs", "j", opts.Jobs, "Number of files formatted in parallel.")
+	cmd.Flags().BoolVar(&opts.Changed, "changed", false, "Format only files changed relative to the base git ref, including uncommitted and untracked files.")
+	cmd.Flags().StringVar(&opts.Base, "base", "", "Git ref that --changed compares with. The merge base of HEAD and the default branch if not set.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, paths []string, opts FmtOptions) error {
+	if opts.Jobs < 1 {
+		return fmt.Errorf("jobs must be positive, got %d", opts.Jobs)
+	}
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	f, err := formatter.New(formatter.Style{
+		Indent:          cfg.Fmt.Indent,
+		Quote:           formatter.QuoteStyle(cfg.Fmt.Quote),
+		SortMembers:     cfg.Fmt.SortMembers,
+		SortAnnotations: cfg.Fmt.SortAnnotations,
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", cti.ConfigFileName, err)
+	}
+
+	if slices.Contains(paths, stdinPath) {
+		if len(paths) != 1 {
+			return errors.New("stdin cannot be formatted along with other paths")
+		}
+		if opts.Changed {
+			return errors.New("stdin cannot be formatted with --changed")
+		}
+		return formatStdin(f, opts)
+	}
+	if opts.Base != "" && !opts.Changed {
+		return errors.New("--base requires --changed")
+	}
+
+	files, err := collectFiles(baseDir, paths)
+	if err != nil {
+		return err
+	}
+	if opts.Changed {
+		if files, err = filterChanged(baseDir, files, opts.Base); err != nil {
+			return fmt.Errorf("find changed files: %w", err)
+		}
+	}
+
+	// Files are formatted in parallel, results are processed in order of files, so diffs and errors are reproducible.
+	results := make([]result, len(files))
+	var g errgroup.Group
+	g.SetLimit(opts.Jobs)
+	for i, file := range files {
+		g.Go(func() error {
+			results[i] = formatFile(f, file)
+			if results[i].err == nil && opts.Verify {
+				results[i].err = formatter.Verify(relPath(baseDir, file), results[i].res, formatFunc(f, file))
+			}
+			return nil
+		})
+	}
+	_ = g.Wait()
+
+	var errs []error
+	for _, r := range results {
+		if r.err != nil {
+			errs = append(errs, r.err)
+		}
+	}
+	if len(errs) != 0 {
+		return errors.Join(errs...)
+	}
+
+	changed := 0
+	for i, file := range files {
+		src, res := results[i].src, results[i].res
+		if bytes.Equal(src, res) {
+			continue
+		}
+		changed++
+		if opts.Check {
+			if err := printDiff(baseDir, file, src, res); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := writeFile(file, res); err != nil {
+			return err
+		}
+		slog.Info("Formatted", slog.String("path", file))
+	}
+
+	if opts.Check {
+		if changed != 0 {
+			return fmt.Errorf("%d of %d files are not formatted, run cti fmt", changed, len(files))
+		}
+		slog.Info("All files are formatted", slog.Int("files", len(files)))
+		return nil
+	}
+	slog.Info("Formatting has been completed", slog.Int("files", len(files)), slog.Int("changed", changed))
+	return nil
+}
+
+// formatStdin formats the content of stdin and writes it to stdout, or prints the diff in the check mode.
+func formatStdin(f *formatter.Formatter, opts FmtOptions) error {
+	src, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		return fmt.Errorf("read stdin: %w", err)
+	}
+	format := f.Format
+	if isJSON(src) {
+		format = f.FormatJSON
+	}
+	res, err := format(src)
+	if err != nil {
+		return fmt.Errorf("format stdin: %w", err)
+	}
+	if opts.Verify {
+		if err := formatter.Verify("stdin", res, format); err != nil {
+			return err
+		}
+	}
+	if !opts.Check {
+		_, err = os.Stdout.Write(res)
+		return err
+	}
+	if bytes.Equal(src, res) {
+		return nil
+	}
+	diff, err := formatter.Diff("stdin", src, res)
+	if err != nil {
+		return fmt.Errorf("diff stdin: %w", err)
+	}
+	if _, err := os.Stdout.WriteString(diff); err != nil {
+		return err
+	}
+	return errors.New("stdin is not formatted")
+}
+
+// result holds the content of the file and the formatted content.
+type result struct {
+	src []byte
+	res []byte
+	err error
+}
+
+func formatFile(f *formatter.Formatter, file string) result {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return result{err: fmt.Errorf("read %s: %w", file, err)}
+	}
+	res, err := formatFunc(f, file)(src)
+	if err != nil {
+		return result{err: fmt.Errorf("format %s: %w", file, err)}
+	}
+	return result{src: src, res: res}
+}
+
+// formatFunc returns the function formatting the file by its extension.
+func formatFunc(f *formatter.Formatter, file string) func([]byte) ([]byte, error) {
+	if filepath.Ext(file) == formatter.JSONExtension {
+		return f.FormatJSON
+	}
+	return f.Format
+}
+
+// isJSON reports whether stdin holds a JSON document, e.g. serialized metadata, rather than a RAML document.
+func isJSON(src []byte) bool {
+	src = bytes.TrimSpace(src)
+	return len(src) != 0 && (src[0] == '{' || src[0] == '[')
+}
+
+// printDiff prints the unified diff of the file with the path relative to the package.
+func printDiff(baseDir, file string, src, res []byte) error {
+	diff, err := formatter.Diff(relPath(baseDir, file), src, res)
+	if err != nil {
+		return fmt.Errorf("diff %s: %w", file, err)
+	}
+	_, err = os.Stdout.WriteString(diff)
+	return err
+}
+
+// relPath returns the slash-separated path of the file relative to the package, or the path as is.
+func relPath(baseDir, file string) string {
+	if rel, err := filepath.Rel(baseDir, file); err == nil {
+		return filepath.ToSlash(rel)
+	}
+	return file
+}
+
+func writeFile(file string, res []byte) error {
+	info, err := os.Stat(file)
+	if err != nil {
+		return fmt.Errorf("stat %s: %w", file, err)
+	}
+	if err := os.WriteFile(file, res, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("write %s: %w", file, err)
+	}
+	return nil
+}
+
+// filterChanged returns files changed relative to the base ref.
+func filterChanged(baseDir string, files []string, base string) ([]string, error) {
+	if base == "" {
+		var err error
+		if base, err = mergeBase(baseDir); err != nil {
+			return nil, err
+		}
+	}
+	changed, err := changedFiles(baseDir, base)
+	if err != nil {
+		return nil, err
+	}
+	var res []string
+	for _, file := range files {
+		if changed[file] {
+			res = append(res, file)
+		}
+	}
+	slog.Info("Formatting changed files", slog.String("base", base), slog.Int("changed", len(res)))
+	return res, nil
+}
+
+// collectFiles returns RAML files of the paths relative to the package, or of the whole package.
+// Files specified explicitly are returned as is, e.g. JSON files with serialized metadata.
+// Hidden directories, e.g. dependencies and RAMLx specifications, are skipped when walking directories.
+func collectFiles(baseDir string, paths []string) ([]string, error) {
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+	var files []string
+	seen := make(map[string]bool)
+	for _, p := range paths {
+		if !filepath.IsAbs(p) {
+			p = filepath.Join(baseDir, p)
+		}
+		info, err := os.Stat(p)
+		if err != nil {
+			return nil, fmt.Errorf("stat %s: %w", p, err)
+		}
+		if !info.IsDir() {
+			if !seen[p] {
+				seen[p] = true
+				files = append(files, p)
+			}
+			continue
+		}
+		err = filepath.WalkDir(p, func(fPath string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if d.IsDir() {
+				if fPath != p && strings.HasPrefix(d.Name(), ".") {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if filepath.Ext(fPath) == formatter.Extension && !seen[fPath] {
+				seen[fPath] = true
+				files = append(files, fPath)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("walk %s: %w", p, err)
+		}
+	}
+	return files, nil
 }
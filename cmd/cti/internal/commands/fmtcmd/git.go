@@ -0,0 +1,61 @@
+package fmtcmd
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+)
+
+// defaultBranches are branches compared with if the remote default branch is unknown.
+var defaultBranches = []string{"main", "master"}
+
+// changedFiles returns absolute paths of files in the directory changed relative to the git ref: files changed
+// in commits since the ref, staged and unstaged changes and untracked files. Deleted files are not returned.
+func changedFiles(dir, ref string) (map[string]bool, error) {
+	diff, err := command.Git(dir, "diff", "--name-only", "--relative", "--diff-filter=ACMR", "-z", ref, "--")
+	if err != nil {
+		return nil, err
+	}
+	untracked, err := command.Git(dir, "ls-files", "--others", "--exclude-standard", "-z")
+	if err != nil {
+		return nil, err
+	}
+	files := make(map[string]bool)
+	for _, out := range []string{diff, untracked} {
+		for _, name := range strings.Split(out, "\x00") {
+			if name != "" {
+				files[filepath.Join(dir, filepath.FromSlash(name))] = true
+			}
+		}
+	}
+	return files, nil
+}
+
+// mergeBase returns the merge base of HEAD and the default branch: the remote HEAD of origin if set,
+// otherwise the first existing branch of defaultBranches.
+func mergeBase(dir string) (string, error) {
+	branch, err := defaultBranch(dir)
+	if err != nil {
+		return "", err
+	}
+	base, err := command.Git(dir, "merge-base", "HEAD", branch)
+	if err != nil {
+		return "", fmt.Errorf("find merge base with %s: %w", branch, err)
+	}
+	return strings.TrimSpace(base), nil
+}
+
+func defaultBranch(dir string) (string, error) {
+	if ref, err := command.Git(dir, "symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimSpace(ref), nil
+	}
+	for _, branch := range defaultBranches {
+		if _, err := command.Git(dir, "rev-parse", "--verify", "--quiet", branch+"^{commit}"); err == nil {
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("default branch not found, tried origin/HEAD and %s, set the base ref explicitly",
+		strings.Join(defaultBranches, ", "))
+}
@@ -0,0 +1,74 @@
+package asyncapicmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/spf13/cobra"
+)
+
+type AsyncAPIOptions struct {
+	command.GenOptions
+	Title      string
+	APIVersion string
+	Format     OutputFormat
+	Channels   map[string]string
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := AsyncAPIOptions{Format: OutputFormatYAML}
+	cmd := &cobra.Command{
+		Use:   "asyncapi",
+		Short: "generate AsyncAPI 3.0 document with channels of cti event types",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	command.AddGenFlags(cmd, &opts.GenOptions, "gen/asyncapi")
+	cmd.Flags().StringVar(&opts.Title, "title", "", "Title of the document.")
+	cmd.Flags().StringVar(&opts.APIVersion, "api-version", "1.0.0", "Version of the document.")
+	cmd.Flags().VarP(&opts.Format, "format", "f", `Output format. allowed: `+strings.Join(ListOutputFormats, ","))
+	cmd.Flags().StringToStringVar(&opts.Channels, "channel", nil,
+		"Address of the channel of the type and its descendants, e.g. cti.x.y.event.v1.0=events. Adds to channels defined in "+cti.ConfigFileName+".")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts AsyncAPIOptions) error {
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	// Channels set with flags take precedence over the configuration.
+	channels := make(map[string]string, len(cfg.Gen.AsyncAPI.Channels)+len(opts.Channels))
+	for id, address := range cfg.Gen.AsyncAPI.Channels {
+		channels[id] = address
+	}
+	for id, address := range opts.Channels {
+		channels[id] = address
+	}
+
+	slog.Info("Generating AsyncAPI document", slog.Int("channels", len(channels)))
+
+	return command.RunGen(baseDir, opts.GenOptions, func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error) {
+		return g.AsyncAPI(entities, codegen.AsyncAPIOptions{
+			Title:    opts.Title,
+			Version:  opts.APIVersion,
+			Format:   codegen.DocumentFormat(opts.Format),
+			Channels: channels,
+		})
+	})
+}
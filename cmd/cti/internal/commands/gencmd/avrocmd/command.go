@@ -0,0 +1,47 @@
+package avrocmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/spf13/cobra"
+)
+
+type AvroOptions struct {
+	command.GenOptions
+	Namespace string
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := AvroOptions{}
+	cmd := &cobra.Command{
+		Use:   "avro",
+		Short: "generate avro schemas with logical types and namespaces of vendors and packages",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	command.AddGenFlags(cmd, &opts.GenOptions, "gen/avro")
+	cmd.Flags().StringVar(&opts.Namespace, "namespace", "", "Prefix of namespaces of the schemas, e.g. com.example.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts AvroOptions) error {
+	slog.Info("Generating Avro schemas")
+
+	return command.RunGen(baseDir, opts.GenOptions, func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error) {
+		return g.Avro(entities, codegen.AvroOptions{Namespace: opts.Namespace})
+	})
+}
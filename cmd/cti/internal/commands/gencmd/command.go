@@ -0,0 +1,45 @@
+package gencmd
+
+import (
+	"context"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd/asyncapicmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd/avrocmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd/crdcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd/cuecmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd/gocmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd/graphqlcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd/javacmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd/jsonldcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd/jsonschemacmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd/mdcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd/openapicmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd/protocmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd/terraformcmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/gencmd/tscmd"
+	"github.com/spf13/cobra"
+)
+
+func New(ctx context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "gen",
+		Short: "generate schemas and code of other languages from cti types",
+	}
+	cmd.AddCommand(
+		jsonschemacmd.New(ctx),
+		openapicmd.New(ctx),
+		tscmd.New(ctx),
+		gocmd.New(ctx),
+		protocmd.New(ctx),
+		graphqlcmd.New(ctx),
+		jsonldcmd.New(ctx),
+		cuecmd.New(ctx),
+		avrocmd.New(ctx),
+		asyncapicmd.New(ctx),
+		crdcmd.New(ctx),
+		terraformcmd.New(ctx),
+		mdcmd.New(ctx),
+		javacmd.New(ctx),
+	)
+	return cmd
+}
@@ -0,0 +1,50 @@
+package crdcmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/spf13/cobra"
+)
+
+type CRDOptions struct {
+	command.GenOptions
+	Group string
+	Scope Scope
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := CRDOptions{Scope: ScopeNamespaced}
+	cmd := &cobra.Command{
+		Use:   "crd",
+		Short: "generate kubernetes custom resource definitions with structural schemas",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	command.AddGenFlags(cmd, &opts.GenOptions, "gen/crd")
+	cmd.Flags().StringVar(&opts.Group, "group", "", "API group of the resources, e.g. example.com. Derived from packages and vendors of the types by default.")
+	cmd.Flags().Var(&opts.Scope, "scope", `Scope of the resources. allowed: `+strings.Join(ListScopes, ","))
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts CRDOptions) error {
+	slog.Info("Generating custom resource definitions")
+
+	return command.RunGen(baseDir, opts.GenOptions, func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error) {
+		return g.CRD(entities, codegen.CRDOptions{Group: opts.Group, Scope: string(opts.Scope)})
+	})
+}
@@ -0,0 +1,38 @@
+package crdcmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata/codegen"
+)
+
+type Scope string
+
+const (
+	ScopeNamespaced Scope = codegen.CRDScopeNamespaced
+	ScopeCluster    Scope = codegen.CRDScopeCluster
+)
+
+var ListScopes = []string{string(ScopeNamespaced), string(ScopeCluster)}
+
+// String is used both by fmt.Print and by Cobra in help text
+func (e *Scope) String() string {
+	return string(*e)
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (e *Scope) Set(v string) error {
+	switch v {
+	case string(ScopeNamespaced), string(ScopeCluster):
+		*e = Scope(v)
+		return nil
+	default:
+		return errors.New(`must be one of ` + strings.Join(ListScopes, ","))
+	}
+}
+
+// Type is only used in help text
+func (e *Scope) Type() string {
+	return "scope"
+}
@@ -0,0 +1,47 @@
+package cuecmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/spf13/cobra"
+)
+
+type CUEOptions struct {
+	command.GenOptions
+	Package string
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := CUEOptions{}
+	cmd := &cobra.Command{
+		Use:   "cue",
+		Short: "generate cue definitions with constraints of cti types",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	command.AddGenFlags(cmd, &opts.GenOptions, "gen/cue")
+	cmd.Flags().StringVar(&opts.Package, "package", codegen.DefaultCUEPackage, "Name of the generated package.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts CUEOptions) error {
+	slog.Info("Generating CUE definitions")
+
+	return command.RunGen(baseDir, opts.GenOptions, func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error) {
+		return g.CUE(entities, codegen.CUEOptions{Package: opts.Package})
+	})
+}
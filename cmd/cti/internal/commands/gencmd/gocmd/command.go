@@ -0,0 +1,49 @@
+package gocmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/spf13/cobra"
+)
+
+type GoOptions struct {
+	command.GenOptions
+	Package    string
+	Validation bool
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := GoOptions{}
+	cmd := &cobra.Command{
+		Use:   "go",
+		Short: "generate go structs and constants of cti identifiers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	command.AddGenFlags(cmd, &opts.GenOptions, "gen/go/"+codegen.DefaultGoPackage)
+	cmd.Flags().StringVar(&opts.Package, "package", codegen.DefaultGoPackage, "Name of the generated package.")
+	cmd.Flags().BoolVar(&opts.Validation, "validation", false, "Generate Validate methods of the types.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts GoOptions) error {
+	slog.Info("Generating Go types")
+
+	return command.RunGen(baseDir, opts.GenOptions, func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error) {
+		return g.Go(entities, codegen.GoOptions{Package: opts.Package, Validation: opts.Validation})
+	})
+}
@@ -0,0 +1,41 @@
+package graphqlcmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/spf13/cobra"
+)
+
+func New(ctx context.Context) *cobra.Command {
+	opts := command.GenOptions{}
+	cmd := &cobra.Command{
+		Use:   "graphql",
+		Short: "generate graphql schema with object types, interfaces and enums",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	command.AddGenFlags(cmd, &opts, "gen/graphql")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts command.GenOptions) error {
+	slog.Info("Generating GraphQL schema")
+
+	return command.RunGen(baseDir, opts, func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error) {
+		return g.GraphQL(entities)
+	})
+}
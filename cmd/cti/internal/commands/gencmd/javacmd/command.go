@@ -0,0 +1,50 @@
+package javacmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/spf13/cobra"
+)
+
+type JavaOptions struct {
+	command.GenOptions
+	Package string
+	Style   Style
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := JavaOptions{Style: StyleRecord}
+	cmd := &cobra.Command{
+		Use:   "java",
+		Short: "generate java classes with jackson annotations",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	command.AddGenFlags(cmd, &opts.GenOptions, "gen/java")
+	cmd.Flags().StringVar(&opts.Package, "package", codegen.DefaultJavaPackage, "Name of the generated package, e.g. com.example.events.")
+	cmd.Flags().Var(&opts.Style, "style", `Style of the generated classes. allowed: `+strings.Join(ListStyles, ","))
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts JavaOptions) error {
+	slog.Info("Generating Java classes")
+
+	return command.RunGen(baseDir, opts.GenOptions, func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error) {
+		return g.Java(entities, codegen.JavaOptions{Package: opts.Package, Style: string(opts.Style)})
+	})
+}
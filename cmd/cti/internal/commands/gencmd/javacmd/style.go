@@ -0,0 +1,38 @@
+package javacmd
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata/codegen"
+)
+
+type Style string
+
+const (
+	StyleRecord Style = codegen.JavaStyleRecord
+	StylePOJO   Style = codegen.JavaStylePOJO
+)
+
+var ListStyles = []string{string(StyleRecord), string(StylePOJO)}
+
+// String is used both by fmt.Print and by Cobra in help text
+func (e *Style) String() string {
+	return string(*e)
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (e *Style) Set(v string) error {
+	switch v {
+	case string(StyleRecord), string(StylePOJO):
+		*e = Style(v)
+		return nil
+	default:
+		return errors.New(`must be one of ` + strings.Join(ListStyles, ","))
+	}
+}
+
+// Type is only used in help text
+func (e *Style) Type() string {
+	return "style"
+}
@@ -0,0 +1,49 @@
+package jsonldcmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/spf13/cobra"
+)
+
+type JSONLDOptions struct {
+	command.GenOptions
+	Base     string
+	Ontology bool
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := JSONLDOptions{}
+	cmd := &cobra.Command{
+		Use:   "jsonld",
+		Short: "generate json-ld context and rdf/owl ontology with cti identifiers as iris",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	command.AddGenFlags(cmd, &opts.GenOptions, "gen/jsonld")
+	cmd.Flags().StringVar(&opts.Base, "base", codegen.DefaultIRIBase, "Prefix of IRIs of CTIs.")
+	cmd.Flags().BoolVar(&opts.Ontology, "ontology", false, "Generate OWL ontology in Turtle as well.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts JSONLDOptions) error {
+	slog.Info("Generating JSON-LD context", slog.String("base", opts.Base))
+
+	return command.RunGen(baseDir, opts.GenOptions, func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error) {
+		return g.JSONLD(entities, codegen.JSONLDOptions{Base: opts.Base, Ontology: opts.Ontology})
+	})
+}
@@ -0,0 +1,56 @@
+package jsonschemacmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/spf13/cobra"
+)
+
+type JSONSchemaOptions struct {
+	command.GenOptions
+	Draft       Draft
+	Layout      Layout
+	Dereference bool
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := JSONSchemaOptions{Draft: Draft7, Layout: LayoutType}
+	cmd := &cobra.Command{
+		Use:   "jsonschema",
+		Short: "generate self-contained JSON Schema documents of cti types",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	command.AddGenFlags(cmd, &opts.GenOptions, "gen/jsonschema")
+	cmd.Flags().Var(&opts.Draft, "draft", `JSON Schema draft. allowed: `+strings.Join(ListDrafts, ","))
+	cmd.Flags().Var(&opts.Layout, "layout", `Document per type or per package. allowed: `+strings.Join(ListLayouts, ","))
+	cmd.Flags().BoolVar(&opts.Dereference, "dereference", false, "Inline referenced definitions instead of bundling them.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts JSONSchemaOptions) error {
+	slog.Info("Generating JSON Schema", slog.String("draft", string(opts.Draft)), slog.String("layout", string(opts.Layout)))
+
+	return command.RunGen(baseDir, opts.GenOptions, func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error) {
+		return g.JSONSchema(entities, codegen.JSONSchemaOptions{
+			Draft:       codegen.Draft(opts.Draft),
+			Layout:      codegen.Layout(opts.Layout),
+			Dereference: opts.Dereference,
+		})
+	})
+}
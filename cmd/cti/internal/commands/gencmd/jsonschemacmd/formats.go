@@ -0,0 +1,67 @@
+package jsonschemacmd
+
+import (
+	"errors"
+	"strings"
+)
+
+type Draft string
+
+const (
+	Draft7      Draft = "draft-07"
+	Draft201909 Draft = "2019-09"
+	Draft202012 Draft = "2020-12"
+)
+
+var ListDrafts = []string{string(Draft7), string(Draft201909), string(Draft202012)}
+
+// String is used both by fmt.Print and by Cobra in help text
+func (e *Draft) String() string {
+	return string(*e)
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (e *Draft) Set(v string) error {
+	switch v {
+	case string(Draft7), string(Draft201909), string(Draft202012):
+		*e = Draft(v)
+		return nil
+	default:
+		return errors.New(`must be one of ` + strings.Join(ListDrafts, ","))
+	}
+}
+
+// Type is only used in help text
+func (e *Draft) Type() string {
+	return "draft"
+}
+
+type Layout string
+
+const (
+	LayoutType    Layout = "type"
+	LayoutPackage Layout = "package"
+)
+
+var ListLayouts = []string{string(LayoutType), string(LayoutPackage)}
+
+// String is used both by fmt.Print and by Cobra in help text
+func (e *Layout) String() string {
+	return string(*e)
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (e *Layout) Set(v string) error {
+	switch v {
+	case string(LayoutType), string(LayoutPackage):
+		*e = Layout(v)
+		return nil
+	default:
+		return errors.New(`must be one of ` + strings.Join(ListLayouts, ","))
+	}
+}
+
+// Type is only used in help text
+func (e *Layout) Type() string {
+	return "layout"
+}
@@ -0,0 +1,41 @@
+package mdcmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/spf13/cobra"
+)
+
+func New(ctx context.Context) *cobra.Command {
+	opts := command.GenOptions{}
+	cmd := &cobra.Command{
+		Use:   "md",
+		Short: "generate markdown reference with attribute tables of types per package",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	command.AddGenFlags(cmd, &opts, "gen/md")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts command.GenOptions) error {
+	slog.Info("Generating Markdown reference")
+
+	return command.RunGen(baseDir, opts, func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error) {
+		return g.Markdown(entities)
+	})
+}
@@ -0,0 +1,56 @@
+package openapicmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/spf13/cobra"
+)
+
+type OpenAPIOptions struct {
+	command.GenOptions
+	Title      string
+	APIVersion string
+	Format     OutputFormat
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := OpenAPIOptions{Format: OutputFormatYAML}
+	cmd := &cobra.Command{
+		Use:   "openapi",
+		Short: "generate OpenAPI 3.1 document with cti types in components",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	command.AddGenFlags(cmd, &opts.GenOptions, "gen/openapi")
+	cmd.Flags().StringVar(&opts.Title, "title", "", "Title of the document.")
+	cmd.Flags().StringVar(&opts.APIVersion, "api-version", "1.0.0", "Version of the document.")
+	cmd.Flags().VarP(&opts.Format, "format", "f", `Output format. allowed: `+strings.Join(ListOutputFormats, ","))
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts OpenAPIOptions) error {
+	slog.Info("Generating OpenAPI document")
+
+	return command.RunGen(baseDir, opts.GenOptions, func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error) {
+		return g.OpenAPI(entities, codegen.OpenAPIOptions{
+			Title:   opts.Title,
+			Version: opts.APIVersion,
+			Format:  codegen.DocumentFormat(opts.Format),
+		})
+	})
+}
@@ -0,0 +1,78 @@
+package protocmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/spf13/cobra"
+)
+
+type ProtoOptions struct {
+	command.GenOptions
+	Package   string
+	GoPackage string
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := ProtoOptions{}
+	cmd := &cobra.Command{
+		Use:   "proto",
+		Short: "generate proto3 messages with field numbers kept across runs",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	command.AddGenFlags(cmd, &opts.GenOptions, "gen/proto")
+	cmd.Flags().StringVar(&opts.Package, "package", codegen.DefaultProtoPackage, "Name of the proto package.")
+	cmd.Flags().StringVar(&opts.GoPackage, "go-package", "", "Value of the go_package option.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts ProtoOptions) error {
+	slog.Info("Generating proto messages")
+
+	numbering, err := readNumbering(filepath.Join(opts.OutputDir(baseDir), codegen.ProtoLockFile))
+	if err != nil {
+		return err
+	}
+
+	return command.RunGen(baseDir, opts.GenOptions, func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error) {
+		return g.Proto(entities, codegen.ProtoOptions{
+			Package:   opts.Package,
+			GoPackage: opts.GoPackage,
+			Numbering: numbering,
+		})
+	})
+}
+
+// readNumbering reads field numbers assigned by previous runs. The lock file is missing on the first run.
+func readNumbering(fPath string) (*codegen.ProtoNumbering, error) {
+	data, err := os.ReadFile(fPath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("read lock file: %w", err)
+	}
+	var numbering codegen.ProtoNumbering
+	if err := json.Unmarshal(data, &numbering); err != nil {
+		return nil, fmt.Errorf("decode lock file %s: %w", fPath, err)
+	}
+	return &numbering, nil
+}
@@ -0,0 +1,47 @@
+package terraformcmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/spf13/cobra"
+)
+
+type TerraformOptions struct {
+	command.GenOptions
+	Package string
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := TerraformOptions{}
+	cmd := &cobra.Command{
+		Use:   "terraform",
+		Short: "generate terraform resource schema stubs and models of cti types",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	command.AddGenFlags(cmd, &opts.GenOptions, "gen/terraform")
+	cmd.Flags().StringVar(&opts.Package, "package", codegen.DefaultTerraformPackage, "Name of the generated package.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts TerraformOptions) error {
+	slog.Info("Generating Terraform schemas")
+
+	return command.RunGen(baseDir, opts.GenOptions, func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error) {
+		return g.Terraform(entities, codegen.TerraformOptions{Package: opts.Package})
+	})
+}
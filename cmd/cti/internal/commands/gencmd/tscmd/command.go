@@ -0,0 +1,47 @@
+package tscmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/codegen"
+	"github.com/spf13/cobra"
+)
+
+type TypeScriptOptions struct {
+	command.GenOptions
+	Zod bool
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := TypeScriptOptions{}
+	cmd := &cobra.Command{
+		Use:   "ts",
+		Short: "generate typescript interfaces and constants of cti identifiers",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	command.AddGenFlags(cmd, &opts.GenOptions, "gen/ts")
+	cmd.Flags().BoolVar(&opts.Zod, "zod", false, "Generate zod validators of the types.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts TypeScriptOptions) error {
+	slog.Info("Generating TypeScript types")
+
+	return command.RunGen(baseDir, opts.GenOptions, func(g *codegen.Generator, entities metadata.Entities) ([]codegen.File, error) {
+		return g.TypeScript(entities, codegen.TypeScriptOptions{Zod: opts.Zod})
+	})
+}
@@ -0,0 +1,23 @@
+package importcmd
+
+import (
+	"context"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/importcmd/jsonschemacmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/importcmd/openapicmd"
+	"github.com/acronis/go-cti/cmd/cti/internal/commands/importcmd/protocmd"
+	"github.com/spf13/cobra"
+)
+
+func New(ctx context.Context) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "import",
+		Short: "import type definitions of other formats as cti types",
+	}
+	cmd.AddCommand(
+		jsonschemacmd.New(ctx),
+		openapicmd.New(ctx),
+		protocmd.New(ctx),
+	)
+	return cmd
+}
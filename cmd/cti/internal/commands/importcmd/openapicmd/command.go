@@ -0,0 +1,40 @@
+package openapicmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata/importer"
+	"github.com/spf13/cobra"
+)
+
+func New(ctx context.Context) *cobra.Command {
+	opts := command.ImportOptions{}
+	cmd := &cobra.Command{
+		Use:   "openapi <file|dir>",
+		Short: "convert component schemas of OpenAPI 3.x documents into cti types",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, args[0], opts))
+		},
+	}
+
+	command.AddImportFlags(cmd, &opts)
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir, source string, opts command.ImportOptions) error {
+	slog.Info("Importing OpenAPI", slog.String("source", source))
+
+	return command.RunImport(baseDir, opts, func(imp *importer.Importer) ([]importer.File, error) {
+		return imp.ImportOpenAPI(source)
+	})
+}
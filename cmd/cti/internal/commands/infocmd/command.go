@@ -3,17 +3,156 @@ package infocmd
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata/ctipackage"
 
 	"github.com/spf13/cobra"
 )
 
-func New(_ context.Context) *cobra.Command {
-	return &cobra.Command{
-		Use:   "info",
+type InfoOptions struct {
+	Hierarchy string
+	Render    RenderFormat
+	Tree      bool
+	Remote    bool
+	Verify    bool
+	Format    OutputFormat
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := InfoOptions{Render: RenderFormatASCII, Format: OutputFormatText}
+	cmd := &cobra.Command{
+		Use:   "info [cti]",
 		Short: "print detailed information for cti package",
-		Args:  cobra.MinimumNArgs(0),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return errors.New("not implemented")
+		Long: `Print the package summary: identifier, RAMLx version, counts of files and entities, and dependencies
+with required and installed versions and integrity hashes. Use --format json or --format yaml for scripts.
+Pass a CTI to inspect the entity instead: its source, ancestors, annotations, flattened attributes with
+constraints, descendants and instances. Use --hierarchy to print the inheritance tree of a CTI type
+and --tree to print the tree of installed dependencies. Use --remote to compare the package with deployments
+of environments and dependencies with their latest published versions. Use --verify-deps to compare files
+of installed dependencies with the integrity recorded in the lock and with the cached packages, it fails
+if dependencies were modified locally, are stale, missing or not recorded in the lock.`,
+		Args: cobra.MaximumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			switch {
+			case len(args) == 1 && (opts.Hierarchy != "" || opts.Tree || opts.Remote || opts.Verify):
+				return command.WrapError(errors.New("--hierarchy, --tree, --remote and --verify-deps cannot be used with the CTI argument"))
+			case len(args) == 1:
+				return command.WrapError(executeEntity(ctx, baseDir, args[0], opts))
+			case opts.Hierarchy != "":
+				return command.WrapError(executeHierarchy(ctx, baseDir, opts))
+			case opts.Tree:
+				return command.WrapError(executeTree(ctx, baseDir, opts))
+			case opts.Remote:
+				pm, err := command.InitializePackageManager(cmd)
+				if err != nil {
+					return command.WrapError(fmt.Errorf("initialize package manager: %w", err))
+				}
+				return command.WrapError(executeRemote(ctx, baseDir, pm, opts))
+			case opts.Verify:
+				pm, err := command.InitializePackageManager(cmd)
+				if err != nil {
+					return command.WrapError(fmt.Errorf("initialize package manager: %w", err))
+				}
+				return command.WrapError(executeVerify(ctx, baseDir, pm, opts))
+			default:
+				return command.WrapError(execute(ctx, baseDir, opts))
+			}
 		},
 	}
+
+	cmd.Flags().StringVar(&opts.Hierarchy, "hierarchy", "", "Print ancestors and descendants of the specified CTI type.")
+	cmd.Flags().Var(&opts.Render, "render", `Hierarchy render format. allowed: `+strings.Join(ListRenderFormats, ","))
+	cmd.Flags().BoolVar(&opts.Tree, "tree", false, "Print the tree of installed dependencies, repeated subtrees are marked with (*).")
+	cmd.Flags().VarP(&opts.Format, "format", "f", `Output format of the summary, the entity, the tree, the comparison and the verification. allowed: `+strings.Join(ListOutputFormats, ","))
+	cmd.Flags().BoolVar(&opts.Remote, "remote", false, "Compare the package and its dependencies with published versions.")
+	cmd.Flags().BoolVar(&opts.Verify, "verify-deps", false, "Compare files of installed dependencies with the lock and the cache.")
+	cmd.MarkFlagsMutuallyExclusive("hierarchy", "tree", "remote", "verify-deps")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts InfoOptions) error {
+	slog.Debug("Summarize package", slog.String("path", baseDir))
+
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+	if err := pkg.Parse(); err != nil {
+		return fmt.Errorf("parse package: %w", err)
+	}
+
+	return writeSummary(os.Stdout, summarize(pkg), opts.Format)
+}
+
+func executeEntity(_ context.Context, baseDir string, id string, opts InfoOptions) error {
+	slog.Debug("Inspect entity", slog.String("path", baseDir), slog.String("cti", id))
+
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+	if err := pkg.Parse(); err != nil {
+		return fmt.Errorf("parse package: %w", err)
+	}
+
+	details, err := inspectEntity(pkg.Registry, id)
+	if err != nil {
+		return fmt.Errorf("inspect entity: %w", err)
+	}
+
+	return writeEntity(os.Stdout, details, opts.Format)
+}
+
+func executeTree(_ context.Context, baseDir string, opts InfoOptions) error {
+	slog.Debug("Build dependency tree", slog.String("path", baseDir))
+
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	// The tree is built from the index and the lock only, so it is printed even if dependencies are broken.
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+
+	return writeTree(os.Stdout, buildTree(pkg), opts.Format)
+}
+
+func executeHierarchy(_ context.Context, baseDir string, opts InfoOptions) error {
+	slog.Debug("Build type hierarchy", slog.String("path", baseDir), slog.String("cti", opts.Hierarchy))
+
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+	if err := pkg.Parse(); err != nil {
+		return fmt.Errorf("parse package: %w", err)
+	}
+
+	root, err := buildHierarchy(pkg.Registry, opts.Hierarchy)
+	if err != nil {
+		return fmt.Errorf("build hierarchy: %w", err)
+	}
+
+	return renderHierarchy(os.Stdout, root, opts.Render)
 }
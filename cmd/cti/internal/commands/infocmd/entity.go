@@ -0,0 +1,247 @@
+package infocmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/docgen"
+	"github.com/acronis/go-cti/metadata/registry"
+
+	"gopkg.in/yaml.v3"
+)
+
+// entityDetails is the inspection of a single CTI entity. Attributes and descendants are set for types,
+// values are set for instances.
+type entityDetails struct {
+	Cti         string              `json:"cti" yaml:"cti"`
+	Kind        string              `json:"kind" yaml:"kind"`
+	DisplayName string              `json:"display_name,omitempty" yaml:"display_name,omitempty"`
+	Description string              `json:"description,omitempty" yaml:"description,omitempty"`
+	Final       bool                `json:"final" yaml:"final"`
+	Source      string              `json:"source,omitempty" yaml:"source,omitempty"`
+	Ancestors   []string            `json:"ancestors" yaml:"ancestors"`
+	Annotations []annotationDetails `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Attributes  []attributeDetails  `json:"attributes,omitempty" yaml:"attributes,omitempty"`
+	Descendants []string            `json:"descendants,omitempty" yaml:"descendants,omitempty"`
+	Instances   []string            `json:"instances,omitempty" yaml:"instances,omitempty"`
+	Values      any                 `json:"values,omitempty" yaml:"values,omitempty"`
+}
+
+type annotationDetails struct {
+	Name  string `json:"name" yaml:"name"`
+	Value string `json:"value" yaml:"value"`
+}
+
+type attributeDetails struct {
+	Path        string              `json:"path" yaml:"path"`
+	Type        string              `json:"type" yaml:"type"`
+	Required    bool                `json:"required" yaml:"required"`
+	Description string              `json:"description,omitempty" yaml:"description,omitempty"`
+	Constraints []string            `json:"constraints,omitempty" yaml:"constraints,omitempty"`
+	Annotations []annotationDetails `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+}
+
+// inspectEntity collects details of the entity from the registry. Ancestors are listed from the root type,
+// descendants and instances include indirect ones.
+func inspectEntity(r *registry.Registry, id string) (*entityDetails, error) {
+	entity, ok := r.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("entity %s not found", id)
+	}
+
+	details := &entityDetails{
+		Cti:         entity.Cti,
+		Kind:        string(registry.KindOf(entity)),
+		DisplayName: entity.DisplayName,
+		Description: entity.Description,
+		Final:       entity.Final,
+		Source:      sourceOf(entity),
+		Ancestors:   []string{},
+	}
+	ancestors := r.Ancestors(id)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		details.Ancestors = append(details.Ancestors, ancestors[i].Cti)
+	}
+
+	if entity.IsInstance() {
+		if err := json.Unmarshal(entity.Values, &details.Values); err != nil {
+			return nil, fmt.Errorf("unmarshal values: %w", err)
+		}
+		details.Annotations = annotationsOf(entity.Annotations["."])
+		return details, nil
+	}
+
+	gen, err := docgen.New(r)
+	if err != nil {
+		return nil, fmt.Errorf("new describer: %w", err)
+	}
+	page, err := gen.Describe(id)
+	if err != nil {
+		return nil, fmt.Errorf("describe type: %w", err)
+	}
+	details.Annotations = convertAnnotations(page.Annotations)
+	for _, attr := range page.Attributes {
+		details.Attributes = append(details.Attributes, attributeDetails{
+			Path:        attr.Path,
+			Type:        attr.Type,
+			Required:    attr.Required,
+			Description: attr.Description,
+			Constraints: attr.Constraints,
+			Annotations: convertAnnotations(attr.Annotations),
+		})
+	}
+	for _, descendant := range r.Descendants(id) {
+		if descendant.IsInstance() {
+			details.Instances = append(details.Instances, descendant.Cti)
+		} else {
+			details.Descendants = append(details.Descendants, descendant.Cti)
+		}
+	}
+	return details, nil
+}
+
+// sourceOf returns the file relative to the package directory and the line where the entity is defined, empty if
+// it is unknown, e.g. for entities of dependencies that are installed without source maps.
+func sourceOf(entity *metadata.Entity) string {
+	if !entity.SourceMap.HasOriginalPath() {
+		return ""
+	}
+	file := ctipackage.SourcePath(entity.SourceMap.OriginalPath)
+	if entity.SourceMap.Line > 0 {
+		return fmt.Sprintf("%s:%d", file, entity.SourceMap.Line)
+	}
+	return file
+}
+
+func convertAnnotations(docs []docgen.AnnotationDoc) []annotationDetails {
+	res := make([]annotationDetails, 0, len(docs))
+	for _, doc := range docs {
+		res = append(res, annotationDetails{Name: doc.Name, Value: doc.Value})
+	}
+	return res
+}
+
+// annotationsOf returns values of the instance annotations sorted by name, the CTI of the instance is omitted.
+func annotationsOf(a metadata.Annotations) []annotationDetails {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil
+	}
+	delete(values, metadata.Cti)
+	res := make([]annotationDetails, 0, len(values))
+	for name, val := range values {
+		if s, ok := val.(string); ok {
+			res = append(res, annotationDetails{Name: name, Value: s})
+			continue
+		}
+		data, _ := json.Marshal(val)
+		res = append(res, annotationDetails{Name: name, Value: string(data)})
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Name < res[j].Name })
+	return res
+}
+
+func writeEntity(w io.Writer, d *entityDetails, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(d)
+	case OutputFormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(d); err != nil {
+			return err
+		}
+		return enc.Close()
+	case OutputFormatText:
+		fallthrough
+	default:
+		return writeEntityText(w, d)
+	}
+}
+
+func writeEntityText(w io.Writer, d *entityDetails) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "CTI:\t%s\n", d.Cti)
+	fmt.Fprintf(tw, "Kind:\t%s\n", d.Kind)
+	if d.DisplayName != "" {
+		fmt.Fprintf(tw, "Display name:\t%s\n", d.DisplayName)
+	}
+	if d.Description != "" {
+		fmt.Fprintf(tw, "Description:\t%s\n", d.Description)
+	}
+	fmt.Fprintf(tw, "Final:\t%t\n", d.Final)
+	if d.Source != "" {
+		fmt.Fprintf(tw, "Source:\t%s\n", d.Source)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	writeList(w, "Ancestors", d.Ancestors)
+
+	fmt.Fprintln(w, "\nAnnotations:")
+	if len(d.Annotations) == 0 {
+		fmt.Fprintln(w, "  (none)")
+	}
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, a := range d.Annotations {
+		fmt.Fprintf(tw, "  %s\t%s\n", a.Name, a.Value)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if d.Kind == string(registry.KindInstance) {
+		data, err := json.MarshalIndent(d.Values, "", "  ")
+		if err != nil {
+			return fmt.Errorf("marshal values: %w", err)
+		}
+		_, err = fmt.Fprintf(w, "\nValues:\n%s\n", data)
+		return err
+	}
+
+	fmt.Fprintln(w, "\nAttributes:")
+	if len(d.Attributes) == 0 {
+		fmt.Fprintln(w, "  (none)")
+	} else {
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "  PATH\tTYPE\tREQUIRED\tCONSTRAINTS\tANNOTATIONS")
+		for _, attr := range d.Attributes {
+			annotations := make([]string, 0, len(attr.Annotations))
+			for _, a := range attr.Annotations {
+				annotations = append(annotations, a.Name+"="+a.Value)
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%t\t%s\t%s\n", attr.Path, attr.Type, attr.Required,
+				strings.Join(attr.Constraints, "; "), strings.Join(annotations, "; "))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	writeList(w, "Descendants", d.Descendants)
+	writeList(w, "Instances", d.Instances)
+	return nil
+}
+
+func writeList(w io.Writer, title string, items []string) {
+	fmt.Fprintf(w, "\n%s:\n", title)
+	if len(items) == 0 {
+		fmt.Fprintln(w, "  (none)")
+	}
+	for _, item := range items {
+		fmt.Fprintf(w, "  %s\n", item)
+	}
+}
@@ -0,0 +1,193 @@
+package infocmd
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+type RenderFormat string
+
+const (
+	RenderFormatASCII   RenderFormat = "ascii"
+	RenderFormatDOT     RenderFormat = "dot"
+	RenderFormatMermaid RenderFormat = "mermaid"
+)
+
+var ListRenderFormats = []string{string(RenderFormatASCII), string(RenderFormatDOT), string(RenderFormatMermaid)}
+
+// String is used both by fmt.Print and by Cobra in help text
+func (e *RenderFormat) String() string {
+	return string(*e)
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (e *RenderFormat) Set(v string) error {
+	switch v {
+	case string(RenderFormatASCII), string(RenderFormatDOT), string(RenderFormatMermaid):
+		*e = RenderFormat(v)
+		return nil
+	default:
+		return errors.New(`must be one of ` + strings.Join(ListRenderFormats, ","))
+	}
+}
+
+// Type is only used in help text
+func (e *RenderFormat) Type() string {
+	return "renderFormat"
+}
+
+type hierarchyNode struct {
+	Entity   *metadata.Entity
+	Target   bool
+	Children []*hierarchyNode
+}
+
+// buildHierarchy builds a tree that starts from the root ancestor of the target,
+// follows the inheritance chain down to the target and includes all target descendants.
+func buildHierarchy(r *registry.Registry, id string) (*hierarchyNode, error) {
+	entity, ok := r.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("entity %s not found", id)
+	}
+
+	node := &hierarchyNode{Entity: entity, Target: true, Children: buildDescendants(r, id)}
+	for _, ancestor := range r.Ancestors(id) {
+		node = &hierarchyNode{Entity: ancestor, Children: []*hierarchyNode{node}}
+	}
+	return node, nil
+}
+
+func buildDescendants(r *registry.Registry, id string) []*hierarchyNode {
+	var res []*hierarchyNode
+	for _, child := range r.Children(id) {
+		res = append(res, &hierarchyNode{Entity: child, Children: buildDescendants(r, child.Cti)})
+	}
+	return res
+}
+
+func (n *hierarchyNode) label() string {
+	var sb strings.Builder
+	sb.WriteString(n.Entity.Cti)
+	if n.Entity.Values != nil {
+		sb.WriteString(" (instance)")
+	}
+	if n.Target {
+		sb.WriteString(" *")
+	}
+	return sb.String()
+}
+
+func (n *hierarchyNode) walk(fn func(parent, child *hierarchyNode)) {
+	for _, child := range n.Children {
+		fn(n, child)
+		child.walk(fn)
+	}
+}
+
+func renderHierarchy(w io.Writer, root *hierarchyNode, format RenderFormat) error {
+	switch format {
+	case RenderFormatDOT:
+		return renderDOT(w, root)
+	case RenderFormatMermaid:
+		return renderMermaid(w, root)
+	case RenderFormatASCII:
+		fallthrough
+	default:
+		return renderASCII(w, root)
+	}
+}
+
+func renderASCII(w io.Writer, root *hierarchyNode) error {
+	var sb strings.Builder
+	sb.WriteString(root.label())
+	sb.WriteByte('\n')
+	writeASCIIChildren(&sb, root, "")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeASCIIChildren(sb *strings.Builder, n *hierarchyNode, prefix string) {
+	for i, child := range n.Children {
+		connector, indent := "├── ", "│   "
+		if i == len(n.Children)-1 {
+			connector, indent = "└── ", "    "
+		}
+		sb.WriteString(prefix + connector + child.label() + "\n")
+		writeASCIIChildren(sb, child, prefix+indent)
+	}
+}
+
+func renderDOT(w io.Writer, root *hierarchyNode) error {
+	var sb strings.Builder
+	sb.WriteString("digraph hierarchy {\n  rankdir=TB;\n  node [shape=box];\n")
+	writeDOTNode(&sb, root)
+	root.walk(func(_, child *hierarchyNode) {
+		writeDOTNode(&sb, child)
+	})
+	root.walk(func(parent, child *hierarchyNode) {
+		fmt.Fprintf(&sb, "  %q -> %q;\n", parent.Entity.Cti, child.Entity.Cti)
+	})
+	sb.WriteString("}\n")
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
+
+func writeDOTNode(sb *strings.Builder, n *hierarchyNode) {
+	var attrs []string
+	if n.Entity.Values != nil {
+		attrs = append(attrs, "shape=ellipse")
+	}
+	if n.Target {
+		attrs = append(attrs, "style=bold")
+	}
+	if len(attrs) == 0 {
+		fmt.Fprintf(sb, "  %q;\n", n.Entity.Cti)
+		return
+	}
+	fmt.Fprintf(sb, "  %q [%s];\n", n.Entity.Cti, strings.Join(attrs, ","))
+}
+
+func renderMermaid(w io.Writer, root *hierarchyNode) error {
+	ids := map[*hierarchyNode]string{}
+	nodeID := func(n *hierarchyNode) string {
+		if id, ok := ids[n]; ok {
+			return id
+		}
+		id := fmt.Sprintf("n%d", len(ids))
+		ids[n] = id
+		return id
+	}
+	nodeDef := func(n *hierarchyNode) string {
+		label := strings.ReplaceAll(n.Entity.Cti, `"`, "#quot;")
+		if n.Entity.Values != nil {
+			return fmt.Sprintf(`%s(["%s"])`, nodeID(n), label)
+		}
+		return fmt.Sprintf(`%s["%s"]`, nodeID(n), label)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("graph TD\n")
+	if len(root.Children) == 0 {
+		fmt.Fprintf(&sb, "  %s\n", nodeDef(root))
+	}
+	var target *hierarchyNode
+	if root.Target {
+		target = root
+	}
+	root.walk(func(parent, child *hierarchyNode) {
+		fmt.Fprintf(&sb, "  %s --> %s\n", nodeDef(parent), nodeDef(child))
+		if child.Target {
+			target = child
+		}
+	})
+	if target != nil {
+		fmt.Fprintf(&sb, "  style %s stroke-width:3px\n", nodeID(target))
+	}
+	_, err := io.WriteString(w, sb.String())
+	return err
+}
@@ -0,0 +1,222 @@
+package infocmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/deploy"
+	"github.com/acronis/go-cti/metadata/pacman"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Statuses of the local bundle compared with the active deployment of an environment.
+const (
+	bundleUpToDate = "up-to-date"
+	// bundleOutdated is reported if the local bundle was deployed before the active deployment.
+	bundleOutdated = "outdated"
+	// bundleModified is reported if the local bundle was never deployed to the environment.
+	bundleModified    = "modified"
+	bundleNotDeployed = "not-deployed"
+	bundleUnknown     = "unknown"
+)
+
+// remoteReport compares the local bundle with deployments of environments and installed dependencies with
+// versions published by their storages.
+type remoteReport struct {
+	PackageID    string                    `json:"package_id" yaml:"package_id"`
+	Digest       string                    `json:"digest,omitempty" yaml:"digest,omitempty"`
+	Environments []bundleStatus            `json:"environments" yaml:"environments"`
+	Dependencies []pacman.DependencyStatus `json:"dependencies" yaml:"dependencies"`
+}
+
+type bundleStatus struct {
+	Env      string `json:"env" yaml:"env"`
+	Deployed string `json:"deployed,omitempty" yaml:"deployed,omitempty"`
+	Status   string `json:"status" yaml:"status"`
+	Error    string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+func executeRemote(ctx context.Context, baseDir string, pm pacman.PackageManager, opts InfoOptions) error {
+	slog.Debug("Compare package with remotes", slog.String("path", baseDir))
+
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+
+	report := remoteReport{PackageID: pkg.Index.PackageID}
+	if report.Dependencies, err = pm.Status(pkg); err != nil {
+		return fmt.Errorf("compare dependencies: %w", err)
+	}
+	if len(cfg.Environments) != 0 {
+		if report.Digest, err = bundleDigest(baseDir); err != nil {
+			slog.Warn("Package is not packed, it is not compared with deployments", slog.String("error", err.Error()))
+		}
+		report.Environments = compareDeployments(ctx, cfg, pkg.Index.PackageID, report.Digest)
+	}
+
+	return writeRemote(os.Stdout, report, opts.Format)
+}
+
+// bundleDigest packs the package and returns the digest of contents of the bundle, see deploy.Artifact.
+func bundleDigest(baseDir string) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "cti-info-")
+	if err != nil {
+		return "", fmt.Errorf("create temporary directory: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	archive, err := command.PackBundle(baseDir, tmpDir)
+	if err != nil {
+		return "", err
+	}
+	artifact, err := deploy.OpenArtifact(archive, "")
+	if err != nil {
+		return "", fmt.Errorf("open artifact: %w", err)
+	}
+	return artifact.Digest, nil
+}
+
+// compareDeployments compares the digest of the local bundle with active deployments of environments sorted by
+// names. Environments that are not available are reported with errors.
+func compareDeployments(ctx context.Context, cfg *cti.Config, packageID, digest string) []bundleStatus {
+	names := make([]string, 0, len(cfg.Environments))
+	for name := range cfg.Environments {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	res := make([]bundleStatus, 0, len(names))
+	for _, name := range names {
+		s, err := compareDeployment(ctx, name, cfg.Environments[name], packageID, digest)
+		if err != nil {
+			s = bundleStatus{Env: name, Status: bundleUnknown, Error: err.Error()}
+		}
+		res = append(res, s)
+	}
+	return res
+}
+
+func compareDeployment(ctx context.Context, name string, env cti.Environment, packageID, digest string) (bundleStatus, error) {
+	target, err := command.DeployTarget(ctx, name, env)
+	if err != nil {
+		return bundleStatus{}, err
+	}
+	current, err := target.Current(ctx, packageID)
+	if err != nil {
+		return bundleStatus{}, fmt.Errorf("get current deployment: %w", err)
+	}
+	s := bundleStatus{Env: name, Status: bundleNotDeployed}
+	if current == nil {
+		return s, nil
+	}
+	s.Deployed = current.Version
+	switch {
+	case digest == "":
+		s.Status = bundleUnknown
+	case current.Digest == digest:
+		s.Status = bundleUpToDate
+	default:
+		history, err := target.History(ctx, packageID)
+		if err != nil {
+			return bundleStatus{}, fmt.Errorf("get history: %w", err)
+		}
+		s.Status = bundleModified
+		for _, d := range history {
+			if d.Digest == digest {
+				s.Status = bundleOutdated
+				break
+			}
+		}
+	}
+	return s, nil
+}
+
+func writeRemote(w io.Writer, r remoteReport, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(r)
+	case OutputFormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(r); err != nil {
+			return err
+		}
+		return enc.Close()
+	case OutputFormatText:
+		fallthrough
+	default:
+		return writeRemoteText(w, r)
+	}
+}
+
+func writeRemoteText(w io.Writer, r remoteReport) error {
+	if len(r.Environments) != 0 {
+		fmt.Fprintf(w, "Package %s", r.PackageID)
+		if r.Digest != "" {
+			fmt.Fprintf(w, " (%s)", deploy.ShortDigest(r.Digest))
+		}
+		fmt.Fprintln(w)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "ENV\tDEPLOYED\tSTATUS")
+		for _, e := range r.Environments {
+			fmt.Fprintf(tw, "%s\t%s\t%s\n", e.Env, valueOr(e.Deployed, "-"), withError(e.Status, e.Error))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(r.Dependencies) == 0 {
+		_, err := fmt.Fprintln(w, "No dependencies.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tVERSION\tLATEST\tSTATUS")
+	for _, dep := range r.Dependencies {
+		status := string(dep.Status)
+		if dep.Modified {
+			status += ", modified"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", dep.Source, valueOr(dep.Version, "-"), valueOr(dep.Latest, "-"),
+			withError(status, dep.Error))
+	}
+	return tw.Flush()
+}
+
+func valueOr(value, fallback string) string {
+	if value == "" {
+		return fallback
+	}
+	return value
+}
+
+// withError appends the first line of the error to the status.
+func withError(status, err string) string {
+	if err == "" {
+		return status
+	}
+	line, _, _ := strings.Cut(err, "\n")
+	return status + " (" + line + ")"
+}
@@ -0,0 +1,172 @@
+package infocmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/acronis/go-cti/metadata/collector"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+
+	"gopkg.in/yaml.v3"
+)
+
+// packageSummary is the machine-readable description of the package, its dependencies and entities.
+type packageSummary struct {
+	PackageID    string              `json:"package_id" yaml:"package_id"`
+	RamlxVersion string              `json:"ramlx_version,omitempty" yaml:"ramlx_version,omitempty"`
+	Path         string              `json:"path" yaml:"path"`
+	Files        fileCounts          `json:"files" yaml:"files"`
+	Entities     entitySummary       `json:"entities" yaml:"entities"`
+	Dependencies []dependencySummary `json:"dependencies" yaml:"dependencies"`
+}
+
+type fileCounts struct {
+	Apis         int `json:"apis" yaml:"apis"`
+	Entities     int `json:"entities" yaml:"entities"`
+	Assets       int `json:"assets" yaml:"assets"`
+	Dictionaries int `json:"dictionaries" yaml:"dictionaries"`
+	Examples     int `json:"examples" yaml:"examples"`
+}
+
+type entityCounts struct {
+	Types     int `json:"types" yaml:"types"`
+	Instances int `json:"instances" yaml:"instances"`
+}
+
+type entitySummary struct {
+	// Local are entities declared by the package itself.
+	Local entityCounts `json:"local" yaml:"local"`
+	// Dependencies are entities declared by installed dependencies.
+	Dependencies entityCounts `json:"dependencies" yaml:"dependencies"`
+}
+
+// dependencySummary joins the dependency required by the index with the installed one recorded in the lock.
+// Transitive dependencies are not required by the index, direct ones may be not installed yet.
+type dependencySummary struct {
+	Source          string `json:"source" yaml:"source"`
+	Direct          bool   `json:"direct" yaml:"direct"`
+	Required        string `json:"required,omitempty" yaml:"required,omitempty"`
+	PackageID       string `json:"package_id,omitempty" yaml:"package_id,omitempty"`
+	Version         string `json:"version,omitempty" yaml:"version,omitempty"`
+	Integrity       string `json:"integrity,omitempty" yaml:"integrity,omitempty"`
+	SourceIntegrity string `json:"source_integrity,omitempty" yaml:"source_integrity,omitempty"`
+	Replace         string `json:"replace,omitempty" yaml:"replace,omitempty"`
+}
+
+func summarize(pkg *ctipackage.Package) packageSummary {
+	s := packageSummary{
+		PackageID:    pkg.Index.PackageID,
+		RamlxVersion: pkg.Index.RamlxVersion,
+		Path:         pkg.BaseDir,
+		Files: fileCounts{
+			Apis:         len(pkg.Index.Apis),
+			Entities:     len(pkg.Index.Entities),
+			Assets:       len(pkg.Index.Assets),
+			Dictionaries: len(pkg.Index.Dictionaries),
+			Examples:     len(pkg.Index.Examples),
+		},
+		Entities:     summarizeEntities(pkg.LocalRegistry, pkg.GlobalRegistry),
+		Dependencies: []dependencySummary{},
+	}
+
+	deps := map[string]*dependencySummary{}
+	for source, version := range pkg.Index.Depends {
+		deps[source] = &dependencySummary{Source: source, Direct: true, Required: version}
+	}
+	for source, info := range pkg.IndexLock.SourceInfo {
+		dep, ok := deps[source]
+		if !ok {
+			dep = &dependencySummary{Source: source}
+			deps[source] = dep
+		}
+		dep.PackageID = info.PackageID
+		dep.Version = info.Version
+		dep.Integrity = info.Integrity
+		dep.SourceIntegrity = info.SourceIntegrity
+		dep.Replace = info.Replace
+	}
+	for _, dep := range deps {
+		s.Dependencies = append(s.Dependencies, *dep)
+	}
+	sort.Slice(s.Dependencies, func(i, j int) bool {
+		return s.Dependencies[i].Source < s.Dependencies[j].Source
+	})
+	return s
+}
+
+// summarizeEntities counts entities of the package, the global registry includes both local entities and entities
+// of dependencies.
+func summarizeEntities(local, global *collector.MetadataRegistry) entitySummary {
+	var s entitySummary
+	if local != nil {
+		s.Local = entityCounts{Types: len(local.Types), Instances: len(local.Instances)}
+	}
+	if global != nil {
+		s.Dependencies = entityCounts{
+			Types:     len(global.Types) - s.Local.Types,
+			Instances: len(global.Instances) - s.Local.Instances,
+		}
+	}
+	return s
+}
+
+func writeSummary(w io.Writer, s packageSummary, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(s)
+	case OutputFormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(s); err != nil {
+			return err
+		}
+		return enc.Close()
+	case OutputFormatText:
+		fallthrough
+	default:
+		return writeText(w, s)
+	}
+}
+
+func writeText(w io.Writer, s packageSummary) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Package:\t%s\n", s.PackageID)
+	if s.RamlxVersion != "" {
+		fmt.Fprintf(tw, "RAMLx version:\t%s\n", s.RamlxVersion)
+	}
+	fmt.Fprintf(tw, "Path:\t%s\n", s.Path)
+	fmt.Fprintf(tw, "Files:\t%d apis, %d entities, %d assets, %d dictionaries, %d examples\n",
+		s.Files.Apis, s.Files.Entities, s.Files.Assets, s.Files.Dictionaries, s.Files.Examples)
+	fmt.Fprintf(tw, "Entities:\t%d types, %d instances\n", s.Entities.Local.Types, s.Entities.Local.Instances)
+	fmt.Fprintf(tw, "Dependency entities:\t%d types, %d instances\n",
+		s.Entities.Dependencies.Types, s.Entities.Dependencies.Instances)
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if len(s.Dependencies) == 0 {
+		_, err := fmt.Fprintln(w, "\nNo dependencies.")
+		return err
+	}
+
+	fmt.Fprintln(w)
+	tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tREQUIRED\tVERSION\tPACKAGE\tINTEGRITY\tREPLACE")
+	for _, dep := range s.Dependencies {
+		required := dep.Required
+		if !dep.Direct {
+			required = "(transitive)"
+		}
+		version := dep.Version
+		if version == "" {
+			version = "(not installed)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			dep.Source, required, version, dep.PackageID, dep.Integrity, dep.Replace)
+	}
+	return tw.Flush()
+}
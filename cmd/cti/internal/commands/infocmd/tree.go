@@ -0,0 +1,127 @@
+package infocmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+
+	"gopkg.in/yaml.v3"
+)
+
+// dependencyNode is a node of the resolved dependency closure. The subtree of a dependency that is required
+// by several packages is expanded once, later occurrences are marked as repeated and have no children.
+type dependencyNode struct {
+	Source    string `json:"source" yaml:"source"`
+	PackageID string `json:"package_id,omitempty" yaml:"package_id,omitempty"`
+	// Required is the version required by the parent, Version is the installed one, they differ if a higher
+	// version is required by another package.
+	Required string `json:"required,omitempty" yaml:"required,omitempty"`
+	Version  string `json:"version,omitempty" yaml:"version,omitempty"`
+	// Replace is the local directory the dependency is installed from, see Index.Replace.
+	Replace  string            `json:"replace,omitempty" yaml:"replace,omitempty"`
+	Repeated bool              `json:"repeated,omitempty" yaml:"repeated,omitempty"`
+	Children []*dependencyNode `json:"dependencies,omitempty" yaml:"dependencies,omitempty"`
+}
+
+// buildTree builds the dependency tree of the package from the index and the lock, dependencies are sorted
+// by source.
+func buildTree(pkg *ctipackage.Package) *dependencyNode {
+	root := &dependencyNode{Source: pkg.Index.PackageID, PackageID: pkg.Index.PackageID}
+	expanded := map[string]bool{}
+	root.Children = buildChildren(pkg.IndexLock, pkg.Index.Depends, expanded)
+	return root
+}
+
+func buildChildren(lock *ctipackage.IndexLock, depends map[string]string, expanded map[string]bool) []*dependencyNode {
+	sources := make([]string, 0, len(depends))
+	for source := range depends {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	res := make([]*dependencyNode, 0, len(sources))
+	for _, source := range sources {
+		node := &dependencyNode{Source: source, Required: depends[source]}
+		res = append(res, node)
+		info, ok := lock.SourceInfo[source]
+		if !ok {
+			continue
+		}
+		node.PackageID = info.PackageID
+		node.Version = info.Version
+		node.Replace = info.Replace
+		if len(info.Depends) == 0 {
+			continue
+		}
+		// Marking the source before descending also stops at cycles.
+		if expanded[source] {
+			node.Repeated = true
+			continue
+		}
+		expanded[source] = true
+		node.Children = buildChildren(lock, info.Depends, expanded)
+	}
+	return res
+}
+
+func (n *dependencyNode) label() string {
+	var sb strings.Builder
+	sb.WriteString(n.Source)
+	switch {
+	case n.Version == "":
+		fmt.Fprintf(&sb, " %s (not installed)", n.Required)
+	case n.Required != "" && n.Required != n.Version:
+		fmt.Fprintf(&sb, " %s => %s", n.Required, n.Version)
+	case n.Version != "":
+		sb.WriteString(" " + n.Version)
+	}
+	if n.PackageID != "" && n.PackageID != n.Source {
+		fmt.Fprintf(&sb, " [%s]", n.PackageID)
+	}
+	if n.Replace != "" {
+		sb.WriteString(" from " + n.Replace)
+	}
+	if n.Repeated {
+		sb.WriteString(" (*)")
+	}
+	return sb.String()
+}
+
+func writeTree(w io.Writer, root *dependencyNode, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(root)
+	case OutputFormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(root); err != nil {
+			return err
+		}
+		return enc.Close()
+	case OutputFormatText:
+		fallthrough
+	default:
+		var sb strings.Builder
+		sb.WriteString(root.Source + "\n")
+		writeTreeChildren(&sb, root, "")
+		_, err := io.WriteString(w, sb.String())
+		return err
+	}
+}
+
+func writeTreeChildren(sb *strings.Builder, n *dependencyNode, prefix string) {
+	for i, child := range n.Children {
+		connector, indent := "├── ", "│   "
+		if i == len(n.Children)-1 {
+			connector, indent = "└── ", "    "
+		}
+		sb.WriteString(prefix + connector + child.label() + "\n")
+		writeTreeChildren(sb, child, prefix+indent)
+	}
+}
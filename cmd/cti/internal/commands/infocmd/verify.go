@@ -0,0 +1,103 @@
+package infocmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"text/tabwriter"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/pacman"
+
+	"gopkg.in/yaml.v3"
+)
+
+// executeVerify reports installed dependencies whose files drifted from the lock, and fails if there are any.
+func executeVerify(_ context.Context, baseDir string, pm pacman.PackageManager, opts InfoOptions) error {
+	slog.Debug("Verify installed dependencies", slog.String("path", baseDir))
+
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+
+	drifts, err := pm.Verify(pkg)
+	if err != nil {
+		return fmt.Errorf("verify dependencies: %w", err)
+	}
+	if err := writeVerify(os.Stdout, drifts, opts.Format); err != nil {
+		return err
+	}
+
+	drifted := 0
+	for _, d := range drifts {
+		if d.Status != pacman.DriftNone {
+			drifted++
+		}
+	}
+	if drifted != 0 {
+		return fmt.Errorf("%d of %d installed dependencies do not match the lock", drifted, len(drifts))
+	}
+	return nil
+}
+
+func writeVerify(w io.Writer, drifts []pacman.DependencyDrift, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(drifts)
+	case OutputFormatYAML:
+		enc := yaml.NewEncoder(w)
+		enc.SetIndent(2)
+		if err := enc.Encode(drifts); err != nil {
+			return err
+		}
+		return enc.Close()
+	case OutputFormatText:
+		fallthrough
+	default:
+		return writeVerifyText(w, drifts)
+	}
+}
+
+// writeVerifyText prints statuses of installed dependencies followed by changed files of modified ones.
+func writeVerifyText(w io.Writer, drifts []pacman.DependencyDrift) error {
+	if len(drifts) == 0 {
+		_, err := fmt.Fprintln(w, "No dependencies.")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PACKAGE\tSOURCE\tVERSION\tSTATUS")
+	for _, d := range drifts {
+		status := string(d.Status)
+		if d.Status == pacman.DriftModified && d.Files == nil {
+			status += " (package is not in the cache, files are not compared)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", d.PackageID, valueOr(d.Source, "-"), valueOr(d.Version, "-"), status)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, d := range drifts {
+		if len(d.Files) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", d.PackageID)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, f := range d.Files {
+			fmt.Fprintf(tw, "  %s\t%s\n", f.Change, f.Path)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
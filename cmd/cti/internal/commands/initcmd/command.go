@@ -2,47 +2,280 @@ package initcmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
 	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/scaffold"
+	"github.com/acronis/go-cti/metadata/validator"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"golang.org/x/term"
 )
 
+// ramlxVersion is the version of RAMLx of new packages.
+const ramlxVersion = "1.0"
+
+type InitOptions struct {
+	GenerateID string
+	// ID is the identifier of the new package in <vendor>.<package> form.
+	ID string
+	// Template scaffolds the package, see scaffold.Open.
+	Template string
+	// Version and License are written to the index, see ctipackage.Index.
+	Version string
+	License string
+	// Registry is the registry of the dev environment written to the configuration of the package.
+	Registry string
+	// Interactive prompts for options with the wizard, see runWizard.
+	Interactive bool
+	// FromRAML initializes the package from existing RAML and JSON Schema files, see migrate.Scan.
+	FromRAML bool
+	// Git sets up the git repository of the package, see setupGit. Defaults to init.git of the configuration.
+	Git *bool
+}
+
 func New(ctx context.Context) *cobra.Command {
-	return &cobra.Command{
+	opts := InitOptions{}
+	var git bool
+	cmd := &cobra.Command{
 		Use:   "init",
 		Short: "generate cti project with default dependencies",
-		Args:  cobra.MinimumNArgs(0),
+		Long: `Initialize a CTI package: write index.json and the .ramlx folder with CTI specification files for RAMLx.
+Use --template to scaffold the layout of directories, example entities, validation profiles and test cases:
+a built-in template (` + strings.Join(scaffold.Builtin(), ", ") + `), a template of the user templates
+directory (~/.cti/templates or init.templates_dir), a path of a directory or a git URL with an optional
+branch or tag after #. Templates require the package identifier, see --id.
+
+Use --from-raml to bootstrap the package of an existing repository of RAML and JSON Schema files: entities,
+APIs and examples are listed in the index, and items that need manual fixes are reported.
+
+Use --git, or set init.git in the configuration, to initialize the git repository of the package, ignore
+files written by cti and install the pre-commit hook running cti fmt --check and cti validate, the lint step
+of the package. For packages in existing repositories only .gitignore and the hook are written, also for
+initialized packages.
+
+Run without flags on a terminal, the command prompts for the vendor, the package name, the initial version,
+the license and the registry of the new package.`,
+		Args: cobra.MinimumNArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			baseDir, err := command.GetWorkingDir(cmd)
 			if err != nil {
 				return fmt.Errorf("get working directory: %w", err)
 			}
 
-			return command.WrapError(execute(ctx, baseDir))
+			if opts.GenerateID != "" {
+				return command.WrapError(executeGenerateID(ctx, baseDir, opts.GenerateID))
+			}
+			if cmd.Flags().Changed("git") {
+				opts.Git = &git
+			}
+			if opts.FromRAML {
+				return command.WrapError(executeFromRAML(ctx, baseDir, opts))
+			}
+			opts.Interactive = !flagsChanged(cmd) && term.IsTerminal(int(os.Stdin.Fd()))
+			return command.WrapError(execute(ctx, baseDir, opts))
 		},
 	}
+
+	cmd.Flags().StringVar(&opts.GenerateID, "generate-id", "",
+		"Print a new identifier derived from the specified CTI type that follows identifier rules of the package and its dependencies.")
+	cmd.Flags().StringVar(&opts.ID, "id", "", "Identifier of the package in <vendor>.<package> form.")
+	cmd.Flags().StringVarP(&opts.Template, "template", "t", "",
+		"Template of the package: "+strings.Join(scaffold.Builtin(), ", ")+", a name of a user template, a directory or a git URL.")
+	cmd.Flags().BoolVar(&opts.FromRAML, "from-raml", false,
+		"Initialize the package from existing RAML and JSON Schema files of the directory and report items that need manual fixes.")
+	cmd.Flags().BoolVar(&git, "git", false,
+		"Initialize the git repository with .gitignore and the pre-commit hook running cti fmt --check and cti validate.")
+	cmd.MarkFlagsMutuallyExclusive("from-raml", "template")
+	cmd.MarkFlagsMutuallyExclusive("git", "generate-id")
+	cmd.MarkFlagsMutuallyExclusive("from-raml", "generate-id")
+
+	return cmd
 }
 
-func execute(_ context.Context, baseDir string) error {
+func execute(_ context.Context, baseDir string, opts InitOptions) error {
+	if existing, err := ctipackage.New(baseDir); err != nil {
+		return fmt.Errorf("new package: %w", err)
+	} else if existing.Read() == nil {
+		if opts.Template != "" {
+			return errors.New("package is already initialized, templates apply to new packages only")
+		}
+		if opts.Git != nil && *opts.Git {
+			return setupGit(baseDir)
+		}
+		slog.Info("Package already initialized")
+		return nil
+	}
+	if opts.Interactive {
+		var err error
+		if opts, err = runWizard(os.Stdin, os.Stderr, baseDir, opts); err != nil {
+			return err
+		}
+	}
 	slog.Info("Initialize package", slog.String("path", baseDir))
 
-	pkg, err := ctipackage.New(baseDir)
+	var pkgOpts []ctipackage.InitializeOption
+	if opts.ID != "" {
+		pkgOpts = append(pkgOpts, ctipackage.WithID(opts.ID), ctipackage.WithRamlxVersion(ramlxVersion))
+	}
+	if opts.Version != "" {
+		pkgOpts = append(pkgOpts, ctipackage.WithVersion(opts.Version))
+	}
+	if opts.License != "" {
+		pkgOpts = append(pkgOpts, ctipackage.WithLicense(opts.License))
+	}
+	pkg, err := ctipackage.New(baseDir, pkgOpts...)
 	if err != nil {
 		return fmt.Errorf("new package: %w", err)
 	}
-	if pkg.Read() == nil {
-		slog.Info("Package already initialized")
-		return nil
+
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if opts.Template == "" {
+		opts.Template = cfg.Init.Template
+	}
+	if opts.Template != "" {
+		if err := applyTemplate(pkg, cfg, opts); err != nil {
+			return err
+		}
 	}
 
 	if err := pkg.Initialize(); err != nil {
 		return fmt.Errorf("initialize the package: %w", err)
 	}
+	if opts.Registry != "" {
+		if err := addEnvironment(baseDir, wizardEnv, opts.Registry); err != nil {
+			return err
+		}
+	}
+	if useGit(opts, cfg) {
+		if err := setupGit(baseDir); err != nil {
+			return err
+		}
+	}
 
 	slog.Info("Package was initialized")
 	return nil
 }
+
+// applyTemplate generates files of the template in the package directory and reads the index of the template.
+// Templates without index.json keep the index of the package.
+func applyTemplate(pkg *ctipackage.Package, cfg *cti.Config, opts InitOptions) error {
+	if opts.ID == "" {
+		return errors.New("package identifier is required by templates, use --id <vendor>.<package>")
+	}
+	userDir, err := cfg.Init.TemplatesDirPath()
+	if err != nil {
+		return err
+	}
+	tmpl, err := scaffold.Open(opts.Template, userDir)
+	if err != nil {
+		return err
+	}
+	defer tmpl.Close()
+
+	files, err := tmpl.Execute(pkg.BaseDir, scaffold.NewData(opts.ID, ramlxVersion))
+	if err != nil {
+		return err
+	}
+	slog.Info("Package was scaffolded", slog.String("template", opts.Template), slog.Any("files", files))
+
+	if !slices.Contains(files, ctipackage.IndexFileName) {
+		return nil
+	}
+	idx, err := ctipackage.ReadIndex(pkg.BaseDir)
+	if err != nil {
+		return fmt.Errorf("read index of the template: %w", err)
+	}
+	if idx.PackageID != opts.ID {
+		return fmt.Errorf("index of the template has package id %s instead of %s", idx.PackageID, opts.ID)
+	}
+	if idx.Version == "" {
+		idx.Version = pkg.Index.Version
+	}
+	if idx.License == "" {
+		idx.License = pkg.Index.License
+	}
+	pkg.Index = idx
+	return nil
+}
+
+// addEnvironment adds the environment of the registry to the configuration of the package, and makes it the
+// default environment unless another one is.
+func addEnvironment(baseDir, env, registry string) error {
+	f, err := cti.ReadConfigFile(filepath.Join(baseDir, cti.ConfigFileName))
+	if err != nil {
+		return err
+	}
+	if err := f.Set("environments."+env+".registry", registry); err != nil {
+		return err
+	}
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if cfg.DefaultEnv == "" {
+		if err := f.Set("default_env", env); err != nil {
+			return err
+		}
+	}
+	if err := f.Save(); err != nil {
+		return err
+	}
+	slog.Info("Environment was added", slog.String("env", env), slog.String("registry", registry))
+	return nil
+}
+
+// useGit reports whether to set up the git repository, --git takes precedence over init.git of the configuration.
+func useGit(opts InitOptions, cfg *cti.Config) bool {
+	if opts.Git != nil {
+		return *opts.Git
+	}
+	return cfg.Init.Git != nil && *cfg.Init.Git
+}
+
+// flagsChanged reports whether any flag of the command was set, inherited flags like --working-dir aside.
+func flagsChanged(cmd *cobra.Command) bool {
+	changed := false
+	cmd.LocalFlags().VisitAll(func(f *pflag.Flag) {
+		changed = changed || f.Changed
+	})
+	return changed
+}
+
+func executeGenerateID(_ context.Context, baseDir string, parent string) error {
+	slog.Debug("Generate identifier", slog.String("path", baseDir), slog.String("parent", parent))
+
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+	if err := pkg.Parse(); err != nil {
+		return fmt.Errorf("parse package: %w", err)
+	}
+	if _, ok := pkg.Registry.GetType(parent); !ok {
+		return fmt.Errorf("type %s not found", parent)
+	}
+
+	vendor, pkgName, _ := strings.Cut(pkg.Index.PackageID, ".")
+	id, err := validator.GenerateIdentifier(pkg.IdentifierRules, parent, vendor, pkgName)
+	if err != nil {
+		return err
+	}
+	fmt.Println(id)
+	return nil
+}
@@ -0,0 +1,115 @@
+package initcmd
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/packer"
+)
+
+// hookMarker marks pre-commit hooks written by cti init, other hooks are never overwritten.
+const hookMarker = "# Written by cti init --git."
+
+// gitignorePatterns ignore files that cti writes to the package directory and that are not committed.
+var gitignorePatterns = []struct {
+	comment string
+	pattern string
+}{
+	{"Dependencies installed by cti pkg get, restored from " + ctipackage.IndexLockFileName + ".", "/" + ctipackage.DependencyDirName + "/"},
+	{"Metadata cache of the parser.", ctipackage.MetadataCacheFile},
+	{"Bundles written by cti pack.", "*" + packer.ArchiveExtension},
+}
+
+// setupGit initializes the git repository of the package unless the package is in a repository already, ignores
+// files written by cti and installs the pre-commit hook that checks formatting and validates the package.
+func setupGit(baseDir string) error {
+	top, err := command.Git(baseDir, "rev-parse", "--show-toplevel")
+	if err != nil {
+		if _, err := command.Git(baseDir, "init", "--quiet"); err != nil {
+			return err
+		}
+		slog.Info("Git repository was initialized")
+		if top, err = command.Git(baseDir, "rev-parse", "--show-toplevel"); err != nil {
+			return err
+		}
+	}
+	if err := writeGitignore(baseDir); err != nil {
+		return err
+	}
+	return installHook(baseDir, strings.TrimSpace(top))
+}
+
+// writeGitignore writes .gitignore of the package, or appends patterns missing from the existing one.
+func writeGitignore(baseDir string) error {
+	p := filepath.Join(baseDir, ".gitignore")
+	data, err := os.ReadFile(p)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("read .gitignore: %w", err)
+	}
+	existing := map[string]bool{}
+	for _, line := range strings.Split(string(data), "\n") {
+		existing[strings.TrimSpace(line)] = true
+	}
+
+	var sb strings.Builder
+	for _, p := range gitignorePatterns {
+		if !existing[p.pattern] {
+			fmt.Fprintf(&sb, "# %s\n%s\n", p.comment, p.pattern)
+		}
+	}
+	if sb.Len() == 0 {
+		return nil
+	}
+	if len(data) != 0 && !strings.HasSuffix(string(data), "\n") {
+		data = append(data, '\n')
+	}
+	if err := os.WriteFile(p, append(data, sb.String()...), 0644); err != nil {
+		return fmt.Errorf("write .gitignore: %w", err)
+	}
+	slog.Info("Ignored files were added to .gitignore")
+	return nil
+}
+
+// installHook writes the pre-commit hook of the repository. Hooks run in the top-level directory of the
+// repository, so the hook changes to the package directory first.
+func installHook(baseDir, top string) error {
+	hooksDir, err := command.Git(baseDir, "rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return err
+	}
+	hooksDir = strings.TrimSpace(hooksDir)
+	if !filepath.IsAbs(hooksDir) {
+		hooksDir = filepath.Join(baseDir, hooksDir)
+	}
+	p := filepath.Join(hooksDir, "pre-commit")
+	if data, err := os.ReadFile(p); err == nil && !strings.Contains(string(data), hookMarker) {
+		slog.Warn("Pre-commit hook exists, add cti fmt --check and cti validate to it manually", slog.String("path", p))
+		return nil
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "#!/bin/sh\n%s It checks formatting and validates the CTI package before commits.\nset -e\n", hookMarker)
+	dir, err := filepath.EvalSymlinks(baseDir)
+	if err != nil {
+		return fmt.Errorf("resolve package directory: %w", err)
+	}
+	if rel, err := filepath.Rel(top, dir); err == nil && rel != "." {
+		fmt.Fprintf(&sb, "cd \"$(git rev-parse --show-toplevel)/%s\"\n", filepath.ToSlash(rel))
+	}
+	sb.WriteString("cti fmt --check\ncti validate\n")
+
+	if err := os.MkdirAll(hooksDir, 0755); err != nil {
+		return fmt.Errorf("create hooks directory: %w", err)
+	}
+	if err := os.WriteFile(p, []byte(sb.String()), 0755); err != nil { //nolint:gosec // hooks are executable
+		return fmt.Errorf("write pre-commit hook: %w", err)
+	}
+	slog.Info("Pre-commit hook was installed", slog.String("path", p))
+	return nil
+}
@@ -0,0 +1,68 @@
+package initcmd
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// buildCTI builds the cti binary to a temporary directory and prepends the directory to PATH, like hooks find it.
+func buildCTI(t *testing.T) {
+	t.Helper()
+	for _, name := range []string{"go", "git", "sh"} {
+		if _, err := exec.LookPath(name); err != nil {
+			t.Skipf("%s is not available", name)
+		}
+	}
+	binDir := t.TempDir()
+	out, err := exec.Command("go", "build", "-o", binDir, "github.com/acronis/go-cti/cmd/cti").CombinedOutput()
+	require.NoError(t, err, string(out))
+	t.Setenv("PATH", binDir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+func Test_PreCommitHook(t *testing.T) {
+	if testing.Short() {
+		t.Skip("builds the cti binary")
+	}
+	buildCTI(t)
+	t.Setenv("HOME", t.TempDir())
+
+	dir := t.TempDir()
+	git := true
+	require.NoError(t, execute(context.Background(), dir, InitOptions{ID: "acme.shop", Template: "service", Git: &git}))
+
+	hook := filepath.Join(dir, ".git", "hooks", "pre-commit")
+	data, err := os.ReadFile(hook)
+	require.NoError(t, err)
+	require.Contains(t, string(data), hookMarker)
+	require.Contains(t, string(data), "cti fmt --check\ncti validate\n")
+
+	runHook := func() (string, error) {
+		cmd := exec.Command("sh", hook)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		return string(out), err
+	}
+	out, err := runHook()
+	require.NoError(t, err, out)
+
+	entity := filepath.Join(dir, "entities", "shop.raml")
+	data, err = os.ReadFile(entity)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(entity, []byte(strings.Replace(string(data), "types:\n", "types:\n\n\n", 1)), 0600))
+	out, err = runHook()
+	require.Error(t, err)
+	require.Contains(t, out, "not formatted")
+
+	// The name of the resource violates the pattern of names, the file is formatted.
+	require.NoError(t, os.WriteFile(entity, []byte(strings.Replace(string(data), "name: default", "name: Default", 1)), 0600))
+	out, err = runHook()
+	require.Error(t, err)
+	require.Contains(t, out, "All files are formatted")
+	require.Contains(t, out, "Validating package")
+}
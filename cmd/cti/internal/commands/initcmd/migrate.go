@@ -0,0 +1,80 @@
+package initcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/migrate"
+)
+
+// executeFromRAML initializes the package from existing RAML and JSON Schema files of the directory and prints the
+// report of items that need manual fixes.
+func executeFromRAML(_ context.Context, baseDir string, opts InitOptions) error {
+	slog.Info("Initialize package from RAML", slog.String("path", baseDir))
+
+	if existing, err := ctipackage.New(baseDir); err != nil {
+		return fmt.Errorf("new package: %w", err)
+	} else if existing.Read() == nil {
+		return errors.New("package is already initialized")
+	}
+
+	var scanOpts []migrate.Option
+	if opts.ID != "" {
+		scanOpts = append(scanOpts, migrate.WithPackageID(opts.ID))
+	}
+	res, err := migrate.Scan(baseDir, scanOpts...)
+	if err != nil {
+		if opts.ID == "" {
+			return fmt.Errorf("%w, use --id <vendor>.<package>", err)
+		}
+		return err
+	}
+
+	pkg, err := ctipackage.New(baseDir, ctipackage.WithID(res.PackageID), ctipackage.WithRamlxVersion(ramlxVersion),
+		ctipackage.WithEntities(res.Entities))
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	pkg.Index.Apis = res.Apis
+	pkg.Index.Examples = res.Examples
+	if err := pkg.Initialize(); err != nil {
+		return fmt.Errorf("initialize the package: %w", err)
+	}
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	if useGit(opts, cfg) {
+		if err := setupGit(baseDir); err != nil {
+			return err
+		}
+	}
+
+	return writeReport(os.Stdout, res)
+}
+
+func writeReport(w io.Writer, res *migrate.Result) error {
+	fmt.Fprintf(w, "Package %s was initialized with %d entities, %d APIs and %d examples.\n",
+		res.PackageID, len(res.Entities), len(res.Apis), len(res.Examples))
+
+	if len(res.Dependencies) != 0 {
+		fmt.Fprintln(w, "\nReferenced packages, add their sources with cti pkg get <source>:")
+		for _, dep := range res.Dependencies {
+			fmt.Fprintf(w, "  %s\n", dep)
+		}
+	}
+	if len(res.Issues) != 0 {
+		fmt.Fprintln(w, "\nNeeds manual fixes:")
+		for _, issue := range res.Issues {
+			fmt.Fprintf(w, "  %s\n", issue)
+		}
+	}
+	_, err := fmt.Fprintln(w, "\nRun cti validate to check the package.")
+	return err
+}
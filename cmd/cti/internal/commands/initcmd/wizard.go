@@ -0,0 +1,125 @@
+package initcmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/deploy"
+)
+
+const (
+	// defaultVersion is the initial version of packages created with the wizard.
+	defaultVersion = "v0.1.0"
+	// wizardEnv is the environment of the registry answered in the wizard.
+	wizardEnv = "dev"
+)
+
+// licenseRe matches SPDX license expressions without parentheses, e.g. Apache-2.0 or MIT OR GPL-2.0-only.
+var licenseRe = regexp.MustCompile(`^[A-Za-z0-9.+-]+( (AND|OR|WITH) [A-Za-z0-9.+-]+)*$`)
+
+// prompter asks questions and repeats them until answers are valid.
+type prompter struct {
+	in  *bufio.Reader
+	out io.Writer
+}
+
+// ask prints the question with the default answer and returns the answer checked and normalized by check.
+// Empty answers are replaced with the default answer.
+func (p *prompter) ask(question, def string, check func(string) (string, error)) (string, error) {
+	for {
+		if def != "" {
+			fmt.Fprintf(p.out, "%s [%s]: ", question, def)
+		} else {
+			fmt.Fprintf(p.out, "%s: ", question)
+		}
+		line, err := p.in.ReadString('\n')
+		if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+			fmt.Fprintln(p.out)
+			return "", fmt.Errorf("read answer: %w", err)
+		}
+		answer := strings.TrimSpace(line)
+		if answer == "" {
+			answer = def
+		}
+		res, checkErr := check(answer)
+		if checkErr == nil {
+			return res, nil
+		}
+		fmt.Fprintf(p.out, "  %v\n", checkErr)
+		if err != nil {
+			return "", checkErr
+		}
+	}
+}
+
+// runWizard prompts for the identifier, the version, the license and the registry of the new package in the
+// directory. Package names default to the name of the directory.
+func runWizard(in io.Reader, out io.Writer, baseDir string, opts InitOptions) (InitOptions, error) {
+	p := &prompter{in: bufio.NewReader(in), out: out}
+	fmt.Fprintln(out, "This wizard creates a CTI package. Press Enter to accept the default answer in brackets.")
+
+	vendor, err := p.ask("Vendor", "", checkSegment)
+	if err != nil {
+		return opts, err
+	}
+	def := strings.ToLower(strings.ReplaceAll(filepath.Base(baseDir), "-", "_"))
+	if ctipackage.ValidateIDSegment(def) != nil {
+		def = ""
+	}
+	pkgName, err := p.ask("Package (application code)", def, checkSegment)
+	if err != nil {
+		return opts, err
+	}
+	opts.ID = vendor + "." + pkgName
+
+	if opts.Version, err = p.ask("Initial version", defaultVersion, checkVersion); err != nil {
+		return opts, err
+	}
+	if opts.License, err = p.ask("License (SPDX expression, empty for none)", "", checkLicense); err != nil {
+		return opts, err
+	}
+	if opts.Registry, err = p.ask("Registry URL of the "+wizardEnv+" environment (empty for none)", "", checkRegistry); err != nil {
+		return opts, err
+	}
+	return opts, nil
+}
+
+func checkSegment(answer string) (string, error) {
+	if answer == "" {
+		return "", errors.New("answer is required")
+	}
+	return answer, ctipackage.ValidateIDSegment(answer)
+}
+
+// checkVersion accepts versions with or without the v prefix.
+func checkVersion(answer string) (string, error) {
+	if !strings.HasPrefix(answer, "v") {
+		answer = "v" + answer
+	}
+	return answer, ctipackage.ValidateVersion(answer)
+}
+
+func checkLicense(answer string) (string, error) {
+	if answer != "" && !licenseRe.MatchString(answer) {
+		return "", fmt.Errorf("invalid license %s, expected an SPDX expression, e.g. Apache-2.0 or MIT", answer)
+	}
+	return answer, nil
+}
+
+// checkRegistry accepts URLs of deployment targets, see deploy.NewTarget.
+func checkRegistry(answer string) (string, error) {
+	if answer == "" {
+		return "", nil
+	}
+	if _, err := deploy.NewTarget(answer, http.DefaultClient); err != nil {
+		return "", err
+	}
+	return answer, nil
+}
@@ -13,7 +13,7 @@ func New(_ context.Context) *cobra.Command {
 		Short: "lint cti package",
 		Args:  cobra.MinimumNArgs(0),
 		RunE: func(_ *cobra.Command, args []string) error {
-			return errors.New("not implemented")
+			return errors.New("not implemented, use cti validate")
 		},
 	}
 }
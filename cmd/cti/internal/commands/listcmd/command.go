@@ -0,0 +1,147 @@
+package listcmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/registry"
+
+	"github.com/spf13/cobra"
+)
+
+type ListOptions struct {
+	IncludeDependencies bool
+	Kind                string
+	Vendor              string
+	Package             string
+	Annotation          string
+	Format              OutputFormat
+}
+
+type entry struct {
+	Cti         string `json:"cti"`
+	Kind        string `json:"kind"`
+	Vendor      string `json:"vendor"`
+	Package     string `json:"package"`
+	DisplayName string `json:"display_name"`
+	Source      string `json:"source,omitempty"`
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := ListOptions{Format: OutputFormatTable}
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "list cti entities of the package",
+		Args:  cobra.MinimumNArgs(0),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
+		},
+	}
+
+	cmd.Flags().BoolVarP(&opts.IncludeDependencies, "all", "a", false, "Include entities of dependencies.")
+	cmd.Flags().StringVar(&opts.Kind, "kind", "", "Filter by entity kind. allowed: type,instance")
+	cmd.Flags().StringVar(&opts.Vendor, "vendor", "", "Filter by vendor.")
+	cmd.Flags().StringVar(&opts.Package, "package", "", "Filter by package in <vendor>.<package> form.")
+	cmd.Flags().StringVar(&opts.Annotation, "annotation", "", "Filter by annotation name, e.g. cti.reference.")
+	cmd.Flags().VarP(&opts.Format, "format", "f", `Output format. allowed: `+strings.Join(ListOutputFormats, ","))
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, opts ListOptions) error {
+	filter := registry.Filter{
+		Kind:       registry.Kind(opts.Kind),
+		Vendor:     opts.Vendor,
+		Package:    opts.Package,
+		Annotation: opts.Annotation,
+	}
+	switch filter.Kind {
+	case "", registry.KindType, registry.KindInstance:
+	default:
+		return errors.New("kind must be one of type,instance")
+	}
+
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+	if err := pkg.Parse(); err != nil {
+		return fmt.Errorf("parse package: %w", err)
+	}
+
+	r := pkg.Registry
+	if !opts.IncludeDependencies {
+		if r, err = registry.New(pkg.LocalRegistry.Index); err != nil {
+			return fmt.Errorf("index local entities: %w", err)
+		}
+	}
+
+	entities := r.Find(filter)
+	entries := make([]entry, 0, len(entities))
+	for _, entity := range entities {
+		entries = append(entries, makeEntry(entity))
+	}
+
+	return writeEntries(os.Stdout, entries, opts.Format)
+}
+
+func makeEntry(entity *metadata.Entity) entry {
+	vendor, pkg := metadata.GetVendorAndPackage(entity.Cti)
+	// Instances often have no display name, the CTI identifies them instead.
+	displayName := entity.DisplayName
+	if displayName == "" {
+		displayName = entity.Cti
+	}
+	return entry{
+		Cti:         entity.Cti,
+		Kind:        string(registry.KindOf(entity)),
+		Vendor:      vendor,
+		Package:     registry.PackageKey(vendor, pkg),
+		DisplayName: displayName,
+		Source:      ctipackage.SourcePath(entity.SourceMap.OriginalPath),
+	}
+}
+
+func writeEntries(w io.Writer, entries []entry, format OutputFormat) error {
+	switch format {
+	case OutputFormatJSON:
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(entries)
+	case OutputFormatCSV:
+		cw := csv.NewWriter(w)
+		_ = cw.Write([]string{"cti", "kind", "vendor", "package", "display_name", "source"})
+		for _, e := range entries {
+			_ = cw.Write([]string{e.Cti, e.Kind, e.Vendor, e.Package, e.DisplayName, e.Source})
+		}
+		cw.Flush()
+		return cw.Error()
+	case OutputFormatTable:
+		fallthrough
+	default:
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, "CTI\tKIND\tPACKAGE\tDISPLAY NAME\tSOURCE")
+		for _, e := range entries {
+			fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", e.Cti, e.Kind, e.Package, e.DisplayName, e.Source)
+		}
+		return tw.Flush()
+	}
+}
@@ -0,0 +1,46 @@
+package listcmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+func Test_WriteEntriesDisplayName(t *testing.T) {
+	const id = "cti.acme.shop.order.v1.0~acme.shop.pending.v1.0"
+	entries := []entry{makeEntry(&metadata.Entity{
+		Cti:       id,
+		Values:    json.RawMessage(`{}`),
+		SourceMap: metadata.SourceMap{OriginalPath: "../entities/orders.raml"},
+	})}
+
+	// Each format reports the CTI as the display name of the instance.
+	var buf bytes.Buffer
+	require.NoError(t, writeEntries(&buf, entries, OutputFormatTable))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 2)
+	require.Equal(t, []string{id, "instance", "acme.shop", id, "entities/orders.raml"}, strings.Fields(lines[1]))
+
+	buf.Reset()
+	require.NoError(t, writeEntries(&buf, entries, OutputFormatCSV))
+	records, err := csv.NewReader(&buf).ReadAll()
+	require.NoError(t, err)
+	require.Equal(t, [][]string{
+		{"cti", "kind", "vendor", "package", "display_name", "source"},
+		{id, "instance", "acme", "acme.shop", id, "entities/orders.raml"},
+	}, records)
+
+	buf.Reset()
+	require.NoError(t, writeEntries(&buf, entries, OutputFormatJSON))
+	var decoded []entry
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	require.Equal(t, []entry{{
+		Cti: id, Kind: "instance", Vendor: "acme", Package: "acme.shop", DisplayName: id, Source: "entities/orders.raml",
+	}}, decoded)
+}
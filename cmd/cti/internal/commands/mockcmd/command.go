@@ -0,0 +1,167 @@
+package mockcmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/mock"
+	"github.com/acronis/go-cti/metadata/registry"
+
+	"github.com/spf13/cobra"
+)
+
+// minIndexWidth is the minimal width of zero padded indexes in names of files, e.g. 0001.json.
+const minIndexWidth = 4
+
+type MockOptions struct {
+	Seed     int64
+	MaxDepth int
+	Count    int
+	Output   string
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := MockOptions{MaxDepth: mock.DefaultMaxDepth, Count: 1}
+	cmd := &cobra.Command{
+		Use:   "mock <type>",
+		Short: "generate example values of instances of the type",
+		Long: "Generate example values of instances of the type that are valid against its schema.\n" +
+			"Values respect required properties, enums, formats, patterns and ranges, cti.reference\n" +
+			"properties refer to entities of the package and its dependencies.\n\n" +
+			"With --output the argument is a CTI pattern, e.g. cti.x.y.event.v1.0~*, and instances of all\n" +
+			"matching types are written into the directory, a file per instance.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+			if !cmd.Flags().Changed("seed") {
+				opts.Seed = time.Now().UnixNano()
+			}
+
+			return command.WrapError(execute(ctx, baseDir, args[0], opts))
+		},
+	}
+
+	cmd.Flags().Int64Var(&opts.Seed, "seed", 0, "Seed of the generator to reproduce values. Random if not set.")
+	cmd.Flags().IntVar(&opts.MaxDepth, "max-depth", opts.MaxDepth, "Depth of nested objects after which only required properties are generated.")
+	cmd.Flags().IntVarP(&opts.Count, "count", "n", opts.Count, "Number of instances per type.")
+	cmd.Flags().StringVarP(&opts.Output, "output", "o", "", "Output directory, relative to the package. Values are printed if not set.")
+
+	return cmd
+}
+
+func execute(_ context.Context, baseDir string, arg string, opts MockOptions) error {
+	if opts.Count < 1 {
+		return errors.New("count must be positive")
+	}
+
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+	if err := pkg.Parse(); err != nil {
+		return fmt.Errorf("parse package: %w", err)
+	}
+
+	slog.Debug("Generating mock values", slog.String("type", arg), slog.Int64("seed", opts.Seed))
+	g := mock.New(pkg.Registry, mock.WithSeed(opts.Seed), mock.WithMaxDepth(opts.MaxDepth))
+
+	if opts.Output == "" {
+		instances, err := g.Instances(arg, opts.Count)
+		if err != nil {
+			return fmt.Errorf("generate instances: %w", err)
+		}
+		return writeValues(os.Stdout, instances)
+	}
+
+	outDir := opts.Output
+	if !filepath.IsAbs(outDir) {
+		outDir = filepath.Join(baseDir, outDir)
+	}
+	return writeDataset(g, pkg.Registry, arg, outDir, opts.Count)
+}
+
+// writeValues prints values of the instance, or an array of values of the instances.
+func writeValues(w io.Writer, instances metadata.Entities) error {
+	values := make([]any, 0, len(instances))
+	for _, instance := range instances {
+		var v any
+		if err := json.Unmarshal(instance.Values, &v); err != nil {
+			return fmt.Errorf("decode values: %w", err)
+		}
+		values = append(values, v)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if len(values) == 1 {
+		return enc.Encode(values[0])
+	}
+	return enc.Encode(values)
+}
+
+// writeDataset writes values of instances of types matching the pattern into <outDir>/<type>/<index>.json.
+// Directories of the types are recreated, so files of previous runs with greater counts do not remain.
+func writeDataset(g *mock.Generator, r *registry.Registry, pattern string, outDir string, count int) error {
+	types, err := command.FilterEntities(r.Types(), pattern)
+	if err != nil {
+		return err
+	}
+
+	width := max(minIndexWidth, len(strconv.Itoa(count)))
+	var generated int
+	for _, typ := range types {
+		if typ.Final {
+			slog.Debug("Skipping final type", slog.String("type", typ.Cti))
+			continue
+		}
+		instances, err := g.Instances(typ.Cti, count)
+		if err != nil {
+			return fmt.Errorf("generate instances: %w", err)
+		}
+
+		typeDir := filepath.Join(outDir, typ.Cti)
+		if err := os.RemoveAll(typeDir); err != nil {
+			return fmt.Errorf("remove %s: %w", typeDir, err)
+		}
+		if err := os.MkdirAll(typeDir, os.ModePerm); err != nil {
+			return fmt.Errorf("create directory: %w", err)
+		}
+		for i, instance := range instances {
+			var v any
+			if err := json.Unmarshal(instance.Values, &v); err != nil {
+				return fmt.Errorf("decode values: %w", err)
+			}
+			data, err := json.MarshalIndent(v, "", "  ")
+			if err != nil {
+				return fmt.Errorf("serialize values: %w", err)
+			}
+			fPath := filepath.Join(typeDir, fmt.Sprintf("%0*d.json", width, i+1))
+			if err := os.WriteFile(fPath, append(data, '\n'), 0600); err != nil {
+				return fmt.Errorf("write %s: %w", fPath, err)
+			}
+		}
+		generated++
+	}
+	if generated == 0 {
+		return fmt.Errorf("no types match %s", pattern)
+	}
+	slog.Info("Generation has been completed", slog.String("path", outDir), slog.Int("types", generated),
+		slog.Int("files", generated*count))
+	return nil
+}
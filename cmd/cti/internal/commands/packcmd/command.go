@@ -10,6 +10,7 @@ import (
 	"github.com/acronis/go-cti/cmd/cti/internal/command"
 	"github.com/acronis/go-cti/metadata/archiver/tgzwriter"
 	"github.com/acronis/go-cti/metadata/archiver/zippacker"
+	"github.com/acronis/go-cti/metadata/compiled"
 	"github.com/acronis/go-cti/metadata/ctipackage"
 	"github.com/acronis/go-cti/metadata/packer"
 	"github.com/spf13/cobra"
@@ -19,6 +20,7 @@ type PackOptions struct {
 	FileName      string
 	Prefix        string
 	IncludeSource bool
+	Compiled      bool
 	Format        PackFormat
 }
 
@@ -41,6 +43,7 @@ func New(ctx context.Context) *cobra.Command {
 	cmd.Flags().StringVarP(&packOpts.FileName, "output", "o", "package."+packer.ArchiveExtension, "Output file name with path.")
 	cmd.Flags().StringVarP(&packOpts.Prefix, "prefix", "p", "", "Output prefix.")
 	cmd.Flags().BoolVarP(&packOpts.IncludeSource, "include-source", "s", false, "Include source files in the resulting package.")
+	cmd.Flags().BoolVar(&packOpts.Compiled, "compiled", false, "Write the compiled registry next to the resulting package.")
 	cmd.Flags().Var(&packOpts.Format, "format", `Archive format. allowed: `+strings.Join(ListPackFormats, ","))
 
 	return cmd
@@ -80,5 +83,25 @@ func execute(_ context.Context, baseDir string, opts PackOptions) error {
 	}
 
 	slog.Info("Packing has been completed", "path", fullPath)
+
+	if opts.Compiled {
+		return writeCompiled(pkg, fullPath)
+	}
+	return nil
+}
+
+// writeCompiled writes the compiled registry of the package with its dependencies next to the archive,
+// e.g. x.y.registry.json for the x.y package.
+func writeCompiled(pkg *ctipackage.Package, archivePath string) error {
+	r, err := compiled.Compile(pkg.Registry, compiled.WithPackageID(pkg.Index.PackageID))
+	if err != nil {
+		return fmt.Errorf("compile registry: %w", err)
+	}
+	fPath := compiled.FilePath(archivePath, pkg.Index.PackageID)
+	if err := r.Save(fPath); err != nil {
+		return fmt.Errorf("save compiled registry: %w", err)
+	}
+	slog.Info("Compiled registry has been written", slog.String("path", fPath),
+		slog.Int("types", len(r.Types)), slog.Int("instances", len(r.Instances)))
 	return nil
 }
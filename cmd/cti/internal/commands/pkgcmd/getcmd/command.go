@@ -2,10 +2,14 @@ package getcmd
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"path/filepath"
+	"strings"
 
 	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
 	"github.com/acronis/go-cti/metadata/ctipackage"
 	"github.com/acronis/go-cti/metadata/pacman"
 
@@ -13,10 +17,25 @@ import (
 )
 
 func New(ctx context.Context) *cobra.Command {
-	return &cobra.Command{
-		Use:   "pkg",
-		Short: "command to add new or install cti from cache",
-		Args:  cobra.MinimumNArgs(0),
+	var env, update string
+	cmd := &cobra.Command{
+		Use:     "get [-u[=patch]] [<source>[@<version>] | <dir>]...",
+		Aliases: []string{"pkg"},
+		Short:   "command to add new or install cti from cache",
+		Long: "Adds the packages to dependencies of the package, or installs all dependencies without arguments.\n" +
+			"The version is a query resolved against versions of the source like go get does: latest (the default),\n" +
+			"a prefix of a version like v1 or v1.2 resolved to the highest matching version, a version like v1.2.3,\n" +
+			"or a commit hash resolved to a pseudo-version.\n" +
+			"A local directory, e.g. ./path/to/bundle or file:///path/to/bundle, is installed instead of the source\n" +
+			"of the package with the same id, and recorded in replace of index.json. The package is added with\n" +
+			"its id as the source if it is not a dependency yet. Getting the source with a version drops the replacement.\n" +
+			"Packages are added at once: index.json, index-lock.json and installed dependencies are left intact if any\n" +
+			"package fails to install or the package fails validation with --env.\n" +
+			"With -u, the dependencies of the sources, or all dependencies without arguments or with ./..., are updated\n" +
+			"to the highest versions with the same major version, or with the same minor version with -u=patch, and\n" +
+			"versions before and after the update are printed. Dependencies are never downgraded, and dependencies\n" +
+			"replaced with local directories are kept.",
+		Args: cobra.MinimumNArgs(0),
 		RunE: func(cmd *cobra.Command, args []string) error {
 			baseDir, err := command.GetWorkingDir(cmd)
 			if err != nil {
@@ -28,24 +47,97 @@ func New(ctx context.Context) *cobra.Command {
 				return fmt.Errorf("initialize package manager: %w", err)
 			}
 
-			if len(args) > 0 {
-				packages, err := command.ParsePackages(args)
+			if update != "" {
+				sources, err := updateSources(baseDir, args, update)
 				if err != nil {
-					return fmt.Errorf("parse packages: %w", err)
+					return command.WrapError(err)
 				}
+				return command.WrapError(updatePackages(ctx, baseDir, pm, sources, update, env))
+			}
+
+			if len(args) == 0 {
+				if err := installAll(ctx, baseDir, pm); err != nil {
+					return command.WrapError(err)
+				}
+				return command.WrapError(validateEnv(baseDir, env))
+			}
 
-				return command.WrapError(addPackages(ctx, baseDir, pm, packages))
+			var dirs, remotes []string
+			for _, arg := range args {
+				if dir, ok := localDir(baseDir, arg); ok {
+					dirs = append(dirs, dir)
+				} else {
+					remotes = append(remotes, arg)
+				}
+			}
+			packages, err := command.ParsePackages(remotes)
+			if err != nil {
+				return fmt.Errorf("parse packages: %w", err)
 			}
 
-			return command.WrapError(installAll(ctx, baseDir, pm))
+			return command.WrapError(getPackages(ctx, baseDir, pm, packages, dirs, env))
 		},
 	}
+
+	command.AddDepthFlags(cmd)
+	cmd.Flags().StringVarP(&update, "update", "u", "",
+		"Update dependencies to the highest versions with the same major version, or the same minor version with -u=patch.")
+	cmd.Flags().Lookup("update").NoOptDefVal = updateMinor
+	cmd.Flags().StringVar(&env, "env", "", "Validate the package with dependencies using the validation profile of the environment.")
+
+	return cmd
+}
+
+// updateSources returns sources of dependencies to update, nil updates all dependencies.
+func updateSources(baseDir string, args []string, mode string) ([]string, error) {
+	if mode != updateMinor && mode != updatePatch {
+		return nil, fmt.Errorf("invalid update mode %s, should be %s or %s", mode, updateMinor, updatePatch)
+	}
+	var sources []string
+	for _, arg := range args {
+		if arg == allPackages {
+			return nil, nil
+		}
+		if _, ok := localDir(baseDir, arg); ok {
+			return nil, fmt.Errorf("%s is a local directory, only sources of dependencies are updated", arg)
+		}
+		sources = append(sources, arg)
+	}
+	return sources, nil
+}
+
+// validateEnv validates the package with the validation profile of the environment, if any.
+func validateEnv(baseDir string, name string) error {
+	if name == "" {
+		return nil
+	}
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	env, envCfg, err := cfg.Environment(name)
+	if err != nil {
+		return err
+	}
+	if envCfg.Profile == "" {
+		slog.Warn("Environment has no validation profile, dependencies are not validated", slog.String("env", env))
+		return nil
+	}
+	slog.Info("Validating package with dependencies", slog.String("env", env), slog.String("profile", envCfg.Profile))
+	if err := command.ValidateWithProfile(baseDir, cfg, envCfg.Profile); err != nil {
+		return fmt.Errorf("environment %s: %w", env, err)
+	}
+	return nil
 }
 
-func addPackages(_ context.Context, baseDir string, pm pacman.PackageManager, packages map[string]string) error {
+// getPackages adds the packages and the local directories at once. The index, the lock and installed dependencies
+// are rolled back if any package fails to install or the package fails validation of the environment with them.
+func getPackages(_ context.Context, baseDir string, pm pacman.PackageManager, packages map[string]string,
+	dirs []string, env string) error {
 	slog.Info("Add package dependencies",
 		slog.String("path", baseDir),
 		slog.Any("packages", packages),
+		slog.Any("dirs", dirs),
 	)
 
 	pkg, err := ctipackage.New(baseDir)
@@ -56,11 +148,38 @@ func addPackages(_ context.Context, baseDir string, pm pacman.PackageManager, pa
 		return fmt.Errorf("read package: %w", err)
 	}
 
-	if err := pm.Add(pkg, packages); err != nil {
-		return fmt.Errorf("install dependencies: %w", err)
+	checkpoint, err := pacman.NewCheckpoint(baseDir)
+	if err != nil {
+		return fmt.Errorf("create checkpoint: %w", err)
+	}
+	err = pm.Get(pkg, packages, dirs)
+	if err != nil {
+		err = fmt.Errorf("install dependencies: %w", err)
+	} else {
+		err = validateEnv(baseDir, env)
+	}
+	if err != nil {
+		if rollbackErr := checkpoint.Rollback(); rollbackErr != nil {
+			return errors.Join(err, fmt.Errorf("roll back: %w", rollbackErr))
+		}
+		slog.Warn("Changes of dependencies have been rolled back")
+		return err
 	}
+	return checkpoint.Release()
+}
 
-	return nil
+// localDir returns the directory of the argument if it is a path or a file:// URL rather than a source.
+// Relative paths are resolved against the working directory.
+func localDir(baseDir string, arg string) (string, bool) {
+	switch {
+	case strings.HasPrefix(arg, "file://"):
+		return filepath.FromSlash(strings.TrimPrefix(arg, "file://")), true
+	case filepath.IsAbs(arg):
+		return arg, true
+	case arg == ".", arg == "..", strings.HasPrefix(arg, "./"), strings.HasPrefix(arg, "../"):
+		return filepath.Join(baseDir, arg), true
+	}
+	return "", false
 }
 
 func installAll(_ context.Context, baseDir string, pm pacman.PackageManager) error {
@@ -0,0 +1,119 @@
+package getcmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"sort"
+	"text/tabwriter"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/pacman"
+)
+
+// Modes of --update: minor updates to the highest version with the same major version, patch to the highest
+// version with the same minor version.
+const (
+	updateMinor = "minor"
+	updatePatch = "patch"
+)
+
+// allPackages is the argument of --update that updates all dependencies, like go get -u ./...
+const allPackages = "./..."
+
+// updatePackages updates dependencies of the sources, or all dependencies, and prints versions before and after
+// the update. The index, the lock and installed dependencies are rolled back as in getPackages.
+func updatePackages(_ context.Context, baseDir string, pm pacman.PackageManager, sources []string, mode string,
+	env string) error {
+	slog.Info("Update package dependencies",
+		slog.String("path", baseDir),
+		slog.Any("packages", sources),
+		slog.String("mode", mode),
+	)
+
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+	before := lockedVersions(pkg)
+
+	checkpoint, err := pacman.NewCheckpoint(baseDir)
+	if err != nil {
+		return fmt.Errorf("create checkpoint: %w", err)
+	}
+	err = pm.Update(pkg, sources, mode == updatePatch)
+	if err != nil {
+		err = fmt.Errorf("update dependencies: %w", err)
+	} else {
+		err = validateEnv(baseDir, env)
+	}
+	if err != nil {
+		if rollbackErr := checkpoint.Rollback(); rollbackErr != nil {
+			return errors.Join(err, fmt.Errorf("roll back: %w", rollbackErr))
+		}
+		slog.Warn("Changes of dependencies have been rolled back")
+		return err
+	}
+	if err := checkpoint.Release(); err != nil {
+		return err
+	}
+
+	return writeUpdateSummary(os.Stdout, pkg, before, lockedVersions(pkg))
+}
+
+// lockedVersions returns versions of installed dependencies by sources.
+func lockedVersions(pkg *ctipackage.Package) map[string]string {
+	res := make(map[string]string, len(pkg.IndexLock.SourceInfo))
+	for source, info := range pkg.IndexLock.SourceInfo {
+		res[source] = info.Version
+	}
+	return res
+}
+
+// writeUpdateSummary prints dependencies whose versions changed, transitive dependencies are marked as indirect.
+func writeUpdateSummary(w io.Writer, pkg *ctipackage.Package, before, after map[string]string) error {
+	sources := make([]string, 0, len(after))
+	for source := range after {
+		if before[source] != after[source] {
+			sources = append(sources, source)
+		}
+	}
+	for source := range before {
+		if _, ok := after[source]; !ok {
+			sources = append(sources, source)
+		}
+	}
+	if len(sources) == 0 {
+		_, err := fmt.Fprintln(w, "All dependencies are up to date.")
+		return err
+	}
+	sort.Strings(sources)
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tBEFORE\tAFTER")
+	for _, source := range sources {
+		name := source
+		if _, ok := pkg.Index.Depends[source]; !ok {
+			name += " (indirect)"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", name, versionOr(before[source]), versionOr(after[source]))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nUpdated %d of %d dependencies.\n", len(sources), len(after))
+	return err
+}
+
+func versionOr(version string) string {
+	if version == "" {
+		return "-"
+	}
+	return version
+}
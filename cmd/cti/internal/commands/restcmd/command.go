@@ -3,17 +3,278 @@ package restcmd
 import (
 	"context"
 	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/cmd/cti/internal/server"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/validator"
 	"github.com/spf13/cobra"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
 )
 
-func New(_ context.Context) *cobra.Command {
-	return &cobra.Command{
+// readHeaderTimeout limits reading of request headers, so idle clients do not hold connections.
+const readHeaderTimeout = 10 * time.Second
+
+type RestOptions struct {
+	// Addr is a TCP address or a Unix socket in unix:<path> form.
+	Addr string
+	// GRPCAddr is an address of the gRPC registry service. The service is not started if empty.
+	GRPCAddr string
+	GraphQL  bool
+	// Watch reloads the registry when files of the package change. WatchDeps also watches dependencies.
+	Watch     bool
+	WatchDeps bool
+	// Reload enables POST /reload, also enabled by Watch. The endpoint requires authentication of admins.
+	Reload bool
+
+	// TLSCert and TLSKey are files of the certificate and its key. ACMEDomains enables certificates issued
+	// by Let's Encrypt for the domains instead.
+	TLSCert      string
+	TLSKey       string
+	ACMEDomains  []string
+	ACMECacheDir string
+	ACMEEmail    string
+
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	// ShutdownDelay keeps serving requests after the signal while /readyz reports the server is shutting down,
+	// so load balancers stop routing requests to it. ShutdownTimeout limits completion of requests in progress.
+	ShutdownDelay   time.Duration
+	ShutdownTimeout time.Duration
+
+	// Env is the environment whose validation profile validates instances, see ValidateInstance of the server.
+	Env string
+}
+
+func New(ctx context.Context) *cobra.Command {
+	opts := RestOptions{
+		Addr:            "localhost:8080",
+		ReadTimeout:     30 * time.Second,
+		WriteTimeout:    time.Minute,
+		IdleTimeout:     2 * time.Minute,
+		ShutdownTimeout: 30 * time.Second,
+	}
+	cmd := &cobra.Command{
 		Use:   "rest",
 		Short: "run http server to expose restful api",
-		Args:  cobra.MinimumNArgs(0),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return errors.New("not implemented")
+		Long: "Runs an HTTP server exposing a read API of the registry built from the package and its dependencies: " +
+			"bundles, types with raw and flattened schemas and instances. The same registry can be served over gRPC.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
 		},
 	}
+
+	cmd.Flags().StringVar(&opts.Addr, "addr", opts.Addr, "Address to listen on, e.g. :8080 or unix:/run/cti/registry.sock.")
+	cmd.Flags().BoolVar(&opts.GraphQL, "graphql", false, "Enable the GraphQL endpoint at /graphql.")
+	cmd.Flags().BoolVar(&opts.Watch, "watch", false, "Reload the registry when files of the package change.")
+	cmd.Flags().BoolVar(&opts.WatchDeps, "watch-deps", false, "Reload the registry when files of dependencies change too. Implies --watch.")
+	cmd.Flags().BoolVar(&opts.Reload, "reload", false, "Enable POST /reload for admins, enabled by --watch too. Requires rest.auth of the configuration.")
+	cmd.Flags().StringVar(&opts.GRPCAddr, "grpc-addr", "", "Address of the gRPC registry service, e.g. localhost:9090. Not started if not set.")
+	cmd.Flags().StringVar(&opts.TLSCert, "tls-cert", "", "Certificate file to serve over TLS.")
+	cmd.Flags().StringVar(&opts.TLSKey, "tls-key", "", "Key file of the certificate.")
+	cmd.Flags().StringSliceVar(&opts.ACMEDomains, "acme-domain", nil, "Serve over TLS with certificates issued by Let's Encrypt for the domain. Can be repeated.")
+	cmd.Flags().StringVar(&opts.ACMECacheDir, "acme-cache-dir", "", "Directory to cache issued certificates in. Defaults to cti/acme in the user cache directory.")
+	cmd.Flags().StringVar(&opts.ACMEEmail, "acme-email", "", "Contact email of the Let's Encrypt account.")
+	cmd.Flags().DurationVar(&opts.ReadTimeout, "read-timeout", opts.ReadTimeout, "Maximum duration of reading a request.")
+	cmd.Flags().DurationVar(&opts.WriteTimeout, "write-timeout", opts.WriteTimeout, "Maximum duration of writing a response.")
+	cmd.Flags().DurationVar(&opts.IdleTimeout, "idle-timeout", opts.IdleTimeout, "Maximum duration of keeping idle connections open.")
+	cmd.Flags().DurationVar(&opts.ShutdownDelay, "shutdown-delay", 0, "Duration of serving requests after a termination signal while the server reports it is not ready.")
+	cmd.Flags().DurationVar(&opts.ShutdownTimeout, "shutdown-timeout", opts.ShutdownTimeout, "Maximum duration of completing requests in progress on shutdown.")
+	cmd.Flags().StringVar(&opts.Env, "env", "", "Environment whose validation profile is applied to validated instances.")
+
+	return cmd
+}
+
+func execute(ctx context.Context, baseDir string, opts RestOptions) error {
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	auth, err := authenticator(ctx, cfg.Rest.Auth)
+	if err != nil {
+		return fmt.Errorf("%s: auth: %w", cti.ConfigFileName, err)
+	}
+	hooks, err := webhooks(cfg.Rest.Webhooks)
+	if err != nil {
+		return fmt.Errorf("%s: %w", cti.ConfigFileName, err)
+	}
+	tlsCfg, err := tlsConfig(opts)
+	if err != nil {
+		return fmt.Errorf("tls: %w", err)
+	}
+	watch := opts.Watch || opts.WatchDeps
+	if len(hooks) > 0 && !watch {
+		slog.Warn("Webhooks are configured but not notified, since the registry is not reloaded without --watch")
+	}
+	if opts.Reload && auth == nil {
+		slog.Warn("Reload endpoint is not served, since clients are not authenticated without rest.auth")
+	}
+	validatorOpts, err := envValidatorOptions(baseDir, cfg, opts.Env)
+	if err != nil {
+		return err
+	}
+	snapshot, err := server.LoadPackage(baseDir, validatorOpts...)
+	if err != nil {
+		return fmt.Errorf("load registry: %w", err)
+	}
+
+	load := func() (*server.Snapshot, error) {
+		return server.LoadPackage(baseDir, validatorOpts...)
+	}
+	var srvOpts []server.Option
+	if watch || opts.Reload {
+		srvOpts = append(srvOpts, server.WithReload(load))
+	}
+	if opts.GraphQL {
+		srvOpts = append(srvOpts, server.WithGraphQL())
+	}
+	if auth != nil {
+		srvOpts = append(srvOpts, server.WithAuth(auth))
+	}
+	if len(hooks) > 0 {
+		srvOpts = append(srvOpts, server.WithWebhooks(hooks...))
+	}
+	registrySrv := server.New(snapshot, srvOpts...)
+	ln, err := listen(opts.Addr)
+	if err != nil {
+		return fmt.Errorf("listen: %w", err)
+	}
+	srv := &http.Server{
+		Handler:           registrySrv,
+		TLSConfig:         tlsCfg,
+		ReadHeaderTimeout: readHeaderTimeout,
+		ReadTimeout:       opts.ReadTimeout,
+		WriteTimeout:      opts.WriteTimeout,
+		IdleTimeout:       opts.IdleTimeout,
+	}
+
+	var grpcSrv *grpc.Server
+	if opts.GRPCAddr != "" {
+		grpcLn, err := listen(opts.GRPCAddr)
+		if err != nil {
+			_ = ln.Close()
+			return fmt.Errorf("listen grpc: %w", err)
+		}
+		grpcOpts := []grpc.ServerOption{grpc.UnaryInterceptor(registrySrv.UnaryInterceptor())}
+		if tlsCfg != nil {
+			grpcOpts = append(grpcOpts, grpc.Creds(credentials.NewTLS(tlsCfg)))
+		}
+		grpcSrv = grpc.NewServer(grpcOpts...)
+		registrySrv.RegisterGRPC(grpcSrv)
+		go func() {
+			if err := grpcSrv.Serve(grpcLn); err != nil {
+				slog.Error("gRPC server failed", slog.String("error", err.Error()))
+			}
+		}()
+		slog.Info("Serving gRPC registry service", slog.String("address", endpoint(grpcLn, tlsCfg)))
+	}
+
+	if watch {
+		dirs := []string{baseDir}
+		depsDir := filepath.Join(baseDir, ctipackage.DependencyDirName)
+		if _, err := os.Stat(depsDir); err == nil && opts.WatchDeps {
+			dirs = append(dirs, depsDir)
+		}
+		go func() {
+			if err := registrySrv.Watch(ctx, load, dirs...); err != nil {
+				slog.Error("Failed to watch files, registry is not reloaded", slog.String("error", err.Error()))
+			}
+		}()
+	}
+
+	shutdownErr := make(chan error, 1)
+	go func() {
+		<-ctx.Done()
+		shutdownErr <- shutdown(registrySrv, srv, grpcSrv, opts)
+	}()
+
+	slog.Info("Serving registry",
+		slog.String("address", endpoint(ln, tlsCfg)),
+		slog.Int("entities", snapshot.Registry.Len()),
+		slog.Int("bundles", len(snapshot.Bundles)),
+		slog.Bool("auth", auth != nil),
+		slog.Bool("watch", watch),
+		slog.Int("webhooks", len(hooks)),
+		slog.String("env", opts.Env))
+	if tlsCfg != nil {
+		err = srv.ServeTLS(ln, "", "")
+	} else {
+		err = srv.Serve(ln)
+	}
+	if !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("serve: %w", err)
+	}
+	return <-shutdownErr
+}
+
+// shutdown drains the server: it reports the server is not ready for the shutdown delay, then stops accepting
+// connections and waits for requests in progress to complete. Requests still in progress after the shutdown
+// timeout are interrupted.
+func shutdown(registrySrv *server.Server, srv *http.Server, grpcSrv *grpc.Server, opts RestOptions) error {
+	slog.Info("Shutting down", slog.String("delay", opts.ShutdownDelay.String()))
+	registrySrv.Drain()
+	time.Sleep(opts.ShutdownDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), opts.ShutdownTimeout)
+	defer cancel()
+	grpcStopped := make(chan struct{})
+	go func() {
+		defer close(grpcStopped)
+		if grpcSrv == nil {
+			return
+		}
+		stopped := make(chan struct{})
+		go func() {
+			grpcSrv.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-ctx.Done():
+			grpcSrv.Stop()
+		}
+	}()
+	err := srv.Shutdown(ctx)
+	<-grpcStopped
+	if err != nil {
+		_ = srv.Close()
+		return fmt.Errorf("shutdown: requests are not completed in %s: %w", opts.ShutdownTimeout, err)
+	}
+	slog.Info("Server stopped")
+	return nil
+}
+
+// envValidatorOptions returns options of the validator with the validation profile of the environment,
+// none if the environment is not specified or has no profile.
+func envValidatorOptions(baseDir string, cfg *cti.Config, name string) ([]validator.Option, error) {
+	if name == "" {
+		return nil, nil
+	}
+	env, envCfg, err := cfg.Environment(name)
+	if err != nil {
+		return nil, err
+	}
+	if envCfg.Profile == "" {
+		return nil, nil
+	}
+	validatorOpts, err := command.ProfileValidatorOptions(baseDir, cfg, envCfg.Profile)
+	if err != nil {
+		return nil, fmt.Errorf("environment %s: %w", env, err)
+	}
+	return validatorOpts, nil
 }
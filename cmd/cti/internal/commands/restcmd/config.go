@@ -0,0 +1,83 @@
+package restcmd
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+	"github.com/acronis/go-cti/cmd/cti/internal/server"
+)
+
+// authenticator creates the authenticator of clients configured in the configuration file of the package.
+// Nil is returned if authentication is not configured.
+func authenticator(ctx context.Context, cfg cti.AuthConfig) (server.Authenticator, error) {
+	var auth server.Authenticators
+	if len(cfg.Tokens) > 0 {
+		tokens := make(server.Tokens, len(cfg.Tokens))
+		for _, t := range cfg.Tokens {
+			role, err := server.ParseRole(t.Role)
+			if err != nil {
+				return nil, fmt.Errorf("token %s: %w", t.Env, err)
+			}
+			token := os.Getenv(t.Env)
+			if token == "" {
+				return nil, fmt.Errorf("token %s: environment variable is not set", t.Env)
+			}
+			tokens[token] = role
+		}
+		auth = append(auth, tokens)
+	}
+	if cfg.OIDC != nil {
+		oidcCfg := server.OIDCConfig{
+			Issuer:     cfg.OIDC.Issuer,
+			Audience:   cfg.OIDC.Audience,
+			RolesClaim: cfg.OIDC.RolesClaim,
+			Roles:      make(map[string]server.Role, len(cfg.OIDC.Roles)),
+		}
+		for value, name := range cfg.OIDC.Roles {
+			role, err := server.ParseRole(name)
+			if err != nil {
+				return nil, fmt.Errorf("oidc role %s: %w", value, err)
+			}
+			oidcCfg.Roles[value] = role
+		}
+		if cfg.OIDC.DefaultRole != "" {
+			role, err := server.ParseRole(cfg.OIDC.DefaultRole)
+			if err != nil {
+				return nil, fmt.Errorf("oidc default role: %w", err)
+			}
+			oidcCfg.DefaultRole = role
+		}
+		oidcAuth, err := server.NewOIDC(ctx, oidcCfg)
+		if err != nil {
+			return nil, err
+		}
+		auth = append(auth, oidcAuth)
+	}
+	if len(auth) == 0 {
+		return nil, nil
+	}
+	return auth, nil
+}
+
+// webhooks creates webhooks configured in the configuration file of the package.
+func webhooks(cfg []cti.WebhookConfig) ([]server.Webhook, error) {
+	hooks := make([]server.Webhook, 0, len(cfg))
+	for _, h := range cfg {
+		u, err := url.Parse(h.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return nil, fmt.Errorf("webhook %s: url must be an absolute http or https url", h.URL)
+		}
+		hook := server.Webhook{URL: h.URL}
+		if h.SecretEnv != "" {
+			hook.Secret = os.Getenv(h.SecretEnv)
+			if hook.Secret == "" {
+				return nil, fmt.Errorf("webhook %s: secret %s: environment variable is not set", h.URL, h.SecretEnv)
+			}
+		}
+		hooks = append(hooks, hook)
+	}
+	return hooks, nil
+}
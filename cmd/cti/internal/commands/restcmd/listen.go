@@ -0,0 +1,84 @@
+package restcmd
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/crypto/acme/autocert"
+)
+
+// unixPrefix marks addresses of Unix sockets, e.g. unix:/run/cti/registry.sock.
+const unixPrefix = "unix:"
+
+// listen listens on the TCP address or the Unix socket. A stale socket left by a crashed server is removed,
+// other files are not.
+func listen(addr string) (net.Listener, error) {
+	path, ok := strings.CutPrefix(addr, unixPrefix)
+	if !ok {
+		return net.Listen("tcp", addr)
+	}
+	if info, err := os.Stat(path); err == nil {
+		if info.Mode().Type() != fs.ModeSocket {
+			return nil, fmt.Errorf("%s exists and is not a socket", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("remove stale socket: %w", err)
+		}
+	}
+	return net.Listen("unix", path)
+}
+
+// endpoint returns the URL of the listener for logs.
+func endpoint(ln net.Listener, tlsCfg *tls.Config) string {
+	if ln.Addr().Network() == "unix" {
+		return unixPrefix + ln.Addr().String()
+	}
+	if tlsCfg != nil {
+		return "https://" + ln.Addr().String()
+	}
+	return "http://" + ln.Addr().String()
+}
+
+// tlsConfig creates the TLS configuration from the certificate files or certificates obtained from the ACME CA
+// for the domains. Nil is returned if TLS is not enabled.
+func tlsConfig(opts RestOptions) (*tls.Config, error) {
+	switch {
+	case len(opts.ACMEDomains) > 0:
+		if opts.TLSCert != "" || opts.TLSKey != "" {
+			return nil, errors.New("--tls-cert and --tls-key cannot be used with --acme-domain")
+		}
+		cacheDir := opts.ACMECacheDir
+		if cacheDir == "" {
+			userCacheDir, err := os.UserCacheDir()
+			if err != nil {
+				return nil, fmt.Errorf("get cache directory: %w", err)
+			}
+			cacheDir = filepath.Join(userCacheDir, "cti", "acme")
+		}
+		m := &autocert.Manager{
+			Prompt:     autocert.AcceptTOS,
+			Cache:      autocert.DirCache(cacheDir),
+			HostPolicy: autocert.HostWhitelist(opts.ACMEDomains...),
+			Email:      opts.ACMEEmail,
+		}
+		return m.TLSConfig(), nil
+	case opts.TLSCert != "" || opts.TLSKey != "":
+		if opts.TLSCert == "" || opts.TLSKey == "" {
+			return nil, errors.New("both --tls-cert and --tls-key must be set")
+		}
+		// The key pair is loaded up front, so misconfigured servers fail at start rather than on the first request.
+		cert, err := tls.LoadX509KeyPair(opts.TLSCert, opts.TLSKey)
+		if err != nil {
+			return nil, fmt.Errorf("load key pair: %w", err)
+		}
+		return &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}, nil
+	default:
+		return nil, nil
+	}
+}
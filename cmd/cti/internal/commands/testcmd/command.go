@@ -3,17 +3,382 @@ package testcmd
 import (
 	"context"
 	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/metadata/compiled"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/ctitest"
+	"github.com/acronis/go-cti/metadata/pacman"
+	"github.com/acronis/go-cti/metadata/registry"
+	"github.com/acronis/go-cti/metadata/schemaref"
+	"github.com/acronis/go-cti/metadata/storage"
+	"github.com/acronis/go-cti/metadata/validator"
 
 	"github.com/spf13/cobra"
 )
 
-func New(_ context.Context) *cobra.Command {
-	return &cobra.Command{
+type TestOptions struct {
+	// Dir is the directory of test files, relative to the package.
+	Dir string
+	// Overlay selects environment-specific values of setups of test files, e.g. prod.
+	Overlay string
+	Offline bool
+	Format  OutputFormat
+	// Update rewrites golden files of snapshots with current schemas instead of comparing them.
+	Update bool
+	// Coverage reports types and attributes that are not covered by cases. CoverageMin fails tests if
+	// the percentage of covered types is less.
+	Coverage    bool
+	CoverageMin float64
+	// Fuzz is the number of random instances generated for each type of the package, 0 disables fuzzing.
+	// FuzzSeed makes generated instances reproducible, a random seed is used if 0.
+	Fuzz     int
+	FuzzSeed int64
+	// Parallel is the number of cases validated concurrently. Shard runs a part of cases in i/n form.
+	Parallel int
+	Shard    string
+	// Against is the URL of a registry served by 'cti rest' that validates values of cases instead of
+	// the local validator.
+	Against string
+	// Run is a CTI pattern of types of cases to run. Tags select cases by tags, ones prefixed with ! exclude.
+	Run  string
+	Tags []string
+}
+
+// tokenEnv is the environment variable with the bearer token of the registry of --against.
+const tokenEnv = "CTI_REGISTRY_TOKEN"
+
+// defaultFuzz is the number of instances per type if --fuzz is set without a value.
+const defaultFuzz = 10
+
+func New(ctx context.Context) *cobra.Command {
+	opts := TestOptions{Dir: ctitest.DefaultDir, Format: OutputFormatText, Parallel: runtime.NumCPU()}
+	cmd := &cobra.Command{
 		Use:   "test",
 		Short: "test cti package",
-		Args:  cobra.MinimumNArgs(0),
-		RunE: func(_ *cobra.Command, args []string) error {
-			return errors.New("not implemented")
+		Long: "Run test cases of the package. A test case pairs values of an instance with the type they are\n" +
+			"validated against and the expected outcome: valid, or invalid with the expected diagnostic codes\n" +
+			"or details of diagnostics: the code, the path of the invalid value and a substring of the message.\n" +
+			"Cases are defined in YAML files of the tests directory of the package, e.g.:\n\n" +
+			"  cases:\n" +
+			"    - name: unknown severity\n" +
+			"      type: cti.x.y.alert.v1.0\n" +
+			"      values: {severity: fatal}\n" +
+			"      valid: false\n" +
+			"      codes: [CTI1020]\n" +
+			"      diagnostics:\n" +
+			"        - {code: CTI1020, path: severity, message: must be one of}\n" +
+			"  snapshots:\n" +
+			"    - cti.x.y.alert.v1.0\n\n" +
+			"Flattened schemas of types listed in snapshots are compared with golden files of the snapshots\n" +
+			"subdirectory, use --update to create or rewrite golden files after intentional changes.\n\n" +
+			"Values shared by cases are kept in YAML or JSON files of the fixtures subdirectory and referred to\n" +
+			"by fixture: <name>. A setup of a file provides defaults of values, environment-specific overlays\n" +
+			"selected with --overlay, and fixtures generated as valid instances of types:\n\n" +
+			"  setup:\n" +
+			"    values: {source: monitoring}\n" +
+			"    overlays:\n" +
+			"      prod: {severity: critical}\n" +
+			"    generate:\n" +
+			"      - {fixture: alert, type: cti.x.y.alert.v1.0}\n" +
+			"  cases:\n" +
+			"    - name: unknown severity\n" +
+			"      type: cti.x.y.alert.v1.0\n" +
+			"      fixture: alert\n" +
+			"      values: {severity: fatal}\n" +
+			"      valid: false\n\n" +
+			"Values of the setup, the fixture, the overlay and the case are merged in this order.\n\n" +
+			"Use --against to validate values of cases with a registry deployed with 'cti rest' instead of the\n" +
+			"local validator, so that the registry is checked to behave the same way as the local toolchain.\n" +
+			"The bearer token of the registry is read from the " + tokenEnv + " environment variable.\n" +
+			"Snapshots are still checked against schemas of the local package.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, _ []string) error {
+			baseDir, err := command.GetWorkingDir(cmd)
+			if err != nil {
+				return fmt.Errorf("get working directory: %w", err)
+			}
+
+			return command.WrapError(execute(ctx, baseDir, opts))
 		},
 	}
+
+	cmd.Flags().StringVar(&opts.Dir, "dir", opts.Dir, "Directory of test files, relative to the package.")
+	cmd.Flags().StringVar(&opts.Overlay, "overlay", "", "Apply overlays with the name of setups of test files, e.g. prod.")
+	cmd.Flags().VarP(&opts.Format, "format", "f", `Output format. allowed: `+strings.Join(ListOutputFormats, ","))
+	cmd.Flags().BoolVar(&opts.Update, "update", false, "Update golden files of snapshots with current schemas.")
+	cmd.Flags().BoolVar(&opts.Offline, "offline", false, "Do not download remote schemas referenced with $ref, use cached copies only.")
+	cmd.Flags().IntVar(&opts.Fuzz, "fuzz", 0, "Generate random instances of each type of the package, valid and with a violated constraint,\n"+
+		"and check that validation accepts and rejects them respectively. The value is the number of instances per type.")
+	cmd.Flags().Lookup("fuzz").NoOptDefVal = strconv.Itoa(defaultFuzz)
+	cmd.Flags().Int64Var(&opts.FuzzSeed, "fuzz-seed", 0, "Seed of generated instances to reproduce a fuzzing run, random if not set.")
+	cmd.Flags().StringVar(&opts.Against, "against", "", "Validate values of cases with the registry served by 'cti rest' at the URL instead of locally.")
+	cmd.Flags().StringVar(&opts.Run, "run", "", "Run only cases and snapshots of types matching the CTI pattern, e.g. cti.x.y.alert.*.")
+	cmd.Flags().StringSliceVar(&opts.Tags, "tags", nil, "Run only cases with any of the tags, tags prefixed with ! exclude cases, e.g. alerts,!slow.")
+	cmd.Flags().IntVar(&opts.Parallel, "parallel", opts.Parallel, "Number of cases validated concurrently.")
+	cmd.Flags().StringVar(&opts.Shard, "shard", "", "Run only a part of cases and snapshots in i/n form, e.g. 2/4 runs the second of four parts.\n"+
+		"Use the same --fuzz-seed in all shards when fuzzing.")
+	cmd.Flags().BoolVar(&opts.Coverage, "coverage", false, "Report types and attributes of the package that are not covered by cases.")
+	cmd.Flags().Float64Var(&opts.CoverageMin, "coverage-min", 0, "Fail if less percent of types of the package are covered by cases, e.g. 80.")
+
+	return cmd
+}
+
+// NormalizeArgs rewrites -run of arguments of cti test to --run, so the pattern is passed like to go test -run.
+// Otherwise the flag parser would read -run as shorthands. Arguments of other commands are returned as is.
+func NormalizeArgs(root *cobra.Command, args []string) []string {
+	cmd, _, err := root.Find(args)
+	if err != nil || cmd.Name() != "test" || cmd.Parent() != root {
+		return args
+	}
+	res := make([]string, len(args))
+	copy(res, args)
+	for i, arg := range res {
+		if arg == "--" {
+			break
+		}
+		if arg == "-run" || strings.HasPrefix(arg, "-run=") {
+			res[i] = "-" + arg
+		}
+	}
+	return res
+}
+
+func execute(ctx context.Context, baseDir string, opts TestOptions) error {
+	if opts.CoverageMin < 0 || opts.CoverageMin > 100 {
+		return errors.New("coverage-min must be between 0 and 100")
+	}
+	testsDir := opts.Dir
+	if !filepath.IsAbs(testsDir) {
+		testsDir = filepath.Join(baseDir, testsDir)
+	}
+	if opts.Fuzz < 0 {
+		return errors.New("fuzz must not be negative")
+	}
+	if opts.Parallel < 1 {
+		return errors.New("parallel must be positive")
+	}
+	shard := ctitest.Shard{Index: 1, Total: 1}
+	if opts.Shard != "" {
+		var err error
+		if shard, err = ctitest.ParseShard(opts.Shard); err != nil {
+			return err
+		}
+	}
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return fmt.Errorf("read package: %w", err)
+	}
+	if err := pkg.Parse(); err != nil {
+		return fmt.Errorf("parse package: %w", err)
+	}
+
+	suite, err := ctitest.Load(testsDir, ctitest.WithRegistry(pkg.Registry), ctitest.WithOverlay(opts.Overlay))
+	switch {
+	case errors.Is(err, fs.ErrNotExist) && opts.Fuzz > 0:
+		// Types can be fuzzed without test files.
+		suite = &ctitest.Suite{}
+	case err != nil:
+		return fmt.Errorf("load tests: %w", err)
+	}
+	if len(suite.Cases) == 0 && len(suite.Snapshots) == 0 && opts.Fuzz == 0 {
+		return fmt.Errorf("no test cases found in %s", testsDir)
+	}
+
+	v, err := newValidator(pkg, opts)
+	if err != nil {
+		return err
+	}
+
+	// Generated cases are run after cases of test files, but they do not count towards coverage.
+	run := &ctitest.Suite{Cases: suite.Cases, Snapshots: suite.Snapshots}
+	if opts.Fuzz > 0 {
+		fuzzed, err := fuzz(pkg, opts)
+		if err != nil {
+			return err
+		}
+		run.Cases = append(append([]*ctitest.Case{}, suite.Cases...), fuzzed.Cases...)
+	}
+	if run, err = (ctitest.Filter{Pattern: opts.Run, Tags: opts.Tags}).Select(run); err != nil {
+		return fmt.Errorf("filter tests: %w", err)
+	}
+	if len(run.Cases) == 0 && len(run.Snapshots) == 0 {
+		slog.Warn("No test cases match the filter", slog.String("run", opts.Run), slog.Any("tags", opts.Tags))
+	}
+	run = shard.Select(run)
+
+	slog.Debug("Running tests", slog.String("path", testsDir), slog.String("shard", shard.String()),
+		slog.Int("cases", len(run.Cases)), slog.Int("parallel", opts.Parallel))
+	results := ctitest.Run(ctx, run, v, ctitest.WithParallel(opts.Parallel))
+	if len(run.Snapshots) > 0 {
+		start := time.Now()
+		c, err := compiled.Compile(pkg.Registry)
+		if err != nil {
+			return fmt.Errorf("compile package: %w", err)
+		}
+		results.Snapshots = ctitest.CheckSnapshots(c, testsDir, run.Snapshots, opts.Update)
+		results.Duration += time.Since(start)
+	}
+	if err := writeResults(os.Stdout, results, opts.Format); err != nil {
+		return fmt.Errorf("write results: %w", err)
+	}
+	if failed := results.Failed(); failed > 0 {
+		return fmt.Errorf("%d of %d test(s) failed", failed, results.Total())
+	}
+
+	if !opts.Coverage && opts.CoverageMin == 0 {
+		return nil
+	}
+	coverage, err := ctitest.MeasureCoverage(pkg.Registry, pkg.Index.PackageID, suite)
+	if err != nil {
+		return fmt.Errorf("measure coverage: %w", err)
+	}
+	// Results in other formats are parsed by CI servers, so coverage is written apart from them.
+	out := os.Stdout
+	if opts.Format != OutputFormatText {
+		out = os.Stderr
+	}
+	if err := writeCoverage(out, coverage, opts.Coverage); err != nil {
+		return fmt.Errorf("write coverage: %w", err)
+	}
+	if coverage.Percent() < opts.CoverageMin {
+		return fmt.Errorf("coverage %.1f%% is below the minimum %.1f%%", coverage.Percent(), opts.CoverageMin)
+	}
+	return nil
+}
+
+// newValidator creates the validator of values of cases: the registry of --against or the local one.
+func newValidator(pkg *ctipackage.Package, opts TestOptions) (ctitest.Validator, error) {
+	if opts.Against != "" {
+		slog.Info("Validating against the registry", slog.String("url", opts.Against))
+		v, err := ctitest.NewRemoteValidator(opts.Against, ctitest.WithToken(os.Getenv(tokenEnv)))
+		if err != nil {
+			return nil, fmt.Errorf("new remote validator: %w", err)
+		}
+		return v, nil
+	}
+	cacheDir, err := pacman.GetSchemaCacheDir()
+	if err != nil {
+		return nil, fmt.Errorf("get schema cache dir: %w", err)
+	}
+	resolver := schemaref.New(pkg.BaseDir, schemaref.WithCacheDir(cacheDir), schemaref.WithOffline(opts.Offline || storage.IsOffline()))
+	return ctitest.NewLocalValidator(pkg.Registry, validator.WithRefResolver(resolver)), nil
+}
+
+// fuzz generates cases of types of the package that are not final, since final types have no instances.
+func fuzz(pkg *ctipackage.Package, opts TestOptions) (*ctitest.Suite, error) {
+	seed := opts.FuzzSeed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	var types []string
+	for _, typ := range pkg.Registry.Find(registry.Filter{Kind: registry.KindType, Package: pkg.Index.PackageID}) {
+		if !typ.Final {
+			types = append(types, typ.Cti)
+		}
+	}
+	sort.Strings(types)
+
+	slog.Info("Fuzzing types", slog.Int("types", len(types)), slog.Int("instances", opts.Fuzz), slog.Int64("seed", seed))
+	suite, err := ctitest.Fuzz(pkg.Registry, types, opts.Fuzz, seed)
+	if err != nil {
+		return nil, fmt.Errorf("fuzz: %w", err)
+	}
+	return suite, nil
+}
+
+func writeResults(w io.Writer, results *ctitest.Results, format OutputFormat) error {
+	switch format {
+	case OutputFormatJUnit:
+		return ctitest.WriteJUnit(w, results)
+	case OutputFormatTAP:
+		return ctitest.WriteTAP(w, results)
+	default:
+		return writeText(w, results)
+	}
+}
+
+// writeText writes a line per case and snapshot, with failures of failed ones, and a summary.
+func writeText(w io.Writer, results *ctitest.Results) error {
+	var errs []error
+	for _, res := range results.Results {
+		status := "PASS"
+		if !res.Passed() {
+			status = "FAIL"
+		}
+		_, err := fmt.Fprintf(w, "%s %s (%s)\n", status, res.Case.ID(), res.Duration.Round(time.Microsecond))
+		errs = append(errs, err)
+		if res.Err != nil {
+			_, err = fmt.Fprintf(w, "    %s\n", res.Err)
+			errs = append(errs, err)
+		}
+		for _, failure := range res.Failures {
+			_, err = fmt.Fprintf(w, "    %s\n", failure)
+			errs = append(errs, err)
+		}
+	}
+	for _, res := range results.Snapshots {
+		status := "PASS"
+		if !res.Passed() {
+			status = "FAIL"
+		}
+		note := ""
+		if res.Updated {
+			note = ", updated"
+		}
+		_, err := fmt.Fprintf(w, "%s %s (%s%s)\n", status, res.File, res.Duration.Round(time.Microsecond), note)
+		errs = append(errs, err)
+		if res.Err != nil {
+			_, err = fmt.Fprintf(w, "    %s\n", res.Err)
+			errs = append(errs, err)
+		}
+		for _, line := range strings.SplitAfter(res.Diff, "\n") {
+			if line != "" {
+				_, err = fmt.Fprintf(w, "    %s", line)
+				errs = append(errs, err)
+			}
+		}
+	}
+	failed := results.Failed()
+	_, err := fmt.Fprintf(w, "\n%d passed, %d failed in %s\n", results.Total()-failed, failed, results.Duration.Round(time.Microsecond))
+	errs = append(errs, err)
+	return errors.Join(errs...)
+}
+
+// writeCoverage writes percentages of covered types and attributes. Details list types that are not covered
+// and attributes that are not covered by cases of covered types.
+func writeCoverage(w io.Writer, coverage *ctitest.Coverage, details bool) error {
+	_, err := fmt.Fprintf(w, "coverage: %.1f%% of types (%d of %d), %.1f%% of attributes\n",
+		coverage.Percent(), coverage.Covered(), len(coverage.Types), coverage.AttributesPercent())
+	errs := []error{err}
+	if !details {
+		return err
+	}
+	for _, t := range coverage.Types {
+		switch {
+		case t.Cases == 0:
+			_, err = fmt.Fprintf(w, "    %s: not covered\n", t.Cti)
+		case len(t.Uncovered) > 0:
+			_, err = fmt.Fprintf(w, "    %s: %d case(s), attributes not covered: %s\n", t.Cti, t.Cases,
+				strings.Join(t.Uncovered, ", "))
+		default:
+			continue
+		}
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
 }
@@ -0,0 +1,33 @@
+package testcmd
+
+import (
+	"context"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NormalizeArgs(t *testing.T) {
+	root := &cobra.Command{Use: "cti"}
+	root.AddCommand(New(context.Background()), &cobra.Command{Use: "fmt"})
+
+	for _, tc := range []struct {
+		args     []string
+		expected []string
+	}{
+		{[]string{"test", "-run", "cti.a.p.alert.*"}, []string{"test", "--run", "cti.a.p.alert.*"}},
+		{[]string{"test", "-run=cti.a.p.alert.*", "--tags", "slow"}, []string{"test", "--run=cti.a.p.alert.*", "--tags", "slow"}},
+		{[]string{"test", "--run", "cti.a.p.alert.*"}, []string{"test", "--run", "cti.a.p.alert.*"}},
+		{[]string{"test", "--", "-run"}, []string{"test", "--", "-run"}},
+		{[]string{"fmt", "-run"}, []string{"fmt", "-run"}},
+	} {
+		require.Equal(t, tc.expected, NormalizeArgs(root, tc.args), tc.args)
+	}
+
+	// The go test spelling sets the pattern of --run.
+	cmd, flags, err := root.Find(NormalizeArgs(root, []string{"test", "-run", "cti.a.p.alert.*"}))
+	require.NoError(t, err)
+	require.NoError(t, cmd.ParseFlags(flags))
+	require.Equal(t, "cti.a.p.alert.*", cmd.Flags().Lookup("run").Value.String())
+}
@@ -0,0 +1,37 @@
+package testcmd
+
+import (
+	"errors"
+	"strings"
+)
+
+type OutputFormat string
+
+const (
+	OutputFormatText  OutputFormat = "text"
+	OutputFormatJUnit OutputFormat = "junit"
+	OutputFormatTAP   OutputFormat = "tap"
+)
+
+var ListOutputFormats = []string{string(OutputFormatText), string(OutputFormatJUnit), string(OutputFormatTAP)}
+
+// String is used both by fmt.Print and by Cobra in help text
+func (e *OutputFormat) String() string {
+	return string(*e)
+}
+
+// Set must have pointer receiver so it doesn't change the value of a copy
+func (e *OutputFormat) Set(v string) error {
+	switch v {
+	case string(OutputFormatText), string(OutputFormatJUnit), string(OutputFormatTAP):
+		*e = OutputFormat(v)
+		return nil
+	default:
+		return errors.New(`must be one of ` + strings.Join(ListOutputFormats, ","))
+	}
+}
+
+// Type is only used in help text
+func (e *OutputFormat) Type() string {
+	return "outputFormat"
+}
@@ -2,17 +2,37 @@ package validatecmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
+	"os"
+	"strings"
 
 	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
 	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/pacman"
+	"github.com/acronis/go-cti/metadata/schemaref"
+	"github.com/acronis/go-cti/metadata/storage"
+	"github.com/acronis/go-cti/metadata/validator"
 
 	"github.com/spf13/cobra"
 )
 
+type ValidateOptions struct {
+	Suppress []string
+	Strict   bool
+	Offline  bool
+	Limits   validator.Limits
+	Format   OutputFormat
+	Profile  string
+	Severity map[validator.Code]validator.Severity
+}
+
 func New(ctx context.Context) *cobra.Command {
-	return &cobra.Command{
+	opts := ValidateOptions{Limits: validator.DefaultLimits(), Format: OutputFormatText}
+	cmd := &cobra.Command{
 		Use:   "validate",
 		Short: "validate cti",
 		Args:  cobra.MinimumNArgs(0),
@@ -21,13 +41,29 @@ func New(ctx context.Context) *cobra.Command {
 			if err != nil {
 				return fmt.Errorf("get working directory: %w", err)
 			}
+			if opts.Profile != "" {
+				if err := applyProfile(cmd, baseDir, &opts); err != nil {
+					return command.WrapError(fmt.Errorf("apply profile: %w", err))
+				}
+			}
 
-			return command.WrapError(execute(ctx, baseDir))
+			return command.WrapError(execute(ctx, baseDir, opts))
 		},
 	}
+
+	cmd.Flags().BoolVar(&opts.Strict, "strict", false, "Report warnings as errors, reject unknown annotations and schema keywords.")
+	cmd.Flags().BoolVar(&opts.Offline, "offline", false, "Do not download remote schemas referenced with $ref, use cached copies only.")
+	cmd.Flags().IntVar(&opts.Limits.MaxSchemaDepth, "max-schema-depth", opts.Limits.MaxSchemaDepth, "Maximum nesting depth of a schema after $ref resolution. 0 disables the limit.")
+	cmd.Flags().IntVar(&opts.Limits.MaxSchemaSize, "max-schema-size", opts.Limits.MaxSchemaSize, "Maximum number of JSON values in a resolved or merged schema. 0 disables the limit.")
+	cmd.Flags().IntVar(&opts.Limits.MaxInstanceDepth, "max-instance-depth", opts.Limits.MaxInstanceDepth, "Maximum nesting depth of instance values and traits. 0 disables the limit.")
+	cmd.Flags().VarP(&opts.Format, "format", "f", `Output format. allowed: `+strings.Join(ListOutputFormats, ","))
+	cmd.Flags().StringVar(&opts.Profile, "profile", "", "Validation profile defined in "+cti.ConfigFileName+", e.g. release.")
+	cmd.Flags().StringSliceVar(&opts.Suppress, "suppress", nil, "Diagnostic codes to suppress, e.g. CTI1003. See 'cti explain'.")
+
+	return cmd
 }
 
-func execute(ctx context.Context, baseDir string) error {
+func execute(ctx context.Context, baseDir string, opts ValidateOptions) error {
 	slog.Info("Validating package", slog.String("path", baseDir))
 
 	pkg, err := ctipackage.New(baseDir)
@@ -39,10 +75,61 @@ func execute(ctx context.Context, baseDir string) error {
 		return fmt.Errorf("read package: %w", err)
 	}
 
+	var codes []validator.Code
+	for _, code := range opts.Suppress {
+		if _, ok := validator.Explain(validator.Code(code)); !ok {
+			return fmt.Errorf("unknown diagnostic code: %s", code)
+		}
+		codes = append(codes, validator.Code(code))
+	}
+
+	mode := validator.ModePermissive
+	if opts.Strict {
+		mode = validator.ModeStrict
+	}
+
+	cacheDir, err := pacman.GetSchemaCacheDir()
+	if err != nil {
+		return fmt.Errorf("get schema cache dir: %w", err)
+	}
+	resolver := schemaref.New(pkg.BaseDir, schemaref.WithCacheDir(cacheDir), schemaref.WithOffline(opts.Offline || storage.IsOffline()),
+		schemaref.WithLimits(opts.Limits.MaxSchemaDepth, opts.Limits.MaxSchemaSize))
+
+	validatorOpts := []validator.Option{
+		validator.WithSuppressed(codes...),
+		validator.WithMode(mode),
+		validator.WithRefResolver(resolver),
+		validator.WithLimits(opts.Limits),
+	}
+	for code, severity := range opts.Severity {
+		validatorOpts = append(validatorOpts, validator.WithSeverity(code, severity))
+	}
+
+	if opts.Format == OutputFormatJSON {
+		report, err := pkg.ValidateReport(validatorOpts...)
+		if err != nil {
+			return fmt.Errorf("validate package: %w", err)
+		}
+		return writeReport(os.Stdout, report)
+	}
+
 	// TODO: Validation for usage of indirect dependencies
-	if err := pkg.Validate(); err != nil {
+	if err := pkg.Validate(validatorOpts...); err != nil {
 		return fmt.Errorf("validate package: %w", err)
 	}
 	slog.Info("No errors found")
 	return nil
 }
+
+// writeReport writes the report as JSON and returns an error if the report contains errors.
+func writeReport(w io.Writer, report *validator.Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(report); err != nil {
+		return fmt.Errorf("write report: %w", err)
+	}
+	if !report.Valid {
+		return fmt.Errorf("validation failed with %d error(s)", report.Summary.Errors)
+	}
+	return nil
+}
@@ -0,0 +1,47 @@
+package validatecmd
+
+import (
+	"fmt"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/command"
+	"github.com/acronis/go-cti/cmd/cti/internal/cti"
+
+	"github.com/spf13/cobra"
+)
+
+// applyProfile applies the validation profile from the configuration file of the package.
+// Flags that are set explicitly take precedence over the profile, suppressed codes are combined.
+func applyProfile(cmd *cobra.Command, baseDir string, opts *ValidateOptions) error {
+	cfg, err := cti.LoadConfig(baseDir)
+	if err != nil {
+		return fmt.Errorf("load config: %w", err)
+	}
+	profile, err := cfg.Validate.Profile(opts.Profile)
+	if err != nil {
+		return err
+	}
+
+	setBool := func(flag string, dst *bool, val *bool) {
+		if val != nil && !cmd.Flags().Changed(flag) {
+			*dst = *val
+		}
+	}
+	setInt := func(flag string, dst *int, val *int) {
+		if val != nil && !cmd.Flags().Changed(flag) {
+			*dst = *val
+		}
+	}
+	setBool("strict", &opts.Strict, profile.Strict)
+	setBool("offline", &opts.Offline, profile.Offline)
+	setInt("max-schema-depth", &opts.Limits.MaxSchemaDepth, profile.Limits.MaxSchemaDepth)
+	setInt("max-schema-size", &opts.Limits.MaxSchemaSize, profile.Limits.MaxSchemaSize)
+	setInt("max-instance-depth", &opts.Limits.MaxInstanceDepth, profile.Limits.MaxInstanceDepth)
+	opts.Suppress = append(opts.Suppress, profile.Suppress...)
+
+	severities, err := command.ProfileSeverity(opts.Profile, profile)
+	if err != nil {
+		return err
+	}
+	opts.Severity = severities
+	return nil
+}
@@ -1,6 +1,274 @@
 package cti
 
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ConfigFileName is a name of the optional tool configuration file in the package directory.
+const ConfigFileName = ".cti.yaml"
+
+// GlobalConfigDirName and GlobalConfigFileName locate the optional configuration file in the home directory.
+const (
+	GlobalConfigDirName  = ".cti"
+	GlobalConfigFileName = "config.yaml"
+	// TemplatesDirName is the directory of user templates of 'cti init' in the global configuration directory.
+	TemplatesDirName = "templates"
+)
+
 // Options defines a set of options to configure gbs.
 type Options struct {
 	// TODO remove unnecessary
 }
+
+// Config is a tool configuration read from the package directory.
+type Config struct {
+	Validate ValidateConfig `yaml:"validate"`
+	Gen      GenConfig      `yaml:"gen"`
+	Fmt      FmtConfig      `yaml:"fmt"`
+	Rest     RestConfig     `yaml:"rest"`
+	Deploy   DeployConfig   `yaml:"deploy"`
+	Init     InitConfig     `yaml:"init"`
+	// Environments are stages by names selected with --env.
+	Environments map[string]Environment `yaml:"environments"`
+	// DefaultEnv is the environment of deploy commands run without --env.
+	DefaultEnv string `yaml:"default_env"`
+	// CacheDir is the directory of downloaded packages and remote schemas, ~/.cti/src by default.
+	CacheDir string `yaml:"cache_dir"`
+	// Proxy is the URL of the proxy of requests to registries, sources of packages and remote schemas.
+	// Defaults to HTTPS_PROXY.
+	Proxy string `yaml:"proxy"`
+	// Offline installs packages and resolves remote schemas from the cache only.
+	Offline bool `yaml:"offline"`
+	// NoColor disables colors of the log. Defaults to NO_COLOR.
+	NoColor bool `yaml:"no_color"`
+}
+
+type DeployConfig struct {
+	// Hooks run for deployments to all environments, before hooks of the environment.
+	Hooks DeployHooks `yaml:"hooks"`
+}
+
+// DeployHooks are shell commands run in the package directory before and after deployments, see cti deploy.
+type DeployHooks struct {
+	// Pre commands run before the deployment, a failing command cancels it.
+	Pre []string `yaml:"pre"`
+	// Post commands run after the deployment, whether it succeeded or not.
+	Post []string `yaml:"post"`
+}
+
+// Environment is a named stage that packages are deployed to, e.g. dev, staging or prod.
+type Environment struct {
+	// Registry is an http:// or https:// URL of the deployment API, or an oci:// or oci+http:// URL of a repository
+	// of an OCI registry, optionally with the tag of the active artifact, e.g. oci://registry.example.com/cti/x.y:prod.
+	Registry string           `yaml:"registry"`
+	Auth     ClientAuthConfig `yaml:"auth"`
+	// Profile is a validation profile of validate.profiles that packages must pass in the environment.
+	Profile string `yaml:"profile"`
+	// RequireCompat refuses deployments that break clients of the deployed version, see cti deploy --require-compat.
+	RequireCompat bool `yaml:"require_compat"`
+	// SigningKeyEnv is an environment variable with the PEM encoded private key that signs bundles pushed to OCI
+	// registries.
+	SigningKeyEnv string      `yaml:"signing_key_env"`
+	Hooks         DeployHooks `yaml:"hooks"`
+}
+
+// ClientAuthConfig authenticates requests to a registry with a static token or with tokens issued by an OpenID
+// Connect provider. Secrets are read from environment variables, so they are not stored in the package.
+type ClientAuthConfig struct {
+	TokenEnv string            `yaml:"token_env"`
+	OIDC     *ClientOIDCConfig `yaml:"oidc"`
+}
+
+// ClientOIDCConfig configures the client credentials flow with an OpenID Connect provider.
+type ClientOIDCConfig struct {
+	Issuer          string   `yaml:"issuer"`
+	ClientID        string   `yaml:"client_id"`
+	ClientSecretEnv string   `yaml:"client_secret_env"`
+	Scopes          []string `yaml:"scopes"`
+	Audience        string   `yaml:"audience"`
+}
+
+// RestConfig configures the registry server run with 'cti rest'.
+type RestConfig struct {
+	Auth AuthConfig `yaml:"auth"`
+	// Webhooks are notified about changes of entities when the registry is reloaded with --watch.
+	Webhooks []WebhookConfig `yaml:"webhooks"`
+}
+
+// WebhookConfig is an URL notified about changes of the registry.
+type WebhookConfig struct {
+	URL string `yaml:"url"`
+	// SecretEnv is an environment variable with the secret signing notifications. Not signed if empty.
+	SecretEnv string `yaml:"secret_env"`
+}
+
+// AuthConfig enables authentication of clients of the registry server.
+// Clients are not authenticated if neither tokens nor an OIDC provider are configured.
+type AuthConfig struct {
+	Tokens []TokenConfig `yaml:"tokens"`
+	OIDC   *OIDCConfig   `yaml:"oidc"`
+}
+
+// TokenConfig is a static token granting the role: read or admin.
+// The token is read from the environment variable, so secrets are not stored in the package.
+type TokenConfig struct {
+	Env  string `yaml:"env"`
+	Role string `yaml:"role"`
+}
+
+// OIDCConfig configures authentication by tokens issued by an OpenID Connect provider.
+type OIDCConfig struct {
+	Issuer   string `yaml:"issuer"`
+	Audience string `yaml:"audience"`
+	// RolesClaim is a path to the claim with roles or groups of the client, e.g. realm_access.roles. Defaults to roles.
+	RolesClaim string `yaml:"roles_claim"`
+	// Roles maps values of the claim to roles, e.g. registry-admins: admin.
+	Roles map[string]string `yaml:"roles"`
+	// DefaultRole is granted to all clients with a valid token.
+	DefaultRole string `yaml:"default_role"`
+}
+
+// InitConfig are defaults of 'cti init'.
+type InitConfig struct {
+	// Template is the template of new packages, see 'cti init --template'.
+	Template string `yaml:"template"`
+	// TemplatesDir is the directory of user templates selected by names, ~/.cti/templates by default.
+	TemplatesDir string `yaml:"templates_dir"`
+	// Git initializes git repositories of new packages, see 'cti init --git'.
+	Git *bool `yaml:"git"`
+}
+
+// TemplatesDirPath returns the directory of user templates of 'cti init'.
+func (c *InitConfig) TemplatesDirPath() (string, error) {
+	if c.TemplatesDir != "" {
+		return c.TemplatesDir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home directory: %w", err)
+	}
+	return filepath.Join(home, GlobalConfigDirName, TemplatesDirName), nil
+}
+
+// FmtConfig is a style of RAML files formatted with 'cti fmt'. Unset fields keep the default style.
+type FmtConfig struct {
+	Indent          int    `yaml:"indent"`
+	Quote           string `yaml:"quote"`
+	SortMembers     bool   `yaml:"sort_members"`
+	SortAnnotations bool   `yaml:"sort_annotations"`
+}
+
+type GenConfig struct {
+	AsyncAPI AsyncAPIConfig `yaml:"asyncapi"`
+}
+
+type AsyncAPIConfig struct {
+	// Channels maps CTIs of event types to addresses of channels, e.g. cti.x.y.event.v1.0: events.
+	Channels map[string]string `yaml:"channels"`
+}
+
+type ValidateConfig struct {
+	// Profiles are named rule sets selected with 'cti validate --profile'.
+	Profiles map[string]ValidateProfile `yaml:"profiles"`
+}
+
+// ValidateProfile is a named set of validation rules and strictness.
+// Unset fields keep the defaults of the command.
+type ValidateProfile struct {
+	Strict   *bool    `yaml:"strict"`
+	Offline  *bool    `yaml:"offline"`
+	Suppress []string `yaml:"suppress"`
+	// Severity overrides the default severity of diagnostic codes, e.g. CTI1060: error.
+	Severity map[string]string `yaml:"severity"`
+	Limits   ProfileLimits     `yaml:"limits"`
+}
+
+type ProfileLimits struct {
+	MaxSchemaDepth   *int `yaml:"max_schema_depth"`
+	MaxSchemaSize    *int `yaml:"max_schema_size"`
+	MaxInstanceDepth *int `yaml:"max_instance_depth"`
+}
+
+// LoadConfig reads the effective configuration of the package directory: keys of the configuration file of the
+// package override keys of the global configuration file, and environment variables override both, see LoadLayers.
+// Environments and validation profiles of the package replace global definitions with the same names. Empty
+// configuration is returned if neither file exists.
+func LoadConfig(dir string) (*Config, error) {
+	layers, err := LoadLayers(dir)
+	if err != nil {
+		return nil, err
+	}
+	return layers.Config()
+}
+
+// ConfigPath returns the path of the configuration file of the package directory, or of the global configuration
+// file if global is set.
+func ConfigPath(dir string, global bool) (string, error) {
+	if global {
+		return GlobalConfigPath()
+	}
+	return filepath.Join(dir, ConfigFileName), nil
+}
+
+// ConfigEnvVar overrides the path of the global configuration file.
+const ConfigEnvVar = "CTI_CONFIG"
+
+// GlobalConfigPath returns the path of the configuration file shared by packages of the user,
+// ~/.cti/config.yaml unless it is overridden with CTI_CONFIG.
+func GlobalConfigPath() (string, error) {
+	if path := os.Getenv(ConfigEnvVar); path != "" {
+		return path, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("get home directory: %w", err)
+	}
+	return filepath.Join(home, GlobalConfigDirName, GlobalConfigFileName), nil
+}
+
+// Profile returns the validation profile with the specified name.
+func (c *ValidateConfig) Profile(name string) (ValidateProfile, error) {
+	profile, ok := c.Profiles[name]
+	if !ok {
+		names := make([]string, 0, len(c.Profiles))
+		for n := range c.Profiles {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		if len(names) == 0 {
+			return ValidateProfile{}, fmt.Errorf("unknown profile %s: no profiles are defined in %s", name, ConfigFileName)
+		}
+		return ValidateProfile{}, fmt.Errorf("unknown profile %s, available profiles: %s", name, strings.Join(names, ", "))
+	}
+	return profile, nil
+}
+
+// Environment returns the environment with the name. The name may be omitted if the default environment is set
+// or a single environment is defined.
+func (c *Config) Environment(name string) (string, Environment, error) {
+	if name == "" {
+		name = c.DefaultEnv
+	}
+	names := make([]string, 0, len(c.Environments))
+	for n := range c.Environments {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	switch {
+	case len(names) == 0:
+		return "", Environment{}, fmt.Errorf("no environments are defined in %s or the global configuration", ConfigFileName)
+	case name == "" && len(names) == 1:
+		return names[0], c.Environments[names[0]], nil
+	case name == "":
+		return "", Environment{}, fmt.Errorf("environment is not specified, available environments: %s", strings.Join(names, ", "))
+	}
+	env, ok := c.Environments[name]
+	if !ok {
+		return "", Environment{}, fmt.Errorf("unknown environment %s, available environments: %s", name, strings.Join(names, ", "))
+	}
+	return name, env, nil
+}
@@ -0,0 +1,190 @@
+package cti
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeLayers writes the global configuration and the configuration of the package, files are not written if their
+// contents are empty. It returns the package directory.
+func writeLayers(t *testing.T, global, project string) string {
+	t.Helper()
+	globalPath := filepath.Join(t.TempDir(), GlobalConfigFileName)
+	t.Setenv(ConfigEnvVar, globalPath)
+	dir := t.TempDir()
+	if global != "" {
+		require.NoError(t, os.WriteFile(globalPath, []byte(global), 0600))
+	}
+	if project != "" {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, ConfigFileName), []byte(project), 0600))
+	}
+	return dir
+}
+
+func Test_LoadLayers(t *testing.T) {
+	testCases := []struct {
+		name    string
+		global  string
+		project string
+		env     map[string]string
+		key     string
+		value   string
+		source  ConfigLayer
+	}{
+		{name: "global", global: "default_env: dev\n", key: "default_env", value: "dev", source: LayerGlobal},
+		{name: "project", project: "default_env: prod\n", key: "default_env", value: "prod", source: LayerProject},
+		{
+			name: "project overrides global", global: "default_env: dev\n", project: "default_env: prod\n",
+			key: "default_env", value: "prod", source: LayerProject,
+		},
+		{
+			name: "env overrides project", global: "default_env: dev\n", project: "default_env: prod\n",
+			env: map[string]string{"CTI_DEFAULT_ENV": "staging"}, key: "default_env", value: "staging", source: LayerEnv,
+		},
+		{
+			name: "empty env does not override", project: "default_env: prod\n",
+			env: map[string]string{"CTI_DEFAULT_ENV": ""}, key: "default_env", value: "prod", source: LayerProject,
+		},
+		{
+			name: "sections are merged", global: "fmt:\n  indent: 4\n", project: "fmt:\n  quote: single\n",
+			key: "fmt.indent", value: "4", source: LayerGlobal,
+		},
+		{
+			name: "environments are replaced", global: "environments:\n  prod:\n    registry: https://a\n    profile: release\n",
+			project: "environments:\n  prod:\n    registry: https://b\n", key: "environments.prod.profile",
+		},
+		{
+			name: "env overrides environments", project: "environments:\n  prod:\n    registry: https://b\n",
+			env: map[string]string{"CTI_ENVIRONMENTS_PROD_REGISTRY": "https://c"}, key: "environments.prod.registry",
+			value: "https://c", source: LayerEnv,
+		},
+		{
+			name: "env flag", env: map[string]string{"CTI_OFFLINE": "1"}, key: "offline", value: "true", source: LayerEnv,
+		},
+		{name: "default of setting", key: "offline", value: "false", source: LayerDefault},
+		{name: "unset", key: "default_env"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeLayers(t, tc.global, tc.project)
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+
+			layers, err := LoadLayers(dir)
+			require.NoError(t, err)
+			v, err := layers.Get(tc.key)
+			require.NoError(t, err)
+			if tc.source == "" {
+				require.Nil(t, v)
+				return
+			}
+			require.NotNil(t, v)
+			require.Equal(t, tc.value, v.Value)
+			require.Equal(t, tc.source, v.Source)
+		})
+	}
+}
+
+func Test_LoadConfig(t *testing.T) {
+	dir := writeLayers(t,
+		"fmt:\n  indent: 4\nenvironments:\n  prod:\n    registry: https://a\n    profile: release\n  dev:\n    registry: https://d\n",
+		"fmt:\n  quote: single\nenvironments:\n  prod:\n    registry: https://b\n")
+	t.Setenv("CTI_ENVIRONMENTS_DEV_REGISTRY", "https://e")
+
+	cfg, err := LoadConfig(dir)
+	require.NoError(t, err)
+	require.Equal(t, FmtConfig{Indent: 4, Quote: "single"}, cfg.Fmt)
+	require.Equal(t, map[string]Environment{"prod": {Registry: "https://b"}, "dev": {Registry: "https://e"}}, cfg.Environments)
+}
+
+func Test_LoadLayersErrors(t *testing.T) {
+	testCases := []struct {
+		name    string
+		global  string
+		project string
+		env     map[string]string
+		err     string
+	}{
+		{name: "malformed project", project: "fmt: [", err: ConfigFileName + ": yaml: line 1: did not find expected node content"},
+		{name: "malformed global", global: "fmt:\n  indent: four\n", err: GlobalConfigFileName + ": yaml: unmarshal errors"},
+		{name: "unknown key", project: "fmt:\n  width: 80\n", err: "field width not found"},
+		{
+			name: "malformed env", env: map[string]string{"CTI_FMT_INDENT": "four"},
+			err: "environment variable CTI_FMT_INDENT: invalid value of fmt.indent",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dir := writeLayers(t, tc.global, tc.project)
+			for name, value := range tc.env {
+				t.Setenv(name, value)
+			}
+			_, err := LoadLayers(dir)
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+
+	// Missing layers are empty.
+	cfg, err := LoadConfig(writeLayers(t, "", ""))
+	require.NoError(t, err)
+	require.Equal(t, &Config{}, cfg)
+}
+
+func Test_ConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", ConfigFileName)
+	f, err := ReadConfigFile(path)
+	require.NoError(t, err)
+
+	require.NoError(t, f.Set("environments.prod.registry", "https://registry.example.com"))
+	require.NoError(t, f.Set("fmt.indent", "4"))
+	require.NoError(t, f.Set("gen.asyncapi.channels.cti.x.y.event.v1.0", "events"))
+	require.NoError(t, f.Save())
+
+	f, err = ReadConfigFile(path)
+	require.NoError(t, err)
+	cfg, err := decodeConfig(mustReadFile(t, path), path)
+	require.NoError(t, err)
+	require.Equal(t, "https://registry.example.com", cfg.Environments["prod"].Registry)
+	require.Equal(t, 4, cfg.Fmt.Indent)
+	require.Equal(t, map[string]string{"cti.x.y.event.v1.0": "events"}, cfg.Gen.AsyncAPI.Channels)
+
+	unset, err := f.Unset("environments.prod.registry")
+	require.NoError(t, err)
+	require.True(t, unset)
+	unset, err = f.Unset("environments.prod.registry")
+	require.NoError(t, err)
+	require.False(t, unset)
+	require.NoError(t, f.Save())
+	// Sections left empty are removed.
+	require.Equal(t, "fmt:\n  indent: 4\ngen:\n  asyncapi:\n    channels:\n      cti.x.y.event.v1.0: events\n", string(mustReadFile(t, path)))
+
+	testCases := []struct {
+		key   string
+		value string
+		err   string
+	}{
+		{key: "fmt.width", value: "80", err: "unknown key fmt.width"},
+		{key: "default_env.name", value: "prod", err: "unknown key default_env.name: default_env is not a section"},
+		{key: "fmt..indent", value: "4", err: `invalid key "fmt..indent"`},
+		{key: "fmt.indent", value: "four", err: "invalid value of fmt.indent"},
+		{key: "offline", value: "maybe", err: "invalid value of offline"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.key, func(t *testing.T) {
+			require.ErrorContains(t, f.Set(tc.key, tc.value), tc.err)
+		})
+	}
+}
+
+func mustReadFile(t *testing.T, path string) []byte {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	return data
+}
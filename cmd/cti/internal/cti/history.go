@@ -0,0 +1,94 @@
+package cti
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/acronis/go-cti/metadata/deploy"
+)
+
+// DeploymentsFileName is the file in the global configuration directory that records deployments made by the user.
+const DeploymentsFileName = "deployments.jsonl"
+
+// LocalDeployment is a deployment recorded locally, see RecordDeployment.
+type LocalDeployment struct {
+	Env      string `json:"env"`
+	Registry string `json:"registry"`
+	deploy.Deployment
+}
+
+// DeploymentsPath returns the path of the local history of deployments.
+func DeploymentsPath() (string, error) {
+	configPath, err := GlobalConfigPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(filepath.Dir(configPath), DeploymentsFileName), nil
+}
+
+// RecordDeployment appends the deployment to the environment to the local history, so it is known even if the
+// registry is not available.
+func RecordDeployment(env string, cfg Environment, d *deploy.Deployment) error {
+	path, err := DeploymentsPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+	record := LocalDeployment{Env: env, Registry: cfg.Registry, Deployment: *d}
+	if record.Time.IsZero() {
+		record.Time = time.Now().UTC().Truncate(time.Second)
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("encode deployment: %w", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("write %s: %w", path, err)
+	}
+	return nil
+}
+
+// ReadDeployments returns deployments of the package to the registry of the environment recorded locally,
+// the latest first.
+func ReadDeployments(env string, cfg Environment, packageID string) ([]*deploy.Deployment, error) {
+	path, err := DeploymentsPath()
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var history []*deploy.Deployment
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		var record LocalDeployment
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			return nil, fmt.Errorf("decode %s:%d: %w", path, line, err)
+		}
+		if record.Env == env && record.Registry == cfg.Registry && record.Package == packageID {
+			history = append([]*deploy.Deployment{&record.Deployment}, history...)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %w", path, err)
+	}
+	return history, nil
+}
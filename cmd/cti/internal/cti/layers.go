@@ -0,0 +1,536 @@
+package cti
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ConfigLayer is a source of configuration values. Keys of the project override keys of the global configuration,
+// and environment variables override both.
+type ConfigLayer string
+
+const (
+	LayerGlobal  ConfigLayer = "global"
+	LayerProject ConfigLayer = "project"
+	LayerEnv     ConfigLayer = "env"
+)
+
+// EnvVarPrefix prefixes environment variables that override keys of the configuration, see EnvVarName.
+const EnvVarPrefix = "CTI_"
+
+// replacedSections hold definitions that the project replaces as a whole instead of merging them key by key,
+// so e.g. an environment of the package does not inherit the registry of the global environment with the same name.
+var replacedSections = []string{"environments", "validate.profiles"}
+
+var configType = reflect.TypeOf(Config{})
+
+// ConfigFile is a configuration file of a layer. Keys are set and unset in place, keeping comments and the order
+// of other keys.
+type ConfigFile struct {
+	Path string
+	doc  *yaml.Node
+}
+
+// ConfigValue is an effective value of a key and the layer it comes from.
+type ConfigValue struct {
+	Key string `json:"key"`
+	// Value is a scalar value, or a list or a mapping in the YAML flow style.
+	Value  string      `json:"value"`
+	Source ConfigLayer `json:"source"`
+	// Origin is the path of the file or the name of the environment variable that sets the value.
+	Origin string     `json:"origin"`
+	Node   *yaml.Node `json:"-"`
+}
+
+// Layers are the global configuration file of the user and the configuration file of the package, with environment
+// variables overriding them.
+type Layers struct {
+	Global  *ConfigFile
+	Project *ConfigFile
+	// merged holds keys of both files and overrides of environment variables.
+	merged *yaml.Node
+	// overrides maps overridden keys to names of environment variables.
+	overrides map[string]string
+}
+
+// LoadLayers reads configuration files of the package directory and of the user and applies overrides of environment
+// variables. Empty variables do not override keys.
+func LoadLayers(dir string) (*Layers, error) {
+	project, err := ReadConfigFile(filepath.Join(dir, ConfigFileName))
+	if err != nil {
+		return nil, err
+	}
+	globalPath, err := GlobalConfigPath()
+	if err != nil {
+		return nil, err
+	}
+	global, err := ReadConfigFile(globalPath)
+	if err != nil {
+		return nil, err
+	}
+	l := &Layers{
+		Global:    global,
+		Project:   project,
+		merged:    mergeNodes(global.root(), project.root(), ""),
+		overrides: map[string]string{},
+	}
+	for _, segs := range overridableKeys(configType, nil, l.merged) {
+		name := EnvVarName(strings.Join(segs, "."))
+		value := os.Getenv(name)
+		if value == "" || exported[name] {
+			continue
+		}
+		node, err := parseValue(segs, value)
+		if err != nil {
+			return nil, fmt.Errorf("environment variable %s: %w", name, err)
+		}
+		setNode(l.merged, segs, node)
+		l.overrides[strings.Join(segs, ".")] = name
+	}
+	return l, nil
+}
+
+// Config returns the effective configuration.
+func (l *Layers) Config() (*Config, error) {
+	data, err := yaml.Marshal(l.merged)
+	if err != nil {
+		return nil, fmt.Errorf("encode config: %w", err)
+	}
+	return decodeConfig(data, "config")
+}
+
+// Values returns effective values of keys that are set, and defaults of settings that are not, sorted by keys.
+// Values of lists and of sections are returned as a whole, values of mappings key by key.
+func (l *Layers) Values() []ConfigValue {
+	var values []ConfigValue
+	walkValues(configType, nil, l.merged, func(segs []string, node *yaml.Node) {
+		values = append(values, l.value(segs, node))
+	})
+	for _, key := range settings {
+		if lookupNode(l.merged, []string{key}) == nil {
+			if v := defaultValue(key); v != nil {
+				values = append(values, *v)
+			}
+		}
+	}
+	sort.Slice(values, func(i, j int) bool { return values[i].Key < values[j].Key })
+	return values
+}
+
+// Get returns the effective value of the key, the default of a setting or nil if it is not set.
+func (l *Layers) Get(key string) (*ConfigValue, error) {
+	segs, _, err := splitKey(key)
+	if err != nil {
+		return nil, err
+	}
+	node := lookupNode(l.merged, segs)
+	if node == nil {
+		return defaultValue(key), nil
+	}
+	v := l.value(segs, node)
+	return &v, nil
+}
+
+func (l *Layers) value(segs []string, node *yaml.Node) ConfigValue {
+	v := ConfigValue{Key: strings.Join(segs, "."), Value: formatValue(node), Node: node}
+	for i := len(segs); i > 0; i-- {
+		if name, ok := l.overrides[strings.Join(segs[:i], ".")]; ok {
+			v.Source, v.Origin = LayerEnv, name
+			return v
+		}
+	}
+	if lookupNode(l.Project.root(), segs) != nil {
+		v.Source, v.Origin = LayerProject, l.Project.Path
+	} else {
+		v.Source, v.Origin = LayerGlobal, l.Global.Path
+	}
+	return v
+}
+
+// EnvVarName returns the name of the environment variable that overrides the key: the key in upper case prefixed
+// with CTI_, with characters other than letters and digits replaced with underscores,
+// e.g. CTI_ENVIRONMENTS_PROD_REGISTRY overrides environments.prod.registry.
+func EnvVarName(key string) string {
+	return EnvVarPrefix + strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return r - 'a' + 'A'
+		case r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			return r
+		default:
+			return '_'
+		}
+	}, key)
+}
+
+// ReadConfigFile reads the configuration file. An empty configuration is returned if the file does not exist.
+func ReadConfigFile(path string) (*ConfigFile, error) {
+	f := &ConfigFile{Path: path, doc: &yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{newMapping()}}}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return f, nil
+		}
+		return nil, fmt.Errorf("read config: %w", err)
+	}
+	if _, err := decodeConfig(data, path); err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", path, err)
+	}
+	if len(doc.Content) == 1 && doc.Content[0].Kind == yaml.MappingNode {
+		f.doc = &doc
+	}
+	return f, nil
+}
+
+// Set sets the key to the value parsed as YAML, e.g. true, 4 or [a, b].
+func (f *ConfigFile) Set(key, value string) error {
+	segs, _, err := splitKey(key)
+	if err != nil {
+		return err
+	}
+	node, err := parseValue(segs, value)
+	if err != nil {
+		return err
+	}
+	setNode(f.root(), segs, node)
+	data, err := yaml.Marshal(f.root())
+	if err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	if _, err := decodeConfig(data, f.Path); err != nil {
+		return fmt.Errorf("set %s: %w", key, err)
+	}
+	return nil
+}
+
+// Unset removes the key and sections left empty. It reports whether the key was set.
+func (f *ConfigFile) Unset(key string) (bool, error) {
+	segs, _, err := splitKey(key)
+	if err != nil {
+		return false, err
+	}
+	return unsetNode(f.root(), segs), nil
+}
+
+// Save writes the configuration file, creating its directory if needed.
+func (f *ConfigFile) Save() error {
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(f.doc); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return fmt.Errorf("encode config: %w", err)
+	}
+	perm := os.FileMode(0o644)
+	if info, err := os.Stat(f.Path); err == nil {
+		perm = info.Mode().Perm()
+	}
+	if err := os.MkdirAll(filepath.Dir(f.Path), 0o755); err != nil {
+		return fmt.Errorf("create config directory: %w", err)
+	}
+	if err := os.WriteFile(f.Path, buf.Bytes(), perm); err != nil {
+		return fmt.Errorf("write config: %w", err)
+	}
+	return nil
+}
+
+func (f *ConfigFile) root() *yaml.Node {
+	return f.doc.Content[0]
+}
+
+func decodeConfig(data []byte, source string) (*Config, error) {
+	cfg := &Config{}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("decode %s: %w", source, err)
+	}
+	return cfg, nil
+}
+
+// splitKey splits the dotted key into names of mapping keys and returns the type of its value. Keys of mappings
+// with values other than sections take the rest of the key, so they may contain dots, e.g. CTIs of channels.
+func splitKey(key string) ([]string, reflect.Type, error) {
+	if key == "" || strings.HasPrefix(key, ".") || strings.HasSuffix(key, ".") || strings.Contains(key, "..") {
+		return nil, nil, fmt.Errorf("invalid key %q", key)
+	}
+	var segs []string
+	t, rest := configType, key
+	for rest != "" {
+		t = deref(t)
+		switch {
+		case t.Kind() == reflect.Struct:
+			name, tail, _ := strings.Cut(rest, ".")
+			field, ok := structField(t, name)
+			if !ok {
+				return nil, nil, fmt.Errorf("unknown key %s", key)
+			}
+			segs, t, rest = append(segs, name), field.Type, tail
+		case t.Kind() == reflect.Map && deref(t.Elem()).Kind() == reflect.Struct:
+			name, tail, _ := strings.Cut(rest, ".")
+			segs, t, rest = append(segs, name), t.Elem(), tail
+		case t.Kind() == reflect.Map:
+			segs, t, rest = append(segs, rest), t.Elem(), ""
+		default:
+			return nil, nil, fmt.Errorf("unknown key %s: %s is not a section", key, strings.Join(segs, "."))
+		}
+	}
+	return segs, t, nil
+}
+
+// overridableKeys returns keys of the type that environment variables may override: all fields of sections and
+// fields of definitions of environments and profiles that are in the configuration. Lists and mappings are
+// overridden as a whole.
+func overridableKeys(t reflect.Type, segs []string, node *yaml.Node) [][]string {
+	t = deref(t)
+	switch {
+	case t.Kind() == reflect.Struct:
+		var keys [][]string
+		for i := range t.NumField() {
+			name := yamlName(t.Field(i))
+			if name == "" {
+				continue
+			}
+			child := append(slices.Clone(segs), name)
+			keys = append(keys, overridableKeys(t.Field(i).Type, child, lookupNode(node, []string{name}))...)
+		}
+		return keys
+	case t.Kind() == reflect.Map && deref(t.Elem()).Kind() == reflect.Struct:
+		if node == nil || node.Kind != yaml.MappingNode {
+			return nil
+		}
+		var keys [][]string
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			child := append(slices.Clone(segs), node.Content[i].Value)
+			keys = append(keys, overridableKeys(t.Elem(), child, node.Content[i+1])...)
+		}
+		return keys
+	default:
+		return [][]string{segs}
+	}
+}
+
+// walkValues visits values of the node of the type, see Layers.Values.
+func walkValues(t reflect.Type, segs []string, node *yaml.Node, visit func([]string, *yaml.Node)) {
+	t = deref(t)
+	if node.Kind != yaml.MappingNode || (t.Kind() != reflect.Struct && t.Kind() != reflect.Map) {
+		visit(segs, node)
+		return
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		name, value := node.Content[i].Value, node.Content[i+1]
+		child := append(slices.Clone(segs), name)
+		switch {
+		case t.Kind() == reflect.Struct:
+			if field, ok := structField(t, name); ok {
+				walkValues(field.Type, child, value, visit)
+			}
+		case deref(t.Elem()).Kind() == reflect.Struct:
+			walkValues(t.Elem(), child, value, visit)
+		default:
+			visit(child, value)
+		}
+	}
+}
+
+func structField(t reflect.Type, name string) (reflect.StructField, bool) {
+	for i := range t.NumField() {
+		if yamlName(t.Field(i)) == name {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func yamlName(f reflect.StructField) string {
+	name, _, _ := strings.Cut(f.Tag.Get("yaml"), ",")
+	if name == "-" {
+		return ""
+	}
+	return name
+}
+
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	return t
+}
+
+// parseValue parses the value of the key as YAML. Values of string keys are always strings, e.g. 123 or true.
+func parseValue(segs []string, value string) (*yaml.Node, error) {
+	_, t, err := splitKey(strings.Join(segs, "."))
+	if err != nil {
+		return nil, err
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal([]byte(value), &doc); err != nil {
+		return nil, fmt.Errorf("parse value of %s: %w", strings.Join(segs, "."), err)
+	}
+	node := &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	if len(doc.Content) == 1 {
+		node = doc.Content[0]
+	}
+	if deref(t).Kind() == reflect.String && node.Kind == yaml.ScalarNode {
+		node = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: value}
+	}
+	// Flags of environment variables are often set to 1 or 0, e.g. CTI_OFFLINE=1.
+	if b, err := strconv.ParseBool(value); err == nil && deref(t).Kind() == reflect.Bool {
+		node = &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(b)}
+	}
+	data, err := yaml.Marshal(node)
+	if err != nil {
+		return nil, fmt.Errorf("encode value of %s: %w", strings.Join(segs, "."), err)
+	}
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(reflect.New(t).Interface()); err != nil {
+		return nil, fmt.Errorf("invalid value of %s: %w", strings.Join(segs, "."), err)
+	}
+	return node, nil
+}
+
+// formatValue returns the scalar value, or the value in the YAML flow style.
+func formatValue(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	flow := cloneNode(node)
+	var setFlow func(*yaml.Node)
+	setFlow = func(n *yaml.Node) {
+		n.Style = yaml.FlowStyle
+		n.HeadComment, n.LineComment, n.FootComment = "", "", ""
+		for _, c := range n.Content {
+			setFlow(c)
+		}
+	}
+	setFlow(flow)
+	data, err := yaml.Marshal(flow)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// mergeNodes returns a copy of the global mapping with keys of the project mapping merged in.
+func mergeNodes(global, project *yaml.Node, path string) *yaml.Node {
+	merged := cloneNode(global)
+	for i := 0; i+1 < len(project.Content); i += 2 {
+		key, value := project.Content[i], project.Content[i+1]
+		childPath := key.Value
+		if path != "" {
+			childPath = path + "." + key.Value
+		}
+		j := mappingIndex(merged, key.Value)
+		switch {
+		case j < 0:
+			merged.Content = append(merged.Content, cloneNode(key), cloneNode(value))
+		case value.Kind == yaml.MappingNode && merged.Content[j+1].Kind == yaml.MappingNode &&
+			!slices.Contains(replacedSections, path):
+			merged.Content[j+1] = mergeNodes(merged.Content[j+1], value, childPath)
+		default:
+			merged.Content[j+1] = cloneNode(value)
+		}
+	}
+	return merged
+}
+
+func lookupNode(node *yaml.Node, segs []string) *yaml.Node {
+	for _, seg := range segs {
+		if node == nil {
+			return nil
+		}
+		i := mappingIndex(node, seg)
+		if i < 0 {
+			return nil
+		}
+		node = node.Content[i+1]
+	}
+	return node
+}
+
+// setNode sets the value of the key, creating sections that do not exist. Comments of the replaced value are kept.
+func setNode(node *yaml.Node, segs []string, value *yaml.Node) {
+	for i, seg := range segs {
+		j := mappingIndex(node, seg)
+		if i == len(segs)-1 {
+			if j < 0 {
+				node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: seg}, value)
+				return
+			}
+			old := node.Content[j+1]
+			value.HeadComment, value.LineComment, value.FootComment = old.HeadComment, old.LineComment, old.FootComment
+			node.Content[j+1] = value
+			return
+		}
+		if j < 0 {
+			node.Content = append(node.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: seg}, newMapping())
+			j = len(node.Content) - 2
+		} else if node.Content[j+1].Kind != yaml.MappingNode {
+			node.Content[j+1] = newMapping()
+		}
+		node = node.Content[j+1]
+	}
+}
+
+// unsetNode removes the key and sections left empty by removing it. It reports whether the key was set.
+func unsetNode(node *yaml.Node, segs []string) bool {
+	i := mappingIndex(node, segs[0])
+	if i < 0 {
+		return false
+	}
+	if len(segs) > 1 {
+		child := node.Content[i+1]
+		if !unsetNode(child, segs[1:]) {
+			return false
+		}
+		if len(child.Content) != 0 {
+			return true
+		}
+	}
+	node.Content = slices.Delete(node.Content, i, i+2)
+	return true
+}
+
+// mappingIndex returns the index of the key in the mapping node, -1 if the node is not a mapping or has no such key.
+func mappingIndex(node *yaml.Node, key string) int {
+	if node.Kind != yaml.MappingNode {
+		return -1
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return i
+		}
+	}
+	return -1
+}
+
+func cloneNode(node *yaml.Node) *yaml.Node {
+	c := *node
+	c.Content = make([]*yaml.Node, len(node.Content))
+	for i, child := range node.Content {
+		c.Content[i] = cloneNode(child)
+	}
+	return &c
+}
+
+func newMapping() *yaml.Node {
+	return &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+}
@@ -0,0 +1,45 @@
+package cti
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/zalando/go-keyring"
+)
+
+// KeyringService is the service of secrets stored in the keyring of the OS: the Keychain on macOS, the Credential
+// Manager on Windows and the Secret Service on Linux.
+const KeyringService = "cti"
+
+// StoreSecret stores the secret of the registry of the environment in the keyring: the static token, or the client
+// secret if the environment authenticates with an OpenID Connect provider. Environments with the same registry share
+// the secret.
+func StoreSecret(env Environment, secret string) error {
+	if err := keyring.Set(KeyringService, env.Registry, secret); err != nil {
+		return fmt.Errorf("store secret of %s in the keyring: %w", env.Registry, err)
+	}
+	return nil
+}
+
+// LoadSecret returns the secret of the registry of the environment, empty if no secret is stored.
+func LoadSecret(env Environment) (string, error) {
+	secret, err := keyring.Get(KeyringService, env.Registry)
+	if err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return "", nil
+		}
+		return "", fmt.Errorf("read secret of %s from the keyring: %w", env.Registry, err)
+	}
+	return secret, nil
+}
+
+// DeleteSecret deletes the secret of the registry of the environment. It reports whether a secret was stored.
+func DeleteSecret(env Environment) (bool, error) {
+	if err := keyring.Delete(KeyringService, env.Registry); err != nil {
+		if errors.Is(err, keyring.ErrNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("delete secret of %s from the keyring: %w", env.Registry, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,92 @@
+package cti
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/acronis/go-cti/metadata/pacman"
+	"github.com/acronis/go-cti/metadata/storage"
+
+	"gopkg.in/yaml.v3"
+)
+
+// LayerDefault is the source of values of settings that are set by none of the layers.
+const LayerDefault ConfigLayer = "default"
+
+// NoColorEnvVar disables colors if set to any value, see https://no-color.org.
+const NoColorEnvVar = "NO_COLOR"
+
+// proxyEnvVars are variables of the proxy honored by the HTTP client and by git.
+var proxyEnvVars = []string{"HTTPS_PROXY", "HTTP_PROXY"}
+
+// settings are keys of cti itself rather than of commands. They are listed with defaults if they are not set.
+var settings = []string{"cache_dir", "no_color", "offline", "proxy"}
+
+// exported holds variables set by ApplySettings, so they are not mistaken for overrides by later loads of layers.
+var exported = map[string]bool{}
+
+// ApplySettings exports settings of the configuration to environment variables read by the package manager,
+// storages and the HTTP client, and inherited by git and hooks.
+func ApplySettings(cfg *Config) error {
+	vars := map[string]string{}
+	if cfg.CacheDir != "" {
+		vars[pacman.CacheDirEnvVar] = cfg.CacheDir
+	}
+	// CTI_OFFLINE may enable the offline mode with any true value, e.g. 1.
+	if cfg.Offline && !storage.IsOffline() {
+		vars[storage.OfflineEnvVar] = "true"
+	}
+	if cfg.Proxy != "" {
+		for _, name := range proxyEnvVars {
+			vars[name] = cfg.Proxy
+		}
+	}
+	for name, value := range vars {
+		if os.Getenv(name) == value {
+			continue
+		}
+		if err := os.Setenv(name, value); err != nil {
+			return fmt.Errorf("set %s: %w", name, err)
+		}
+		exported[name] = true
+	}
+	return nil
+}
+
+// ColorDisabled reports whether colors are disabled by the configuration or by NO_COLOR.
+func (c *Config) ColorDisabled() bool {
+	return c.NoColor || os.Getenv(NoColorEnvVar) != ""
+}
+
+// defaultValue returns the value of the setting that is set by none of the layers, nil if the key is not a setting.
+// Settings that default to standard environment variables are reported with the variable as the origin.
+func defaultValue(key string) *ConfigValue {
+	v := &ConfigValue{Key: key, Source: LayerDefault}
+	tag := "!!str"
+	switch key {
+	case "cache_dir":
+		dir, err := pacman.CacheDirPath()
+		if err != nil {
+			return nil
+		}
+		v.Value = dir
+	case "no_color":
+		v.Value, tag = "false", "!!bool"
+		if os.Getenv(NoColorEnvVar) != "" {
+			v.Value, v.Source, v.Origin = "true", LayerEnv, NoColorEnvVar
+		}
+	case "offline":
+		v.Value, tag = "false", "!!bool"
+	case "proxy":
+		for _, name := range proxyEnvVars {
+			if value := os.Getenv(name); value != "" && !exported[name] {
+				v.Value, v.Source, v.Origin = value, LayerEnv, name
+				break
+			}
+		}
+	default:
+		return nil
+	}
+	v.Node = &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.Value}
+	return v
+}
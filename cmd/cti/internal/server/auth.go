@@ -0,0 +1,167 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/tidwall/gjson"
+)
+
+// ErrUnauthenticated is returned for requests without a valid bearer token.
+var ErrUnauthenticated = errors.New("unauthenticated")
+
+// Role defines endpoints a client is allowed to call.
+type Role string
+
+const (
+	// RoleRead allows reading the registry.
+	RoleRead Role = "read"
+	// RoleAdmin allows all endpoints, including the ones changing the state of the server.
+	RoleAdmin Role = "admin"
+)
+
+// ParseRole parses the name of the role.
+func ParseRole(s string) (Role, error) {
+	switch role := Role(s); role {
+	case RoleRead, RoleAdmin:
+		return role, nil
+	default:
+		return "", fmt.Errorf("unknown role %q, expected %s or %s", s, RoleRead, RoleAdmin)
+	}
+}
+
+// allows reports whether the role grants access to endpoints requiring the role. Endpoints without a role are public.
+func (r Role) allows(required Role) bool {
+	return required == "" || r == RoleAdmin || r == required
+}
+
+// higher returns the role granting more access of the two.
+func (r Role) higher(other Role) Role {
+	if r == RoleAdmin || other == "" {
+		return r
+	}
+	return other
+}
+
+// Authenticator resolves the role of the client by its bearer token.
+// Authenticated clients without any role get the empty role and are allowed to call public endpoints only.
+type Authenticator interface {
+	Authenticate(ctx context.Context, token string) (Role, error)
+}
+
+// Authenticators tries authenticators in order and returns the role from the first one accepting the token.
+type Authenticators []Authenticator
+
+func (a Authenticators) Authenticate(ctx context.Context, token string) (Role, error) {
+	err := ErrUnauthenticated
+	for _, auth := range a {
+		var role Role
+		role, err = auth.Authenticate(ctx, token)
+		if err == nil {
+			return role, nil
+		}
+	}
+	return "", err
+}
+
+// Tokens authenticates clients by static tokens mapped to their roles.
+type Tokens map[string]Role
+
+func (t Tokens) Authenticate(_ context.Context, token string) (Role, error) {
+	for known, role := range t {
+		if subtle.ConstantTimeCompare([]byte(known), []byte(token)) == 1 {
+			return role, nil
+		}
+	}
+	return "", fmt.Errorf("%w: unknown token", ErrUnauthenticated)
+}
+
+// OIDCConfig configures authentication by tokens issued by an OpenID Connect provider.
+type OIDCConfig struct {
+	// Issuer is the URL of the provider, its keys are discovered from <issuer>/.well-known/openid-configuration.
+	Issuer string
+	// Audience is the expected aud claim of tokens, usually the client ID of the registry.
+	Audience string
+	// RolesClaim is a GJSON path to the claim holding roles or groups of the client, e.g. realm_access.roles.
+	// Defaults to roles.
+	RolesClaim string
+	// Roles maps values of the roles claim to roles of the registry, e.g. registry-admins: admin.
+	Roles map[string]Role
+	// DefaultRole is granted to all clients with a valid token. No role is granted if empty.
+	DefaultRole Role
+}
+
+// OIDC authenticates clients by tokens issued by an OpenID Connect provider.
+type OIDC struct {
+	verifier *oidc.IDTokenVerifier
+	cfg      OIDCConfig
+}
+
+// NewOIDC discovers the provider and creates an authenticator verifying tokens with its keys.
+func NewOIDC(ctx context.Context, cfg OIDCConfig) (*OIDC, error) {
+	provider, err := oidc.NewProvider(ctx, cfg.Issuer)
+	if err != nil {
+		return nil, fmt.Errorf("discover oidc provider: %w", err)
+	}
+	return newOIDC(provider.Verifier(&oidc.Config{ClientID: cfg.Audience, SkipClientIDCheck: cfg.Audience == ""}), cfg), nil
+}
+
+func newOIDC(verifier *oidc.IDTokenVerifier, cfg OIDCConfig) *OIDC {
+	if cfg.RolesClaim == "" {
+		cfg.RolesClaim = "roles"
+	}
+	return &OIDC{verifier: verifier, cfg: cfg}
+}
+
+func (o *OIDC) Authenticate(ctx context.Context, token string) (Role, error) {
+	idToken, err := o.verifier.Verify(ctx, token)
+	if err != nil {
+		return "", fmt.Errorf("%w: %w", ErrUnauthenticated, err)
+	}
+	var claims json.RawMessage
+	if err := idToken.Claims(&claims); err != nil {
+		return "", fmt.Errorf("%w: decode claims: %w", ErrUnauthenticated, err)
+	}
+
+	role := o.cfg.DefaultRole
+	gjson.GetBytes(claims, o.cfg.RolesClaim).ForEach(func(_, value gjson.Result) bool {
+		role = role.higher(o.cfg.Roles[value.String()])
+		return true
+	})
+	return role, nil
+}
+
+// authorize wraps the handler of the endpoint requiring the role, so it is called for authorized clients only.
+func (s *Server) authorize(required Role, next http.HandlerFunc) http.HandlerFunc {
+	if s.opts.auth == nil || required == "" {
+		return next
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		role, err := s.authenticate(r.Context(), r.Header.Get("Authorization"))
+		if err != nil {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="cti"`)
+			writeError(w, http.StatusUnauthorized, err)
+			return
+		}
+		if !role.allows(required) {
+			writeError(w, http.StatusForbidden, fmt.Errorf("%s requires the %s role", r.URL.Path, required))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// authenticate resolves the role of the client by the value of the Authorization header.
+func (s *Server) authenticate(ctx context.Context, header string) (Role, error) {
+	scheme, token, ok := strings.Cut(header, " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") || token == "" {
+		return "", fmt.Errorf("%w: bearer token is required", ErrUnauthenticated)
+	}
+	return s.opts.auth.Authenticate(ctx, token)
+}
@@ -0,0 +1,197 @@
+package server
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/go-jose/go-jose/v4"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const testIssuer = "https://idp.example.com"
+
+func testOIDC(t *testing.T, cfg OIDCConfig) (*OIDC, func(claims map[string]any) string) {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	signer, err := jose.NewSigner(jose.SigningKey{Algorithm: jose.RS256, Key: key}, nil)
+	require.NoError(t, err)
+
+	keySet := &oidc.StaticKeySet{PublicKeys: []crypto.PublicKey{&key.PublicKey}}
+	auth := newOIDC(oidc.NewVerifier(testIssuer, keySet, &oidc.Config{ClientID: cfg.Audience}), cfg)
+	sign := func(claims map[string]any) string {
+		payload := map[string]any{"iss": testIssuer, "aud": cfg.Audience, "exp": time.Now().Add(time.Hour).Unix()}
+		for k, v := range claims {
+			payload[k] = v
+		}
+		data, err := json.Marshal(payload)
+		require.NoError(t, err)
+		jws, err := signer.Sign(data)
+		require.NoError(t, err)
+		token, err := jws.CompactSerialize()
+		require.NoError(t, err)
+		return token
+	}
+	return auth, sign
+}
+
+func Test_OIDC(t *testing.T) {
+	auth, sign := testOIDC(t, OIDCConfig{
+		Audience:    "cti",
+		RolesClaim:  "realm_access.roles",
+		Roles:       map[string]Role{"registry-admins": RoleAdmin, "registry-readers": RoleRead},
+		DefaultRole: "",
+	})
+
+	testCases := []struct {
+		name     string
+		claims   map[string]any
+		expected Role
+		err      string
+	}{
+		{
+			name:     "admin",
+			claims:   map[string]any{"realm_access": map[string]any{"roles": []string{"registry-readers", "registry-admins"}}},
+			expected: RoleAdmin,
+		},
+		{
+			name:     "read",
+			claims:   map[string]any{"realm_access": map[string]any{"roles": []string{"offline_access", "registry-readers"}}},
+			expected: RoleRead,
+		},
+		{
+			name:     "single role",
+			claims:   map[string]any{"realm_access": map[string]any{"roles": "registry-readers"}},
+			expected: RoleRead,
+		},
+		{
+			name:     "no roles",
+			claims:   map[string]any{},
+			expected: "",
+		},
+		{
+			name:   "expired",
+			claims: map[string]any{"exp": time.Now().Add(-time.Hour).Unix()},
+			err:    "unauthenticated: oidc: token is expired",
+		},
+		{
+			name:   "wrong audience",
+			claims: map[string]any{"aud": "other"},
+			err:    "unauthenticated: oidc: expected audience \"cti\"",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			role, err := auth.Authenticate(context.Background(), sign(tc.claims))
+			if tc.err != "" {
+				require.ErrorIs(t, err, ErrUnauthenticated)
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, role)
+		})
+	}
+
+	_, err := auth.Authenticate(context.Background(), "not-a-jwt")
+	require.ErrorIs(t, err, ErrUnauthenticated)
+}
+
+func Test_Auth(t *testing.T) {
+	oidcAuth, sign := testOIDC(t, OIDCConfig{Audience: "cti", DefaultRole: RoleRead})
+	snapshot := testSnapshot(t)
+	srv := New(snapshot, WithGraphQL(), WithReload(func() (*Snapshot, error) { return snapshot, nil }), WithAuth(Authenticators{
+		Tokens{"admin-token": RoleAdmin, "read-token": RoleRead, "no-role-token": ""},
+		oidcAuth,
+	}))
+
+	testCases := []struct {
+		name   string
+		method string
+		path   string
+		header string
+		status int
+	}{
+		{name: "public openapi", method: http.MethodGet, path: "/openapi.json", status: http.StatusOK},
+		{name: "public docs", method: http.MethodGet, path: "/docs", status: http.StatusOK},
+		{name: "no token", method: http.MethodGet, path: "/types", status: http.StatusUnauthorized},
+		{name: "not bearer", method: http.MethodGet, path: "/types", header: "Basic cmVhZC10b2tlbg==", status: http.StatusUnauthorized},
+		{name: "unknown token", method: http.MethodGet, path: "/types", header: "Bearer unknown", status: http.StatusUnauthorized},
+		{name: "read token", method: http.MethodGet, path: "/types", header: "Bearer read-token", status: http.StatusOK},
+		{name: "admin token", method: http.MethodGet, path: "/bundles", header: "bearer admin-token", status: http.StatusOK},
+		{name: "token without role", method: http.MethodGet, path: "/types", header: "Bearer no-role-token", status: http.StatusForbidden},
+		{name: "oidc token", method: http.MethodGet, path: "/instances", header: "Bearer " + sign(nil), status: http.StatusOK},
+		{name: "graphql", method: http.MethodPost, path: "/graphql", status: http.StatusUnauthorized},
+		{name: "public health", method: http.MethodGet, path: "/healthz", status: http.StatusOK},
+		{name: "metrics without token", method: http.MethodGet, path: "/metrics", status: http.StatusUnauthorized},
+		{name: "metrics with read token", method: http.MethodGet, path: "/metrics", header: "Bearer read-token", status: http.StatusForbidden},
+		{name: "metrics with admin token", method: http.MethodGet, path: "/metrics", header: "Bearer admin-token", status: http.StatusOK},
+		{name: "reload with read token", method: http.MethodPost, path: "/reload", header: "Bearer read-token", status: http.StatusForbidden},
+		{name: "reload with oidc token", method: http.MethodPost, path: "/reload", header: "Bearer " + sign(nil), status: http.StatusForbidden},
+		{name: "reload with admin token", method: http.MethodPost, path: "/reload", header: "Bearer admin-token", status: http.StatusOK},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(tc.method, tc.path, nil)
+			if tc.header != "" {
+				req.Header.Set("Authorization", tc.header)
+			}
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, req)
+			require.Equal(t, tc.status, rec.Code, rec.Body.String())
+			if tc.status == http.StatusUnauthorized {
+				require.Equal(t, `Bearer realm="cti"`, rec.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func Test_GRPCAuth(t *testing.T) {
+	srv := New(testSnapshot(t), WithAuth(Tokens{"read-token": RoleRead, "no-role-token": ""}))
+	interceptor := srv.UnaryInterceptor()
+	handler := func(context.Context, any) (any, error) {
+		return "ok", nil
+	}
+
+	testCases := []struct {
+		name   string
+		md     metadata.MD
+		status codes.Code
+	}{
+		{name: "no token", md: metadata.MD{}, status: codes.Unauthenticated},
+		{name: "read token", md: metadata.Pairs("authorization", "Bearer read-token"), status: codes.OK},
+		{name: "token without role", md: metadata.Pairs("authorization", "Bearer no-role-token"), status: codes.PermissionDenied},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			ctx := metadata.NewIncomingContext(context.Background(), tc.md)
+			_, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+			require.Equal(t, tc.status, status.Code(err))
+		})
+	}
+}
+
+func Test_ParseRole(t *testing.T) {
+	role, err := ParseRole("admin")
+	require.NoError(t, err)
+	require.Equal(t, RoleAdmin, role)
+
+	_, err = ParseRole("owner")
+	require.EqualError(t, err, `unknown role "owner", expected read or admin`)
+}
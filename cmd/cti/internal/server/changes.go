@@ -0,0 +1,106 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+// Action is a change of the entity between snapshots.
+type Action string
+
+const (
+	ActionAdded   Action = "added"
+	ActionChanged Action = "changed"
+	ActionRemoved Action = "removed"
+)
+
+// Change is a change of the entity between snapshots.
+type Change struct {
+	Cti    string        `json:"cti"`
+	Kind   registry.Kind `json:"kind"`
+	Action Action        `json:"action"`
+	// Diff is a unified diff of the entity serialized as indented JSON with sorted keys. Set for changed entities.
+	Diff string `json:"diff,omitempty"`
+}
+
+// Changes returns changes of entities between the snapshots sorted by CTI.
+// Source maps are not compared, so entities moved within files are not reported as changed.
+func Changes(prev, next *Snapshot) ([]Change, error) {
+	var changes []Change
+	for _, entity := range prev.Registry.Entities() {
+		if _, ok := next.Registry.Get(entity.Cti); !ok {
+			changes = append(changes, Change{Cti: entity.Cti, Kind: registry.KindOf(entity), Action: ActionRemoved})
+		}
+	}
+	for _, entity := range next.Registry.Entities() {
+		old, ok := prev.Registry.Get(entity.Cti)
+		if !ok {
+			changes = append(changes, Change{Cti: entity.Cti, Kind: registry.KindOf(entity), Action: ActionAdded})
+			continue
+		}
+		diff, err := diffEntities(old, entity)
+		if err != nil {
+			return nil, err
+		}
+		if diff != "" {
+			changes = append(changes, Change{Cti: entity.Cti, Kind: registry.KindOf(entity), Action: ActionChanged, Diff: diff})
+		}
+	}
+	sort.Slice(changes, func(a, b int) bool {
+		return changes[a].Cti < changes[b].Cti
+	})
+	return changes, nil
+}
+
+func diffEntities(old, entity *metadata.Entity) (string, error) {
+	a, err := normalizeEntity(old)
+	if err != nil {
+		return "", err
+	}
+	b, err := normalizeEntity(entity)
+	if err != nil {
+		return "", err
+	}
+	if bytes.Equal(a, b) {
+		return "", nil
+	}
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(a)),
+		B:        difflib.SplitLines(string(b)),
+		FromFile: "a/" + entity.Cti,
+		ToFile:   "b/" + entity.Cti,
+		Context:  3,
+	})
+}
+
+// normalizeEntity serializes the entity without the source map as indented JSON with sorted keys,
+// so equal entities are serialized equally and diffs show one value per line.
+func normalizeEntity(entity *metadata.Entity) ([]byte, error) {
+	data, err := json.Marshal(entity)
+	if err != nil {
+		return nil, fmt.Errorf("encode %s: %w", entity.Cti, err)
+	}
+	var v map[string]any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", entity.Cti, err)
+	}
+	delete(v, "source_map")
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("encode %s: %w", entity.Cti, err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,63 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func Test_Changes(t *testing.T) {
+	newSnapshot := func(entities metadata.EntitiesMap) *Snapshot {
+		r, err := registry.New(entities)
+		require.NoError(t, err)
+		s, err := NewSnapshot(r, nil)
+		require.NoError(t, err)
+		return s
+	}
+	schema := func(description string) []byte {
+		return []byte(`{
+			"$schema": "http://json-schema.org/draft-07/schema",
+			"$ref": "#/definitions/T",
+			"definitions": {"T": {"type": "object", "description": "` + description + `"}}
+		}`)
+	}
+
+	prev := newSnapshot(metadata.EntitiesMap{
+		"cti.a.b.kept.v1.0":    {Cti: "cti.a.b.kept.v1.0", Schema: schema("kept"), SourceMap: metadata.SourceMap{SourcePath: "a.raml"}},
+		"cti.a.b.changed.v1.0": {Cti: "cti.a.b.changed.v1.0", Schema: schema("old <description>")},
+		"cti.a.b.removed.v1.0": {Cti: "cti.a.b.removed.v1.0", Schema: schema("removed")},
+	})
+	next := newSnapshot(metadata.EntitiesMap{
+		"cti.a.b.kept.v1.0":                {Cti: "cti.a.b.kept.v1.0", Schema: schema("kept"), SourceMap: metadata.SourceMap{SourcePath: "b.raml"}},
+		"cti.a.b.changed.v1.0":             {Cti: "cti.a.b.changed.v1.0", Schema: schema("new <description>")},
+		"cti.a.b.added.v1.0":               {Cti: "cti.a.b.added.v1.0", Schema: schema("added")},
+		"cti.a.b.added.v1.0~a.b.item.v1.0": {Cti: "cti.a.b.added.v1.0~a.b.item.v1.0", Values: []byte(`{}`)},
+	})
+
+	changes, err := Changes(prev, next)
+	require.NoError(t, err)
+	require.Equal(t, []Change{
+		{Cti: "cti.a.b.added.v1.0", Kind: registry.KindType, Action: ActionAdded},
+		{Cti: "cti.a.b.added.v1.0~a.b.item.v1.0", Kind: registry.KindInstance, Action: ActionAdded},
+		{Cti: "cti.a.b.changed.v1.0", Kind: registry.KindType, Action: ActionChanged, Diff: `--- a/cti.a.b.changed.v1.0
++++ b/cti.a.b.changed.v1.0
+@@ -6,7 +6,7 @@
+     "$schema": "http://json-schema.org/draft-07/schema",
+     "definitions": {
+       "T": {
+-        "description": "old <description>",
++        "description": "new <description>",
+         "type": "object"
+       }
+     }
+`},
+		{Cti: "cti.a.b.removed.v1.0", Kind: registry.KindType, Action: ActionRemoved},
+	}, changes)
+
+	changes, err = Changes(next, next)
+	require.NoError(t, err)
+	require.Empty(t, changes)
+}
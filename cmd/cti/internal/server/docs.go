@@ -0,0 +1,37 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+var (
+	// openAPI is the OpenAPI document of the server.
+	//go:embed openapi.json
+	openAPI []byte
+
+	// docsPage renders openAPI with Swagger UI. Assets of Swagger UI are loaded from the CDN,
+	// so the page is small enough to be embedded into the binary.
+	//go:embed docs.html
+	docsPage []byte
+
+	// uiPage is the explorer of the registry: a single page application browsing packages, hierarchies of types
+	// and their schemas with the API of the server. It has no external dependencies, so it works offline.
+	//go:embed ui.html
+	uiPage []byte
+)
+
+func serveOpenAPI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPI)
+}
+
+func serveDocs(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(docsPage)
+}
+
+func serveUI(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	_, _ = w.Write(uiPage)
+}
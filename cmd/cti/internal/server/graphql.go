@@ -0,0 +1,322 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	graphql "github.com/graph-gophers/graphql-go"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+// graphQLSchema lets clients fetch types with their ancestors, attributes and instances in one round trip.
+const graphQLSchema = `
+schema {
+	query: Query
+}
+
+"Arbitrary JSON value, e.g. a JSON Schema or values of an instance."
+scalar JSON
+
+type Query {
+	bundles: [Bundle!]!
+	type(cti: String!): Type
+	"Types matching the CTI expression sorted by CTI, all types if not set."
+	types(pattern: String): [Type!]!
+	instance(cti: String!): Instance
+	"Instances matching the CTI expression sorted by CTI, all instances if not set."
+	instances(pattern: String): [Instance!]!
+}
+
+type Bundle {
+	id: String!
+	version: String
+	source: String
+	local: Boolean!
+}
+
+type Type {
+	cti: String!
+	vendor: String!
+	"Package in <vendor>.<package> form."
+	package: String!
+	displayName: String
+	description: String
+	final: Boolean!
+	parent: Type
+	"Ancestors starting from the parent."
+	ancestors: [Type!]!
+	children: [Type!]!
+	"Direct instances of the type."
+	instances: [Instance!]!
+	"Properties of the flattened schema."
+	attributes: [Attribute!]!
+	schema: JSON!
+	"The schema merged with schemas of ancestors, with all definitions it refers to."
+	flattenedSchema: JSON!
+}
+
+type Attribute {
+	name: String!
+	"JSON type of the property, e.g. string or object. Not set for references and compositions."
+	type: String
+	format: String
+	description: String
+	required: Boolean!
+}
+
+type Instance {
+	cti: String!
+	vendor: String!
+	package: String!
+	displayName: String
+	description: String
+	type: Type
+	values: JSON!
+}
+`
+
+// graphQLRequest is a GraphQL request sent as JSON in the body of POST requests.
+type graphQLRequest struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName"`
+	Variables     map[string]any `json:"variables"`
+}
+
+type snapshotKey struct{}
+
+// serveGraphQL executes the request against the snapshot taken at the start of the request,
+// so all fields are resolved from the same state.
+func (s *Server) serveGraphQL(schema *graphql.Schema) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+			return
+		}
+		ctx := context.WithValue(r.Context(), snapshotKey{}, s.Snapshot())
+		writeJSON(w, http.StatusOK, schema.Exec(ctx, req.Query, req.OperationName, req.Variables))
+	}
+}
+
+func mustParseGraphQLSchema() *graphql.Schema {
+	return graphql.MustParseSchema(graphQLSchema, &queryResolver{}, graphql.UseFieldResolvers())
+}
+
+func snapshotOf(ctx context.Context) *Snapshot {
+	return ctx.Value(snapshotKey{}).(*Snapshot)
+}
+
+// JSON is the GraphQL scalar of raw JSON values.
+type JSON json.RawMessage
+
+func (JSON) ImplementsGraphQLType(name string) bool {
+	return name == "JSON"
+}
+
+func (j *JSON) UnmarshalGraphQL(input any) error {
+	data, err := json.Marshal(input)
+	if err != nil {
+		return err
+	}
+	*j = data
+	return nil
+}
+
+func (j JSON) MarshalJSON() ([]byte, error) {
+	if j == nil {
+		return []byte("null"), nil
+	}
+	return j, nil
+}
+
+type queryResolver struct{}
+
+func (queryResolver) Bundles(ctx context.Context) []*bundleResolver {
+	bundles := snapshotOf(ctx).Bundles
+	res := make([]*bundleResolver, 0, len(bundles))
+	for i := range bundles {
+		res = append(res, &bundleResolver{&bundles[i]})
+	}
+	return res
+}
+
+func (queryResolver) Type(ctx context.Context, args struct{ Cti string }) *typeResolver {
+	s := snapshotOf(ctx)
+	entity, ok := s.Registry.GetType(args.Cti)
+	if !ok {
+		return nil
+	}
+	return &typeResolver{s: s, entity: entity}
+}
+
+func (queryResolver) Types(ctx context.Context, args struct{ Pattern *string }) ([]*typeResolver, error) {
+	s := snapshotOf(ctx)
+	entities, err := s.Find(Query{Kind: registry.KindType, Pattern: deref(args.Pattern)})
+	if err != nil {
+		return nil, err
+	}
+	return s.types(entities), nil
+}
+
+func (queryResolver) Instance(ctx context.Context, args struct{ Cti string }) *instanceResolver {
+	s := snapshotOf(ctx)
+	entity, ok := s.Registry.GetInstance(args.Cti)
+	if !ok {
+		return nil
+	}
+	return &instanceResolver{s: s, entity: entity}
+}
+
+func (queryResolver) Instances(ctx context.Context, args struct{ Pattern *string }) ([]*instanceResolver, error) {
+	s := snapshotOf(ctx)
+	entities, err := s.Find(Query{Kind: registry.KindInstance, Pattern: deref(args.Pattern)})
+	if err != nil {
+		return nil, err
+	}
+	return s.instances(entities), nil
+}
+
+func (s *Snapshot) types(entities metadata.Entities) []*typeResolver {
+	res := make([]*typeResolver, 0, len(entities))
+	for _, entity := range entities {
+		if !entity.IsInstance() {
+			res = append(res, &typeResolver{s: s, entity: entity})
+		}
+	}
+	return res
+}
+
+func (s *Snapshot) instances(entities metadata.Entities) []*instanceResolver {
+	res := make([]*instanceResolver, 0, len(entities))
+	for _, entity := range entities {
+		if entity.IsInstance() {
+			res = append(res, &instanceResolver{s: s, entity: entity})
+		}
+	}
+	return res
+}
+
+type bundleResolver struct {
+	b *Bundle
+}
+
+func (b *bundleResolver) ID() string       { return b.b.ID }
+func (b *bundleResolver) Version() *string { return optional(b.b.Version) }
+func (b *bundleResolver) Source() *string  { return optional(b.b.Source) }
+func (b *bundleResolver) Local() bool      { return b.b.Local }
+
+type typeResolver struct {
+	s      *Snapshot
+	entity *metadata.Entity
+}
+
+func (t *typeResolver) Cti() string          { return t.entity.Cti }
+func (t *typeResolver) Vendor() string       { return makeEntry(t.entity).Vendor }
+func (t *typeResolver) Package() string      { return makeEntry(t.entity).Package }
+func (t *typeResolver) DisplayName() *string { return optional(t.entity.DisplayName) }
+func (t *typeResolver) Description() *string { return optional(t.entity.Description) }
+func (t *typeResolver) Final() bool          { return t.entity.Final }
+func (t *typeResolver) Schema() JSON         { return JSON(t.entity.Schema) }
+func (t *typeResolver) Children() []*typeResolver {
+	return t.s.types(t.s.Registry.Children(t.entity.Cti))
+}
+func (t *typeResolver) Ancestors() []*typeResolver {
+	return t.s.types(t.s.Registry.Ancestors(t.entity.Cti))
+}
+func (t *typeResolver) Instances() []*instanceResolver {
+	return t.s.instances(t.s.Registry.Children(t.entity.Cti))
+}
+
+func (t *typeResolver) Parent() *typeResolver {
+	parent, ok := t.s.Registry.Parent(t.entity.Cti)
+	if !ok {
+		return nil
+	}
+	return &typeResolver{s: t.s, entity: parent}
+}
+
+func (t *typeResolver) FlattenedSchema() (JSON, error) {
+	schema, err := t.s.FlattenedSchema(t.entity.Cti)
+	return JSON(schema), err
+}
+
+func (t *typeResolver) Attributes() ([]*attribute, error) {
+	schema, err := t.s.FlattenedSchema(t.entity.Cti)
+	if err != nil {
+		return nil, err
+	}
+	var s struct {
+		Properties map[string]struct {
+			Type        any    `json:"type"`
+			Format      string `json:"format"`
+			Description string `json:"description"`
+		} `json:"properties"`
+		Required []string `json:"required"`
+	}
+	if err := json.Unmarshal(schema, &s); err != nil {
+		return nil, fmt.Errorf("decode schema of %s: %w", t.entity.Cti, err)
+	}
+	res := make([]*attribute, 0, len(s.Properties))
+	for name, p := range s.Properties {
+		a := &attribute{Name: name, Format: optional(p.Format), Description: optional(p.Description)}
+		if typ, ok := p.Type.(string); ok {
+			a.Type = &typ
+		}
+		for _, r := range s.Required {
+			a.Required = a.Required || r == name
+		}
+		res = append(res, a)
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].Name < res[b].Name
+	})
+	return res, nil
+}
+
+// attribute is a property of the flattened schema of the type.
+type attribute struct {
+	Name        string
+	Type        *string
+	Format      *string
+	Description *string
+	Required    bool
+}
+
+type instanceResolver struct {
+	s      *Snapshot
+	entity *metadata.Entity
+}
+
+func (i *instanceResolver) Cti() string          { return i.entity.Cti }
+func (i *instanceResolver) Vendor() string       { return makeEntry(i.entity).Vendor }
+func (i *instanceResolver) Package() string      { return makeEntry(i.entity).Package }
+func (i *instanceResolver) DisplayName() *string { return optional(i.entity.DisplayName) }
+func (i *instanceResolver) Description() *string { return optional(i.entity.Description) }
+func (i *instanceResolver) Values() JSON         { return JSON(i.entity.Values) }
+
+func (i *instanceResolver) Type() *typeResolver {
+	parent, ok := i.s.Registry.Parent(i.entity.Cti)
+	if !ok {
+		return nil
+	}
+	return &typeResolver{s: i.s, entity: parent}
+}
+
+func optional(s string) *string {
+	if s == "" {
+		return nil
+	}
+	return &s
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+	return *s
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_GraphQL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		expected string
+	}{
+		{
+			name: "type with ancestors, attributes and instances",
+			body: `{"query": "{ type(cti: \"cti.x.y.event.v1.0~x.y.created.v1.0\") { cti package displayName parent { cti } ancestors { cti } children { cti } attributes { name type required } } }"}`,
+			expected: `{"data": {"type": {
+				"cti": "cti.x.y.event.v1.0~x.y.created.v1.0",
+				"package": "x.y",
+				"displayName": "Created",
+				"parent": {"cti": "cti.x.y.event.v1.0"},
+				"ancestors": [{"cti": "cti.x.y.event.v1.0"}],
+				"children": [],
+				"attributes": [
+					{"name": "id", "type": "string", "required": true},
+					{"name": "login", "type": "string", "required": true}
+				]
+			}}}`,
+		},
+		{
+			name: "instances of type",
+			body: `{"query": "query($cti: String!) { type(cti: $cti) { instances { cti values type { cti } } } }", "variables": {"cti": "cti.a.b.topic.v1.0"}}`,
+			expected: `{"data": {"type": {"instances": [
+				{"cti": "cti.a.b.topic.v1.0~a.b.users.v1.0", "values": {"name": "users"}, "type": {"cti": "cti.a.b.topic.v1.0"}}
+			]}}}`,
+		},
+		{
+			name:     "types by pattern",
+			body:     `{"query": "{ types(pattern: \"cti.x.y.*\") { cti } instances { cti } }"}`,
+			expected: `{"data": {"types": [{"cti": "cti.x.y.event.v1.0"}, {"cti": "cti.x.y.event.v1.0~x.y.created.v1.0"}], "instances": [{"cti": "cti.a.b.topic.v1.0~a.b.users.v1.0"}]}}`,
+		},
+		{
+			name:     "unknown type",
+			body:     `{"query": "{ type(cti: \"cti.x.y.unknown.v1.0\") { cti } bundles { id version } }"}`,
+			expected: `{"data": {"type": null, "bundles": [{"id": "x.y", "version": null}, {"id": "a.b", "version": "v1.0.0"}]}}`,
+		},
+		{
+			name:     "invalid pattern",
+			body:     `{"query": "{ types(pattern: \"x\") { cti } }"}`,
+			expected: `{"errors": [{"message": "parse pattern: not CTI expression", "path": ["types"]}], "data": null}`,
+		},
+	}
+	srv := New(testSnapshot(t), WithGraphQL())
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(tc.body)))
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			require.JSONEq(t, tc.expected, rec.Body.String())
+		})
+	}
+}
+
+func Test_GraphQLDisabled(t *testing.T) {
+	rec := httptest.NewRecorder()
+	New(testSnapshot(t)).ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(`{"query": "{ bundles { id } }"}`)))
+	require.Equal(t, http.StatusNotFound, rec.Code)
+}
@@ -0,0 +1,156 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/server/registrypb"
+	ctimetadata "github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+// RegisterGRPC registers the gRPC registry service serving the same snapshot as the server, see registrypb.
+func (s *Server) RegisterGRPC(g *grpc.Server) {
+	registrypb.RegisterRegistryServer(g, &grpcService{srv: s})
+}
+
+// UnaryInterceptor authenticates calls of the gRPC service with bearer tokens passed in the authorization metadata,
+// the same way as requests to the server. All methods of the service require the read role.
+// Calls are not checked if authentication is not enabled.
+func (s *Server) UnaryInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if s.opts.auth == nil {
+			return handler(ctx, req)
+		}
+		md, _ := metadata.FromIncomingContext(ctx)
+		var header string
+		if values := md.Get("authorization"); len(values) > 0 {
+			header = values[0]
+		}
+		role, err := s.authenticate(ctx, header)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		if !role.allows(RoleRead) {
+			return nil, status.Errorf(codes.PermissionDenied, "registry service requires the %s role", RoleRead)
+		}
+		return handler(ctx, req)
+	}
+}
+
+type grpcService struct {
+	registrypb.UnimplementedRegistryServer
+	srv *Server
+}
+
+func (g *grpcService) Lookup(_ context.Context, req *registrypb.LookupRequest) (*registrypb.Entity, error) {
+	entity, err := g.srv.Snapshot().Lookup(req.GetCti())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	annotations, err := marshalAnnotations(entity.Annotations)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return &registrypb.Entity{
+		Cti:              entity.Cti,
+		Kind:             kindToProto(registry.KindOf(entity)),
+		Final:            entity.Final,
+		DisplayName:      entity.DisplayName,
+		Description:      entity.Description,
+		SchemaJson:       entity.Schema,
+		ValuesJson:       entity.Values,
+		TraitsSchemaJson: entity.TraitsSchema,
+		TraitsJson:       entity.Traits,
+		AnnotationsJson:  annotations,
+	}, nil
+}
+
+func (g *grpcService) Query(_ context.Context, req *registrypb.QueryRequest) (*registrypb.QueryResponse, error) {
+	q := Query{Pattern: req.GetPattern()}
+	switch req.GetKind() {
+	case registrypb.Kind_KIND_TYPE:
+		q.Kind = registry.KindType
+	case registrypb.Kind_KIND_INSTANCE:
+		q.Kind = registry.KindInstance
+	}
+	entities, err := g.srv.Snapshot().Find(q)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	res := &registrypb.QueryResponse{Entries: make([]*registrypb.Entry, 0, len(entities))}
+	for _, entity := range entities {
+		e := makeEntry(entity)
+		res.Entries = append(res.Entries, &registrypb.Entry{
+			Cti:         e.Cti,
+			Kind:        kindToProto(registry.Kind(e.Kind)),
+			Vendor:      e.Vendor,
+			Package:     e.Package,
+			DisplayName: e.DisplayName,
+		})
+	}
+	return res, nil
+}
+
+func (g *grpcService) ResolveSchema(_ context.Context, req *registrypb.ResolveSchemaRequest) (*registrypb.ResolveSchemaResponse, error) {
+	schema, err := g.srv.Snapshot().FlattenedSchema(req.GetCti())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	return &registrypb.ResolveSchemaResponse{SchemaJson: schema}, nil
+}
+
+func (g *grpcService) ValidateInstance(_ context.Context, req *registrypb.ValidateInstanceRequest) (*registrypb.ValidateInstanceResponse, error) {
+	report, err := g.srv.Snapshot().ValidateInstance(req.GetType(), req.GetId(), req.GetValuesJson())
+	if err != nil {
+		return nil, grpcError(err)
+	}
+	g.srv.metrics.observeValidation(report)
+	res := &registrypb.ValidateInstanceResponse{Valid: report.Valid}
+	for _, file := range report.Files {
+		for _, d := range file.Diagnostics {
+			res.Diagnostics = append(res.Diagnostics, &registrypb.Diagnostic{
+				Code:     string(d.Code),
+				Severity: string(d.Severity),
+				Category: string(d.Category),
+				Id:       d.ID,
+				Path:     d.Path,
+				Message:  d.Message,
+			})
+		}
+	}
+	return res, nil
+}
+
+func kindToProto(kind registry.Kind) registrypb.Kind {
+	if kind == registry.KindInstance {
+		return registrypb.Kind_KIND_INSTANCE
+	}
+	return registrypb.Kind_KIND_TYPE
+}
+
+// grpcError converts errors of the snapshot into gRPC statuses.
+func grpcError(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return status.Error(codes.NotFound, err.Error())
+	}
+	return status.Error(codes.InvalidArgument, err.Error())
+}
+
+func marshalAnnotations(annotations map[ctimetadata.GJsonPath]ctimetadata.Annotations) ([]byte, error) {
+	if annotations == nil {
+		return nil, nil
+	}
+	data, err := json.Marshal(annotations)
+	if err != nil {
+		return nil, fmt.Errorf("encode annotations: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/server/registrypb"
+)
+
+func testGRPCClient(t *testing.T) registrypb.RegistryClient {
+	t.Helper()
+
+	ln := bufconn.Listen(1 << 20)
+	g := grpc.NewServer()
+	New(testSnapshot(t)).RegisterGRPC(g)
+	go func() {
+		_ = g.Serve(ln)
+	}()
+	t.Cleanup(g.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return ln.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return registrypb.NewRegistryClient(conn)
+}
+
+func Test_GRPCLookup(t *testing.T) {
+	client := testGRPCClient(t)
+	ctx := context.Background()
+
+	entity, err := client.Lookup(ctx, &registrypb.LookupRequest{Cti: "cti.a.b.topic.v1.0~a.b.users.v1.0"})
+	require.NoError(t, err)
+	require.Equal(t, "cti.a.b.topic.v1.0~a.b.users.v1.0", entity.GetCti())
+	require.Equal(t, registrypb.Kind_KIND_INSTANCE, entity.GetKind())
+	require.Equal(t, "Users", entity.GetDisplayName())
+	require.JSONEq(t, `{"name":"users"}`, string(entity.GetValuesJson()))
+	require.Empty(t, entity.GetAnnotationsJson())
+
+	entity, err = client.Lookup(ctx, &registrypb.LookupRequest{Cti: "cti.a.b.topic.v1.0"})
+	require.NoError(t, err)
+	require.Equal(t, registrypb.Kind_KIND_TYPE, entity.GetKind())
+	require.JSONEq(t, `{".name":{"cti.display_name":true}}`, string(entity.GetAnnotationsJson()))
+
+	_, err = client.Lookup(ctx, &registrypb.LookupRequest{Cti: "cti.a.b.unknown.v1.0"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+	require.ErrorContains(t, err, "entity cti.a.b.unknown.v1.0 not found")
+}
+
+func Test_GRPCQuery(t *testing.T) {
+	client := testGRPCClient(t)
+	ctx := context.Background()
+
+	res, err := client.Query(ctx, &registrypb.QueryRequest{Kind: registrypb.Kind_KIND_TYPE, Pattern: "cti.x.y.*"})
+	require.NoError(t, err)
+	var ids []string
+	for _, e := range res.GetEntries() {
+		ids = append(ids, e.GetCti())
+		require.Equal(t, "x.y", e.GetPackage())
+	}
+	require.Equal(t, []string{"cti.x.y.event.v1.0", "cti.x.y.event.v1.0~x.y.created.v1.0"}, ids)
+
+	res, err = client.Query(ctx, &registrypb.QueryRequest{})
+	require.NoError(t, err)
+	require.Len(t, res.GetEntries(), 4)
+
+	_, err = client.Query(ctx, &registrypb.QueryRequest{Pattern: "x"})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func Test_GRPCResolveSchema(t *testing.T) {
+	client := testGRPCClient(t)
+
+	res, err := client.ResolveSchema(context.Background(), &registrypb.ResolveSchemaRequest{Cti: "cti.x.y.event.v1.0~x.y.created.v1.0"})
+	require.NoError(t, err)
+	require.Contains(t, string(res.GetSchemaJson()), `"required":["id","login"]`)
+
+	_, err = client.ResolveSchema(context.Background(), &registrypb.ResolveSchemaRequest{Cti: "cti.a.b.topic.v1.0~a.b.users.v1.0"})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func Test_GRPCValidateInstance(t *testing.T) {
+	client := testGRPCClient(t)
+	ctx := context.Background()
+
+	res, err := client.ValidateInstance(ctx, &registrypb.ValidateInstanceRequest{
+		Type:       "cti.a.b.topic.v1.0",
+		ValuesJson: []byte(`{"name":"tenants"}`),
+	})
+	require.NoError(t, err)
+	require.True(t, res.GetValid())
+	require.Empty(t, res.GetDiagnostics())
+
+	res, err = client.ValidateInstance(ctx, &registrypb.ValidateInstanceRequest{
+		Type:       "cti.a.b.topic.v1.0",
+		Id:         "cti.a.b.topic.v1.0~a.b.tenants.v1.0",
+		ValuesJson: []byte(`{"name":1}`),
+	})
+	require.NoError(t, err)
+	require.False(t, res.GetValid())
+	require.Len(t, res.GetDiagnostics(), 1)
+	require.Equal(t, "CTI1020", res.GetDiagnostics()[0].GetCode())
+	require.Equal(t, "cti.a.b.topic.v1.0~a.b.tenants.v1.0", res.GetDiagnostics()[0].GetId())
+	require.Equal(t, "name", res.GetDiagnostics()[0].GetPath())
+
+	_, err = client.ValidateInstance(ctx, &registrypb.ValidateInstanceRequest{
+		Type:       "cti.a.b.topic.v1.0",
+		Id:         "cti.x.y.event.v1.0~x.y.tenants.v1.0",
+		ValuesJson: []byte(`{}`),
+	})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.ErrorContains(t, err, "instance cti.x.y.event.v1.0~x.y.tenants.v1.0 is not derived from cti.a.b.topic.v1.0")
+
+	_, err = client.ValidateInstance(ctx, &registrypb.ValidateInstanceRequest{Type: "cti.a.b.topic.v1.0", ValuesJson: []byte(`{`)})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.ValidateInstance(ctx, &registrypb.ValidateInstanceRequest{Type: "cti.a.b.unknown.v1.0", ValuesJson: []byte(`{}`)})
+	require.Equal(t, codes.NotFound, status.Code(err))
+}
@@ -0,0 +1,129 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+const (
+	// defaultLimit is a number of entries per page if the limit is not set.
+	defaultLimit = 100
+	maxLimit     = 1000
+)
+
+// sortFields maps names of fields entries can be sorted by to their values. Ties are broken by CTIs.
+var sortFields = map[string]func(Entry) string{
+	"cti":          func(e Entry) string { return e.Cti },
+	"display_name": func(e Entry) string { return e.DisplayName },
+}
+
+// page selects a page of a sorted list.
+type page struct {
+	sort  string
+	desc  bool
+	limit int
+	after *cursor
+}
+
+// cursor points at the last entry of the previous page. Unlike offsets, cursors stay valid when
+// entries are added or removed between requests.
+type cursor struct {
+	Sort string `json:"s"`
+	Key  string `json:"k"`
+	Cti  string `json:"c"`
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// parseList parses filters and the page of list endpoints from query parameters.
+func parseList(params url.Values) (Query, page, error) {
+	q := Query{
+		Pattern: params.Get("pattern"),
+		Vendor:  params.Get("vendor"),
+		Package: params.Get("package"),
+	}
+	p := page{sort: "cti", limit: defaultLimit}
+	if sortBy := params.Get("sort"); sortBy != "" {
+		p.sort, p.desc = strings.TrimPrefix(sortBy, "-"), strings.HasPrefix(sortBy, "-")
+		if _, ok := sortFields[p.sort]; !ok {
+			return Query{}, page{}, fmt.Errorf("invalid sort %s, expected cti or display_name with optional - prefix", sortBy)
+		}
+	}
+	if limit := params.Get("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 || n > maxLimit {
+			return Query{}, page{}, fmt.Errorf("invalid limit %s, expected 1 to %d", limit, maxLimit)
+		}
+		p.limit = n
+	}
+	if token := params.Get("cursor"); token != "" {
+		after, err := decodeCursor(token)
+		if err != nil {
+			return Query{}, page{}, err
+		}
+		if after.Sort != p.order() {
+			return Query{}, page{}, errors.New("invalid cursor: it was issued for another sort order")
+		}
+		p.after = after
+	}
+	return q, p, nil
+}
+
+func decodeCursor(token string) (*cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	return &c, nil
+}
+
+// order returns the sort order in the form of the sort parameter, e.g. -display_name.
+func (p page) order() string {
+	if p.desc {
+		return "-" + p.sort
+	}
+	return p.sort
+}
+
+// apply sorts entries and returns the page with the cursor of the next page. The cursor is empty for the last page.
+func (p page) apply(entries []Entry) ([]Entry, string) {
+	key := sortFields[p.sort]
+	less := func(keyA, ctiA, keyB, ctiB string) bool {
+		if p.desc {
+			keyA, ctiA, keyB, ctiB = keyB, ctiB, keyA, ctiA
+		}
+		if keyA != keyB {
+			return keyA < keyB
+		}
+		return ctiA < ctiB
+	}
+	sort.Slice(entries, func(a, b int) bool {
+		return less(key(entries[a]), entries[a].Cti, key(entries[b]), entries[b].Cti)
+	})
+
+	if p.after != nil {
+		start := sort.Search(len(entries), func(i int) bool {
+			return less(p.after.Key, p.after.Cti, key(entries[i]), entries[i].Cti)
+		})
+		entries = entries[start:]
+	}
+	if len(entries) <= p.limit {
+		return entries, ""
+	}
+	entries = entries[:p.limit]
+	last := entries[len(entries)-1]
+	return entries, cursor{Sort: p.order(), Key: key(last), Cti: last.Cti}.encode()
+}
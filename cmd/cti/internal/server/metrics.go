@@ -0,0 +1,133 @@
+package server
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/acronis/go-cti/metadata/validator"
+)
+
+// metrics are collected per server and exposed at /metrics in the Prometheus format.
+type metrics struct {
+	registry        *prometheus.Registry
+	requests        *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+	reloads         *prometheus.CounterVec
+	reloadDuration  prometheus.Histogram
+	validations     *prometheus.CounterVec
+	diagnostics     *prometheus.CounterVec
+}
+
+func newMetrics(s *Server) *metrics {
+	m := &metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cti",
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests by route and status code.",
+		}, []string{"route", "method", "code"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "cti",
+			Name:      "http_request_duration_seconds",
+			Help:      "Duration of HTTP requests by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		reloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cti",
+			Name:      "registry_reloads_total",
+			Help:      "Number of reloads of the registry by result: success or failure.",
+		}, []string{"result"}),
+		reloadDuration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: "cti",
+			Name:      "registry_reload_duration_seconds",
+			Help:      "Duration of reloads of the registry, including parsing of the package.",
+			Buckets:   []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30},
+		}),
+		validations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cti",
+			Name:      "validations_total",
+			Help:      "Number of validated instances by result: valid or invalid.",
+		}, []string{"result"}),
+		diagnostics: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "cti",
+			Name:      "validation_diagnostics_total",
+			Help:      "Number of diagnostics of validated instances by code and severity.",
+		}, []string{"code", "severity"}),
+	}
+	entities := func(kind string, count func(*Snapshot) int) prometheus.Collector {
+		return prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+			Namespace:   "cti",
+			Name:        "registry_entities",
+			Help:        "Number of served entities by kind.",
+			ConstLabels: prometheus.Labels{"kind": kind},
+		}, func() float64 {
+			if snapshot := s.Snapshot(); snapshot != nil {
+				return float64(count(snapshot))
+			}
+			return 0
+		})
+	}
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests, m.requestDuration, m.reloads, m.reloadDuration, m.validations, m.diagnostics,
+		entities("type", func(s *Snapshot) int { return len(s.Registry.Types()) }),
+		entities("instance", func(s *Snapshot) int { return len(s.Registry.Instances()) }),
+	)
+	return m
+}
+
+// instrument counts requests to the route and observes their durations.
+func (m *metrics) instrument(route string, next http.HandlerFunc) http.HandlerFunc {
+	labels := prometheus.Labels{"route": route}
+	return promhttp.InstrumentHandlerDuration(m.requestDuration.MustCurryWith(labels),
+		promhttp.InstrumentHandlerCounter(m.requests.MustCurryWith(labels), next))
+}
+
+func (m *metrics) observeReload(start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "failure"
+	}
+	m.reloads.WithLabelValues(result).Inc()
+	m.reloadDuration.Observe(time.Since(start).Seconds())
+}
+
+func (m *metrics) observeValidation(report *validator.Report) {
+	result := "valid"
+	if !report.Valid {
+		result = "invalid"
+	}
+	m.validations.WithLabelValues(result).Inc()
+	for _, file := range report.Files {
+		for _, d := range file.Diagnostics {
+			m.diagnostics.WithLabelValues(string(d.Code), string(d.Severity)).Inc()
+		}
+	}
+}
+
+func (s *Server) serveMetrics() http.HandlerFunc {
+	return promhttp.HandlerFor(s.metrics.registry, promhttp.HandlerOpts{}).ServeHTTP
+}
+
+func serveHealth(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// serveReady reports whether the server serves a registry and is not draining, so it can receive traffic.
+func (s *Server) serveReady(w http.ResponseWriter, _ *http.Request) {
+	if s.draining.Load() {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "shutting down"})
+		return
+	}
+	snapshot := s.Snapshot()
+	if snapshot == nil {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"status": "registry is not loaded"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"status": "ready", "entities": snapshot.Registry.Len()})
+}
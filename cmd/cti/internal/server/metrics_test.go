@@ -0,0 +1,90 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/cmd/cti/internal/server/registrypb"
+)
+
+func Test_Metrics(t *testing.T) {
+	srv := New(testSnapshot(t))
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	get("/types/cti.x.y.event.v1.0")
+	get("/types/cti.x.y.unknown.v1.0")
+	require.Error(t, srv.reload(func() (*Snapshot, error) { return nil, errors.New("parse package: syntax error") }, &notifier{}))
+	_, err := (&grpcService{srv: srv}).ValidateInstance(context.Background(), &registrypb.ValidateInstanceRequest{
+		Type:       "cti.a.b.topic.v1.0",
+		ValuesJson: []byte(`{"name":1}`),
+	})
+	require.NoError(t, err)
+
+	rec := get("/metrics")
+	require.Equal(t, http.StatusOK, rec.Code)
+	body := rec.Body.String()
+	for _, line := range []string{
+		`cti_http_requests_total{code="200",method="get",route="/types/{cti}"} 1`,
+		`cti_http_requests_total{code="404",method="get",route="/types/{cti}"} 1`,
+		`cti_http_request_duration_seconds_count{method="get",route="/types/{cti}"} 2`,
+		`cti_registry_entities{kind="instance"} 1`,
+		`cti_registry_entities{kind="type"} 3`,
+		`cti_registry_reloads_total{result="failure"} 1`,
+		`cti_registry_reload_duration_seconds_count 1`,
+		`cti_validations_total{result="invalid"} 1`,
+		`cti_validation_diagnostics_total{code="CTI1020",severity="error"} 1`,
+		`go_goroutines`,
+	} {
+		require.Contains(t, body, line)
+	}
+}
+
+func Test_Health(t *testing.T) {
+	srv := New(testSnapshot(t), WithAuth(Tokens{"token": RoleRead}))
+
+	testCases := []struct {
+		name     string
+		path     string
+		status   int
+		expected string
+	}{
+		{name: "healthz", path: "/healthz", status: http.StatusOK, expected: `{"status":"ok"}`},
+		{name: "readyz", path: "/readyz", status: http.StatusOK, expected: `{"status":"ready","entities":4}`},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			require.Equal(t, tc.status, rec.Code)
+			require.JSONEq(t, tc.expected, rec.Body.String())
+		})
+	}
+
+	srv.Swap(nil)
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	srv.Swap(testSnapshot(t))
+	srv.Drain()
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	require.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	require.JSONEq(t, `{"status":"shutting down"}`, rec.Body.String())
+
+	// Draining servers keep serving the registry.
+	rec = httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodGet, "/types/cti.a.b.topic.v1.0", nil)
+	req.Header.Set("Authorization", "Bearer token")
+	srv.ServeHTTP(rec, req)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
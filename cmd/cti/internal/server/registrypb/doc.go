@@ -0,0 +1,3 @@
+// Package registrypb holds the gRPC API of the CTI registry server generated from registry.proto.
+// Run make proto to regenerate the code.
+package registrypb
@@ -0,0 +1,58 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/ctitest"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+// Test_RemoteValidator checks that the remote validator of ctitest reports the same as the local one.
+func Test_RemoteValidator(t *testing.T) {
+	displayName := true
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.a.b.topic.v1.0": {
+			Cti: "cti.a.b.topic.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Topic",
+				"definitions": {"Topic": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}}
+			}`),
+			Annotations: map[metadata.GJsonPath]metadata.Annotations{".name": {DisplayName: &displayName}},
+		},
+	})
+	require.NoError(t, err)
+	snapshot, err := NewSnapshot(r, nil)
+	require.NoError(t, err)
+	srv := httptest.NewServer(New(snapshot, WithAuth(Tokens{"read-token": RoleRead})))
+	t.Cleanup(srv.Close)
+
+	valid, invalid := true, false
+	suite := &ctitest.Suite{Cases: []*ctitest.Case{
+		{Name: "valid", Type: "cti.a.b.topic.v1.0", Values: map[string]any{"name": "orders"}, Valid: &valid},
+		{Name: "invalid", Type: "cti.a.b.topic.v1.0", Values: map[string]any{"name": 1}, Valid: &invalid},
+		{Name: "missing name", Type: "cti.a.b.topic.v1.0", Values: map[string]any{}, Valid: &valid},
+		{Name: "unknown type", Type: "cti.a.b.unknown.v1.0", Values: map[string]any{}, Valid: &valid},
+	}}
+
+	remote, err := ctitest.NewRemoteValidator(srv.URL+"/", ctitest.WithToken("read-token"))
+	require.NoError(t, err)
+	expected := ctitest.Run(context.Background(), suite, ctitest.NewLocalValidator(r))
+	actual := ctitest.Run(context.Background(), suite, remote)
+	for i, res := range actual.Results {
+		require.Equal(t, expected.Results[i].Report, res.Report, res.Case.Name)
+		require.Equal(t, expected.Results[i].Failures, res.Failures, res.Case.Name)
+	}
+	require.EqualError(t, actual.Results[3].Err,
+		"validate: registry responded with 404 Not Found: type cti.a.b.unknown.v1.0 not found")
+
+	remote, err = ctitest.NewRemoteValidator(srv.URL, ctitest.WithToken("unknown-token"))
+	require.NoError(t, err)
+	_, err = remote.Validate(context.Background(), "cti.a.b.topic.v1.0", []byte(`{}`))
+	require.ErrorContains(t, err, "registry responded with 401 Unauthorized")
+}
@@ -0,0 +1,203 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/zeebo/xxh3"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+// annotationKeywordPrefix prefixes names of CTI annotations applied to resolved schemas, e.g. x-cti.reference.
+const annotationKeywordPrefix = "x-"
+
+// ResolvedSchema is the flattened schema of the type with annotations applied, see Snapshot.ResolvedSchema.
+type ResolvedSchema struct {
+	Schema json.RawMessage
+	// ETag is a strong entity tag of the schema, it changes only when the schema changes.
+	ETag string
+}
+
+// ResolvedSchema returns the flattened schema of the type with annotations of the type and its ancestors applied
+// as keywords of the annotated schemas, e.g. "x-cti.reference": "cti.x.y.topic.v1.0" of a property. Annotations
+// of the type override annotations of ancestors. RAML extensions are removed, so the schema is ready to be used
+// by API gateways at runtime. Schemas are resolved once per snapshot.
+func (s *Snapshot) ResolvedSchema(id string) (*ResolvedSchema, error) {
+	if cached, ok := s.resolved.Load(id); ok {
+		return cached.(*ResolvedSchema), nil
+	}
+	flattened, err := s.FlattenedSchema(id)
+	if err != nil {
+		return nil, err
+	}
+
+	var schema map[string]any
+	dec := json.NewDecoder(bytes.NewReader(flattened))
+	dec.UseNumber()
+	if err := dec.Decode(&schema); err != nil {
+		return nil, fmt.Errorf("decode schema of %s: %w", id, err)
+	}
+	defs, _ := schema["definitions"].(map[string]any)
+	removeExtensions(schema)
+
+	annotations, err := s.inheritedAnnotations(id)
+	if err != nil {
+		return nil, err
+	}
+	for path, keywords := range annotations {
+		for _, target := range locate(schema, defs, splitAnnotationPath(path)) {
+			for name, value := range keywords {
+				target[annotationKeywordPrefix+name] = value
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(schema); err != nil {
+		return nil, fmt.Errorf("encode schema of %s: %w", id, err)
+	}
+	res := &ResolvedSchema{
+		Schema: buf.Bytes(),
+		ETag:   `"` + strconv.FormatUint(xxh3.Hash(buf.Bytes()), 16) + `"`,
+	}
+	cached, _ := s.resolved.LoadOrStore(id, res)
+	return cached.(*ResolvedSchema), nil
+}
+
+// inheritedAnnotations returns annotations of the type merged with annotations of its ancestors by paths.
+func (s *Snapshot) inheritedAnnotations(id string) (map[metadata.GJsonPath]map[string]any, error) {
+	chain := s.Registry.Ancestors(id)
+	if typ, ok := s.Registry.GetType(id); ok {
+		chain = append(metadata.Entities{typ}, chain...)
+	}
+	res := make(map[metadata.GJsonPath]map[string]any)
+	// The chain starts from the type, so it is applied from the end for descendants to override ancestors.
+	for i := len(chain) - 1; i >= 0; i-- {
+		for path, a := range chain[i].Annotations {
+			data, err := json.Marshal(a)
+			if err != nil {
+				return nil, fmt.Errorf("encode annotations of %s: %w", chain[i].Cti, err)
+			}
+			var keywords map[string]any
+			if err := json.Unmarshal(data, &keywords); err != nil {
+				return nil, fmt.Errorf("decode annotations of %s: %w", chain[i].Cti, err)
+			}
+			if res[path] == nil {
+				res[path] = make(map[string]any, len(keywords))
+			}
+			for k, v := range keywords {
+				res[path][k] = v
+			}
+		}
+	}
+	return res, nil
+}
+
+// splitAnnotationPath splits the path of annotations into names of properties and # for items of arrays,
+// e.g. .items.#.name into items, #, name. The root path . has no segments.
+func splitAnnotationPath(path metadata.GJsonPath) []string {
+	p := strings.TrimPrefix(string(path), ".")
+	if p == "" {
+		return nil
+	}
+	return strings.Split(p, ".")
+}
+
+// locate returns schemas at the path. References to definitions are inlined along the path, so annotations applied
+// to located schemas are not ignored as siblings of $ref and do not leak into other schemas sharing the definitions.
+// All members of compositions are searched.
+func locate(schema map[string]any, defs map[string]any, path []string) []map[string]any {
+	if ref, ok := schema["$ref"].(string); ok {
+		if def, ok := defs[strings.TrimPrefix(ref, "#/definitions/")].(map[string]any); ok {
+			delete(schema, "$ref")
+			for k, v := range cloneJSON(def).(map[string]any) {
+				if _, ok := schema[k]; !ok {
+					schema[k] = v
+				}
+			}
+		}
+	}
+	if len(path) == 0 {
+		return []map[string]any{schema}
+	}
+
+	var res []map[string]any
+	var children []any
+	if path[0] == "#" {
+		children = append(children, schema["items"])
+	} else {
+		for _, key := range []string{"properties", "patternProperties"} {
+			if props, ok := schema[key].(map[string]any); ok {
+				children = append(children, props[path[0]])
+			}
+		}
+	}
+	for _, child := range children {
+		if child, ok := child.(map[string]any); ok {
+			res = append(res, locate(child, defs, path[1:])...)
+		}
+	}
+	for _, key := range []string{"anyOf", "oneOf", "allOf"} {
+		members, _ := schema[key].([]any)
+		for _, member := range members {
+			if member, ok := member.(map[string]any); ok {
+				res = append(res, locate(member, defs, path)...)
+			}
+		}
+	}
+	return res
+}
+
+// removeExtensions removes RAML extensions like x-custom from the schema and its subschemas.
+func removeExtensions(v any) {
+	switch v := v.(type) {
+	case map[string]any:
+		for k, child := range v {
+			if strings.HasPrefix(k, "x-") {
+				delete(v, k)
+				continue
+			}
+			removeExtensions(child)
+		}
+	case []any:
+		for _, child := range v {
+			removeExtensions(child)
+		}
+	}
+}
+
+func cloneJSON(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for k, child := range v {
+			res[k] = cloneJSON(child)
+		}
+		return res
+	case []any:
+		res := make([]any, len(v))
+		for i, child := range v {
+			res[i] = cloneJSON(child)
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+// matchETag reports whether the If-None-Match header matches the entity tag. Weak tags match by their value.
+func matchETag(header, etag string) bool {
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == etag {
+			return true
+		}
+	}
+	return false
+}
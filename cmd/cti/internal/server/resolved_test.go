@@ -0,0 +1,129 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func Test_ResolvedSchema(t *testing.T) {
+	final := true
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.x.y.message.v1.0": {
+			Cti: "cti.x.y.message.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Message",
+				"definitions": {
+					"Message": {
+						"type": "object",
+						"properties": {
+							"topic": {"$ref": "#/definitions/Ref"},
+							"source": {"$ref": "#/definitions/Ref"},
+							"tags": {"type": "array", "items": {"type": "string"}}
+						},
+						"x-custom": {"x-domainExt-cti.cti": "cti.x.y.message.v1.0"}
+					},
+					"Ref": {"type": "string"}
+				}
+			}`),
+			Annotations: map[metadata.GJsonPath]metadata.Annotations{
+				".":       {Cti: "cti.x.y.message.v1.0"},
+				".topic":  {Reference: "cti.x.y.topic.v1.0", Final: &final},
+				".tags.#": {Reference: true},
+			},
+		},
+		"cti.x.y.message.v1.0~x.y.alert.v1.0": {
+			Cti: "cti.x.y.message.v1.0~x.y.alert.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Alert",
+				"definitions": {"Alert": {"type": "object", "properties": {"severity": {"type": "integer"}}}}
+			}`),
+			Annotations: map[metadata.GJsonPath]metadata.Annotations{
+				".topic": {Reference: "cti.x.y.alerts.v1.0"},
+			},
+		},
+	})
+	require.NoError(t, err)
+	s, err := NewSnapshot(r, nil)
+	require.NoError(t, err)
+
+	resolved, err := s.ResolvedSchema("cti.x.y.message.v1.0~x.y.alert.v1.0")
+	require.NoError(t, err)
+	// Annotations of the type override annotations of the parent and are not applied to shared definitions.
+	require.JSONEq(t, `{
+		"definitions": {
+			"Alert": {"type": "object", "properties": {"severity": {"type": "integer"}}},
+			"Message": {
+				"type": "object",
+				"properties": {
+					"source": {"$ref": "#/definitions/Ref"},
+					"tags": {"type": "array", "items": {"type": "string"}},
+					"topic": {"$ref": "#/definitions/Ref"}
+				}
+			},
+			"Ref": {"type": "string"}
+		},
+		"type": "object",
+		"properties": {
+			"severity": {"type": "integer"},
+			"source": {"$ref": "#/definitions/Ref"},
+			"tags": {"type": "array", "items": {"type": "string", "x-cti.reference": true}},
+			"topic": {"type": "string", "x-cti.final": true, "x-cti.reference": "cti.x.y.alerts.v1.0"}
+		},
+		"x-cti.cti": "cti.x.y.message.v1.0"
+	}`, string(resolved.Schema))
+
+	cached, err := s.ResolvedSchema("cti.x.y.message.v1.0~x.y.alert.v1.0")
+	require.NoError(t, err)
+	require.Same(t, resolved, cached)
+
+	_, err = s.ResolvedSchema("cti.x.y.unknown.v1.0")
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func Test_ResolvedSchemaETag(t *testing.T) {
+	srv := New(testSnapshot(t))
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/types/cti.a.b.topic.v1.0/resolved", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := get("")
+	require.Equal(t, http.StatusOK, rec.Code)
+	etag := rec.Header().Get("ETag")
+	require.NotEmpty(t, etag)
+
+	testCases := []struct {
+		name        string
+		ifNoneMatch string
+		status      int
+	}{
+		{name: "matched", ifNoneMatch: etag, status: http.StatusNotModified},
+		{name: "weak", ifNoneMatch: "W/" + etag, status: http.StatusNotModified},
+		{name: "list", ifNoneMatch: `"stale", ` + etag, status: http.StatusNotModified},
+		{name: "any", ifNoneMatch: "*", status: http.StatusNotModified},
+		{name: "stale", ifNoneMatch: `"stale"`, status: http.StatusOK},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := get(tc.ifNoneMatch)
+			require.Equal(t, tc.status, rec.Code)
+			require.Equal(t, etag, rec.Header().Get("ETag"))
+			if tc.status == http.StatusNotModified {
+				require.Empty(t, rec.Body.String())
+			}
+		})
+	}
+}
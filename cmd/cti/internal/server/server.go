@@ -0,0 +1,251 @@
+// Package server serves CTI registries over HTTP: bundles, types with raw, flattened and resolved schemas and instances.
+// The API is described by the OpenAPI document served at /openapi.json and browsable at /docs.
+// The registry itself is browsable with the explorer served at /ui.
+// The served snapshot is swapped atomically, so the registry can be replaced while requests are served.
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+// Server is an http.Handler serving the read API of the registry snapshot.
+type Server struct {
+	snapshot atomic.Pointer[Snapshot]
+	mux      *http.ServeMux
+	opts     serverOptions
+	metrics  *metrics
+	// draining is set when the server is shutting down, see Drain.
+	draining atomic.Bool
+	// notifier delivers notifications to webhooks while Watch is running.
+	notifier atomic.Pointer[notifier]
+	// reloadMu serializes reloads of Watch and /reload, so snapshots are swapped and compared in order.
+	reloadMu sync.Mutex
+}
+
+type serverOptions struct {
+	graphQL  bool
+	auth     Authenticator
+	webhooks []Webhook
+	load     func() (*Snapshot, error)
+}
+
+type Option func(*serverOptions)
+
+// WithGraphQL enables the GraphQL endpoint at /graphql.
+func WithGraphQL() Option {
+	return func(o *serverOptions) {
+		o.graphQL = true
+	}
+}
+
+// WithAuth requires clients to authenticate with bearer tokens, see Authenticator.
+// Endpoints reading the registry require the read role, metrics and reloads require the admin role.
+// The OpenAPI document, its docs, the explorer and health checks are public.
+func WithAuth(auth Authenticator) Option {
+	return func(o *serverOptions) {
+		o.auth = auth
+	}
+}
+
+// WithReload enables the endpoint at /reload replacing the served snapshot with the one returned by load.
+// The endpoint is not served without authentication, see WithAuth.
+func WithReload(load func() (*Snapshot, error)) Option {
+	return func(o *serverOptions) {
+		o.load = load
+	}
+}
+
+// WithWebhooks notifies the webhooks about changes of entities when Watch reloads the registry.
+func WithWebhooks(hooks ...Webhook) Option {
+	return func(o *serverOptions) {
+		o.webhooks = append(o.webhooks, hooks...)
+	}
+}
+
+// New creates a server of the snapshot.
+func New(snapshot *Snapshot, opts ...Option) *Server {
+	s := &Server{mux: http.NewServeMux()}
+	for _, opt := range opts {
+		opt(&s.opts)
+	}
+	s.snapshot.Store(snapshot)
+	s.metrics = newMetrics(s)
+
+	for _, rt := range s.routes() {
+		s.mux.HandleFunc(rt.method+" "+rt.path, s.metrics.instrument(rt.path, s.authorize(rt.role, rt.handler)))
+	}
+	return s
+}
+
+type route struct {
+	method string
+	path   string
+	// role is required to call the endpoint if authentication is enabled. The endpoint is public if empty.
+	role    Role
+	handler http.HandlerFunc
+}
+
+// routes returns endpoints of the server. All endpoints must be described in openapi.json.
+func (s *Server) routes() []route {
+	routes := []route{
+		{http.MethodGet, "/openapi.json", "", serveOpenAPI},
+		{http.MethodGet, "/docs", "", serveDocs},
+		{http.MethodGet, "/ui", "", serveUI},
+		{http.MethodGet, "/metrics", RoleAdmin, s.serveMetrics()},
+		{http.MethodGet, "/healthz", "", serveHealth},
+		{http.MethodGet, "/readyz", "", s.serveReady},
+		{http.MethodGet, "/bundles", RoleRead, s.listBundles},
+		{http.MethodGet, "/types", RoleRead, s.listEntities(registry.KindType)},
+		{http.MethodGet, "/types/{cti}", RoleRead, s.getEntity(registry.KindType)},
+		{http.MethodGet, "/types/{cti}/schema", RoleRead, s.getSchema},
+		{http.MethodGet, "/types/{cti}/flattened", RoleRead, s.getFlattenedSchema},
+		{http.MethodGet, "/types/{cti}/resolved", RoleRead, s.getResolvedSchema},
+		{http.MethodGet, "/instances", RoleRead, s.listEntities(registry.KindInstance)},
+		{http.MethodGet, "/instances/{cti}", RoleRead, s.getEntity(registry.KindInstance)},
+		{http.MethodPost, "/validate", RoleRead, s.validate},
+	}
+	if s.opts.graphQL {
+		routes = append(routes, route{http.MethodPost, "/graphql", RoleRead, s.serveGraphQL(mustParseGraphQLSchema())})
+	}
+	if s.opts.load != nil && s.opts.auth != nil {
+		routes = append(routes, route{http.MethodPost, "/reload", RoleAdmin, s.serveReload})
+	}
+	return routes
+}
+
+// Snapshot returns the served snapshot.
+func (s *Server) Snapshot() *Snapshot {
+	return s.snapshot.Load()
+}
+
+// Drain reports the server as not ready at /readyz, so load balancers stop routing new requests to it
+// while requests in progress are completed. The server keeps serving all endpoints.
+func (s *Server) Drain() {
+	s.draining.Store(true)
+}
+
+// Swap replaces the served snapshot. Requests in progress are completed with the previous one.
+func (s *Server) Swap(snapshot *Snapshot) {
+	s.snapshot.Store(snapshot)
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mux.ServeHTTP(w, r)
+}
+
+// Entry is a summary of the entity in lists.
+type Entry struct {
+	Cti         string `json:"cti"`
+	Kind        string `json:"kind"`
+	Vendor      string `json:"vendor"`
+	Package     string `json:"package"`
+	DisplayName string `json:"display_name,omitempty"`
+}
+
+func makeEntry(entity *metadata.Entity) Entry {
+	vendor, pkg := metadata.GetVendorAndPackage(entity.Cti)
+	return Entry{
+		Cti:         entity.Cti,
+		Kind:        string(registry.KindOf(entity)),
+		Vendor:      vendor,
+		Package:     registry.PackageKey(vendor, pkg),
+		DisplayName: entity.DisplayName,
+	}
+}
+
+func (s *Server) listBundles(w http.ResponseWriter, _ *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{"bundles": s.Snapshot().Bundles})
+}
+
+func (s *Server) listEntities(kind registry.Kind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q, p, err := parseList(r.URL.Query())
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		q.Kind = kind
+		entities, err := s.Snapshot().Find(q)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+		entries := make([]Entry, 0, len(entities))
+		for _, entity := range entities {
+			entries = append(entries, makeEntry(entity))
+		}
+		entries, next := p.apply(entries)
+		res := map[string]any{string(kind) + "s": entries}
+		if next != "" {
+			res["next_cursor"] = next
+		}
+		writeJSON(w, http.StatusOK, res)
+	}
+}
+
+func (s *Server) getEntity(kind registry.Kind) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		entity, err := s.Snapshot().Lookup(r.PathValue("cti"))
+		if err == nil && registry.KindOf(entity) != kind {
+			err = fmt.Errorf("%s %s %w", kind, entity.Cti, ErrNotFound)
+		}
+		if err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, entity)
+	}
+}
+
+func (s *Server) getSchema(w http.ResponseWriter, r *http.Request) {
+	typ, ok := s.Snapshot().Registry.GetType(r.PathValue("cti"))
+	if !ok {
+		writeError(w, http.StatusNotFound, fmt.Errorf("type %s %w", r.PathValue("cti"), ErrNotFound))
+		return
+	}
+	writeJSON(w, http.StatusOK, typ.Schema)
+}
+
+func (s *Server) getFlattenedSchema(w http.ResponseWriter, r *http.Request) {
+	schema, err := s.Snapshot().FlattenedSchema(r.PathValue("cti"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, schema)
+}
+
+func (s *Server) getResolvedSchema(w http.ResponseWriter, r *http.Request) {
+	resolved, err := s.Snapshot().ResolvedSchema(r.PathValue("cti"))
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	w.Header().Set("ETag", resolved.ETag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if matchETag(r.Header.Get("If-None-Match"), resolved.ETag) {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+	writeJSON(w, http.StatusOK, resolved.Schema)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		slog.Error("Failed to write response", slog.String("error", err.Error()))
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,338 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func testSnapshot(t *testing.T) *Snapshot {
+	t.Helper()
+
+	displayName := true
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.x.y.event.v1.0": {
+			Cti: "cti.x.y.event.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Event",
+				"definitions": {
+					"Event": {"type": "object", "properties": {"id": {"type": "string"}}, "required": ["id"]}
+				}
+			}`),
+		},
+		"cti.x.y.event.v1.0~x.y.created.v1.0": {
+			Cti:         "cti.x.y.event.v1.0~x.y.created.v1.0",
+			DisplayName: "Created",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Created",
+				"definitions": {
+					"Created": {"type": "object", "properties": {"login": {"type": "string"}}, "required": ["login"]}
+				}
+			}`),
+		},
+		"cti.a.b.topic.v1.0": {
+			Cti: "cti.a.b.topic.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Topic",
+				"definitions": {"Topic": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}}
+			}`),
+			Annotations: map[metadata.GJsonPath]metadata.Annotations{".name": {DisplayName: &displayName}},
+		},
+		"cti.a.b.topic.v1.0~a.b.users.v1.0": {
+			Cti:         "cti.a.b.topic.v1.0~a.b.users.v1.0",
+			DisplayName: "Users",
+			Values:      []byte(`{"name":"users"}`),
+		},
+	})
+	require.NoError(t, err)
+
+	s, err := NewSnapshot(r, []Bundle{{ID: "x.y", Local: true}, {ID: "a.b", Version: "v1.0.0", Source: "github.com/a/b"}})
+	require.NoError(t, err)
+	return s
+}
+
+func Test_Server(t *testing.T) {
+	testCases := []struct {
+		name     string
+		path     string
+		status   int
+		expected string
+	}{
+		{
+			name:     "bundles",
+			path:     "/bundles",
+			status:   http.StatusOK,
+			expected: `{"bundles":[{"id":"x.y","local":true},{"id":"a.b","version":"v1.0.0","source":"github.com/a/b","local":false}]}`,
+		},
+		{
+			name:   "types",
+			path:   "/types",
+			status: http.StatusOK,
+			expected: `{"types":[
+				{"cti":"cti.a.b.topic.v1.0","kind":"type","vendor":"a","package":"a.b"},
+				{"cti":"cti.x.y.event.v1.0","kind":"type","vendor":"x","package":"x.y"},
+				{"cti":"cti.x.y.event.v1.0~x.y.created.v1.0","kind":"type","vendor":"x","package":"x.y","display_name":"Created"}
+			]}`,
+		},
+		{
+			name:   "types by pattern",
+			path:   "/types?pattern=cti.x.y.event.v1.0~*",
+			status: http.StatusOK,
+			expected: `{"types":[
+				{"cti":"cti.x.y.event.v1.0~x.y.created.v1.0","kind":"type","vendor":"x","package":"x.y","display_name":"Created"}
+			]}`,
+		},
+		{
+			name:     "types by invalid pattern",
+			path:     "/types?pattern=x",
+			status:   http.StatusBadRequest,
+			expected: `{"error":"parse pattern: not CTI expression"}`,
+		},
+		{
+			name:   "types by vendor",
+			path:   "/types?vendor=a",
+			status: http.StatusOK,
+			expected: `{"types":[
+				{"cti":"cti.a.b.topic.v1.0","kind":"type","vendor":"a","package":"a.b"}
+			]}`,
+		},
+		{
+			name:   "types by package sorted by display name",
+			path:   "/types?package=x.y&sort=-display_name",
+			status: http.StatusOK,
+			expected: `{"types":[
+				{"cti":"cti.x.y.event.v1.0~x.y.created.v1.0","kind":"type","vendor":"x","package":"x.y","display_name":"Created"},
+				{"cti":"cti.x.y.event.v1.0","kind":"type","vendor":"x","package":"x.y"}
+			]}`,
+		},
+		{
+			name:     "types by invalid sort",
+			path:     "/types?sort=vendor",
+			status:   http.StatusBadRequest,
+			expected: `{"error":"invalid sort vendor, expected cti or display_name with optional - prefix"}`,
+		},
+		{
+			name:     "types by invalid limit",
+			path:     "/types?limit=0",
+			status:   http.StatusBadRequest,
+			expected: `{"error":"invalid limit 0, expected 1 to 1000"}`,
+		},
+		{
+			name:     "types by invalid cursor",
+			path:     "/types?cursor=x",
+			status:   http.StatusBadRequest,
+			expected: `{"error":"invalid cursor"}`,
+		},
+		{
+			name:   "instances",
+			path:   "/instances",
+			status: http.StatusOK,
+			expected: `{"instances":[
+				{"cti":"cti.a.b.topic.v1.0~a.b.users.v1.0","kind":"instance","vendor":"a","package":"a.b","display_name":"Users"}
+			]}`,
+		},
+		{
+			name:     "instance",
+			path:     "/instances/cti.a.b.topic.v1.0~a.b.users.v1.0",
+			status:   http.StatusOK,
+			expected: `{"final":false,"cti":"cti.a.b.topic.v1.0~a.b.users.v1.0","display_name":"Users","values":{"name":"users"},"source_map":{}}`,
+		},
+		{
+			name:     "type is not an instance",
+			path:     "/instances/cti.a.b.topic.v1.0",
+			status:   http.StatusNotFound,
+			expected: `{"error":"instance cti.a.b.topic.v1.0 not found"}`,
+		},
+		{
+			name:   "schema",
+			path:   "/types/cti.x.y.event.v1.0~x.y.created.v1.0/schema",
+			status: http.StatusOK,
+			expected: `{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Created",
+				"definitions": {
+					"Created": {"type": "object", "properties": {"login": {"type": "string"}}, "required": ["login"]}
+				}
+			}`,
+		},
+		{
+			name:   "flattened schema",
+			path:   "/types/cti.x.y.event.v1.0~x.y.created.v1.0/flattened",
+			status: http.StatusOK,
+			expected: `{
+				"type": "object",
+				"properties": {"id": {"type": "string"}, "login": {"type": "string"}},
+				"required": ["id", "login"],
+				"definitions": {
+					"Created": {"type": "object", "properties": {"login": {"type": "string"}}, "required": ["login"]},
+					"Event": {"type": "object", "properties": {"id": {"type": "string"}}, "required": ["id"]}
+				}
+			}`,
+		},
+		{
+			name:   "resolved schema",
+			path:   "/types/cti.a.b.topic.v1.0/resolved",
+			status: http.StatusOK,
+			expected: `{
+				"type": "object",
+				"properties": {"name": {"type": "string", "x-cti.display_name": true}},
+				"required": ["name"],
+				"definitions": {"Topic": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}}
+			}`,
+		},
+		{
+			name:     "unknown resolved type",
+			path:     "/types/cti.x.y.unknown.v1.0/resolved",
+			status:   http.StatusNotFound,
+			expected: `{"error":"type cti.x.y.unknown.v1.0 not found"}`,
+		},
+		{
+			name:     "unknown type",
+			path:     "/types/cti.x.y.unknown.v1.0/flattened",
+			status:   http.StatusNotFound,
+			expected: `{"error":"type cti.x.y.unknown.v1.0 not found"}`,
+		},
+	}
+	srv := New(testSnapshot(t))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tc.path, nil))
+			res := rec.Result()
+			body, err := io.ReadAll(res.Body)
+			require.NoError(t, err)
+			require.Equal(t, tc.status, res.StatusCode, string(body))
+			require.Equal(t, "application/json", res.Header.Get("Content-Type"))
+			require.JSONEq(t, tc.expected, string(body))
+		})
+	}
+}
+
+func Test_ServerSwap(t *testing.T) {
+	srv := New(testSnapshot(t))
+
+	r, err := registry.New(metadata.EntitiesMap{})
+	require.NoError(t, err)
+	empty, err := NewSnapshot(r, nil)
+	require.NoError(t, err)
+	srv.Swap(empty)
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/types", nil))
+	require.JSONEq(t, `{"types":[]}`, rec.Body.String())
+}
+
+func Test_ServerPagination(t *testing.T) {
+	srv := New(testSnapshot(t))
+
+	testCases := []struct {
+		name     string
+		query    string
+		expected []string
+	}{
+		{
+			name:     "by cti",
+			query:    "limit=2",
+			expected: []string{"cti.a.b.topic.v1.0", "cti.x.y.event.v1.0", "cti.x.y.event.v1.0~x.y.created.v1.0"},
+		},
+		{
+			name:     "by cti descending",
+			query:    "limit=1&sort=-cti",
+			expected: []string{"cti.x.y.event.v1.0~x.y.created.v1.0", "cti.x.y.event.v1.0", "cti.a.b.topic.v1.0"},
+		},
+		{
+			name:     "by display name",
+			query:    "limit=1&sort=display_name",
+			expected: []string{"cti.a.b.topic.v1.0", "cti.x.y.event.v1.0", "cti.x.y.event.v1.0~x.y.created.v1.0"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var ids []string
+			query := tc.query
+			for pages := 0; ; pages++ {
+				require.Less(t, pages, len(tc.expected), "too many pages")
+				rec := httptest.NewRecorder()
+				srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/types?"+query, nil))
+				require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+				var res struct {
+					Types      []Entry `json:"types"`
+					NextCursor string  `json:"next_cursor"`
+				}
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+				for _, e := range res.Types {
+					ids = append(ids, e.Cti)
+				}
+				if res.NextCursor == "" {
+					break
+				}
+				query = tc.query + "&cursor=" + res.NextCursor
+			}
+			require.Equal(t, tc.expected, ids)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/types?limit=1", nil))
+	var res struct {
+		NextCursor string `json:"next_cursor"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/types?sort=display_name&cursor="+res.NextCursor, nil))
+	require.Equal(t, http.StatusBadRequest, rec.Code)
+	require.JSONEq(t, `{"error":"invalid cursor: it was issued for another sort order"}`, rec.Body.String())
+}
+
+func Test_OpenAPI(t *testing.T) {
+	snapshot := testSnapshot(t)
+	srv := New(snapshot, WithGraphQL(), WithReload(func() (*Snapshot, error) { return snapshot, nil }), WithAuth(Tokens{}))
+
+	rec := httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Security *[]any `json:"security"`
+		} `json:"paths"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+
+	documented := make(map[string]bool)
+	for path, operations := range doc.Paths {
+		for method, op := range operations {
+			// Operations overriding security with an empty list are public.
+			documented[strings.ToUpper(method)+" "+path] = op.Security == nil
+		}
+	}
+	served := make(map[string]bool)
+	for _, rt := range srv.routes() {
+		served[rt.method+" "+rt.path] = rt.role != ""
+	}
+	require.Equal(t, served, documented, "endpoints of the server and openapi.json differ")
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "SwaggerUIBundle")
+
+	rec = httptest.NewRecorder()
+	srv.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/ui", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+	require.Contains(t, rec.Body.String(), "CTI registry explorer")
+}
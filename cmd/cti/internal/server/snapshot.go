@@ -0,0 +1,161 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+
+	"github.com/acronis/go-cti"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/compiled"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/registry"
+	"github.com/acronis/go-cti/metadata/validator"
+)
+
+// ErrNotFound is returned for unknown entities.
+var ErrNotFound = errors.New("not found")
+
+// Bundle is a package whose entities are served: the package itself or one of its dependencies.
+type Bundle struct {
+	ID      string `json:"id"`
+	Version string `json:"version,omitempty"`
+	Source  string `json:"source,omitempty"`
+	// Local is set for the served package, dependencies are not local.
+	Local bool `json:"local"`
+}
+
+// Snapshot is an immutable state of the served registry. Servers swap snapshots as a whole,
+// so a request is served from a single consistent state.
+type Snapshot struct {
+	Bundles  []Bundle
+	Registry *registry.Registry
+	// Compiled holds flattened schemas of types.
+	Compiled *compiled.Registry
+
+	// The validator collects diagnostics of the last call, so validations are serialized.
+	mu        sync.Mutex
+	validator *validator.MetadataValidator
+
+	// resolved caches resolved schemas by CTI of types.
+	resolved sync.Map
+}
+
+// NewSnapshot creates a snapshot of the registry and compiles its types.
+// Instances are validated with the validator configured by the options, see ValidateInstance.
+func NewSnapshot(r *registry.Registry, bundles []Bundle, opts ...validator.Option) (*Snapshot, error) {
+	c, err := compiled.Compile(r)
+	if err != nil {
+		return nil, fmt.Errorf("compile registry: %w", err)
+	}
+	v := validator.MakeMetadataValidator(opts...)
+	v.LoadRegistry(r)
+	return &Snapshot{Bundles: bundles, Registry: r, Compiled: c, validator: v}, nil
+}
+
+// LoadPackage parses the package in the directory and creates a snapshot of its entities and
+// entities of its dependencies. Instances are validated with the validator configured by the options.
+func LoadPackage(baseDir string, opts ...validator.Option) (*Snapshot, error) {
+	pkg, err := ctipackage.New(baseDir)
+	if err != nil {
+		return nil, fmt.Errorf("new package: %w", err)
+	}
+	if err := pkg.Read(); err != nil {
+		return nil, fmt.Errorf("read package: %w", err)
+	}
+	if err := pkg.Parse(); err != nil {
+		return nil, fmt.Errorf("parse package: %w", err)
+	}
+
+	bundles := []Bundle{{ID: pkg.Index.PackageID, Local: true}}
+	for _, info := range pkg.IndexLock.SourceInfo {
+		bundles = append(bundles, Bundle{ID: info.PackageID, Version: info.Version, Source: info.Source})
+	}
+	sort.SliceStable(bundles[1:], func(a, b int) bool {
+		return bundles[a+1].ID < bundles[b+1].ID
+	})
+	return NewSnapshot(pkg.Registry, bundles, opts...)
+}
+
+// Query defines criteria to select entities from the snapshot. Empty fields are not taken into account.
+type Query struct {
+	Kind registry.Kind
+	// Vendor is a vendor that owns entities.
+	Vendor string
+	// Package is a package that owns entities in <vendor>.<package> form.
+	Package string
+	// Pattern is a CTI expression that entities must match, e.g. cti.x.y.* or cti.x.y.event.v1.0.
+	Pattern string
+}
+
+// Find returns entities that satisfy the query sorted by CTI.
+func (s *Snapshot) Find(q Query) (metadata.Entities, error) {
+	entities := s.Registry.Find(registry.Filter{Kind: q.Kind, Vendor: q.Vendor, Package: q.Package})
+	if q.Pattern == "" {
+		return entities, nil
+	}
+
+	p := cti.NewParser(cti.WithAllowAnonymousEntity(true))
+	expr, err := p.Parse(q.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("parse pattern: %w", err)
+	}
+	var res metadata.Entities
+	for _, entity := range entities {
+		id, err := p.Parse(entity.Cti)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", entity.Cti, err)
+		}
+		ok, err := expr.Match(id)
+		if err != nil {
+			return nil, fmt.Errorf("match %s: %w", entity.Cti, err)
+		}
+		if ok {
+			res = append(res, entity)
+		}
+	}
+	return res, nil
+}
+
+// Lookup returns the entity by CTI.
+func (s *Snapshot) Lookup(id string) (*metadata.Entity, error) {
+	entity, ok := s.Registry.Get(id)
+	if !ok {
+		return nil, fmt.Errorf("entity %s %w", id, ErrNotFound)
+	}
+	return entity, nil
+}
+
+// FlattenedSchema returns the schema of the type merged with schemas of ancestors, with all definitions it refers to.
+func (s *Snapshot) FlattenedSchema(id string) (json.RawMessage, error) {
+	typ, ok := s.Compiled.Type(id)
+	if !ok {
+		return nil, fmt.Errorf("type %s %w", id, ErrNotFound)
+	}
+	return typ.Schema, nil
+}
+
+// ValidateInstance validates values of an instance of the type the same way as instances of the package are validated.
+// The id identifies the instance in diagnostics, the anonymous instance of the type with nil UUID is validated if empty.
+func (s *Snapshot) ValidateInstance(typeID, id string, values json.RawMessage) (*validator.Report, error) {
+	if _, ok := s.Registry.GetType(typeID); !ok {
+		return nil, fmt.Errorf("type %s %w", typeID, ErrNotFound)
+	}
+	if !json.Valid(values) {
+		return nil, errors.New("values are not valid JSON")
+	}
+	if id == "" {
+		id = typeID + "~" + uuid.Nil.String()
+	} else if metadata.GetParentCti(id) != typeID {
+		return nil, fmt.Errorf("instance %s is not derived from %s", id, typeID)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	diagnostics := s.validator.Diagnostics(&metadata.Entity{Cti: id, Values: values})
+	return validator.NewReport(1, diagnostics...), nil
+}
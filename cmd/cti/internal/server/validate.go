@@ -0,0 +1,56 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// maxValidateBody limits the size of payloads accepted by /validate.
+const maxValidateBody = 4 << 20
+
+// ValidateRequest is the body of /validate: values of an instance and the type they are validated against.
+type ValidateRequest struct {
+	Type string `json:"type"`
+	// ID identifies the instance in diagnostics, see Snapshot.ValidateInstance.
+	ID     string          `json:"id,omitempty"`
+	Values json.RawMessage `json:"values"`
+}
+
+// validate validates the payload against the type and responds with the validation report. Invalid payloads are
+// reported with 200, as the validation itself succeeded, so clients check the valid field of the report.
+func (s *Server) validate(w http.ResponseWriter, r *http.Request) {
+	var req ValidateRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxValidateBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&req); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, fmt.Errorf("request exceeds %d bytes", maxBytesErr.Limit))
+			return
+		}
+		writeError(w, http.StatusBadRequest, fmt.Errorf("decode request: %w", err))
+		return
+	}
+	if req.Type == "" {
+		writeError(w, http.StatusBadRequest, errors.New("type is required"))
+		return
+	}
+	if req.Values == nil {
+		writeError(w, http.StatusBadRequest, errors.New("values are required"))
+		return
+	}
+
+	report, err := s.Snapshot().ValidateInstance(req.Type, req.ID, req.Values)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, ErrNotFound) {
+			status = http.StatusNotFound
+		}
+		writeError(w, status, err)
+		return
+	}
+	s.metrics.observeValidation(report)
+	writeJSON(w, http.StatusOK, report)
+}
@@ -0,0 +1,108 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata/validator"
+)
+
+func Test_Validate(t *testing.T) {
+	testCases := []struct {
+		name     string
+		body     string
+		status   int
+		expected string
+	}{
+		{
+			name:     "valid",
+			body:     `{"type":"cti.a.b.topic.v1.0","values":{"name":"users"}}`,
+			status:   http.StatusOK,
+			expected: `{"version":1,"valid":true,"summary":{"entities":1,"errors":0,"warnings":0,"codes":{}},"files":[]}`,
+		},
+		{
+			name:   "invalid",
+			body:   `{"type":"cti.a.b.topic.v1.0","id":"cti.a.b.topic.v1.0~a.b.orders.v1.0","values":{"name":1}}`,
+			status: http.StatusOK,
+			expected: `{
+				"version": 1,
+				"valid": false,
+				"summary": {"entities": 1, "errors": 1, "warnings": 0, "codes": {"CTI1020": 1}},
+				"files": [{"path": "", "diagnostics": [{
+					"code": "CTI1020",
+					"severity": "error",
+					"category": "constraint",
+					"id": "cti.a.b.topic.v1.0~a.b.orders.v1.0",
+					"path": "name",
+					"message": "cti.a.b.topic.v1.0~a.b.orders.v1.0@name: Invalid type. Expected: string, given: integer"
+				}]}]
+			}`,
+		},
+		{
+			name:     "unknown type",
+			body:     `{"type":"cti.a.b.unknown.v1.0","values":{}}`,
+			status:   http.StatusNotFound,
+			expected: `{"error":"type cti.a.b.unknown.v1.0 not found"}`,
+		},
+		{
+			name:     "foreign instance",
+			body:     `{"type":"cti.a.b.topic.v1.0","id":"cti.x.y.event.v1.0~x.y.orders.v1.0","values":{}}`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":"instance cti.x.y.event.v1.0~x.y.orders.v1.0 is not derived from cti.a.b.topic.v1.0"}`,
+		},
+		{
+			name:     "missing type",
+			body:     `{"values":{}}`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":"type is required"}`,
+		},
+		{
+			name:     "missing values",
+			body:     `{"type":"cti.a.b.topic.v1.0"}`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":"values are required"}`,
+		},
+		{
+			name:     "unknown field",
+			body:     `{"type":"cti.a.b.topic.v1.0","value":{}}`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":"decode request: json: unknown field \"value\""}`,
+		},
+		{
+			name:     "malformed",
+			body:     `{"type":`,
+			status:   http.StatusBadRequest,
+			expected: `{"error":"decode request: unexpected EOF"}`,
+		},
+		{
+			name:     "too large",
+			body:     `{"type":"cti.a.b.topic.v1.0","values":"` + strings.Repeat("a", maxValidateBody) + `"}`,
+			status:   http.StatusRequestEntityTooLarge,
+			expected: `{"error":"request exceeds 4194304 bytes"}`,
+		},
+	}
+	srv := New(testSnapshot(t))
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			srv.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/validate", strings.NewReader(tc.body)))
+			require.Equal(t, tc.status, rec.Code, rec.Body.String())
+			require.JSONEq(t, tc.expected, rec.Body.String())
+		})
+	}
+}
+
+func Test_ValidateWithOptions(t *testing.T) {
+	s := testSnapshot(t)
+	lenient, err := NewSnapshot(s.Registry, s.Bundles, validator.WithSeverity(validator.CodeInvalidValues, validator.SeverityWarning))
+	require.NoError(t, err)
+
+	report, err := lenient.ValidateInstance("cti.a.b.topic.v1.0", "", []byte(`{"name":1}`))
+	require.NoError(t, err)
+	require.True(t, report.Valid)
+	require.Equal(t, 1, report.Summary.Warnings)
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is a delay between the last change of files and the reload, so saving several files results
+// in a single reload.
+var watchDebounce = 200 * time.Millisecond
+
+// Watch reloads the served snapshot with load when files of the registry change in the directories, until the
+// context is done. Subdirectories are watched too, except hidden ones like .dep or .git: pass them explicitly to
+// watch them. The previous snapshot is served if load fails, so the server survives files saved half-way.
+// Webhooks of the server are notified about changes of entities after each reload.
+func (s *Server) Watch(ctx context.Context, load func() (*Snapshot, error), dirs ...string) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("create watcher: %w", err)
+	}
+	defer w.Close()
+	for _, dir := range dirs {
+		if err := watchDir(w, dir); err != nil {
+			return err
+		}
+	}
+
+	n := s.startNotifier(ctx)
+	s.notifier.Store(n)
+	defer s.notifier.Store(nil)
+	var reload <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if event.Has(fsnotify.Create) && !hidden(event.Name) {
+				// New directories are not watched automatically. Errors are ignored since the directory may be removed already.
+				_ = watchDir(w, event.Name)
+			}
+			if affectsRegistry(event) {
+				reload = time.After(watchDebounce)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			slog.Warn("Failed to watch files", slog.String("error", err.Error()))
+		case <-reload:
+			reload = nil
+			if err := s.reload(load, n); err != nil {
+				slog.Error("Failed to reload registry, serving the previous one", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+// reload swaps the served snapshot with the loaded one and notifies webhooks about changes if the notifier is set.
+// The previous snapshot is served if load fails. Reloads run one at a time, so load is never called concurrently
+// and changes are computed against the snapshot replaced.
+func (s *Server) reload(load func() (*Snapshot, error), n *notifier) error {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	start := time.Now()
+	snapshot, err := load()
+	s.metrics.observeReload(start, err)
+	if err != nil {
+		return err
+	}
+	prev := s.Snapshot()
+	s.Swap(snapshot)
+	slog.Info("Reloaded registry", slog.Int("entities", snapshot.Registry.Len()))
+
+	if n == nil || len(n.hooks) == 0 {
+		return nil
+	}
+	changes, err := Changes(prev, snapshot)
+	if err != nil {
+		slog.Error("Failed to compare registries, webhooks are not notified", slog.String("error", err.Error()))
+		return nil
+	}
+	if len(changes) > 0 {
+		n.notify(changes)
+	}
+	return nil
+}
+
+// serveReload reloads the registry on request, e.g. after dependencies were updated without watching them.
+func (s *Server) serveReload(w http.ResponseWriter, _ *http.Request) {
+	if err := s.reload(s.opts.load, s.notifier.Load()); err != nil {
+		writeError(w, http.StatusInternalServerError, fmt.Errorf("reload registry: %w", err))
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// watchDir adds the directory and its subdirectories that are not hidden to the watcher.
+func watchDir(w *fsnotify.Watcher, root string) error {
+	return filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if path != root && hidden(path) {
+			return filepath.SkipDir
+		}
+		if err := w.Add(path); err != nil {
+			return fmt.Errorf("watch %s: %w", path, err)
+		}
+		return nil
+	})
+}
+
+func hidden(path string) bool {
+	return strings.HasPrefix(filepath.Base(path), ".")
+}
+
+// affectsRegistry reports whether the event may change the registry: changes of RAML files and indexes of packages
+// or directories holding them. Hidden files like caches and temporary files of editors are ignored.
+func affectsRegistry(event fsnotify.Event) bool {
+	if event.Op == fsnotify.Chmod || hidden(event.Name) {
+		return false
+	}
+	switch filepath.Ext(event.Name) {
+	case ".raml", ".json", ".yaml":
+		return true
+	case "":
+		// Directories are created, moved or removed along with their files.
+		return !event.Has(fsnotify.Write)
+	default:
+		return false
+	}
+}
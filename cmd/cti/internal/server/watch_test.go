@@ -0,0 +1,135 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Watch(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "entities"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".dep"), 0o755))
+
+	initial := testSnapshot(t)
+	srv := New(initial)
+	reloaded := testSnapshot(t)
+	var loads atomic.Int32
+	var fail atomic.Bool
+	load := func() (*Snapshot, error) {
+		loads.Add(1)
+		if fail.Load() {
+			return nil, errors.New("parse package: syntax error")
+		}
+		return reloaded, nil
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- srv.Watch(ctx, load, dir)
+	}()
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+	})
+	// Give the watcher time to add directories.
+	time.Sleep(100 * time.Millisecond)
+
+	// Saving several files results in a single reload.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "entities", "a.raml"), []byte("#%RAML 1.0 Library"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "index.json"), []byte("{}"), 0o600))
+	require.Eventually(t, func() bool { return srv.Snapshot() != initial }, 5*time.Second, 10*time.Millisecond)
+	time.Sleep(2 * watchDebounce)
+	require.EqualValues(t, 1, loads.Load())
+
+	// Hidden directories, hidden files and other files are ignored.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".dep", "b.raml"), []byte("#%RAML 1.0 Library"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".cache.json"), []byte("{}"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("notes"), 0o600))
+	time.Sleep(3 * watchDebounce)
+	require.EqualValues(t, 1, loads.Load())
+
+	// Files in new directories are watched.
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "entities", "events"), 0o755))
+	require.Eventually(t, func() bool { return loads.Load() == 2 }, 5*time.Second, 10*time.Millisecond)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "entities", "events", "c.raml"), []byte("#%RAML 1.0 Library"), 0o600))
+	require.Eventually(t, func() bool { return loads.Load() == 3 }, 5*time.Second, 10*time.Millisecond)
+
+	// The previous snapshot is served if the registry fails to load.
+	fail.Store(true)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "entities", "a.raml"), []byte("#%RAML 1.0 Library\n:"), 0o600))
+	require.Eventually(t, func() bool { return loads.Load() == 4 }, 5*time.Second, 10*time.Millisecond)
+	require.Same(t, reloaded, srv.Snapshot())
+}
+
+func Test_Reload(t *testing.T) {
+	initial := testSnapshot(t)
+	reloaded := testSnapshot(t)
+	var fail atomic.Bool
+	load := func() (*Snapshot, error) {
+		if fail.Load() {
+			return nil, errors.New("parse package: syntax error")
+		}
+		return reloaded, nil
+	}
+	srv := New(initial, WithReload(load), WithAuth(Tokens{"admin-token": RoleAdmin}))
+	post := func(srv *Server) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/reload", nil)
+		req.Header.Set("Authorization", "Bearer admin-token")
+		rec := httptest.NewRecorder()
+		srv.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := post(srv)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	require.Same(t, reloaded, srv.Snapshot())
+
+	fail.Store(true)
+	rec = post(srv)
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+	require.JSONEq(t, `{"error": "reload registry: parse package: syntax error"}`, rec.Body.String())
+	require.Same(t, reloaded, srv.Snapshot())
+
+	// The endpoint is not served if reloads are not enabled or clients are not authenticated.
+	require.Equal(t, http.StatusNotFound, post(New(initial, WithAuth(Tokens{"admin-token": RoleAdmin}))).Code)
+	require.Equal(t, http.StatusNotFound, post(New(initial, WithReload(load))).Code)
+}
+
+func Test_ReloadSerialized(t *testing.T) {
+	srv := New(testSnapshot(t))
+	var running, overlapped atomic.Int32
+	var last atomic.Pointer[Snapshot]
+	load := func() (*Snapshot, error) {
+		if running.Add(1) > 1 {
+			overlapped.Add(1)
+		}
+		defer running.Add(-1)
+		time.Sleep(time.Millisecond)
+		snapshot := testSnapshot(t)
+		last.Store(snapshot)
+		return snapshot, nil
+	}
+
+	var wg sync.WaitGroup
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			require.NoError(t, srv.reload(load, nil))
+		}()
+	}
+	wg.Wait()
+	require.Zero(t, overlapped.Load(), "load was called concurrently")
+	require.Same(t, last.Load(), srv.Snapshot())
+}
@@ -0,0 +1,147 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// webhookQueueSize is a number of notifications waiting for delivery to a slow webhook before new ones are dropped.
+	webhookQueueSize = 64
+	webhookAttempts  = 3
+	webhookTimeout   = 10 * time.Second
+)
+
+// webhookBackoff is a delay before the second attempt to deliver the notification, doubled for next attempts.
+var webhookBackoff = time.Second
+
+// Webhook is an endpoint notified about changes of the registry reloaded by Watch.
+type Webhook struct {
+	URL string
+	// Secret signs notifications with HMAC-SHA256. The signature is sent in the X-CTI-Signature-256 header
+	// as sha256=<hex>, so the endpoint can check that notifications come from the server. Not signed if empty.
+	Secret string
+}
+
+// Notification is a body of requests sent to webhooks.
+type Notification struct {
+	// ID identifies the notification, it is the same for all webhooks and retries.
+	ID      string    `json:"id"`
+	Time    time.Time `json:"time"`
+	Changes []Change  `json:"changes"`
+}
+
+// notifier delivers notifications to webhooks. Each webhook has its own queue, so a slow webhook does not
+// delay others, and notifications are delivered to a webhook in order.
+type notifier struct {
+	hooks  []Webhook
+	queues []chan Notification
+}
+
+// startNotifier starts delivering notifications to webhooks of the server until the context is done.
+func (s *Server) startNotifier(ctx context.Context) *notifier {
+	n := &notifier{hooks: s.opts.webhooks}
+	client := &http.Client{Timeout: webhookTimeout}
+	for _, hook := range n.hooks {
+		queue := make(chan Notification, webhookQueueSize)
+		n.queues = append(n.queues, queue)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case notification := <-queue:
+					if err := deliver(ctx, client, hook, notification); err != nil {
+						slog.Error("Failed to notify webhook",
+							slog.String("url", hook.URL),
+							slog.String("id", notification.ID),
+							slog.String("error", err.Error()))
+					}
+				}
+			}
+		}()
+	}
+	return n
+}
+
+// notify queues the notification about changes for delivery to all webhooks.
+func (n *notifier) notify(changes []Change) {
+	notification := Notification{ID: uuid.NewString(), Time: time.Now().UTC(), Changes: changes}
+	for i, queue := range n.queues {
+		select {
+		case queue <- notification:
+		default:
+			slog.Warn("Webhook does not keep up with changes, notification is dropped",
+				slog.String("url", n.hooks[i].URL),
+				slog.String("id", notification.ID))
+		}
+	}
+}
+
+// deliver posts the notification to the webhook. Failed attempts are retried with exponential backoff,
+// unless the webhook rejects the notification with a 4xx status.
+func deliver(ctx context.Context, client *http.Client, hook Webhook, notification Notification) error {
+	body, err := json.Marshal(notification)
+	if err != nil {
+		return fmt.Errorf("encode notification: %w", err)
+	}
+	backoff := webhookBackoff
+	for attempt := 1; ; attempt++ {
+		retry, err := post(ctx, client, hook, notification.ID, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt == webhookAttempts {
+			return fmt.Errorf("attempt %d: %w", attempt, err)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+			backoff *= 2
+		}
+	}
+}
+
+// post sends the notification once and reports whether a failed request may be retried.
+func post(ctx context.Context, client *http.Client, hook Webhook, id string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cti-registry")
+	req.Header.Set("X-CTI-Delivery", id)
+	if hook.Secret != "" {
+		req.Header.Set("X-CTI-Signature-256", sign(hook.Secret, body))
+	}
+
+	res, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode/100 == 2 {
+		return false, nil
+	}
+	return res.StatusCode/100 != 4, fmt.Errorf("unexpected status %s", res.Status)
+}
+
+// sign returns the HMAC-SHA256 signature of the body in the form of the X-CTI-Signature-256 header.
+func sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
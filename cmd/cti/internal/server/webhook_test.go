@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func Test_Deliver(t *testing.T) {
+	webhookBackoff = time.Millisecond
+	notification := Notification{ID: "1", Changes: []Change{{Cti: "cti.a.b.topic.v1.0", Kind: registry.KindType, Action: ActionAdded}}}
+
+	testCases := []struct {
+		name     string
+		statuses []int
+		attempts int32
+		err      string
+	}{
+		{name: "delivered", statuses: []int{http.StatusNoContent}, attempts: 1},
+		{name: "retried", statuses: []int{http.StatusBadGateway, http.StatusInternalServerError, http.StatusOK}, attempts: 3},
+		{
+			name:     "retries exceeded",
+			statuses: []int{http.StatusBadGateway, http.StatusBadGateway, http.StatusBadGateway},
+			attempts: 3,
+			err:      "attempt 3: unexpected status 502 Bad Gateway",
+		},
+		{name: "rejected", statuses: []int{http.StatusBadRequest}, attempts: 1, err: "attempt 1: unexpected status 400 Bad Request"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			hook := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := attempts.Add(1)
+				body, err := io.ReadAll(r.Body)
+				require.NoError(t, err)
+				require.Equal(t, sign("secret", body), r.Header.Get("X-CTI-Signature-256"))
+				require.Equal(t, "1", r.Header.Get("X-CTI-Delivery"))
+				require.JSONEq(t, `{"id":"1","time":"0001-01-01T00:00:00Z",
+					"changes":[{"cti":"cti.a.b.topic.v1.0","kind":"type","action":"added"}]}`, string(body))
+				w.WriteHeader(tc.statuses[n-1])
+			}))
+			defer hook.Close()
+
+			err := deliver(context.Background(), hook.Client(), Webhook{URL: hook.URL, Secret: "secret"}, notification)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tc.attempts, attempts.Load())
+		})
+	}
+}
+
+func Test_ReloadNotifiesWebhooks(t *testing.T) {
+	received := make(chan Notification, 1)
+	hook := httptest.NewServer(http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+		var n Notification
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&n))
+		received <- n
+	}))
+	defer hook.Close()
+
+	srv := New(testSnapshot(t), WithWebhooks(Webhook{URL: hook.URL}))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	n := srv.startNotifier(ctx)
+
+	entities := srv.Snapshot().Registry.Entities()
+	m := make(metadata.EntitiesMap, len(entities))
+	for _, entity := range entities {
+		m[entity.Cti] = entity
+	}
+	delete(m, "cti.a.b.topic.v1.0~a.b.users.v1.0")
+	r, err := registry.New(m)
+	require.NoError(t, err)
+	next, err := NewSnapshot(r, nil)
+	require.NoError(t, err)
+
+	// Unchanged registries are not notified about.
+	require.NoError(t, srv.reload(func() (*Snapshot, error) { return srv.Snapshot(), nil }, n))
+	require.NoError(t, srv.reload(func() (*Snapshot, error) { return next, nil }, n))
+	select {
+	case notification := <-received:
+		require.NotEmpty(t, notification.ID)
+		require.Equal(t, []Change{
+			{Cti: "cti.a.b.topic.v1.0~a.b.users.v1.0", Kind: registry.KindInstance, Action: ActionRemoved},
+		}, notification.Changes)
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "webhook is not notified")
+	}
+	require.Empty(t, received)
+}
@@ -0,0 +1,165 @@
+// Package binding exposes the parser and the validator through a JSON API for hosts that cannot call Go
+// directly, e.g. browsers and plugin sandboxes running the WebAssembly build (see binding/wasm and binding/wasi).
+// Hosts get the same diagnostics as the CLI since the same validator is used.
+package binding
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/acronis/go-cti"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+	"github.com/acronis/go-cti/metadata/validator"
+)
+
+const (
+	// MethodParse parses the identifier of the request.
+	MethodParse = "parse"
+	// MethodValidate validates entities of the request against the registry of the request.
+	MethodValidate = "validate"
+)
+
+// Request is a call of the JSON API.
+type Request struct {
+	Method string `json:"method"`
+	// ID is an identifier to parse.
+	ID string `json:"id,omitempty"`
+	// Registry holds entities that validated entities refer to, e.g. the content of .cache.json of the package.
+	Registry json.RawMessage `json:"registry,omitempty"`
+	// Entities holds an entity or an array of entities to validate. Entities of the registry are validated if not set.
+	Entities json.RawMessage `json:"entities,omitempty"`
+}
+
+// Response is a result of the call. Error is set if the request cannot be processed, diagnostics of invalid
+// identifiers and entities are reported in results.
+type Response struct {
+	Parse  *ParseResult      `json:"parse,omitempty"`
+	Report *validator.Report `json:"report,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// ParseResult is a result of parsing of the identifier.
+type ParseResult struct {
+	Valid   bool   `json:"valid"`
+	Error   string `json:"error,omitempty"`
+	Parent  string `json:"parent,omitempty"`
+	Vendor  string `json:"vendor,omitempty"`
+	Package string `json:"package,omitempty"`
+	// Anonymous is set for identifiers of anonymous entities, e.g. cti.x.y.event.v1.0~3f2a....
+	Anonymous bool `json:"anonymous,omitempty"`
+}
+
+// Call processes the request encoded as JSON and returns the encoded response.
+func Call(request []byte) []byte {
+	var res Response
+	var req Request
+	if err := json.Unmarshal(request, &req); err != nil {
+		res.Error = fmt.Sprintf("decode request: %s", err)
+		return encode(res)
+	}
+	switch req.Method {
+	case MethodParse:
+		res.Parse = Parse(req.ID)
+	case MethodValidate:
+		v, err := NewValidator(req.Registry)
+		if err != nil {
+			res.Error = err.Error()
+			break
+		}
+		if len(req.Entities) == 0 {
+			res.Report = v.ValidateAll()
+			break
+		}
+		if res.Report, err = v.Validate(req.Entities); err != nil {
+			res.Error = err.Error()
+		}
+	default:
+		res.Error = fmt.Sprintf("unknown method %q", req.Method)
+	}
+	return encode(res)
+}
+
+func encode(v any) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		// Responses consist of strings and numbers only.
+		panic(err)
+	}
+	return data
+}
+
+// Parse parses the identifier the same way as the validator does.
+func Parse(id string) *ParseResult {
+	expr, err := cti.NewParser(cti.WithAllowAnonymousEntity(true)).Parse(id)
+	if err != nil {
+		return &ParseResult{Error: err.Error()}
+	}
+	res := &ParseResult{Valid: true, Anonymous: expr.HasAnonymousEntity()}
+	if parent := metadata.GetParentCti(id); parent != id {
+		res.Parent = parent
+	}
+	res.Vendor, res.Package = metadata.GetVendorAndPackage(id)
+	return res
+}
+
+// Validator validates entities against the registry.
+type Validator struct {
+	registry  *registry.Registry
+	validator *validator.MetadataValidator
+}
+
+// NewValidator creates a validator of entities against the registry serialized as a JSON array of entities.
+func NewValidator(data []byte) (*Validator, error) {
+	var entities metadata.Entities
+	if len(data) != 0 {
+		if err := json.Unmarshal(data, &entities); err != nil {
+			return nil, fmt.Errorf("decode registry: %w", err)
+		}
+	}
+	m := make(metadata.EntitiesMap, len(entities))
+	for _, entity := range entities {
+		if _, ok := m[entity.Cti]; ok {
+			return nil, fmt.Errorf("duplicate entity %s in registry", entity.Cti)
+		}
+		m[entity.Cti] = entity
+	}
+	r, err := registry.New(m)
+	if err != nil {
+		return nil, fmt.Errorf("index registry: %w", err)
+	}
+	v := validator.MakeMetadataValidator()
+	v.LoadRegistry(r)
+	return &Validator{registry: r, validator: v}, nil
+}
+
+// Validate validates the entity or the array of entities serialized as JSON, e.g. instances received
+// at runtime. Entities are not added to the registry.
+func (v *Validator) Validate(data []byte) (*validator.Report, error) {
+	var entities metadata.Entities
+	if trimmed := bytes.TrimSpace(data); len(trimmed) != 0 && trimmed[0] == '{' {
+		var entity metadata.Entity
+		if err := json.Unmarshal(trimmed, &entity); err != nil {
+			return nil, fmt.Errorf("decode entity: %w", err)
+		}
+		entities = metadata.Entities{&entity}
+	} else if err := json.Unmarshal(data, &entities); err != nil {
+		return nil, fmt.Errorf("decode entities: %w", err)
+	}
+	if len(entities) == 0 {
+		return nil, errors.New("no entities to validate")
+	}
+	var diagnostics []*validator.Error
+	for _, entity := range entities {
+		diagnostics = append(diagnostics, v.validator.Diagnostics(entity)...)
+	}
+	return validator.NewReport(len(entities), diagnostics...), nil
+}
+
+// ValidateAll validates all entities of the registry.
+func (v *Validator) ValidateAll() *validator.Report {
+	_ = v.validator.ValidateAll()
+	return v.validator.Report()
+}
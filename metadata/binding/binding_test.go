@@ -0,0 +1,156 @@
+package binding
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testRegistry = `[
+	{
+		"cti": "cti.x.y.topic.v1.0",
+		"final": false,
+		"schema": {
+			"$schema": "http://json-schema.org/draft-07/schema",
+			"$ref": "#/definitions/Topic",
+			"definitions": {
+				"Topic": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}
+			}
+		},
+		"annotations": {".": {"cti.cti": "cti.x.y.topic.v1.0"}}
+	},
+	{"cti": "cti.x.y.topic.v1.0~x.y.users.v1.0", "final": true, "values": {"name": "users"}}
+]`
+
+func Test_Parse(t *testing.T) {
+	testCases := []struct {
+		id       string
+		expected *ParseResult
+	}{
+		{
+			id:       "cti.x.y.topic.v1.0",
+			expected: &ParseResult{Valid: true, Vendor: "x", Package: "y"},
+		},
+		{
+			id:       "cti.x.y.topic.v1.0~a.b.users.v1.0",
+			expected: &ParseResult{Valid: true, Parent: "cti.x.y.topic.v1.0", Vendor: "a", Package: "b"},
+		},
+		{
+			id: "cti.x.y.topic.v1.0~ba3c448e-55e3-4f7f-ae54-4e87eb8635f6",
+			expected: &ParseResult{
+				Valid: true, Parent: "cti.x.y.topic.v1.0", Vendor: "x", Package: "y", Anonymous: true,
+			},
+		},
+		{
+			id:       "topic",
+			expected: &ParseResult{Error: "not CTI expression"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.id, func(t *testing.T) {
+			require.Equal(t, tc.expected, Parse(tc.id))
+		})
+	}
+}
+
+func Test_Validate(t *testing.T) {
+	v, err := NewValidator([]byte(testRegistry))
+	require.NoError(t, err)
+
+	report := v.ValidateAll()
+	require.True(t, report.Valid)
+	require.Equal(t, 2, report.Summary.Entities)
+
+	report, err = v.Validate([]byte(`{"cti": "cti.x.y.topic.v1.0~x.y.groups.v1.0", "values": {"name": "groups"}}`))
+	require.NoError(t, err)
+	require.True(t, report.Valid)
+
+	report, err = v.Validate([]byte(`[
+		{"cti": "cti.x.y.topic.v1.0~x.y.groups.v1.0", "values": {"name": 1}},
+		{"cti": "cti.x.y.topic.v1.0~x.y.roles.v1.0", "values": {}}
+	]`))
+	require.NoError(t, err)
+	require.False(t, report.Valid)
+	require.Equal(t, 2, report.Summary.Entities)
+	require.Equal(t, 2, report.Summary.Errors)
+	require.Len(t, report.Files, 1)
+	require.Equal(t, "cti.x.y.topic.v1.0~x.y.groups.v1.0", report.Files[0].Diagnostics[0].ID)
+	require.Equal(t, "name", report.Files[0].Diagnostics[0].Path)
+
+	_, err = v.Validate([]byte(`[]`))
+	require.EqualError(t, err, "no entities to validate")
+	_, err = v.Validate([]byte(`{`))
+	require.ErrorContains(t, err, "decode entity")
+}
+
+func Test_NewValidatorInvalid(t *testing.T) {
+	_, err := NewValidator([]byte(`{}`))
+	require.ErrorContains(t, err, "decode registry")
+
+	_, err = NewValidator([]byte(`[{"cti": "cti.x.y.topic.v1.0"}, {"cti": "cti.x.y.topic.v1.0"}]`))
+	require.EqualError(t, err, "duplicate entity cti.x.y.topic.v1.0 in registry")
+}
+
+func Test_Call(t *testing.T) {
+	testCases := []struct {
+		name    string
+		request string
+		check   func(t *testing.T, res Response)
+	}{
+		{
+			name:    "parse",
+			request: `{"method": "parse", "id": "cti.x.y.topic.v1.0"}`,
+			check: func(t *testing.T, res Response) {
+				require.Equal(t, &ParseResult{Valid: true, Vendor: "x", Package: "y"}, res.Parse)
+			},
+		},
+		{
+			name:    "validate registry",
+			request: `{"method": "validate", "registry": ` + testRegistry + `}`,
+			check: func(t *testing.T, res Response) {
+				require.Empty(t, res.Error)
+				require.True(t, res.Report.Valid)
+			},
+		},
+		{
+			name: "validate entities",
+			request: `{"method": "validate", "registry": ` + testRegistry + `,
+				"entities": {"cti": "cti.x.y.topic.v1.0~x.y.groups.v1.0", "values": {}}}`,
+			check: func(t *testing.T, res Response) {
+				require.Empty(t, res.Error)
+				require.False(t, res.Report.Valid)
+				require.Equal(t, map[string]int{"CTI1020": 1}, toMap(res.Report.Summary.Codes))
+			},
+		},
+		{
+			name:    "unknown method",
+			request: `{"method": "format"}`,
+			check: func(t *testing.T, res Response) {
+				require.Equal(t, `unknown method "format"`, res.Error)
+			},
+		},
+		{
+			name:    "invalid request",
+			request: `[]`,
+			check: func(t *testing.T, res Response) {
+				require.Contains(t, res.Error, "decode request")
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var res Response
+			require.NoError(t, json.Unmarshal(Call([]byte(tc.request)), &res))
+			tc.check(t, res)
+		})
+	}
+}
+
+func toMap[K ~string](m map[K]int) map[string]int {
+	res := make(map[string]int, len(m))
+	for k, v := range m {
+		res[string(k)] = v
+	}
+	return res
+}
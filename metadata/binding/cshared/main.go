@@ -0,0 +1,102 @@
+//go:build cgo
+
+// Command cshared is the C shared library build of the parser and the validator for non-Go services:
+//
+//	go build -buildmode=c-shared -o libcti.so ./binding/cshared
+//
+// Functions take and return JSON of the binding API as NUL-terminated UTF-8 strings. Returned strings
+// are allocated by the library and must be released with cti_free. Validators keep the decoded registry
+// between calls and are released with cti_validator_free. Functions are safe for concurrent use,
+// except that a validator must not be used after it is released.
+package main
+
+/*
+#include <stdint.h>
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"encoding/json"
+	"runtime/cgo"
+	"unsafe"
+
+	"github.com/acronis/go-cti/metadata/binding"
+)
+
+func main() {}
+
+// cti_parse parses the identifier and returns the parse result.
+//
+//export cti_parse
+func cti_parse(id *C.char) *C.char {
+	return encode(binding.Parse(C.GoString(id)))
+}
+
+// cti_call processes the request and returns the response.
+//
+//export cti_call
+func cti_call(request *C.char) *C.char {
+	return C.CString(string(binding.Call([]byte(C.GoString(request)))))
+}
+
+// cti_validator_new creates a validator of entities against the registry, a JSON array of entities.
+// It returns 0 and sets the error, that must be released with cti_free, if the registry is invalid.
+//
+//export cti_validator_new
+func cti_validator_new(registry *C.char, errOut **C.char) C.uintptr_t {
+	v, err := binding.NewValidator([]byte(C.GoString(registry)))
+	if err != nil {
+		if errOut != nil {
+			*errOut = C.CString(err.Error())
+		}
+		return 0
+	}
+	return C.uintptr_t(cgo.NewHandle(v))
+}
+
+// cti_validator_validate validates the entity or the array of entities and returns the response
+// with the report. Entities of the registry are validated if entities are NULL.
+//
+//export cti_validator_validate
+func cti_validator_validate(handle C.uintptr_t, entities *C.char) *C.char {
+	if handle == 0 {
+		return encode(binding.Response{Error: "invalid validator"})
+	}
+	v, ok := cgo.Handle(handle).Value().(*binding.Validator)
+	if !ok {
+		return encode(binding.Response{Error: "invalid validator"})
+	}
+	if entities == nil {
+		return encode(binding.Response{Report: v.ValidateAll()})
+	}
+	report, err := v.Validate([]byte(C.GoString(entities)))
+	if err != nil {
+		return encode(binding.Response{Error: err.Error()})
+	}
+	return encode(binding.Response{Report: report})
+}
+
+// cti_validator_free releases the validator.
+//
+//export cti_validator_free
+func cti_validator_free(handle C.uintptr_t) {
+	if handle != 0 {
+		cgo.Handle(handle).Delete()
+	}
+}
+
+// cti_free releases the string returned by the library.
+//
+//export cti_free
+func cti_free(s *C.char) {
+	C.free(unsafe.Pointer(s))
+}
+
+func encode(v any) *C.char {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(binding.Response{Error: err.Error()})
+	}
+	return C.CString(string(data))
+}
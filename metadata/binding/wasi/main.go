@@ -0,0 +1,34 @@
+//go:build wasip1
+
+// Command wasi is the WebAssembly build of the parser and the validator for WASI hosts, e.g. plugin sandboxes.
+// It reads a JSON request of the binding API from stdin and writes the JSON response to stdout:
+//
+//	echo '{"method":"parse","id":"cti.x.y.event.v1.0"}' | wasmtime cti.wasm
+//
+// The exit code is 1 if the request cannot be processed.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/acronis/go-cti/metadata/binding"
+)
+
+func main() {
+	request, err := io.ReadAll(os.Stdin)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "read request: %s\n", err)
+		os.Exit(1)
+	}
+	response := binding.Call(request)
+	if _, err := os.Stdout.Write(append(response, '\n')); err != nil {
+		os.Exit(1)
+	}
+	var res binding.Response
+	if err := json.Unmarshal(response, &res); err != nil || res.Error != "" {
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,49 @@
+// JavaScript binding of the WebAssembly build of the CTI parser and validator.
+//
+// Load wasm_exec.js of the Go distribution used to build cti.wasm before this file, then:
+//
+//   const cti = await loadCti(fetch('cti.wasm'));
+//   cti.parse('cti.x.y.event.v1.0');                      // {valid: true, vendor: 'x', package: 'y'}
+//   const validator = cti.newValidator(registry);          // registry is an array of entities, e.g. .cache.json
+//   validator.validate({cti: '...', values: {...}});       // report of the entity or the array of entities
+//   validator.validateAll();                               // report of entities of the registry
+//   cti.exit();
+//
+// Reports have the same format as reports of cti validate --format json. Errors are thrown.
+
+/**
+ * Instantiates cti.wasm and returns the binding.
+ * @param {Response|Promise<Response>|BufferSource} source - the module, e.g. fetch('cti.wasm').
+ */
+async function loadCti(source) {
+  const go = new Go();
+  const imports = go.importObject;
+  const result = source instanceof ArrayBuffer || ArrayBuffer.isView(source)
+    ? await WebAssembly.instantiate(source, imports)
+    : await WebAssembly.instantiateStreaming(source, imports);
+  go.run(result.instance);
+
+  const raw = globalThis.ctiWasm;
+  const unwrap = (v) => {
+    if (v instanceof Error) {
+      throw v;
+    }
+    return v;
+  };
+  return {
+    parse: (id) => raw.parse(id),
+    call: (request) => JSON.parse(raw.call(typeof request === 'string' ? request : JSON.stringify(request))),
+    newValidator: (registry) => {
+      const v = unwrap(raw.newValidator(registry));
+      return {
+        validate: (entities) => unwrap(v.validate(entities)),
+        validateAll: () => unwrap(v.validateAll()),
+      };
+    },
+    exit: () => raw.exit(),
+  };
+}
+
+if (typeof module !== 'undefined') {
+  module.exports = { loadCti };
+}
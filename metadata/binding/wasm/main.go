@@ -0,0 +1,78 @@
+//go:build js && wasm
+
+// Command wasm is the WebAssembly build of the parser and the validator for browsers and other JavaScript hosts.
+// It registers the global ctiWasm object used by cti.js and keeps running until ctiWasm.exit() is called.
+// Functions return plain JavaScript objects of the same shape as JSON responses of the binding API,
+// or Error values that cti.js throws.
+package main
+
+import (
+	"encoding/json"
+	"syscall/js"
+
+	"github.com/acronis/go-cti/metadata/binding"
+)
+
+func main() {
+	done := make(chan struct{})
+	js.Global().Set("ctiWasm", js.ValueOf(map[string]any{
+		"parse": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			return toJS(binding.Parse(stringArg(args, 0)))
+		}),
+		"call": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			return string(binding.Call([]byte(stringArg(args, 0))))
+		}),
+		"newValidator": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			v, err := binding.NewValidator([]byte(stringArg(args, 0)))
+			if err != nil {
+				return jsError(err)
+			}
+			return newValidator(v)
+		}),
+		"exit": js.FuncOf(func(js.Value, []js.Value) any {
+			close(done)
+			return nil
+		}),
+	}))
+	<-done
+}
+
+func newValidator(v *binding.Validator) js.Value {
+	return js.ValueOf(map[string]any{
+		"validate": js.FuncOf(func(_ js.Value, args []js.Value) any {
+			report, err := v.Validate([]byte(stringArg(args, 0)))
+			if err != nil {
+				return jsError(err)
+			}
+			return toJS(report)
+		}),
+		"validateAll": js.FuncOf(func(js.Value, []js.Value) any {
+			return toJS(v.ValidateAll())
+		}),
+	})
+}
+
+// stringArg returns the argument as a string. Other values are encoded as JSON, so hosts may pass objects.
+func stringArg(args []js.Value, i int) string {
+	if i >= len(args) {
+		return ""
+	}
+	if args[i].Type() == js.TypeString {
+		return args[i].String()
+	}
+	return js.Global().Get("JSON").Call("stringify", args[i]).String()
+}
+
+// toJS converts the value into a JavaScript object through JSON.
+func toJS(v any) any {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return jsError(err)
+	}
+	return js.Global().Get("JSON").Call("parse", string(data))
+}
+
+// jsError returns the error as a JavaScript Error. Go functions cannot throw, so cti.js throws returned errors.
+func jsError(err error) js.Value {
+	return js.Global().Get("Error").New(err.Error())
+}
@@ -0,0 +1,163 @@
+package codegen
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+const (
+	// AsyncAPIVersion is a version of generated AsyncAPI documents.
+	AsyncAPIVersion = "3.0.0"
+
+	asyncAPISchemasKey = "components/schemas"
+)
+
+type AsyncAPIOptions struct {
+	Title   string
+	Version string
+	Format  DocumentFormat
+	// Channels maps CTIs of types to addresses of channels (topics) their messages are sent to. Descendants
+	// of the types are sent to the same channels unless mapped themselves.
+	Channels map[string]string
+}
+
+type asyncAPIDocument struct {
+	AsyncAPI           string                      `json:"asyncapi" yaml:"asyncapi"`
+	Info               openAPIInfo                 `json:"info" yaml:"info"`
+	DefaultContentType string                      `json:"defaultContentType" yaml:"defaultContentType"`
+	Channels           map[string]*asyncAPIChannel `json:"channels" yaml:"channels"`
+	Components         asyncAPIComponents          `json:"components" yaml:"components"`
+}
+
+type asyncAPIChannel struct {
+	Address     string                 `json:"address" yaml:"address"`
+	Title       string                 `json:"title,omitempty" yaml:"title,omitempty"`
+	Description string                 `json:"description,omitempty" yaml:"description,omitempty"`
+	Messages    map[string]asyncAPIRef `json:"messages" yaml:"messages"`
+	Cti         string                 `json:"x-cti" yaml:"x-cti"`
+}
+
+type asyncAPIMessage struct {
+	Name    string      `json:"name" yaml:"name"`
+	Title   string      `json:"title,omitempty" yaml:"title,omitempty"`
+	Summary string      `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Payload asyncAPIRef `json:"payload" yaml:"payload"`
+	Cti     string      `json:"x-cti" yaml:"x-cti"`
+}
+
+type asyncAPIRef struct {
+	Ref string `json:"$ref" yaml:"$ref"`
+}
+
+type asyncAPIComponents struct {
+	Schemas  map[string]any              `json:"schemas" yaml:"schemas"`
+	Messages map[string]*asyncAPIMessage `json:"messages" yaml:"messages"`
+}
+
+// AsyncAPI generates an AsyncAPI document with a channel per mapped type. Messages of channels are
+// the mapped types and their descendants among the types, each type is sent to the channel of the nearest
+// mapped ancestor or of itself. Types that are not mapped are skipped. Payloads are component schemas
+// named as in OpenAPI, channels and messages are named after the types as in TypeScript.
+func (g *Generator) AsyncAPI(entities metadata.Entities, opts AsyncAPIOptions) ([]File, error) {
+	if opts.Format == "" {
+		opts.Format = DocumentFormatYAML
+	}
+	if opts.Title == "" {
+		opts.Title = "CTI events"
+	}
+	if opts.Version == "" {
+		opts.Version = "1.0.0"
+	}
+	if len(opts.Channels) == 0 {
+		return nil, errors.New("no channels: map CTIs of types to channel addresses")
+	}
+
+	mapped := make(metadata.Entities, 0, len(opts.Channels))
+	for _, cti := range sortedKeys(opts.Channels) {
+		typ, ok := g.registry.GetType(cti)
+		if !ok {
+			return nil, fmt.Errorf("channel %s: type %s not found", opts.Channels[cti], cti)
+		}
+		if opts.Channels[cti] == "" {
+			return nil, fmt.Errorf("channel of %s: empty address", cti)
+		}
+		mapped = append(mapped, typ)
+	}
+	channelNames := EntityNames(mapped)
+
+	// Types are sent to channels of the nearest mapped ancestors.
+	channelOf := make(map[string]string)
+	var messages metadata.Entities
+	for _, typ := range sortedTypes(entities) {
+		if _, ok := opts.Channels[typ.Cti]; ok {
+			channelOf[typ.Cti] = typ.Cti
+		} else {
+			for _, ancestor := range g.registry.Ancestors(typ.Cti) {
+				if _, ok := opts.Channels[ancestor.Cti]; ok {
+					channelOf[typ.Cti] = ancestor.Cti
+					break
+				}
+			}
+		}
+		if _, ok := channelOf[typ.Cti]; ok {
+			messages = append(messages, typ)
+		}
+	}
+	messageNames := EntityNames(messages)
+
+	res := asyncAPIDocument{
+		AsyncAPI:           AsyncAPIVersion,
+		Info:               openAPIInfo{Title: opts.Title, Version: opts.Version},
+		DefaultContentType: "application/json",
+		Channels:           make(map[string]*asyncAPIChannel),
+		Components:         asyncAPIComponents{Messages: make(map[string]*asyncAPIMessage)},
+	}
+	doc := &packageDocument{defs: make(map[string]any)}
+	for _, typ := range messages {
+		root, defs, err := g.bundle(typ, false)
+		if err != nil {
+			return nil, err
+		}
+		schemaName := ComponentName(typ.Cti)
+		doc.add(schemaName, root, defs, asyncAPISchemasKey)
+		root[CtiExtension] = typ.Cti
+
+		name := messageNames[typ.Cti]
+		res.Components.Messages[name] = &asyncAPIMessage{
+			Name:    name,
+			Title:   typ.DisplayName,
+			Summary: typ.Description,
+			Payload: asyncAPIRef{Ref: "#/" + asyncAPISchemasKey + "/" + escapePointer(schemaName)},
+			Cti:     typ.Cti,
+		}
+
+		channelCti := channelOf[typ.Cti]
+		channelName := channelNames[channelCti]
+		channel, ok := res.Channels[channelName]
+		if !ok {
+			mappedType, _ := g.registry.GetType(channelCti)
+			channel = &asyncAPIChannel{
+				Address:     opts.Channels[channelCti],
+				Title:       mappedType.DisplayName,
+				Description: mappedType.Description,
+				Messages:    make(map[string]asyncAPIRef),
+				Cti:         channelCti,
+			}
+			res.Channels[channelName] = channel
+		}
+		channel.Messages[name] = asyncAPIRef{Ref: "#/components/messages/" + name}
+	}
+	for _, schema := range doc.defs {
+		// Default schema format of AsyncAPI is a superset of draft-07.
+		translateSchema(schema.(map[string]any), Draft7)
+	}
+	res.Components.Schemas = doc.defs
+
+	data, err := encodeDocument(res, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	return []File{{Name: "asyncapi." + string(opts.Format), Data: data}}, nil
+}
@@ -0,0 +1,109 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+func Test_AsyncAPI(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	testCases := []struct {
+		name     string
+		channels map[string]string
+		expected map[string]any
+	}{
+		{
+			name:     "descendants are sent to channel of ancestor",
+			channels: map[string]string{"cti.x.y.event.v1.0": "events"},
+			expected: map[string]any{
+				"Event": map[string]any{
+					"address": "events",
+					"messages": map[string]any{
+						"Event":       map[string]any{"$ref": "#/components/messages/Event"},
+						"UserCreated": map[string]any{"$ref": "#/components/messages/UserCreated"},
+					},
+					"x-cti": "cti.x.y.event.v1.0",
+				},
+			},
+		},
+		{
+			name: "mapped descendants are sent to own channels",
+			channels: map[string]string{
+				"cti.x.y.event.v1.0":                       "events",
+				"cti.x.y.event.v1.0~x.y.user_created.v1.0": "users.created",
+			},
+			expected: map[string]any{
+				"Event": map[string]any{
+					"address":  "events",
+					"messages": map[string]any{"Event": map[string]any{"$ref": "#/components/messages/Event"}},
+					"x-cti":    "cti.x.y.event.v1.0",
+				},
+				"UserCreated": map[string]any{
+					"address":  "users.created",
+					"title":    "User created",
+					"messages": map[string]any{"UserCreated": map[string]any{"$ref": "#/components/messages/UserCreated"}},
+					"x-cti":    "cti.x.y.event.v1.0~x.y.user_created.v1.0",
+				},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files, err := g.AsyncAPI(r.Entities(), AsyncAPIOptions{Channels: tc.channels})
+			require.NoError(t, err)
+			require.Len(t, files, 1)
+			require.Equal(t, "asyncapi.yaml", files[0].Name)
+
+			var doc map[string]any
+			require.NoError(t, yaml.Unmarshal(files[0].Data, &doc))
+			require.Equal(t, "3.0.0", doc["asyncapi"])
+			require.Equal(t, tc.expected, doc["channels"])
+		})
+	}
+}
+
+func Test_AsyncAPIComponents(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	files, err := g.AsyncAPI(r.Entities(), AsyncAPIOptions{
+		Channels: map[string]string{"cti.x.y.event.v1.0~x.y.user_created.v1.0": "users"},
+	})
+	require.NoError(t, err)
+
+	var doc map[string]any
+	require.NoError(t, yaml.Unmarshal(files[0].Data, &doc))
+	components := doc["components"].(map[string]any)
+	require.Equal(t, map[string]any{
+		"UserCreated": map[string]any{
+			"name":    "UserCreated",
+			"title":   "User created",
+			"payload": map[string]any{"$ref": "#/components/schemas/cti.x.y.event.v1.0-x.y.user_created.v1.0"},
+			"x-cti":   "cti.x.y.event.v1.0~x.y.user_created.v1.0",
+		},
+	}, components["messages"])
+
+	schemas := components["schemas"].(map[string]any)
+	require.Equal(t, []string{"Person", "Tag", "cti.x.y.event.v1.0-x.y.user_created.v1.0"}, sortedKeys(schemas))
+	userCreated := schemas["cti.x.y.event.v1.0-x.y.user_created.v1.0"].(map[string]any)
+	require.Equal(t, map[string]any{"$ref": "#/components/schemas/Person"},
+		userCreated["properties"].(map[string]any)["manager"])
+}
+
+func Test_AsyncAPIInvalidChannels(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	_, err := g.AsyncAPI(r.Entities(), AsyncAPIOptions{})
+	require.EqualError(t, err, "no channels: map CTIs of types to channel addresses")
+
+	_, err = g.AsyncAPI(r.Entities(), AsyncAPIOptions{Channels: map[string]string{"cti.x.y.unknown.v1.0": "events"}})
+	require.EqualError(t, err, "channel events: type cti.x.y.unknown.v1.0 not found")
+
+	_, err = g.AsyncAPI(r.Entities(), AsyncAPIOptions{Channels: map[string]string{"cti.x.y.event.v1.0": ""}})
+	require.EqualError(t, err, "channel of cti.x.y.event.v1.0: empty address")
+}
@@ -0,0 +1,351 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+// AvroFileExt is an extension of generated Avro schemas. Each CTI type is generated into a separate
+// self-contained schema named after its full name, e.g. x.y.UserCreated.avsc.
+const AvroFileExt = ".avsc"
+
+var avroName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+type AvroOptions struct {
+	// Namespace is a prefix of namespaces derived from vendors and packages of the types, e.g. com.example
+	// for com.example.x.y.
+	Namespace string
+}
+
+// Avro generates Avro schemas of the types to register in schema registries. Records are placed into
+// namespaces derived from vendors and packages of the types, e.g. x.y for cti.x.y.event.v1.0. Nested
+// objects and string enums become named records and enums, optional properties are unions with null
+// defaulting to null. String formats use logical types: uuid, date, time-millis and timestamp-millis.
+// Values that cannot be typed in Avro (objects without properties, values of any type) are JSON strings.
+func (g *Generator) Avro(entities metadata.Entities, opts AvroOptions) ([]File, error) {
+	if opts.Namespace != "" {
+		for _, part := range strings.Split(opts.Namespace, ".") {
+			if !avroName.MatchString(part) {
+				return nil, fmt.Errorf("invalid namespace %s", opts.Namespace)
+			}
+		}
+	}
+
+	m, err := g.model(entities)
+	if err != nil {
+		return nil, err
+	}
+
+	var files []File
+	for _, nt := range m.types {
+		if nt.Entity == nil {
+			continue
+		}
+		namespace := avroNamespace(nt.Entity.Cti)
+		if opts.Namespace != "" {
+			namespace = opts.Namespace + "." + namespace
+		}
+		a := &avroGenerator{
+			defined: make(map[*namedType]bool),
+			inlined: make(map[*namedType]bool),
+			used:    make(map[string]bool, len(m.types)),
+		}
+		for _, t := range m.types {
+			a.used[t.Name] = true
+		}
+
+		schema := a.named(nt)
+		if record, ok := schema.(*avroRecord); ok {
+			record.Namespace = namespace
+			record.Cti = nt.Entity.Cti
+		}
+		data, err := json.MarshalIndent(schema, "", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("serialize schema of %s: %w", nt.Entity.Cti, err)
+		}
+		files = append(files, File{Name: namespace + "." + nt.Name + AvroFileExt, Data: append(data, '\n')})
+	}
+	return files, nil
+}
+
+type avroRecord struct {
+	Type      string       `json:"type"`
+	Name      string       `json:"name"`
+	Namespace string       `json:"namespace,omitempty"`
+	Doc       string       `json:"doc,omitempty"`
+	Cti       string       `json:"cti,omitempty"`
+	Fields    []*avroField `json:"fields"`
+}
+
+type avroField struct {
+	Name    string          `json:"name"`
+	Type    any             `json:"type"`
+	Doc     string          `json:"doc,omitempty"`
+	Default json.RawMessage `json:"default,omitempty"`
+}
+
+type avroEnum struct {
+	Type    string   `json:"type"`
+	Name    string   `json:"name"`
+	Doc     string   `json:"doc,omitempty"`
+	Symbols []string `json:"symbols"`
+}
+
+type avroArray struct {
+	Type  string `json:"type"`
+	Items any    `json:"items"`
+}
+
+type avroMap struct {
+	Type   string `json:"type"`
+	Values any    `json:"values"`
+}
+
+type avroLogical struct {
+	Type        string `json:"type"`
+	LogicalType string `json:"logicalType"`
+}
+
+// avroLogicalTypes are logical types of JSON Schema string formats.
+var avroLogicalTypes = map[string]avroLogical{
+	"uuid":      {Type: "string", LogicalType: "uuid"},
+	"date":      {Type: "int", LogicalType: "date"},
+	"time":      {Type: "int", LogicalType: "time-millis"},
+	"date-time": {Type: "long", LogicalType: "timestamp-millis"},
+}
+
+// avroGenerator builds a self-contained schema: named types are defined on first use and referred to
+// by names afterward.
+type avroGenerator struct {
+	defined map[*namedType]bool
+	// inlined holds named types being inlined.
+	inlined map[*namedType]bool
+	// used holds names of the schema, names of nested records and enums are unique among them.
+	used map[string]bool
+}
+
+// named returns the definition of the named type or its name if already defined. Named types that are
+// neither records nor enums are inlined.
+func (a *avroGenerator) named(nt *namedType) any {
+	if a.defined[nt] {
+		return nt.Name
+	}
+	e := nt.Expr
+	doc := nt.Description
+	if doc == nt.Name {
+		doc = ""
+	}
+	switch {
+	case e.Kind == kindObject && len(e.Fields) > 0:
+		// Records are defined before their fields, so they may refer to themselves.
+		a.defined[nt] = true
+		return a.record(nt.Name, doc, e)
+	case e.Kind == kindEnum && avroSymbols(e) != nil:
+		a.defined[nt] = true
+		return &avroEnum{Type: "enum", Name: nt.Name, Doc: doc, Symbols: avroSymbols(e)}
+	case a.inlined[nt]:
+		// Inlined types cannot refer to themselves.
+		return "string"
+	default:
+		a.inlined[nt] = true
+		defer delete(a.inlined, nt)
+		return a.avroType(e, nt.Name)
+	}
+}
+
+func (a *avroGenerator) record(name, doc string, e *typeExpr) *avroRecord {
+	r := &avroRecord{Type: "record", Name: name, Doc: doc, Fields: []*avroField{}}
+	names := make(map[string]bool, len(e.Fields))
+	for _, f := range e.Fields {
+		typ := a.avroType(f.Expr, name+exportedName(f.Name))
+		af := &avroField{Name: uniqueName(avroFieldName(f.Name), names), Doc: f.Description}
+		def, hasDefault := avroDefault(typ, f.Expr.Schema["default"])
+		switch {
+		case f.Required:
+			af.Type = typ
+		case hasDefault:
+			// Defaults of unions must match their first members.
+			af.Type = avroUnion(typ, "null")
+		default:
+			af.Type = avroUnion("null", typ)
+			def, hasDefault = json.RawMessage("null"), true
+		}
+		if hasDefault {
+			af.Default = def
+		}
+		r.Fields = append(r.Fields, af)
+	}
+	return r
+}
+
+func (a *avroGenerator) avroType(e *typeExpr, hint string) any {
+	switch e.Kind {
+	case kindString:
+		if logical, ok := avroLogicalTypes[e.format()]; ok {
+			return logical
+		}
+		return "string"
+	case kindInteger:
+		if e.format() == "int32" {
+			return "int"
+		}
+		return "long"
+	case kindNumber:
+		if e.format() == "float" {
+			return "float"
+		}
+		return "double"
+	case kindBoolean:
+		return "boolean"
+	case kindNull:
+		return "null"
+	case kindRef:
+		return a.named(e.Ref)
+	case kindArray:
+		return &avroArray{Type: "array", Items: a.avroType(e.Items, hint+"Item")}
+	case kindMap:
+		return &avroMap{Type: "map", Values: a.avroType(e.Values, hint+"Value")}
+	case kindObject:
+		if len(e.Fields) > 0 {
+			return a.record(uniqueName(hint, a.used), "", e)
+		}
+	case kindEnum:
+		if symbols := avroSymbols(e); symbols != nil {
+			return &avroEnum{Type: "enum", Name: uniqueName(hint, a.used), Symbols: symbols}
+		}
+		return a.avroType(&typeExpr{Kind: e.Base, Schema: e.Schema}, hint)
+	case kindUnion:
+		members := make([]any, 0, len(e.Members))
+		for _, m := range e.Members {
+			members = append(members, a.avroType(m, hint))
+		}
+		return avroUnion(members...)
+	}
+	return "string"
+}
+
+// avroUnion returns the union of the types. Nested unions are flattened, since Avro does not allow them,
+// as well as members of the same type.
+func avroUnion(types ...any) any {
+	var res []any
+	seen := make(map[string]bool)
+	for _, t := range types {
+		members, ok := t.([]any)
+		if !ok {
+			members = []any{t}
+		}
+		for _, m := range members {
+			if key := avroUnionKey(m); !seen[key] {
+				seen[key] = true
+				res = append(res, m)
+			}
+		}
+	}
+	if len(res) == 1 {
+		return res[0]
+	}
+	return res
+}
+
+// avroUnionKey identifies the type among members of a union: named types by names, others by types.
+func avroUnionKey(t any) string {
+	switch t := t.(type) {
+	case string:
+		return t
+	case *avroRecord:
+		return t.Name
+	case *avroEnum:
+		return t.Name
+	case *avroArray:
+		return t.Type
+	case *avroMap:
+		return t.Type
+	case avroLogical:
+		return t.Type
+	default:
+		return fmt.Sprint(t)
+	}
+}
+
+// avroDefault returns the default value if it is valid for the type. Only defaults of primitive types
+// and enums are kept.
+func avroDefault(t, def any) (json.RawMessage, bool) {
+	if def == nil {
+		return nil, false
+	}
+	valid := false
+	switch t := t.(type) {
+	case string:
+		switch def.(type) {
+		case string:
+			valid = t == "string"
+		case float64:
+			valid = t == "int" || t == "long" || t == "float" || t == "double"
+		case bool:
+			valid = t == "boolean"
+		}
+	case *avroEnum:
+		for _, symbol := range t.Symbols {
+			valid = valid || def == symbol
+		}
+	}
+	if !valid {
+		return nil, false
+	}
+	return json.RawMessage(literal(def)), true
+}
+
+// avroSymbols returns symbols of the string enum or nil if values are not valid Avro symbols.
+func avroSymbols(e *typeExpr) []string {
+	if e.Base != kindString {
+		return nil
+	}
+	symbols := make([]string, 0, len(e.Enum))
+	for _, v := range e.Enum {
+		s, ok := v.(string)
+		if !ok || !avroName.MatchString(s) {
+			return nil
+		}
+		symbols = append(symbols, s)
+	}
+	return symbols
+}
+
+// avroNamespace returns the namespace of the type derived from the vendor and the package of its entity
+// name, e.g. x.y for cti.x.y.event.v1.0~x.y.user_created.v1.0, or cti if the name has no vendor.
+func avroNamespace(cti string) string {
+	vendor, pkg := metadata.GetVendorAndPackage(cti)
+	if vendor == "" {
+		return "cti"
+	}
+	return vendor + "." + pkg
+}
+
+// avroFieldName replaces characters that are not allowed in Avro names with underscores.
+func avroFieldName(name string) string {
+	if avroName.MatchString(name) {
+		return name
+	}
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_' || r < 128 && (r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z'):
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	if b.Len() == 0 {
+		return "_"
+	}
+	return b.String()
+}
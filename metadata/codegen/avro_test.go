@@ -0,0 +1,133 @@
+package codegen
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Avro(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	files, err := g.Avro(r.Entities(), AvroOptions{Namespace: "com.example"})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "com.example.x.y.Event.avsc", files[0].Name)
+	require.Equal(t, "com.example.x.y.UserCreated.avsc", files[1].Name)
+	require.JSONEq(t, `{
+		"type": "record",
+		"name": "UserCreated",
+		"namespace": "com.example.x.y",
+		"doc": "User created",
+		"cti": "cti.x.y.event.v1.0~x.y.user_created.v1.0",
+		"fields": [
+			{"name": "id", "type": {"type": "string", "logicalType": "uuid"}},
+			{"name": "login", "type": "string"},
+			{"name": "manager", "type": ["null", {
+				"type": "record",
+				"name": "Person",
+				"fields": [
+					{"name": "name", "type": ["null", "string"], "default": null},
+					{"name": "reports", "type": ["null", {"type": "array", "items": "Person"}], "default": null}
+				]
+			}], "default": null},
+			{"name": "severity", "type": ["null", {"type": "enum", "name": "UserCreatedSeverity", "symbols": ["low", "high"]}], "default": null},
+			{"name": "tags", "type": ["null", {"type": "array", "items": {
+				"type": "record",
+				"name": "Tag",
+				"fields": [{"name": "name", "type": "string"}]
+			}}], "default": null}
+		]
+	}`, string(files[1].Data))
+
+	_, err = g.Avro(r.Entities(), AvroOptions{Namespace: "com.1example"})
+	require.EqualError(t, err, "invalid namespace com.1example")
+}
+
+func Test_AvroType(t *testing.T) {
+	testCases := []struct {
+		name   string
+		schema map[string]any
+		avro   string
+	}{
+		{
+			name:   "date-time",
+			schema: map[string]any{"type": "string", "format": "date-time"},
+			avro:   `{"type": "long", "logicalType": "timestamp-millis"}`,
+		},
+		{
+			name:   "date",
+			schema: map[string]any{"type": "string", "format": "date"},
+			avro:   `{"type": "int", "logicalType": "date"}`,
+		},
+		{
+			name:   "int32",
+			schema: map[string]any{"type": "integer", "format": "int32"},
+			avro:   `"int"`,
+		},
+		{
+			name:   "nullable number",
+			schema: map[string]any{"type": []any{"number", "null"}},
+			avro:   `["double", "null"]`,
+		},
+		{
+			name: "nested unions are flattened",
+			schema: map[string]any{"anyOf": []any{
+				map[string]any{"type": "string"},
+				map[string]any{"type": []any{"string", "null"}},
+			}},
+			avro: `["string", "null"]`,
+		},
+		{
+			name:   "enum with invalid symbols",
+			schema: map[string]any{"type": "string", "enum": []any{"in-progress", "done"}},
+			avro:   `"string"`,
+		},
+		{
+			name:   "map",
+			schema: map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "boolean"}},
+			avro:   `{"type": "map", "values": "boolean"}`,
+		},
+		{
+			name:   "object without properties",
+			schema: map[string]any{"type": "object"},
+			avro:   `"string"`,
+		},
+		{
+			name: "object with defaults",
+			schema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"level": map[string]any{"type": "string", "enum": []any{"low", "high"}, "default": "high"},
+					"count": map[string]any{"type": "integer", "default": 1.0},
+					"2fa":   map[string]any{"type": "boolean"},
+				},
+				"required": []any{"count"},
+			},
+			avro: `{"type": "record", "name": "Hint", "fields": [
+				{"name": "_2fa", "type": ["null", "boolean"], "default": null},
+				{"name": "count", "type": "long", "default": 1},
+				{"name": "level", "type": [{"type": "enum", "name": "HintLevel", "symbols": ["low", "high"]}, "null"], "default": "high"}
+			]}`,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			a := &avroGenerator{defined: make(map[*namedType]bool), inlined: make(map[*namedType]bool), used: make(map[string]bool)}
+			data, err := json.Marshal(a.avroType((&modelBuilder{}).expr(tc.schema), "Hint"))
+			require.NoError(t, err)
+			require.JSONEq(t, tc.avro, string(data))
+		})
+	}
+}
+
+func Test_AvroNames(t *testing.T) {
+	require.Equal(t, "x.y", avroNamespace("cti.x.y.event.v1.0"))
+	require.Equal(t, "a.b", avroNamespace("cti.x.y.event.v1.0~a.b.user_created.v1.0"))
+	require.Equal(t, "cti", avroNamespace("cti.event"))
+	require.Equal(t, "created_at", avroFieldName("created_at"))
+	require.Equal(t, "x_request_id", avroFieldName("x-request-id"))
+	require.Equal(t, "_", avroFieldName(""))
+}
@@ -0,0 +1,204 @@
+// Package codegen generates schemas and code of other languages from CTI types.
+//
+// Generators work on resolved types: the schema of the type merged with schemas of its ancestors
+// along with named definitions the merged schema refers to.
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+	"github.com/acronis/go-cti/metadata/validator"
+)
+
+// definitionsRef is a prefix of local references to named definitions of CTI type schemas.
+const definitionsRef = "#/definitions/"
+
+// File is a generated file. Name is relative to the output directory.
+type File struct {
+	Name string
+	Data []byte
+}
+
+type Generator struct {
+	registry  *registry.Registry
+	validator *validator.MetadataValidator
+}
+
+// New creates a generator. The registry is used to resolve ancestors of generated types and may contain
+// entities that are not generated, e.g. of dependencies.
+func New(r *registry.Registry) *Generator {
+	v := validator.MakeMetadataValidator()
+	v.LoadRegistry(r)
+	return &Generator{registry: r, validator: v}
+}
+
+// resolvedType is a CTI type with the merged schema and named definitions the schema refers to.
+type resolvedType struct {
+	entity *metadata.Entity
+	schema map[string]any
+	// definitions holds definitions referenced by the schema or by other definitions, directly or indirectly.
+	definitions map[string]map[string]any
+}
+
+// resolve merges the schema of the type with its ancestors and collects referenced definitions.
+func (g *Generator) resolve(typ *metadata.Entity) (*resolvedType, error) {
+	schema, err := g.validator.GetMergedSchema(typ.Cti)
+	if err != nil {
+		return nil, fmt.Errorf("merge schema of %s: %w", typ.Cti, err)
+	}
+
+	available := make(map[string]map[string]any)
+	for _, entity := range append(metadata.Entities{typ}, g.registry.Ancestors(typ.Cti)...) {
+		var own map[string]any
+		if err := json.Unmarshal(entity.Schema, &own); err != nil {
+			return nil, fmt.Errorf("decode schema of %s: %w", entity.Cti, err)
+		}
+		defs, _ := own["definitions"].(map[string]any)
+		for name, def := range defs {
+			if _, ok := available[name]; ok {
+				continue
+			}
+			if m, ok := def.(map[string]any); ok {
+				available[name] = m
+			}
+		}
+	}
+
+	res := &resolvedType{entity: typ, schema: schema, definitions: make(map[string]map[string]any)}
+	pending := []map[string]any{schema}
+	for len(pending) > 0 {
+		current := pending[0]
+		pending = pending[1:]
+		walkSchema(current, func(s map[string]any) {
+			name, ok := definitionName(s)
+			if !ok {
+				return
+			}
+			if _, ok := res.definitions[name]; ok {
+				return
+			}
+			if def, ok := available[name]; ok {
+				res.definitions[name] = def
+				pending = append(pending, def)
+			}
+		})
+	}
+	return res, nil
+}
+
+// sortedTypes returns types of the entities sorted by CTI. Instances are skipped.
+func sortedTypes(entities metadata.Entities) metadata.Entities {
+	var res metadata.Entities
+	for _, entity := range entities {
+		if !entity.IsInstance() {
+			res = append(res, entity)
+		}
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].Cti < res[b].Cti
+	})
+	return res
+}
+
+// definitionName returns the name of the definition the schema refers to.
+func definitionName(schema map[string]any) (string, bool) {
+	ref, ok := schema["$ref"].(string)
+	if !ok || !strings.HasPrefix(ref, definitionsRef) {
+		return "", false
+	}
+	return strings.TrimPrefix(ref, definitionsRef), true
+}
+
+// schemaMapKeywords hold maps of named subschemas.
+var schemaMapKeywords = []string{"properties", "patternProperties", "definitions", "$defs", "dependencies", "dependentSchemas"}
+
+// schemaListKeywords hold lists of subschemas.
+var schemaListKeywords = []string{"anyOf", "oneOf", "allOf", "prefixItems"}
+
+// schemaKeywords hold a single subschema.
+var schemaKeywords = []string{
+	"additionalProperties", "additionalItems", "unevaluatedProperties", "unevaluatedItems", "not", "propertyNames",
+	"contains", "if", "then", "else",
+}
+
+// walkSchema calls the function for the schema and all of its subschemas, parents first.
+func walkSchema(schema map[string]any, fn func(map[string]any)) {
+	fn(schema)
+	for _, key := range schemaMapKeywords {
+		if m, ok := schema[key].(map[string]any); ok {
+			for _, name := range sortedKeys(m) {
+				if sub, ok := m[name].(map[string]any); ok {
+					walkSchema(sub, fn)
+				}
+			}
+		}
+	}
+	for _, key := range schemaListKeywords {
+		if list, ok := schema[key].([]any); ok {
+			for _, item := range list {
+				if sub, ok := item.(map[string]any); ok {
+					walkSchema(sub, fn)
+				}
+			}
+		}
+	}
+	for _, key := range schemaKeywords {
+		if sub, ok := schema[key].(map[string]any); ok {
+			walkSchema(sub, fn)
+		}
+	}
+	switch items := schema["items"].(type) {
+	case map[string]any:
+		walkSchema(items, fn)
+	case []any:
+		for _, item := range items {
+			if sub, ok := item.(map[string]any); ok {
+				walkSchema(sub, fn)
+			}
+		}
+	}
+}
+
+// clone returns a deep copy of the decoded JSON value.
+func clone(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for k, item := range v {
+			res[k] = clone(item)
+		}
+		return res
+	case []any:
+		res := make([]any, len(v))
+		for i, item := range v {
+			res[i] = clone(item)
+		}
+		return res
+	case []string:
+		res := make([]any, len(v))
+		for i, item := range v {
+			res[i] = item
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+func cloneSchema(schema map[string]any) map[string]any {
+	return clone(schema).(map[string]any)
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,90 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func testRegistry(t *testing.T) *registry.Registry {
+	t.Helper()
+
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.x.y.event.v1.0": {
+			Cti: "cti.x.y.event.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Event",
+				"definitions": {
+					"Event": {
+						"type": "object",
+						"description": "Base event.",
+						"properties": {
+							"id": {"type": "string", "format": "uuid", "x-custom": {"x-domainExt-cti.id": true}},
+							"severity": {"type": "string", "enum": ["low", "high"]},
+							"tags": {"type": "array", "items": {"$ref": "#/definitions/Tag"}}
+						},
+						"required": ["id"],
+						"x-custom": {"x-domainExt-cti.cti": "cti.x.y.event.v1.0"}
+					},
+					"Tag": {
+						"type": "object",
+						"properties": {"name": {"type": "string", "minLength": 1}},
+						"required": ["name"]
+					}
+				}
+			}`),
+			Annotations: map[metadata.GJsonPath]metadata.Annotations{
+				".": {Cti: "cti.x.y.event.v1.0"},
+			},
+		},
+		"cti.x.y.event.v1.0~x.y.user_created.v1.0": {
+			Cti:         "cti.x.y.event.v1.0~x.y.user_created.v1.0",
+			DisplayName: "User created",
+			Final:       true,
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/UserCreated",
+				"definitions": {
+					"UserCreated": {
+						"type": "object",
+						"properties": {
+							"login": {"type": "string"},
+							"manager": {"$ref": "#/definitions/Person"}
+						},
+						"required": ["login"]
+					},
+					"Person": {
+						"type": "object",
+						"properties": {
+							"name": {"type": "string"},
+							"reports": {"type": "array", "items": {"$ref": "#/definitions/Person"}}
+						}
+					}
+				}
+			}`),
+		},
+		"cti.x.y.event.v1.0~x.y.user_created.v1.0~x.y.sample.v1.0": {
+			Cti:    "cti.x.y.event.v1.0~x.y.user_created.v1.0~x.y.sample.v1.0",
+			Final:  true,
+			Values: []byte(`{"login": "admin"}`),
+		},
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func Test_Resolve(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	typ, _ := r.GetType("cti.x.y.event.v1.0~x.y.user_created.v1.0")
+	rt, err := g.resolve(typ)
+	require.NoError(t, err)
+	require.Contains(t, rt.schema["properties"], "id")
+	require.Contains(t, rt.schema["properties"], "login")
+	require.ElementsMatch(t, []string{"Person", "Tag"}, sortedKeys(rt.definitions))
+}
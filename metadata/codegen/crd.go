@@ -0,0 +1,345 @@
+package codegen
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/acronis/go-cti"
+	"github.com/acronis/go-cti/metadata"
+)
+
+const (
+	CRDFileExt = ".yaml"
+
+	crdHeader = "# Code generated by cti gen crd. DO NOT EDIT.\n"
+
+	// CRDScopeNamespaced and CRDScopeCluster are scopes of custom resources.
+	CRDScopeNamespaced = "Namespaced"
+	CRDScopeCluster    = "Cluster"
+
+	// preserveUnknownFields marks nodes of structural schemas that accept any values.
+	preserveUnknownFields = "x-kubernetes-preserve-unknown-fields"
+)
+
+var crdGroup = regexp.MustCompile(`^[a-z0-9]([-a-z0-9]*[a-z0-9])?(\.[a-z0-9]([-a-z0-9]*[a-z0-9])?)+$`)
+
+type CRDOptions struct {
+	// Group is an API group of all resources. If empty, groups are derived from packages and vendors
+	// of the types, e.g. y.x for cti.x.y.event.v1.0.
+	Group string
+	// Scope is either Namespaced (default) or Cluster.
+	Scope string
+}
+
+type crdManifest struct {
+	APIVersion string      `yaml:"apiVersion"`
+	Kind       string      `yaml:"kind"`
+	Metadata   crdMetadata `yaml:"metadata"`
+	Spec       crdSpec     `yaml:"spec"`
+}
+
+type crdMetadata struct {
+	Name        string            `yaml:"name"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+}
+
+type crdSpec struct {
+	Group    string       `yaml:"group"`
+	Names    crdNames     `yaml:"names"`
+	Scope    string       `yaml:"scope"`
+	Versions []crdVersion `yaml:"versions"`
+}
+
+type crdNames struct {
+	Kind     string `yaml:"kind"`
+	ListKind string `yaml:"listKind"`
+	Plural   string `yaml:"plural"`
+	Singular string `yaml:"singular"`
+}
+
+type crdVersion struct {
+	Name    string        `yaml:"name"`
+	Served  bool          `yaml:"served"`
+	Storage bool          `yaml:"storage"`
+	Schema  crdValidation `yaml:"schema"`
+}
+
+type crdValidation struct {
+	OpenAPIV3Schema map[string]any `yaml:"openAPIV3Schema"`
+}
+
+// crdResource collects versions of a type with the same group and kind.
+type crdResource struct {
+	group, kind string
+	// lineage is the CTI of the type without versions, types of different lineages cannot share kinds.
+	lineage string
+	// cti is the CTI of the first version of the type.
+	cti string
+	// versions holds the latest minor versions of the type by major versions.
+	versions map[uint]*crdTypeVersion
+}
+
+type crdTypeVersion struct {
+	minor uint
+	nt    *namedType
+}
+
+// CRD generates CustomResourceDefinition manifests of the types with structural schemas of the types
+// in spec of the resources. Kinds are derived from entity names of the types, e.g. UserCreated
+// for cti.x.y.event.v1.0~x.y.user_created.v1.0, and versions from major versions, e.g. v1. The latest
+// minor version of each major version is served, the latest major version is stored. References are
+// inlined since structural schemas cannot contain them, recursive references and values that cannot be
+// typed preserve unknown fields.
+func (g *Generator) CRD(entities metadata.Entities, opts CRDOptions) ([]File, error) {
+	if opts.Group != "" && !crdGroup.MatchString(opts.Group) {
+		return nil, fmt.Errorf("invalid group %s: must be a DNS subdomain with at least one dot", opts.Group)
+	}
+	switch opts.Scope {
+	case "":
+		opts.Scope = CRDScopeNamespaced
+	case CRDScopeNamespaced, CRDScopeCluster:
+	default:
+		return nil, fmt.Errorf("invalid scope %s: must be %s or %s", opts.Scope, CRDScopeNamespaced, CRDScopeCluster)
+	}
+
+	m, err := g.model(entities)
+	if err != nil {
+		return nil, err
+	}
+
+	p := cti.NewParser()
+	resources := make(map[string]*crdResource)
+	for _, nt := range m.types {
+		if nt.Entity == nil {
+			continue
+		}
+		expr, err := p.Parse(nt.Entity.Cti)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", nt.Entity.Cti, err)
+		}
+		tail := expr.Tail()
+		group := opts.Group
+		if group == "" {
+			group = strings.ReplaceAll(string(tail.Package)+"."+string(tail.Vendor), "_", "-")
+		}
+		kind := exportedName(string(tail.EntityName))
+		lineage := ctiLineage(expr)
+
+		key := group + "/" + kind
+		r, ok := resources[key]
+		if !ok {
+			r = &crdResource{group: group, kind: kind, lineage: lineage, cti: nt.Entity.Cti, versions: make(map[uint]*crdTypeVersion)}
+			resources[key] = r
+		}
+		if r.lineage != lineage {
+			return nil, fmt.Errorf("types %s and %s have the same kind %s in group %s", r.cti, nt.Entity.Cti, kind, group)
+		}
+		major, minor := tail.Version.Major.Value, tail.Version.Minor.Value
+		if v, ok := r.versions[major]; !ok || v.minor < minor {
+			r.versions[major] = &crdTypeVersion{minor: minor, nt: nt}
+		}
+	}
+
+	var files []File
+	for _, key := range sortedKeys(resources) {
+		r := resources[key]
+		plural := crdPlural(strings.ToLower(r.kind))
+		manifest := crdManifest{
+			APIVersion: "apiextensions.k8s.io/v1",
+			Kind:       "CustomResourceDefinition",
+			Metadata:   crdMetadata{Name: plural + "." + r.group, Annotations: make(map[string]string)},
+			Spec: crdSpec{
+				Group: r.group,
+				Names: crdNames{
+					Kind:     r.kind,
+					ListKind: r.kind + "List",
+					Plural:   plural,
+					Singular: strings.ToLower(r.kind),
+				},
+				Scope: opts.Scope,
+			},
+		}
+
+		majors := make([]uint, 0, len(r.versions))
+		for major := range r.versions {
+			majors = append(majors, major)
+		}
+		sort.Slice(majors, func(a, b int) bool {
+			return majors[a] < majors[b]
+		})
+		for i, major := range majors {
+			nt := r.versions[major].nt
+			name := "v" + strconv.FormatUint(uint64(major), 10)
+			manifest.Metadata.Annotations["cti/"+name] = nt.Entity.Cti
+
+			root := map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"apiVersion": map[string]any{"type": "string"},
+					"kind":       map[string]any{"type": "string"},
+					"metadata":   map[string]any{"type": "object"},
+					"spec":       structuralSchema(nt.Expr, nil),
+				},
+				"required": []string{"spec"},
+			}
+			if nt.Description != "" {
+				root["description"] = nt.Description
+			}
+			manifest.Spec.Versions = append(manifest.Spec.Versions, crdVersion{
+				Name:    name,
+				Served:  true,
+				Storage: i == len(majors)-1,
+				Schema:  crdValidation{OpenAPIV3Schema: root},
+			})
+		}
+
+		var buf bytes.Buffer
+		buf.WriteString(crdHeader)
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(manifest); err != nil {
+			return nil, fmt.Errorf("serialize %s: %w", manifest.Metadata.Name, err)
+		}
+		if err := enc.Close(); err != nil {
+			return nil, fmt.Errorf("serialize %s: %w", manifest.Metadata.Name, err)
+		}
+		files = append(files, File{Name: r.group + "_" + plural + CRDFileExt, Data: buf.Bytes()})
+	}
+	return files, nil
+}
+
+// structuralSchema returns the structural schema of the expression: every node has a type, references are
+// inlined and only keywords supported by Kubernetes are kept. Named types on the stack are recursive.
+func structuralSchema(e *typeExpr, stack map[*namedType]bool) map[string]any {
+	var res map[string]any
+	switch e.Kind {
+	case kindString, kindInteger, kindNumber, kindBoolean:
+		res = map[string]any{"type": crdTypes[e.Kind]}
+		for _, key := range []string{"format", "pattern", "minLength", "maxLength", "multipleOf", "default"} {
+			if v, ok := e.Schema[key]; ok {
+				res[key] = v
+			}
+		}
+		crdBounds(e.Schema, res)
+	case kindRef:
+		if stack[e.Ref] {
+			return map[string]any{"type": "object", preserveUnknownFields: true}
+		}
+		nested := make(map[*namedType]bool, len(stack)+1)
+		for nt := range stack {
+			nested[nt] = true
+		}
+		nested[e.Ref] = true
+		res = structuralSchema(e.Ref.Expr, nested)
+	case kindArray:
+		res = map[string]any{"type": "array", "items": structuralSchema(e.Items, stack)}
+		for _, key := range []string{"minItems", "maxItems"} {
+			if v, ok := e.Schema[key]; ok {
+				res[key] = v
+			}
+		}
+		// Unique items are supported only for lists of scalars, which are sets.
+		if unique, _ := e.Schema["uniqueItems"].(bool); unique && crdTypes[e.Items.Kind] != "" {
+			res["x-kubernetes-list-type"] = "set"
+		}
+	case kindMap:
+		res = map[string]any{"type": "object", "additionalProperties": structuralSchema(e.Values, stack)}
+	case kindObject:
+		res = map[string]any{"type": "object"}
+		if len(e.Fields) == 0 || e.Values != nil {
+			res[preserveUnknownFields] = true
+		}
+		if len(e.Fields) > 0 {
+			props := make(map[string]any, len(e.Fields))
+			var required []string
+			for _, f := range e.Fields {
+				prop := structuralSchema(f.Expr, stack)
+				if f.Description != "" {
+					prop["description"] = f.Description
+				}
+				props[f.Name] = prop
+				if f.Required {
+					required = append(required, f.Name)
+				}
+			}
+			res["properties"] = props
+			if len(required) > 0 {
+				res["required"] = required
+			}
+		}
+	case kindEnum:
+		typ, ok := crdTypes[e.Base]
+		if !ok {
+			return map[string]any{preserveUnknownFields: true}
+		}
+		res = map[string]any{"type": typ, "enum": e.Enum}
+		if v, ok := e.Schema["default"]; ok {
+			res["default"] = v
+		}
+	case kindUnion:
+		if base, nullable := e.nullable(); nullable && base.Kind != kindUnion {
+			res = structuralSchema(base, stack)
+			res["nullable"] = true
+			return res
+		}
+		if crdIntOrString(e) {
+			return map[string]any{"x-kubernetes-int-or-string": true}
+		}
+		return map[string]any{preserveUnknownFields: true}
+	default:
+		return map[string]any{preserveUnknownFields: true}
+	}
+	return res
+}
+
+var crdTypes = map[kind]string{
+	kindString:  "string",
+	kindInteger: "integer",
+	kindNumber:  "number",
+	kindBoolean: "boolean",
+}
+
+// crdBounds converts numeric bounds into OpenAPI v3.0 bounds, where exclusive bounds are flags.
+func crdBounds(schema, res map[string]any) {
+	for _, bound := range []struct{ key, exclusive string }{{"minimum", "exclusiveMinimum"}, {"maximum", "exclusiveMaximum"}} {
+		if v, ok := schema[bound.key]; ok {
+			res[bound.key] = v
+		}
+		switch v := schema[bound.exclusive].(type) {
+		case bool:
+			res[bound.exclusive] = v
+		case nil:
+		default:
+			res[bound.key] = v
+			res[bound.exclusive] = true
+		}
+	}
+}
+
+// crdIntOrString tells whether the union is of integers and strings only.
+func crdIntOrString(e *typeExpr) bool {
+	kinds := make(map[kind]bool)
+	for _, m := range e.Members {
+		kinds[m.Kind] = true
+	}
+	return len(kinds) == 2 && kinds[kindInteger] && kinds[kindString]
+}
+
+// crdPlural returns the plural of the lowercase kind, e.g. policies for policy.
+func crdPlural(kind string) string {
+	switch {
+	case strings.HasSuffix(kind, "s"), strings.HasSuffix(kind, "x"), strings.HasSuffix(kind, "z"),
+		strings.HasSuffix(kind, "ch"), strings.HasSuffix(kind, "sh"):
+		return kind + "es"
+	case strings.HasSuffix(kind, "y") && len(kind) > 1 && !strings.ContainsRune("aeiou", rune(kind[len(kind)-2])):
+		return kind[:len(kind)-1] + "ies"
+	default:
+		return kind + "s"
+	}
+}
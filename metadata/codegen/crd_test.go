@@ -0,0 +1,198 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func Test_CRD(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	files, err := g.CRD(r.Entities(), CRDOptions{Group: "events.example.com", Scope: CRDScopeCluster})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "events.example.com_events.yaml", files[0].Name)
+	require.Equal(t, "events.example.com_usercreateds.yaml", files[1].Name)
+
+	var manifest map[string]any
+	require.NoError(t, yaml.Unmarshal(files[1].Data, &manifest))
+	require.Equal(t, map[string]any{
+		"name":        "usercreateds.events.example.com",
+		"annotations": map[string]any{"cti/v1": "cti.x.y.event.v1.0~x.y.user_created.v1.0"},
+	}, manifest["metadata"])
+	spec := manifest["spec"].(map[string]any)
+	require.Equal(t, "Cluster", spec["scope"])
+	require.Equal(t, map[string]any{
+		"kind":     "UserCreated",
+		"listKind": "UserCreatedList",
+		"plural":   "usercreateds",
+		"singular": "usercreated",
+	}, spec["names"])
+
+	version := spec["versions"].([]any)[0].(map[string]any)
+	require.Equal(t, "v1", version["name"])
+	require.Equal(t, true, version["storage"])
+	root := version["schema"].(map[string]any)["openAPIV3Schema"].(map[string]any)
+	require.Equal(t, "User created", root["description"])
+	// Recursive references preserve unknown fields.
+	require.Equal(t, map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"name": map[string]any{"type": "string"},
+			"reports": map[string]any{
+				"type":  "array",
+				"items": map[string]any{"type": "object", "x-kubernetes-preserve-unknown-fields": true},
+			},
+		},
+	}, root["properties"].(map[string]any)["spec"].(map[string]any)["properties"].(map[string]any)["manager"])
+
+	_, err = g.CRD(r.Entities(), CRDOptions{Group: "events"})
+	require.EqualError(t, err, "invalid group events: must be a DNS subdomain with at least one dot")
+	_, err = g.CRD(r.Entities(), CRDOptions{Scope: "Global"})
+	require.EqualError(t, err, "invalid scope Global: must be Namespaced or Cluster")
+}
+
+func Test_CRDVersions(t *testing.T) {
+	typ := func(cti, prop string) *metadata.Entity {
+		return &metadata.Entity{
+			Cti:    cti,
+			Schema: []byte(`{"$ref": "#/definitions/Policy", "definitions": {"Policy": {"type": "object", "properties": {"` + prop + `": {"type": "string"}}}}}`),
+		}
+	}
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.a.my_pkg.policy.v1.0": typ("cti.a.my_pkg.policy.v1.0", "v10"),
+		"cti.a.my_pkg.policy.v1.1": typ("cti.a.my_pkg.policy.v1.1", "v11"),
+		"cti.a.my_pkg.policy.v2.0": typ("cti.a.my_pkg.policy.v2.0", "v20"),
+	})
+	require.NoError(t, err)
+
+	files, err := New(r).CRD(r.Entities(), CRDOptions{})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "my-pkg.a_policies.yaml", files[0].Name)
+
+	var manifest struct {
+		Metadata struct {
+			Annotations map[string]string `yaml:"annotations"`
+		} `yaml:"metadata"`
+		Spec struct {
+			Versions []struct {
+				Name    string `yaml:"name"`
+				Storage bool   `yaml:"storage"`
+				Schema  struct {
+					OpenAPIV3Schema struct {
+						Properties struct {
+							Spec struct {
+								Properties map[string]any `yaml:"properties"`
+							} `yaml:"spec"`
+						} `yaml:"properties"`
+					} `yaml:"openAPIV3Schema"`
+				} `yaml:"schema"`
+			} `yaml:"versions"`
+		} `yaml:"spec"`
+	}
+	require.NoError(t, yaml.Unmarshal(files[0].Data, &manifest))
+	require.Equal(t, map[string]string{"cti/v1": "cti.a.my_pkg.policy.v1.1", "cti/v2": "cti.a.my_pkg.policy.v2.0"},
+		manifest.Metadata.Annotations)
+	require.Len(t, manifest.Spec.Versions, 2)
+	require.Equal(t, "v1", manifest.Spec.Versions[0].Name)
+	require.False(t, manifest.Spec.Versions[0].Storage)
+	require.Equal(t, []string{"v11"}, sortedKeys(manifest.Spec.Versions[0].Schema.OpenAPIV3Schema.Properties.Spec.Properties))
+	require.Equal(t, "v2", manifest.Spec.Versions[1].Name)
+	require.True(t, manifest.Spec.Versions[1].Storage)
+}
+
+func Test_CRDKindConflict(t *testing.T) {
+	schema := []byte(`{"$ref": "#/definitions/T", "definitions": {"T": {"type": "object", "properties": {"id": {"type": "string"}}}}}`)
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.a.b.event.v1.0":                  {Cti: "cti.a.b.event.v1.0", Schema: schema},
+		"cti.a.b.alert.v1.0":                  {Cti: "cti.a.b.alert.v1.0", Schema: schema},
+		"cti.a.b.event.v1.0~a.b.created.v1.0": {Cti: "cti.a.b.event.v1.0~a.b.created.v1.0", Schema: schema},
+		"cti.a.b.alert.v1.0~a.b.created.v1.0": {Cti: "cti.a.b.alert.v1.0~a.b.created.v1.0", Schema: schema},
+	})
+	require.NoError(t, err)
+
+	_, err = New(r).CRD(r.Entities(), CRDOptions{})
+	require.EqualError(t, err, "types cti.a.b.alert.v1.0~a.b.created.v1.0 and cti.a.b.event.v1.0~a.b.created.v1.0 have the same kind Created in group b.a")
+}
+
+func Test_StructuralSchema(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schema   map[string]any
+		expected map[string]any
+	}{
+		{
+			name:     "exclusive bounds",
+			schema:   map[string]any{"type": "integer", "exclusiveMinimum": 0.0, "maximum": 10.0},
+			expected: map[string]any{"type": "integer", "minimum": 0.0, "exclusiveMinimum": true, "maximum": 10.0},
+		},
+		{
+			name:     "nullable",
+			schema:   map[string]any{"type": []any{"string", "null"}, "maxLength": 3.0},
+			expected: map[string]any{"type": "string", "maxLength": 3.0, "nullable": true},
+		},
+		{
+			name: "int or string",
+			schema: map[string]any{"anyOf": []any{
+				map[string]any{"type": "integer"},
+				map[string]any{"type": "string"},
+			}},
+			expected: map[string]any{"x-kubernetes-int-or-string": true},
+		},
+		{
+			name:     "union",
+			schema:   map[string]any{"type": []any{"string", "boolean"}},
+			expected: map[string]any{"x-kubernetes-preserve-unknown-fields": true},
+		},
+		{
+			name:     "const",
+			schema:   map[string]any{"const": "fixed"},
+			expected: map[string]any{"type": "string", "enum": []any{"fixed"}},
+		},
+		{
+			name:   "set",
+			schema: map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "uniqueItems": true, "minItems": 1.0},
+			expected: map[string]any{
+				"type":                   "array",
+				"items":                  map[string]any{"type": "string"},
+				"minItems":               1.0,
+				"x-kubernetes-list-type": "set",
+			},
+		},
+		{
+			name:     "map",
+			schema:   map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "number"}},
+			expected: map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "number"}},
+		},
+		{
+			name:     "object without properties",
+			schema:   map[string]any{"type": "object"},
+			expected: map[string]any{"type": "object", "x-kubernetes-preserve-unknown-fields": true},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, structuralSchema((&modelBuilder{}).expr(tc.schema), nil))
+		})
+	}
+}
+
+func Test_CRDPlural(t *testing.T) {
+	for kind, plural := range map[string]string{
+		"event":  "events",
+		"policy": "policies",
+		"key":    "keys",
+		"box":    "boxes",
+		"status": "statuses",
+		"match":  "matches",
+	} {
+		require.Equal(t, plural, crdPlural(kind))
+	}
+}
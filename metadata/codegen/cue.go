@@ -0,0 +1,246 @@
+package codegen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+const (
+	CUEFile = "types.cue"
+
+	// DefaultCUEPackage is a name of the generated package if not specified.
+	DefaultCUEPackage = "ctitypes"
+
+	cueHeader = "// Code generated by cti gen cue. DO NOT EDIT.\n"
+)
+
+var cueIdentifier = regexp.MustCompile(`^[A-Za-z$][A-Za-z0-9_$]*$`)
+
+// cueKeywords are identifiers that are quoted when used as labels.
+var cueKeywords = map[string]bool{
+	"package": true, "import": true, "for": true, "in": true, "if": true, "let": true, "func": true,
+	"true": true, "false": true, "null": true,
+}
+
+type CUEOptions struct {
+	// Package is a name of the generated package.
+	Package string
+}
+
+// CUE generates CUE definitions of the types, so configuration validated with CUE may refer to the types,
+// e.g. #UserCreated. Definitions keep constraints of the schemas: patterns, lengths, ranges, sizes of lists
+// and defaults. Objects are open unless the schemas forbid additional properties.
+func (g *Generator) CUE(entities metadata.Entities, opts CUEOptions) ([]File, error) {
+	if opts.Package == "" {
+		opts.Package = DefaultCUEPackage
+	}
+	if !cueIdentifier.MatchString(opts.Package) || strings.HasPrefix(opts.Package, "$") || cueKeywords[opts.Package] {
+		return nil, fmt.Errorf("invalid package name %s", opts.Package)
+	}
+
+	m, err := g.model(entities)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &cueGenerator{imports: make(map[string]bool)}
+	var body strings.Builder
+	for _, nt := range m.types {
+		body.WriteString("\n")
+		writeCUEComment(&body, "", nt.Description, nt.Entity)
+		fmt.Fprintf(&body, "#%s: %s\n", nt.Name, c.cueType(nt.Expr, ""))
+	}
+
+	var b strings.Builder
+	b.WriteString(cueHeader)
+	fmt.Fprintf(&b, "\npackage %s\n", opts.Package)
+	switch imports := sortedKeys(c.imports); len(imports) {
+	case 0:
+	case 1:
+		fmt.Fprintf(&b, "\nimport %s\n", quote(imports[0]))
+	default:
+		b.WriteString("\nimport (\n")
+		for _, pkg := range imports {
+			fmt.Fprintf(&b, "\t%s\n", quote(pkg))
+		}
+		b.WriteString(")\n")
+	}
+	b.WriteString(body.String())
+	return []File{{Name: CUEFile, Data: []byte(b.String())}}, nil
+}
+
+type cueGenerator struct {
+	// imports holds packages of builtin validators used by definitions.
+	imports map[string]bool
+}
+
+// validator returns the call of the builtin validator and imports its package.
+func (c *cueGenerator) validator(pkg, call string) string {
+	c.imports[pkg] = true
+	return pkg + "." + call
+}
+
+// cueType returns the CUE expression of the type expression followed by its constraints and default.
+func (c *cueGenerator) cueType(e *typeExpr, indent string) string {
+	switch e.Kind {
+	case kindString:
+		return c.withDefault(e, c.constrained("string", c.stringConstraints(e)))
+	case kindInteger:
+		return c.withDefault(e, c.constrained("int", c.numberConstraints(e)))
+	case kindNumber:
+		return c.withDefault(e, c.constrained("number", c.numberConstraints(e)))
+	case kindBoolean:
+		return c.withDefault(e, "bool")
+	case kindNull:
+		return "null"
+	case kindRef:
+		return "#" + e.Ref.Name
+	case kindArray:
+		items := c.cueType(e.Items, indent)
+		var constraints []string
+		if v, ok := intFacet(e.Schema, "minItems"); ok {
+			constraints = append(constraints, c.validator("list", fmt.Sprintf("MinItems(%d)", v)))
+		}
+		if v, ok := intFacet(e.Schema, "maxItems"); ok {
+			constraints = append(constraints, c.validator("list", fmt.Sprintf("MaxItems(%d)", v)))
+		}
+		if unique, _ := e.Schema["uniqueItems"].(bool); unique {
+			constraints = append(constraints, c.validator("list", "UniqueItems()"))
+		}
+		return c.constrained("[..."+items+"]", constraints)
+	case kindMap:
+		return "{[string]: " + c.cueType(e.Values, indent) + "}"
+	case kindObject:
+		return c.cueStruct(e, indent)
+	case kindUnion:
+		members := make([]string, 0, len(e.Members))
+		for _, m := range e.Members {
+			members = append(members, c.cueType(m, indent))
+		}
+		return strings.Join(members, " | ")
+	case kindEnum:
+		def, hasDefault := e.Schema["default"]
+		values := make([]string, 0, len(e.Enum))
+		for _, v := range e.Enum {
+			value := literal(v)
+			if hasDefault && value == literal(def) {
+				value = "*" + value
+			}
+			values = append(values, value)
+		}
+		return strings.Join(values, " | ")
+	default:
+		return "_"
+	}
+}
+
+func (c *cueGenerator) cueStruct(e *typeExpr, indent string) string {
+	closed := e.Schema["additionalProperties"] == false
+	if len(e.Fields) == 0 {
+		if closed {
+			return "close({})"
+		}
+		return "{...}"
+	}
+
+	var b strings.Builder
+	b.WriteString("{\n")
+	inner := indent + "\t"
+	for _, f := range e.Fields {
+		writeCUEComment(&b, inner, f.Description, nil)
+		optional := ""
+		if !f.Required {
+			optional = "?"
+		}
+		fmt.Fprintf(&b, "%s%s%s: %s\n", inner, cueLabel(f.Name), optional, c.cueType(f.Expr, inner))
+	}
+	if !closed {
+		// Definitions are closed, additional properties are allowed explicitly.
+		fmt.Fprintf(&b, "%s...\n", inner)
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// cueStringFormats are validators of JSON Schema string formats.
+var cueStringFormats = map[string]struct{ pkg, call string }{
+	"date-time": {"time", "Time"},
+	"date":      {"time", "Format(time.RFC3339Date)"},
+	"uuid":      {"uuid", "Valid"},
+}
+
+func (c *cueGenerator) stringConstraints(e *typeExpr) []string {
+	var res []string
+	if f, ok := cueStringFormats[e.format()]; ok {
+		res = append(res, c.validator(f.pkg, f.call))
+	}
+	if pattern, ok := e.Schema["pattern"].(string); ok {
+		res = append(res, "=~"+quote(pattern))
+	}
+	if v, ok := intFacet(e.Schema, "minLength"); ok {
+		res = append(res, c.validator("strings", fmt.Sprintf("MinRunes(%d)", v)))
+	}
+	if v, ok := intFacet(e.Schema, "maxLength"); ok {
+		res = append(res, c.validator("strings", fmt.Sprintf("MaxRunes(%d)", v)))
+	}
+	return res
+}
+
+func (c *cueGenerator) numberConstraints(e *typeExpr) []string {
+	var res []string
+	for _, facet := range []struct{ key, op string }{
+		{"minimum", ">="}, {"exclusiveMinimum", ">"}, {"maximum", "<="}, {"exclusiveMaximum", "<"},
+	} {
+		if v, ok := e.Schema[facet.key]; ok {
+			if _, isBool := v.(bool); !isBool {
+				res = append(res, facet.op+literal(v))
+			}
+		}
+	}
+	if v, ok := e.Schema["multipleOf"]; ok {
+		res = append(res, c.validator("math", "MultipleOf("+literal(v)+")"))
+	}
+	return res
+}
+
+// constrained unifies the type with its constraints, e.g. int & >=0.
+func (c *cueGenerator) constrained(typ string, constraints []string) string {
+	return strings.Join(append([]string{typ}, constraints...), " & ")
+}
+
+// withDefault marks the default value of the schema, e.g. int & >=0 | *10.
+func (c *cueGenerator) withDefault(e *typeExpr, typ string) string {
+	def, ok := e.Schema["default"]
+	if !ok {
+		return typ
+	}
+	return typ + " | *" + literal(def)
+}
+
+// cueLabel returns the property name if it is a valid CUE identifier, otherwise the quoted name. Names
+// starting with # or _ would declare definitions or hidden fields, so they are quoted as well.
+func cueLabel(name string) string {
+	if cueIdentifier.MatchString(name) && !cueKeywords[name] {
+		return name
+	}
+	return quote(name)
+}
+
+func writeCUEComment(b *strings.Builder, indent, description string, entity *metadata.Entity) {
+	var lines []string
+	if description != "" {
+		lines = append(lines, strings.Split(description, "\n")...)
+	}
+	if entity != nil {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "CTI: "+entity.Cti)
+	}
+	for _, line := range lines {
+		fmt.Fprintf(b, "%s//%s\n", indent, strings.TrimRight(" "+line, " "))
+	}
+}
@@ -0,0 +1,135 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CUE(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	files, err := g.CUE(r.Entities(), CUEOptions{Package: "events"})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, CUEFile, files[0].Name)
+	require.Equal(t, `// Code generated by cti gen cue. DO NOT EDIT.
+
+package events
+
+import (
+	"strings"
+	"uuid"
+)
+
+// CTI: cti.x.y.event.v1.0
+#Event: {
+	id: string & uuid.Valid
+	severity?: "low" | "high"
+	tags?: [...#Tag]
+	...
+}
+
+// User created
+//
+// CTI: cti.x.y.event.v1.0~x.y.user_created.v1.0
+#UserCreated: {
+	id: string & uuid.Valid
+	login: string
+	manager?: #Person
+	severity?: "low" | "high"
+	tags?: [...#Tag]
+	...
+}
+
+#Person: {
+	name?: string
+	reports?: [...#Person]
+	...
+}
+
+#Tag: {
+	name: string & strings.MinRunes(1)
+	...
+}
+`, string(files[0].Data))
+
+	_, err = g.CUE(r.Entities(), CUEOptions{Package: "import"})
+	require.EqualError(t, err, "invalid package name import")
+}
+
+func Test_CUEExpressions(t *testing.T) {
+	testCases := []struct {
+		name    string
+		schema  map[string]any
+		cue     string
+		imports []string
+	}{
+		{
+			name:    "string with pattern and default",
+			schema:  map[string]any{"type": "string", "pattern": `^\d+$`, "maxLength": 3.0, "default": "1"},
+			cue:     `string & =~"^\\d+$" & strings.MaxRunes(3) | *"1"`,
+			imports: []string{"strings"},
+		},
+		{
+			name:    "date-time",
+			schema:  map[string]any{"type": "string", "format": "date-time"},
+			cue:     "string & time.Time",
+			imports: []string{"time"},
+		},
+		{
+			name:   "integer range",
+			schema: map[string]any{"type": "integer", "minimum": 0.0, "exclusiveMaximum": 10.0},
+			cue:    "int & >=0 & <10",
+		},
+		{
+			name:    "number multiple of",
+			schema:  map[string]any{"type": "number", "multipleOf": 0.5},
+			cue:     "number & math.MultipleOf(0.5)",
+			imports: []string{"math"},
+		},
+		{
+			name:   "nullable boolean",
+			schema: map[string]any{"type": []any{"boolean", "null"}},
+			cue:    "bool | null",
+		},
+		{
+			name:   "enum with default",
+			schema: map[string]any{"enum": []any{"a", "b"}, "default": "b"},
+			cue:    `"a" | *"b"`,
+		},
+		{
+			name:    "list",
+			schema:  map[string]any{"type": "array", "items": map[string]any{"type": "integer"}, "minItems": 1.0, "uniqueItems": true},
+			cue:     "[...int] & list.MinItems(1) & list.UniqueItems()",
+			imports: []string{"list"},
+		},
+		{
+			name:   "map",
+			schema: map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "number"}},
+			cue:    "{[string]: number}",
+		},
+		{
+			name:   "open object without properties",
+			schema: map[string]any{"type": "object"},
+			cue:    "{...}",
+		},
+		{
+			name: "closed object",
+			schema: map[string]any{
+				"type":                 "object",
+				"properties":           map[string]any{"_id": map[string]any{"type": "string"}, "if": map[string]any{}},
+				"additionalProperties": false,
+			},
+			cue: "{\n\t\"_id\"?: string\n\t\"if\"?: _\n}",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			c := &cueGenerator{imports: make(map[string]bool)}
+			require.Equal(t, tc.cue, c.cueType((&modelBuilder{}).expr(tc.schema), ""))
+			require.ElementsMatch(t, tc.imports, sortedKeys(c.imports))
+		})
+	}
+}
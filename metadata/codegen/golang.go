@@ -0,0 +1,571 @@
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+const (
+	GoTypesFile    = "types.go"
+	GoCtisFile     = "ctis.go"
+	GoValidateFile = "validate.go"
+
+	// DefaultGoPackage is a name of the generated package if not specified.
+	DefaultGoPackage = "ctitypes"
+
+	goHeader = "// Code generated by cti gen go. DO NOT EDIT.\n"
+)
+
+// goInitialisms are words that are written in upper case in Go identifiers, e.g. ID in UserID.
+var goInitialisms = map[string]bool{
+	"ACL": true, "API": true, "CPU": true, "CTI": true, "DNS": true, "HTML": true, "HTTP": true, "HTTPS": true,
+	"ID": true, "IP": true, "JSON": true, "SQL": true, "TCP": true, "TLS": true, "TTL": true, "UDP": true,
+	"UI": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+type GoOptions struct {
+	// Package is a name of the generated package.
+	Package string
+	// Validation generates Validate methods that check values against constraints of the schemas.
+	Validation bool
+}
+
+// Go generates Go structs of the types with json tags, constants of CTIs of the entities and, optionally,
+// Validate methods. Objects nested into properties become separate types named after the property,
+// string enums become string types with constants of allowed values. Optional properties are pointers
+// unless they are slices or maps. The output is formatted and stable for the same input.
+func (g *Generator) Go(entities metadata.Entities, opts GoOptions) ([]File, error) {
+	if opts.Package == "" {
+		opts.Package = DefaultGoPackage
+	}
+	if !token.IsIdentifier(opts.Package) {
+		return nil, fmt.Errorf("invalid package name %s", opts.Package)
+	}
+	m, err := g.model(entities)
+	if err != nil {
+		return nil, err
+	}
+
+	gg := &goGenerator{validation: opts.Validation, used: make(map[string]bool), named: make(map[string]bool)}
+	for _, nt := range m.types {
+		gg.used[nt.Name] = true
+		gg.named[nt.Name] = true
+	}
+	for _, nt := range m.types {
+		var doc []string
+		if nt.Description == nt.Name {
+			nt.Description = ""
+		}
+		if nt.Entity != nil {
+			doc = append(doc, fmt.Sprintf("%s represents %s.", nt.Name, nt.Entity.Cti))
+			if nt.Description != "" {
+				doc = append(doc, "")
+			}
+		}
+		if nt.Description != "" {
+			doc = append(doc, strings.Split(nt.Description, "\n")...)
+		}
+		gg.generate(&goDecl{name: nt.Name, doc: doc, expr: nt.Expr})
+	}
+
+	var ctis goSource
+	ctis.WriteString("const (\n")
+	for _, c := range ctiConstants(m, entities, "CTI", gg.used) {
+		if c.entity.DisplayName != "" {
+			writeGoDoc(&ctis, "\t", strings.Split(c.entity.DisplayName, "\n"))
+		}
+		fmt.Fprintf(&ctis, "\t%s = %s\n", c.name, strconv.Quote(c.entity.Cti))
+	}
+	ctis.WriteString(")\n")
+
+	sources := []struct {
+		name   string
+		source *goSource
+	}{
+		{GoTypesFile, &gg.types},
+		{GoCtisFile, &ctis},
+	}
+	if opts.Validation {
+		sources = append(sources, struct {
+			name   string
+			source *goSource
+		}{GoValidateFile, gg.validateSource()})
+	}
+
+	files := make([]File, 0, len(sources))
+	for _, s := range sources {
+		data, err := s.source.file(opts.Package)
+		if err != nil {
+			return nil, fmt.Errorf("format %s: %w", s.name, err)
+		}
+		files = append(files, File{Name: s.name, Data: data})
+	}
+	return files, nil
+}
+
+// goSource is a body of a generated Go file along with packages it imports.
+type goSource struct {
+	strings.Builder
+	imports map[string]bool
+	// header is the first line of the file, the header of cti gen go if empty.
+	header string
+}
+
+func (s *goSource) use(pkg string) {
+	if s.imports == nil {
+		s.imports = make(map[string]bool)
+	}
+	s.imports[pkg] = true
+}
+
+// file returns the formatted file of the source.
+func (s *goSource) file(pkg string) ([]byte, error) {
+	var b strings.Builder
+	if s.header != "" {
+		b.WriteString(s.header)
+	} else {
+		b.WriteString(goHeader)
+	}
+	fmt.Fprintf(&b, "\npackage %s\n", pkg)
+	if len(s.imports) > 0 {
+		b.WriteString("\nimport (\n")
+		for _, imp := range sortedKeys(s.imports) {
+			fmt.Fprintf(&b, "\t%s\n", strconv.Quote(imp))
+		}
+		b.WriteString(")\n")
+	}
+	if s.Len() > 0 {
+		b.WriteString("\n")
+		b.WriteString(s.String())
+	}
+	return format.Source([]byte(b.String()))
+}
+
+// goDecl is a declaration of a generated Go type.
+type goDecl struct {
+	name string
+	doc  []string
+	expr *typeExpr
+}
+
+type goGenerator struct {
+	validation bool
+	// used holds package level names, named holds names of generated types.
+	used  map[string]bool
+	named map[string]bool
+	// pending holds types nested into the declaration being generated.
+	pending []*goDecl
+
+	types    goSource
+	validate goSource
+	patterns map[string]string
+}
+
+// generate writes the declaration followed by types nested into it.
+func (g *goGenerator) generate(d *goDecl) {
+	g.pending = nil
+	g.writeDecl(d)
+	nested := g.pending
+	for _, n := range nested {
+		g.generate(n)
+	}
+}
+
+// nest declares a type of the expression nested into the declaration being generated.
+func (g *goGenerator) nest(hint string, e *typeExpr) string {
+	name := uniqueName(hint, g.used)
+	g.named[name] = true
+	g.pending = append(g.pending, &goDecl{name: name, expr: e})
+	return name
+}
+
+func (g *goGenerator) writeDecl(d *goDecl) {
+	e := d.expr
+	g.types.WriteString("\n")
+	writeGoDoc(&g.types, "", d.doc)
+	switch {
+	case e.Kind == kindObject && len(e.Fields) > 0:
+		g.writeStruct(d)
+	case e.Kind == kindEnum && e.Base == kindString:
+		g.writeEnum(d)
+	default:
+		t := g.goType(e, d.name)
+		fmt.Fprintf(&g.types, "type %s %s\n", d.name, t)
+		if g.validation {
+			value := t + "(v)"
+			if strings.HasPrefix(t, "*") {
+				value = "(" + t + ")(v)"
+			}
+			g.writeValidate(d.name, g.checks(e, t, value, goPath{}, "\t"))
+		}
+	}
+}
+
+func (g *goGenerator) writeStruct(d *goDecl) {
+	fields := make(map[string]bool)
+	if g.validation {
+		fields["Validate"] = true
+	}
+	var checks strings.Builder
+	fmt.Fprintf(&g.types, "type %s struct {\n", d.name)
+	for _, f := range d.expr.Fields {
+		name := uniqueName(goIdentifier(f.Name), fields)
+		t := g.goType(f.Expr, d.name+name)
+		// Self references are pointers, otherwise the type would have infinite size.
+		if (!f.Required || t == d.name) && goPointable(t) {
+			t = "*" + t
+		}
+		tag := "json:\"" + f.Name
+		if !f.Required {
+			tag += ",omitempty"
+		}
+		tag += "\""
+		if strings.Contains(tag, "`") {
+			tag = strconv.Quote(tag)
+		} else {
+			tag = "`" + tag + "`"
+		}
+		if f.Description != "" {
+			writeGoDoc(&g.types, "\t", strings.Split(f.Description, "\n"))
+		}
+		fmt.Fprintf(&g.types, "\t%s %s %s\n", name, t, tag)
+		checks.WriteString(g.checks(f.Expr, t, "v."+name, goPath{}.field(f.Name), "\t"))
+	}
+	g.types.WriteString("}\n")
+	if g.validation {
+		g.writeValidate(d.name, checks.String())
+	}
+}
+
+func (g *goGenerator) writeEnum(d *goDecl) {
+	fmt.Fprintf(&g.types, "type %s string\n\nconst (\n", d.name)
+	var names []string
+	for _, v := range d.expr.Enum {
+		s, ok := v.(string)
+		if !ok {
+			continue
+		}
+		suffix := goIdentifier(s)
+		if s == "" {
+			suffix = "Empty"
+		}
+		name := uniqueName(d.name+suffix, g.used)
+		names = append(names, name)
+		fmt.Fprintf(&g.types, "\t%s %s = %s\n", name, d.name, strconv.Quote(s))
+	}
+	g.types.WriteString(")\n")
+	if g.validation && len(names) == 0 {
+		g.writeValidate(d.name, "")
+	} else if g.validation {
+		g.validate.use("fmt")
+		fmt.Fprintf(&g.validate, "\n// Validate checks that the value is one of allowed values.\nfunc (v %s) Validate() error {\n", d.name)
+		fmt.Fprintf(&g.validate, "\tswitch v {\n\tcase %s:\n\t\treturn nil\n", strings.Join(names, ", "))
+		g.validate.WriteString("\tdefault:\n\t\treturn fmt.Errorf(\"invalid value %q\", string(v))\n\t}\n}\n")
+	}
+}
+
+func (g *goGenerator) writeValidate(name, checks string) {
+	fmt.Fprintf(&g.validate, "\n// Validate checks the value against constraints of the schema.\nfunc (v %s) Validate() error {\n", name)
+	g.validate.WriteString(checks)
+	g.validate.WriteString("\treturn nil\n}\n")
+}
+
+// validateSource returns the source with Validate methods along with patterns they use.
+func (g *goGenerator) validateSource() *goSource {
+	res := &goSource{imports: g.validate.imports}
+	if len(g.patterns) > 0 {
+		res.WriteString("var (\n")
+		names := make([]string, 0, len(g.patterns))
+		patterns := make(map[string]string, len(g.patterns))
+		for pattern, name := range g.patterns {
+			names = append(names, name)
+			patterns[name] = pattern
+		}
+		sort.Slice(names, func(a, b int) bool {
+			return len(names[a]) < len(names[b]) || len(names[a]) == len(names[b]) && names[a] < names[b]
+		})
+		for _, name := range names {
+			fmt.Fprintf(res, "\t%s = regexp.MustCompile(%s)\n", name, strconv.Quote(patterns[name]))
+		}
+		res.WriteString(")\n")
+	}
+	res.WriteString(g.validate.String())
+	return res
+}
+
+// goType returns the Go type of the expression. Nested objects and string enums are declared
+// as separate types named after the hint.
+func (g *goGenerator) goType(e *typeExpr, hint string) string {
+	switch e.Kind {
+	case kindString:
+		if e.format() == "date-time" {
+			g.types.use("time")
+			return "time.Time"
+		}
+		return "string"
+	case kindInteger:
+		return "int64"
+	case kindNumber:
+		return "float64"
+	case kindBoolean:
+		return "bool"
+	case kindRef:
+		return e.Ref.Name
+	case kindArray:
+		return "[]" + g.goType(e.Items, hint+"Item")
+	case kindMap:
+		return "map[string]" + g.goType(e.Values, hint+"Value")
+	case kindObject:
+		if len(e.Fields) > 0 {
+			return g.nest(hint, e)
+		}
+		if e.Values != nil {
+			return "map[string]" + g.goType(e.Values, hint+"Value")
+		}
+		return "map[string]any"
+	case kindEnum:
+		switch e.Base {
+		case kindString:
+			return g.nest(hint, e)
+		case kindInteger:
+			return "int64"
+		case kindNumber:
+			return "float64"
+		case kindBoolean:
+			return "bool"
+		default:
+			return "any"
+		}
+	case kindUnion:
+		base, nullable := e.nullable()
+		if nullable && base.Kind != kindUnion {
+			t := g.goType(base, hint)
+			if goPointable(t) {
+				return "*" + t
+			}
+			return t
+		}
+		return "any"
+	default:
+		return "any"
+	}
+}
+
+// goPointable tells whether a value of the type may be missing only if it is a pointer.
+func goPointable(t string) bool {
+	return t != "any" && !strings.HasPrefix(t, "*") && !strings.HasPrefix(t, "[]") && !strings.HasPrefix(t, "map[")
+}
+
+// goPath is a path to the checked value in errors of Validate methods. It is a format string
+// with arguments, e.g. "tags[%d].name" with i.
+type goPath struct {
+	format string
+	args   []string
+}
+
+func (p goPath) field(name string) goPath {
+	name = strings.ReplaceAll(name, "%", "%%")
+	if p.format != "" {
+		name = p.format + "." + name
+	}
+	return goPath{format: name, args: p.args}
+}
+
+func (p goPath) index(format, arg string) goPath {
+	return goPath{format: p.format + format, args: append(append([]string(nil), p.args...), arg)}
+}
+
+// fail returns an expression of the error of the check at the path. The message is a format string.
+func (g *goGenerator) fail(path goPath, msg string, args ...string) string {
+	if path.format != "" {
+		msg = path.format + ": " + msg
+	}
+	args = append(append([]string(nil), path.args...), args...)
+	if len(args) == 0 {
+		g.validate.use("errors")
+		return "errors.New(" + strconv.Quote(strings.ReplaceAll(msg, "%%", "%")) + ")"
+	}
+	g.validate.use("fmt")
+	return "fmt.Errorf(" + strconv.Quote(msg) + ", " + strings.Join(args, ", ") + ")"
+}
+
+// checks returns statements of Validate methods that check the value of the Go type t built
+// from the expression.
+func (g *goGenerator) checks(e *typeExpr, t, value string, path goPath, indent string) string {
+	if e.Kind == kindUnion {
+		e, _ = e.nullable()
+	}
+	if strings.HasPrefix(t, "*") {
+		inner := g.checks(e, t[1:], "(*"+value+")", path, indent+"\t")
+		if inner == "" {
+			return ""
+		}
+		return indent + "if " + value + " != nil {\n" + inner + indent + "}\n"
+	}
+
+	var b strings.Builder
+	check := func(cond, err string) {
+		fmt.Fprintf(&b, "%sif %s {\n%s\treturn %s\n%s}\n", indent, cond, indent, err, indent)
+	}
+	if g.named[t] {
+		var err string
+		if path.format == "" {
+			err = "err"
+		} else {
+			err = g.fail(path, "%w", "err")
+		}
+		fmt.Fprintf(&b, "%sif err := %s.Validate(); err != nil {\n%s\treturn %s\n%s}\n", indent, value, indent, err, indent)
+		return b.String()
+	}
+
+	switch {
+	case t == "string":
+		if v, ok := intFacet(e.Schema, "minLength"); ok {
+			g.validate.use("unicode/utf8")
+			check(fmt.Sprintf("utf8.RuneCountInString(%s) < %d", value, v), g.fail(path, fmt.Sprintf("length must be at least %d", v)))
+		}
+		if v, ok := intFacet(e.Schema, "maxLength"); ok {
+			g.validate.use("unicode/utf8")
+			check(fmt.Sprintf("utf8.RuneCountInString(%s) > %d", value, v), g.fail(path, fmt.Sprintf("length must be at most %d", v)))
+		}
+		if pattern, ok := e.Schema["pattern"].(string); ok {
+			check("!"+g.pattern(pattern)+".MatchString("+value+")",
+				g.fail(path, "must match pattern "+strings.ReplaceAll(pattern, "%", "%%")))
+		}
+	case t == "int64" || t == "float64":
+		for _, facet := range []struct{ key, op, msg string }{
+			{"minimum", "<", "must be greater than or equal to"},
+			{"maximum", ">", "must be less than or equal to"},
+			{"exclusiveMinimum", "<=", "must be greater than"},
+			{"exclusiveMaximum", ">=", "must be less than"},
+		} {
+			bound, ok := e.Schema[facet.key].(float64)
+			if !ok {
+				continue
+			}
+			lhs, rhs := value, literal(bound)
+			if t == "int64" && bound != float64(int64(bound)) {
+				lhs = "float64(" + unparen(value) + ")"
+			}
+			check(lhs+" "+facet.op+" "+rhs, g.fail(path, facet.msg+" "+rhs))
+		}
+		if v, ok := e.Schema["multipleOf"].(float64); ok && t == "int64" && v >= 1 && v == float64(int64(v)) {
+			check(fmt.Sprintf("%s%%%d != 0", value, int64(v)), g.fail(path, fmt.Sprintf("must be a multiple of %d", int64(v))))
+		}
+		if e.Kind == kindEnum {
+			b.WriteString(g.enumCheck(e, value, path, indent))
+		}
+	case t == "bool":
+		if e.Kind == kindEnum {
+			b.WriteString(g.enumCheck(e, value, path, indent))
+		}
+	case strings.HasPrefix(t, "[]"):
+		if v, ok := intFacet(e.Schema, "minItems"); ok {
+			check(fmt.Sprintf("len(%s) < %d", value, v), g.fail(path, fmt.Sprintf("must have at least %d items", v)))
+		}
+		if v, ok := intFacet(e.Schema, "maxItems"); ok {
+			check(fmt.Sprintf("len(%s) > %d", value, v), g.fail(path, fmt.Sprintf("must have at most %d items", v)))
+		}
+		if e.Items != nil {
+			i := goLoopVar("i", len(path.args))
+			inner := g.checks(e.Items, t[2:], value+"["+i+"]", path.index("[%d]", i), indent+"\t")
+			if inner != "" {
+				fmt.Fprintf(&b, "%sfor %s := range %s {\n%s%s}\n", indent, i, value, inner, indent)
+			}
+		}
+	case strings.HasPrefix(t, "map[string]"):
+		if e.Values != nil {
+			k := goLoopVar("k", len(path.args))
+			inner := g.checks(e.Values, t[len("map[string]"):], value+"["+k+"]", path.index("[%q]", k), indent+"\t")
+			if inner != "" {
+				fmt.Fprintf(&b, "%sfor %s := range %s {\n%s%s}\n", indent, k, value, inner, indent)
+			}
+		}
+	}
+	return b.String()
+}
+
+func (g *goGenerator) enumCheck(e *typeExpr, value string, path goPath, indent string) string {
+	values := make([]string, 0, len(e.Enum))
+	for _, v := range e.Enum {
+		switch v.(type) {
+		case float64, bool:
+			values = append(values, literal(v))
+		}
+	}
+	if len(values) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%sswitch %s {\n%scase %s:\n%sdefault:\n%s\treturn %s\n%s}\n",
+		indent, value, indent, strings.Join(values, ", "), indent, indent, g.fail(path, "invalid value %v", unparen(value)), indent)
+}
+
+// pattern returns the name of the package level variable with the compiled pattern.
+func (g *goGenerator) pattern(pattern string) string {
+	if g.patterns == nil {
+		g.patterns = make(map[string]string)
+	}
+	name, ok := g.patterns[pattern]
+	if !ok {
+		name = "pattern" + strconv.Itoa(len(g.patterns)+1)
+		g.patterns[pattern] = name
+		g.validate.use("regexp")
+	}
+	return name
+}
+
+// unparen removes parentheses around the expression where they are redundant, e.g. in arguments.
+func unparen(expr string) string {
+	if strings.HasPrefix(expr, "(") && strings.HasSuffix(expr, ")") {
+		return expr[1 : len(expr)-1]
+	}
+	return expr
+}
+
+// goLoopVar returns the name of the loop variable unique for the number of enclosing loops.
+func goLoopVar(base string, depth int) string {
+	if depth > 0 {
+		return base + strconv.Itoa(depth)
+	}
+	return base
+}
+
+// goIdentifier converts the name into an exported Go identifier with initialisms in upper case,
+// e.g. user_id becomes UserID.
+func goIdentifier(name string) string {
+	words := strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	var b strings.Builder
+	for _, word := range words {
+		if upper := strings.ToUpper(word); goInitialisms[upper] {
+			b.WriteString(upper)
+			continue
+		}
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	s := b.String()
+	if s == "" || unicode.IsDigit([]rune(s)[0]) {
+		s = "X" + s
+	}
+	return s
+}
+
+func writeGoDoc(b *goSource, indent string, lines []string) {
+	for _, line := range lines {
+		if line == "" {
+			fmt.Fprintf(b, "%s//\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
@@ -0,0 +1,195 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Go(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	files, err := g.Go(r.Entities(), GoOptions{Package: "events", Validation: true})
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+
+	require.Equal(t, GoTypesFile, files[0].Name)
+	require.Equal(t, `// Code generated by cti gen go. DO NOT EDIT.
+
+package events
+
+// Event represents cti.x.y.event.v1.0.
+type Event struct {
+	ID       string         `+"`json:\"id\"`"+`
+	Severity *EventSeverity `+"`json:\"severity,omitempty\"`"+`
+	Tags     []Tag          `+"`json:\"tags,omitempty\"`"+`
+}
+
+type EventSeverity string
+
+const (
+	EventSeverityLow  EventSeverity = "low"
+	EventSeverityHigh EventSeverity = "high"
+)
+
+// UserCreated represents cti.x.y.event.v1.0~x.y.user_created.v1.0.
+//
+// User created
+type UserCreated struct {
+	ID       string               `+"`json:\"id\"`"+`
+	Login    string               `+"`json:\"login\"`"+`
+	Manager  *Person              `+"`json:\"manager,omitempty\"`"+`
+	Severity *UserCreatedSeverity `+"`json:\"severity,omitempty\"`"+`
+	Tags     []Tag                `+"`json:\"tags,omitempty\"`"+`
+}
+
+type UserCreatedSeverity string
+
+const (
+	UserCreatedSeverityLow  UserCreatedSeverity = "low"
+	UserCreatedSeverityHigh UserCreatedSeverity = "high"
+)
+
+type Person struct {
+	Name    *string  `+"`json:\"name,omitempty\"`"+`
+	Reports []Person `+"`json:\"reports,omitempty\"`"+`
+}
+
+type Tag struct {
+	Name string `+"`json:\"name\"`"+`
+}
+`, string(files[0].Data))
+
+	require.Equal(t, GoCtisFile, files[1].Name)
+	require.Contains(t, string(files[1].Data), `	EventCTI = "cti.x.y.event.v1.0"
+	// User created
+	UserCreatedCTI = "cti.x.y.event.v1.0~x.y.user_created.v1.0"
+	SampleCTI      = "cti.x.y.event.v1.0~x.y.user_created.v1.0~x.y.sample.v1.0"
+`)
+
+	require.Equal(t, GoValidateFile, files[2].Name)
+	require.Contains(t, string(files[2].Data), `func (v Tag) Validate() error {
+	if utf8.RuneCountInString(v.Name) < 1 {
+		return errors.New("name: length must be at least 1")
+	}
+	return nil
+}`)
+	require.Contains(t, string(files[2].Data), `	for i := range v.Reports {
+		if err := v.Reports[i].Validate(); err != nil {
+			return fmt.Errorf("reports[%d]: %w", i, err)
+		}
+	}`)
+
+	// The output is stable.
+	again, err := g.Go(r.Entities(), GoOptions{Package: "events", Validation: true})
+	require.NoError(t, err)
+	require.Equal(t, files, again)
+
+	files, err = g.Go(r.Entities(), GoOptions{})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Contains(t, string(files[0].Data), "package ctitypes\n")
+
+	_, err = g.Go(r.Entities(), GoOptions{Package: "type"})
+	require.EqualError(t, err, "invalid package name type")
+}
+
+func Test_GoChecks(t *testing.T) {
+	testCases := []struct {
+		name   string
+		schema map[string]any
+		typ    string
+		checks string
+	}{
+		{
+			name:   "nullable integer",
+			schema: map[string]any{"type": []any{"integer", "null"}, "minimum": 0.5, "maximum": 10.0},
+			typ:    "*int64",
+			checks: `if v.F != nil {
+	if float64(*v.F) < 0.5 {
+		return errors.New("f: must be greater than or equal to 0.5")
+	}
+	if (*v.F) > 10 {
+		return errors.New("f: must be less than or equal to 10")
+	}
+}
+`,
+		},
+		{
+			name:   "pattern",
+			schema: map[string]any{"type": "string", "pattern": "^a%$"},
+			typ:    "string",
+			checks: `if !pattern1.MatchString(v.F) {
+	return errors.New("f: must match pattern ^a%$")
+}
+`,
+		},
+		{
+			name:   "nested arrays",
+			schema: map[string]any{"type": "array", "maxItems": 2.0, "items": map[string]any{"type": "array", "items": map[string]any{"enum": []any{1.0, 2.0}}}},
+			typ:    "[][]float64",
+			checks: `if len(v.F) > 2 {
+	return errors.New("f: must have at most 2 items")
+}
+for i := range v.F {
+	for i1 := range v.F[i] {
+		switch v.F[i][i1] {
+		case 1, 2:
+		default:
+			return fmt.Errorf("f[%d][%d]: invalid value %v", i, i1, v.F[i][i1])
+		}
+	}
+}
+`,
+		},
+		{
+			name:   "map",
+			schema: map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string", "maxLength": 3.0}},
+			typ:    "map[string]string",
+			checks: `for k := range v.F {
+	if utf8.RuneCountInString(v.F[k]) > 3 {
+		return fmt.Errorf("f[%q]: length must be at most 3", k)
+	}
+}
+`,
+		},
+		{
+			name:   "date-time",
+			schema: map[string]any{"type": "string", "format": "date-time"},
+			typ:    "time.Time",
+		},
+		{
+			name:   "union",
+			schema: map[string]any{"oneOf": []any{map[string]any{"type": "string"}, map[string]any{"type": "integer"}}},
+			typ:    "any",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &goGenerator{validation: true, used: make(map[string]bool), named: make(map[string]bool)}
+			e := (&modelBuilder{}).expr(tc.schema)
+			typ := g.goType(e, "F")
+			require.Equal(t, tc.typ, typ)
+			require.Equal(t, tc.checks, g.checks(e, typ, "v.F", goPath{}.field("f"), ""))
+		})
+	}
+}
+
+func Test_GoIdentifier(t *testing.T) {
+	testCases := []struct {
+		name string
+		want string
+	}{
+		{"user_id", "UserID"},
+		{"api-url", "APIURL"},
+		{"createdAt", "CreatedAt"},
+		{"2fa", "X2fa"},
+		{"_", "X"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, goIdentifier(tc.name))
+		})
+	}
+}
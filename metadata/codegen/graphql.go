@@ -0,0 +1,307 @@
+package codegen
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+const (
+	GraphQLFile = "schema.graphql"
+
+	graphQLHeader = "# Code generated by cti gen graphql. DO NOT EDIT.\n"
+)
+
+// graphQLScalars are custom scalars used for values that have no built-in GraphQL types.
+var graphQLScalars = map[string]string{
+	"DateTime": "Date and time in RFC 3339 format.",
+	"JSON":     "Arbitrary JSON value.",
+	"Long":     "64-bit integer.",
+}
+
+var graphQLName = regexp.MustCompile(`^[_A-Za-z][_0-9A-Za-z]*$`)
+
+// GraphQL generates GraphQL SDL with object types and enums of the types. Types that have descendants among
+// generated types are abstract: they become interfaces implemented by their descendants. Fields inherited
+// from interfaces keep types of the interfaces, so implementations stay valid when descendants narrow them.
+// Values that cannot be typed in GraphQL (unions, maps, objects without properties) use the JSON scalar.
+func (g *Generator) GraphQL(entities metadata.Entities) ([]File, error) {
+	m, err := g.model(entities)
+	if err != nil {
+		return nil, err
+	}
+
+	gg := &graphQLGenerator{used: make(map[string]bool), fields: make(map[string]map[string]string)}
+	ctis := make(map[string]bool)
+	for _, nt := range m.types {
+		gg.used[nt.Name] = true
+		if nt.Entity != nil {
+			ctis[nt.Entity.Cti] = true
+		}
+	}
+	abstract := make(map[string]bool)
+	for cti := range ctis {
+		for _, ancestor := range g.registry.Ancestors(cti) {
+			if ctis[ancestor.Cti] {
+				abstract[ancestor.Cti] = true
+			}
+		}
+	}
+
+	for _, nt := range m.types {
+		var lines []string
+		if nt.Description != "" && nt.Description != nt.Name {
+			lines = append(lines, nt.Description)
+		}
+		d := &graphQLDecl{name: nt.Name, expr: nt.Expr}
+		if nt.Entity != nil {
+			lines = append(lines, "CTI: "+nt.Entity.Cti)
+			d.cti = nt.Entity.Cti
+			d.abstract = abstract[nt.Entity.Cti]
+			// Interfaces are listed from the root, the nearest one defines types of inherited fields.
+			ancestors := g.registry.Ancestors(nt.Entity.Cti)
+			for i := len(ancestors) - 1; i >= 0; i-- {
+				if iface, ok := gg.interfaceOf(ancestors[i].Cti); ok {
+					d.implements = append(d.implements, iface)
+					d.inherited = gg.fields[ancestors[i].Cti]
+				}
+			}
+		}
+		d.description = strings.Join(lines, "\n\n")
+		gg.generate(d)
+	}
+
+	var b strings.Builder
+	b.WriteString(graphQLHeader)
+	for _, name := range sortedKeys(gg.scalars) {
+		b.WriteString("\n")
+		writeGraphQLDescription(&b, "", graphQLScalars[name])
+		fmt.Fprintf(&b, "scalar %s\n", name)
+	}
+	b.WriteString(gg.body.String())
+	return []File{{Name: GraphQLFile, Data: []byte(b.String())}}, nil
+}
+
+// graphQLDecl is a declaration of a generated type.
+type graphQLDecl struct {
+	name        string
+	description string
+	expr        *typeExpr
+	// cti is set for CTI types. Abstract CTI types become interfaces.
+	cti      string
+	abstract bool
+	// implements holds names of interfaces the type implements, inherited holds types of their fields.
+	implements []string
+	inherited  map[string]string
+}
+
+type graphQLGenerator struct {
+	used    map[string]bool
+	scalars map[string]bool
+	// names holds names of interfaces by CTIs, fields holds types of their fields.
+	names  map[string]string
+	fields map[string]map[string]string
+	// pending holds types nested into the declaration being generated.
+	pending []*graphQLDecl
+
+	body strings.Builder
+}
+
+// interfaceOf returns the name of the interface generated for the abstract type.
+func (g *graphQLGenerator) interfaceOf(cti string) (string, bool) {
+	name, ok := g.names[cti]
+	return name, ok
+}
+
+// generate writes the declaration followed by types nested into it.
+func (g *graphQLGenerator) generate(d *graphQLDecl) {
+	g.pending = nil
+	g.writeDecl(d)
+	nested := g.pending
+	for _, n := range nested {
+		g.generate(n)
+	}
+}
+
+func (g *graphQLGenerator) nest(hint string, e *typeExpr) string {
+	name := uniqueName(hint, g.used)
+	g.pending = append(g.pending, &graphQLDecl{name: name, expr: e})
+	return name
+}
+
+func (g *graphQLGenerator) scalar(name string) string {
+	if g.scalars == nil {
+		g.scalars = make(map[string]bool)
+	}
+	g.scalars[name] = true
+	return name
+}
+
+func (g *graphQLGenerator) writeDecl(d *graphQLDecl) {
+	e := d.expr
+	g.body.WriteString("\n")
+	writeGraphQLDescription(&g.body, "", d.description)
+	switch {
+	case e.Kind == kindObject && len(e.Fields) > 0:
+		g.writeObject(d)
+	case e.Kind == kindEnum && e.Base == kindString:
+		fmt.Fprintf(&g.body, "enum %s {\n", d.name)
+		values := make(map[string]bool)
+		for _, v := range e.Enum {
+			if s, ok := v.(string); ok {
+				fmt.Fprintf(&g.body, "  %s\n", uniqueName(graphQLEnumValue(s), values))
+			}
+		}
+		g.body.WriteString("}\n")
+	default:
+		// Types without fields cannot be declared as objects.
+		fmt.Fprintf(&g.body, "scalar %s\n", d.name)
+	}
+}
+
+func (g *graphQLGenerator) writeObject(d *graphQLDecl) {
+	keyword := "type"
+	if d.abstract {
+		keyword = "interface"
+	}
+	fmt.Fprintf(&g.body, "%s %s", keyword, d.name)
+	if len(d.implements) > 0 {
+		fmt.Fprintf(&g.body, " implements %s", strings.Join(d.implements, " & "))
+	}
+	g.body.WriteString(" {\n")
+
+	fields := make(map[string]string, len(d.expr.Fields))
+	used := make(map[string]bool)
+	for _, f := range d.expr.Fields {
+		name := uniqueName(graphQLFieldName(f.Name), used)
+		typ, ok := d.inherited[name]
+		if !ok {
+			typ = g.graphQLType(f.Expr, d.name+exportedName(f.Name))
+		}
+		// Implementations cannot relax non-null fields of interfaces.
+		if _, nullable := f.Expr.nullable(); f.Required && !nullable && !strings.HasSuffix(typ, "!") {
+			typ += "!"
+		}
+		fields[name] = typ
+		writeGraphQLDescription(&g.body, "  ", f.Description)
+		fmt.Fprintf(&g.body, "  %s: %s\n", name, typ)
+	}
+	g.body.WriteString("}\n")
+
+	if d.abstract {
+		if g.names == nil {
+			g.names = make(map[string]string)
+		}
+		g.names[d.cti] = d.name
+		g.fields[d.cti] = fields
+	}
+}
+
+// graphQLType returns the GraphQL type of the expression without the non-null modifier. Nested objects
+// and string enums are declared as separate types named after the hint.
+func (g *graphQLGenerator) graphQLType(e *typeExpr, hint string) string {
+	switch e.Kind {
+	case kindString:
+		switch e.format() {
+		case "date-time":
+			return g.scalar("DateTime")
+		case "uuid":
+			return "ID"
+		}
+		return "String"
+	case kindInteger:
+		if e.format() == "int64" {
+			return g.scalar("Long")
+		}
+		return "Int"
+	case kindNumber:
+		return "Float"
+	case kindBoolean:
+		return "Boolean"
+	case kindRef:
+		return e.Ref.Name
+	case kindArray:
+		item := g.graphQLType(e.Items, hint+"Item")
+		if _, nullable := e.Items.nullable(); !nullable && e.Items.Kind != kindAny {
+			item += "!"
+		}
+		return "[" + item + "]"
+	case kindObject:
+		if len(e.Fields) > 0 {
+			return g.nest(hint, e)
+		}
+	case kindEnum:
+		switch e.Base {
+		case kindString:
+			return g.nest(hint, e)
+		case kindInteger:
+			return "Int"
+		case kindNumber:
+			return "Float"
+		case kindBoolean:
+			return "Boolean"
+		}
+	case kindUnion:
+		if base, nullable := e.nullable(); nullable && base.Kind != kindUnion {
+			return g.graphQLType(base, hint)
+		}
+	}
+	return g.scalar("JSON")
+}
+
+// graphQLFieldName returns the property name if it is a valid GraphQL name, otherwise invalid characters
+// are replaced by underscores.
+func graphQLFieldName(name string) string {
+	if graphQLName.MatchString(name) && !strings.HasPrefix(name, "__") {
+		return name
+	}
+	var b strings.Builder
+	for i, r := range name {
+		switch {
+		case r == '_' || r < 128 && (r >= 'A' && r <= 'Z' || r >= 'a' && r <= 'z'):
+			b.WriteRune(r)
+		case r >= '0' && r <= '9':
+			if i == 0 {
+				b.WriteRune('_')
+			}
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return "f" + strings.TrimLeft(b.String(), "_")
+}
+
+// graphQLEnumValue converts the enum value into UPPER_SNAKE_CASE, e.g. in-progress becomes IN_PROGRESS.
+func graphQLEnumValue(value string) string {
+	s := upperSnake(value)
+	switch {
+	case s == "":
+		return "EMPTY"
+	case s == "TRUE" || s == "FALSE" || s == "NULL" || s[0] >= '0' && s[0] <= '9':
+		return "_" + s
+	default:
+		return s
+	}
+}
+
+func writeGraphQLDescription(b *strings.Builder, indent, description string) {
+	if description == "" {
+		return
+	}
+	if !strings.Contains(description, "\n") {
+		fmt.Fprintf(b, "%s%s\n", indent, quote(description))
+		return
+	}
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+	for _, line := range strings.Split(strings.ReplaceAll(description, `"""`, `\"""`), "\n") {
+		if line == "" {
+			b.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(b, "%s%s\n", indent, line)
+	}
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+}
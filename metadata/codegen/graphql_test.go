@@ -0,0 +1,171 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func Test_GraphQL(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	files, err := g.GraphQL(r.Entities())
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, GraphQLFile, files[0].Name)
+	require.Equal(t, `# Code generated by cti gen graphql. DO NOT EDIT.
+
+"CTI: cti.x.y.event.v1.0"
+interface Event {
+  id: ID!
+  severity: EventSeverity
+  tags: [Tag!]
+}
+
+enum EventSeverity {
+  LOW
+  HIGH
+}
+
+"""
+User created
+
+CTI: cti.x.y.event.v1.0~x.y.user_created.v1.0
+"""
+type UserCreated implements Event {
+  id: ID!
+  login: String!
+  manager: Person
+  severity: EventSeverity
+  tags: [Tag!]
+}
+
+type Person {
+  name: String
+  reports: [Person!]
+}
+
+type Tag {
+  name: String!
+}
+`, string(files[0].Data))
+
+	// Without descendants the type is not abstract.
+	event, ok := r.GetType("cti.x.y.event.v1.0")
+	require.True(t, ok)
+	files, err = g.GraphQL(metadata.Entities{event})
+	require.NoError(t, err)
+	require.Contains(t, string(files[0].Data), "type Event {\n")
+}
+
+func Test_GraphQLInterfaces(t *testing.T) {
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.a.b.task.v1.0": {
+			Cti: "cti.a.b.task.v1.0",
+			Schema: []byte(`{
+				"$ref": "#/definitions/Task",
+				"definitions": {"Task": {
+					"type": "object",
+					"properties": {
+						"status": {"type": "string", "enum": ["in-progress", "done"]},
+						"at": {"type": "string", "format": "date-time"}
+					}
+				}}
+			}`),
+		},
+		"cti.a.b.task.v1.0~a.b.build.v1.0": {
+			Cti: "cti.a.b.task.v1.0~a.b.build.v1.0",
+			Schema: []byte(`{
+				"$ref": "#/definitions/Build",
+				"definitions": {"Build": {
+					"type": "object",
+					"properties": {
+						"status": {"type": "string", "enum": ["done"]},
+						"artifacts": {"type": "array", "items": {"type": "object", "properties": {"size": {"type": "integer", "format": "int64"}}}}
+					},
+					"required": ["status"]
+				}}
+			}`),
+		},
+		"cti.a.b.task.v1.0~a.b.build.v1.0~a.b.release.v1.0": {
+			Cti: "cti.a.b.task.v1.0~a.b.build.v1.0~a.b.release.v1.0",
+			Schema: []byte(`{
+				"$ref": "#/definitions/Release",
+				"definitions": {"Release": {
+					"type": "object",
+					"properties": {"notes": {"anyOf": [{"type": "string"}, {"type": "object"}]}}
+				}}
+			}`),
+		},
+	})
+	require.NoError(t, err)
+
+	files, err := New(r).GraphQL(r.Entities())
+	require.NoError(t, err)
+	require.Equal(t, `# Code generated by cti gen graphql. DO NOT EDIT.
+
+"Date and time in RFC 3339 format."
+scalar DateTime
+
+"Arbitrary JSON value."
+scalar JSON
+
+"64-bit integer."
+scalar Long
+
+"CTI: cti.a.b.task.v1.0"
+interface Task {
+  at: DateTime
+  status: TaskStatus
+}
+
+enum TaskStatus {
+  IN_PROGRESS
+  DONE
+}
+
+"CTI: cti.a.b.task.v1.0~a.b.build.v1.0"
+interface Build implements Task {
+  artifacts: [BuildArtifactsItem!]
+  at: DateTime
+  status: TaskStatus!
+}
+
+type BuildArtifactsItem {
+  size: Long
+}
+
+"CTI: cti.a.b.task.v1.0~a.b.build.v1.0~a.b.release.v1.0"
+type Release implements Task & Build {
+  artifacts: [BuildArtifactsItem!]
+  at: DateTime
+  notes: JSON
+  status: TaskStatus!
+}
+`, string(files[0].Data))
+}
+
+func Test_GraphQLNames(t *testing.T) {
+	testCases := []struct {
+		value string
+		field string
+		enum  string
+	}{
+		{value: "createdAt", field: "createdAt", enum: "CREATED_AT"},
+		{value: "a-b", field: "fa_b", enum: "A_B"},
+		{value: "2fa", field: "f2fa", enum: "_2FA"},
+		{value: "__type", field: "ftype", enum: "TYPE"},
+		{value: "true", field: "true", enum: "_TRUE"},
+		{value: "", field: "f", enum: "EMPTY"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			require.Equal(t, tc.field, graphQLFieldName(tc.value))
+			require.Equal(t, tc.enum, graphQLEnumValue(tc.value))
+		})
+	}
+}
@@ -0,0 +1,430 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+const (
+	JavaFileExt = ".java"
+
+	// JavaCtisClass is a name of the generated class with constants of CTIs.
+	JavaCtisClass = "Ctis"
+
+	// DefaultJavaPackage is a name of the generated package if not specified.
+	DefaultJavaPackage = "ctitypes"
+
+	// JavaStyleRecord and JavaStylePOJO are styles of generated classes: immutable records (Java 16+)
+	// or mutable classes with getters and setters.
+	JavaStyleRecord = "record"
+	JavaStylePOJO   = "pojo"
+
+	javaHeader = "// Code generated by cti gen java. DO NOT EDIT.\n"
+
+	jacksonPackage = "com.fasterxml.jackson.annotation."
+)
+
+// javaKeywords are reserved words that cannot be used as identifiers.
+var javaKeywords = map[string]bool{
+	"abstract": true, "assert": true, "boolean": true, "break": true, "byte": true, "case": true, "catch": true,
+	"char": true, "class": true, "const": true, "continue": true, "default": true, "do": true, "double": true,
+	"else": true, "enum": true, "extends": true, "final": true, "finally": true, "float": true, "for": true,
+	"goto": true, "if": true, "implements": true, "import": true, "instanceof": true, "int": true,
+	"interface": true, "long": true, "native": true, "new": true, "package": true, "private": true,
+	"protected": true, "public": true, "return": true, "short": true, "static": true, "strictfp": true,
+	"super": true, "switch": true, "synchronized": true, "this": true, "throw": true, "throws": true,
+	"transient": true, "try": true, "void": true, "volatile": true, "true": true, "false": true, "null": true,
+	"record": true, "var": true, "yield": true, "_": true,
+}
+
+// javaClassNames are names of classes used by generated classes, generated classes are renamed
+// to not shadow them.
+var javaClassNames = map[string]bool{
+	"Object": true, "String": true, "Long": true, "Double": true, "Boolean": true, "List": true, "Map": true,
+	"UUID": true, "LocalDate": true, "OffsetDateTime": true, "JsonProperty": true, "JsonInclude": true,
+	"JsonIgnoreProperties": true, JavaCtisClass: true,
+}
+
+// javaBoxed are wrapper classes of primitive types used for optional values and type arguments.
+var javaBoxed = map[string]string{"long": "Long", "double": "Double", "boolean": "Boolean"}
+
+// javaFormats are classes of JSON Schema string formats supported by jackson-datatype-jsr310.
+var javaFormats = map[string]string{
+	"date-time": "java.time.OffsetDateTime",
+	"date":      "java.time.LocalDate",
+	"uuid":      "java.util.UUID",
+}
+
+type JavaOptions struct {
+	// Package is a name of the generated package, e.g. com.example.events.
+	Package string
+	// Style is either record (default) or pojo.
+	Style string
+}
+
+// Java generates Java classes of the types with Jackson annotations, a class per file in the directory
+// of the package, and the Ctis class with constants of CTIs of the entities. Objects nested into properties
+// become separate classes named after the property, string enums become enums. Required properties of
+// primitive types are primitives, optional ones are wrappers. Unknown properties are ignored on
+// deserialization and null values are not serialized. Date and time formats require jackson-datatype-jsr310.
+func (g *Generator) Java(entities metadata.Entities, opts JavaOptions) ([]File, error) {
+	if opts.Package == "" {
+		opts.Package = DefaultJavaPackage
+	}
+	for _, part := range strings.Split(opts.Package, ".") {
+		if !javaIsIdentifier(part) {
+			return nil, fmt.Errorf("invalid package name %s", opts.Package)
+		}
+	}
+	switch opts.Style {
+	case "":
+		opts.Style = JavaStyleRecord
+	case JavaStyleRecord, JavaStylePOJO:
+	default:
+		return nil, fmt.Errorf("invalid style %s: must be %s or %s", opts.Style, JavaStyleRecord, JavaStylePOJO)
+	}
+
+	m, err := g.model(entities)
+	if err != nil {
+		return nil, err
+	}
+
+	j := &javaGenerator{
+		pkg:   opts.Package,
+		style: opts.Style,
+		used:  make(map[string]bool, len(m.types)+len(javaClassNames)),
+		names: make(map[*namedType]string, len(m.types)),
+	}
+	for name := range javaClassNames {
+		j.used[name] = true
+	}
+	for _, nt := range m.types {
+		j.names[nt] = uniqueName(nt.Name, j.used)
+	}
+	for _, nt := range m.types {
+		if !javaDeclared(nt.Expr) {
+			// Java has no type aliases, such types are inlined.
+			continue
+		}
+		var doc []string
+		if nt.Description != "" && nt.Description != nt.Name {
+			doc = append(doc, strings.Split(nt.Description, "\n")...)
+		}
+		if nt.Entity != nil {
+			if len(doc) > 0 {
+				doc = append(doc, "")
+			}
+			doc = append(doc, "CTI: "+nt.Entity.Cti)
+		}
+		j.generate(&javaClass{name: j.names[nt], doc: doc, expr: nt.Expr})
+	}
+
+	ctis := &javaSource{}
+	fmt.Fprintf(ctis, "/**\n * Constants of CTIs of entities.\n */\npublic final class %s {\n", JavaCtisClass)
+	constants := make(map[string]bool)
+	for _, c := range ctiConstants(m, entities, "", constants) {
+		if c.entity.DisplayName != "" {
+			writeJavaDoc(ctis, "    ", strings.Split(c.entity.DisplayName, "\n"))
+		}
+		fmt.Fprintf(ctis, "    public static final String %s = %s;\n\n", javaConstantName(c.name), quote(c.entity.Cti))
+	}
+	fmt.Fprintf(ctis, "    private %s() {\n    }\n}\n", JavaCtisClass)
+	j.files = append(j.files, ctis.file(opts.Package, JavaCtisClass))
+	return j.files, nil
+}
+
+// javaSource is a body of a generated Java file along with classes it imports.
+type javaSource struct {
+	strings.Builder
+	imports map[string]bool
+}
+
+// use imports the class and returns its simple name.
+func (s *javaSource) use(class string) string {
+	if s.imports == nil {
+		s.imports = make(map[string]bool)
+	}
+	s.imports[class] = true
+	return class[strings.LastIndex(class, ".")+1:]
+}
+
+func (s *javaSource) file(pkg, class string) File {
+	var b strings.Builder
+	b.WriteString(javaHeader)
+	fmt.Fprintf(&b, "\npackage %s;\n", pkg)
+	if len(s.imports) > 0 {
+		b.WriteString("\n")
+		for _, imp := range sortedKeys(s.imports) {
+			fmt.Fprintf(&b, "import %s;\n", imp)
+		}
+	}
+	b.WriteString("\n")
+	b.WriteString(s.String())
+	return File{Name: strings.ReplaceAll(pkg, ".", "/") + "/" + class + JavaFileExt, Data: []byte(b.String())}
+}
+
+// javaClass is a declaration of a generated class or enum.
+type javaClass struct {
+	name string
+	doc  []string
+	expr *typeExpr
+}
+
+type javaGenerator struct {
+	pkg, style string
+	// used holds names of classes, names maps named types to their classes.
+	used  map[string]bool
+	names map[*namedType]string
+	// inlined holds named types being inlined.
+	inlined map[*namedType]bool
+	// pending holds classes nested into the class being generated.
+	pending []*javaClass
+	files   []File
+}
+
+// generate writes the class followed by classes nested into it.
+func (j *javaGenerator) generate(c *javaClass) {
+	j.pending = nil
+	src := &javaSource{}
+	if c.expr.Kind == kindEnum {
+		j.writeEnum(src, c)
+	} else {
+		j.writeClass(src, c)
+	}
+	j.files = append(j.files, src.file(j.pkg, c.name))
+	nested := j.pending
+	for _, n := range nested {
+		j.generate(n)
+	}
+}
+
+// nest declares a class of the expression nested into the class being generated.
+func (j *javaGenerator) nest(hint string, e *typeExpr) string {
+	name := uniqueName(hint, j.used)
+	j.pending = append(j.pending, &javaClass{name: name, expr: e})
+	return name
+}
+
+// javaField is a property of a generated class.
+type javaField struct {
+	name, property, typ, doc string
+	required                 bool
+}
+
+func (j *javaGenerator) writeClass(src *javaSource, c *javaClass) {
+	names := make(map[string]bool, len(c.expr.Fields))
+	fields := make([]*javaField, 0, len(c.expr.Fields))
+	for _, f := range c.expr.Fields {
+		fields = append(fields, &javaField{
+			name:     uniqueName(javaFieldName(f.Name), names),
+			property: f.Name,
+			typ:      j.javaType(src, f.Expr, c.name+exportedName(f.Name), f.Required),
+			doc:      f.Description,
+			required: f.Required,
+		})
+	}
+
+	doc := c.doc
+	if j.style == JavaStyleRecord {
+		// Components of records are documented by tags of the record.
+		for _, f := range fields {
+			if f.doc == "" {
+				continue
+			}
+			if len(doc) > 0 && !strings.HasPrefix(doc[len(doc)-1], "@param") {
+				doc = append(doc, "")
+			}
+			lines := strings.Split(f.doc, "\n")
+			doc = append(doc, "@param "+f.name+" "+lines[0])
+			for _, line := range lines[1:] {
+				doc = append(doc, "       "+line)
+			}
+		}
+	}
+	writeJavaDoc(src, "", doc)
+	fmt.Fprintf(src, "@%s(JsonInclude.Include.NON_NULL)\n", src.use(jacksonPackage+"JsonInclude"))
+	fmt.Fprintf(src, "@%s(ignoreUnknown = true)\n", src.use(jacksonPackage+"JsonIgnoreProperties"))
+	property := src.use(jacksonPackage + "JsonProperty")
+	annotation := func(f *javaField) string {
+		if f.required {
+			return fmt.Sprintf("@%s(value = %s, required = true)", property, quote(f.property))
+		}
+		return fmt.Sprintf("@%s(%s)", property, quote(f.property))
+	}
+
+	if j.style == JavaStyleRecord {
+		fmt.Fprintf(src, "public record %s(", c.name)
+		for i, f := range fields {
+			if i > 0 {
+				src.WriteString(",")
+			}
+			fmt.Fprintf(src, "\n        %s %s %s", annotation(f), f.typ, f.name)
+		}
+		src.WriteString(") {\n}\n")
+		return
+	}
+
+	fmt.Fprintf(src, "public class %s {\n", c.name)
+	for _, f := range fields {
+		if f.doc != "" {
+			writeJavaDoc(src, "    ", strings.Split(f.doc, "\n"))
+		}
+		fmt.Fprintf(src, "    %s\n    private %s %s;\n\n", annotation(f), f.typ, f.name)
+	}
+	for i, f := range fields {
+		if i > 0 {
+			src.WriteString("\n")
+		}
+		accessor := strings.ToUpper(f.name[:1]) + f.name[1:]
+		getter := "get" + accessor
+		if f.typ == "boolean" {
+			getter = "is" + accessor
+		}
+		fmt.Fprintf(src, "    public %s %s() {\n        return %s;\n    }\n\n", f.typ, getter, f.name)
+		fmt.Fprintf(src, "    public void set%s(%s %s) {\n        this.%s = %s;\n    }\n", accessor, f.typ, f.name, f.name, f.name)
+	}
+	src.WriteString("}\n")
+}
+
+func (j *javaGenerator) writeEnum(src *javaSource, c *javaClass) {
+	property := src.use(jacksonPackage + "JsonProperty")
+	writeJavaDoc(src, "", c.doc)
+	fmt.Fprintf(src, "public enum %s {\n", c.name)
+	names := make(map[string]bool, len(c.expr.Enum))
+	for i, v := range c.expr.Enum {
+		s, _ := v.(string)
+		if i > 0 {
+			src.WriteString(",\n")
+		}
+		fmt.Fprintf(src, "    @%s(%s)\n    %s", property, quote(s), uniqueName(javaConstantName(s), names))
+	}
+	src.WriteString("\n}\n")
+}
+
+// javaType returns the Java type of the expression. Nested objects and string enums are declared
+// as separate classes named after the hint. Required values of primitive types are primitives.
+func (j *javaGenerator) javaType(src *javaSource, e *typeExpr, hint string, required bool) string {
+	primitive := func(t string) string {
+		if required {
+			return t
+		}
+		return javaBoxed[t]
+	}
+	switch e.Kind {
+	case kindString:
+		if class, ok := javaFormats[e.format()]; ok {
+			return src.use(class)
+		}
+		return "String"
+	case kindInteger:
+		return primitive("long")
+	case kindNumber:
+		return primitive("double")
+	case kindBoolean:
+		return primitive("boolean")
+	case kindRef:
+		if javaDeclared(e.Ref.Expr) {
+			return j.names[e.Ref]
+		}
+		if j.inlined[e.Ref] {
+			return "Object"
+		}
+		if j.inlined == nil {
+			j.inlined = make(map[*namedType]bool)
+		}
+		j.inlined[e.Ref] = true
+		defer delete(j.inlined, e.Ref)
+		return j.javaType(src, e.Ref.Expr, hint, required)
+	case kindArray:
+		return src.use("java.util.List") + "<" + j.javaType(src, e.Items, hint+"Item", false) + ">"
+	case kindMap:
+		return src.use("java.util.Map") + "<String, " + j.javaType(src, e.Values, hint+"Value", false) + ">"
+	case kindObject:
+		if len(e.Fields) > 0 {
+			return j.nest(hint, e)
+		}
+		values := "Object"
+		if e.Values != nil {
+			values = j.javaType(src, e.Values, hint+"Value", false)
+		}
+		return src.use("java.util.Map") + "<String, " + values + ">"
+	case kindEnum:
+		switch e.Base {
+		case kindString:
+			return j.nest(hint, e)
+		case kindInteger:
+			return primitive("long")
+		case kindNumber:
+			return primitive("double")
+		case kindBoolean:
+			return primitive("boolean")
+		default:
+			return "Object"
+		}
+	case kindUnion:
+		if base, nullable := e.nullable(); nullable && base.Kind != kindUnion {
+			return j.javaType(src, base, hint, false)
+		}
+		return "Object"
+	default:
+		return "Object"
+	}
+}
+
+// javaDeclared tells whether the expression is declared as a class or an enum.
+func javaDeclared(e *typeExpr) bool {
+	return e.Kind == kindObject && len(e.Fields) > 0 || e.Kind == kindEnum && e.Base == kindString
+}
+
+// javaFieldName converts the property name into camelCase, e.g. created_at becomes createdAt.
+// Leading acronyms are lower case, e.g. URLPath becomes urlPath.
+func javaFieldName(name string) string {
+	runes := []rune(exportedName(name))
+	for i := range runes {
+		if i > 0 && i+1 < len(runes) && unicode.IsLower(runes[i+1]) || !unicode.IsUpper(runes[i]) {
+			break
+		}
+		runes[i] = unicode.ToLower(runes[i])
+	}
+	s := string(runes)
+	if javaKeywords[s] {
+		s += "_"
+	}
+	return s
+}
+
+// javaConstantName converts the value into UPPER_SNAKE_CASE, e.g. in-progress becomes IN_PROGRESS.
+func javaConstantName(value string) string {
+	s := upperSnake(value)
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "VALUE_" + s
+	}
+	return strings.TrimSuffix(s, "_")
+}
+
+func javaIsIdentifier(s string) bool {
+	if s == "" || javaKeywords[s] {
+		return false
+	}
+	for i, r := range s {
+		if !unicode.IsLetter(r) && r != '_' && r != '$' && (i == 0 || !unicode.IsDigit(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func writeJavaDoc(b *javaSource, indent string, lines []string) {
+	if len(lines) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		line = strings.ReplaceAll(line, "*/", "*&#47;")
+		fmt.Fprintf(b, "%s%s\n", indent, strings.TrimRight(" * "+line, " "))
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
@@ -0,0 +1,206 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func javaFiles(files []File) map[string]string {
+	res := make(map[string]string, len(files))
+	for _, f := range files {
+		res[f.Name] = string(f.Data)
+	}
+	return res
+}
+
+func Test_Java(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	files, err := g.Java(r.Entities(), JavaOptions{Package: "com.example.events"})
+	require.NoError(t, err)
+	sources := javaFiles(files)
+	require.ElementsMatch(t, []string{
+		"com/example/events/Ctis.java",
+		"com/example/events/Event.java",
+		"com/example/events/EventSeverity.java",
+		"com/example/events/Person.java",
+		"com/example/events/Tag.java",
+		"com/example/events/UserCreated.java",
+		"com/example/events/UserCreatedSeverity.java",
+	}, sortedKeys(sources))
+
+	require.Equal(t, `// Code generated by cti gen java. DO NOT EDIT.
+
+package com.example.events;
+
+import com.fasterxml.jackson.annotation.JsonIgnoreProperties;
+import com.fasterxml.jackson.annotation.JsonInclude;
+import com.fasterxml.jackson.annotation.JsonProperty;
+import java.util.List;
+import java.util.UUID;
+
+/**
+ * User created
+ *
+ * CTI: cti.x.y.event.v1.0~x.y.user_created.v1.0
+ */
+@JsonInclude(JsonInclude.Include.NON_NULL)
+@JsonIgnoreProperties(ignoreUnknown = true)
+public record UserCreated(
+        @JsonProperty(value = "id", required = true) UUID id,
+        @JsonProperty(value = "login", required = true) String login,
+        @JsonProperty("manager") Person manager,
+        @JsonProperty("severity") UserCreatedSeverity severity,
+        @JsonProperty("tags") List<Tag> tags) {
+}
+`, sources["com/example/events/UserCreated.java"])
+
+	require.Equal(t, `// Code generated by cti gen java. DO NOT EDIT.
+
+package com.example.events;
+
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+public enum EventSeverity {
+    @JsonProperty("low")
+    LOW,
+    @JsonProperty("high")
+    HIGH
+}
+`, sources["com/example/events/EventSeverity.java"])
+
+	require.Equal(t, `// Code generated by cti gen java. DO NOT EDIT.
+
+package com.example.events;
+
+/**
+ * Constants of CTIs of entities.
+ */
+public final class Ctis {
+    public static final String EVENT = "cti.x.y.event.v1.0";
+
+    /**
+     * User created
+     */
+    public static final String USER_CREATED = "cti.x.y.event.v1.0~x.y.user_created.v1.0";
+
+    public static final String SAMPLE = "cti.x.y.event.v1.0~x.y.user_created.v1.0~x.y.sample.v1.0";
+
+    private Ctis() {
+    }
+}
+`, sources["com/example/events/Ctis.java"])
+
+	files, err = g.Java(r.Entities(), JavaOptions{Style: JavaStylePOJO})
+	require.NoError(t, err)
+	require.Equal(t, `// Code generated by cti gen java. DO NOT EDIT.
+
+package ctitypes;
+
+import com.fasterxml.jackson.annotation.JsonIgnoreProperties;
+import com.fasterxml.jackson.annotation.JsonInclude;
+import com.fasterxml.jackson.annotation.JsonProperty;
+
+@JsonInclude(JsonInclude.Include.NON_NULL)
+@JsonIgnoreProperties(ignoreUnknown = true)
+public class Tag {
+    @JsonProperty(value = "name", required = true)
+    private String name;
+
+    public String getName() {
+        return name;
+    }
+
+    public void setName(String name) {
+        this.name = name;
+    }
+}
+`, javaFiles(files)["ctitypes/Tag.java"])
+}
+
+func Test_JavaTypes(t *testing.T) {
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.a.b.list.v1.0": {
+			Cti:         "cti.a.b.list.v1.0",
+			Description: "List of */ items.",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/List",
+				"definitions": {
+					"List": {
+						"type": "object",
+						"properties": {
+							"URLPath": {"type": "string", "description": "Path.\nRelative."},
+							"class": {"$ref": "#/definitions/Name"},
+							"count": {"type": "integer"},
+							"created": {"type": "string", "format": "date-time"},
+							"enabled": {"type": "boolean"},
+							"labels": {"type": "object", "additionalProperties": {"type": "number"}},
+							"options": {"type": "object", "properties": {"level": {"type": ["integer", "null"]}}},
+							"ratio": {"type": "number"},
+							"value": {"anyOf": [{"type": "string"}, {"type": "integer"}]}
+						},
+						"required": ["count", "enabled"]
+					},
+					"Name": {"type": "string", "minLength": 1}
+				}
+			}`),
+		},
+	})
+	require.NoError(t, err)
+	g := New(r)
+
+	files, err := g.Java(r.Entities(), JavaOptions{})
+	require.NoError(t, err)
+	sources := javaFiles(files)
+	require.ElementsMatch(t, []string{"ctitypes/Ctis.java", "ctitypes/List2.java", "ctitypes/List2Options.java"}, sortedKeys(sources))
+	require.Equal(t, `// Code generated by cti gen java. DO NOT EDIT.
+
+package ctitypes;
+
+import com.fasterxml.jackson.annotation.JsonIgnoreProperties;
+import com.fasterxml.jackson.annotation.JsonInclude;
+import com.fasterxml.jackson.annotation.JsonProperty;
+import java.time.OffsetDateTime;
+import java.util.Map;
+
+/**
+ * List of *&#47; items.
+ *
+ * CTI: cti.a.b.list.v1.0
+ *
+ * @param urlPath Path.
+ *        Relative.
+ */
+@JsonInclude(JsonInclude.Include.NON_NULL)
+@JsonIgnoreProperties(ignoreUnknown = true)
+public record List2(
+        @JsonProperty("URLPath") String urlPath,
+        @JsonProperty("class") String class_,
+        @JsonProperty(value = "count", required = true) long count,
+        @JsonProperty("created") OffsetDateTime created,
+        @JsonProperty(value = "enabled", required = true) boolean enabled,
+        @JsonProperty("labels") Map<String, Double> labels,
+        @JsonProperty("options") List2Options options,
+        @JsonProperty("ratio") Double ratio,
+        @JsonProperty("value") Object value) {
+}
+`, sources["ctitypes/List2.java"])
+
+	files, err = g.Java(r.Entities(), JavaOptions{Style: JavaStylePOJO})
+	require.NoError(t, err)
+	pojo := javaFiles(files)["ctitypes/List2.java"]
+	require.Contains(t, pojo, "    public boolean isEnabled() {\n")
+	require.Contains(t, pojo, "    public void setClass_(String class_) {\n        this.class_ = class_;\n    }\n")
+	require.Contains(t, javaFiles(files)["ctitypes/List2Options.java"], "    private Long level;\n")
+
+	_, err = g.Java(r.Entities(), JavaOptions{Package: "com.example.class"})
+	require.ErrorContains(t, err, "invalid package name com.example.class")
+	_, err = g.Java(r.Entities(), JavaOptions{Style: "bean"})
+	require.ErrorContains(t, err, "invalid style bean: must be record or pojo")
+}
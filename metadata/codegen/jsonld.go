@@ -0,0 +1,384 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+const (
+	JSONLDContextFile = "context.jsonld"
+	OntologyFile      = "ontology.ttl"
+
+	// DefaultIRIBase is a prefix of IRIs of CTIs if not specified, e.g. urn:cti:cti.x.y.event.v1.0.
+	DefaultIRIBase = "urn:cti:"
+
+	xsdNamespace  = "http://www.w3.org/2001/XMLSchema#"
+	rdfNamespace  = "http://www.w3.org/1999/02/22-rdf-syntax-ns#"
+	rdfsNamespace = "http://www.w3.org/2000/01/rdf-schema#"
+	owlNamespace  = "http://www.w3.org/2002/07/owl#"
+
+	// domainExtensionPrefix is a prefix of annotations in x-custom of schemas.
+	domainExtensionPrefix = "x-domainExt-"
+)
+
+type JSONLDOptions struct {
+	// Base is a prefix of IRIs of CTIs. IRIs of types and instances are the base followed by their CTIs,
+	// IRIs of properties are IRIs of types that declare them followed by # and paths to them.
+	Base string
+	// Ontology generates an OWL ontology with classes of the types, their properties and instances.
+	Ontology bool
+}
+
+// JSONLD generates a JSON-LD context with terms of the types and type-scoped contexts with terms of their
+// properties, so values of the types can be interpreted as linked data once typed. Properties annotated
+// with cti.id or cti.reference hold CTIs and are interpreted as IRIs. Objects without properties, maps
+// and unions are kept as JSON literals. Optionally, an OWL ontology in Turtle is generated as well.
+func (g *Generator) JSONLD(entities metadata.Entities, opts JSONLDOptions) ([]File, error) {
+	if opts.Base == "" {
+		opts.Base = DefaultIRIBase
+	}
+	if !strings.Contains(opts.Base, ":") {
+		return nil, fmt.Errorf("invalid base IRI %s: must be absolute", opts.Base)
+	}
+
+	m, err := g.model(entities)
+	if err != nil {
+		return nil, err
+	}
+	// Properties belong to the root-most ancestors that declare them, ancestors may be not generated.
+	types := make(map[string]*metadata.Entity)
+	for _, typ := range sortedTypes(entities) {
+		types[typ.Cti] = typ
+		for _, ancestor := range g.registry.Ancestors(typ.Cti) {
+			types[ancestor.Cti] = ancestor
+		}
+	}
+	all := make(metadata.Entities, 0, len(types))
+	for _, typ := range types {
+		all = append(all, typ)
+	}
+	declaring, err := g.model(all)
+	if err != nil {
+		return nil, err
+	}
+
+	l := &linkedDataGenerator{
+		base:       opts.Base,
+		registry:   g.registry,
+		exprs:      make(map[string]*typeExpr),
+		properties: make(map[string]*rdfProperty),
+	}
+	for _, nt := range declaring.types {
+		if nt.Entity != nil {
+			l.exprs[nt.Entity.Cti] = nt.Expr
+		}
+	}
+
+	context := map[string]any{
+		"@version": 1.1,
+		"@vocab":   opts.Base,
+		"xsd":      xsdNamespace,
+	}
+	for _, nt := range m.types {
+		if nt.Entity == nil {
+			continue
+		}
+		term := map[string]any{"@id": l.iri(nt.Entity.Cti)}
+		if scoped := l.context(nt.Entity, nt.Expr, nil, nil); len(scoped) > 0 {
+			term["@context"] = scoped
+		}
+		context[nt.Name] = term
+	}
+	data, err := json.MarshalIndent(map[string]any{"@context": context}, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("serialize context: %w", err)
+	}
+	files := []File{{Name: JSONLDContextFile, Data: append(data, '\n')}}
+
+	if opts.Ontology {
+		files = append(files, File{Name: OntologyFile, Data: []byte(l.ontology(m, entities))})
+	}
+	return files, nil
+}
+
+type linkedDataGenerator struct {
+	base     string
+	registry *registry.Registry
+	// exprs holds expressions of generated types and their ancestors by CTIs.
+	exprs map[string]*typeExpr
+	// properties holds properties of terms by IRIs.
+	properties map[string]*rdfProperty
+}
+
+// rdfProperty is a property of the ontology.
+type rdfProperty struct {
+	label string
+	// domain is set for properties of types, range is set for typed values and references as Turtle terms.
+	domain string
+	rng    string
+	object bool
+}
+
+func (l *linkedDataGenerator) iri(cti string) string {
+	return l.base + cti
+}
+
+// context returns terms of properties of the object at the path of the type. Objects referred recursively
+// get no context since property-scoped contexts propagate to nested objects.
+func (l *linkedDataGenerator) context(typ *metadata.Entity, e *typeExpr, path []string, stack map[*namedType]bool) map[string]any {
+	for e.Kind != kindObject {
+		switch e.Kind {
+		case kindRef:
+			if stack[e.Ref] {
+				return nil
+			}
+			nested := make(map[*namedType]bool, len(stack)+1)
+			for nt := range stack {
+				nested[nt] = true
+			}
+			nested[e.Ref] = true
+			stack, e = nested, e.Ref.Expr
+		case kindArray:
+			e = e.Items
+		case kindUnion:
+			base, nullable := e.nullable()
+			if !nullable || base.Kind == kindUnion {
+				return nil
+			}
+			e = base
+		default:
+			return nil
+		}
+	}
+
+	res := make(map[string]any, len(e.Fields))
+	for _, f := range e.Fields {
+		fieldPath := append(append([]string(nil), path...), f.Name)
+		owner := l.owner(typ, fieldPath)
+		iri := l.iri(owner) + "#" + escapePath(fieldPath)
+		p := &rdfProperty{label: f.Name}
+		if len(fieldPath) == 1 {
+			p.domain = l.iri(owner)
+		}
+
+		term := map[string]any{"@id": iri}
+		value := valueOf(f.Expr)
+		switch reference, ok := annotation(f.Expr.Schema, metadata.ID, metadata.Reference); {
+		case ok:
+			// Values are CTIs, they are expanded into IRIs with the vocabulary.
+			term["@type"] = "@vocab"
+			p.object = true
+			if cti, isCti := reference.(string); isCti {
+				p.rng = "<" + l.iri(cti) + ">"
+			}
+		case value.Kind == kindObject && len(value.Fields) > 0:
+			p.object = true
+			if scoped := l.context(typ, f.Expr, fieldPath, stack); len(scoped) > 0 {
+				term["@context"] = scoped
+			}
+		case value.Kind == kindString && value.format() == "uri":
+			term["@type"] = "@id"
+			p.object = true
+		case value.Kind == kindString, value.Kind == kindInteger, value.Kind == kindNumber,
+			value.Kind == kindBoolean, value.Kind == kindEnum:
+			p.rng = xsdType(value)
+			if dt, ok := xsdFormats[value.format()]; ok && value.Kind == kindString {
+				term["@type"] = dt
+			}
+		default:
+			term["@type"] = "@json"
+			p.rng = "rdf:JSON"
+		}
+		res[f.Name] = term
+		if _, ok := l.properties[iri]; !ok {
+			l.properties[iri] = p
+		}
+	}
+	return res
+}
+
+// owner returns the CTI of the root-most ancestor of the type, or the type itself, that declares the property
+// at the path.
+func (l *linkedDataGenerator) owner(typ *metadata.Entity, path []string) string {
+	ancestors := l.registry.Ancestors(typ.Cti)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		if e, ok := l.exprs[ancestors[i].Cti]; ok && hasPath(e, path) {
+			return ancestors[i].Cti
+		}
+	}
+	return typ.Cti
+}
+
+// hasPath tells whether the expression has the property at the path. Arrays and references are transparent.
+func hasPath(e *typeExpr, path []string) bool {
+	for _, name := range path {
+		e = valueOf(e)
+		var next *typeExpr
+		if e.Kind == kindObject {
+			for _, f := range e.Fields {
+				if f.Name == name {
+					next = f.Expr
+					break
+				}
+			}
+		}
+		if next == nil {
+			return false
+		}
+		e = next
+	}
+	return true
+}
+
+// valueOf returns the expression of values, i.e. the expression with references resolved, arrays replaced
+// by items and null removed from unions.
+func valueOf(e *typeExpr) *typeExpr {
+	seen := make(map[*typeExpr]bool)
+	for !seen[e] {
+		seen[e] = true
+		switch e.Kind {
+		case kindRef:
+			if e.Ref.Expr == nil {
+				return e
+			}
+			e = e.Ref.Expr
+		case kindArray:
+			e = e.Items
+		case kindUnion:
+			base, nullable := e.nullable()
+			if !nullable || base.Kind == kindUnion {
+				return e
+			}
+			e = base
+		default:
+			return e
+		}
+	}
+	return e
+}
+
+// annotation returns the value of the first of the annotations the schema has.
+func annotation(schema map[string]any, names ...string) (any, bool) {
+	custom, _ := schema[metadata.CustomKey].(map[string]any)
+	for _, name := range names {
+		if v, ok := custom[domainExtensionPrefix+name]; ok && v != false {
+			return v, true
+		}
+	}
+	return nil, false
+}
+
+// xsdFormats are XML Schema datatypes of JSON Schema string formats.
+var xsdFormats = map[string]string{
+	"date-time": "xsd:dateTime",
+	"date":      "xsd:date",
+	"time":      "xsd:time",
+}
+
+func xsdType(e *typeExpr) string {
+	k := e.Kind
+	if k == kindEnum {
+		k = e.Base
+	}
+	switch k {
+	case kindString:
+		if dt, ok := xsdFormats[e.format()]; ok {
+			return dt
+		}
+		return "xsd:string"
+	case kindInteger:
+		return "xsd:integer"
+	case kindNumber:
+		return "xsd:double"
+	case kindBoolean:
+		return "xsd:boolean"
+	default:
+		return ""
+	}
+}
+
+func escapePath(path []string) string {
+	escaped := make([]string, 0, len(path))
+	for _, name := range path {
+		escaped = append(escaped, url.PathEscape(name))
+	}
+	return strings.Join(escaped, "/")
+}
+
+// ontology renders the OWL ontology in Turtle. Types are classes, subclasses of their parents, properties
+// are datatype or object properties, instances are named individuals of their types.
+func (l *linkedDataGenerator) ontology(m *typeModel, entities metadata.Entities) string {
+	var b strings.Builder
+	for _, prefix := range []struct{ name, iri string }{
+		{"owl", owlNamespace}, {"rdf", rdfNamespace}, {"rdfs", rdfsNamespace}, {"xsd", xsdNamespace},
+	} {
+		fmt.Fprintf(&b, "@prefix %s: <%s> .\n", prefix.name, prefix.iri)
+	}
+
+	for _, nt := range m.types {
+		if nt.Entity == nil {
+			continue
+		}
+		statements := []string{"a owl:Class"}
+		if parent := metadata.GetParentCti(nt.Entity.Cti); parent != nt.Entity.Cti {
+			statements = append(statements, "rdfs:subClassOf <"+l.iri(parent)+">")
+		}
+		statements = append(statements, entityLabels(nt.Entity)...)
+		writeTurtle(&b, l.iri(nt.Entity.Cti), statements)
+	}
+
+	iris := sortedKeys(l.properties)
+	for _, iri := range iris {
+		p := l.properties[iri]
+		statements := []string{"a owl:DatatypeProperty"}
+		if p.object {
+			statements[0] = "a owl:ObjectProperty"
+		}
+		statements = append(statements, "rdfs:label "+quote(p.label))
+		if p.domain != "" {
+			statements = append(statements, "rdfs:domain <"+p.domain+">")
+		}
+		if p.rng != "" {
+			statements = append(statements, "rdfs:range "+p.rng)
+		}
+		writeTurtle(&b, iri, statements)
+	}
+
+	var instances metadata.Entities
+	for _, entity := range entities {
+		if entity.IsInstance() {
+			instances = append(instances, entity)
+		}
+	}
+	sort.Slice(instances, func(a, b int) bool {
+		return instances[a].Cti < instances[b].Cti
+	})
+	for _, instance := range instances {
+		statements := []string{"a owl:NamedIndividual, <" + l.iri(metadata.GetParentCti(instance.Cti)) + ">"}
+		statements = append(statements, entityLabels(instance)...)
+		writeTurtle(&b, l.iri(instance.Cti), statements)
+	}
+	return b.String()
+}
+
+func entityLabels(entity *metadata.Entity) []string {
+	var res []string
+	if entity.DisplayName != "" {
+		res = append(res, "rdfs:label "+quote(entity.DisplayName))
+	}
+	if entity.Description != "" {
+		res = append(res, "rdfs:comment "+quote(entity.Description))
+	}
+	return res
+}
+
+func writeTurtle(b *strings.Builder, subject string, statements []string) {
+	fmt.Fprintf(b, "\n<%s> %s", subject, strings.Join(statements, " ;\n    "))
+	b.WriteString(" .\n")
+}
@@ -0,0 +1,130 @@
+package codegen
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func Test_JSONLD(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	files, err := g.JSONLD(r.Entities(), JSONLDOptions{})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, JSONLDContextFile, files[0].Name)
+
+	var doc map[string]map[string]any
+	require.NoError(t, json.Unmarshal(files[0].Data, &doc))
+	context := doc["@context"]
+	require.Equal(t, 1.1, context["@version"])
+	require.Equal(t, "urn:cti:", context["@vocab"])
+
+	userCreated := context["UserCreated"].(map[string]any)
+	require.Equal(t, "urn:cti:cti.x.y.event.v1.0~x.y.user_created.v1.0", userCreated["@id"])
+	scoped := userCreated["@context"].(map[string]any)
+	// Inherited properties are declared by the ancestor.
+	require.Equal(t, map[string]any{"@id": "urn:cti:cti.x.y.event.v1.0#id", "@type": "@vocab"}, scoped["id"])
+	require.Equal(t, map[string]any{"@id": "urn:cti:cti.x.y.event.v1.0~x.y.user_created.v1.0#login"}, scoped["login"])
+	// Recursive objects rely on propagation of property-scoped contexts.
+	require.Equal(t, map[string]any{
+		"@id": "urn:cti:cti.x.y.event.v1.0~x.y.user_created.v1.0#manager",
+		"@context": map[string]any{
+			"name":    map[string]any{"@id": "urn:cti:cti.x.y.event.v1.0~x.y.user_created.v1.0#manager/name"},
+			"reports": map[string]any{"@id": "urn:cti:cti.x.y.event.v1.0~x.y.user_created.v1.0#manager/reports"},
+		},
+	}, scoped["manager"])
+
+	_, err = g.JSONLD(r.Entities(), JSONLDOptions{Base: "cti"})
+	require.EqualError(t, err, "invalid base IRI cti: must be absolute")
+}
+
+func Test_JSONLDOntology(t *testing.T) {
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.a.b.topic.v1.0": {
+			Cti:         "cti.a.b.topic.v1.0",
+			DisplayName: "Topic",
+			Description: `Topic of "events".`,
+			Schema:      []byte(`{"$ref": "#/definitions/Topic", "definitions": {"Topic": {"type": "object", "properties": {"id": {"type": "string", "x-custom": {"x-domainExt-cti.id": true}}}}}}`),
+		},
+		"cti.a.b.topic.v1.0~a.b.users.v1.0": {
+			Cti:         "cti.a.b.topic.v1.0~a.b.users.v1.0",
+			DisplayName: "Users",
+			Values:      []byte(`{"id": "cti.a.b.topic.v1.0~a.b.users.v1.0"}`),
+		},
+		"cti.a.b.event.v1.0": {
+			Cti: "cti.a.b.event.v1.0",
+			Schema: []byte(`{"$ref": "#/definitions/Event", "definitions": {"Event": {"type": "object", "properties": {
+				"topic": {"type": "string", "x-custom": {"x-domainExt-cti.reference": "cti.a.b.topic.v1.0"}},
+				"at": {"type": "string", "format": "date-time"},
+				"link": {"type": "string", "format": "uri"},
+				"count": {"type": ["integer", "null"]},
+				"data": {"type": "object"}
+			}}}}`),
+		},
+	})
+	require.NoError(t, err)
+
+	files, err := New(r).JSONLD(r.Entities(), JSONLDOptions{Base: "https://example.com/cti/", Ontology: true})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	var doc map[string]map[string]any
+	require.NoError(t, json.Unmarshal(files[0].Data, &doc))
+	require.Equal(t, map[string]any{
+		"at":    map[string]any{"@id": "https://example.com/cti/cti.a.b.event.v1.0#at", "@type": "xsd:dateTime"},
+		"count": map[string]any{"@id": "https://example.com/cti/cti.a.b.event.v1.0#count"},
+		"data":  map[string]any{"@id": "https://example.com/cti/cti.a.b.event.v1.0#data", "@type": "@json"},
+		"link":  map[string]any{"@id": "https://example.com/cti/cti.a.b.event.v1.0#link", "@type": "@id"},
+		"topic": map[string]any{"@id": "https://example.com/cti/cti.a.b.event.v1.0#topic", "@type": "@vocab"},
+	}, doc["@context"]["Event"].(map[string]any)["@context"])
+
+	require.Equal(t, OntologyFile, files[1].Name)
+	require.Equal(t, `@prefix owl: <http://www.w3.org/2002/07/owl#> .
+@prefix rdf: <http://www.w3.org/1999/02/22-rdf-syntax-ns#> .
+@prefix rdfs: <http://www.w3.org/2000/01/rdf-schema#> .
+@prefix xsd: <http://www.w3.org/2001/XMLSchema#> .
+
+<https://example.com/cti/cti.a.b.event.v1.0> a owl:Class .
+
+<https://example.com/cti/cti.a.b.topic.v1.0> a owl:Class ;
+    rdfs:label "Topic" ;
+    rdfs:comment "Topic of \"events\"." .
+
+<https://example.com/cti/cti.a.b.event.v1.0#at> a owl:DatatypeProperty ;
+    rdfs:label "at" ;
+    rdfs:domain <https://example.com/cti/cti.a.b.event.v1.0> ;
+    rdfs:range xsd:dateTime .
+
+<https://example.com/cti/cti.a.b.event.v1.0#count> a owl:DatatypeProperty ;
+    rdfs:label "count" ;
+    rdfs:domain <https://example.com/cti/cti.a.b.event.v1.0> ;
+    rdfs:range xsd:integer .
+
+<https://example.com/cti/cti.a.b.event.v1.0#data> a owl:DatatypeProperty ;
+    rdfs:label "data" ;
+    rdfs:domain <https://example.com/cti/cti.a.b.event.v1.0> ;
+    rdfs:range rdf:JSON .
+
+<https://example.com/cti/cti.a.b.event.v1.0#link> a owl:ObjectProperty ;
+    rdfs:label "link" ;
+    rdfs:domain <https://example.com/cti/cti.a.b.event.v1.0> .
+
+<https://example.com/cti/cti.a.b.event.v1.0#topic> a owl:ObjectProperty ;
+    rdfs:label "topic" ;
+    rdfs:domain <https://example.com/cti/cti.a.b.event.v1.0> ;
+    rdfs:range <https://example.com/cti/cti.a.b.topic.v1.0> .
+
+<https://example.com/cti/cti.a.b.topic.v1.0#id> a owl:ObjectProperty ;
+    rdfs:label "id" ;
+    rdfs:domain <https://example.com/cti/cti.a.b.topic.v1.0> .
+
+<https://example.com/cti/cti.a.b.topic.v1.0~a.b.users.v1.0> a owl:NamedIndividual, <https://example.com/cti/cti.a.b.topic.v1.0> ;
+    rdfs:label "Users" .
+`, string(files[1].Data))
+}
@@ -0,0 +1,435 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+// JSONSchemaExt is an extension of generated JSON Schema documents.
+const JSONSchemaExt = ".schema.json"
+
+// Draft is a version of JSON Schema specification.
+type Draft string
+
+const (
+	Draft7      Draft = "draft-07"
+	Draft201909 Draft = "2019-09"
+	Draft202012 Draft = "2020-12"
+)
+
+var draftURIs = map[Draft]string{
+	Draft7:      "http://json-schema.org/draft-07/schema#",
+	Draft201909: "https://json-schema.org/draft/2019-09/schema",
+	Draft202012: "https://json-schema.org/draft/2020-12/schema",
+}
+
+// Layout defines how generated schemas are arranged into documents.
+type Layout string
+
+const (
+	// LayoutType writes a document per type.
+	LayoutType Layout = "type"
+	// LayoutPackage writes a document per package with types kept in definitions by their CTI.
+	LayoutPackage Layout = "package"
+)
+
+type JSONSchemaOptions struct {
+	Draft  Draft
+	Layout Layout
+	// Dereference inlines referenced definitions. Recursive definitions cannot be inlined and are kept.
+	Dereference bool
+}
+
+// JSONSchema generates self-contained JSON Schema documents of the types. Schemas are merged with ancestors,
+// referenced definitions are bundled into each document and RAML extensions (x-custom, etc.) are removed.
+func (g *Generator) JSONSchema(entities metadata.Entities, opts JSONSchemaOptions) ([]File, error) {
+	if opts.Draft == "" {
+		opts.Draft = Draft7
+	}
+	if opts.Layout == "" {
+		opts.Layout = LayoutType
+	}
+	draftURI, ok := draftURIs[opts.Draft]
+	if !ok {
+		return nil, fmt.Errorf("unsupported draft %s", opts.Draft)
+	}
+	defsKey := "$defs"
+	if opts.Draft == Draft7 {
+		defsKey = "definitions"
+	}
+
+	var files []File
+	packages := make(map[string]*packageDocument)
+	var packageKeys []string
+	for _, typ := range sortedTypes(entities) {
+		root, defs, err := g.bundle(typ, opts.Dereference)
+		if err != nil {
+			return nil, err
+		}
+
+		switch opts.Layout {
+		case LayoutType:
+			finalizeSchema(root, defsKey, nil)
+			if len(defs) > 0 {
+				bundled := make(map[string]any, len(defs))
+				for name, def := range defs {
+					finalizeSchema(def, defsKey, nil)
+					bundled[name] = def
+				}
+				root[defsKey] = bundled
+			}
+			translateSchema(root, opts.Draft)
+			root["$schema"] = draftURI
+			data, err := marshalDocument(root)
+			if err != nil {
+				return nil, fmt.Errorf("serialize %s: %w", typ.Cti, err)
+			}
+			files = append(files, File{Name: typ.Cti + JSONSchemaExt, Data: data})
+		case LayoutPackage:
+			vendor, pkg := metadata.GetVendorAndPackage(typ.Cti)
+			key := registry.PackageKey(vendor, pkg)
+			doc, ok := packages[key]
+			if !ok {
+				doc = &packageDocument{defs: make(map[string]any)}
+				packages[key] = doc
+				packageKeys = append(packageKeys, key)
+			}
+			doc.add(typ.Cti, root, defs, defsKey)
+		default:
+			return nil, fmt.Errorf("unsupported layout %s", opts.Layout)
+		}
+	}
+
+	for _, key := range packageKeys {
+		doc := map[string]any{
+			"$schema":  draftURI,
+			"$comment": "CTI package " + key,
+			defsKey:    packages[key].defs,
+		}
+		translateSchema(doc, opts.Draft)
+		data, err := marshalDocument(doc)
+		if err != nil {
+			return nil, fmt.Errorf("serialize %s: %w", key, err)
+		}
+		files = append(files, File{Name: key + JSONSchemaExt, Data: data})
+	}
+	return files, nil
+}
+
+// bundle returns a copy of the merged schema of the type and definitions it refers to.
+func (g *Generator) bundle(typ *metadata.Entity, deref bool) (map[string]any, map[string]map[string]any, error) {
+	rt, err := g.resolve(typ)
+	if err != nil {
+		return nil, nil, err
+	}
+	root, defs := cloneSchema(rt.schema), make(map[string]map[string]any, len(rt.definitions))
+	for name, def := range rt.definitions {
+		defs[name] = cloneSchema(def)
+	}
+	if deref {
+		root, defs = dereference(root, defs)
+	}
+	if _, ok := root["title"]; !ok && typ.DisplayName != "" {
+		root["title"] = typ.DisplayName
+	}
+	root["$comment"] = "CTI type " + typ.Cti
+	return root, defs, nil
+}
+
+// packageDocument holds definitions of types of the package and definitions they refer to.
+type packageDocument struct {
+	defs map[string]any
+}
+
+// add puts the type and its definitions into the document under the name. Definitions of different types
+// with the same name are shared if equal, otherwise the definition of the type is renamed.
+func (d *packageDocument) add(typeName string, root map[string]any, defs map[string]map[string]any, defsKey string) {
+	rename := make(map[string]string)
+	for _, name := range sortedKeys(defs) {
+		target := name
+		for i := 2; ; i++ {
+			existing, ok := d.defs[target]
+			if !ok || reflect.DeepEqual(existing, finalized(defs[name], defsKey, nil)) {
+				break
+			}
+			target = name + "_" + strconv.Itoa(i)
+		}
+		rename[name] = target
+	}
+	for name, def := range defs {
+		finalizeSchema(def, defsKey, rename)
+		d.defs[rename[name]] = def
+	}
+	finalizeSchema(root, defsKey, rename)
+	d.defs[typeName] = root
+}
+
+// finalizeSchema removes RAML extensions from the schema and rewrites references to definitions
+// according to the draft. Definitions may be renamed.
+func finalizeSchema(schema map[string]any, defsKey string, rename map[string]string) {
+	walkSchema(schema, func(s map[string]any) {
+		for k := range s {
+			if strings.HasPrefix(k, "x-") {
+				delete(s, k)
+			}
+		}
+		if name, ok := definitionName(s); ok {
+			if renamed, ok := rename[name]; ok {
+				name = renamed
+			}
+			s["$ref"] = "#/" + defsKey + "/" + escapePointer(name)
+		}
+	})
+}
+
+func finalized(schema map[string]any, defsKey string, rename map[string]string) map[string]any {
+	res := cloneSchema(schema)
+	finalizeSchema(res, defsKey, rename)
+	return res
+}
+
+// refAnnotations are keywords that may be next to $ref in draft-07, where other keywords next to $ref are ignored.
+var refAnnotations = map[string]bool{
+	"$ref": true, "$schema": true, "$id": true, "$comment": true, "title": true, "description": true,
+	"default": true, "examples": true, "deprecated": true, "readOnly": true, "writeOnly": true,
+	"definitions": true, "$defs": true,
+}
+
+// translateSchema rewrites keywords of the schema and its subschemas that differ between drafts: tuples
+// (items and additionalItems or prefixItems and items), dependencies (dependentRequired and dependentSchemas)
+// and keywords next to $ref, that are ignored by draft-07 validators and are moved next to allOf. Keywords
+// of other specifications are translated for all drafts, e.g. nullable of OpenAPI 3.0 adds null to types
+// and boolean exclusiveMinimum of draft-04 becomes the number.
+func translateSchema(schema map[string]any, draft Draft) {
+	walkSchema(schema, func(s map[string]any) {
+		translateNullable(s)
+		for _, bound := range []struct{ key, exclusive string }{{"minimum", "exclusiveMinimum"}, {"maximum", "exclusiveMaximum"}} {
+			if exclusive, ok := s[bound.exclusive].(bool); ok {
+				if v, ok := s[bound.key]; ok && exclusive {
+					s[bound.exclusive] = v
+					delete(s, bound.key)
+				} else {
+					delete(s, bound.exclusive)
+				}
+			}
+		}
+
+		if draft == Draft202012 {
+			if tuple, ok := s["items"].([]any); ok {
+				s["prefixItems"] = tuple
+				delete(s, "items")
+				if additional, ok := s["additionalItems"]; ok {
+					s["items"] = additional
+					delete(s, "additionalItems")
+				}
+			}
+		} else if tuple, ok := s["prefixItems"]; ok {
+			if rest, ok := s["items"]; ok {
+				s["additionalItems"] = rest
+			}
+			s["items"] = tuple
+			delete(s, "prefixItems")
+		}
+
+		if draft == Draft7 {
+			dependencies, _ := s["dependencies"].(map[string]any)
+			for _, key := range []string{"dependentRequired", "dependentSchemas"} {
+				if m, ok := s[key].(map[string]any); ok {
+					if dependencies == nil {
+						dependencies = make(map[string]any, len(m))
+					}
+					for name, v := range m {
+						dependencies[name] = v
+					}
+					delete(s, key)
+				}
+			}
+			if dependencies != nil {
+				s["dependencies"] = dependencies
+			}
+			wrapRef(s)
+		} else if dependencies, ok := s["dependencies"].(map[string]any); ok {
+			delete(s, "dependencies")
+			for name, v := range dependencies {
+				key := "dependentSchemas"
+				if _, isList := v.([]any); isList {
+					key = "dependentRequired"
+				}
+				m, ok := s[key].(map[string]any)
+				if !ok {
+					m = make(map[string]any)
+					s[key] = m
+				}
+				m[name] = v
+			}
+		}
+	})
+}
+
+// translateNullable replaces nullable: true with null among types or enum values, or with a union with null.
+func translateNullable(s map[string]any) {
+	nullable, ok := s["nullable"].(bool)
+	if !ok {
+		return
+	}
+	delete(s, "nullable")
+	if !nullable {
+		return
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		for _, v := range enum {
+			if v == nil {
+				return
+			}
+		}
+		s["enum"] = append(enum, nil)
+	}
+	switch typ := s["type"].(type) {
+	case string:
+		if typ != "null" {
+			s["type"] = []any{typ, "null"}
+		}
+	case []any:
+		for _, t := range typ {
+			if t == "null" {
+				return
+			}
+		}
+		s["type"] = append(typ, "null")
+	case nil:
+		if _, ok := s["enum"]; ok {
+			return
+		}
+		nonNull := make(map[string]any, len(s))
+		for k, v := range s {
+			if !refAnnotations[k] || k == "$ref" {
+				nonNull[k] = v
+				delete(s, k)
+			}
+		}
+		s["anyOf"] = []any{nonNull, map[string]any{"type": "null"}}
+	}
+}
+
+// wrapRef moves $ref into allOf if the schema has other keywords that draft-07 validators would ignore.
+func wrapRef(s map[string]any) {
+	ref, ok := s["$ref"]
+	if !ok {
+		return
+	}
+	for k := range s {
+		if refAnnotations[k] || strings.HasPrefix(k, "x-") {
+			continue
+		}
+		all, _ := s["allOf"].([]any)
+		s["allOf"] = append([]any{map[string]any{"$ref": ref}}, all...)
+		delete(s, "$ref")
+		return
+	}
+}
+
+// dereference inlines definitions referenced by the schema. Definitions that refer to themselves, directly
+// or indirectly, are kept and returned.
+func dereference(schema map[string]any, defs map[string]map[string]any) (map[string]any, map[string]map[string]any) {
+	kept := make(map[string]map[string]any)
+	var pending []string
+
+	var inline func(s map[string]any, stack map[string]bool) map[string]any
+	inline = func(s map[string]any, stack map[string]bool) map[string]any {
+		if name, ok := definitionName(s); ok {
+			def, found := defs[name]
+			if !found {
+				return s
+			}
+			if stack[name] {
+				if _, ok := kept[name]; !ok {
+					kept[name] = nil
+					pending = append(pending, name)
+				}
+				return s
+			}
+			stack[name] = true
+			res := inline(cloneSchema(def), stack)
+			delete(stack, name)
+			// Keywords next to the reference refine the definition.
+			for k, v := range s {
+				if k != "$ref" {
+					res[k] = v
+				}
+			}
+			return res
+		}
+		replaceSubschemas(s, func(sub map[string]any) map[string]any {
+			return inline(sub, stack)
+		})
+		return s
+	}
+
+	schema = inline(schema, make(map[string]bool))
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		def := cloneSchema(defs[name])
+		replaceSubschemas(def, func(sub map[string]any) map[string]any {
+			return inline(sub, map[string]bool{name: true})
+		})
+		kept[name] = def
+	}
+	return schema, kept
+}
+
+// replaceSubschemas replaces direct subschemas of the schema with results of the function.
+func replaceSubschemas(schema map[string]any, fn func(map[string]any) map[string]any) {
+	for _, key := range schemaMapKeywords {
+		if m, ok := schema[key].(map[string]any); ok {
+			for name, item := range m {
+				if sub, ok := item.(map[string]any); ok {
+					m[name] = fn(sub)
+				}
+			}
+		}
+	}
+	for _, key := range schemaListKeywords {
+		if list, ok := schema[key].([]any); ok {
+			for i, item := range list {
+				if sub, ok := item.(map[string]any); ok {
+					list[i] = fn(sub)
+				}
+			}
+		}
+	}
+	for _, key := range schemaKeywords {
+		if sub, ok := schema[key].(map[string]any); ok {
+			schema[key] = fn(sub)
+		}
+	}
+	switch items := schema["items"].(type) {
+	case map[string]any:
+		schema["items"] = fn(items)
+	case []any:
+		for i, item := range items {
+			if sub, ok := item.(map[string]any); ok {
+				items[i] = fn(sub)
+			}
+		}
+	}
+}
+
+// escapePointer escapes the reference token of JSON Pointer.
+func escapePointer(s string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(s, "~", "~0"), "/", "~1")
+}
+
+func marshalDocument(doc map[string]any) ([]byte, error) {
+	data, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,217 @@
+package codegen
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_JSONSchema(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	testCases := []struct {
+		name  string
+		opts  JSONSchemaOptions
+		files []string
+		check func(t *testing.T, docs map[string]map[string]any)
+	}{
+		{
+			name:  "type layout",
+			opts:  JSONSchemaOptions{},
+			files: []string{"cti.x.y.event.v1.0.schema.json", "cti.x.y.event.v1.0~x.y.user_created.v1.0.schema.json"},
+			check: func(t *testing.T, docs map[string]map[string]any) {
+				doc := docs["cti.x.y.event.v1.0~x.y.user_created.v1.0.schema.json"]
+				require.Equal(t, "http://json-schema.org/draft-07/schema#", doc["$schema"])
+				require.Equal(t, "User created", doc["title"])
+				require.Equal(t, "CTI type cti.x.y.event.v1.0~x.y.user_created.v1.0", doc["$comment"])
+				require.NotContains(t, doc, "x-custom")
+				require.ElementsMatch(t, []any{"id", "login"}, doc["required"])
+
+				props := doc["properties"].(map[string]any)
+				require.Equal(t, map[string]any{"type": "string", "format": "uuid"}, props["id"])
+				require.Equal(t, map[string]any{"$ref": "#/definitions/Person"}, props["manager"])
+				require.Equal(t, map[string]any{"$ref": "#/definitions/Tag"}, props["tags"].(map[string]any)["items"])
+				require.Contains(t, doc["definitions"], "Tag")
+				require.Contains(t, doc["definitions"], "Person")
+			},
+		},
+		{
+			name:  "dereference",
+			opts:  JSONSchemaOptions{Draft: Draft202012, Dereference: true},
+			files: []string{"cti.x.y.event.v1.0.schema.json", "cti.x.y.event.v1.0~x.y.user_created.v1.0.schema.json"},
+			check: func(t *testing.T, docs map[string]map[string]any) {
+				event := docs["cti.x.y.event.v1.0.schema.json"]
+				require.Equal(t, "https://json-schema.org/draft/2020-12/schema", event["$schema"])
+				require.NotContains(t, event, "$defs")
+				items := event["properties"].(map[string]any)["tags"].(map[string]any)["items"].(map[string]any)
+				require.Equal(t, "object", items["type"])
+
+				// Recursive definitions are kept.
+				doc := docs["cti.x.y.event.v1.0~x.y.user_created.v1.0.schema.json"]
+				manager := doc["properties"].(map[string]any)["manager"].(map[string]any)
+				require.Equal(t, "object", manager["type"])
+				reports := manager["properties"].(map[string]any)["reports"].(map[string]any)
+				require.Equal(t, map[string]any{"$ref": "#/$defs/Person"}, reports["items"])
+				require.Equal(t, []string{"Person"}, sortedKeys(doc["$defs"].(map[string]any)))
+			},
+		},
+		{
+			name:  "package layout",
+			opts:  JSONSchemaOptions{Draft: Draft201909, Layout: LayoutPackage},
+			files: []string{"x.y.schema.json"},
+			check: func(t *testing.T, docs map[string]map[string]any) {
+				doc := docs["x.y.schema.json"]
+				require.Equal(t, "CTI package x.y", doc["$comment"])
+				require.Equal(t, []string{
+					"Person",
+					"Tag",
+					"cti.x.y.event.v1.0",
+					"cti.x.y.event.v1.0~x.y.user_created.v1.0",
+				}, sortedKeys(doc["$defs"].(map[string]any)))
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			files, err := g.JSONSchema(r.Entities(), tc.opts)
+			require.NoError(t, err)
+
+			docs := make(map[string]map[string]any)
+			var names []string
+			for _, f := range files {
+				var doc map[string]any
+				require.NoError(t, json.Unmarshal(f.Data, &doc))
+				docs[f.Name] = doc
+				names = append(names, f.Name)
+			}
+			require.Equal(t, tc.files, names)
+			tc.check(t, docs)
+		})
+	}
+}
+
+func Test_JSONSchemaUnsupported(t *testing.T) {
+	g := New(testRegistry(t))
+
+	_, err := g.JSONSchema(nil, JSONSchemaOptions{Draft: "draft-04"})
+	require.EqualError(t, err, "unsupported draft draft-04")
+}
+
+func Test_PackageDocumentRenamesConflicts(t *testing.T) {
+	doc := &packageDocument{defs: make(map[string]any)}
+	doc.add("cti.a", map[string]any{"$ref": "#/definitions/Item"}, map[string]map[string]any{
+		"Item": {"type": "string"},
+	}, "$defs")
+	doc.add("cti.b", map[string]any{"$ref": "#/definitions/Item"}, map[string]map[string]any{
+		"Item": {"type": "integer"},
+	}, "$defs")
+	doc.add("cti.c", map[string]any{"$ref": "#/definitions/Item"}, map[string]map[string]any{
+		"Item": {"type": "string"},
+	}, "$defs")
+
+	require.Equal(t, map[string]any{
+		"Item":   map[string]any{"type": "string"},
+		"Item_2": map[string]any{"type": "integer"},
+		"cti.a":  map[string]any{"$ref": "#/$defs/Item"},
+		"cti.b":  map[string]any{"$ref": "#/$defs/Item_2"},
+		"cti.c":  map[string]any{"$ref": "#/$defs/Item"},
+	}, doc.defs)
+}
+
+func Test_TranslateSchema(t *testing.T) {
+	testCases := []struct {
+		name     string
+		draft    Draft
+		schema   map[string]any
+		expected map[string]any
+	}{
+		{
+			name:     "tuple to prefixItems",
+			draft:    Draft202012,
+			schema:   map[string]any{"items": []any{map[string]any{"type": "string"}}, "additionalItems": false},
+			expected: map[string]any{"prefixItems": []any{map[string]any{"type": "string"}}, "items": false},
+		},
+		{
+			name:     "prefixItems to tuple",
+			draft:    Draft201909,
+			schema:   map[string]any{"prefixItems": []any{map[string]any{"type": "string"}}, "items": false},
+			expected: map[string]any{"items": []any{map[string]any{"type": "string"}}, "additionalItems": false},
+		},
+		{
+			name:  "dependencies to dependent keywords",
+			draft: Draft202012,
+			schema: map[string]any{"dependencies": map[string]any{
+				"a": []any{"b"},
+				"c": map[string]any{"required": []any{"d"}},
+			}},
+			expected: map[string]any{
+				"dependentRequired": map[string]any{"a": []any{"b"}},
+				"dependentSchemas":  map[string]any{"c": map[string]any{"required": []any{"d"}}},
+			},
+		},
+		{
+			name:  "dependent keywords to dependencies",
+			draft: Draft7,
+			schema: map[string]any{
+				"dependentRequired": map[string]any{"a": []any{"b"}},
+				"dependentSchemas":  map[string]any{"c": map[string]any{"required": []any{"d"}}},
+			},
+			expected: map[string]any{"dependencies": map[string]any{
+				"a": []any{"b"},
+				"c": map[string]any{"required": []any{"d"}},
+			}},
+		},
+		{
+			name:  "ref with keywords in draft-07",
+			draft: Draft7,
+			schema: map[string]any{"properties": map[string]any{
+				"a": map[string]any{"$ref": "#/definitions/A", "description": "A.", "required": []any{"b"}},
+				"b": map[string]any{"$ref": "#/definitions/B", "description": "B."},
+			}},
+			expected: map[string]any{"properties": map[string]any{
+				"a": map[string]any{"allOf": []any{map[string]any{"$ref": "#/definitions/A"}}, "description": "A.", "required": []any{"b"}},
+				"b": map[string]any{"$ref": "#/definitions/B", "description": "B."},
+			}},
+		},
+		{
+			name:     "ref with keywords in 2020-12",
+			draft:    Draft202012,
+			schema:   map[string]any{"$ref": "#/$defs/A", "required": []any{"b"}},
+			expected: map[string]any{"$ref": "#/$defs/A", "required": []any{"b"}},
+		},
+		{
+			name:  "nullable",
+			draft: Draft202012,
+			schema: map[string]any{"properties": map[string]any{
+				"a": map[string]any{"type": "string", "nullable": true},
+				"b": map[string]any{"type": "string", "enum": []any{"x"}, "nullable": true},
+				"c": map[string]any{"$ref": "#/$defs/C", "description": "C.", "nullable": true},
+				"d": map[string]any{"type": "string", "nullable": false},
+			}},
+			expected: map[string]any{"properties": map[string]any{
+				"a": map[string]any{"type": []any{"string", "null"}},
+				"b": map[string]any{"type": []any{"string", "null"}, "enum": []any{"x", nil}},
+				"c": map[string]any{
+					"anyOf":       []any{map[string]any{"$ref": "#/$defs/C"}, map[string]any{"type": "null"}},
+					"description": "C.",
+				},
+				"d": map[string]any{"type": "string"},
+			}},
+		},
+		{
+			name:     "boolean exclusive bounds",
+			draft:    Draft7,
+			schema:   map[string]any{"minimum": 0.0, "exclusiveMinimum": true, "maximum": 1.0, "exclusiveMaximum": false},
+			expected: map[string]any{"exclusiveMinimum": 0.0, "maximum": 1.0},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			translateSchema(tc.schema, tc.draft)
+			require.Equal(t, tc.expected, tc.schema)
+		})
+	}
+}
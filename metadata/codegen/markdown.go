@@ -0,0 +1,309 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-cti"
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+const (
+	MarkdownFileExt = ".md"
+
+	markdownHeader = "<!-- Code generated by cti gen md. DO NOT EDIT. -->\n"
+)
+
+// markdownConstraintKeys are schema keywords listed in the constraints column.
+var markdownConstraintKeys = []string{
+	"const", "enum", "format", "pattern", "minimum", "exclusiveMinimum", "maximum", "exclusiveMaximum", "multipleOf",
+	"minLength", "maxLength", "minItems", "maxItems", "uniqueItems", "minProperties", "maxProperties", "default",
+}
+
+// markdownKinds are names of kinds in the type column.
+var markdownKinds = map[kind]string{
+	kindAny:     "any",
+	kindString:  "string",
+	kindInteger: "integer",
+	kindNumber:  "number",
+	kindBoolean: "boolean",
+	kindNull:    "null",
+	kindObject:  "object",
+}
+
+// markdownAttribute is a row of the attribute table.
+type markdownAttribute struct {
+	path        string
+	typ         string
+	constraints []string
+	description string
+	since       string
+}
+
+// markdownVersion is a version of the type within the lineage.
+type markdownVersion struct {
+	major, minor uint
+	entity       *metadata.Entity
+}
+
+func (v markdownVersion) String() string {
+	return fmt.Sprintf("v%d.%d", v.major, v.minor)
+}
+
+// Markdown generates a Markdown reference of the types with a file per package, e.g. x.y.md, to be included
+// into existing documentation sites. Each type gets a section with the table of its attributes, including
+// inherited ones, nested attributes are flattened into paths, e.g. tags.#.name. The since column holds
+// the earliest minor version of the type with the attribute among versions available in the registry.
+func (g *Generator) Markdown(entities metadata.Entities) ([]File, error) {
+	m, err := g.model(entities)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := g.markdownVersions()
+	if err != nil {
+		return nil, err
+	}
+
+	packages := make(map[string]*strings.Builder)
+	for _, nt := range m.types {
+		if nt.Entity == nil {
+			continue
+		}
+		vendor, pkg := metadata.GetVendorAndPackage(nt.Entity.Cti)
+		key := registry.PackageKey(vendor, pkg)
+		b, ok := packages[key]
+		if !ok {
+			b = &strings.Builder{}
+			b.WriteString(markdownHeader)
+			fmt.Fprintf(b, "\n# %s\n", key)
+			packages[key] = b
+		}
+
+		attrs := markdownAttributes(nt.Expr, "", nil)
+		if err := g.markdownSince(nt.Entity, attrs, versions); err != nil {
+			return nil, err
+		}
+		writeMarkdownType(b, nt, attrs)
+	}
+
+	files := make([]File, 0, len(packages))
+	for _, key := range sortedKeys(packages) {
+		files = append(files, File{Name: key + MarkdownFileExt, Data: []byte(packages[key].String())})
+	}
+	return files, nil
+}
+
+// markdownVersions indexes versions of all types of the registry by lineages and major versions.
+// Versions are sorted from the latest minor version.
+func (g *Generator) markdownVersions() (map[string][]markdownVersion, error) {
+	p := cti.NewParser()
+	res := make(map[string][]markdownVersion)
+	for _, typ := range g.registry.Types() {
+		expr, err := p.Parse(typ.Cti)
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", typ.Cti, err)
+		}
+		tail := expr.Tail()
+		v := markdownVersion{major: tail.Version.Major.Value, minor: tail.Version.Minor.Value, entity: typ}
+		key := fmt.Sprintf("%s@%d", ctiLineage(expr), v.major)
+		res[key] = append(res[key], v)
+	}
+	for _, list := range res {
+		sort.Slice(list, func(a, b int) bool {
+			return list[a].minor > list[b].minor
+		})
+	}
+	return res, nil
+}
+
+// markdownSince sets the earliest version of attributes. Versions are walked back from the type while
+// they have the attribute, so attributes removed and added again are new since the latest addition.
+func (g *Generator) markdownSince(typ *metadata.Entity, attrs []*markdownAttribute, versions map[string][]markdownVersion) error {
+	expr, err := cti.NewParser().Parse(typ.Cti)
+	if err != nil {
+		return fmt.Errorf("parse %s: %w", typ.Cti, err)
+	}
+	tail := expr.Tail()
+	current := markdownVersion{major: tail.Version.Major.Value, minor: tail.Version.Minor.Value, entity: typ}
+	for _, a := range attrs {
+		a.since = current.String()
+	}
+
+	pending := attrs
+	for _, v := range versions[fmt.Sprintf("%s@%d", ctiLineage(expr), current.major)] {
+		if v.minor >= current.minor {
+			continue
+		}
+		if len(pending) == 0 {
+			break
+		}
+		m, err := g.model(metadata.Entities{v.entity})
+		if err != nil {
+			return err
+		}
+		paths := make(map[string]bool)
+		for _, a := range markdownAttributes(m.types[0].Expr, "", nil) {
+			paths[a.path] = true
+		}
+		var present []*markdownAttribute
+		for _, a := range pending {
+			if paths[a.path] {
+				a.since = v.String()
+				present = append(present, a)
+			}
+		}
+		pending = present
+	}
+	return nil
+}
+
+// markdownAttributes flattens properties of the expression into attributes. Items of arrays are denoted
+// by #, values of maps by *. Named types on the stack are recursive and not expanded again.
+func markdownAttributes(e *typeExpr, prefix string, stack map[*namedType]bool) []*markdownAttribute {
+	switch e.Kind {
+	case kindRef:
+		if stack[e.Ref] {
+			return nil
+		}
+		nested := make(map[*namedType]bool, len(stack)+1)
+		for nt := range stack {
+			nested[nt] = true
+		}
+		nested[e.Ref] = true
+		return markdownAttributes(e.Ref.Expr, prefix, nested)
+	case kindUnion:
+		if base, _ := e.nullable(); base.Kind != kindUnion {
+			return markdownAttributes(base, prefix, stack)
+		}
+	case kindArray:
+		return markdownAttributes(e.Items, prefix+"#.", stack)
+	case kindMap:
+		return markdownAttributes(e.Values, prefix+"*.", stack)
+	case kindObject:
+		var res []*markdownAttribute
+		for _, f := range e.Fields {
+			a := &markdownAttribute{
+				path:        prefix + f.Name,
+				typ:         markdownType(f.Expr),
+				constraints: markdownConstraints(f.Expr.Schema),
+				description: f.Description,
+			}
+			if f.Required {
+				a.constraints = append([]string{"required"}, a.constraints...)
+			}
+			res = append(res, a)
+			res = append(res, markdownAttributes(f.Expr, a.path+".", stack)...)
+		}
+		return res
+	}
+	return nil
+}
+
+// markdownType returns the name of the type of the expression, e.g. array of Tag.
+func markdownType(e *typeExpr) string {
+	switch e.Kind {
+	case kindRef:
+		return e.Ref.Name
+	case kindArray:
+		return "array of " + markdownType(e.Items)
+	case kindMap:
+		return "map of " + markdownType(e.Values)
+	case kindUnion:
+		members := make([]string, 0, len(e.Members))
+		for _, m := range e.Members {
+			members = append(members, markdownType(m))
+		}
+		return strings.Join(members, " | ")
+	case kindEnum:
+		return markdownKinds[e.Base]
+	default:
+		return markdownKinds[e.Kind]
+	}
+}
+
+// markdownConstraints returns facets of the schema, e.g. minLength: 1. Values annotated with cti.id
+// or cti.reference are described by the annotations instead of patterns of CTIs.
+func markdownConstraints(schema map[string]any) []string {
+	var res []string
+	_, ctiValue := annotation(schema, metadata.ID, metadata.Reference)
+	if _, ok := annotation(schema, metadata.ID); ok {
+		res = append(res, metadata.ID)
+	}
+	if reference, ok := annotation(schema, metadata.Reference); ok {
+		if target, isCti := reference.(string); isCti {
+			res = append(res, metadata.Reference+": "+target)
+		} else {
+			res = append(res, metadata.Reference)
+		}
+	}
+	for _, key := range markdownConstraintKeys {
+		v, ok := schema[key]
+		if !ok || key == "pattern" && ctiValue {
+			continue
+		}
+		switch v := v.(type) {
+		case string:
+			if key == "format" || key == "pattern" {
+				res = append(res, key+": "+v)
+			} else {
+				res = append(res, key+": "+literal(v))
+			}
+		case []any:
+			values := make([]string, 0, len(v))
+			for _, item := range v {
+				values = append(values, literal(item))
+			}
+			res = append(res, key+": "+strings.Join(values, ", "))
+		default:
+			data, _ := json.Marshal(v)
+			res = append(res, key+": "+string(data))
+		}
+	}
+	return res
+}
+
+func writeMarkdownType(b *strings.Builder, nt *namedType, attrs []*markdownAttribute) {
+	title := nt.Entity.DisplayName
+	if title == "" {
+		title = nt.Name
+	}
+	fmt.Fprintf(b, "\n## %s\n\n`%s`", title, nt.Entity.Cti)
+	if nt.Entity.Final {
+		b.WriteString(" (final)")
+	}
+	b.WriteString("\n")
+	if parent := metadata.GetParentCti(nt.Entity.Cti); parent != nt.Entity.Cti {
+		fmt.Fprintf(b, "\nExtends `%s`.\n", parent)
+	}
+	if nt.Description != "" && nt.Description != title {
+		fmt.Fprintf(b, "\n%s\n", nt.Description)
+	}
+
+	if len(attrs) == 0 {
+		b.WriteString("\nNo attributes.\n")
+		return
+	}
+	b.WriteString("\n| Attribute | Type | Constraints | Description | Since |\n")
+	b.WriteString("|-----------|------|-------------|-------------|-------|\n")
+	for _, a := range attrs {
+		constraints := make([]string, 0, len(a.constraints))
+		for _, c := range a.constraints {
+			if c == "required" {
+				constraints = append(constraints, c)
+			} else {
+				constraints = append(constraints, "`"+markdownCell(c)+"`")
+			}
+		}
+		fmt.Fprintf(b, "| `%s` | %s | %s | %s | %s |\n",
+			markdownCell(a.path), markdownCell(a.typ), strings.Join(constraints, "<br>"), markdownCell(a.description), a.since)
+	}
+}
+
+// markdownCell escapes the value to be used in a Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
@@ -0,0 +1,112 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func Test_Markdown(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	files, err := g.Markdown(r.Entities())
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "x.y.md", files[0].Name)
+	require.Equal(t, "<!-- Code generated by cti gen md. DO NOT EDIT. -->\n"+`
+# x.y
+
+## Event
+
+`+"`cti.x.y.event.v1.0`"+`
+
+| Attribute | Type | Constraints | Description | Since |
+|-----------|------|-------------|-------------|-------|
+| `+"`id`"+` | string | required<br>`+"`cti.id`"+`<br>`+"`format: uuid`"+` |  | v1.0 |
+| `+"`severity`"+` | string | `+"`enum: \"low\", \"high\"`"+` |  | v1.0 |
+| `+"`tags`"+` | array of Tag |  |  | v1.0 |
+| `+"`tags.#.name`"+` | string | required<br>`+"`minLength: 1`"+` |  | v1.0 |
+
+## User created
+
+`+"`cti.x.y.event.v1.0~x.y.user_created.v1.0`"+` (final)
+
+Extends `+"`cti.x.y.event.v1.0`"+`.
+
+| Attribute | Type | Constraints | Description | Since |
+|-----------|------|-------------|-------------|-------|
+| `+"`id`"+` | string | required<br>`+"`cti.id`"+`<br>`+"`format: uuid`"+` |  | v1.0 |
+| `+"`login`"+` | string | required |  | v1.0 |
+| `+"`manager`"+` | Person |  |  | v1.0 |
+| `+"`manager.name`"+` | string |  |  | v1.0 |
+| `+"`manager.reports`"+` | array of Person |  |  | v1.0 |
+| `+"`severity`"+` | string | `+"`enum: \"low\", \"high\"`"+` |  | v1.0 |
+| `+"`tags`"+` | array of Tag |  |  | v1.0 |
+| `+"`tags.#.name`"+` | string | required<br>`+"`minLength: 1`"+` |  | v1.0 |
+`, string(files[0].Data))
+}
+
+func Test_MarkdownSince(t *testing.T) {
+	order := func(cti, description string, properties string) *metadata.Entity {
+		return &metadata.Entity{
+			Cti:         cti,
+			Description: description,
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Order",
+				"definitions": {"Order": {"type": "object", "properties": {` + properties + `}}}
+			}`),
+		}
+	}
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.a.b.order.v1.0": order("cti.a.b.order.v1.0", "", `"id": {"type": "string"}`),
+		"cti.a.b.order.v1.1": order("cti.a.b.order.v1.1", "", `
+			"id": {"type": "string"},
+			"note": {"type": "string", "description": "Free | form\nnote."}`),
+		"cti.a.b.order.v1.2": order("cti.a.b.order.v1.2", "Order of goods.", `
+			"id": {"type": "string"},
+			"note": {"type": "string", "description": "Free | form\nnote."},
+			"total": {"type": "number", "minimum": 0}`),
+		"cti.a.b.order.v2.0": order("cti.a.b.order.v2.0", "", `
+			"id": {"type": "string"},
+			"lines": {"type": "object", "additionalProperties": {"type": "object", "properties": {"qty": {"type": "integer"}}}}`),
+	})
+	require.NoError(t, err)
+	g := New(r)
+
+	v12, _ := r.GetType("cti.a.b.order.v1.2")
+	v20, _ := r.GetType("cti.a.b.order.v2.0")
+	files, err := g.Markdown(metadata.Entities{v12, v20})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "a.b.md", files[0].Name)
+	require.Equal(t, "<!-- Code generated by cti gen md. DO NOT EDIT. -->\n"+`
+# a.b
+
+## ABOrderV1
+
+`+"`cti.a.b.order.v1.2`"+`
+
+Order of goods.
+
+| Attribute | Type | Constraints | Description | Since |
+|-----------|------|-------------|-------------|-------|
+| `+"`id`"+` | string |  |  | v1.0 |
+| `+"`note`"+` | string |  | Free \| form<br>note. | v1.1 |
+| `+"`total`"+` | number | `+"`minimum: 0`"+` |  | v1.2 |
+
+## ABOrderV2
+
+`+"`cti.a.b.order.v2.0`"+`
+
+| Attribute | Type | Constraints | Description | Since |
+|-----------|------|-------------|-------------|-------|
+| `+"`id`"+` | string |  |  | v2.0 |
+| `+"`lines`"+` | map of object |  |  | v2.0 |
+| `+"`lines.*.qty`"+` | integer |  |  | v2.0 |
+`, string(files[0].Data))
+}
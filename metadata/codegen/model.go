@@ -0,0 +1,413 @@
+package codegen
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/acronis/go-cti"
+	"github.com/acronis/go-cti/metadata"
+)
+
+// kind is a kind of the type expression in the language-neutral model of generated types.
+type kind int
+
+const (
+	kindAny kind = iota
+	kindString
+	kindInteger
+	kindNumber
+	kindBoolean
+	kindNull
+	kindObject
+	kindArray
+	kindMap
+	kindRef
+	kindUnion
+	kindEnum
+)
+
+// typeModel is a language-neutral model of generated types. Generators of specific languages render
+// the model instead of interpreting JSON Schema on their own.
+type typeModel struct {
+	// types holds CTI types sorted by CTI followed by definitions they refer to sorted by name.
+	types []*namedType
+}
+
+// namedType is a CTI type or a named definition referred to by CTI types.
+type namedType struct {
+	Name string
+	// Entity is set for CTI types.
+	Entity      *metadata.Entity
+	Description string
+	Expr        *typeExpr
+}
+
+// typeExpr is a type expression built from a schema.
+type typeExpr struct {
+	Kind kind
+	// Ref is a named type the expression refers to.
+	Ref *namedType
+	// Items holds array items, Values holds map values.
+	Items  *typeExpr
+	Values *typeExpr
+	// Members holds members of union.
+	Members []*typeExpr
+	// Fields holds properties of objects sorted by name.
+	Fields []*field
+	// Enum holds allowed values, Base holds the kind of values (string, integer, etc.).
+	Enum []any
+	Base kind
+	// Schema is the source schema of the expression. It is used for constraints, formats, etc.
+	Schema map[string]any
+}
+
+type field struct {
+	Name        string
+	Required    bool
+	Description string
+	Expr        *typeExpr
+}
+
+// nullable returns the expression without null members of the union and tells whether null is allowed.
+func (e *typeExpr) nullable() (*typeExpr, bool) {
+	if e.Kind != kindUnion {
+		return e, e.Kind == kindNull
+	}
+	var members []*typeExpr
+	for _, m := range e.Members {
+		if m.Kind != kindNull {
+			members = append(members, m)
+		}
+	}
+	switch {
+	case len(members) == len(e.Members):
+		return e, false
+	case len(members) == 1:
+		return members[0], true
+	default:
+		return &typeExpr{Kind: kindUnion, Members: members, Schema: e.Schema}, true
+	}
+}
+
+// format returns the format of the schema of the expression.
+func (e *typeExpr) format() string {
+	f, _ := e.Schema["format"].(string)
+	return f
+}
+
+// model builds the type model of the types. Names of CTI types are derived from entity names of their CTIs,
+// names of definitions are kept, conflicting names are qualified.
+func (g *Generator) model(entities metadata.Entities) (*typeModel, error) {
+	types := sortedTypes(entities)
+	names := EntityNames(types)
+
+	m := &typeModel{}
+	used := make(map[string]bool)
+	for _, typ := range types {
+		used[names[typ.Cti]] = true
+	}
+
+	type definition struct {
+		schema map[string]any
+		nt     *namedType
+	}
+	var defTypes []*namedType
+	shared := make(map[string][]*definition)
+
+	for _, typ := range types {
+		rt, err := g.resolve(typ)
+		if err != nil {
+			return nil, err
+		}
+		// Merged schemas inherit descriptions of ancestors, so only the own description of the type is used.
+		description := typ.Description
+		if description == "" {
+			description = typ.DisplayName
+		}
+		nt := &namedType{Name: names[typ.Cti], Entity: typ, Description: description}
+		m.types = append(m.types, nt)
+
+		// Definitions of different types with the same name are shared if equal.
+		local := make(map[string]*namedType, len(rt.definitions))
+		for _, defName := range sortedKeys(rt.definitions) {
+			schema := rt.definitions[defName]
+			var found *namedType
+			for _, d := range shared[defName] {
+				if reflect.DeepEqual(d.schema, schema) {
+					found = d.nt
+					break
+				}
+			}
+			if found == nil {
+				name := uniqueName(exportedName(defName), used)
+				found = &namedType{Name: name, Description: firstString(schema, "description", "")}
+				shared[defName] = append(shared[defName], &definition{schema: schema, nt: found})
+				defTypes = append(defTypes, found)
+			}
+			local[defName] = found
+		}
+
+		b := &modelBuilder{definitions: local}
+		nt.Expr = b.expr(rt.schema)
+		for defName, def := range local {
+			if def.Expr == nil {
+				def.Expr = b.expr(rt.definitions[defName])
+			}
+		}
+	}
+
+	sort.Slice(defTypes, func(a, b int) bool {
+		return defTypes[a].Name < defTypes[b].Name
+	})
+	m.types = append(m.types, defTypes...)
+	return m, nil
+}
+
+type modelBuilder struct {
+	definitions map[string]*namedType
+}
+
+func (b *modelBuilder) expr(schema map[string]any) *typeExpr {
+	if name, ok := definitionName(schema); ok {
+		if nt, ok := b.definitions[name]; ok {
+			return &typeExpr{Kind: kindRef, Ref: nt, Schema: schema}
+		}
+		return &typeExpr{Kind: kindAny, Schema: schema}
+	}
+	for _, key := range []string{"anyOf", "oneOf"} {
+		if members, ok := schema[key].([]any); ok {
+			e := &typeExpr{Kind: kindUnion, Schema: schema}
+			for _, member := range members {
+				if s, ok := member.(map[string]any); ok {
+					e.Members = append(e.Members, b.expr(s))
+				}
+			}
+			return e
+		}
+	}
+	if values, ok := schema["enum"].([]any); ok {
+		return &typeExpr{Kind: kindEnum, Enum: values, Base: b.enumBase(schema, values), Schema: schema}
+	}
+	if value, ok := schema["const"]; ok {
+		return &typeExpr{Kind: kindEnum, Enum: []any{value}, Base: b.enumBase(schema, []any{value}), Schema: schema}
+	}
+
+	switch typ := schema["type"].(type) {
+	case string:
+		return b.typed(typ, schema)
+	case []any:
+		e := &typeExpr{Kind: kindUnion, Schema: schema}
+		for _, t := range typ {
+			s, _ := t.(string)
+			e.Members = append(e.Members, b.typed(s, schema))
+		}
+		return e
+	}
+	if _, ok := schema["properties"]; ok {
+		return b.typed("object", schema)
+	}
+	return &typeExpr{Kind: kindAny, Schema: schema}
+}
+
+func (b *modelBuilder) typed(typ string, schema map[string]any) *typeExpr {
+	switch typ {
+	case "string":
+		return &typeExpr{Kind: kindString, Schema: schema}
+	case "integer":
+		return &typeExpr{Kind: kindInteger, Schema: schema}
+	case "number":
+		return &typeExpr{Kind: kindNumber, Schema: schema}
+	case "boolean":
+		return &typeExpr{Kind: kindBoolean, Schema: schema}
+	case "null":
+		return &typeExpr{Kind: kindNull, Schema: schema}
+	case "array":
+		e := &typeExpr{Kind: kindArray, Schema: schema}
+		if items, ok := schema["items"].(map[string]any); ok {
+			e.Items = b.expr(items)
+		} else {
+			e.Items = &typeExpr{Kind: kindAny}
+		}
+		return e
+	case "object":
+		props, _ := schema["properties"].(map[string]any)
+		additional, hasAdditional := schema["additionalProperties"].(map[string]any)
+		if len(props) == 0 && hasAdditional {
+			return &typeExpr{Kind: kindMap, Values: b.expr(additional), Schema: schema}
+		}
+		e := &typeExpr{Kind: kindObject, Schema: schema}
+		if hasAdditional {
+			e.Values = b.expr(additional)
+		}
+		required := make(map[string]bool)
+		for _, name := range requiredNames(schema) {
+			required[name] = true
+		}
+		for _, name := range sortedKeys(props) {
+			prop, _ := props[name].(map[string]any)
+			e.Fields = append(e.Fields, &field{
+				Name:        name,
+				Required:    required[name],
+				Description: firstString(prop, "description", ""),
+				Expr:        b.expr(prop),
+			})
+		}
+		return e
+	default:
+		return &typeExpr{Kind: kindAny, Schema: schema}
+	}
+}
+
+// enumBase returns the kind of enum values from the schema type or from the values themselves.
+func (b *modelBuilder) enumBase(schema map[string]any, values []any) kind {
+	if typ, ok := schema["type"].(string); ok {
+		return b.typed(typ, map[string]any{}).Kind
+	}
+	base := kindAny
+	for i, v := range values {
+		var k kind
+		switch v.(type) {
+		case string:
+			k = kindString
+		case float64, int, int64:
+			k = kindNumber
+		case bool:
+			k = kindBoolean
+		default:
+			return kindAny
+		}
+		if i > 0 && k != base {
+			return kindAny
+		}
+		base = k
+	}
+	return base
+}
+
+// EntityNames returns type names of the entities derived from entity names of their CTIs, e.g. UserCreated
+// for cti.x.y.event.v1.0~x.y.user_created.v1.0. Conflicting names are qualified with the package and then
+// with the version, e.g. XYUserCreated and XYUserCreatedV2.
+func EntityNames(entities metadata.Entities) map[string]string {
+	res := make(map[string]string, len(entities))
+	candidates := func(cti string, level int) string {
+		tail := cti[strings.LastIndex(cti, "~")+1:]
+		tail = strings.TrimPrefix(tail, "cti.")
+		parts := strings.Split(tail, ".")
+		if len(parts) < 5 {
+			// Anonymous entities are named after their UUIDs.
+			return exportedName(tail)
+		}
+		vendor, pkg := parts[0], parts[1]
+		name := strings.Join(parts[2:len(parts)-2], "_")
+		major, minor := strings.TrimPrefix(parts[len(parts)-2], "v"), parts[len(parts)-1]
+		switch level {
+		case 0:
+			return exportedName(name)
+		case 1:
+			return exportedName(vendor + "_" + pkg + "_" + name)
+		case 2:
+			return exportedName(vendor+"_"+pkg+"_"+name) + "V" + major
+		default:
+			return exportedName(vendor+"_"+pkg+"_"+name) + "V" + major + "_" + minor
+		}
+	}
+
+	pending := make([]string, 0, len(entities))
+	for _, entity := range entities {
+		pending = append(pending, entity.Cti)
+	}
+	for level := 0; len(pending) > 0; level++ {
+		byName := make(map[string][]string)
+		for _, cti := range pending {
+			name := candidates(cti, level)
+			byName[name] = append(byName[name], cti)
+		}
+		pending = pending[:0]
+		for name, ctis := range byName {
+			if len(ctis) == 1 || level >= 3 {
+				sort.Strings(ctis)
+				for i, cti := range ctis {
+					if i == 0 {
+						res[cti] = name
+					} else {
+						res[cti] = name + "_" + strconv.Itoa(i+1)
+					}
+				}
+				continue
+			}
+			pending = append(pending, ctis...)
+		}
+		sort.Strings(pending)
+	}
+	return res
+}
+
+// exportedName converts the name into PascalCase, e.g. user_created becomes UserCreated.
+func exportedName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	s := b.String()
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "T" + s
+	}
+	return s
+}
+
+// ctiLineage returns the CTI without versions, e.g. cti.x.y.event~x.y.user_created. Versions of a type
+// share the lineage.
+func ctiLineage(expr cti.Expression) string {
+	var names []string
+	for n := expr.Head; n != nil; n = n.Child {
+		names = append(names, string(n.Vendor)+"."+string(n.Package)+"."+string(n.EntityName))
+	}
+	return "cti." + strings.Join(names, "~")
+}
+
+func uniqueName(name string, used map[string]bool) string {
+	res := name
+	for i := 2; used[res]; i++ {
+		res = fmt.Sprintf("%s%d", name, i)
+	}
+	used[res] = true
+	return res
+}
+
+// requiredNames returns names of required properties of the schema. Merged schemas keep them as []string.
+func requiredNames(schema map[string]any) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []any:
+		res := make([]string, 0, len(required))
+		for _, name := range required {
+			if s, ok := name.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	default:
+		return nil
+	}
+}
+
+func firstString(schema map[string]any, key, fallback string) string {
+	if s, ok := schema[key].(string); ok && s != "" {
+		return s
+	}
+	return fallback
+}
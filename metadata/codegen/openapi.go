@@ -0,0 +1,124 @@
+package codegen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+const (
+	// OpenAPIVersion is a version of generated OpenAPI documents. It uses JSON Schema 2020-12 for schemas.
+	OpenAPIVersion = "3.1.0"
+
+	// CtiExtension is a specification extension that holds the CTI of the component schema.
+	CtiExtension = "x-cti"
+
+	openAPISchemasKey = "components/schemas"
+)
+
+// DocumentFormat is a serialization format of generated documents.
+type DocumentFormat string
+
+const (
+	DocumentFormatYAML DocumentFormat = "yaml"
+	DocumentFormatJSON DocumentFormat = "json"
+)
+
+type OpenAPIOptions struct {
+	Title   string
+	Version string
+	Format  DocumentFormat
+}
+
+type openAPIDocument struct {
+	OpenAPI    string            `json:"openapi" yaml:"openapi"`
+	Info       openAPIInfo       `json:"info" yaml:"info"`
+	Components openAPIComponents `json:"components" yaml:"components"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title" yaml:"title"`
+	Version string `json:"version" yaml:"version"`
+}
+
+type openAPIComponents struct {
+	Schemas map[string]any `json:"schemas" yaml:"schemas"`
+}
+
+// OpenAPI generates an OpenAPI document with the types in components/schemas. Types are named after
+// their CTI with ~ replaced by -, e.g. cti.x.y.event.v1.0-x.y.user_created.v1.0, since component names
+// cannot contain ~. The CTI itself is kept in the x-cti extension. Definitions the types refer to
+// are added as separate components.
+func (g *Generator) OpenAPI(entities metadata.Entities, opts OpenAPIOptions) ([]File, error) {
+	if opts.Format == "" {
+		opts.Format = DocumentFormatYAML
+	}
+	if opts.Title == "" {
+		opts.Title = "CTI types"
+	}
+	if opts.Version == "" {
+		opts.Version = "1.0.0"
+	}
+
+	doc := &packageDocument{defs: make(map[string]any)}
+	for _, typ := range sortedTypes(entities) {
+		root, defs, err := g.bundle(typ, false)
+		if err != nil {
+			return nil, err
+		}
+		name := ComponentName(typ.Cti)
+		doc.add(name, root, defs, openAPISchemasKey)
+		root[CtiExtension] = typ.Cti
+	}
+
+	for _, schema := range doc.defs {
+		translateSchema(schema.(map[string]any), Draft202012)
+	}
+
+	res := openAPIDocument{
+		OpenAPI:    OpenAPIVersion,
+		Info:       openAPIInfo{Title: opts.Title, Version: opts.Version},
+		Components: openAPIComponents{Schemas: doc.defs},
+	}
+	data, err := encodeDocument(res, opts.Format)
+	if err != nil {
+		return nil, err
+	}
+	return []File{{Name: "openapi." + string(opts.Format), Data: data}}, nil
+}
+
+// encodeDocument serializes the document in the format.
+func encodeDocument(doc any, format DocumentFormat) ([]byte, error) {
+	var data []byte
+	var err error
+	switch format {
+	case DocumentFormatYAML:
+		var buf bytes.Buffer
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err = enc.Encode(doc); err == nil {
+			err = enc.Close()
+		}
+		data = buf.Bytes()
+	case DocumentFormatJSON:
+		if data, err = json.MarshalIndent(doc, "", "  "); err == nil {
+			data = append(data, '\n')
+		}
+	default:
+		return nil, fmt.Errorf("unsupported format %s", format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("serialize document: %w", err)
+	}
+	return data, nil
+}
+
+// ComponentName returns the name of the OpenAPI component schema of the CTI type.
+func ComponentName(cti string) string {
+	return strings.ReplaceAll(cti, "~", "-")
+}
@@ -0,0 +1,56 @@
+package codegen
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+func Test_OpenAPI(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	files, err := g.OpenAPI(r.Entities(), OpenAPIOptions{Title: "x.y"})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "openapi.yaml", files[0].Name)
+
+	var doc map[string]any
+	require.NoError(t, yaml.Unmarshal(files[0].Data, &doc))
+	require.Equal(t, "3.1.0", doc["openapi"])
+	require.Equal(t, map[string]any{"title": "x.y", "version": "1.0.0"}, doc["info"])
+
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	require.Equal(t, []string{
+		"Person",
+		"Tag",
+		"cti.x.y.event.v1.0",
+		"cti.x.y.event.v1.0-x.y.user_created.v1.0",
+	}, sortedKeys(schemas))
+
+	userCreated := schemas["cti.x.y.event.v1.0-x.y.user_created.v1.0"].(map[string]any)
+	require.Equal(t, "cti.x.y.event.v1.0~x.y.user_created.v1.0", userCreated["x-cti"])
+	require.NotContains(t, userCreated, "x-custom")
+	require.Equal(t, map[string]any{"$ref": "#/components/schemas/Person"},
+		userCreated["properties"].(map[string]any)["manager"])
+}
+
+func Test_OpenAPIJSON(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	event, _ := r.GetType("cti.x.y.event.v1.0")
+	files, err := g.OpenAPI(metadata.Entities{event}, OpenAPIOptions{Format: DocumentFormatJSON})
+	require.NoError(t, err)
+	require.Equal(t, "openapi.json", files[0].Name)
+
+	var doc map[string]any
+	require.NoError(t, json.Unmarshal(files[0].Data, &doc))
+	require.Equal(t, "CTI types", doc["info"].(map[string]any)["title"])
+	schemas := doc["components"].(map[string]any)["schemas"].(map[string]any)
+	require.Equal(t, []string{"Tag", "cti.x.y.event.v1.0"}, sortedKeys(schemas))
+}
@@ -0,0 +1,506 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+const (
+	ProtoFile     = "types.proto"
+	ProtoLockFile = "types.proto.lock.json"
+
+	// DefaultProtoPackage is a name of the generated proto package if not specified.
+	DefaultProtoPackage = "cti.types"
+
+	protoHeader = "// Code generated by cti gen proto. DO NOT EDIT.\n"
+
+	protoStructImport    = "google/protobuf/struct.proto"
+	protoTimestampImport = "google/protobuf/timestamp.proto"
+
+	// Field numbers from 19000 to 19999 are reserved by the protobuf implementation.
+	protoReservedFirst = 19000
+	protoReservedLast  = 19999
+)
+
+type ProtoOptions struct {
+	// Package is a name of the generated proto package.
+	Package string
+	// GoPackage is a value of the go_package option. The option is omitted if empty.
+	GoPackage string
+	// Numbering holds numbers assigned by previous runs. It is updated and returned as the lock file.
+	Numbering *ProtoNumbering
+}
+
+// ProtoNumbering holds numbers of fields of messages and values of enums by their names. Numbers are assigned
+// once and kept across runs, so generated messages stay wire compatible as types evolve. Numbers of removed
+// fields are reserved and reused if fields with the same names are added back.
+type ProtoNumbering struct {
+	Messages map[string]*ProtoNumbers `json:"messages"`
+	Enums    map[string]*ProtoNumbers `json:"enums"`
+}
+
+type ProtoNumbers struct {
+	Numbers  map[string]int `json:"numbers"`
+	Reserved map[string]int `json:"reserved,omitempty"`
+}
+
+// assign returns numbers of the names. Known names keep their numbers, new names get numbers following
+// the largest one in order of the names. Numbers of missing names are reserved.
+func (n *ProtoNumbers) assign(names []string) map[string]int {
+	if n.Numbers == nil {
+		n.Numbers = make(map[string]int)
+	}
+	if n.Reserved == nil {
+		n.Reserved = make(map[string]int)
+	}
+	next := 1
+	for _, numbers := range []map[string]int{n.Numbers, n.Reserved} {
+		for _, num := range numbers {
+			if num >= next {
+				next = num + 1
+			}
+		}
+	}
+
+	present := make(map[string]bool, len(names))
+	for _, name := range names {
+		present[name] = true
+	}
+	for name, num := range n.Numbers {
+		if !present[name] {
+			n.Reserved[name] = num
+			delete(n.Numbers, name)
+		}
+	}
+	for _, name := range names {
+		if _, ok := n.Numbers[name]; ok {
+			continue
+		}
+		if num, ok := n.Reserved[name]; ok {
+			n.Numbers[name] = num
+			delete(n.Reserved, name)
+			continue
+		}
+		if next >= protoReservedFirst && next <= protoReservedLast {
+			next = protoReservedLast + 1
+		}
+		n.Numbers[name] = next
+		next++
+	}
+	return n.Numbers
+}
+
+// reserved returns reserved numbers and names sorted by numbers.
+func (n *ProtoNumbers) reserved() ([]int, []string) {
+	names := sortedKeys(n.Reserved)
+	sort.SliceStable(names, func(a, b int) bool {
+		return n.Reserved[names[a]] < n.Reserved[names[b]]
+	})
+	numbers := make([]int, 0, len(names))
+	for _, name := range names {
+		numbers = append(numbers, n.Reserved[name])
+	}
+	return numbers, names
+}
+
+func numbersOf(m map[string]*ProtoNumbers, name string) *ProtoNumbers {
+	n, ok := m[name]
+	if !ok {
+		n = &ProtoNumbers{}
+		m[name] = n
+	}
+	return n
+}
+
+// Proto generates proto3 messages of the types into a single file along with the lock file of the numbering.
+// Objects nested into properties become separate messages, string enums become enums prefixed with their names.
+// Properties that cannot be typed in proto3 (unions, nested arrays, etc.) use google.protobuf.Value,
+// ListValue and Struct, date-time strings use google.protobuf.Timestamp.
+func (g *Generator) Proto(entities metadata.Entities, opts ProtoOptions) ([]File, error) {
+	if opts.Package == "" {
+		opts.Package = DefaultProtoPackage
+	}
+	for _, part := range strings.Split(opts.Package, ".") {
+		if !token.IsIdentifier(part) {
+			return nil, fmt.Errorf("invalid package name %s", opts.Package)
+		}
+	}
+	numbering := opts.Numbering
+	if numbering == nil {
+		numbering = &ProtoNumbering{}
+	}
+	if numbering.Messages == nil {
+		numbering.Messages = make(map[string]*ProtoNumbers)
+	}
+	if numbering.Enums == nil {
+		numbering.Enums = make(map[string]*ProtoNumbers)
+	}
+
+	m, err := g.model(entities)
+	if err != nil {
+		return nil, err
+	}
+	pg := &protoGenerator{numbering: numbering, used: make(map[string]bool), enums: make(map[string]bool)}
+	for _, nt := range m.types {
+		pg.used[nt.Name] = true
+		if nt.Expr.Kind == kindEnum && nt.Expr.Base == kindString {
+			pg.enums[nt.Name] = true
+		}
+	}
+	for _, nt := range m.types {
+		var doc []string
+		if nt.Description != "" && nt.Description != nt.Name {
+			doc = append(doc, strings.Split(nt.Description, "\n")...)
+		}
+		if nt.Entity != nil {
+			if len(doc) > 0 {
+				doc = append(doc, "")
+			}
+			doc = append(doc, "CTI: "+nt.Entity.Cti)
+		}
+		pg.generate(&protoDecl{name: nt.Name, doc: doc, expr: nt.Expr})
+	}
+
+	var b strings.Builder
+	b.WriteString(protoHeader)
+	b.WriteString("\nsyntax = \"proto3\";\n")
+	fmt.Fprintf(&b, "\npackage %s;\n", opts.Package)
+	if len(pg.imports) > 0 {
+		b.WriteString("\n")
+		for _, imp := range sortedKeys(pg.imports) {
+			fmt.Fprintf(&b, "import %s;\n", strconv.Quote(imp))
+		}
+	}
+	if opts.GoPackage != "" {
+		fmt.Fprintf(&b, "\noption go_package = %s;\n", strconv.Quote(opts.GoPackage))
+	}
+	b.WriteString(pg.body.String())
+
+	lock, err := json.MarshalIndent(numbering, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("serialize numbering: %w", err)
+	}
+	return []File{
+		{Name: ProtoFile, Data: []byte(b.String())},
+		{Name: ProtoLockFile, Data: append(lock, '\n')},
+	}, nil
+}
+
+// protoDecl is a declaration of a generated message or enum.
+type protoDecl struct {
+	name string
+	doc  []string
+	expr *typeExpr
+}
+
+type protoGenerator struct {
+	numbering *ProtoNumbering
+	// used holds names of the package scope, enums holds names of generated enums.
+	used    map[string]bool
+	enums   map[string]bool
+	imports map[string]bool
+	// pending holds types nested into the declaration being generated.
+	pending []*protoDecl
+
+	body strings.Builder
+}
+
+// generate writes the declaration followed by types nested into it.
+func (g *protoGenerator) generate(d *protoDecl) {
+	g.pending = nil
+	g.writeDecl(d)
+	nested := g.pending
+	for _, n := range nested {
+		g.generate(n)
+	}
+}
+
+func (g *protoGenerator) nest(hint string, e *typeExpr) string {
+	name := uniqueName(hint, g.used)
+	if e.Kind == kindEnum {
+		g.enums[name] = true
+	}
+	g.pending = append(g.pending, &protoDecl{name: name, expr: e})
+	return name
+}
+
+func (g *protoGenerator) use(imp string) {
+	if g.imports == nil {
+		g.imports = make(map[string]bool)
+	}
+	g.imports[imp] = true
+}
+
+func (g *protoGenerator) writeDecl(d *protoDecl) {
+	e := d.expr
+	g.body.WriteString("\n")
+	writeProtoDoc(&g.body, "", d.doc)
+	switch {
+	case e.Kind == kindObject && len(e.Fields) > 0:
+		g.writeMessage(d.name, e.Fields)
+	case e.Kind == kindEnum && e.Base == kindString:
+		g.writeEnum(d.name, e)
+	default:
+		// Messages cannot be aliases, so other types are wrapped.
+		g.writeMessage(d.name, []*field{{Name: "value", Required: true, Expr: e}})
+	}
+}
+
+type protoField struct {
+	name     string
+	typ      string
+	label    string
+	jsonName string
+	doc      string
+	number   int
+}
+
+func (g *protoGenerator) writeMessage(name string, fields []*field) {
+	used := make(map[string]bool)
+	res := make([]*protoField, 0, len(fields))
+	names := make([]string, 0, len(fields))
+	for _, f := range fields {
+		pf := &protoField{name: uniqueName(protoFieldName(f.Name), used), doc: f.Description}
+		var nullable bool
+		if f.Expr.Kind == kindUnion {
+			_, nullable = f.Expr.nullable()
+		}
+		pf.typ, pf.label = g.protoType(f.Expr, name+exportedName(f.Name))
+		if pf.label == "" && (!f.Required || nullable) && g.scalar(pf.typ) {
+			pf.label = "optional"
+		}
+		if protoDefaultJSONName(pf.name) != f.Name {
+			pf.jsonName = f.Name
+		}
+		res = append(res, pf)
+		names = append(names, pf.name)
+	}
+
+	numbers := numbersOf(g.numbering.Messages, name)
+	assigned := numbers.assign(names)
+	for _, pf := range res {
+		pf.number = assigned[pf.name]
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].number < res[b].number
+	})
+
+	fmt.Fprintf(&g.body, "message %s {\n", name)
+	for _, pf := range res {
+		if pf.doc != "" {
+			writeProtoDoc(&g.body, "  ", strings.Split(pf.doc, "\n"))
+		}
+		g.body.WriteString("  ")
+		if pf.label != "" {
+			g.body.WriteString(pf.label + " ")
+		}
+		fmt.Fprintf(&g.body, "%s %s = %d", pf.typ, pf.name, pf.number)
+		if pf.jsonName != "" {
+			fmt.Fprintf(&g.body, " [json_name = %s]", strconv.Quote(pf.jsonName))
+		}
+		g.body.WriteString(";\n")
+	}
+	writeProtoReserved(&g.body, numbers)
+	g.body.WriteString("}\n")
+}
+
+func (g *protoGenerator) writeEnum(name string, e *typeExpr) {
+	prefix := upperSnake(name) + "_"
+	var values []string
+	for _, v := range e.Enum {
+		if s, ok := v.(string); ok {
+			values = append(values, s)
+		}
+	}
+	numbers := numbersOf(g.numbering.Enums, name)
+	assigned := numbers.assign(values)
+	sort.SliceStable(values, func(a, b int) bool {
+		return assigned[values[a]] < assigned[values[b]]
+	})
+
+	fmt.Fprintf(&g.body, "enum %s {\n", name)
+	fmt.Fprintf(&g.body, "  %sUNSPECIFIED = 0;\n", prefix)
+	g.used[prefix+"UNSPECIFIED"] = true
+	for _, v := range values {
+		suffix := upperSnake(v)
+		if suffix == "" {
+			suffix = "EMPTY"
+		}
+		valueName := uniqueName(prefix+suffix, g.used)
+		fmt.Fprintf(&g.body, "  %s = %d;\n", valueName, assigned[v])
+	}
+	writeProtoReserved(&g.body, numbers)
+	g.body.WriteString("}\n")
+}
+
+// protoType returns the proto type of the expression and its label (repeated for arrays). Nested objects
+// and string enums are declared as separate types named after the hint.
+func (g *protoGenerator) protoType(e *typeExpr, hint string) (string, string) {
+	switch e.Kind {
+	case kindString:
+		if e.format() == "date-time" {
+			g.use(protoTimestampImport)
+			return "google.protobuf.Timestamp", ""
+		}
+		return "string", ""
+	case kindInteger:
+		return "int64", ""
+	case kindNumber:
+		return "double", ""
+	case kindBoolean:
+		return "bool", ""
+	case kindRef:
+		return e.Ref.Name, ""
+	case kindArray:
+		typ, label := g.protoType(e.Items, hint+"Item")
+		switch {
+		case label == "repeated":
+			g.use(protoStructImport)
+			return "google.protobuf.ListValue", "repeated"
+		case strings.HasPrefix(typ, "map<"):
+			g.use(protoStructImport)
+			return "google.protobuf.Struct", "repeated"
+		default:
+			return typ, "repeated"
+		}
+	case kindMap:
+		return g.protoMap(e.Values, hint), ""
+	case kindObject:
+		if len(e.Fields) > 0 {
+			return g.nest(hint, e), ""
+		}
+		if e.Values != nil {
+			return g.protoMap(e.Values, hint), ""
+		}
+		g.use(protoStructImport)
+		return "google.protobuf.Struct", ""
+	case kindEnum:
+		switch e.Base {
+		case kindString:
+			return g.nest(hint, e), ""
+		case kindInteger:
+			return "int64", ""
+		case kindNumber:
+			return "double", ""
+		case kindBoolean:
+			return "bool", ""
+		}
+	case kindUnion:
+		if base, nullable := e.nullable(); nullable && base.Kind != kindUnion {
+			return g.protoType(base, hint)
+		}
+	}
+	g.use(protoStructImport)
+	return "google.protobuf.Value", ""
+}
+
+// protoMap returns the map type with values of the expression. Values of maps cannot be repeated or maps.
+func (g *protoGenerator) protoMap(values *typeExpr, hint string) string {
+	typ, label := g.protoType(values, hint+"Value")
+	switch {
+	case label == "repeated":
+		g.use(protoStructImport)
+		typ = "google.protobuf.ListValue"
+	case strings.HasPrefix(typ, "map<"):
+		g.use(protoStructImport)
+		typ = "google.protobuf.Struct"
+	}
+	return "map<string, " + typ + ">"
+}
+
+// scalar tells whether the type is a scalar or an enum, i.e. has no presence unless it is optional.
+func (g *protoGenerator) scalar(typ string) bool {
+	switch typ {
+	case "string", "int64", "double", "bool":
+		return true
+	default:
+		return g.enums[typ]
+	}
+}
+
+func writeProtoReserved(b *strings.Builder, numbers *ProtoNumbers) {
+	reserved, names := numbers.reserved()
+	if len(reserved) == 0 {
+		return
+	}
+	nums := make([]string, 0, len(reserved))
+	for _, num := range reserved {
+		nums = append(nums, strconv.Itoa(num))
+	}
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, strconv.Quote(name))
+	}
+	fmt.Fprintf(b, "  reserved %s;\n", strings.Join(nums, ", "))
+	fmt.Fprintf(b, "  reserved %s;\n", strings.Join(quoted, ", "))
+}
+
+func writeProtoDoc(b *strings.Builder, indent string, lines []string) {
+	for _, line := range lines {
+		if line == "" {
+			fmt.Fprintf(b, "%s//\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s// %s\n", indent, line)
+	}
+}
+
+// protoFieldName converts the property name into a snake_case field name, e.g. createdAt becomes created_at.
+func protoFieldName(name string) string {
+	s := strings.ToLower(snake(name))
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "f_" + s
+	}
+	return strings.TrimSuffix(s, "_")
+}
+
+// upperSnake converts the name into UPPER_SNAKE_CASE, e.g. EventSeverity becomes EVENT_SEVERITY.
+func upperSnake(name string) string {
+	return strings.ToUpper(snake(name))
+}
+
+// snake splits words of the name by underscores. Words are separated by non-alphanumeric characters
+// and by upper case letters following lower case letters or digits.
+func snake(name string) string {
+	var b strings.Builder
+	var prev rune
+	for _, r := range name {
+		switch {
+		case r > unicode.MaxASCII || !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			r = '_'
+		case unicode.IsUpper(r) && (unicode.IsLower(prev) || unicode.IsDigit(prev)):
+			b.WriteRune('_')
+		}
+		if r == '_' && (prev == '_' || b.Len() == 0) {
+			continue
+		}
+		b.WriteRune(r)
+		prev = r
+	}
+	return strings.TrimSuffix(b.String(), "_")
+}
+
+// protoDefaultJSONName returns the JSON name protoc derives from the field name, e.g. created_at becomes createdAt.
+func protoDefaultJSONName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
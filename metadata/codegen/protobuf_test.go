@@ -0,0 +1,201 @@
+package codegen
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Proto(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	files, err := g.Proto(r.Entities(), ProtoOptions{GoPackage: "example.com/types"})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, ProtoFile, files[0].Name)
+	require.Equal(t, `// Code generated by cti gen proto. DO NOT EDIT.
+
+syntax = "proto3";
+
+package cti.types;
+
+option go_package = "example.com/types";
+
+// CTI: cti.x.y.event.v1.0
+message Event {
+  string id = 1;
+  optional EventSeverity severity = 2;
+  repeated Tag tags = 3;
+}
+
+enum EventSeverity {
+  EVENT_SEVERITY_UNSPECIFIED = 0;
+  EVENT_SEVERITY_LOW = 1;
+  EVENT_SEVERITY_HIGH = 2;
+}
+
+// User created
+//
+// CTI: cti.x.y.event.v1.0~x.y.user_created.v1.0
+message UserCreated {
+  string id = 1;
+  string login = 2;
+  Person manager = 3;
+  optional UserCreatedSeverity severity = 4;
+  repeated Tag tags = 5;
+}
+
+enum UserCreatedSeverity {
+  USER_CREATED_SEVERITY_UNSPECIFIED = 0;
+  USER_CREATED_SEVERITY_LOW = 1;
+  USER_CREATED_SEVERITY_HIGH = 2;
+}
+
+message Person {
+  optional string name = 1;
+  repeated Person reports = 2;
+}
+
+message Tag {
+  string name = 1;
+}
+`, string(files[0].Data))
+
+	require.Equal(t, ProtoLockFile, files[1].Name)
+	var numbering ProtoNumbering
+	require.NoError(t, json.Unmarshal(files[1].Data, &numbering))
+	require.Equal(t, map[string]int{"id": 1, "severity": 2, "tags": 3}, numbering.Messages["Event"].Numbers)
+	require.Equal(t, map[string]int{"low": 1, "high": 2}, numbering.Enums["EventSeverity"].Numbers)
+
+	// Numbers of previous runs are kept, numbers of removed fields are reserved.
+	files, err = g.Proto(r.Entities(), ProtoOptions{Package: "x.y.v1", Numbering: &ProtoNumbering{
+		Messages: map[string]*ProtoNumbers{
+			"Event": {Numbers: map[string]int{"tags": 1, "source": 2}, Reserved: map[string]int{"id": 3, "old": 4}},
+		},
+		Enums: map[string]*ProtoNumbers{
+			"EventSeverity": {Numbers: map[string]int{"high": 1, "medium": 2}},
+		},
+	}})
+	require.NoError(t, err)
+	require.Contains(t, string(files[0].Data), "package x.y.v1;\n")
+	require.Contains(t, string(files[0].Data), `message Event {
+  repeated Tag tags = 1;
+  string id = 3;
+  optional EventSeverity severity = 5;
+  reserved 2, 4;
+  reserved "source", "old";
+}`)
+	require.Contains(t, string(files[0].Data), `enum EventSeverity {
+  EVENT_SEVERITY_UNSPECIFIED = 0;
+  EVENT_SEVERITY_HIGH = 1;
+  EVENT_SEVERITY_LOW = 3;
+  reserved 2;
+  reserved "medium";
+}`)
+
+	_, err = g.Proto(r.Entities(), ProtoOptions{Package: "x.1y"})
+	require.EqualError(t, err, "invalid package name x.1y")
+}
+
+func Test_ProtoNumbersAssign(t *testing.T) {
+	n := &ProtoNumbers{}
+	require.Equal(t, map[string]int{"a": 1, "b": 2, "c": 3}, n.assign([]string{"a", "b", "c"}))
+	require.Equal(t, map[string]int{"a": 1, "c": 3, "d": 4}, n.assign([]string{"a", "c", "d"}))
+	require.Equal(t, map[string]int{"b": 2}, n.Reserved)
+	require.Equal(t, map[string]int{"a": 1, "b": 2}, n.assign([]string{"a", "b"}))
+	require.Equal(t, map[string]int{"c": 3, "d": 4}, n.Reserved)
+
+	// Numbers reserved by the protobuf implementation are skipped.
+	n = &ProtoNumbers{Numbers: map[string]int{"a": 18999}}
+	require.Equal(t, map[string]int{"a": 18999, "b": 20000}, n.assign([]string{"a", "b"}))
+}
+
+func Test_ProtoType(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schema   map[string]any
+		typ      string
+		label    string
+		imports  []string
+		declared []string
+	}{
+		{
+			name:   "nullable string",
+			schema: map[string]any{"type": []any{"string", "null"}},
+			typ:    "string",
+		},
+		{
+			name:    "date-time",
+			schema:  map[string]any{"type": "string", "format": "date-time"},
+			typ:     "google.protobuf.Timestamp",
+			imports: []string{protoTimestampImport},
+		},
+		{
+			name:    "nested arrays",
+			schema:  map[string]any{"type": "array", "items": map[string]any{"type": "array", "items": map[string]any{"type": "integer"}}},
+			typ:     "google.protobuf.ListValue",
+			label:   "repeated",
+			imports: []string{protoStructImport},
+		},
+		{
+			name:    "map of arrays",
+			schema:  map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "array"}},
+			typ:     "map<string, google.protobuf.ListValue>",
+			imports: []string{protoStructImport},
+		},
+		{
+			name:    "union",
+			schema:  map[string]any{"anyOf": []any{map[string]any{"type": "string"}, map[string]any{"type": "number"}}},
+			typ:     "google.protobuf.Value",
+			imports: []string{protoStructImport},
+		},
+		{
+			name: "array of objects",
+			schema: map[string]any{"type": "array", "items": map[string]any{
+				"type": "object", "properties": map[string]any{"kind": map[string]any{"enum": []any{"a", "b"}}},
+			}},
+			typ:      "FItem",
+			label:    "repeated",
+			declared: []string{"FItem"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			g := &protoGenerator{used: make(map[string]bool), enums: make(map[string]bool)}
+			typ, label := g.protoType((&modelBuilder{}).expr(tc.schema), "F")
+			require.Equal(t, tc.typ, typ)
+			require.Equal(t, tc.label, label)
+			require.ElementsMatch(t, tc.imports, sortedKeys(g.imports))
+			var declared []string
+			for _, d := range g.pending {
+				declared = append(declared, d.name)
+			}
+			require.Equal(t, tc.declared, declared)
+		})
+	}
+}
+
+func Test_ProtoNames(t *testing.T) {
+	testCases := []struct {
+		name  string
+		field string
+		json  string
+		upper string
+	}{
+		{name: "createdAt", field: "created_at", json: "createdAt", upper: "CREATED_AT"},
+		{name: "user_id", field: "user_id", json: "userId", upper: "USER_ID"},
+		{name: "b-c", field: "b_c", json: "bC", upper: "B_C"},
+		{name: "2fa", field: "f_2fa", json: "f2fa", upper: "2FA"},
+		{name: "EventSeverity", field: "event_severity", json: "eventSeverity", upper: "EVENT_SEVERITY"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			field := protoFieldName(tc.name)
+			require.Equal(t, tc.field, field)
+			require.Equal(t, tc.json, protoDefaultJSONName(field))
+			require.Equal(t, tc.upper, upperSnake(tc.name))
+		})
+	}
+}
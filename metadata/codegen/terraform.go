@@ -0,0 +1,265 @@
+package codegen
+
+import (
+	"fmt"
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+const (
+	TerraformSchemasFile = "schemas.go"
+
+	// DefaultTerraformPackage is a name of the generated package if not specified.
+	DefaultTerraformPackage = "provider"
+
+	terraformHeader = "// Code generated by cti gen terraform. DO NOT EDIT.\n"
+
+	terraformSchemaPackage = "github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	terraformTypesPackage  = "github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// terraformReserved are names that cannot be used by root attributes of resources.
+var terraformReserved = map[string]bool{
+	"connection": true, "count": true, "depends_on": true, "for_each": true, "lifecycle": true,
+	"provider": true, "provisioner": true,
+}
+
+type TerraformOptions struct {
+	// Package is a name of the generated package.
+	Package string
+}
+
+// Terraform generates stubs of Terraform resource schemas of the types for terraform-plugin-framework:
+// a function that returns the schema and a model struct with tfsdk tags for each type. Nested objects
+// become nested attributes, lists of unique items become sets. Attribute names are snake_case.
+// Values that cannot be typed in Terraform (unions, objects without properties, recursive references)
+// are JSON encoded strings. Types that are not objects are skipped.
+func (g *Generator) Terraform(entities metadata.Entities, opts TerraformOptions) ([]File, error) {
+	if opts.Package == "" {
+		opts.Package = DefaultTerraformPackage
+	}
+	if !token.IsIdentifier(opts.Package) {
+		return nil, fmt.Errorf("invalid package name %s", opts.Package)
+	}
+	m, err := g.model(entities)
+	if err != nil {
+		return nil, err
+	}
+
+	src := &goSource{header: terraformHeader}
+	used := make(map[string]bool)
+	for _, nt := range m.types {
+		if nt.Entity == nil || nt.Expr.Kind != kindObject || len(nt.Expr.Fields) == 0 {
+			continue
+		}
+		src.use(terraformSchemaPackage)
+		src.use(terraformTypesPackage)
+
+		attrs := terraformAttributes(nt.Expr, nil, true)
+		schemaFunc := uniqueName(nt.Name+"Schema", used)
+		model := uniqueName(nt.Name+"Model", used)
+
+		fmt.Fprintf(src, "// %s returns the schema of resources of %s.\n", schemaFunc, nt.Entity.Cti)
+		fmt.Fprintf(src, "func %s() schema.Schema {\n\treturn schema.Schema{\n", schemaFunc)
+		if nt.Description != "" && nt.Description != nt.Name {
+			fmt.Fprintf(src, "\t\tDescription: %s,\n", strconv.Quote(nt.Description))
+		}
+		fmt.Fprintf(src, "\t\tAttributes: %s,\n\t}\n}\n\n", terraformAttributesSource(attrs, "\t\t"))
+
+		fmt.Fprintf(src, "// %s holds values of resources of %s.\n", model, nt.Entity.Cti)
+		fmt.Fprintf(src, "type %s struct {\n", model)
+		fields := make(map[string]bool, len(attrs))
+		for _, a := range attrs {
+			fmt.Fprintf(src, "\t%s %s `tfsdk:%s`\n", uniqueName(goIdentifier(a.name), fields), a.model, strconv.Quote(a.name))
+		}
+		src.WriteString("}\n\n")
+	}
+
+	data, err := src.file(opts.Package)
+	if err != nil {
+		return nil, fmt.Errorf("format %s: %w", TerraformSchemasFile, err)
+	}
+	return []File{{Name: TerraformSchemasFile, Data: data}}, nil
+}
+
+// terraformAttribute is an attribute of a Terraform schema.
+type terraformAttribute struct {
+	name        string
+	description string
+	required    bool
+	// typ is the attribute type, e.g. schema.StringAttribute, model is the type of its value, e.g. types.String.
+	typ   string
+	model string
+	// elem is the type of elements of collections, e.g. types.StringType.
+	elem string
+	// nested holds attributes of nested objects, collection is set for collections of nested objects.
+	nested     []*terraformAttribute
+	collection bool
+}
+
+// terraformScalars are Terraform types of scalar values.
+var terraformScalars = map[kind]string{
+	kindString:  "String",
+	kindInteger: "Int64",
+	kindNumber:  "Float64",
+	kindBoolean: "Bool",
+}
+
+// terraformAttributes returns attributes of properties of the object. Named types on the stack are recursive.
+func terraformAttributes(e *typeExpr, stack map[*namedType]bool, root bool) []*terraformAttribute {
+	res := make([]*terraformAttribute, 0, len(e.Fields))
+	names := make(map[string]bool, len(e.Fields))
+	for _, f := range e.Fields {
+		name := terraformAttributeName(f.Name)
+		if root && terraformReserved[name] {
+			name += "_value"
+		}
+		a := terraformAttr(f.Expr, stack)
+		a.name = uniqueName(name, names)
+		a.required = f.Required
+		a.description = strings.TrimSpace(f.Description + " " + a.description)
+		res = append(res, a)
+	}
+	return res
+}
+
+func terraformAttr(e *typeExpr, stack map[*namedType]bool) *terraformAttribute {
+	e, stack = terraformResolve(e, stack)
+	if e == nil {
+		return terraformJSON()
+	}
+	if s, ok := terraformScalars[e.Kind]; ok {
+		return &terraformAttribute{typ: "schema." + s + "Attribute", model: "types." + s}
+	}
+	switch e.Kind {
+	case kindEnum:
+		s, ok := terraformScalars[e.Base]
+		if !ok {
+			return terraformJSON()
+		}
+		values := make([]string, 0, len(e.Enum))
+		for _, v := range e.Enum {
+			values = append(values, "`"+strings.Trim(literal(v), `"`)+"`")
+		}
+		return &terraformAttribute{
+			typ:         "schema." + s + "Attribute",
+			model:       "types." + s,
+			description: "Allowed values: " + strings.Join(values, ", ") + ".",
+		}
+	case kindObject:
+		if len(e.Fields) > 0 {
+			return &terraformAttribute{
+				typ:    "schema.SingleNestedAttribute",
+				model:  "types.Object",
+				nested: terraformAttributes(e, stack, false),
+			}
+		}
+	case kindArray, kindMap:
+		collection, values := "List", e.Items
+		if e.Kind == kindMap {
+			collection, values = "Map", e.Values
+		} else if unique, _ := e.Schema["uniqueItems"].(bool); unique {
+			collection = "Set"
+		}
+		values, valuesStack := terraformResolve(values, stack)
+		if values == nil {
+			break
+		}
+		scalar := values.Kind
+		if values.Kind == kindEnum {
+			scalar = values.Base
+		}
+		if s, ok := terraformScalars[scalar]; ok {
+			return &terraformAttribute{
+				typ:   "schema." + collection + "Attribute",
+				model: "types." + collection,
+				elem:  "types." + s + "Type",
+			}
+		}
+		if values.Kind == kindObject && len(values.Fields) > 0 {
+			return &terraformAttribute{
+				typ:        "schema." + collection + "NestedAttribute",
+				model:      "types." + collection,
+				nested:     terraformAttributes(values, valuesStack, false),
+				collection: true,
+			}
+		}
+	}
+	return terraformJSON()
+}
+
+// terraformResolve resolves references and removes null from unions. It returns nil for recursive references
+// and unions that cannot be typed.
+func terraformResolve(e *typeExpr, stack map[*namedType]bool) (*typeExpr, map[*namedType]bool) {
+	for {
+		switch e.Kind {
+		case kindRef:
+			if stack[e.Ref] {
+				return nil, stack
+			}
+			nested := make(map[*namedType]bool, len(stack)+1)
+			for nt := range stack {
+				nested[nt] = true
+			}
+			nested[e.Ref] = true
+			e, stack = e.Ref.Expr, nested
+		case kindUnion:
+			base, nullable := e.nullable()
+			if !nullable || base.Kind == kindUnion {
+				return nil, stack
+			}
+			e = base
+		default:
+			return e, stack
+		}
+	}
+}
+
+func terraformJSON() *terraformAttribute {
+	return &terraformAttribute{typ: "schema.StringAttribute", model: "types.String", description: "JSON encoded value."}
+}
+
+// terraformAttributesSource renders the map of attributes.
+func terraformAttributesSource(attrs []*terraformAttribute, indent string) string {
+	var b strings.Builder
+	b.WriteString("map[string]schema.Attribute{\n")
+	for _, a := range attrs {
+		inner := indent + "\t"
+		fmt.Fprintf(&b, "%s%s: %s{\n", inner, strconv.Quote(a.name), a.typ)
+		if a.required {
+			fmt.Fprintf(&b, "%s\tRequired: true,\n", inner)
+		} else {
+			fmt.Fprintf(&b, "%s\tOptional: true,\n", inner)
+		}
+		if a.description != "" {
+			fmt.Fprintf(&b, "%s\tDescription: %s,\n", inner, strconv.Quote(a.description))
+		}
+		if a.elem != "" {
+			fmt.Fprintf(&b, "%s\tElementType: %s,\n", inner, a.elem)
+		}
+		switch {
+		case a.collection:
+			fmt.Fprintf(&b, "%s\tNestedObject: schema.NestedAttributeObject{\n", inner)
+			fmt.Fprintf(&b, "%s\t\tAttributes: %s,\n", inner, terraformAttributesSource(a.nested, inner+"\t\t"))
+			fmt.Fprintf(&b, "%s\t},\n", inner)
+		case a.nested != nil:
+			fmt.Fprintf(&b, "%s\tAttributes: %s,\n", inner, terraformAttributesSource(a.nested, inner+"\t"))
+		}
+		fmt.Fprintf(&b, "%s},\n", inner)
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+// terraformAttributeName converts the property name into snake_case, e.g. createdAt becomes created_at.
+func terraformAttributeName(name string) string {
+	s := strings.ToLower(snake(name))
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "_" + s
+	}
+	return s
+}
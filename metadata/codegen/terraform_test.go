@@ -0,0 +1,147 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+func Test_Terraform(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	event, _ := r.GetType("cti.x.y.event.v1.0")
+	files, err := g.Terraform(metadata.Entities{event}, TerraformOptions{})
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, TerraformSchemasFile, files[0].Name)
+	require.Equal(t, `// Code generated by cti gen terraform. DO NOT EDIT.
+
+package provider
+
+import (
+	"github.com/hashicorp/terraform-plugin-framework/resource/schema"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+)
+
+// EventSchema returns the schema of resources of cti.x.y.event.v1.0.
+func EventSchema() schema.Schema {
+	return schema.Schema{
+		Attributes: map[string]schema.Attribute{
+			"id": schema.StringAttribute{
+				Required: true,
+			},
+			"severity": schema.StringAttribute{
+				Optional:    true,
+				Description: "Allowed values: `+"`low`, `high`"+`.",
+			},
+			"tags": schema.ListNestedAttribute{
+				Optional: true,
+				NestedObject: schema.NestedAttributeObject{
+					Attributes: map[string]schema.Attribute{
+						"name": schema.StringAttribute{
+							Required: true,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// EventModel holds values of resources of cti.x.y.event.v1.0.
+type EventModel struct {
+	ID       types.String `+"`tfsdk:\"id\"`"+`
+	Severity types.String `+"`tfsdk:\"severity\"`"+`
+	Tags     types.List   `+"`tfsdk:\"tags\"`"+`
+}
+`, string(files[0].Data))
+
+	files, err = g.Terraform(r.Entities(), TerraformOptions{Package: "resources"})
+	require.NoError(t, err)
+	// Recursive references are JSON encoded.
+	require.Contains(t, string(files[0].Data), `					"reports": schema.StringAttribute{
+						Optional:    true,
+						Description: "JSON encoded value.",
+					},
+`)
+
+	_, err = g.Terraform(r.Entities(), TerraformOptions{Package: "my-provider"})
+	require.EqualError(t, err, "invalid package name my-provider")
+}
+
+func Test_TerraformAttributes(t *testing.T) {
+	testCases := []struct {
+		name     string
+		schema   map[string]any
+		expected []*terraformAttribute
+	}{
+		{
+			name: "scalars",
+			schema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"createdAt": map[string]any{"type": "string", "description": "Creation time."},
+					"count":     map[string]any{"type": []any{"integer", "null"}},
+					"ratio":     map[string]any{"type": "number"},
+					"2fa":       map[string]any{"type": "boolean"},
+				},
+				"required": []any{"count"},
+			},
+			expected: []*terraformAttribute{
+				{name: "_2fa", typ: "schema.BoolAttribute", model: "types.Bool"},
+				{name: "count_value", typ: "schema.Int64Attribute", model: "types.Int64", required: true},
+				{name: "created_at", typ: "schema.StringAttribute", model: "types.String", description: "Creation time."},
+				{name: "ratio", typ: "schema.Float64Attribute", model: "types.Float64"},
+			},
+		},
+		{
+			name: "collections",
+			schema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"labels": map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "string"}},
+					"ports":  map[string]any{"type": "array", "items": map[string]any{"type": "integer"}, "uniqueItems": true},
+					"rules": map[string]any{"type": "array", "uniqueItems": true, "items": map[string]any{
+						"type":       "object",
+						"properties": map[string]any{"action": map[string]any{"enum": []any{"allow", "deny"}}},
+					}},
+				},
+			},
+			expected: []*terraformAttribute{
+				{name: "labels", typ: "schema.MapAttribute", model: "types.Map", elem: "types.StringType"},
+				{name: "ports", typ: "schema.SetAttribute", model: "types.Set", elem: "types.Int64Type"},
+				{
+					name:       "rules",
+					typ:        "schema.SetNestedAttribute",
+					model:      "types.Set",
+					collection: true,
+					nested: []*terraformAttribute{
+						{name: "action", typ: "schema.StringAttribute", model: "types.String", description: "Allowed values: `allow`, `deny`."},
+					},
+				},
+			},
+		},
+		{
+			name: "untyped values",
+			schema: map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"data":  map[string]any{"type": "object"},
+					"value": map[string]any{"type": []any{"string", "integer"}},
+				},
+			},
+			expected: []*terraformAttribute{
+				{name: "data", typ: "schema.StringAttribute", model: "types.String", description: "JSON encoded value."},
+				{name: "value", typ: "schema.StringAttribute", model: "types.String", description: "JSON encoded value."},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.expected, terraformAttributes((&modelBuilder{}).expr(tc.schema), nil, true))
+		})
+	}
+}
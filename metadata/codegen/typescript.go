@@ -0,0 +1,367 @@
+package codegen
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+const (
+	TypeScriptTypesFile   = "types.ts"
+	TypeScriptCtisFile    = "ctis.ts"
+	TypeScriptSchemasFile = "schemas.ts"
+
+	typeScriptHeader = "// Code generated by cti gen ts. DO NOT EDIT.\n"
+)
+
+var tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+
+type TypeScriptOptions struct {
+	// Zod generates zod validators of the types.
+	Zod bool
+}
+
+// TypeScript generates TypeScript interfaces of the types, a module with constants of CTIs of the entities
+// and, optionally, zod validators of the types. Enums are converted into unions of literal types.
+func (g *Generator) TypeScript(entities metadata.Entities, opts TypeScriptOptions) ([]File, error) {
+	m, err := g.model(entities)
+	if err != nil {
+		return nil, err
+	}
+
+	var types strings.Builder
+	types.WriteString(typeScriptHeader)
+	for _, nt := range m.types {
+		types.WriteString("\n")
+		writeTSDoc(&types, "", nt.Description, nt.Entity)
+		if nt.Expr.Kind == kindObject && nt.Expr.Values == nil && len(nt.Expr.Fields) > 0 {
+			fmt.Fprintf(&types, "export interface %s ", nt.Name)
+			writeTSObject(&types, nt.Expr, "")
+			types.WriteString("\n")
+			continue
+		}
+		fmt.Fprintf(&types, "export type %s = %s;\n", nt.Name, tsType(nt.Expr, ""))
+	}
+
+	files := []File{
+		{Name: TypeScriptTypesFile, Data: []byte(types.String())},
+		{Name: TypeScriptCtisFile, Data: []byte(tsConstants(m, entities))},
+	}
+	if opts.Zod {
+		files = append(files, File{Name: TypeScriptSchemasFile, Data: []byte(zodSchemas(m))})
+	}
+	return files, nil
+}
+
+// tsConstants renders constants of CTIs of types and instances, e.g. EventCti for cti.x.y.event.v1.0.
+func tsConstants(m *typeModel, entities metadata.Entities) string {
+	var b strings.Builder
+	b.WriteString(typeScriptHeader)
+	for _, c := range ctiConstants(m, entities, "Cti", make(map[string]bool)) {
+		b.WriteString("\n")
+		writeTSDoc(&b, "", c.entity.DisplayName, nil)
+		fmt.Fprintf(&b, "export const %s = %s as const;\n", c.name, quote(c.entity.Cti))
+	}
+	return b.String()
+}
+
+type ctiConstant struct {
+	name   string
+	entity *metadata.Entity
+}
+
+// ctiConstants returns constants of CTIs of types named after the types and of instances named after
+// their entity names, both with the suffix. Names are unique among used names. Constants are sorted by CTI.
+func ctiConstants(m *typeModel, entities metadata.Entities, suffix string, used map[string]bool) []ctiConstant {
+	var res []ctiConstant
+	for _, nt := range m.types {
+		if nt.Entity != nil {
+			name := uniqueName(nt.Name+suffix, used)
+			res = append(res, ctiConstant{name: name, entity: nt.Entity})
+		}
+	}
+	var instances metadata.Entities
+	for _, entity := range entities {
+		if entity.IsInstance() {
+			instances = append(instances, entity)
+		}
+	}
+	names := EntityNames(instances)
+	sort.Slice(instances, func(a, b int) bool {
+		return instances[a].Cti < instances[b].Cti
+	})
+	for _, instance := range instances {
+		res = append(res, ctiConstant{name: uniqueName(names[instance.Cti]+suffix, used), entity: instance})
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].entity.Cti < res[b].entity.Cti
+	})
+	return res
+}
+
+func writeTSDoc(b *strings.Builder, indent, description string, entity *metadata.Entity) {
+	var lines []string
+	if description != "" {
+		lines = append(lines, strings.Split(description, "\n")...)
+	}
+	if entity != nil {
+		if len(lines) > 0 {
+			lines = append(lines, "")
+		}
+		lines = append(lines, "CTI: "+entity.Cti)
+	}
+	if len(lines) == 0 {
+		return
+	}
+	if len(lines) == 1 {
+		fmt.Fprintf(b, "%s/** %s */\n", indent, tsComment(lines[0]))
+		return
+	}
+	fmt.Fprintf(b, "%s/**\n", indent)
+	for _, line := range lines {
+		if line == "" {
+			fmt.Fprintf(b, "%s *\n", indent)
+			continue
+		}
+		fmt.Fprintf(b, "%s * %s\n", indent, tsComment(line))
+	}
+	fmt.Fprintf(b, "%s */\n", indent)
+}
+
+func tsComment(s string) string {
+	return strings.ReplaceAll(s, "*/", "*\\/")
+}
+
+func writeTSObject(b *strings.Builder, e *typeExpr, indent string) {
+	b.WriteString("{\n")
+	inner := indent + "  "
+	for _, f := range e.Fields {
+		writeTSDoc(b, inner, f.Description, nil)
+		optional := ""
+		if !f.Required {
+			optional = "?"
+		}
+		fmt.Fprintf(b, "%s%s%s: %s;\n", inner, tsPropertyName(f.Name), optional, tsType(f.Expr, inner))
+	}
+	if e.Values != nil {
+		values := "unknown"
+		if len(e.Fields) == 0 {
+			values = tsType(e.Values, inner)
+		}
+		fmt.Fprintf(b, "%s[key: string]: %s;\n", inner, values)
+	}
+	b.WriteString(indent + "}")
+}
+
+func tsType(e *typeExpr, indent string) string {
+	switch e.Kind {
+	case kindString:
+		return "string"
+	case kindInteger, kindNumber:
+		return "number"
+	case kindBoolean:
+		return "boolean"
+	case kindNull:
+		return "null"
+	case kindRef:
+		return e.Ref.Name
+	case kindArray:
+		items := tsType(e.Items, indent)
+		if e.Items.Kind == kindUnion || e.Items.Kind == kindEnum && len(e.Items.Enum) > 1 {
+			items = "(" + items + ")"
+		}
+		return items + "[]"
+	case kindMap:
+		return "Record<string, " + tsType(e.Values, indent) + ">"
+	case kindObject:
+		if len(e.Fields) == 0 && e.Values == nil {
+			return "Record<string, unknown>"
+		}
+		var b strings.Builder
+		writeTSObject(&b, e, indent)
+		return b.String()
+	case kindUnion:
+		members := make([]string, 0, len(e.Members))
+		for _, m := range e.Members {
+			members = append(members, tsType(m, indent))
+		}
+		return strings.Join(members, " | ")
+	case kindEnum:
+		values := make([]string, 0, len(e.Enum))
+		for _, v := range e.Enum {
+			values = append(values, literal(v))
+		}
+		return strings.Join(values, " | ")
+	default:
+		return "unknown"
+	}
+}
+
+func tsPropertyName(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+	return quote(name)
+}
+
+// zodSchemas renders zod validators of the types. References are lazy, so validators may be declared
+// in any order and may be recursive.
+func zodSchemas(m *typeModel) string {
+	var b strings.Builder
+	b.WriteString(typeScriptHeader)
+	b.WriteString("\nimport { z } from \"zod\";\n\nimport type * as types from \"./types\";\n")
+	for _, nt := range m.types {
+		b.WriteString("\n")
+		fmt.Fprintf(&b, "export const %sSchema: z.ZodType<types.%s> = %s;\n", nt.Name, nt.Name, zodType(nt.Expr, ""))
+	}
+	return b.String()
+}
+
+func zodType(e *typeExpr, indent string) string {
+	switch e.Kind {
+	case kindString:
+		return "z.string()" + zodStringChecks(e)
+	case kindInteger:
+		return "z.number().int()" + zodNumberChecks(e)
+	case kindNumber:
+		return "z.number()" + zodNumberChecks(e)
+	case kindBoolean:
+		return "z.boolean()"
+	case kindNull:
+		return "z.null()"
+	case kindRef:
+		return "z.lazy(() => " + e.Ref.Name + "Schema)"
+	case kindArray:
+		res := "z.array(" + zodType(e.Items, indent) + ")"
+		if v, ok := intFacet(e.Schema, "minItems"); ok {
+			res += fmt.Sprintf(".min(%d)", v)
+		}
+		if v, ok := intFacet(e.Schema, "maxItems"); ok {
+			res += fmt.Sprintf(".max(%d)", v)
+		}
+		return res
+	case kindMap:
+		return "z.record(" + zodType(e.Values, indent) + ")"
+	case kindObject:
+		if len(e.Fields) == 0 && e.Values == nil {
+			return "z.record(z.unknown())"
+		}
+		var b strings.Builder
+		b.WriteString("z.object({\n")
+		inner := indent + "  "
+		for _, f := range e.Fields {
+			value := zodType(f.Expr, inner)
+			if !f.Required {
+				value += ".optional()"
+			}
+			fmt.Fprintf(&b, "%s%s: %s,\n", inner, tsPropertyName(f.Name), value)
+		}
+		b.WriteString(indent + "})")
+		if e.Values != nil {
+			b.WriteString(".catchall(" + zodType(e.Values, indent) + ")")
+		}
+		return b.String()
+	case kindUnion:
+		base, nullable := e.nullable()
+		if nullable && base != e {
+			return zodType(base, indent) + ".nullable()"
+		}
+		members := make([]string, 0, len(e.Members))
+		for _, m := range e.Members {
+			members = append(members, zodType(m, indent))
+		}
+		if len(members) == 1 {
+			return members[0]
+		}
+		return "z.union([" + strings.Join(members, ", ") + "])"
+	case kindEnum:
+		values := make([]string, 0, len(e.Enum))
+		for _, v := range e.Enum {
+			values = append(values, literal(v))
+		}
+		if e.Base == kindString {
+			return "z.enum([" + strings.Join(values, ", ") + "])"
+		}
+		literals := make([]string, 0, len(values))
+		for _, v := range values {
+			literals = append(literals, "z.literal("+v+")")
+		}
+		if len(literals) == 1 {
+			return literals[0]
+		}
+		return "z.union([" + strings.Join(literals, ", ") + "])"
+	default:
+		return "z.unknown()"
+	}
+}
+
+// zodStringFormats are checks of zod string validators for JSON Schema formats.
+var zodStringFormats = map[string]string{
+	"date-time": ".datetime({ offset: true })",
+	"date":      ".date()",
+	"time":      ".time()",
+	"uuid":      ".uuid()",
+	"email":     ".email()",
+	"uri":       ".url()",
+}
+
+func zodStringChecks(e *typeExpr) string {
+	var res string
+	if check, ok := zodStringFormats[e.format()]; ok {
+		res += check
+	}
+	if v, ok := intFacet(e.Schema, "minLength"); ok {
+		res += fmt.Sprintf(".min(%d)", v)
+	}
+	if v, ok := intFacet(e.Schema, "maxLength"); ok {
+		res += fmt.Sprintf(".max(%d)", v)
+	}
+	if pattern, ok := e.Schema["pattern"].(string); ok {
+		res += ".regex(new RegExp(" + quote(pattern) + "))"
+	}
+	return res
+}
+
+func zodNumberChecks(e *typeExpr) string {
+	var res string
+	for _, facet := range []struct{ key, method string }{
+		{"minimum", "gte"}, {"maximum", "lte"}, {"exclusiveMinimum", "gt"}, {"exclusiveMaximum", "lt"}, {"multipleOf", "multipleOf"},
+	} {
+		if v, ok := e.Schema[facet.key]; ok {
+			if _, isBool := v.(bool); !isBool {
+				res += "." + facet.method + "(" + literal(v) + ")"
+			}
+		}
+	}
+	return res
+}
+
+func intFacet(schema map[string]any, key string) (int64, bool) {
+	switch v := schema[key].(type) {
+	case float64:
+		return int64(v), true
+	case int:
+		return int64(v), true
+	case int64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+// literal returns the value as JSON literal, which is valid in TypeScript and other languages.
+func literal(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "null"
+	}
+	return string(data)
+}
+
+func quote(s string) string {
+	return literal(s)
+}
@@ -0,0 +1,159 @@
+package codegen
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+func Test_TypeScript(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	files, err := g.TypeScript(r.Entities(), TypeScriptOptions{})
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, TypeScriptTypesFile, files[0].Name)
+	require.Equal(t, `// Code generated by cti gen ts. DO NOT EDIT.
+
+/** CTI: cti.x.y.event.v1.0 */
+export interface Event {
+  id: string;
+  severity?: "low" | "high";
+  tags?: Tag[];
+}
+
+/**
+ * User created
+ *
+ * CTI: cti.x.y.event.v1.0~x.y.user_created.v1.0
+ */
+export interface UserCreated {
+  id: string;
+  login: string;
+  manager?: Person;
+  severity?: "low" | "high";
+  tags?: Tag[];
+}
+
+export interface Person {
+  name?: string;
+  reports?: Person[];
+}
+
+export interface Tag {
+  name: string;
+}
+`, string(files[0].Data))
+
+	require.Equal(t, TypeScriptCtisFile, files[1].Name)
+	require.Equal(t, `// Code generated by cti gen ts. DO NOT EDIT.
+
+export const EventCti = "cti.x.y.event.v1.0" as const;
+
+/** User created */
+export const UserCreatedCti = "cti.x.y.event.v1.0~x.y.user_created.v1.0" as const;
+
+export const SampleCti = "cti.x.y.event.v1.0~x.y.user_created.v1.0~x.y.sample.v1.0" as const;
+`, string(files[1].Data))
+}
+
+func Test_TypeScriptZod(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	files, err := g.TypeScript(r.Entities(), TypeScriptOptions{Zod: true})
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	require.Equal(t, TypeScriptSchemasFile, files[2].Name)
+	require.Contains(t, string(files[2].Data), `import { z } from "zod";`)
+	require.Contains(t, string(files[2].Data), `export const EventSchema: z.ZodType<types.Event> = z.object({
+  id: z.string().uuid(),
+  severity: z.enum(["low", "high"]).optional(),
+  tags: z.array(z.lazy(() => TagSchema)).optional(),
+});
+`)
+	require.Contains(t, string(files[2].Data), `export const PersonSchema: z.ZodType<types.Person> = z.object({
+  name: z.string().optional(),
+  reports: z.array(z.lazy(() => PersonSchema)).optional(),
+});
+`)
+}
+
+func Test_TypeScriptExpressions(t *testing.T) {
+	testCases := []struct {
+		name   string
+		schema map[string]any
+		ts     string
+		zod    string
+	}{
+		{
+			name:   "nullable integer",
+			schema: map[string]any{"anyOf": []any{map[string]any{"type": "integer", "minimum": 1.0}, map[string]any{"type": "null"}}},
+			ts:     "number | null",
+			zod:    "z.number().int().gte(1).nullable()",
+		},
+		{
+			name:   "union array",
+			schema: map[string]any{"type": "array", "items": map[string]any{"type": []any{"string", "boolean"}}, "maxItems": 3.0},
+			ts:     "(string | boolean)[]",
+			zod:    "z.array(z.union([z.string(), z.boolean()])).max(3)",
+		},
+		{
+			name:   "map",
+			schema: map[string]any{"type": "object", "additionalProperties": map[string]any{"type": "number"}},
+			ts:     "Record<string, number>",
+			zod:    "z.record(z.number())",
+		},
+		{
+			name:   "numeric enum",
+			schema: map[string]any{"enum": []any{1.0, 2.0}},
+			ts:     "1 | 2",
+			zod:    "z.union([z.literal(1), z.literal(2)])",
+		},
+		{
+			name:   "pattern",
+			schema: map[string]any{"type": "string", "format": "date-time", "pattern": `^\d+$`},
+			ts:     "string",
+			zod:    `z.string().datetime({ offset: true }).regex(new RegExp("^\\d+$"))`,
+		},
+		{
+			name: "inline object",
+			schema: map[string]any{"type": "object", "properties": map[string]any{
+				"a-b": map[string]any{"type": "string"},
+			}},
+			ts:  "{\n  \"a-b\"?: string;\n}",
+			zod: "z.object({\n  \"a-b\": z.string().optional(),\n})",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			e := (&modelBuilder{}).expr(tc.schema)
+			require.Equal(t, tc.ts, tsType(e, ""))
+			require.Equal(t, tc.zod, zodType(e, ""))
+		})
+	}
+}
+
+func Test_EntityNames(t *testing.T) {
+	entities := metadata.Entities{
+		{Cti: "cti.a.b.event.v1.0"},
+		{Cti: "cti.a.b.event.v1.0~a.b.created.v1.0"},
+		{Cti: "cti.a.b.event.v1.0~c.d.created.v1.0"},
+		{Cti: "cti.a.b.item.v1.0"},
+		{Cti: "cti.a.b.item.v2.0"},
+		{Cti: "cti.a.b.state.v1.0"},
+		{Cti: "cti.a.b.state.v1.1"},
+	}
+	require.Equal(t, map[string]string{
+		"cti.a.b.event.v1.0":                  "Event",
+		"cti.a.b.event.v1.0~a.b.created.v1.0": "ABCreated",
+		"cti.a.b.event.v1.0~c.d.created.v1.0": "CDCreated",
+		"cti.a.b.item.v1.0":                   "ABItemV1",
+		"cti.a.b.item.v2.0":                   "ABItemV2",
+		"cti.a.b.state.v1.0":                  "ABStateV1_0",
+		"cti.a.b.state.v1.1":                  "ABStateV1_1",
+	}, EntityNames(entities))
+}
@@ -15,6 +15,7 @@ var annotationsToMove = []string{metadata.Reference, metadata.Schema}
 
 type Collector struct {
 	baseDir              string
+	packageID            string
 	raml                 *raml.RAML
 	jsonSchemaConverter  *raml.JSONSchemaConverter
 	annotationsCollector *AnnotationsCollector
@@ -30,18 +31,22 @@ type Collector struct {
 	localRamlCtiTypes  map[string]*raml.BaseShape
 	globalRamlCtiTypes map[string]*raml.BaseShape
 	unwrappedCtiTypes  map[string]*raml.BaseShape
+
+	// definitions holds definition sites of all collected entities to report duplicates.
+	definitions map[string]Definition
 }
 
 func New() *Collector {
 	return &Collector{
 		jsonSchemaConverter:  raml.NewJSONSchemaConverter(raml.WithOmitRefs(true)),
 		annotationsCollector: NewAnnotationsCollector(),
-		ctiParser:            cti.NewParser(),
+		ctiParser:            cti.NewParser(cti.WithAllowAnonymousEntity(true)),
 		LocalRegistry:        NewMetadataRegistry(),
 		GlobalRegistry:       NewMetadataRegistry(),
 		localRamlCtiTypes:    make(map[string]*raml.BaseShape),
 		globalRamlCtiTypes:   make(map[string]*raml.BaseShape),
 		unwrappedCtiTypes:    make(map[string]*raml.BaseShape),
+		definitions:          make(map[string]Definition),
 	}
 }
 
@@ -151,6 +156,11 @@ func (c *Collector) MakeMetadataTypeFromShape(id string, shape *raml.BaseShape)
 	if err != nil {
 		return nil, fmt.Errorf("convert schema: %w", err)
 	}
+	if obj, ok := shape.Shape.(*raml.ObjectShape); ok && obj.Discriminator != nil {
+		if def, ok := schema.Definitions[shape.Name]; ok {
+			setDiscriminator(def, obj)
+		}
+	}
 	schemaBytes, _ := json.Marshal(schema)
 	annotations := c.annotationsCollector.Collect(shape.Shape)
 
@@ -177,6 +187,8 @@ func (c *Collector) MakeMetadataTypeFromShape(id string, shape *raml.BaseShape)
 			},
 			OriginalPath: filepath.ToSlash(originalPath),
 			SourcePath:   filepath.ToSlash(sourcePath),
+			Line:         shape.Line,
+			Column:       shape.Column,
 		},
 		Annotations: annotations,
 	}
@@ -459,12 +471,12 @@ func (c *Collector) readCtiType(base *raml.BaseShape) error {
 	}
 
 	for _, cti := range ctis {
-		if _, ok := c.localRamlCtiTypes[cti]; ok {
-			return fmt.Errorf("duplicate cti.cti: %s", cti)
-		}
 		if _, err := c.ctiParser.Parse(cti); err != nil {
 			return fmt.Errorf("parse cti.cti: %w", err)
 		}
+		if err := c.define(cti, base.Location); err != nil {
+			return err
+		}
 		c.globalRamlCtiTypes[cti] = base
 		c.localRamlCtiTypes[cti] = base
 	}
@@ -530,7 +542,12 @@ func (c *Collector) readAndMakeCtiInstances(annotation *raml.DomainExtension, is
 			return fmt.Errorf("child cti doesn't match parent cti: %w", err)
 		}
 
+		if err := c.define(id, annotation.Extension.Location); err != nil {
+			return err
+		}
 		entity := c.MakeMetadataInstanceFromExtension(id, s, obj, annotation.Extension.Location)
+		entity.SourceMap.Line = annotation.Extension.Line
+		entity.SourceMap.Column = annotation.Extension.Column
 		err = c.GlobalRegistry.Add(entity.SourceMap.OriginalPath, entity)
 		if err != nil {
 			return fmt.Errorf("add cti entity: %w", err)
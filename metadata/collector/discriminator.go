@@ -0,0 +1,21 @@
+package collector
+
+import (
+	"github.com/acronis/go-raml"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+// setDiscriminator stores the discriminator property and the discriminator value of the type in the schema
+// since JSON Schema has no equivalent of RAML discriminator.
+func setDiscriminator(schema *raml.JSONSchema, s *raml.ObjectShape) {
+	value := s.DiscriminatorValue
+	if value == nil {
+		value = s.Base().Name
+	}
+	if schema.Extras == nil {
+		schema.Extras = make(map[string]any)
+	}
+	schema.Extras[metadata.DiscriminatorKey] = *s.Discriminator
+	schema.Extras[metadata.DiscriminatorValueKey] = value
+}
@@ -0,0 +1,62 @@
+package collector
+
+import (
+	"fmt"
+	"path/filepath"
+)
+
+// Definition is a place where CTI entity is defined.
+type Definition struct {
+	// PackageID is an ID of the package that defines the entity. May be empty if unknown.
+	PackageID string
+	// Path is a path to the RAML file relative to the package root.
+	Path string
+}
+
+func (d Definition) String() string {
+	if d.PackageID == "" {
+		return d.Path
+	}
+	return fmt.Sprintf("%s (%s)", d.PackageID, d.Path)
+}
+
+// DuplicateError is returned when the same CTI is defined more than once within the collected packages.
+type DuplicateError struct {
+	Cti      string
+	Previous Definition
+	Current  Definition
+}
+
+func (e *DuplicateError) Error() string {
+	msg := fmt.Sprintf("duplicate cti entity %s: defined in %s and %s", e.Cti, e.Previous, e.Current)
+	if e.Previous.PackageID != e.Current.PackageID {
+		return msg + ". Remove one of the definitions, rename the entity or drop the dependency that redefines it"
+	}
+	return msg + ". Remove one of the definitions or rename the entity"
+}
+
+// SetPackageID sets an ID of the package whose RAML is collected. It is used to report definition sites.
+func (c *Collector) SetPackageID(id string) {
+	c.packageID = id
+}
+
+// Definition returns a place where the entity with the specified CTI is defined.
+func (c *Collector) Definition(cti string) (Definition, bool) {
+	d, ok := c.definitions[cti]
+	return d, ok
+}
+
+// define records a definition of the CTI entity located in the specified file
+// and returns DuplicateError if the entity has been already defined.
+func (c *Collector) define(cti string, location string) error {
+	path := location
+	if rel, err := filepath.Rel(filepath.Dir(c.baseDir), location); err == nil {
+		path = filepath.ToSlash(rel)
+	}
+	current := Definition{PackageID: c.packageID, Path: path}
+	if previous, ok := c.definitions[cti]; ok {
+		return &DuplicateError{Cti: cti, Previous: previous, Current: current}
+	}
+	c.definitions[cti] = current
+	return nil
+}
@@ -0,0 +1,29 @@
+package collector
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Define(t *testing.T) {
+	c := New()
+	c.baseDir = "/dep/x.y/index.raml"
+	c.SetPackageID("x.y")
+	require.NoError(t, c.define("cti.x.y.topic.v1.0", "/dep/x.y/entities/events.raml"))
+
+	c.baseDir = "/app/index.raml"
+	c.SetPackageID("a.app")
+	err := c.define("cti.x.y.topic.v1.0", "/app/entities/main.raml")
+
+	var dupErr *DuplicateError
+	require.True(t, errors.As(err, &dupErr))
+	require.Equal(t, Definition{PackageID: "x.y", Path: "entities/events.raml"}, dupErr.Previous)
+	require.Equal(t, Definition{PackageID: "a.app", Path: "entities/main.raml"}, dupErr.Current)
+	require.ErrorContains(t, err, "defined in x.y (entities/events.raml) and a.app (entities/main.raml)")
+
+	d, ok := c.Definition("cti.x.y.topic.v1.0")
+	require.True(t, ok)
+	require.Equal(t, "x.y", d.PackageID)
+}
@@ -0,0 +1,227 @@
+// Package compiled builds and loads compiled registries: single files holding merged schemas of types
+// and values of instances, so services can load CTI metadata at runtime without parsing RAML and
+// resolving inheritance.
+package compiled
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+	"github.com/acronis/go-cti/metadata/validator"
+)
+
+const (
+	// FormatVersion is a version of the compiled registry format. Registries of other versions are not loaded.
+	FormatVersion = 1
+
+	// FileExtension is an extension of compiled registry files, e.g. package.registry.json.
+	FileExtension = ".registry.json"
+)
+
+// Registry is a compiled registry. Types and instances are indexed by CTI.
+type Registry struct {
+	Version   int                  `json:"version"`
+	PackageID string               `json:"package_id,omitempty"`
+	Types     map[string]*Type     `json:"types"`
+	Instances map[string]*Instance `json:"instances"`
+}
+
+// Type is a compiled CTI type. Schema is merged with schemas of ancestors and holds all definitions
+// it refers to, so it can be used for validation as is. Traits schema, traits and annotations are inherited
+// from the nearest ancestor that defines them.
+type Type struct {
+	Cti          string                                      `json:"cti"`
+	Parent       string                                      `json:"parent,omitempty"`
+	Final        bool                                        `json:"final"`
+	DisplayName  string                                      `json:"display_name,omitempty"`
+	Description  string                                      `json:"description,omitempty"`
+	Schema       json.RawMessage                             `json:"schema"`
+	TraitsSchema json.RawMessage                             `json:"traits_schema,omitempty"`
+	Traits       json.RawMessage                             `json:"traits,omitempty"`
+	Annotations  map[metadata.GJsonPath]metadata.Annotations `json:"annotations,omitempty"`
+}
+
+// Instance is a compiled instance of a CTI type.
+type Instance struct {
+	Cti         string          `json:"cti"`
+	Type        string          `json:"type"`
+	DisplayName string          `json:"display_name,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Values      json.RawMessage `json:"values"`
+}
+
+type compileOptions struct {
+	packageID string
+}
+
+type Option func(*compileOptions)
+
+// WithPackageID records the package the registry is compiled from.
+func WithPackageID(id string) Option {
+	return func(o *compileOptions) {
+		o.packageID = id
+	}
+}
+
+// Compile compiles all entities of the registry. Ancestors of types must be in the registry,
+// e.g. the global registry of the package with dependencies.
+func Compile(r *registry.Registry, opts ...Option) (*Registry, error) {
+	o := compileOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	v := validator.MakeMetadataValidator()
+	v.LoadRegistry(r)
+
+	res := &Registry{
+		Version:   FormatVersion,
+		PackageID: o.packageID,
+		Types:     make(map[string]*Type),
+		Instances: make(map[string]*Instance),
+	}
+	for _, typ := range r.Types() {
+		compiled, err := compileType(r, v, typ)
+		if err != nil {
+			return nil, err
+		}
+		res.Types[typ.Cti] = compiled
+	}
+	for _, instance := range r.Instances() {
+		typeCti := metadata.GetParentCti(instance.Cti)
+		if _, ok := res.Types[typeCti]; !ok {
+			return nil, fmt.Errorf("type %s of instance %s not found", typeCti, instance.Cti)
+		}
+		res.Instances[instance.Cti] = &Instance{
+			Cti:         instance.Cti,
+			Type:        typeCti,
+			DisplayName: instance.DisplayName,
+			Description: instance.Description,
+			Values:      instance.Values,
+		}
+	}
+	return res, nil
+}
+
+func compileType(r *registry.Registry, v *validator.MetadataValidator, typ *metadata.Entity) (*Type, error) {
+	schema, err := v.GetMergedSchema(typ.Cti)
+	if err != nil {
+		return nil, fmt.Errorf("merge schema of %s: %w", typ.Cti, err)
+	}
+	chain := append(metadata.Entities{typ}, r.Ancestors(typ.Cti)...)
+
+	// Definitions of the type take precedence over ones of ancestors.
+	defs := make(map[string]any)
+	for _, entity := range chain {
+		var own map[string]any
+		if err := json.Unmarshal(entity.Schema, &own); err != nil {
+			return nil, fmt.Errorf("decode schema of %s: %w", entity.Cti, err)
+		}
+		ownDefs, _ := own["definitions"].(map[string]any)
+		for name, def := range ownDefs {
+			if _, ok := defs[name]; !ok {
+				defs[name] = def
+			}
+		}
+	}
+	if len(defs) != 0 {
+		schema["definitions"] = defs
+	}
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("encode schema of %s: %w", typ.Cti, err)
+	}
+
+	res := &Type{
+		Cti:         typ.Cti,
+		Final:       typ.Final,
+		DisplayName: typ.DisplayName,
+		Description: typ.Description,
+		Schema:      data,
+	}
+	if parent := metadata.GetParentCti(typ.Cti); parent != typ.Cti {
+		res.Parent = parent
+	}
+	for _, entity := range chain {
+		if res.TraitsSchema == nil && entity.TraitsSchema != nil {
+			res.TraitsSchema = entity.TraitsSchema
+		}
+		if res.Traits == nil && entity.Traits != nil {
+			res.Traits = entity.Traits
+		}
+		for key, a := range entity.Annotations {
+			if res.Annotations == nil {
+				res.Annotations = make(map[metadata.GJsonPath]metadata.Annotations)
+			}
+			if _, ok := res.Annotations[key]; !ok {
+				res.Annotations[key] = a
+			}
+		}
+	}
+	return res, nil
+}
+
+// Write writes the compiled registry as JSON.
+func (r *Registry) Write(w io.Writer) error {
+	if err := json.NewEncoder(w).Encode(r); err != nil {
+		return fmt.Errorf("encode compiled registry: %w", err)
+	}
+	return nil
+}
+
+// Save writes the compiled registry into the file.
+func (r *Registry) Save(fPath string) error {
+	f, err := os.Create(fPath)
+	if err != nil {
+		return fmt.Errorf("create %s: %w", fPath, err)
+	}
+	if err := r.Write(f); err != nil {
+		_ = f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// FilePath returns the path of the compiled registry of the package next to its archive, named after the package,
+// e.g. dist/x.y.registry.json for dist/package.cti of the x.y package.
+func FilePath(archivePath, packageID string) string {
+	return filepath.Join(filepath.Dir(archivePath), packageID+FileExtension)
+}
+
+// Read reads the compiled registry written by Write.
+func Read(rd io.Reader) (*Registry, error) {
+	var r Registry
+	if err := json.NewDecoder(rd).Decode(&r); err != nil {
+		return nil, fmt.Errorf("decode compiled registry: %w", err)
+	}
+	if r.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported compiled registry version %d, expected %d", r.Version, FormatVersion)
+	}
+	return &r, nil
+}
+
+// Load reads the compiled registry from the file.
+func Load(fPath string) (*Registry, error) {
+	f, err := os.Open(fPath)
+	if err != nil {
+		return nil, fmt.Errorf("open %s: %w", fPath, err)
+	}
+	defer f.Close()
+	return Read(f)
+}
+
+// Type returns the compiled type by CTI.
+func (r *Registry) Type(cti string) (*Type, bool) {
+	t, ok := r.Types[cti]
+	return t, ok
+}
+
+// Instance returns the compiled instance by CTI.
+func (r *Registry) Instance(cti string) (*Instance, bool) {
+	i, ok := r.Instances[cti]
+	return i, ok
+}
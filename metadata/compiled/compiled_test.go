@@ -0,0 +1,141 @@
+package compiled
+
+import (
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func testRegistry(t *testing.T) *registry.Registry {
+	t.Helper()
+
+	id := true
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.x.y.event.v1.0": {
+			Cti: "cti.x.y.event.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Event",
+				"definitions": {
+					"Event": {
+						"type": "object",
+						"properties": {
+							"id": {"type": "string"},
+							"tags": {"type": "array", "items": {"$ref": "#/definitions/Tag"}}
+						},
+						"required": ["id"]
+					},
+					"Tag": {"type": "string"}
+				}
+			}`),
+			TraitsSchema: []byte(`{"type": "object", "properties": {"topic": {"type": "string"}}}`),
+			Annotations:  map[metadata.GJsonPath]metadata.Annotations{".id": {ID: &id}},
+		},
+		"cti.x.y.event.v1.0~x.y.created.v1.0": {
+			Cti:         "cti.x.y.event.v1.0~x.y.created.v1.0",
+			DisplayName: "Created",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Created",
+				"definitions": {
+					"Created": {"type": "object", "properties": {"login": {"type": "string"}}, "required": ["login"]}
+				}
+			}`),
+			Traits: []byte(`{"topic": "users"}`),
+		},
+		"cti.x.y.event.v1.0~x.y.created.v1.0~x.y.admin.v1.0": {
+			Cti:   "cti.x.y.event.v1.0~x.y.created.v1.0~x.y.admin.v1.0",
+			Final: true,
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Admin",
+				"definitions": {"Admin": {"type": "object"}}
+			}`),
+		},
+		"cti.x.y.event.v1.0~x.y.created.v1.0~x.y.first.v1.0": {
+			Cti:         "cti.x.y.event.v1.0~x.y.created.v1.0~x.y.first.v1.0",
+			DisplayName: "First",
+			Values:      []byte(`{"id":"1","login":"admin"}`),
+		},
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func Test_Compile(t *testing.T) {
+	r, err := Compile(testRegistry(t), WithPackageID("x.y"))
+	require.NoError(t, err)
+	require.Equal(t, FormatVersion, r.Version)
+	require.Equal(t, "x.y", r.PackageID)
+	require.Len(t, r.Types, 3)
+	require.Len(t, r.Instances, 1)
+
+	typ, ok := r.Type("cti.x.y.event.v1.0~x.y.created.v1.0~x.y.admin.v1.0")
+	require.True(t, ok)
+	require.True(t, typ.Final)
+	require.Equal(t, "cti.x.y.event.v1.0~x.y.created.v1.0", typ.Parent)
+	require.JSONEq(t, `{"type": "object", "properties": {"topic": {"type": "string"}}}`, string(typ.TraitsSchema))
+	require.JSONEq(t, `{"topic": "users"}`, string(typ.Traits))
+	require.Contains(t, typ.Annotations, metadata.GJsonPath(".id"))
+
+	var schema map[string]any
+	require.NoError(t, json.Unmarshal(typ.Schema, &schema))
+	require.ElementsMatch(t, []any{"id", "login"}, schema["required"])
+	require.Contains(t, schema["properties"], "tags")
+	require.Contains(t, schema["definitions"], "Tag")
+
+	root, ok := r.Type("cti.x.y.event.v1.0")
+	require.True(t, ok)
+	require.Empty(t, root.Parent)
+	require.Nil(t, root.Traits)
+
+	instance, ok := r.Instance("cti.x.y.event.v1.0~x.y.created.v1.0~x.y.first.v1.0")
+	require.True(t, ok)
+	require.Equal(t, "cti.x.y.event.v1.0~x.y.created.v1.0", instance.Type)
+	require.Equal(t, "First", instance.DisplayName)
+	require.JSONEq(t, `{"id":"1","login":"admin"}`, string(instance.Values))
+}
+
+func Test_CompileMissingParent(t *testing.T) {
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.x.y.event.v1.0~x.y.created.v1.0": {
+			Cti:    "cti.x.y.event.v1.0~x.y.created.v1.0",
+			Schema: []byte(`{"$ref": "#/definitions/Created", "definitions": {"Created": {"type": "object"}}}`),
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = Compile(r)
+	require.ErrorContains(t, err, "merge schema of cti.x.y.event.v1.0~x.y.created.v1.0")
+}
+
+func Test_SaveLoad(t *testing.T) {
+	r, err := Compile(testRegistry(t))
+	require.NoError(t, err)
+
+	fPath := filepath.Join(t.TempDir(), "package"+FileExtension)
+	require.NoError(t, r.Save(fPath))
+	loaded, err := Load(fPath)
+	require.NoError(t, err)
+	var expected, actual bytes.Buffer
+	require.NoError(t, r.Write(&expected))
+	require.NoError(t, loaded.Write(&actual))
+	require.JSONEq(t, expected.String(), actual.String())
+}
+
+func Test_ReadUnsupportedVersion(t *testing.T) {
+	_, err := Read(bytes.NewBufferString(`{"version": 2, "types": {}, "instances": {}}`))
+	require.EqualError(t, err, "unsupported compiled registry version 2, expected 1")
+}
+
+func Test_FilePath(t *testing.T) {
+	// The default output of cti pack.
+	require.Equal(t, "x.y.registry.json", FilePath("package..cti", "x.y"))
+	require.Equal(t, filepath.Join("dist", "x.y.registry.json"), FilePath(filepath.Join("dist", "sample-package.cti"), "x.y"))
+}
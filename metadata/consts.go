@@ -18,3 +18,12 @@ const (
 const (
 	Traits = "cti-traits"
 )
+
+const (
+	// CustomKey is a schema keyword that holds annotations and other RAML facets that have no JSON Schema equivalent.
+	CustomKey = "x-custom"
+	// DiscriminatorKey holds the name of RAML discriminator property in CustomKey.
+	DiscriminatorKey = "x-discriminator"
+	// DiscriminatorValueKey holds the RAML discriminator value of the type in CustomKey.
+	DiscriminatorValueKey = "x-discriminatorValue"
+)
@@ -0,0 +1,151 @@
+package ctipackage
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+// Bundle is a content of the package archive created by the packer.
+type Bundle struct {
+	Index    *Index
+	Entities metadata.Entities
+}
+
+// ReadBundle reads the index and the serialized entities from the package archive.
+// Both tgz and zip archives are supported.
+func ReadBundle(fPath string) (*Bundle, error) {
+	files, err := readArchive(fPath, IndexFileName, MetadataCacheFile)
+	if err != nil {
+		return nil, fmt.Errorf("read archive: %w", err)
+	}
+
+	indexData, ok := files[IndexFileName]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in bundle", IndexFileName)
+	}
+	idx, err := DecodeIndex(bytes.NewReader(indexData))
+	if err != nil {
+		return nil, fmt.Errorf("decode index: %w", err)
+	}
+
+	cacheData, ok := files[MetadataCacheFile]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in bundle", MetadataCacheFile)
+	}
+	var entities metadata.Entities
+	if err := json.Unmarshal(cacheData, &entities); err != nil {
+		return nil, fmt.Errorf("decode entities: %w", err)
+	}
+
+	return &Bundle{Index: idx, Entities: entities}, nil
+}
+
+// ReadArchive returns contents of all regular files of the package archive by their paths.
+// Both tgz and zip archives are supported.
+func ReadArchive(fPath string) (map[string][]byte, error) {
+	files, err := readArchive(fPath)
+	if err != nil {
+		return nil, fmt.Errorf("read archive: %w", err)
+	}
+	return files, nil
+}
+
+// readArchive returns contents of the specified files from the root of the archive, or of all files if
+// none are specified.
+func readArchive(fPath string, names ...string) (map[string][]byte, error) {
+	f, err := os.Open(fPath)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+
+	wanted := make(map[string]bool, len(names))
+	for _, name := range names {
+		wanted[name] = true
+	}
+
+	br := bufio.NewReader(f)
+	magic, err := br.Peek(4)
+	if err != nil {
+		return nil, fmt.Errorf("read archive header: %w", err)
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return readTgz(br, wanted)
+	case bytes.HasPrefix(magic, []byte("PK")):
+		info, err := f.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("stat archive: %w", err)
+		}
+		return readZip(f, info.Size(), wanted)
+	default:
+		return nil, errors.New("unsupported archive format")
+	}
+}
+
+func readTgz(r io.Reader, wanted map[string]bool) (map[string][]byte, error) {
+	gr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("open gzip: %w", err)
+	}
+	defer gr.Close()
+
+	res := make(map[string][]byte, len(wanted))
+	tr := tar.NewReader(gr)
+	for {
+		header, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return res, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("read tar: %w", err)
+		}
+		name := path.Clean(header.Name)
+		if header.Typeflag != tar.TypeReg || (len(wanted) > 0 && !wanted[name]) {
+			continue
+		}
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		res[name] = data
+	}
+}
+
+func readZip(r io.ReaderAt, size int64, wanted map[string]bool) (map[string][]byte, error) {
+	zr, err := zip.NewReader(r, size)
+	if err != nil {
+		return nil, fmt.Errorf("open zip: %w", err)
+	}
+
+	res := make(map[string][]byte, len(wanted))
+	for _, file := range zr.File {
+		name := path.Clean(file.Name)
+		if file.FileInfo().IsDir() || (len(wanted) > 0 && !wanted[name]) {
+			continue
+		}
+		rc, err := file.Open()
+		if err != nil {
+			return nil, fmt.Errorf("open %s: %w", name, err)
+		}
+		data, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", name, err)
+		}
+		res[name] = data
+	}
+	return res, nil
+}
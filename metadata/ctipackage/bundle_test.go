@@ -0,0 +1,52 @@
+package ctipackage
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata/archiver"
+	"github.com/acronis/go-cti/metadata/archiver/tgzwriter"
+	"github.com/acronis/go-cti/metadata/archiver/zippacker"
+)
+
+func Test_ReadBundle(t *testing.T) {
+	testCases := []struct {
+		name     string
+		archiver archiver.Archiver
+	}{
+		{name: "tgz", archiver: tgzwriter.New()},
+		{name: "zip", archiver: zippacker.New()},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dst := filepath.Join(t.TempDir(), "bundle.cti")
+			w, err := tc.archiver.Init(dst)
+			require.NoError(t, err)
+			require.NoError(t, tc.archiver.WriteBytes(IndexFileName, []byte(`{"package_id": "x.y", "serialized": [".cache.json"]}`)))
+			require.NoError(t, tc.archiver.WriteBytes(MetadataCacheFile, []byte(`[
+				{"cti": "cti.x.y.topic.v1.0", "final": true, "schema": {}},
+				{"cti": "cti.x.y.topic.v1.0~x.y.users.v1.0", "final": true, "values": {"name": "users"}}
+			]`)))
+			require.NoError(t, w.Close())
+
+			b, err := ReadBundle(dst)
+			require.NoError(t, err)
+			require.Equal(t, "x.y", b.Index.PackageID)
+			require.Len(t, b.Entities, 2)
+			require.Equal(t, "cti.x.y.topic.v1.0~x.y.users.v1.0", b.Entities[1].Cti)
+
+			files, err := ReadArchive(dst)
+			require.NoError(t, err)
+			require.Len(t, files, 2)
+			require.Contains(t, string(files[MetadataCacheFile]), "cti.x.y.topic.v1.0~x.y.users.v1.0")
+		})
+	}
+
+	t.Run("not an archive", func(t *testing.T) {
+		_, err := ReadBundle(filepath.Join("testdata", "cti.raml"))
+		require.ErrorContains(t, err, "unsupported archive format")
+	})
+}
@@ -5,7 +5,11 @@ import (
 	"regexp"
 )
 
-var packageIdRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}\.[a-z][a-z0-9_]{0,49}$`)
+var (
+	packageIdRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}\.[a-z][a-z0-9_]{0,49}$`)
+	// idSegmentRe matches the vendor or the package name of the package ID.
+	idSegmentRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,49}$`)
+)
 
 func ValidateID(id string) error {
 	if !packageIdRe.MatchString(id) {
@@ -13,3 +17,11 @@ func ValidateID(id string) error {
 	}
 	return nil
 }
+
+// ValidateIDSegment validates the vendor or the package name, the parts of the package ID.
+func ValidateIDSegment(segment string) error {
+	if !idSegmentRe.MatchString(segment) {
+		return fmt.Errorf("invalid %q, it should start with a lowercase letter followed by up to 49 lowercase letters, digits or underscores", segment)
+	}
+	return nil
+}
@@ -1,6 +1,7 @@
 package ctipackage
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -35,3 +36,25 @@ func Test_Id(t *testing.T) {
 	}
 
 }
+
+func Test_IdSegment(t *testing.T) {
+	testcases := map[string]bool{
+		"xyz":                         true,
+		"xyz_2":                       true,
+		"":                            false,
+		"2xyz":                        false,
+		"Xyz":                         false,
+		"xyz.abc":                     false,
+		"x" + strings.Repeat("y", 50): false,
+	}
+
+	for segment, valid := range testcases {
+		t.Run(segment, func(t *testing.T) {
+			if valid {
+				require.NoError(t, ValidateIDSegment(segment))
+			} else {
+				require.Error(t, ValidateIDSegment(segment))
+			}
+		})
+	}
+}
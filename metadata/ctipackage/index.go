@@ -10,6 +10,9 @@ import (
 	"strings"
 
 	"github.com/acronis/go-cti/metadata/filesys"
+	"github.com/acronis/go-cti/metadata/validator"
+
+	"golang.org/x/mod/semver"
 )
 
 const (
@@ -28,6 +31,20 @@ type Index struct {
 	Examples             []string          `json:"examples,omitempty"`
 	AdditionalProperties interface{}       `json:"additional_properties,omitempty"`
 	Serialized           []string          `json:"serialized,omitempty"`
+
+	// Version is the semantic version of the package under development, e.g. v0.1.0. Published versions are tags
+	// of sources of the package, so the version is informational.
+	Version string `json:"version,omitempty"`
+	// License is the SPDX license expression of the package, e.g. Apache-2.0.
+	License string `json:"license,omitempty"`
+
+	// Replace installs dependencies from local directories instead of their sources, e.g. during development of
+	// several packages, like replace directives of go.mod. Keys are sources of Depends, values are paths of
+	// directories relative to the package.
+	Replace map[string]string `json:"replace,omitempty"`
+
+	// IdentifierRules are applied to entities of this package and of all packages that depend on it.
+	IdentifierRules []validator.IdentifierRule `json:"identifier_rules,omitempty"`
 }
 
 func ReadIndex(dirPath string) (*Index, error) {
@@ -87,12 +104,40 @@ func (idx *Index) Check() error {
 			return fmt.Errorf("$.examples[%d]: invalid example extension: %s", i, ext)
 		}
 	}
+	for source, dir := range idx.Replace {
+		if _, ok := idx.Depends[source]; !ok {
+			return fmt.Errorf("$.replace[%s]: source is not a dependency", source)
+		}
+		if dir == "" {
+			return fmt.Errorf("$.replace[%s]: directory cannot be empty", source)
+		}
+	}
+	if idx.Version != "" {
+		if err := ValidateVersion(idx.Version); err != nil {
+			return fmt.Errorf("$.version: %w", err)
+		}
+	}
+	for i, rule := range idx.IdentifierRules {
+		if err := rule.Check(); err != nil {
+			return fmt.Errorf("$.identifier_rules[%d]: %w", i, err)
+		}
+	}
 	if idx.PackageID == "" {
 		return fmt.Errorf("package id is missing")
 	}
 	return nil
 }
 
+// ValidateVersion validates the semantic version of the package, see Index.Version. Shorthands like v1.2 are
+// not accepted.
+func ValidateVersion(version string) error {
+	core, _, _ := strings.Cut(version, "+")
+	if !semver.IsValid(version) || semver.Canonical(version) != core {
+		return fmt.Errorf("invalid semantic version %s, e.g. v1.0.0", version)
+	}
+	return nil
+}
+
 func (idx *Index) GenerateIndexRaml(includeExamples bool) string {
 	// TODO: Maybe it is possible to avoid index.raml generation and reuse RAML parser instance to parse each entity file instead.
 	// Could have something like PackageParser.Initialize(path string) (maybe even in go-raml itself).
@@ -81,6 +81,40 @@ func Test_IndexCheck(t *testing.T) {
 			},
 			expectError: false,
 		},
+		{
+			name: "ValidReplace",
+			index: Index{
+				PackageID: "test.pkg",
+				Depends:   map[string]string{"github.com/acronis/dep": "v1.0.0"},
+				Replace:   map[string]string{"github.com/acronis/dep": "../dep"},
+			},
+			expectError: false,
+		},
+		{
+			name: "ReplaceOfUnknownDependency",
+			index: Index{
+				PackageID: "test.pkg",
+				Replace:   map[string]string{"github.com/acronis/dep": "../dep"},
+			},
+			expectError: true,
+		},
+		{
+			name: "ValidVersion",
+			index: Index{
+				PackageID: "test.pkg",
+				Version:   "v0.1.0",
+				License:   "Apache-2.0",
+			},
+			expectError: false,
+		},
+		{
+			name: "InvalidVersion",
+			index: Index{
+				PackageID: "test.pkg",
+				Version:   "v0.1",
+			},
+			expectError: true,
+		},
 		{
 			name: "EmptyApiPath",
 			index: Index{
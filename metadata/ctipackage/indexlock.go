@@ -36,6 +36,8 @@ type Info struct {
 	Source          string            `json:"source"`
 	SourceIntegrity string            `json:"source_integrity"`
 	Depends         map[string]string `json:"depends"`
+	// Replace is the local directory the dependency was installed from, see Index.Replace.
+	Replace string `json:"replace,omitempty"`
 }
 
 func ReadIndexLock(pkgDir string) (*IndexLock, error) {
@@ -10,6 +10,8 @@ import (
 
 	"github.com/acronis/go-cti/metadata/collector"
 	"github.com/acronis/go-cti/metadata/filesys"
+	"github.com/acronis/go-cti/metadata/registry"
+	"github.com/acronis/go-cti/metadata/validator"
 )
 
 const (
@@ -23,6 +25,12 @@ type Package struct {
 	LocalRegistry  *collector.MetadataRegistry
 	GlobalRegistry *collector.MetadataRegistry
 
+	// Registry indexes all entities of the global registry for fast lookups.
+	Registry *registry.Registry
+
+	// IdentifierRules are identifier rules declared by the package and its dependencies.
+	IdentifierRules []validator.IdentifierRule
+
 	BaseDir string
 }
 
@@ -71,6 +79,26 @@ func WithRamlxVersion(version string) InitializeOption {
 		return nil
 	}
 }
+
+// WithVersion sets the version of the package, see Index.Version.
+func WithVersion(version string) InitializeOption {
+	return func(pkg *Package) error {
+		if err := ValidateVersion(version); err != nil {
+			return err
+		}
+		pkg.Index.Version = version
+		return nil
+	}
+}
+
+// WithLicense sets the SPDX license expression of the package.
+func WithLicense(license string) InitializeOption {
+	return func(pkg *Package) error {
+		pkg.Index.License = license
+		return nil
+	}
+}
+
 func WithEntities(entities []string) InitializeOption {
 	return func(pkg *Package) error {
 		if entities != nil {
@@ -10,6 +10,8 @@ import (
 
 	"github.com/acronis/go-cti/metadata"
 	"github.com/acronis/go-cti/metadata/collector"
+	"github.com/acronis/go-cti/metadata/registry"
+	"github.com/acronis/go-cti/metadata/validator"
 	"github.com/acronis/go-raml"
 )
 
@@ -19,6 +21,7 @@ const (
 
 func (pkg *Package) Parse() error {
 	c := collector.New()
+	var rules []validator.IdentifierRule
 	// TODO: This will work only for top-level packages. Need to handle nested dependencies.
 	for _, dep := range pkg.IndexLock.SourceInfo {
 		depIndexFile := filepath.Join(pkg.BaseDir, DependencyDirName, dep.PackageID)
@@ -37,6 +40,7 @@ func (pkg *Package) Parse() error {
 		if err != nil {
 			return fmt.Errorf("parse dependent package: %w", err)
 		}
+		rules = append(rules, depPkg.Index.IdentifierRules...)
 	}
 
 	err := pkg.parse(c, true)
@@ -45,6 +49,11 @@ func (pkg *Package) Parse() error {
 	}
 	pkg.LocalRegistry = c.LocalRegistry
 	pkg.GlobalRegistry = c.GlobalRegistry
+	pkg.IdentifierRules = append(rules, pkg.Index.IdentifierRules...)
+	pkg.Registry, err = registry.New(c.GlobalRegistry.Index)
+	if err != nil {
+		return fmt.Errorf("index entities: %w", err)
+	}
 
 	// TODO: Maybe need an option to parse without dumping cache?
 	if err := pkg.DumpCache(); err != nil {
@@ -60,12 +69,13 @@ func (pkg *Package) parse(c *collector.Collector, isLocal bool) error {
 		return fmt.Errorf("sync package: %w", err)
 	}
 
-	r, err := raml.ParseFromString(pkg.Index.GenerateIndexRaml(false), "index.raml", pkg.BaseDir, raml.OptWithValidate())
+	r, err := raml.ParseFromString(pkg.Index.GenerateIndexRaml(false), indexRamlName, pkg.BaseDir, raml.OptWithValidate())
 	if err != nil {
 		return fmt.Errorf("parse index.raml: %w", err)
 	}
 
 	c.SetRaml(r)
+	c.SetPackageID(pkg.Index.PackageID)
 	if err := c.Collect(isLocal); err != nil {
 		return fmt.Errorf("collect from package: %w", err)
 	}
@@ -94,7 +94,7 @@ types:
     type: object
 `)},
 			},
-			expectedError: "duplicate cti.cti: cti.x.y.unique_entity.v1.0",
+			expectedError: "duplicate cti entity cti.x.y.unique_entity.v1.0",
 		},
 		{
 			parserTestCase: parserTestCase{
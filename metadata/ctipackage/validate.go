@@ -2,22 +2,72 @@ package ctipackage
 
 import (
 	"fmt"
+	"log/slog"
+	"path"
+	"strings"
 
+	"github.com/acronis/go-cti/metadata/schemaref"
 	"github.com/acronis/go-cti/metadata/validator"
 )
 
-func (pkg *Package) Validate() error {
-	// TODO: Validate must use cache.
-	err := pkg.Parse()
+// indexRamlName is a name of the RAML document that includes package entities.
+// Source map paths of the entities are relative to this document.
+const indexRamlName = "index.raml"
+
+// SourcePath returns the path of the source map relative to the package directory. Paths of source maps are
+// relative to the index RAML generated in the package directory, so they start with "../".
+func SourcePath(originalPath string) string {
+	if originalPath == "" {
+		return ""
+	}
+	return path.Clean(strings.TrimPrefix(originalPath, "../"))
+}
+
+func (pkg *Package) Validate(opts ...validator.Option) error {
+	v, err := pkg.newValidator(opts...)
 	if err != nil {
-		return fmt.Errorf("parse with cache: %w", err)
+		return err
 	}
-	validator := validator.MakeMetadataValidator()
-	validator.LoadFromRegistry(pkg.GlobalRegistry)
 
-	if err := validator.ValidateAll(); err != nil {
+	err = v.ValidateAll()
+	for _, w := range v.Warnings() {
+		slog.Warn(w.Msg, slog.String("code", string(w.Code)), slog.String("cti", w.ID))
+	}
+	if err != nil {
 		return fmt.Errorf("validate all: %w", err)
 	}
 
 	return nil
 }
+
+// ValidateReport validates the package and returns the report of all found diagnostics.
+// File paths in the report are relative to the package directory.
+// Error is returned only if the package cannot be parsed.
+func (pkg *Package) ValidateReport(opts ...validator.Option) (*validator.Report, error) {
+	v, err := pkg.newValidator(opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	// Diagnostics are available in the report.
+	_ = v.ValidateAll()
+	report := v.Report()
+	for i := range report.Files {
+		report.Files[i].Path = SourcePath(report.Files[i].Path)
+	}
+	return report, nil
+}
+
+func (pkg *Package) newValidator(opts ...validator.Option) (*validator.MetadataValidator, error) {
+	// TODO: Validate must use cache.
+	if err := pkg.Parse(); err != nil {
+		return nil, fmt.Errorf("parse with cache: %w", err)
+	}
+	opts = append([]validator.Option{
+		validator.WithIdentifierRules(pkg.IdentifierRules...),
+		validator.WithRefResolver(schemaref.New(pkg.BaseDir)),
+	}, opts...)
+	v := validator.MakeMetadataValidator(opts...)
+	v.LoadRegistry(pkg.Registry)
+	return v, nil
+}
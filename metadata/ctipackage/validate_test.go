@@ -0,0 +1,19 @@
+package ctipackage
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_SourcePath(t *testing.T) {
+	for originalPath, expected := range map[string]string{
+		"":                              "",
+		"../entities/shop.raml":         "entities/shop.raml",
+		"../entities/../apis/shop.raml": "apis/shop.raml",
+		"../.dep/acme.lib/lib.raml":     ".dep/acme.lib/lib.raml",
+		"entities/shop.raml":            "entities/shop.raml",
+	} {
+		require.Equal(t, expected, SourcePath(originalPath), originalPath)
+	}
+}
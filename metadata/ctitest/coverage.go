@@ -0,0 +1,183 @@
+package ctitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata/compiled"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+// Coverage reports types of the package exercised by test cases. A type is covered if at least one case is
+// validated against it. An attribute of the type is covered if values of at least one such case set it.
+type Coverage struct {
+	// Types are types of the package sorted by CTI.
+	Types []*TypeCoverage
+}
+
+// TypeCoverage is coverage of the type.
+type TypeCoverage struct {
+	Cti string
+	// Cases is the number of cases validated against the type.
+	Cases int
+	// Attributes are paths of attributes of the type, e.g. data.login or tags.#, see Attributes.
+	Attributes []string
+	// Uncovered are attributes not set by values of any case.
+	Uncovered []string
+}
+
+// MeasureCoverage measures coverage of types of the package by cases of the suite. The package is identified
+// in <vendor>.<package> form, types of dependencies are not measured.
+func MeasureCoverage(r *registry.Registry, packageID string, suite *Suite) (*Coverage, error) {
+	c, err := compiled.Compile(r)
+	if err != nil {
+		return nil, fmt.Errorf("compile registry: %w", err)
+	}
+	types := r.Find(registry.Filter{Kind: registry.KindType, Package: packageID})
+
+	set := make(map[string]map[string]struct{}, len(types))
+	cases := make(map[string]int, len(types))
+	for _, tc := range suite.Cases {
+		cases[tc.Type]++
+		if set[tc.Type] == nil {
+			set[tc.Type] = make(map[string]struct{})
+		}
+		collectPaths(tc.Values, "", set[tc.Type])
+	}
+
+	res := &Coverage{Types: make([]*TypeCoverage, 0, len(types))}
+	for _, typ := range types {
+		compiledType, ok := c.Type(typ.Cti)
+		if !ok {
+			return nil, fmt.Errorf("type %s is not compiled", typ.Cti)
+		}
+		attributes, err := Attributes(compiledType.Schema)
+		if err != nil {
+			return nil, fmt.Errorf("attributes of %s: %w", typ.Cti, err)
+		}
+		tc := &TypeCoverage{Cti: typ.Cti, Cases: cases[typ.Cti], Attributes: attributes}
+		for _, attr := range attributes {
+			if _, ok := set[typ.Cti][attr]; !ok {
+				tc.Uncovered = append(tc.Uncovered, attr)
+			}
+		}
+		res.Types = append(res.Types, tc)
+	}
+	sort.Slice(res.Types, func(i, j int) bool { return res.Types[i].Cti < res.Types[j].Cti })
+	return res, nil
+}
+
+// Covered returns the number of covered types.
+func (c *Coverage) Covered() int {
+	var n int
+	for _, t := range c.Types {
+		if t.Cases > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// Percent returns the percentage of covered types. Packages without types are fully covered.
+func (c *Coverage) Percent() float64 {
+	if len(c.Types) == 0 {
+		return 100
+	}
+	return float64(c.Covered()) * 100 / float64(len(c.Types))
+}
+
+// AttributesPercent returns the percentage of covered attributes of all types.
+func (c *Coverage) AttributesPercent() float64 {
+	var total, uncovered int
+	for _, t := range c.Types {
+		total += len(t.Attributes)
+		uncovered += len(t.Uncovered)
+	}
+	if total == 0 {
+		return 100
+	}
+	return float64(total-uncovered) * 100 / float64(total)
+}
+
+// Attributes returns paths of attributes of the flattened schema sorted by paths. Nested attributes are joined
+// with dots, items of arrays are denoted by #, e.g. data.login or tags.#.
+func Attributes(schema json.RawMessage) ([]string, error) {
+	var root map[string]any
+	if err := json.Unmarshal(schema, &root); err != nil {
+		return nil, fmt.Errorf("decode schema: %w", err)
+	}
+	defs, _ := root["definitions"].(map[string]any)
+	set := make(map[string]struct{})
+	walkAttributes(root, defs, "", set, make(map[string]bool))
+	res := make([]string, 0, len(set))
+	for path := range set {
+		res = append(res, path)
+	}
+	sort.Strings(res)
+	return res, nil
+}
+
+// walkAttributes collects attributes of the schema. Members of unions contribute attributes at the same paths.
+// seen holds definitions on the current path to stop at recursive references.
+func walkAttributes(schema map[string]any, defs map[string]any, path string, set map[string]struct{},
+	seen map[string]bool) {
+	if ref, ok := schema["$ref"].(string); ok {
+		name := strings.TrimPrefix(ref, "#/definitions/")
+		def, ok := defs[name].(map[string]any)
+		if !ok || seen[name] {
+			return
+		}
+		seen[name] = true
+		defer delete(seen, name)
+		schema = def
+	}
+
+	if props, ok := schema["properties"].(map[string]any); ok {
+		for name, prop := range props {
+			set[join(path, name)] = struct{}{}
+			if prop, ok := prop.(map[string]any); ok {
+				walkAttributes(prop, defs, join(path, name), set, seen)
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]any); ok && path != "" {
+		set[join(path, "#")] = struct{}{}
+		walkAttributes(items, defs, join(path, "#"), set, seen)
+	}
+	for _, key := range []string{"anyOf", "oneOf", "allOf"} {
+		members, _ := schema[key].([]any)
+		for _, member := range members {
+			if member, ok := member.(map[string]any); ok {
+				walkAttributes(member, defs, path, set, seen)
+			}
+		}
+	}
+}
+
+// collectPaths collects paths of attributes set by the values.
+func collectPaths(values any, path string, set map[string]struct{}) {
+	switch v := values.(type) {
+	case map[string]any:
+		for name, child := range v {
+			set[join(path, name)] = struct{}{}
+			collectPaths(child, join(path, name), set)
+		}
+	case []any:
+		if path == "" {
+			return
+		}
+		for _, child := range v {
+			set[join(path, "#")] = struct{}{}
+			collectPaths(child, join(path, "#"), set)
+		}
+	}
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
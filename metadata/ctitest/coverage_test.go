@@ -0,0 +1,91 @@
+package ctitest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func Test_Attributes(t *testing.T) {
+	attributes, err := Attributes([]byte(`{
+		"type": "object",
+		"properties": {
+			"id": {"type": "string"},
+			"data": {"$ref": "#/definitions/Data"},
+			"tags": {"type": "array", "items": {"type": "object", "properties": {"name": {"type": "string"}}}},
+			"payload": {"anyOf": [
+				{"type": "object", "properties": {"text": {"type": "string"}}},
+				{"type": "object", "properties": {"bytes": {"type": "string"}}}
+			]}
+		},
+		"definitions": {
+			"Data": {"type": "object", "properties": {"login": {"type": "string"}, "parent": {"$ref": "#/definitions/Data"}}}
+		}
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"data", "data.login", "data.parent", "id", "payload", "payload.bytes", "payload.text", "tags", "tags.#", "tags.#.name",
+	}, attributes)
+}
+
+func Test_MeasureCoverage(t *testing.T) {
+	displayName := true
+	schema := func(props string) []byte {
+		return []byte(`{
+			"$schema": "http://json-schema.org/draft-07/schema",
+			"$ref": "#/definitions/T",
+			"definitions": {"T": {"type": "object", "properties": ` + props + `}}
+		}`)
+	}
+	annotations := map[metadata.GJsonPath]metadata.Annotations{".name": {DisplayName: &displayName}}
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.a.b.topic.v1.0": {
+			Cti:         "cti.a.b.topic.v1.0",
+			Schema:      schema(`{"name": {"type": "string"}, "tags": {"type": "array", "items": {"type": "string"}}}`),
+			Annotations: annotations,
+		},
+		"cti.a.b.topic.v1.0~a.b.queue.v1.0": {
+			Cti:         "cti.a.b.topic.v1.0~a.b.queue.v1.0",
+			Schema:      schema(`{"size": {"type": "integer"}}`),
+			Annotations: annotations,
+		},
+		"cti.a.b.event.v1.0": {
+			Cti:         "cti.a.b.event.v1.0",
+			Schema:      schema(`{"name": {"type": "string"}}`),
+			Annotations: annotations,
+		},
+		"cti.x.y.dependency.v1.0": {
+			Cti:         "cti.x.y.dependency.v1.0",
+			Schema:      schema(`{"name": {"type": "string"}}`),
+			Annotations: annotations,
+		},
+	})
+	require.NoError(t, err)
+
+	valid := true
+	suite := &Suite{Cases: []*Case{
+		{Type: "cti.a.b.topic.v1.0", Values: map[string]any{"name": "users"}, Valid: &valid},
+		{Type: "cti.a.b.topic.v1.0", Values: map[string]any{"tags": []any{"a"}}, Valid: &valid},
+		{Type: "cti.a.b.topic.v1.0~a.b.queue.v1.0", Values: map[string]any{"name": "orders"}, Valid: &valid},
+		{Type: "cti.x.y.dependency.v1.0", Values: map[string]any{"name": "orders"}, Valid: &valid},
+	}}
+	coverage, err := MeasureCoverage(r, "a.b", suite)
+	require.NoError(t, err)
+	require.Equal(t, []*TypeCoverage{
+		{Cti: "cti.a.b.event.v1.0", Attributes: []string{"name"}, Uncovered: []string{"name"}},
+		{Cti: "cti.a.b.topic.v1.0", Cases: 2, Attributes: []string{"name", "tags", "tags.#"}},
+		{
+			Cti:        "cti.a.b.topic.v1.0~a.b.queue.v1.0",
+			Cases:      1,
+			Attributes: []string{"name", "size", "tags", "tags.#"},
+			Uncovered:  []string{"size", "tags", "tags.#"},
+		},
+	}, coverage.Types)
+	require.Equal(t, 2, coverage.Covered())
+	require.InDelta(t, 66.67, coverage.Percent(), 0.01)
+	require.InDelta(t, 50, coverage.AttributesPercent(), 0.01)
+	require.InDelta(t, 100, (&Coverage{}).Percent(), 0.01)
+}
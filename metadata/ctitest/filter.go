@@ -0,0 +1,92 @@
+package ctitest
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/acronis/go-cti"
+)
+
+// excludePrefix marks tags of cases that are excluded by the filter.
+const excludePrefix = "!"
+
+// Filter selects a part of the suite relevant to a change.
+type Filter struct {
+	// Pattern is a CTI expression that types of cases and snapshots must match, e.g. cti.x.y.alert.*.
+	// The pattern matches descendants of the types as well.
+	Pattern string
+	// Tags select cases with any of the tags. Tags prefixed with ! exclude cases with the tag instead,
+	// e.g. !slow. Snapshots have no tags, so they are selected only if no tags but excluded ones are set.
+	Tags []string
+}
+
+// Select returns cases and snapshots of the suite that match the filter.
+func (f Filter) Select(suite *Suite) (*Suite, error) {
+	match, err := f.matcher()
+	if err != nil {
+		return nil, err
+	}
+	include, exclude := make(map[string]bool), make(map[string]bool)
+	for _, tag := range f.Tags {
+		if name, ok := strings.CutPrefix(tag, excludePrefix); ok {
+			exclude[name] = true
+		} else {
+			include[tag] = true
+		}
+	}
+	tagged := func(tags []string) bool {
+		selected := len(include) == 0
+		for _, tag := range tags {
+			if exclude[tag] {
+				return false
+			}
+			selected = selected || include[tag]
+		}
+		return selected
+	}
+
+	res := &Suite{}
+	for _, c := range suite.Cases {
+		ok, err := match(c.Type)
+		if err != nil {
+			return nil, err
+		}
+		if ok && tagged(c.Tags) {
+			res.Cases = append(res.Cases, c)
+		}
+	}
+	for _, typ := range suite.Snapshots {
+		ok, err := match(typ)
+		if err != nil {
+			return nil, err
+		}
+		if ok && tagged(nil) {
+			res.Snapshots = append(res.Snapshots, typ)
+		}
+	}
+	return res, nil
+}
+
+// matcher returns a function that reports whether the type matches the pattern.
+func (f Filter) matcher() (func(typeID string) (bool, error), error) {
+	if f.Pattern == "" {
+		return func(string) (bool, error) { return true, nil }, nil
+	}
+	p := cti.NewParser()
+	expr, err := p.Parse(f.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("parse pattern: %w", err)
+	}
+	return func(typeID string) (bool, error) {
+		id, err := p.Parse(typeID)
+		if err != nil {
+			// Types of cases are not checked by Load, unknown ones fail when cases are run.
+			return false, nil
+		}
+		ok, err := expr.Match(id)
+		if err != nil {
+			return false, fmt.Errorf("match %s: %w", typeID, err)
+		}
+		return ok, nil
+	}, nil
+}
@@ -0,0 +1,84 @@
+package ctitest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FilterSelect(t *testing.T) {
+	suite := &Suite{
+		Cases: []*Case{
+			{Name: "alert", Type: "cti.a.b.alert.v1.0", Tags: []string{"alerts"}},
+			{Name: "cpu alert", Type: "cti.a.b.alert.v1.0~a.b.cpu.v1.0", Tags: []string{"alerts", "slow"}},
+			{Name: "event", Type: "cti.a.b.event.v1.0"},
+			{Name: "other vendor", Type: "cti.c.d.alert.v1.0", Tags: []string{"slow"}},
+			{Name: "invalid type", Type: "alert"},
+		},
+		Snapshots: []string{"cti.a.b.alert.v1.0", "cti.a.b.event.v1.0"},
+	}
+
+	testCases := []struct {
+		name      string
+		filter    Filter
+		cases     []string
+		snapshots []string
+		err       string
+	}{
+		{
+			name:      "empty",
+			cases:     []string{"alert", "cpu alert", "event", "other vendor", "invalid type"},
+			snapshots: []string{"cti.a.b.alert.v1.0", "cti.a.b.event.v1.0"},
+		},
+		{
+			name:      "type with descendants",
+			filter:    Filter{Pattern: "cti.a.b.alert.v1.0"},
+			cases:     []string{"alert", "cpu alert"},
+			snapshots: []string{"cti.a.b.alert.v1.0"},
+		},
+		{
+			name:      "wildcard",
+			filter:    Filter{Pattern: "cti.a.b.*"},
+			cases:     []string{"alert", "cpu alert", "event"},
+			snapshots: []string{"cti.a.b.alert.v1.0", "cti.a.b.event.v1.0"},
+		},
+		{
+			name:   "tags",
+			filter: Filter{Tags: []string{"alerts", "slow"}},
+			cases:  []string{"alert", "cpu alert", "other vendor"},
+		},
+		{
+			name:      "excluded tags",
+			filter:    Filter{Tags: []string{"!slow"}},
+			cases:     []string{"alert", "event", "invalid type"},
+			snapshots: []string{"cti.a.b.alert.v1.0", "cti.a.b.event.v1.0"},
+		},
+		{
+			name:   "pattern and tags",
+			filter: Filter{Pattern: "cti.a.b.*", Tags: []string{"alerts", "!slow"}},
+			cases:  []string{"alert"},
+		},
+		{
+			name:   "invalid pattern",
+			filter: Filter{Pattern: "alert"},
+			err:    "parse pattern: ",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			res, err := tc.filter.Select(suite)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			var names []string
+			for _, c := range res.Cases {
+				names = append(names, c.Name)
+			}
+			require.Equal(t, tc.cases, names)
+			require.Equal(t, tc.snapshots, res.Snapshots)
+		})
+	}
+}
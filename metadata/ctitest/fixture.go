@@ -0,0 +1,150 @@
+package ctitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/acronis/go-cti/metadata/mock"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+// FixturesDir is the directory of fixtures in the tests directory.
+const FixturesDir = "fixtures"
+
+// Fixtures are values shared by cases of the suite. A fixture is a YAML or JSON file in the fixtures directory
+// named by its path without the extension, e.g. fixtures/alerts/cpu.yaml is the alerts/cpu fixture.
+type Fixtures map[string]any
+
+// LoadFixtures reads fixtures from the directory. A missing directory has no fixtures.
+func LoadFixtures(dir string) (Fixtures, error) {
+	res := make(Fixtures)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && path == dir {
+				return fs.SkipAll
+			}
+			return err
+		}
+		ext := filepath.Ext(path)
+		if d.IsDir() || (ext != ".yaml" && ext != ".yml" && ext != ".json") {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return fmt.Errorf("get relative path: %w", err)
+		}
+		name := strings.TrimSuffix(filepath.ToSlash(rel), ext)
+		if _, ok := res[name]; ok {
+			return fmt.Errorf("%s: duplicate fixture %s", rel, name)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("read fixture: %w", err)
+		}
+		var values any
+		if err := yaml.NewDecoder(bytes.NewReader(data)).Decode(&values); err != nil && !errors.Is(err, io.EOF) {
+			return fmt.Errorf("%s: decode fixture: %w", rel, err)
+		}
+		if values == nil {
+			return fmt.Errorf("%s: empty fixture", rel)
+		}
+		res[name] = values
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", dir, err)
+	}
+	return res, nil
+}
+
+// values returns a copy of values of the fixture.
+func (f Fixtures) values(name string) (any, error) {
+	values, ok := f[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown fixture %s", name)
+	}
+	return clone(values), nil
+}
+
+// Setup prepares cases of a test file. Values, the fixture of the case, the selected overlay and values of the
+// case are merged in this order. Generated fixtures are seen only by cases of the file, so there is nothing to
+// tear down after the cases are run.
+type Setup struct {
+	// Values are defaults of values of cases of the file.
+	Values any `yaml:"values"`
+	// Overlays are environment-specific values, e.g. of prod, that override values of fixtures. The overlay
+	// is selected with WithOverlay.
+	Overlays map[string]any `yaml:"overlays"`
+	// Generate generates fixtures of the file: valid instances of types, so that cases set only values under test.
+	Generate []*Generated `yaml:"generate"`
+}
+
+// Generated is a fixture of a setup generated as a valid instance of the type, see mock.Generator.
+type Generated struct {
+	Fixture string `yaml:"fixture"`
+	Type    string `yaml:"type"`
+	// Seed of the generator, fixtures of the same type and seed have the same values.
+	Seed int64 `yaml:"seed"`
+}
+
+// prepare returns fixtures seen by cases of the file: the fixtures and ones generated by the setup.
+func (s *Setup) prepare(fixtures Fixtures, r *registry.Registry) (Fixtures, error) {
+	if len(s.Generate) == 0 {
+		return fixtures, nil
+	}
+	if r == nil {
+		return nil, errors.New("generating fixtures requires a registry")
+	}
+	res := make(Fixtures, len(fixtures)+len(s.Generate))
+	for name, values := range fixtures {
+		res[name] = values
+	}
+	for i, g := range s.Generate {
+		if g.Fixture == "" || g.Type == "" {
+			return nil, fmt.Errorf("generated fixture %d: missing fixture or type", i+1)
+		}
+		if _, ok := res[g.Fixture]; ok {
+			return nil, fmt.Errorf("generated fixture %s: duplicate fixture", g.Fixture)
+		}
+		instances, err := mock.New(r, mock.WithSeed(g.Seed)).Instances(g.Type, 1)
+		if err != nil {
+			return nil, fmt.Errorf("generated fixture %s: %w", g.Fixture, err)
+		}
+		var values any
+		if err := json.Unmarshal(instances[0].Values, &values); err != nil {
+			return nil, fmt.Errorf("generated fixture %s: decode values: %w", g.Fixture, err)
+		}
+		res[g.Fixture] = values
+	}
+	return res, nil
+}
+
+// mergeValues merges the patch into the values as JSON merge patch (RFC 7386) does: objects are merged
+// recursively, null removes the property, other values replace ones of the values.
+func mergeValues(values, patch any) any {
+	p, ok := patch.(map[string]any)
+	if !ok {
+		return clone(patch)
+	}
+	res, ok := clone(values).(map[string]any)
+	if !ok {
+		res = make(map[string]any, len(p))
+	}
+	for name, v := range p {
+		if v == nil {
+			delete(res, name)
+			continue
+		}
+		res[name] = mergeValues(res[name], v)
+	}
+	return res
+}
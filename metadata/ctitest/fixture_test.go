@@ -0,0 +1,243 @@
+package ctitest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func Test_LoadFixtures(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"topic.yaml":        "name: orders\npartitions: 4\n",
+		"topics/small.json": `{"name": "audit", "partitions": 1}`,
+		"README.md":         "Fixtures of topics.\n",
+	})
+	fixtures, err := LoadFixtures(dir)
+	require.NoError(t, err)
+	require.Equal(t, Fixtures{
+		"topic":        map[string]any{"name": "orders", "partitions": 4},
+		"topics/small": map[string]any{"name": "audit", "partitions": 1},
+	}, fixtures)
+
+	fixtures, err = LoadFixtures(dir + "/missing")
+	require.NoError(t, err)
+	require.Empty(t, fixtures)
+
+	_, err = LoadFixtures(writeFiles(t, map[string]string{"topic.yaml": "name: a\n", "topic.json": `{}`}))
+	require.ErrorContains(t, err, "topic.yaml: duplicate fixture topic")
+
+	_, err = LoadFixtures(writeFiles(t, map[string]string{"topic.yaml": "# no values\n"}))
+	require.ErrorContains(t, err, "topic.yaml: empty fixture")
+}
+
+func Test_mergeValues(t *testing.T) {
+	testCases := []struct {
+		name     string
+		values   any
+		patch    any
+		expected any
+	}{
+		{
+			name:     "nested objects",
+			values:   map[string]any{"name": "orders", "retention": map[string]any{"days": 7, "bytes": 1024}},
+			patch:    map[string]any{"retention": map[string]any{"days": 30}},
+			expected: map[string]any{"name": "orders", "retention": map[string]any{"days": 30, "bytes": 1024}},
+		},
+		{
+			name:     "null removes",
+			values:   map[string]any{"name": "orders", "partitions": 4},
+			patch:    map[string]any{"partitions": nil},
+			expected: map[string]any{"name": "orders"},
+		},
+		{
+			name:     "arrays replace",
+			values:   map[string]any{"tags": []any{"a", "b"}},
+			patch:    map[string]any{"tags": []any{"c"}},
+			expected: map[string]any{"tags": []any{"c"}},
+		},
+		{
+			name:     "object replaces scalar",
+			values:   "orders",
+			patch:    map[string]any{"name": "orders"},
+			expected: map[string]any{"name": "orders"},
+		},
+		{
+			name:     "scalar replaces object",
+			values:   map[string]any{"name": "orders"},
+			patch:    "orders",
+			expected: "orders",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			values := clone(tc.values)
+			require.Equal(t, tc.expected, mergeValues(tc.values, tc.patch))
+			require.Equal(t, values, tc.values, "values must not be modified")
+		})
+	}
+}
+
+func Test_LoadWithFixtures(t *testing.T) {
+	dir := writeFiles(t, map[string]string{
+		"fixtures/topic.yaml": "name: orders\npartitions: 4\nretention: {days: 7}\n",
+		"topics.yaml": `setup:
+  values: {owner: platform, partitions: 1}
+  overlays:
+    prod: {partitions: 16, retention: {days: 30}}
+cases:
+  - name: fixture
+    type: cti.a.b.topic.v1.0
+    fixture: topic
+    valid: true
+  - name: fixture with values
+    type: cti.a.b.topic.v1.0
+    fixture: topic
+    values: {partitions: 0, owner: null}
+    valid: false
+  - name: values
+    type: cti.a.b.topic.v1.0
+    values: {name: audit}
+    valid: true
+`,
+		"other.yaml": "cases:\n  - {name: other, type: cti.a.b.topic.v1.0, fixture: topic, valid: true}\n",
+	})
+
+	suite, err := Load(dir)
+	require.NoError(t, err)
+	require.Len(t, suite.Cases, 4)
+	require.Equal(t, map[string]any{"name": "orders", "partitions": 4, "retention": map[string]any{"days": 7}},
+		suite.Cases[0].Values, "cases of files without setups use fixtures as is")
+	require.Equal(t, map[string]any{"owner": "platform", "name": "orders", "partitions": 4, "retention": map[string]any{"days": 7}},
+		suite.Cases[1].Values)
+	require.Equal(t, map[string]any{"name": "orders", "partitions": 0, "retention": map[string]any{"days": 7}},
+		suite.Cases[2].Values)
+	require.Equal(t, map[string]any{"owner": "platform", "name": "audit", "partitions": 1}, suite.Cases[3].Values)
+
+	suite, err = Load(dir, WithOverlay("prod"))
+	require.NoError(t, err)
+	require.Equal(t, map[string]any{"name": "orders", "partitions": 4, "retention": map[string]any{"days": 7}},
+		suite.Cases[0].Values)
+	require.Equal(t, map[string]any{"owner": "platform", "name": "orders", "partitions": 16, "retention": map[string]any{"days": 30}},
+		suite.Cases[1].Values)
+	require.Equal(t, map[string]any{"name": "orders", "partitions": 0, "retention": map[string]any{"days": 30}},
+		suite.Cases[2].Values)
+	require.Equal(t, map[string]any{"owner": "platform", "name": "audit", "partitions": 16, "retention": map[string]any{"days": 30}},
+		suite.Cases[3].Values)
+
+	_, err = Load(dir, WithOverlay("stage"))
+	require.EqualError(t, err, "overlay stage is not defined in any file")
+}
+
+func topicRegistry(t *testing.T) *registry.Registry {
+	t.Helper()
+
+	displayName := true
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.a.b.topic.v1.0": {
+			Cti: "cti.a.b.topic.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Topic",
+				"definitions": {
+					"Topic": {
+						"type": "object",
+						"properties": {
+							"name": {"type": "string", "minLength": 3},
+							"partitions": {"type": "integer", "minimum": 1, "maximum": 16}
+						},
+						"required": ["name", "partitions"],
+						"additionalProperties": false
+					}
+				}
+			}`),
+			Annotations: map[metadata.GJsonPath]metadata.Annotations{".name": {DisplayName: &displayName}},
+		},
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func Test_LoadGenerated(t *testing.T) {
+	r := topicRegistry(t)
+	dir := writeFiles(t, map[string]string{
+		"topics.yaml": `setup:
+  generate:
+    - {fixture: topic, type: cti.a.b.topic.v1.0}
+    - {fixture: same, type: cti.a.b.topic.v1.0}
+    - {fixture: other, type: cti.a.b.topic.v1.0, seed: 1}
+cases:
+  - {name: generated, type: cti.a.b.topic.v1.0, fixture: topic, valid: true}
+  - {name: same, type: cti.a.b.topic.v1.0, fixture: same, valid: true}
+  - {name: other, type: cti.a.b.topic.v1.0, fixture: other, valid: true}
+  - {name: too many partitions, type: cti.a.b.topic.v1.0, fixture: topic, values: {partitions: 17}, valid: false}
+`,
+	})
+
+	suite, err := Load(dir, WithRegistry(r))
+	require.NoError(t, err)
+	generated := suite.Cases[0].Values.(map[string]any)
+	require.Len(t, generated, 2)
+	require.Equal(t, generated, suite.Cases[1].Values)
+	require.NotEqual(t, generated, suite.Cases[2].Values)
+	require.Equal(t, 17, suite.Cases[3].Values.(map[string]any)["partitions"])
+	require.Equal(t, generated["name"], suite.Cases[3].Values.(map[string]any)["name"])
+
+	results := Run(context.Background(), suite, NewLocalValidator(r))
+	require.Zero(t, results.Failed(), "generated fixtures must be valid")
+
+	_, err = Load(dir)
+	require.EqualError(t, err, "topics.yaml: setup: generating fixtures requires a registry")
+}
+
+func Test_LoadInvalidFixtures(t *testing.T) {
+	testCases := []struct {
+		name  string
+		files map[string]string
+		err   string
+	}{
+		{
+			name:  "unknown fixture",
+			files: map[string]string{"cases.yaml": "cases:\n  - {name: a, type: cti.a.b.topic.v1.0, fixture: topic, valid: true}\n"},
+			err:   "cases.yaml: case a: unknown fixture topic",
+		},
+		{
+			name: "fixture of another file",
+			files: map[string]string{
+				"a.yaml": "setup:\n  generate: [{fixture: topic, type: cti.a.b.topic.v1.0}]\n",
+				"b.yaml": "cases:\n  - {name: b, type: cti.a.b.topic.v1.0, fixture: topic, valid: true}\n",
+			},
+			err: "b.yaml: case b: unknown fixture topic",
+		},
+		{
+			name: "duplicate generated fixture",
+			files: map[string]string{
+				"fixtures/topic.yaml": "name: orders\n",
+				"cases.yaml":          "setup:\n  generate: [{fixture: topic, type: cti.a.b.topic.v1.0}]\n",
+			},
+			err: "cases.yaml: setup: generated fixture topic: duplicate fixture",
+		},
+		{
+			name:  "generated fixture without type",
+			files: map[string]string{"cases.yaml": "setup:\n  generate: [{fixture: topic}]\n"},
+			err:   "cases.yaml: setup: generated fixture 1: missing fixture or type",
+		},
+		{
+			name:  "generated fixture of unknown type",
+			files: map[string]string{"cases.yaml": "setup:\n  generate: [{fixture: topic, type: cti.a.b.unknown.v1.0}]\n"},
+			err:   "cases.yaml: setup: generated fixture topic: ",
+		},
+	}
+
+	r := topicRegistry(t)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(writeFiles(t, tc.files), WithRegistry(r))
+			require.ErrorContains(t, err, tc.err)
+		})
+	}
+}
@@ -0,0 +1,381 @@
+package ctitest
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata/compiled"
+	"github.com/acronis/go-cti/metadata/mock"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+// FuzzDir is the directory of generated cases in reports, cases of a type are reported as fuzz/<cti>.
+const FuzzDir = "fuzz"
+
+// Fuzz generates cases of the types: count random valid instances of each type and invalid mutations of them.
+// A mutation violates a single constraint of the schema at its boundary, e.g. sets a number just above the
+// maximum or removes a required property. Valid instances that are rejected and mutations that are accepted
+// surface schemas that do not constrain data as intended. Cases are the same for the same seed.
+//
+// Constraints are mutated only along properties and items from the root of the schema, not in members of
+// unions, since another member could accept the mutated value.
+func Fuzz(r *registry.Registry, types []string, count int, seed int64) (*Suite, error) {
+	c, err := compiled.Compile(r)
+	if err != nil {
+		return nil, fmt.Errorf("compile registry: %w", err)
+	}
+	g := mock.New(r, mock.WithSeed(seed))
+	valid, invalid := true, false
+
+	suite := &Suite{}
+	for _, typeID := range types {
+		typ, ok := c.Type(typeID)
+		if !ok {
+			return nil, fmt.Errorf("type %s not found", typeID)
+		}
+		var schema map[string]any
+		if err := json.Unmarshal(typ.Schema, &schema); err != nil {
+			return nil, fmt.Errorf("decode schema of %s: %w", typeID, err)
+		}
+		defs, _ := schema["definitions"].(map[string]any)
+
+		instances, err := g.Instances(typeID, count)
+		if err != nil {
+			return nil, fmt.Errorf("generate instances of %s: %w", typeID, err)
+		}
+		file := FuzzDir + "/" + typeID
+		for i, instance := range instances {
+			var values any
+			if err := json.Unmarshal(instance.Values, &values); err != nil {
+				return nil, fmt.Errorf("decode values of %s: %w", instance.Cti, err)
+			}
+			name := fmt.Sprintf("instance %d", i+1)
+			suite.Cases = append(suite.Cases, &Case{Name: name, Type: typeID, Values: values, Valid: &valid, File: file})
+
+			var mutations []mutation
+			mutate(schema, defs, values, nil, &mutations)
+			for _, m := range mutations {
+				suite.Cases = append(suite.Cases, &Case{
+					Name:   name + ": " + m.description,
+					Type:   typeID,
+					Values: m.apply(values),
+					Valid:  &invalid,
+					File:   file,
+				})
+			}
+		}
+	}
+	return suite, nil
+}
+
+// mutation replaces the value at the path, or removes it if remove is set.
+type mutation struct {
+	description string
+	path        []any
+	value       any
+	remove      bool
+}
+
+// apply returns a copy of values with the mutation applied.
+func (m mutation) apply(values any) any {
+	res := clone(values)
+	if len(m.path) == 0 {
+		return m.value
+	}
+	parent := res
+	for _, key := range m.path[:len(m.path)-1] {
+		parent = child(parent, key)
+	}
+	switch key := m.path[len(m.path)-1].(type) {
+	case string:
+		if m.remove {
+			delete(parent.(map[string]any), key)
+		} else {
+			parent.(map[string]any)[key] = m.value
+		}
+	case int:
+		parent.([]any)[key] = m.value
+	}
+	return res
+}
+
+func child(value any, key any) any {
+	if k, ok := key.(string); ok {
+		return value.(map[string]any)[k]
+	}
+	return value.([]any)[key.(int)]
+}
+
+func clone(value any) any {
+	switch v := value.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for k, item := range v {
+			res[k] = clone(item)
+		}
+		return res
+	case []any:
+		res := make([]any, len(v))
+		for i, item := range v {
+			res[i] = clone(item)
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+// mutate collects mutations of constraints of the schema for the value at the path and its nested values.
+func mutate(schema, defs map[string]any, value any, path []any, res *[]mutation) {
+	if ref, ok := schema["$ref"].(string); ok {
+		def, ok := defs[strings.TrimPrefix(ref, "#/definitions/")].(map[string]any)
+		if !ok {
+			return
+		}
+		schema = def
+	}
+	add := func(constraint string, v any) {
+		*res = append(*res, mutation{
+			description: fmt.Sprintf("%s of %s (%s)", constraint, formatPath(path), describe(v)),
+			path:        path,
+			value:       v,
+		})
+	}
+
+	if v, ok := wrongType(schema["type"]); ok {
+		add("type", v)
+	}
+	if enum, ok := schema["enum"].([]any); ok && len(enum) > 0 {
+		add("enum", outside(enum))
+	}
+	if c, ok := schema["const"]; ok {
+		add("const", outside([]any{c}))
+	}
+
+	switch v := value.(type) {
+	case float64:
+		mutateNumber(schema, add)
+	case string:
+		mutateString(schema, v, add)
+	case []any:
+		if n, ok := schema["minItems"].(float64); ok && n > 0 && int(n) <= len(v) {
+			add("minItems", v[:int(n)-1])
+		}
+		if n, ok := schema["maxItems"].(float64); ok && len(v) > 0 {
+			items := append([]any{}, v...)
+			for len(items) <= int(n) {
+				items = append(items, v[0])
+			}
+			add("maxItems", items)
+		}
+		if unique, _ := schema["uniqueItems"].(bool); unique && len(v) > 0 {
+			add("uniqueItems", append(append([]any{}, v...), v[0]))
+		}
+		if items, ok := schema["items"].(map[string]any); ok && len(v) > 0 {
+			// The first item is enough to check constraints of items.
+			mutate(items, defs, v[0], appendPath(path, 0), res)
+		}
+	case map[string]any:
+		mutateObject(schema, defs, v, path, res)
+	}
+}
+
+func mutateNumber(schema map[string]any, add func(string, any)) {
+	// Integers are mutated by one, other numbers by a fraction, to stay close to the boundary.
+	step := 0.001
+	if schema["type"] == "integer" {
+		step = 1
+	}
+	if minimum, ok := schema["minimum"].(float64); ok {
+		if exclusive, _ := schema["exclusiveMinimum"].(bool); exclusive {
+			add("exclusiveMinimum", minimum)
+		} else {
+			add("minimum", minimum-step)
+		}
+	}
+	if minimum, ok := schema["exclusiveMinimum"].(float64); ok {
+		add("exclusiveMinimum", minimum)
+	}
+	if maximum, ok := schema["maximum"].(float64); ok {
+		if exclusive, _ := schema["exclusiveMaximum"].(bool); exclusive {
+			add("exclusiveMaximum", maximum)
+		} else {
+			add("maximum", maximum+step)
+		}
+	}
+	if maximum, ok := schema["exclusiveMaximum"].(float64); ok {
+		add("exclusiveMaximum", maximum)
+	}
+}
+
+func mutateString(schema map[string]any, v string, add func(string, any)) {
+	runes := []rune(v)
+	if n, ok := schema["minLength"].(float64); ok && n > 0 && int(n) <= len(runes) {
+		add("minLength", string(runes[:int(n)-1]))
+	}
+	if n, ok := schema["maxLength"].(float64); ok {
+		s := append([]rune{}, runes...)
+		for len(s) <= int(n) {
+			s = append(s, 'a')
+		}
+		add("maxLength", string(s[:int(n)+1]))
+	}
+	if pattern, ok := schema["pattern"].(string); ok {
+		if re, err := regexp.Compile(pattern); err == nil {
+			for _, candidate := range []string{"", "fuzz", "!", " "} {
+				if !re.MatchString(candidate) {
+					add("pattern", candidate)
+					break
+				}
+			}
+		}
+	}
+}
+
+func mutateObject(schema, defs map[string]any, v map[string]any, path []any, res *[]mutation) {
+	required, _ := schema["required"].([]any)
+	for _, name := range required {
+		if name, ok := name.(string); ok {
+			if _, ok := v[name]; ok {
+				*res = append(*res, mutation{
+					description: fmt.Sprintf("required of %s (removed)", formatPath(appendPath(path, name))),
+					path:        appendPath(path, name),
+					remove:      true,
+				})
+			}
+		}
+	}
+	props, _ := schema["properties"].(map[string]any)
+	if additional, ok := schema["additionalProperties"].(bool); ok && !additional {
+		_, patterns := schema["patternProperties"]
+		if _, ok := props["fuzz"]; !ok && !patterns {
+			*res = append(*res, mutation{
+				description: fmt.Sprintf("additionalProperties of %s (\"fuzz\")", formatPath(appendPath(path, "fuzz"))),
+				path:        appendPath(path, "fuzz"),
+				value:       "fuzz",
+			})
+		}
+	}
+
+	names := make([]string, 0, len(v))
+	for name := range v {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if prop, ok := props[name].(map[string]any); ok {
+			mutate(prop, defs, v[name], appendPath(path, name), res)
+		}
+	}
+}
+
+// wrongType returns a value of a type that the type keyword does not allow.
+func wrongType(typ any) (any, bool) {
+	var allowed []string
+	switch t := typ.(type) {
+	case string:
+		allowed = []string{t}
+	case []any:
+		for _, item := range t {
+			if s, ok := item.(string); ok {
+				allowed = append(allowed, s)
+			}
+		}
+	default:
+		return nil, false
+	}
+	candidates := []struct {
+		types []string
+		value any
+	}{
+		{[]string{"boolean"}, true},
+		{[]string{"string"}, "fuzz"},
+		{[]string{"number"}, 0.5},
+		{[]string{"object"}, map[string]any{}},
+	}
+	for _, c := range candidates {
+		if !containsAny(allowed, c.types) {
+			return c.value, true
+		}
+	}
+	return nil, false
+}
+
+func containsAny(items, values []string) bool {
+	for _, item := range items {
+		for _, v := range values {
+			if item == v {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// outside returns a value of the type of the first item that is not among the items.
+func outside(items []any) any {
+	seen := make(map[string]bool, len(items))
+	for _, item := range items {
+		seen[formatValue(item)] = true
+	}
+	switch items[0].(type) {
+	case float64:
+		v := items[0].(float64)
+		for seen[formatValue(v)] {
+			v++
+		}
+		return v
+	default:
+		v := "fuzz"
+		for i := 1; seen[formatValue(v)]; i++ {
+			v = "fuzz" + strconv.Itoa(i)
+		}
+		return v
+	}
+}
+
+func appendPath(path []any, key any) []any {
+	return append(append([]any{}, path...), key)
+}
+
+// formatPath formats the path of the value, e.g. data.tags[0], the root is denoted by $.
+func formatPath(path []any) string {
+	if len(path) == 0 {
+		return "$"
+	}
+	var b strings.Builder
+	for _, key := range path {
+		switch k := key.(type) {
+		case string:
+			if b.Len() > 0 {
+				b.WriteByte('.')
+			}
+			b.WriteString(k)
+		case int:
+			fmt.Fprintf(&b, "[%d]", k)
+		}
+	}
+	return b.String()
+}
+
+// describe formats the value for names of cases, long values are truncated.
+func describe(v any) string {
+	const maxLength = 40
+	s := []rune(formatValue(v))
+	if len(s) > maxLength {
+		return string(s[:maxLength]) + "..."
+	}
+	return string(s)
+}
+
+func formatValue(v any) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
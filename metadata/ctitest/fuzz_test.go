@@ -0,0 +1,96 @@
+package ctitest
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func Test_Fuzz(t *testing.T) {
+	displayName := true
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.a.b.topic.v1.0": {
+			Cti: "cti.a.b.topic.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Topic",
+				"definitions": {
+					"Topic": {
+						"type": "object",
+						"properties": {
+							"name": {"type": "string", "minLength": 3, "maxLength": 8, "pattern": "^[a-z]+$"},
+							"partitions": {"type": "integer", "minimum": 1, "maximum": 16},
+							"kind": {"type": "string", "enum": ["queue", "stream"]},
+							"tags": {"type": "array", "minItems": 1, "maxItems": 3, "items": {"type": "string", "maxLength": 10}},
+							"payload": {"anyOf": [{"type": "string", "maxLength": 2}, {"type": "number"}]}
+						},
+						"required": ["name", "partitions", "kind", "tags", "payload"],
+						"additionalProperties": false
+					}
+				}
+			}`),
+			Annotations: map[metadata.GJsonPath]metadata.Annotations{".name": {DisplayName: &displayName}},
+		},
+	})
+	require.NoError(t, err)
+
+	suite, err := Fuzz(r, []string{"cti.a.b.topic.v1.0"}, 2, 1)
+	require.NoError(t, err)
+
+	// Values in names depend on generated instances, so only mutated constraints are compared.
+	value := regexp.MustCompile(` \(.*\)$`)
+	var names []string
+	for _, c := range suite.Cases {
+		require.Equal(t, "fuzz/cti.a.b.topic.v1.0", c.File)
+		require.Equal(t, "cti.a.b.topic.v1.0", c.Type)
+		require.Equal(t, c.Name == "instance 1" || c.Name == "instance 2", *c.Valid, c.Name)
+		if c.Name[len("instance ")] == '1' {
+			names = append(names, value.ReplaceAllString(c.Name, ""))
+		}
+	}
+	require.Equal(t, []string{
+		"instance 1",
+		"instance 1: type of $",
+		"instance 1: required of name",
+		"instance 1: required of partitions",
+		"instance 1: required of kind",
+		"instance 1: required of tags",
+		"instance 1: required of payload",
+		"instance 1: additionalProperties of fuzz",
+		"instance 1: type of kind",
+		"instance 1: enum of kind",
+		"instance 1: type of name",
+		"instance 1: minLength of name",
+		"instance 1: maxLength of name",
+		"instance 1: pattern of name",
+		"instance 1: type of partitions",
+		"instance 1: minimum of partitions",
+		"instance 1: maximum of partitions",
+		"instance 1: type of tags",
+		"instance 1: minItems of tags",
+		"instance 1: maxItems of tags",
+		"instance 1: type of tags[0]",
+		"instance 1: maxLength of tags[0]",
+	}, names)
+
+	again, err := Fuzz(r, []string{"cti.a.b.topic.v1.0"}, 2, 1)
+	require.NoError(t, err)
+	require.Equal(t, suite, again)
+
+	results := Run(context.Background(), suite, NewLocalValidator(r))
+	for _, res := range results.Results {
+		require.True(t, res.Passed(), "%s: %v %v", res.Case.ID(), res.Failures, res.Err)
+	}
+}
+
+func Test_FuzzUnknownType(t *testing.T) {
+	r, err := registry.New(metadata.EntitiesMap{})
+	require.NoError(t, err)
+	_, err = Fuzz(r, []string{"cti.a.b.unknown.v1.0"}, 1, 1)
+	require.EqualError(t, err, "type cti.a.b.unknown.v1.0 not found")
+}
@@ -0,0 +1,119 @@
+package ctitest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/acronis/go-cti/metadata/validator"
+)
+
+const (
+	// remoteTimeout limits a validation request of the default HTTP client.
+	remoteTimeout = 30 * time.Second
+	// maxRemoteResponse limits the size of responses of the registry.
+	maxRemoteResponse = 16 << 20
+)
+
+type remoteOptions struct {
+	token  string
+	client *http.Client
+}
+
+type RemoteOption func(*remoteOptions)
+
+// WithToken sets the bearer token sent to the registry.
+func WithToken(token string) RemoteOption {
+	return func(o *remoteOptions) {
+		o.token = token
+	}
+}
+
+// WithHTTPClient sets the HTTP client of requests to the registry.
+func WithHTTPClient(client *http.Client) RemoteOption {
+	return func(o *remoteOptions) {
+		o.client = client
+	}
+}
+
+// RemoteValidator validates values with the /validate endpoint of a registry served by 'cti rest', so that
+// a deployed registry is checked to validate values the same way as the local validator does.
+// It is safe for concurrent use.
+type RemoteValidator struct {
+	endpoint string
+	opts     remoteOptions
+}
+
+// NewRemoteValidator creates a validator of values with the registry at the base URL, e.g. https://registry.example.com.
+func NewRemoteValidator(baseURL string, opts ...RemoteOption) (*RemoteValidator, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse registry url: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("registry url %s must be an absolute http or https url", baseURL)
+	}
+	o := remoteOptions{client: &http.Client{Timeout: remoteTimeout}}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	return &RemoteValidator{endpoint: strings.TrimSuffix(u.String(), "/") + "/validate", opts: o}, nil
+}
+
+// remoteRequest is the body of /validate, see server.ValidateRequest.
+type remoteRequest struct {
+	Type   string          `json:"type"`
+	Values json.RawMessage `json:"values"`
+}
+
+// Validate validates values of the anonymous instance of the type with nil UUID, as the registry does if
+// the identifier of the instance is not set.
+func (v *RemoteValidator) Validate(ctx context.Context, typeID string, values json.RawMessage) (*validator.Report, error) {
+	body, err := json.Marshal(remoteRequest{Type: typeID, Values: values})
+	if err != nil {
+		return nil, fmt.Errorf("encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, v.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "cti-test")
+	if v.opts.token != "" {
+		req.Header.Set("Authorization", "Bearer "+v.opts.token)
+	}
+
+	res, err := v.opts.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request registry: %w", err)
+	}
+	defer res.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxRemoteResponse))
+	if err != nil {
+		return nil, fmt.Errorf("read response: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		var e struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(data, &e) == nil && e.Error != "" {
+			return nil, fmt.Errorf("registry responded with %s: %s", res.Status, e.Error)
+		}
+		return nil, fmt.Errorf("registry responded with %s", res.Status)
+	}
+
+	var report validator.Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("decode report: %w", err)
+	}
+	if report.Version != validator.ReportVersion {
+		return nil, fmt.Errorf("unsupported report version %d, expected %d", report.Version, validator.ReportVersion)
+	}
+	return &report, nil
+}
@@ -0,0 +1,86 @@
+package ctitest
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRegistry serves /validate of the registry with the local validator.
+func fakeRegistry(t *testing.T, local *LocalValidator, token string) *httptest.Server {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON := func(status int, v any) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			_ = json.NewEncoder(w).Encode(v)
+		}
+		if r.Method != http.MethodPost || r.URL.Path != "/validate" {
+			writeJSON(http.StatusNotFound, map[string]string{"error": "not found"})
+			return
+		}
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			writeJSON(http.StatusUnauthorized, map[string]string{"error": "invalid token"})
+			return
+		}
+		var req remoteRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		report, err := local.Validate(r.Context(), req.Type, req.Values)
+		if err != nil {
+			writeJSON(http.StatusNotFound, map[string]string{"error": err.Error()})
+			return
+		}
+		writeJSON(http.StatusOK, report)
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func Test_RemoteValidator(t *testing.T) {
+	local := testValidator(t)
+	srv := fakeRegistry(t, local, "read-token")
+
+	valid, invalid := true, false
+	suite := &Suite{Cases: []*Case{
+		{Name: "valid", Type: "cti.a.b.topic.v1.0", Values: map[string]any{"name": "orders"}, Valid: &valid},
+		{Name: "invalid", Type: "cti.a.b.topic.v1.0", Values: map[string]any{"name": 1}, Valid: &invalid},
+		{Name: "missing name", Type: "cti.a.b.topic.v1.0", Values: map[string]any{}, Valid: &valid},
+		{Name: "unknown type", Type: "cti.a.b.unknown.v1.0", Values: map[string]any{}, Valid: &valid},
+	}}
+
+	remote, err := NewRemoteValidator(srv.URL+"/", WithToken("read-token"))
+	require.NoError(t, err)
+	expected := Run(context.Background(), suite, local)
+	actual := Run(context.Background(), suite, remote)
+	for i, res := range actual.Results {
+		require.Equal(t, expected.Results[i].Report, res.Report, res.Case.Name)
+		require.Equal(t, expected.Results[i].Failures, res.Failures, res.Case.Name)
+	}
+	require.EqualError(t, actual.Results[3].Err,
+		"validate: registry responded with 404 Not Found: type cti.a.b.unknown.v1.0 not found")
+
+	remote, err = NewRemoteValidator(srv.URL, WithToken("unknown-token"))
+	require.NoError(t, err)
+	_, err = remote.Validate(context.Background(), "cti.a.b.topic.v1.0", []byte(`{}`))
+	require.ErrorContains(t, err, "registry responded with 401 Unauthorized")
+
+	other := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"status": "ok"}`))
+	}))
+	t.Cleanup(other.Close)
+	remote, err = NewRemoteValidator(other.URL)
+	require.NoError(t, err)
+	_, err = remote.Validate(context.Background(), "cti.a.b.topic.v1.0", []byte(`{}`))
+	require.EqualError(t, err, "unsupported report version 0, expected 1")
+
+	_, err = NewRemoteValidator("registry.example.com")
+	require.EqualError(t, err, "registry url registry.example.com must be an absolute http or https url")
+}
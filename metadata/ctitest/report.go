@@ -0,0 +1,184 @@
+package ctitest
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+type junitSuites struct {
+	XMLName  xml.Name     `xml:"testsuites"`
+	Tests    int          `xml:"tests,attr"`
+	Failures int          `xml:"failures,attr"`
+	Errors   int          `xml:"errors,attr"`
+	Time     string       `xml:"time,attr"`
+	Suites   []junitSuite `xml:"testsuite"`
+}
+
+type junitSuite struct {
+	Name     string      `xml:"name,attr"`
+	Tests    int         `xml:"tests,attr"`
+	Failures int         `xml:"failures,attr"`
+	Errors   int         `xml:"errors,attr"`
+	Time     string      `xml:"time,attr"`
+	Cases    []junitCase `xml:"testcase"`
+}
+
+type junitCase struct {
+	Name      string        `xml:"name,attr"`
+	ClassName string        `xml:"classname,attr"`
+	Time      string        `xml:"time,attr"`
+	Failure   *junitProblem `xml:"failure,omitempty"`
+	Error     *junitProblem `xml:"error,omitempty"`
+}
+
+type junitProblem struct {
+	Message string `xml:"message,attr"`
+	Text    string `xml:",cdata"`
+}
+
+// WriteJUnit writes results in JUnit XML format understood by CI servers. Cases of a file make a test suite
+// named by the file, snapshots make the suite named snapshots. Unmet expectations and changed schemas are
+// reported as failures, cases and snapshots that could not be checked as errors.
+func WriteJUnit(w io.Writer, results *Results) error {
+	doc := junitSuites{Time: seconds(results.Duration.Seconds())}
+	suites := make(map[string]int)
+	// Suites are timed by their cases, since cases of files are not run separately.
+	var times []float64
+	for _, res := range results.Results {
+		i, ok := suites[res.Case.File]
+		if !ok {
+			i = len(doc.Suites)
+			suites[res.Case.File] = i
+			doc.Suites = append(doc.Suites, junitSuite{Name: res.Case.File})
+			times = append(times, 0)
+		}
+		times[i] += res.Duration.Seconds()
+		suite := &doc.Suites[i]
+
+		tc := junitCase{Name: res.Case.Name, ClassName: res.Case.File, Time: seconds(res.Duration.Seconds())}
+		switch {
+		case res.Err != nil:
+			tc.Error = &junitProblem{Message: res.Err.Error()}
+			suite.Errors++
+			doc.Errors++
+		case len(res.Failures) > 0:
+			tc.Failure = &junitProblem{Message: res.Failures[0], Text: strings.Join(res.Failures, "\n")}
+			suite.Failures++
+			doc.Failures++
+		}
+		suite.Tests++
+		suite.Cases = append(suite.Cases, tc)
+		doc.Tests++
+	}
+	for i := range doc.Suites {
+		doc.Suites[i].Time = seconds(times[i])
+	}
+	if len(results.Snapshots) > 0 {
+		suite := junitSuite{Name: SnapshotsDir}
+		var total float64
+		for _, res := range results.Snapshots {
+			tc := junitCase{Name: res.Type, ClassName: SnapshotsDir, Time: seconds(res.Duration.Seconds())}
+			switch {
+			case res.Err != nil:
+				tc.Error = &junitProblem{Message: res.Err.Error()}
+				suite.Errors++
+			case res.Diff != "":
+				tc.Failure = &junitProblem{Message: snapshotFailure(res), Text: res.Diff}
+				suite.Failures++
+			}
+			suite.Tests++
+			suite.Cases = append(suite.Cases, tc)
+			total += res.Duration.Seconds()
+		}
+		suite.Time = seconds(total)
+		doc.Suites = append(doc.Suites, suite)
+		doc.Tests += suite.Tests
+		doc.Failures += suite.Failures
+		doc.Errors += suite.Errors
+	}
+
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("write header: %w", err)
+	}
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(doc); err != nil {
+		return fmt.Errorf("encode results: %w", err)
+	}
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("write results: %w", err)
+	}
+	return nil
+}
+
+func seconds(s float64) string {
+	return fmt.Sprintf("%.6f", s)
+}
+
+// WriteTAP writes results in Test Anything Protocol version 13. Failures and errors of a case are written
+// in a YAML diagnostic block after its test line.
+func WriteTAP(w io.Writer, results *Results) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "TAP version 13\n1..%d\n", results.Total())
+	for i, res := range results.Results {
+		diag := map[string]any{}
+		switch {
+		case res.Err != nil:
+			diag["error"] = res.Err.Error()
+		case len(res.Failures) > 0:
+			diag["failures"] = res.Failures
+		}
+		if err := writeTAPLine(&b, i+1, res.Case.ID(), diag); err != nil {
+			return err
+		}
+	}
+	for i, res := range results.Snapshots {
+		diag := map[string]any{}
+		switch {
+		case res.Err != nil:
+			diag["error"] = res.Err.Error()
+		case res.Diff != "":
+			diag["message"] = snapshotFailure(res)
+			diag["diff"] = res.Diff
+		}
+		if err := writeTAPLine(&b, len(results.Results)+i+1, res.File, diag); err != nil {
+			return err
+		}
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		return fmt.Errorf("write results: %w", err)
+	}
+	return nil
+}
+
+// writeTAPLine writes the test line, failed if there are diagnostics, followed by the diagnostic block.
+func writeTAPLine(b *strings.Builder, n int, id string, diag map[string]any) error {
+	if len(diag) == 0 {
+		fmt.Fprintf(b, "ok %d - %s\n", n, tapEscape(id))
+		return nil
+	}
+	fmt.Fprintf(b, "not ok %d - %s\n", n, tapEscape(id))
+	data, err := yaml.Marshal(diag)
+	if err != nil {
+		return fmt.Errorf("encode diagnostics: %w", err)
+	}
+	b.WriteString("  ---\n")
+	for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+		b.WriteString("  " + line + "\n")
+	}
+	b.WriteString("  ...\n")
+	return nil
+}
+
+func snapshotFailure(res *SnapshotResult) string {
+	return fmt.Sprintf("schema of %s differs from golden file %s", res.Type, res.File)
+}
+
+// tapEscape escapes characters that have a meaning in test lines: # starts a directive.
+func tapEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "#", `\#`).Replace(s)
+}
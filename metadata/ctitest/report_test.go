@@ -0,0 +1,92 @@
+package ctitest
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testResults() *Results {
+	return &Results{
+		Results: []*Result{
+			{Case: &Case{Name: "valid", File: "alerts.yaml"}, Duration: time.Millisecond},
+			{
+				Case:     &Case{Name: "unexpectedly valid", File: "alerts.yaml"},
+				Failures: []string{"expected invalid values, got no errors"},
+				Duration: 2 * time.Millisecond,
+			},
+			{
+				Case:     &Case{Name: "unknown type #1", File: "events.yaml"},
+				Err:      errors.New("validate: type cti.a.b.unknown.v1.0 not found"),
+				Duration: 500 * time.Microsecond,
+			},
+		},
+		Snapshots: []*SnapshotResult{
+			{Type: "cti.a.b.alert.v1.0", File: "snapshots/cti.a.b.alert.v1.0.json", Duration: time.Millisecond},
+			{
+				Type:     "cti.a.b.topic.v1.0",
+				File:     "snapshots/cti.a.b.topic.v1.0.json",
+				Diff:     "-  \"type\": \"string\"\n+  \"type\": \"integer\"\n",
+				Duration: time.Millisecond,
+			},
+		},
+		Duration: 6 * time.Millisecond,
+	}
+}
+
+func Test_WriteJUnit(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteJUnit(&buf, testResults()))
+	require.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<testsuites tests="5" failures="2" errors="1" time="0.006000">
+  <testsuite name="alerts.yaml" tests="2" failures="1" errors="0" time="0.003000">
+    <testcase name="valid" classname="alerts.yaml" time="0.001000"></testcase>
+    <testcase name="unexpectedly valid" classname="alerts.yaml" time="0.002000">
+      <failure message="expected invalid values, got no errors"><![CDATA[expected invalid values, got no errors]]></failure>
+    </testcase>
+  </testsuite>
+  <testsuite name="events.yaml" tests="1" failures="0" errors="1" time="0.000500">
+    <testcase name="unknown type #1" classname="events.yaml" time="0.000500">
+      <error message="validate: type cti.a.b.unknown.v1.0 not found"></error>
+    </testcase>
+  </testsuite>
+  <testsuite name="snapshots" tests="2" failures="1" errors="0" time="0.002000">
+    <testcase name="cti.a.b.alert.v1.0" classname="snapshots" time="0.001000"></testcase>
+    <testcase name="cti.a.b.topic.v1.0" classname="snapshots" time="0.001000">
+      <failure message="schema of cti.a.b.topic.v1.0 differs from golden file snapshots/cti.a.b.topic.v1.0.json"><![CDATA[-  "type": "string"
++  "type": "integer"
+]]></failure>
+    </testcase>
+  </testsuite>
+</testsuites>
+`, buf.String())
+}
+
+func Test_WriteTAP(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, WriteTAP(&buf, testResults()))
+	require.Equal(t, `TAP version 13
+1..5
+ok 1 - alerts.yaml/valid
+not ok 2 - alerts.yaml/unexpectedly valid
+  ---
+  failures:
+      - expected invalid values, got no errors
+  ...
+not ok 3 - events.yaml/unknown type \#1
+  ---
+  error: 'validate: type cti.a.b.unknown.v1.0 not found'
+  ...
+ok 4 - snapshots/cti.a.b.alert.v1.0.json
+not ok 5 - snapshots/cti.a.b.topic.v1.0.json
+  ---
+  diff: |
+      -  "type": "string"
+      +  "type": "integer"
+  message: schema of cti.a.b.topic.v1.0 differs from golden file snapshots/cti.a.b.topic.v1.0.json
+  ...
+`, buf.String())
+}
@@ -0,0 +1,240 @@
+package ctitest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+	"github.com/acronis/go-cti/metadata/validator"
+)
+
+// Validator validates values of an instance of the type and reports found diagnostics.
+type Validator interface {
+	Validate(ctx context.Context, typeID string, values json.RawMessage) (*validator.Report, error)
+}
+
+// LocalValidator validates values with the validator of the package, the same way as 'cti validate' does.
+// It is safe for concurrent use.
+type LocalValidator struct {
+	registry *registry.Registry
+	opts     []validator.Option
+	// A validator collects diagnostics of the last call, so concurrent validations take validators from the pool.
+	mu   sync.Mutex
+	pool []*validator.MetadataValidator
+}
+
+// NewLocalValidator creates a validator of values of instances of types of the registry.
+func NewLocalValidator(r *registry.Registry, opts ...validator.Option) *LocalValidator {
+	return &LocalValidator{registry: r, opts: opts}
+}
+
+// Validate validates values of the anonymous instance of the type with nil UUID.
+func (v *LocalValidator) Validate(_ context.Context, typeID string, values json.RawMessage) (*validator.Report, error) {
+	if _, ok := v.registry.GetType(typeID); !ok {
+		return nil, fmt.Errorf("type %s not found", typeID)
+	}
+	mv := v.acquire()
+	defer v.release(mv)
+	diagnostics := mv.Diagnostics(&metadata.Entity{Cti: typeID + "~" + uuid.Nil.String(), Values: values})
+	return validator.NewReport(1, diagnostics...), nil
+}
+
+func (v *LocalValidator) acquire() *validator.MetadataValidator {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if n := len(v.pool); n > 0 {
+		mv := v.pool[n-1]
+		v.pool = v.pool[:n-1]
+		return mv
+	}
+	mv := validator.MakeMetadataValidator(v.opts...)
+	mv.LoadRegistry(v.registry)
+	return mv
+}
+
+func (v *LocalValidator) release(mv *validator.MetadataValidator) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.pool = append(v.pool, mv)
+}
+
+// Result is a result of the test case.
+type Result struct {
+	Case *Case
+	// Report is the validation report, nil if the case could not be run.
+	Report *validator.Report
+	// Failures describe unmet expectations of the case.
+	Failures []string
+	// Err is set if the case could not be run, e.g. its type is unknown.
+	Err      error
+	Duration time.Duration
+}
+
+// Passed reports whether the case was run and met all expectations.
+func (r *Result) Passed() bool {
+	return r.Err == nil && len(r.Failures) == 0
+}
+
+// Results are results of cases of the suite in the order of cases, followed by results of snapshots.
+type Results struct {
+	Results   []*Result
+	Snapshots []*SnapshotResult
+	Duration  time.Duration
+}
+
+// Total returns the number of cases and snapshots.
+func (r *Results) Total() int {
+	return len(r.Results) + len(r.Snapshots)
+}
+
+// Failed returns the number of cases and snapshots that did not pass.
+func (r *Results) Failed() int {
+	var n int
+	for _, res := range r.Results {
+		if !res.Passed() {
+			n++
+		}
+	}
+	for _, res := range r.Snapshots {
+		if !res.Passed() {
+			n++
+		}
+	}
+	return n
+}
+
+type runOptions struct {
+	parallel int
+}
+
+type RunOption func(*runOptions)
+
+// WithParallel sets the number of cases validated concurrently. Cases are run one by one by default.
+func WithParallel(n int) RunOption {
+	return func(o *runOptions) {
+		o.parallel = n
+	}
+}
+
+// Run runs cases of the suite with the validator. Results are in the order of cases regardless of the order
+// in which cases are run. Cases that are not run before the context is done fail with the error of the context.
+func Run(ctx context.Context, suite *Suite, v Validator, opts ...RunOption) *Results {
+	o := runOptions{parallel: 1}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	workers := min(max(o.parallel, 1), len(suite.Cases))
+
+	start := time.Now()
+	res := &Results{Results: make([]*Result, len(suite.Cases))}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				if err := ctx.Err(); err != nil {
+					res.Results[i] = &Result{Case: suite.Cases[i], Err: err}
+					continue
+				}
+				res.Results[i] = runCase(ctx, suite.Cases[i], v)
+			}
+		}()
+	}
+	for i := range suite.Cases {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+	res.Duration = time.Since(start)
+	return res
+}
+
+func runCase(ctx context.Context, c *Case, v Validator) *Result {
+	start := time.Now()
+	res := &Result{Case: c}
+	defer func() { res.Duration = time.Since(start) }()
+
+	values, err := c.JSONValues()
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	report, err := v.Validate(ctx, c.Type, values)
+	if err != nil {
+		res.Err = fmt.Errorf("validate: %w", err)
+		return res
+	}
+	res.Report = report
+	res.Failures = check(c, report)
+	return res
+}
+
+// check returns descriptions of expectations of the case that the report does not meet.
+func check(c *Case, report *validator.Report) []string {
+	var errs []validator.ReportDiagnostic
+	for _, file := range report.Files {
+		for _, d := range file.Diagnostics {
+			if d.Severity == validator.SeverityError {
+				errs = append(errs, d)
+			}
+		}
+	}
+
+	if *c.Valid {
+		if report.Valid {
+			return nil
+		}
+		failures := []string{fmt.Sprintf("expected valid values, got %d error(s)", len(errs))}
+		for _, d := range errs {
+			failures = append(failures, fmt.Sprintf("%s: %s", d.Code, d.Message))
+		}
+		return failures
+	}
+
+	if report.Valid {
+		return []string{"expected invalid values, got no errors"}
+	}
+	reported := make(map[validator.Code]struct{}, len(errs))
+	for _, d := range errs {
+		reported[d.Code] = struct{}{}
+	}
+	var failures []string
+	for _, code := range c.Codes {
+		if _, ok := reported[code]; !ok {
+			failures = append(failures, fmt.Sprintf("expected diagnostic %s, got %s", code, joinCodes(reported)))
+		}
+	}
+	var unmet []string
+	for _, expected := range c.Diagnostics {
+		if !slices.ContainsFunc(errs, expected.Match) {
+			unmet = append(unmet, fmt.Sprintf("expected diagnostic %s", expected))
+		}
+	}
+	if len(unmet) > 0 {
+		failures = append(failures, unmet...)
+		for _, d := range errs {
+			failures = append(failures, fmt.Sprintf("got %s at %s: %s", d.Code, formatReportPath(d.Path), d.Message))
+		}
+	}
+	return failures
+}
+
+func joinCodes(codes map[validator.Code]struct{}) string {
+	res := make([]string, 0, len(codes))
+	for code := range codes {
+		res = append(res, string(code))
+	}
+	sort.Strings(res)
+	return strings.Join(res, ", ")
+}
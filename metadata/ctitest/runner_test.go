@@ -0,0 +1,171 @@
+package ctitest
+
+import (
+	"context"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+	"github.com/acronis/go-cti/metadata/validator"
+)
+
+func testValidator(t *testing.T) *LocalValidator {
+	t.Helper()
+
+	displayName := true
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.a.b.topic.v1.0": {
+			Cti: "cti.a.b.topic.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Topic",
+				"definitions": {"Topic": {"type": "object", "properties": {"name": {"type": "string"}}, "required": ["name"]}}
+			}`),
+			Annotations: map[metadata.GJsonPath]metadata.Annotations{".name": {DisplayName: &displayName}},
+		},
+	})
+	require.NoError(t, err)
+	return NewLocalValidator(r)
+}
+
+func Test_Run(t *testing.T) {
+	valid, invalid := true, false
+	rootPath, namePath := "$", "name"
+	testCases := []struct {
+		name     string
+		c        Case
+		failures []string
+		err      string
+	}{
+		{
+			name: "valid",
+			c:    Case{Type: "cti.a.b.topic.v1.0", Values: map[string]any{"name": "users"}, Valid: &valid},
+		},
+		{
+			name: "invalid",
+			c: Case{
+				Type:   "cti.a.b.topic.v1.0",
+				Values: map[string]any{"name": 1},
+				Valid:  &invalid,
+				Codes:  []validator.Code{validator.CodeInvalidValues},
+			},
+		},
+		{
+			name: "unexpectedly invalid",
+			c:    Case{Type: "cti.a.b.topic.v1.0", Values: map[string]any{"name": 1}, Valid: &valid},
+			failures: []string{
+				"expected valid values, got 1 error(s)",
+				"CTI1020: cti.a.b.topic.v1.0~00000000-0000-0000-0000-000000000000@name: Invalid type. Expected: string, given: integer",
+			},
+		},
+		{
+			name:     "unexpectedly valid",
+			c:        Case{Type: "cti.a.b.topic.v1.0", Values: map[string]any{"name": "users"}, Valid: &invalid},
+			failures: []string{"expected invalid values, got no errors"},
+		},
+		{
+			name: "missing code",
+			c: Case{
+				Type:   "cti.a.b.topic.v1.0",
+				Values: map[string]any{},
+				Valid:  &invalid,
+				Codes:  []validator.Code{validator.CodeInvalidValues, validator.CodeDuplicateEntity},
+			},
+			failures: []string{"expected diagnostic CTI1040, got CTI1020"},
+		},
+		{
+			name: "diagnostics",
+			c: Case{
+				Type:   "cti.a.b.topic.v1.0",
+				Values: map[string]any{"name": 1},
+				Valid:  &invalid,
+				Diagnostics: []*Diagnostic{
+					{Code: validator.CodeInvalidValues, Path: &namePath, Message: "Expected: string"},
+					{Message: "given: integer"},
+				},
+			},
+		},
+		{
+			name: "unmet diagnostics",
+			c: Case{
+				Type:   "cti.a.b.topic.v1.0",
+				Values: map[string]any{},
+				Valid:  &invalid,
+				Diagnostics: []*Diagnostic{
+					{Code: validator.CodeInvalidValues, Path: &rootPath, Message: "name is required"},
+					{Code: validator.CodeInvalidValues, Path: &namePath},
+					{Code: validator.CodeDuplicateEntity},
+				},
+			},
+			failures: []string{
+				"expected diagnostic CTI1020 at name",
+				"expected diagnostic CTI1040",
+				"got CTI1020 at $: cti.a.b.topic.v1.0~00000000-0000-0000-0000-000000000000 contains invalid values: name is required",
+			},
+		},
+		{
+			name: "unknown type",
+			c:    Case{Type: "cti.a.b.unknown.v1.0", Values: map[string]any{}, Valid: &valid},
+			err:  "validate: type cti.a.b.unknown.v1.0 not found",
+		},
+	}
+
+	v := testValidator(t)
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.c.Name, tc.c.File = tc.name, "cases.yaml"
+			results := Run(context.Background(), &Suite{Cases: []*Case{&tc.c}}, v)
+			require.Len(t, results.Results, 1)
+			res := results.Results[0]
+			require.Same(t, &tc.c, res.Case)
+			require.Equal(t, tc.failures, res.Failures)
+			if tc.err != "" {
+				require.EqualError(t, res.Err, tc.err)
+				require.Nil(t, res.Report)
+			} else {
+				require.NoError(t, res.Err)
+				require.NotNil(t, res.Report)
+			}
+			passed := tc.err == "" && len(tc.failures) == 0
+			require.Equal(t, passed, res.Passed())
+			if passed {
+				require.Zero(t, results.Failed())
+			} else {
+				require.Equal(t, 1, results.Failed())
+			}
+		})
+	}
+}
+
+func Test_RunParallel(t *testing.T) {
+	valid, invalid := true, false
+	suite := &Suite{}
+	for i := range 20 {
+		c := &Case{Name: strconv.Itoa(i), File: "cases.yaml", Type: "cti.a.b.topic.v1.0"}
+		if i%2 == 0 {
+			c.Values, c.Valid = map[string]any{"name": "users"}, &valid
+		} else {
+			c.Values, c.Valid = map[string]any{"name": i}, &invalid
+		}
+		suite.Cases = append(suite.Cases, c)
+	}
+
+	v := testValidator(t)
+	results := Run(context.Background(), suite, v, WithParallel(4))
+	require.Len(t, results.Results, len(suite.Cases))
+	for i, res := range results.Results {
+		require.Same(t, suite.Cases[i], res.Case)
+		require.True(t, res.Passed(), res.Case.Name)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	results = Run(ctx, suite, v, WithParallel(4))
+	require.Equal(t, len(suite.Cases), results.Failed())
+	for _, res := range results.Results {
+		require.ErrorIs(t, res.Err, context.Canceled)
+	}
+}
@@ -0,0 +1,58 @@
+package ctitest
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Shard is a part of a suite, so that large suites can be split across CI jobs. Cases and snapshots are
+// distributed between shards round-robin in their order, so every case runs in exactly one of Total shards.
+type Shard struct {
+	// Index is the number of the shard starting from 1.
+	Index int
+	Total int
+}
+
+// ParseShard parses the shard in i/n form, e.g. 2/4 is the second of four shards.
+func ParseShard(s string) (Shard, error) {
+	index, total, ok := strings.Cut(s, "/")
+	if !ok {
+		return Shard{}, fmt.Errorf("shard %q is not in i/n form", s)
+	}
+	var (
+		res Shard
+		err error
+	)
+	if res.Index, err = strconv.Atoi(index); err != nil {
+		return Shard{}, fmt.Errorf("parse index of shard %q: %w", s, err)
+	}
+	if res.Total, err = strconv.Atoi(total); err != nil {
+		return Shard{}, fmt.Errorf("parse number of shards %q: %w", s, err)
+	}
+	if res.Total < 1 || res.Index < 1 || res.Index > res.Total {
+		return Shard{}, fmt.Errorf("shard %q must be between 1/n and n/n", s)
+	}
+	return res, nil
+}
+
+// String returns the shard in i/n form.
+func (s Shard) String() string {
+	return fmt.Sprintf("%d/%d", s.Index, s.Total)
+}
+
+// Select returns cases and snapshots of the suite that belong to the shard.
+func (s Shard) Select(suite *Suite) *Suite {
+	res := &Suite{}
+	for i, c := range suite.Cases {
+		if i%s.Total == s.Index-1 {
+			res.Cases = append(res.Cases, c)
+		}
+	}
+	for i, typ := range suite.Snapshots {
+		if i%s.Total == s.Index-1 {
+			res.Snapshots = append(res.Snapshots, typ)
+		}
+	}
+	return res
+}
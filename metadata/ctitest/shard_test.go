@@ -0,0 +1,59 @@
+package ctitest
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseShard(t *testing.T) {
+	testCases := []struct {
+		shard string
+		want  Shard
+		err   string
+	}{
+		{shard: "1/1", want: Shard{Index: 1, Total: 1}},
+		{shard: "2/4", want: Shard{Index: 2, Total: 4}},
+		{shard: "2", err: `shard "2" is not in i/n form`},
+		{shard: "a/4", err: `parse index of shard "a/4": strconv.Atoi: parsing "a": invalid syntax`},
+		{shard: "1/b", err: `parse number of shards "1/b": strconv.Atoi: parsing "b": invalid syntax`},
+		{shard: "0/4", err: `shard "0/4" must be between 1/n and n/n`},
+		{shard: "5/4", err: `shard "5/4" must be between 1/n and n/n`},
+		{shard: "1/0", err: `shard "1/0" must be between 1/n and n/n`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.shard, func(t *testing.T) {
+			shard, err := ParseShard(tc.shard)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.want, shard)
+			require.Equal(t, tc.shard, shard.String())
+		})
+	}
+}
+
+func Test_ShardSelect(t *testing.T) {
+	suite := &Suite{Snapshots: []string{"cti.a.b.a.v1.0", "cti.a.b.b.v1.0", "cti.a.b.c.v1.0"}}
+	for _, name := range []string{"a", "b", "c", "d", "e"} {
+		suite.Cases = append(suite.Cases, &Case{Name: name})
+	}
+
+	names := func(s *Suite) []string {
+		var res []string
+		for _, c := range s.Cases {
+			res = append(res, c.Name)
+		}
+		return res
+	}
+	first := Shard{Index: 1, Total: 2}.Select(suite)
+	require.Equal(t, []string{"a", "c", "e"}, names(first))
+	require.Equal(t, []string{"cti.a.b.a.v1.0", "cti.a.b.c.v1.0"}, first.Snapshots)
+	second := Shard{Index: 2, Total: 2}.Select(suite)
+	require.Equal(t, []string{"b", "d"}, names(second))
+	require.Equal(t, []string{"cti.a.b.b.v1.0"}, second.Snapshots)
+	require.Equal(t, suite, Shard{Index: 1, Total: 1}.Select(suite))
+}
@@ -0,0 +1,124 @@
+package ctitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/pmezard/go-difflib/difflib"
+
+	"github.com/acronis/go-cti/metadata/compiled"
+)
+
+// SnapshotsDir is the directory of golden files in the tests directory.
+const SnapshotsDir = "snapshots"
+
+// SnapshotResult is a result of comparison of the flattened schema of the type with its golden file.
+type SnapshotResult struct {
+	Type string
+	// File is the path of the golden file relative to the tests directory.
+	File string
+	// Diff is the unified diff of the golden file and the schema, empty if they are equal.
+	Diff string
+	// Updated is set if the golden file was created or rewritten in update mode.
+	Updated bool
+	// Err is set if the snapshot could not be checked, e.g. the type is unknown or the golden file is missing.
+	Err      error
+	Duration time.Duration
+}
+
+// Passed reports whether the schema matches the golden file.
+func (r *SnapshotResult) Passed() bool {
+	return r.Err == nil && r.Diff == ""
+}
+
+// SnapshotFile returns the path of the golden file of the type relative to the tests directory.
+func SnapshotFile(typeID string) string {
+	return path.Join(SnapshotsDir, typeID+".json")
+}
+
+// CheckSnapshots compares flattened schemas of the types with golden files in the tests directory, so that
+// unexpected changes of resolution of schemas are caught. In update mode golden files are rewritten instead,
+// to accept intentional changes.
+func CheckSnapshots(r *compiled.Registry, dir string, types []string, update bool) []*SnapshotResult {
+	res := make([]*SnapshotResult, 0, len(types))
+	for _, typeID := range types {
+		res = append(res, checkSnapshot(r, dir, typeID, update))
+	}
+	return res
+}
+
+func checkSnapshot(r *compiled.Registry, dir string, typeID string, update bool) *SnapshotResult {
+	start := time.Now()
+	res := &SnapshotResult{Type: typeID, File: SnapshotFile(typeID)}
+	defer func() { res.Duration = time.Since(start) }()
+
+	typ, ok := r.Type(typeID)
+	if !ok {
+		res.Err = fmt.Errorf("type %s not found", typeID)
+		return res
+	}
+	actual, err := normalizeSchema(typ.Schema)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+
+	fPath := filepath.Join(dir, filepath.FromSlash(res.File))
+	expected, err := os.ReadFile(fPath)
+	switch {
+	case err == nil && bytes.Equal(expected, actual):
+		return res
+	case update:
+		if err := os.MkdirAll(filepath.Dir(fPath), 0755); err != nil {
+			res.Err = fmt.Errorf("create snapshots directory: %w", err)
+			return res
+		}
+		if err := os.WriteFile(fPath, actual, 0644); err != nil {
+			res.Err = fmt.Errorf("write golden file: %w", err)
+			return res
+		}
+		res.Updated = true
+		return res
+	case errors.Is(err, fs.ErrNotExist):
+		res.Err = fmt.Errorf("golden file %s not found, update snapshots to create it", res.File)
+		return res
+	case err != nil:
+		res.Err = fmt.Errorf("read golden file: %w", err)
+		return res
+	}
+
+	res.Diff, err = difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(expected)),
+		B:        difflib.SplitLines(string(actual)),
+		FromFile: "a/" + res.File,
+		ToFile:   "b/" + res.File,
+		Context:  3,
+	})
+	if err != nil {
+		res.Err = fmt.Errorf("diff schema: %w", err)
+	}
+	return res
+}
+
+// normalizeSchema serializes the schema as indented JSON with sorted keys, so equal schemas are serialized
+// equally and diffs show one value per line.
+func normalizeSchema(schema json.RawMessage) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(schema))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("decode schema: %w", err)
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("encode schema: %w", err)
+	}
+	return append(data, '\n'), nil
+}
@@ -0,0 +1,72 @@
+package ctitest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/compiled"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func compileTopic(t *testing.T, nameType string) *compiled.Registry {
+	t.Helper()
+
+	displayName := true
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.a.b.topic.v1.0": {
+			Cti: "cti.a.b.topic.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Topic",
+				"definitions": {"Topic": {"type": "object", "properties": {"name": {"type": "` + nameType + `"}}}}
+			}`),
+			Annotations: map[metadata.GJsonPath]metadata.Annotations{".name": {DisplayName: &displayName}},
+		},
+	})
+	require.NoError(t, err)
+	c, err := compiled.Compile(r)
+	require.NoError(t, err)
+	return c
+}
+
+func Test_CheckSnapshots(t *testing.T) {
+	dir := t.TempDir()
+	types := []string{"cti.a.b.topic.v1.0"}
+	golden := filepath.Join(dir, "snapshots", "cti.a.b.topic.v1.0.json")
+
+	res := CheckSnapshots(compileTopic(t, "string"), dir, types, false)
+	require.Len(t, res, 1)
+	require.Equal(t, "snapshots/cti.a.b.topic.v1.0.json", res[0].File)
+	require.EqualError(t, res[0].Err, "golden file snapshots/cti.a.b.topic.v1.0.json not found, update snapshots to create it")
+	require.False(t, res[0].Passed())
+
+	res = CheckSnapshots(compileTopic(t, "string"), dir, types, true)
+	require.True(t, res[0].Passed())
+	require.True(t, res[0].Updated)
+	data, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	require.Contains(t, string(data), "\n      \"properties\": {\n")
+
+	res = CheckSnapshots(compileTopic(t, "string"), dir, types, false)
+	require.True(t, res[0].Passed())
+	require.False(t, res[0].Updated)
+
+	res = CheckSnapshots(compileTopic(t, "integer"), dir, types, false)
+	require.NoError(t, res[0].Err)
+	require.False(t, res[0].Passed())
+	require.Contains(t, res[0].Diff, "--- a/snapshots/cti.a.b.topic.v1.0.json\n+++ b/snapshots/cti.a.b.topic.v1.0.json\n")
+	require.Contains(t, res[0].Diff, "\n-          \"type\": \"string\"\n+          \"type\": \"integer\"\n")
+
+	res = CheckSnapshots(compileTopic(t, "integer"), dir, types, true)
+	require.True(t, res[0].Passed())
+	require.True(t, res[0].Updated)
+	res = CheckSnapshots(compileTopic(t, "integer"), dir, types, false)
+	require.True(t, res[0].Passed())
+
+	res = CheckSnapshots(compileTopic(t, "integer"), dir, []string{"cti.a.b.unknown.v1.0"}, true)
+	require.EqualError(t, res[0].Err, "type cti.a.b.unknown.v1.0 not found")
+}
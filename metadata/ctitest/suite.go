@@ -0,0 +1,361 @@
+// Package ctitest runs declarative tests of CTI packages. A test case pairs values of an instance with the type
+// they are validated against and the expected outcome, so changes of schemas can be regression-tested.
+//
+// Cases are defined in YAML files of the tests directory of the package, a file holds any number of cases:
+//
+//	cases:
+//	  - name: valid alert
+//	    type: cti.x.y.alert.v1.0
+//	    values: {severity: high, message: Disk is full}
+//	    valid: true
+//	  - name: unknown severity
+//	    type: cti.x.y.alert.v1.0
+//	    values: {severity: fatal, message: Disk is full}
+//	    valid: false
+//	    codes: [CTI1020]
+//	    tags: [alerts]
+//	  - name: empty message
+//	    type: cti.x.y.alert.v1.0
+//	    values: {severity: high, message: ""}
+//	    valid: false
+//	    diagnostics:
+//	      - {code: CTI1020, path: message, message: length must be greater than or equal to 1}
+//
+// Invalid cases may list diagnostic codes that validation must report, other reported codes are allowed.
+// Diagnostics assert details of reported diagnostics: the code, the path of the invalid value and a substring
+// of the message, so that the quality of error reporting is tested as well.
+// Tags label cases, so that a part of the suite can be run.
+//
+// Values shared by cases are kept in fixtures, YAML or JSON files of the fixtures directory, see LoadFixtures.
+// A case names the fixture its values are merged into, and a setup of the file may provide defaults of values,
+// environment-specific overlays and fixtures generated as valid instances of types:
+//
+//	setup:
+//	  values: {source: monitoring}
+//	  overlays:
+//	    prod: {severity: critical}
+//	  generate:
+//	    - {fixture: alert, type: cti.x.y.alert.v1.0}
+//	cases:
+//	  - name: long message
+//	    type: cti.x.y.alert.v1.0
+//	    fixture: alert
+//	    values: {message: ...}
+//	    valid: false
+//
+// Files may also list types whose flattened schemas are snapshotted into golden files, see CheckSnapshots:
+//
+//	snapshots:
+//	  - cti.x.y.alert.v1.0
+package ctitest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/acronis/go-cti/metadata/registry"
+	"github.com/acronis/go-cti/metadata/validator"
+)
+
+// DefaultDir is the directory of test files in the package.
+const DefaultDir = "tests"
+
+// Case is a test case: values of an instance of the type and the expected outcome of their validation.
+type Case struct {
+	Name string `yaml:"name"`
+	Type string `yaml:"type"`
+	// Fixture is a fixture that Values are merged into, see Setup for the order of merging.
+	Fixture string `yaml:"fixture"`
+	Values  any    `yaml:"values"`
+	// Valid is the expected outcome of validation. Required, so that forgotten expectations are not passed silently.
+	Valid *bool `yaml:"valid"`
+	// Codes are diagnostic codes that validation of invalid values must report.
+	Codes []validator.Code `yaml:"codes"`
+	// Diagnostics are details of diagnostics that validation of invalid values must report.
+	Diagnostics []*Diagnostic `yaml:"diagnostics"`
+	// Tags label the case, so that a part of the suite can be run, see Filter.
+	Tags []string `yaml:"tags"`
+
+	// File is the path of the file defining the case relative to the tests directory, set by Load.
+	File string `yaml:"-"`
+}
+
+// Diagnostic is an expected diagnostic of invalid values. A reported error matches it if it has the code,
+// the path and the message containing the substring, unset fields match any value.
+type Diagnostic struct {
+	Code validator.Code `yaml:"code"`
+	// Path is the path of the invalid value, e.g. data.tags.0, the root of values is denoted by $ or the empty path.
+	Path *string `yaml:"path"`
+	// Message is a substring of the message.
+	Message string `yaml:"message"`
+}
+
+// Match reports whether the reported diagnostic matches the expected one.
+func (d *Diagnostic) Match(reported validator.ReportDiagnostic) bool {
+	return (d.Code == "" || d.Code == reported.Code) &&
+		(d.Path == nil || formatReportPath(*d.Path) == formatReportPath(reported.Path)) &&
+		strings.Contains(reported.Message, d.Message)
+}
+
+// String describes the diagnostic, e.g. CTI1020 at threshold with message "maximum", unset fields are omitted.
+func (d *Diagnostic) String() string {
+	var parts []string
+	if d.Code != "" {
+		parts = append(parts, string(d.Code))
+	}
+	if d.Path != nil {
+		parts = append(parts, "at "+formatReportPath(*d.Path))
+	}
+	if d.Message != "" {
+		parts = append(parts, fmt.Sprintf("with message %q", d.Message))
+	}
+	return strings.Join(parts, " ")
+}
+
+// formatReportPath formats the path of a reported diagnostic, the root is denoted by $.
+func formatReportPath(path string) string {
+	if path == "" {
+		return "$"
+	}
+	return path
+}
+
+// ID identifies the case in reports: the file and the name of the case.
+func (c *Case) ID() string {
+	return c.File + "/" + c.Name
+}
+
+// JSONValues returns values of the case in JSON.
+func (c *Case) JSONValues() (json.RawMessage, error) {
+	data, err := json.Marshal(c.Values)
+	if err != nil {
+		return nil, fmt.Errorf("encode values: %w", err)
+	}
+	return data, nil
+}
+
+// File is a file with test cases.
+type File struct {
+	Setup *Setup  `yaml:"setup"`
+	Cases []*Case `yaml:"cases"`
+	// Snapshots are types whose flattened schemas are compared with golden files.
+	Snapshots []string `yaml:"snapshots"`
+}
+
+// Suite is a set of test cases ordered by files and positions of cases in files.
+type Suite struct {
+	Cases []*Case
+	// Snapshots are types of snapshots of all files, ordered the same way as cases.
+	Snapshots []string
+}
+
+type loadOptions struct {
+	overlay  string
+	registry *registry.Registry
+}
+
+type LoadOption func(*loadOptions)
+
+// WithOverlay selects overlays of setups of files with the name, e.g. prod.
+func WithOverlay(name string) LoadOption {
+	return func(o *loadOptions) {
+		o.overlay = name
+	}
+}
+
+// WithRegistry sets the registry that fixtures generated by setups of files are instances of.
+func WithRegistry(r *registry.Registry) LoadOption {
+	return func(o *loadOptions) {
+		o.registry = r
+	}
+}
+
+// Load reads test cases from YAML files in the directory and its subdirectories, except fixtures and snapshots.
+// Values of cases are merged with fixtures and setups of files.
+func Load(dir string, opts ...LoadOption) (*Suite, error) {
+	var o loadOptions
+	for _, opt := range opts {
+		opt(&o)
+	}
+	fixtures, err := LoadFixtures(filepath.Join(dir, FixturesDir))
+	if err != nil {
+		return nil, fmt.Errorf("load fixtures: %w", err)
+	}
+
+	var files []string
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && (path == filepath.Join(dir, FixturesDir) || path == filepath.Join(dir, SnapshotsDir)) {
+			return fs.SkipDir
+		}
+		if !d.IsDir() && (filepath.Ext(path) == ".yaml" || filepath.Ext(path) == ".yml") {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", dir, err)
+	}
+	sort.Strings(files)
+
+	suite := &Suite{}
+	snapshots := make(map[string]struct{})
+	overlayFound := false
+	for _, path := range files {
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return nil, fmt.Errorf("get relative path: %w", err)
+		}
+		f, err := loadFile(path, filepath.ToSlash(rel), fixtures, &o)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", rel, err)
+		}
+		if f.Setup != nil {
+			_, ok := f.Setup.Overlays[o.overlay]
+			overlayFound = overlayFound || ok
+		}
+		suite.Cases = append(suite.Cases, f.Cases...)
+		for _, typ := range f.Snapshots {
+			if _, ok := snapshots[typ]; ok {
+				return nil, fmt.Errorf("%s: snapshot %s: duplicate type", rel, typ)
+			}
+			snapshots[typ] = struct{}{}
+			suite.Snapshots = append(suite.Snapshots, typ)
+		}
+	}
+	if o.overlay != "" && !overlayFound {
+		return nil, fmt.Errorf("overlay %s is not defined in any file", o.overlay)
+	}
+	return suite, nil
+}
+
+func loadFile(path string, name string, fixtures Fixtures, o *loadOptions) (*File, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read file: %w", err)
+	}
+	var f File
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	if err := dec.Decode(&f); err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("decode file: %w", err)
+	}
+	if f.Setup != nil {
+		if fixtures, err = f.Setup.prepare(fixtures, o.registry); err != nil {
+			return nil, fmt.Errorf("setup: %w", err)
+		}
+	}
+
+	names := make(map[string]struct{}, len(f.Cases))
+	for i, c := range f.Cases {
+		err := c.resolve(f.Setup, fixtures, o.overlay)
+		if err == nil {
+			err = c.check()
+		}
+		if err != nil {
+			if c.Name == "" {
+				return nil, fmt.Errorf("case %d: %w", i+1, err)
+			}
+			return nil, fmt.Errorf("case %s: %w", c.Name, err)
+		}
+		if _, ok := names[c.Name]; ok {
+			return nil, fmt.Errorf("case %s: duplicate name", c.Name)
+		}
+		names[c.Name] = struct{}{}
+		c.File = name
+	}
+	for i, typ := range f.Snapshots {
+		if typ == "" {
+			return nil, fmt.Errorf("snapshot %d: missing type", i+1)
+		}
+	}
+	return &f, nil
+}
+
+// resolve merges values of the setup, the fixture, the overlay and the case in this order, so that values of
+// the case take precedence and environment-specific values of the overlay apply to fixtures as well.
+func (c *Case) resolve(setup *Setup, fixtures Fixtures, overlay string) error {
+	if c.Fixture == "" && c.Values == nil {
+		// Values are required, setups do not make them optional.
+		return nil
+	}
+	var layers []any
+	if setup != nil {
+		layers = append(layers, setup.Values)
+	}
+	if c.Fixture != "" {
+		values, err := fixtures.values(c.Fixture)
+		if err != nil {
+			return err
+		}
+		layers = append(layers, values)
+	}
+	if setup != nil && overlay != "" {
+		layers = append(layers, setup.Overlays[overlay])
+	}
+	var res any
+	for _, layer := range append(layers, c.Values) {
+		if layer != nil {
+			res = mergeValues(res, layer)
+		}
+	}
+	c.Values = res
+	return nil
+}
+
+// check checks that the case is complete.
+func (c *Case) check() error {
+	var missing []string
+	if c.Name == "" {
+		missing = append(missing, "name")
+	}
+	if c.Type == "" {
+		missing = append(missing, "type")
+	}
+	if c.Values == nil {
+		missing = append(missing, "values")
+	}
+	if c.Valid == nil {
+		missing = append(missing, "valid")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing %s", strings.Join(missing, ", "))
+	}
+	if *c.Valid && len(c.Codes) > 0 {
+		return errors.New("codes are expected only for invalid values")
+	}
+	if *c.Valid && len(c.Diagnostics) > 0 {
+		return errors.New("diagnostics are expected only for invalid values")
+	}
+	for i, d := range c.Diagnostics {
+		if d == nil || (d.Code == "" && d.Path == nil && d.Message == "") {
+			return fmt.Errorf("diagnostic %d: missing code, path or message", i+1)
+		}
+		if _, ok := validator.Explain(d.Code); d.Code != "" && !ok {
+			return fmt.Errorf("diagnostic %d: unknown diagnostic code %s", i+1, d.Code)
+		}
+	}
+	for _, code := range c.Codes {
+		if _, ok := validator.Explain(code); !ok {
+			return fmt.Errorf("unknown diagnostic code %s", code)
+		}
+	}
+	for _, tag := range c.Tags {
+		if tag == "" || strings.HasPrefix(tag, excludePrefix) || strings.Contains(tag, ",") {
+			return fmt.Errorf("invalid tag %q", tag)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,164 @@
+package ctitest
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata/validator"
+)
+
+func writeFiles(t *testing.T, files map[string]string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+	}
+	return dir
+}
+
+func Test_Load(t *testing.T) {
+	valid, invalid := true, false
+	severityPath := "severity"
+	dir := writeFiles(t, map[string]string{
+		"topics.yaml": `
+cases:
+  - name: valid topic
+    type: cti.a.b.topic.v1.0
+    values: {name: users}
+    valid: true
+snapshots:
+  - cti.a.b.topic.v1.0
+`,
+		"alerts/severity.yml": `
+cases:
+  - name: unknown severity
+    type: cti.a.b.alert.v1.0
+    values: {severity: fatal, tags: [disk]}
+    valid: false
+    codes: [CTI1020]
+    diagnostics:
+      - {code: CTI1020, path: severity, message: must be one of}
+    tags: [alerts, slow]
+snapshots: [cti.a.b.alert.v1.0]
+`,
+		"empty.yaml":  ``,
+		"README.md":   `Not a test file.`,
+		"values.json": `{}`,
+	})
+
+	suite, err := Load(dir)
+	require.NoError(t, err)
+	require.Equal(t, []*Case{
+		{
+			Name:   "unknown severity",
+			Type:   "cti.a.b.alert.v1.0",
+			Values: map[string]any{"severity": "fatal", "tags": []any{"disk"}},
+			Valid:  &invalid,
+			Codes:  []validator.Code{"CTI1020"},
+			Diagnostics: []*Diagnostic{
+				{Code: "CTI1020", Path: &severityPath, Message: "must be one of"},
+			},
+			Tags: []string{"alerts", "slow"},
+			File: "alerts/severity.yml",
+		},
+		{
+			Name:   "valid topic",
+			Type:   "cti.a.b.topic.v1.0",
+			Values: map[string]any{"name": "users"},
+			Valid:  &valid,
+			File:   "topics.yaml",
+		},
+	}, suite.Cases)
+	require.Equal(t, "alerts/severity.yml/unknown severity", suite.Cases[0].ID())
+	require.Equal(t, []string{"cti.a.b.alert.v1.0", "cti.a.b.topic.v1.0"}, suite.Snapshots)
+
+	values, err := suite.Cases[0].JSONValues()
+	require.NoError(t, err)
+	require.JSONEq(t, `{"severity":"fatal","tags":["disk"]}`, string(values))
+}
+
+func Test_LoadInvalid(t *testing.T) {
+	testCases := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{
+			name:    "missing fields",
+			content: "cases:\n  - values: {}\n",
+			err:     "cases.yaml: case 1: missing name, type, valid",
+		},
+		{
+			name:    "missing values",
+			content: "cases:\n  - {name: a, type: cti.a.b.topic.v1.0, valid: true}\n",
+			err:     "cases.yaml: case a: missing values",
+		},
+		{
+			name: "duplicate name",
+			content: "cases:\n  - {name: a, type: cti.a.b.topic.v1.0, values: {}, valid: true}\n" +
+				"  - {name: a, type: cti.a.b.topic.v1.0, values: {}, valid: true}\n",
+			err: "cases.yaml: case a: duplicate name",
+		},
+		{
+			name:    "codes of valid values",
+			content: "cases:\n  - {name: a, type: cti.a.b.topic.v1.0, values: {}, valid: true, codes: [CTI1020]}\n",
+			err:     "cases.yaml: case a: codes are expected only for invalid values",
+		},
+		{
+			name:    "unknown code",
+			content: "cases:\n  - {name: a, type: cti.a.b.topic.v1.0, values: {}, valid: false, codes: [CTI0000]}\n",
+			err:     "cases.yaml: case a: unknown diagnostic code CTI0000",
+		},
+		{
+			name:    "diagnostics of valid values",
+			content: "cases:\n  - {name: a, type: cti.a.b.topic.v1.0, values: {}, valid: true, diagnostics: [{code: CTI1020}]}\n",
+			err:     "cases.yaml: case a: diagnostics are expected only for invalid values",
+		},
+		{
+			name:    "empty diagnostic",
+			content: "cases:\n  - {name: a, type: cti.a.b.topic.v1.0, values: {}, valid: false, diagnostics: [{}]}\n",
+			err:     "cases.yaml: case a: diagnostic 1: missing code, path or message",
+		},
+		{
+			name:    "unknown diagnostic code",
+			content: "cases:\n  - {name: a, type: cti.a.b.topic.v1.0, values: {}, valid: false, diagnostics: [{code: CTI0000}]}\n",
+			err:     "cases.yaml: case a: diagnostic 1: unknown diagnostic code CTI0000",
+		},
+		{
+			name:    "excluded tag",
+			content: "cases:\n  - {name: a, type: cti.a.b.topic.v1.0, values: {}, valid: true, tags: ['!slow']}\n",
+			err:     `cases.yaml: case a: invalid tag "!slow"`,
+		},
+		{
+			name:    "missing snapshot type",
+			content: "snapshots: [cti.a.b.topic.v1.0, '']\n",
+			err:     "cases.yaml: snapshot 2: missing type",
+		},
+		{
+			name:    "unknown field",
+			content: "cases:\n  - {name: a, type: cti.a.b.topic.v1.0, values: {}, expect: valid}\n",
+			err:     "cases.yaml: decode file: yaml: unmarshal errors:\n  line 2: field expect not found in type ctitest.Case",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := Load(writeFiles(t, map[string]string{"cases.yaml": tc.content}))
+			require.EqualError(t, err, tc.err)
+		})
+	}
+
+	t.Run("duplicate snapshot", func(t *testing.T) {
+		_, err := Load(writeFiles(t, map[string]string{
+			"a.yaml": "snapshots: [cti.a.b.topic.v1.0]\n",
+			"b.yaml": "snapshots: [cti.a.b.topic.v1.0]\n",
+		}))
+		require.EqualError(t, err, "b.yaml: snapshot cti.a.b.topic.v1.0: duplicate type")
+	})
+}
@@ -0,0 +1,117 @@
+package deploy
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+)
+
+// Media types of archives of bundles.
+const (
+	MediaTypeTgz = "application/vnd.cti.bundle.v1.tar+gzip"
+	MediaTypeZip = "application/vnd.cti.bundle.v1.zip"
+)
+
+// shortDigestLength is the number of hex digits of the digest in default versions.
+const shortDigestLength = 12
+
+// Artifact is a packed bundle of the package deployed to registries.
+type Artifact struct {
+	PackageID string
+	Version   string
+	// Digest identifies the contents of the bundle in sha256:<hex> form. Unlike a digest of the archive, it does
+	// not depend on the archive format and modification times of files, so a repacked bundle is recognized as
+	// already deployed.
+	Digest    string
+	MediaType string
+	// Path is the archive file.
+	Path string
+	// Depends are versions of dependencies of the package by their sources.
+	Depends map[string]string
+	// Annotations are added to manifests of OCI artifacts, e.g. org.opencontainers.image.source.
+	Annotations map[string]string
+}
+
+// OpenArtifact reads the package ID from the index of the archive and computes the digest of its contents.
+// The version defaults to the short digest.
+func OpenArtifact(path string, version string) (*Artifact, error) {
+	mediaType, err := archiveMediaType(path)
+	if err != nil {
+		return nil, err
+	}
+	files, err := ctipackage.ReadArchive(path)
+	if err != nil {
+		return nil, err
+	}
+	data, ok := files[ctipackage.IndexFileName]
+	if !ok {
+		return nil, fmt.Errorf("%s not found in bundle", ctipackage.IndexFileName)
+	}
+	idx, err := ctipackage.DecodeIndex(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("decode index: %w", err)
+	}
+	if idx.PackageID == "" {
+		return nil, errors.New("bundle has no package id")
+	}
+
+	digest := contentDigest(files)
+	if version == "" {
+		version = ShortDigest(digest)
+	}
+	return &Artifact{PackageID: idx.PackageID, Version: version, Digest: digest, MediaType: mediaType, Path: path,
+		Depends: idx.Depends}, nil
+}
+
+// ShortDigest returns the leading hex digits of the sha256:<hex> digest.
+func ShortDigest(digest string) string {
+	hex := strings.TrimPrefix(digest, "sha256:")
+	if len(hex) > shortDigestLength {
+		return hex[:shortDigestLength]
+	}
+	return hex
+}
+
+// contentDigest hashes paths and contents of files sorted by paths.
+func contentDigest(files map[string][]byte) string {
+	names := make([]string, 0, len(files))
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	h := sha256.New()
+	for _, name := range names {
+		fmt.Fprintf(h, "%s\x00%d\x00", name, len(files[name]))
+		h.Write(files[name])
+	}
+	return "sha256:" + hex.EncodeToString(h.Sum(nil))
+}
+
+func archiveMediaType(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+	magic, err := bufio.NewReader(f).Peek(2)
+	if err != nil {
+		return "", fmt.Errorf("read archive header: %w", err)
+	}
+	switch {
+	case bytes.Equal(magic, []byte{0x1f, 0x8b}):
+		return MediaTypeTgz, nil
+	case bytes.Equal(magic, []byte("PK")):
+		return MediaTypeZip, nil
+	default:
+		return "", errors.New("unsupported archive format")
+	}
+}
@@ -0,0 +1,60 @@
+package deploy
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata/archiver"
+	"github.com/acronis/go-cti/metadata/archiver/tgzwriter"
+	"github.com/acronis/go-cti/metadata/archiver/zippacker"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+)
+
+// writeBundle packs a bundle of the x.y package with the entities, files of the bundle are modified at the time.
+func writeBundle(t *testing.T, a archiver.Archiver, entities string, modTime time.Time) string {
+	t.Helper()
+
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, ctipackage.IndexFileName), []byte(`{"package_id": "x.y"}`), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(src, ctipackage.MetadataCacheFile), []byte(entities), 0600))
+	for _, name := range []string{ctipackage.IndexFileName, ctipackage.MetadataCacheFile} {
+		require.NoError(t, os.Chtimes(filepath.Join(src, name), modTime, modTime))
+	}
+
+	dst := filepath.Join(t.TempDir(), "bundle.cti")
+	w, err := a.Init(dst)
+	require.NoError(t, err)
+	require.NoError(t, a.WriteFile(src, ctipackage.IndexFileName))
+	require.NoError(t, a.WriteFile(src, ctipackage.MetadataCacheFile))
+	require.NoError(t, w.Close())
+	return dst
+}
+
+const testEntities = `[{"cti": "cti.x.y.topic.v1.0", "final": true, "schema": {}}]`
+
+func Test_OpenArtifact(t *testing.T) {
+	now := time.Now()
+	a, err := OpenArtifact(writeBundle(t, tgzwriter.New(), testEntities, now), "1.2.0")
+	require.NoError(t, err)
+	require.Equal(t, "x.y", a.PackageID)
+	require.Equal(t, "1.2.0", a.Version)
+	require.Equal(t, MediaTypeTgz, a.MediaType)
+	require.Regexp(t, `^sha256:[0-9a-f]{64}$`, a.Digest)
+
+	repacked, err := OpenArtifact(writeBundle(t, zippacker.New(), testEntities, now.Add(time.Hour)), "")
+	require.NoError(t, err)
+	require.Equal(t, a.Digest, repacked.Digest, "digest must not depend on the format and modification times")
+	require.Equal(t, MediaTypeZip, repacked.MediaType)
+	require.Equal(t, a.Digest[len("sha256:"):len("sha256:")+12], repacked.Version)
+
+	changed, err := OpenArtifact(writeBundle(t, tgzwriter.New(), `[]`, now), "")
+	require.NoError(t, err)
+	require.NotEqual(t, a.Digest, changed.Digest)
+
+	_, err = OpenArtifact(filepath.Join(t.TempDir(), "missing.cti"), "")
+	require.ErrorContains(t, err, "open archive")
+}
@@ -0,0 +1,201 @@
+package deploy
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+// maxRefDepth limits chains of references, so references to themselves do not loop forever.
+const maxRefDepth = 32
+
+// lowerBounds and upperBounds are keywords that break values valid before if increased or decreased respectively.
+var (
+	lowerBounds = []string{"minimum", "exclusiveMinimum", "minLength", "minItems", "minProperties"}
+	upperBounds = []string{"maximum", "exclusiveMaximum", "maxLength", "maxItems", "maxProperties"}
+)
+
+// breakingChanges returns reasons why the new version of the entity may break clients of the deployed version.
+// A change of a type is breaking if values valid for the deployed schema may be invalid for the new one or
+// properties that clients rely on are removed. Changes of instances are not breaking.
+func breakingChanges(old, entity *metadata.Entity) ([]string, error) {
+	if old.Schema == nil {
+		return nil, nil
+	}
+	var reasons []string
+	if !old.Final && entity.Final {
+		reasons = append(reasons, "type is made final")
+	}
+	for _, s := range []struct {
+		name, prefix string
+		old, new     json.RawMessage
+	}{
+		{"schema", "", old.Schema, entity.Schema},
+		{"traits schema", "traits", old.TraitsSchema, entity.TraitsSchema},
+	} {
+		if s.old == nil {
+			continue
+		}
+		if s.new == nil {
+			reasons = append(reasons, s.name+" is removed")
+			continue
+		}
+		c := &compatChecker{seen: map[string]bool{}}
+		if err := json.Unmarshal(s.old, &c.oldRoot); err != nil {
+			return nil, fmt.Errorf("decode schema of %s: %w", old.Cti, err)
+		}
+		if err := json.Unmarshal(s.new, &c.newRoot); err != nil {
+			return nil, fmt.Errorf("decode schema of %s: %w", entity.Cti, err)
+		}
+		c.check(s.prefix, c.oldRoot, c.newRoot)
+		reasons = append(reasons, c.reasons...)
+	}
+	return reasons, nil
+}
+
+// compatChecker compares the deployed and the new schema of an entity.
+type compatChecker struct {
+	oldRoot, newRoot map[string]any
+	reasons          []string
+	// seen holds pairs of compared definitions, so recursive schemas are compared once.
+	seen map[string]bool
+}
+
+func (c *compatChecker) report(path, format string, args ...any) {
+	if path == "" {
+		path = "."
+	}
+	c.reasons = append(c.reasons, path+": "+fmt.Sprintf(format, args...))
+}
+
+func (c *compatChecker) check(path string, old, s map[string]any) {
+	old, oldRef := resolveRef(c.oldRoot, old)
+	s, ref := resolveRef(c.newRoot, s)
+	if oldRef != "" && ref != "" {
+		key := oldRef + "\x00" + ref
+		if c.seen[key] {
+			return
+		}
+		c.seen[key] = true
+	}
+	if old == nil || s == nil {
+		return
+	}
+
+	if oldTypes, types := schemaTypes(old), schemaTypes(s); len(types) > 0 {
+		for _, t := range oldTypes {
+			if !slices.Contains(types, t) && !(t == "integer" && slices.Contains(types, "number")) {
+				c.report(path, "type is changed from %s to %s", strings.Join(oldTypes, ", "), strings.Join(types, ", "))
+				break
+			}
+		}
+		if len(oldTypes) == 0 {
+			c.report(path, "type %s is added", strings.Join(types, ", "))
+		}
+	}
+	if enum, ok := s["enum"].([]any); ok {
+		oldEnum, ok := old["enum"].([]any)
+		if !ok {
+			c.report(path, "enum is added")
+		}
+		for _, v := range oldEnum {
+			if !slices.ContainsFunc(enum, func(e any) bool { return reflect.DeepEqual(e, v) }) {
+				c.report(path, "enum value %v is removed", v)
+			}
+		}
+	}
+	if v, ok := s["const"]; ok && !reflect.DeepEqual(v, old["const"]) {
+		c.report(path, "const is changed to %v", v)
+	}
+	for _, keyword := range lowerBounds {
+		if v, ok := s[keyword].(float64); ok {
+			if prev, ok := old[keyword].(float64); !ok || v > prev {
+				c.report(path, "%s is increased to %v", keyword, v)
+			}
+		}
+	}
+	for _, keyword := range upperBounds {
+		if v, ok := s[keyword].(float64); ok {
+			if prev, ok := old[keyword].(float64); !ok || v < prev {
+				c.report(path, "%s is decreased to %v", keyword, v)
+			}
+		}
+	}
+	if pattern, ok := s["pattern"].(string); ok && pattern != old["pattern"] {
+		c.report(path, "pattern is changed to %s", pattern)
+	}
+	if allowed, ok := s["additionalProperties"].(bool); ok && !allowed && old["additionalProperties"] != false {
+		c.report(path, "additional properties are not allowed")
+	}
+
+	oldRequired, _ := old["required"].([]any)
+	required, _ := s["required"].([]any)
+	for _, name := range required {
+		if !slices.Contains(oldRequired, name) {
+			c.report(path, "property %v is required", name)
+		}
+	}
+	oldProps, _ := old["properties"].(map[string]any)
+	props, _ := s["properties"].(map[string]any)
+	for _, name := range sortedKeys(oldProps) {
+		prop, ok := props[name]
+		if !ok {
+			c.report(path, "property %s is removed", name)
+			continue
+		}
+		oldProp, _ := oldProps[name].(map[string]any)
+		newProp, _ := prop.(map[string]any)
+		c.check(path+"."+name, oldProp, newProp)
+	}
+	if oldItems, ok := old["items"].(map[string]any); ok {
+		if items, ok := s["items"].(map[string]any); ok {
+			c.check(path+".#", oldItems, items)
+		}
+	}
+}
+
+// resolveRef follows local references of the schema to definitions of the root schema and returns the resolved
+// schema with the name of the last definition, if any.
+func resolveRef(root, s map[string]any) (map[string]any, string) {
+	var name string
+	for range maxRefDepth {
+		ref, ok := s["$ref"].(string)
+		if !ok {
+			return s, name
+		}
+		definitions, _ := root["definitions"].(map[string]any)
+		name = strings.TrimPrefix(ref, "#/definitions/")
+		s, _ = definitions[name].(map[string]any)
+	}
+	return nil, name
+}
+
+func schemaTypes(s map[string]any) []string {
+	switch t := s["type"].(type) {
+	case string:
+		return []string{t}
+	case []any:
+		var types []string
+		for _, v := range t {
+			if name, ok := v.(string); ok {
+				types = append(types, name)
+			}
+		}
+		return types
+	default:
+		return nil
+	}
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
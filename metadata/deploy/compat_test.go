@@ -0,0 +1,162 @@
+package deploy
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+const alertSchema = `{
+	"$ref": "#/definitions/Alert",
+	"definitions": {
+		"Alert": {
+			"type": "object",
+			"properties": {
+				"id": {"type": "string"},
+				"severity": {"type": "string", "enum": ["low", "high"]},
+				"threshold": {"type": "integer", "minimum": 0, "maximum": 200},
+				"tags": {"type": "array", "items": {"type": "string"}},
+				"parent": {"$ref": "#/definitions/Alert"}
+			},
+			"required": ["id"]
+		}
+	}
+}`
+
+func Test_breakingChanges(t *testing.T) {
+	testCases := []struct {
+		name    string
+		oldFunc func(e *metadata.Entity)
+		newFunc func(e *metadata.Entity)
+		reasons []string
+	}{
+		{
+			name: "unchanged",
+		},
+		{
+			name: "relaxed",
+			newFunc: func(e *metadata.Entity) {
+				e.Schema = patchSchema(t, e.Schema,
+					`{"definitions": {"Alert": {"properties": {
+						"severity": {"enum": ["low", "medium", "high"]},
+						"threshold": {"type": "number", "maximum": 1000},
+						"note": {"type": "string"}
+					}}}}`)
+			},
+		},
+		{
+			name: "narrowed",
+			newFunc: func(e *metadata.Entity) {
+				e.Schema = patchSchema(t, e.Schema,
+					`{"definitions": {"Alert": {
+						"additionalProperties": false,
+						"required": ["id", "severity"],
+						"properties": {
+							"id": {"type": "string", "pattern": "^a"},
+							"severity": {"enum": ["high"]},
+							"threshold": {"minimum": 10, "maximum": 100},
+							"tags": {"items": {"type": "integer"}}
+						}
+					}}}`)
+			},
+			reasons: []string{
+				".: additional properties are not allowed",
+				".: property severity is required",
+				".id: pattern is changed to ^a",
+				".severity: enum value low is removed",
+				".tags.#: type is changed from string to integer",
+				".threshold: minimum is increased to 10",
+				".threshold: maximum is decreased to 100",
+			},
+		},
+		{
+			name: "removed property",
+			newFunc: func(e *metadata.Entity) {
+				e.Schema = patchSchema(t, e.Schema, `{"definitions": {"Alert": {"properties": {"tags": null}}}}`)
+			},
+			reasons: []string{".: property tags is removed"},
+		},
+		{
+			name: "final",
+			newFunc: func(e *metadata.Entity) {
+				e.Final = true
+				e.TraitsSchema = json.RawMessage(`{"type": "object"}`)
+			},
+			reasons: []string{"type is made final"},
+		},
+		{
+			name: "traits",
+			oldFunc: func(e *metadata.Entity) {
+				e.TraitsSchema = json.RawMessage(`{"type": "object", "properties": {"topic": {"type": "string"}}}`)
+			},
+			newFunc: func(e *metadata.Entity) {
+				e.TraitsSchema = json.RawMessage(`{"type": "object", "properties": {"topic": {"type": "integer"}}}`)
+			},
+			reasons: []string{"traits.topic: type is changed from string to integer"},
+		},
+		{
+			name: "removed traits",
+			oldFunc: func(e *metadata.Entity) {
+				e.TraitsSchema = json.RawMessage(`{"type": "object"}`)
+			},
+			reasons: []string{"traits schema is removed"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			old := &metadata.Entity{Cti: "cti.x.y.alert.v1.0", Schema: json.RawMessage(alertSchema)}
+			entity := &metadata.Entity{Cti: "cti.x.y.alert.v1.0", Schema: json.RawMessage(alertSchema)}
+			if tc.oldFunc != nil {
+				tc.oldFunc(old)
+			}
+			if tc.newFunc != nil {
+				tc.newFunc(entity)
+			}
+			reasons, err := breakingChanges(old, entity)
+			require.NoError(t, err)
+			require.Equal(t, tc.reasons, reasons)
+		})
+	}
+}
+
+func Test_breakingChangesOfInstances(t *testing.T) {
+	reasons, err := breakingChanges(
+		&metadata.Entity{Cti: "cti.x.y.alert.v1.0~x.y.a.v1.0", Values: json.RawMessage(`{"threshold": 1}`)},
+		&metadata.Entity{Cti: "cti.x.y.alert.v1.0~x.y.a.v1.0", Values: json.RawMessage(`{"threshold": 2}`)},
+	)
+	require.NoError(t, err)
+	require.Empty(t, reasons)
+}
+
+// patchSchema applies the JSON merge patch to the schema.
+func patchSchema(t *testing.T, schema json.RawMessage, patch string) json.RawMessage {
+	t.Helper()
+
+	var doc, p map[string]any
+	require.NoError(t, json.Unmarshal(schema, &doc))
+	require.NoError(t, json.Unmarshal([]byte(patch), &p))
+	data, err := json.Marshal(mergePatch(doc, p))
+	require.NoError(t, err)
+	return data
+}
+
+func mergePatch(doc, patch map[string]any) map[string]any {
+	for key, v := range patch {
+		switch v := v.(type) {
+		case nil:
+			delete(doc, key)
+		case map[string]any:
+			target, _ := doc[key].(map[string]any)
+			if target == nil {
+				target = map[string]any{}
+			}
+			doc[key] = mergePatch(target, v)
+		default:
+			doc[key] = v
+		}
+	}
+	return doc
+}
@@ -0,0 +1,338 @@
+// Package deploy uploads packed bundles of packages to registries and activates them, see Deploy.
+//
+// Two kinds of targets are supported. HTTP targets implement the deployment API:
+//
+//	GET  /deployments/{package}          returns the active Deployment of the package, 404 if it was never deployed
+//	GET  /deployments/{package}/history  returns deployments of the package, the latest first, 404 if none
+//	GET  /deployments/{package}/staged   returns the staged Deployment of the package, 404 if there is none
+//	HEAD /artifacts/{digest}             responds with 200 if the artifact is uploaded, 404 otherwise
+//	GET  /artifacts/{digest}             downloads the archive of the artifact
+//	PUT  /artifacts/{digest}             uploads the archive of the artifact
+//	PUT  /deployments/{package}          activates the uploaded artifact and records it in the history,
+//	                                     the body and the response are a Deployment. If the Deployment has
+//	                                     a stage, the artifact is served to the stage only and replaces the
+//	                                     staged deployment, otherwise it is served to all clients and the
+//	                                     staged deployment ends
+//
+// OCI targets push artifacts to repositories of OCI registries, see OCITarget.
+package deploy
+
+import (
+	"context"
+	"crypto"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Deployment is an artifact activated in a registry.
+type Deployment struct {
+	Package string `json:"package"`
+	Version string `json:"version"`
+	// Digest is the digest of contents of the artifact, see Artifact.
+	Digest string    `json:"digest"`
+	Time   time.Time `json:"time,omitempty"`
+	// Rollback is set if a previous artifact was activated again, see Rollback.
+	Rollback bool `json:"rollback,omitempty"`
+	// Stage is set if the artifact is served to a subset of clients only, see Promote.
+	Stage *Stage `json:"stage,omitempty"`
+	// Justification is set if breaking changes were deployed, see WithAllowBreaking.
+	Justification string `json:"justification,omitempty"`
+	// User is who deployed the artifact, see WithUser.
+	User string `json:"user,omitempty"`
+}
+
+// Target is a registry that artifacts are deployed to.
+type Target interface {
+	// Current returns the active deployment of the package, nil if the package was never deployed.
+	Current(ctx context.Context, packageID string) (*Deployment, error)
+	// Staged returns the staged deployment of the package, nil if there is none.
+	Staged(ctx context.Context, packageID string) (*Deployment, error)
+	// History returns deployments of the package, the latest first, including staged ones.
+	History(ctx context.Context, packageID string) ([]*Deployment, error)
+	// Deploy uploads the artifact, unless it is already uploaded, and activates it as the deployment.
+	Deploy(ctx context.Context, a *Artifact, d *Deployment) (*Deployment, error)
+	// Activate activates the uploaded artifact of the deployment, for the stage of the deployment if it is set.
+	Activate(ctx context.Context, d *Deployment) (*Deployment, error)
+	// Download writes the archive of the deployed artifact.
+	Download(ctx context.Context, d *Deployment, w io.Writer) error
+}
+
+// Status is what is deployed to the target.
+type Status struct {
+	// Active is the active deployment, nil if the package was never deployed.
+	Active *Deployment
+	// Staged is the staged deployment, nil if there is none.
+	Staged *Deployment
+}
+
+// GetStatus returns deployments of the package that are served to clients. Deployments are taken from the history,
+// so they have the time of activation rather than the time of the upload.
+func GetStatus(ctx context.Context, t Target, packageID string) (*Status, error) {
+	history, err := t.History(ctx, packageID)
+	if err != nil {
+		return nil, fmt.Errorf("get deployment history: %w", err)
+	}
+	staged, err := t.Staged(ctx, packageID)
+	if err != nil {
+		return nil, fmt.Errorf("get staged deployment: %w", err)
+	}
+	status := &Status{Staged: staged}
+	if active := unstaged(history); len(active) > 0 {
+		status.Active = active[0]
+	}
+	for _, d := range history {
+		if staged != nil && d.Stage != nil && d.Digest == staged.Digest && *d.Stage == *staged.Stage {
+			status.Staged = d
+			break
+		}
+	}
+	return status, nil
+}
+
+// Result is a result of the deployment.
+type Result struct {
+	Deployment *Deployment
+	// Unchanged is set if the artifact with the same contents was already active, so nothing was deployed.
+	Unchanged bool
+	// Plan is set if compatibility of the deployment was checked, see WithRequireCompat.
+	Plan *Plan
+}
+
+// BreakingError is returned if the deployment breaks clients of the active deployment and breaking changes
+// are not allowed.
+type BreakingError struct {
+	Plan *Plan
+}
+
+func (e *BreakingError) Error() string {
+	return fmt.Sprintf("deployment breaks clients of %s %s", e.Plan.Current.Package, e.Plan.Current.Version)
+}
+
+type deployOptions struct {
+	stage         *Stage
+	requireCompat bool
+	justification string
+}
+
+type DeployOption func(*deployOptions)
+
+// WithStage deploys the artifact to the stage only, so it is soaked before it is promoted to all clients.
+func WithStage(stage *Stage) DeployOption {
+	return func(o *deployOptions) {
+		o.stage = stage
+	}
+}
+
+// WithRequireCompat checks compatibility of the artifact with the active deployment and refuses to deploy breaking
+// changes with BreakingError, see DryRun.
+func WithRequireCompat() DeployOption {
+	return func(o *deployOptions) {
+		o.requireCompat = true
+	}
+}
+
+// WithAllowBreaking checks compatibility of the artifact with the active deployment like WithRequireCompat, but
+// deploys breaking changes with the justification, which is recorded in the history.
+func WithAllowBreaking(justification string) DeployOption {
+	return func(o *deployOptions) {
+		o.requireCompat = true
+		o.justification = justification
+	}
+}
+
+// Deploy deploys the artifact to the target. Deployments are idempotent: if contents of the active artifact
+// are the same, the target is not changed, even if the version differs. The same holds for contents of
+// the staged artifact deployed to the same stage.
+func Deploy(ctx context.Context, t Target, a *Artifact, opts ...DeployOption) (*Result, error) {
+	o := deployOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	if o.stage != nil {
+		if err := o.stage.validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	current, err := t.Current(ctx, a.PackageID)
+	if err != nil {
+		return nil, fmt.Errorf("get current deployment: %w", err)
+	}
+	if current != nil && current.Digest == a.Digest {
+		return &Result{Deployment: current, Unchanged: true}, nil
+	}
+	if o.stage != nil {
+		staged, err := t.Staged(ctx, a.PackageID)
+		if err != nil {
+			return nil, fmt.Errorf("get staged deployment: %w", err)
+		}
+		if staged != nil && staged.Digest == a.Digest && *staged.Stage == *o.stage {
+			return &Result{Deployment: staged, Unchanged: true}, nil
+		}
+	}
+
+	d := &Deployment{Package: a.PackageID, Version: a.Version, Digest: a.Digest, Stage: o.stage}
+	var plan *Plan
+	if o.requireCompat {
+		if plan, err = DryRun(ctx, t, a); err != nil {
+			return nil, fmt.Errorf("check compatibility: %w", err)
+		}
+		if plan.Verdict == VerdictBreaking {
+			if o.justification == "" {
+				return nil, &BreakingError{Plan: plan}
+			}
+			d.Justification = o.justification
+		}
+	}
+	if d, err = t.Deploy(ctx, a, d); err != nil {
+		return nil, fmt.Errorf("deploy %s %s: %w", a.PackageID, a.Version, err)
+	}
+	return &Result{Deployment: d, Plan: plan}, nil
+}
+
+// Rollback activates the artifact of the latest deployment of the version again. If the version is empty,
+// the previous artifact is activated: rolled back artifacts are skipped, so repeated rollbacks step back through
+// the history. Rolling back to the active contents does not change the target. Staged deployments in the
+// history are skipped.
+func Rollback(ctx context.Context, t Target, packageID, version string) (*Result, error) {
+	history, err := t.History(ctx, packageID)
+	if err != nil {
+		return nil, fmt.Errorf("get deployment history: %w", err)
+	}
+	history = unstaged(history)
+	if len(history) == 0 {
+		return nil, fmt.Errorf("package %s was never deployed", packageID)
+	}
+	current := history[0]
+	if version != "" && current.Version == version {
+		return &Result{Deployment: current, Unchanged: true}, nil
+	}
+
+	var previous *Deployment
+	if version == "" {
+		stack := activeStack(history)
+		for i := len(stack) - 2; i >= 0 && previous == nil; i-- {
+			if stack[i].Digest != current.Digest {
+				previous = stack[i]
+			}
+		}
+	} else {
+		for _, d := range history[1:] {
+			if d.Version == version {
+				previous = d
+				break
+			}
+		}
+	}
+	switch {
+	case previous == nil && version != "":
+		return nil, fmt.Errorf("version %s of %s is not found in the deployment history", version, packageID)
+	case previous == nil:
+		return nil, fmt.Errorf("no previous deployment of %s to roll back to", packageID)
+	case previous.Digest == current.Digest:
+		return &Result{Deployment: current, Unchanged: true}, nil
+	}
+
+	d, err := t.Activate(ctx, &Deployment{Package: previous.Package, Version: previous.Version, Digest: previous.Digest,
+		Rollback: true})
+	if err != nil {
+		return nil, fmt.Errorf("activate %s %s: %w", previous.Package, previous.Version, err)
+	}
+	return &Result{Deployment: d}, nil
+}
+
+// activeStack replays the history from the oldest deployment: deployments push their artifacts and rollbacks pop
+// artifacts deployed after the one they activate. The last element is the active deployment.
+func activeStack(history []*Deployment) []*Deployment {
+	var stack []*Deployment
+	for i := len(history) - 1; i >= 0; i-- {
+		d := history[i]
+		if d.Rollback {
+			if j := lastIndex(stack, d.Digest); j >= 0 {
+				stack = stack[:j+1]
+				continue
+			}
+		}
+		stack = append(stack, d)
+	}
+	return stack
+}
+
+// unstaged returns deployments that were served to all clients.
+func unstaged(history []*Deployment) []*Deployment {
+	var res []*Deployment
+	for _, d := range history {
+		if d.Stage == nil {
+			res = append(res, d)
+		}
+	}
+	return res
+}
+
+func lastIndex(stack []*Deployment, digest string) int {
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i].Digest == digest {
+			return i
+		}
+	}
+	return -1
+}
+
+type targetOptions struct {
+	signer crypto.Signer
+	user   string
+}
+
+type TargetOption func(*targetOptions)
+
+// WithSigner signs bundles pushed to OCI registries with the key, see ParseSigningKey.
+func WithSigner(signer crypto.Signer) TargetOption {
+	return func(o *targetOptions) {
+		o.signer = signer
+	}
+}
+
+// WithUser records the user in deployments to the target.
+func WithUser(user string) TargetOption {
+	return func(o *targetOptions) {
+		o.user = user
+	}
+}
+
+// NewTarget creates the target of the URL: http:// or https:// for the deployment API, oci:// for an OCI
+// registry and oci+http:// for an OCI registry without TLS. Requests are sent with the client, which is
+// expected to authenticate them.
+func NewTarget(targetURL string, client *http.Client, opts ...TargetOption) (Target, error) {
+	o := targetOptions{}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	u, err := url.Parse(targetURL)
+	if err != nil {
+		return nil, fmt.Errorf("parse target url: %w", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("target url %s has no host", targetURL)
+	}
+	switch u.Scheme {
+	case "http", "https":
+		if o.signer != nil {
+			return nil, fmt.Errorf("target url %s: bundles are signed for OCI registries only", targetURL)
+		}
+		t := NewHTTPTarget(targetURL, client)
+		t.user = o.user
+		return t, nil
+	case "oci", "oci+http":
+		t, err := NewOCITarget(u, client)
+		if err != nil {
+			return nil, err
+		}
+		t.signer, t.user = o.signer, o.user
+		return t, nil
+	default:
+		return nil, fmt.Errorf("target url %s: unsupported scheme %s, expected http, https, oci or oci+http", targetURL, u.Scheme)
+	}
+}
@@ -0,0 +1,175 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata/archiver/tgzwriter"
+)
+
+func Test_NewTarget(t *testing.T) {
+	testCases := []struct {
+		url string
+		err string
+	}{
+		{url: "ftp://registry.example.com", err: "target url ftp://registry.example.com: unsupported scheme ftp, expected http, https, oci or oci+http"},
+		{url: "registry.example.com", err: "target url registry.example.com has no host"},
+		{url: "oci://registry.example.com", err: "target url oci://registry.example.com has no repository"},
+		{url: "oci://registry.example.com/cti/x.y:-prod", err: "target url oci://registry.example.com/cti/x.y:-prod: invalid tag -prod"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.url, func(t *testing.T) {
+			_, err := NewTarget(tc.url, http.DefaultClient)
+			require.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+// bearerTransport authenticates requests with the bearer token.
+type bearerTransport string
+
+func (tr bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+string(tr))
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func bearerClient(token string) *http.Client {
+	return &http.Client{Transport: bearerTransport(token)}
+}
+
+// testTargets returns a target of each kind with the options backed by an empty fake registry.
+func testTargets(t *testing.T, opts ...TargetOption) map[string]Target {
+	t.Helper()
+
+	_, srv := newFakeAPI(t, "secret")
+	httpTarget, err := NewTarget(srv.URL, bearerClient("secret"), opts...)
+	require.NoError(t, err)
+	_, ociSrv := newFakeOCI(t)
+	u, err := url.Parse(ociSrv.URL)
+	require.NoError(t, err)
+	ociTarget, err := NewTarget("oci+http://"+u.Host+"/cti/x.y", http.DefaultClient, opts...)
+	require.NoError(t, err)
+	return map[string]Target{"http": httpTarget, "oci": ociTarget}
+}
+
+func Test_Rollback(t *testing.T) {
+	ctx := context.Background()
+	for name, target := range testTargets(t) {
+		t.Run(name, func(t *testing.T) {
+			_, err := Rollback(ctx, target, "x.y", "")
+			require.EqualError(t, err, "package x.y was never deployed")
+
+			now := time.Now()
+			var artifacts []*Artifact
+			for i, entities := range []string{testEntities, `[]`, testEntities, `[{"cti": "cti.x.y.topic.v1.1", "schema": {}}]`} {
+				a, err := OpenArtifact(writeBundle(t, tgzwriter.New(), entities, now), fmt.Sprintf("1.%d.0", i))
+				require.NoError(t, err)
+				_, err = Deploy(ctx, target, a)
+				require.NoError(t, err)
+				artifacts = append(artifacts, a)
+			}
+			history, err := target.History(ctx, "x.y")
+			require.NoError(t, err)
+			require.Len(t, history, 4)
+			for i, d := range history {
+				require.Equal(t, artifacts[3-i].Version, d.Version)
+				require.Equal(t, artifacts[3-i].Digest, d.Digest)
+				require.False(t, d.Time.IsZero())
+			}
+
+			res, err := Rollback(ctx, target, "x.y", "")
+			require.NoError(t, err)
+			require.False(t, res.Unchanged)
+			require.Equal(t, "1.2.0", res.Deployment.Version)
+
+			// 1.2.0 has the same contents as 1.0.0, so the previous different contents are of 1.1.0.
+			res, err = Rollback(ctx, target, "x.y", "")
+			require.NoError(t, err)
+			require.Equal(t, "1.1.0", res.Deployment.Version)
+			current, err := target.Current(ctx, "x.y")
+			require.NoError(t, err)
+			require.Equal(t, artifacts[1].Digest, current.Digest)
+
+			res, err = Rollback(ctx, target, "x.y", "")
+			require.NoError(t, err)
+			require.Equal(t, "1.0.0", res.Deployment.Version)
+			_, err = Rollback(ctx, target, "x.y", "")
+			require.EqualError(t, err, "no previous deployment of x.y to roll back to")
+
+			res, err = Rollback(ctx, target, "x.y", "1.3.0")
+			require.NoError(t, err)
+			require.Equal(t, "1.3.0", res.Deployment.Version)
+
+			res, err = Rollback(ctx, target, "x.y", "1.3.0")
+			require.NoError(t, err)
+			require.True(t, res.Unchanged)
+
+			history, err = target.History(ctx, "x.y")
+			require.NoError(t, err)
+			require.Len(t, history, 8, "rollbacks are recorded")
+			require.Equal(t, "1.3.0", history[0].Version)
+			require.True(t, history[0].Rollback)
+			require.False(t, history[4].Rollback)
+
+			_, err = Rollback(ctx, target, "x.y", "2.0.0")
+			require.EqualError(t, err, "version 2.0.0 of x.y is not found in the deployment history")
+		})
+	}
+}
+
+func Test_RollbackWithoutPrevious(t *testing.T) {
+	ctx := context.Background()
+	for name, target := range testTargets(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			for _, modTime := range []time.Time{now, now.Add(time.Hour)} {
+				a, err := OpenArtifact(writeBundle(t, tgzwriter.New(), testEntities, modTime), "")
+				require.NoError(t, err)
+				_, err = Deploy(ctx, target, a)
+				require.NoError(t, err)
+			}
+			_, err := Rollback(ctx, target, "x.y", "")
+			require.EqualError(t, err, "no previous deployment of x.y to roll back to")
+		})
+	}
+}
+
+func Test_GetStatus(t *testing.T) {
+	ctx := context.Background()
+	for name, target := range testTargets(t, WithUser("alice@ci")) {
+		t.Run(name, func(t *testing.T) {
+			status, err := GetStatus(ctx, target, "x.y")
+			require.NoError(t, err)
+			require.Equal(t, &Status{}, status)
+
+			now := time.Now()
+			var artifacts []*Artifact
+			for i, entities := range []string{testEntities, `[]`} {
+				a, err := OpenArtifact(writeBundle(t, tgzwriter.New(), entities, now), fmt.Sprintf("1.%d.0", i))
+				require.NoError(t, err)
+				artifacts = append(artifacts, a)
+			}
+			_, err = Deploy(ctx, target, artifacts[0])
+			require.NoError(t, err)
+			_, err = Deploy(ctx, target, artifacts[1], WithStage(&Stage{Label: "canary"}))
+			require.NoError(t, err)
+
+			status, err = GetStatus(ctx, target, "x.y")
+			require.NoError(t, err)
+			require.Equal(t, "1.0.0", status.Active.Version)
+			require.Equal(t, "alice@ci", status.Active.User)
+			require.False(t, status.Active.Time.IsZero())
+			require.Equal(t, "1.1.0", status.Staged.Version)
+			require.Equal(t, "alice@ci", status.Staged.User)
+			require.Equal(t, &Stage{Label: "canary"}, status.Staged.Stage)
+		})
+	}
+}
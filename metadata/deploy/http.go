@@ -0,0 +1,188 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// maxResponse limits the size of responses of registries other than downloaded artifacts.
+const maxResponse = 4 << 20
+
+// HTTPTarget deploys artifacts with the deployment API, see the package documentation.
+type HTTPTarget struct {
+	baseURL string
+	client  *http.Client
+	user    string
+}
+
+// NewHTTPTarget creates the target of the deployment API at the base URL.
+func NewHTTPTarget(baseURL string, client *http.Client) *HTTPTarget {
+	return &HTTPTarget{baseURL: strings.TrimSuffix(baseURL, "/"), client: client}
+}
+
+func (t *HTTPTarget) Current(ctx context.Context, packageID string) (*Deployment, error) {
+	return t.get(ctx, "/deployments/"+url.PathEscape(packageID))
+}
+
+func (t *HTTPTarget) Staged(ctx context.Context, packageID string) (*Deployment, error) {
+	return t.get(ctx, "/deployments/"+url.PathEscape(packageID)+"/staged")
+}
+
+// get gets the deployment of the path, nil if it does not exist.
+func (t *HTTPTarget) get(ctx context.Context, path string) (*Deployment, error) {
+	res, err := t.do(ctx, http.MethodGet, path, nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err := checkResponse(res); err != nil {
+		return nil, err
+	}
+	return decodeDeployment(res)
+}
+
+func (t *HTTPTarget) History(ctx context.Context, packageID string) ([]*Deployment, error) {
+	res, err := t.do(ctx, http.MethodGet, "/deployments/"+url.PathEscape(packageID)+"/history", nil, "")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil
+	}
+	if err := checkResponse(res); err != nil {
+		return nil, err
+	}
+	var history []*Deployment
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxResponse)).Decode(&history); err != nil {
+		return nil, fmt.Errorf("decode history: %w", err)
+	}
+	return history, nil
+}
+
+func (t *HTTPTarget) Deploy(ctx context.Context, a *Artifact, d *Deployment) (*Deployment, error) {
+	if err := t.upload(ctx, a); err != nil {
+		return nil, err
+	}
+	return t.Activate(ctx, d)
+}
+
+func (t *HTTPTarget) Activate(ctx context.Context, d *Deployment) (*Deployment, error) {
+	body, err := json.Marshal(Deployment{Package: d.Package, Version: d.Version, Digest: d.Digest, Rollback: d.Rollback,
+		Stage: d.Stage, Justification: d.Justification, User: t.user})
+	if err != nil {
+		return nil, fmt.Errorf("encode deployment: %w", err)
+	}
+	res, err := t.do(ctx, http.MethodPut, "/deployments/"+url.PathEscape(d.Package), bytes.NewReader(body), "application/json")
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if err := checkResponse(res); err != nil {
+		return nil, fmt.Errorf("activate artifact: %w", err)
+	}
+	return decodeDeployment(res)
+}
+
+func (t *HTTPTarget) Download(ctx context.Context, d *Deployment, w io.Writer) error {
+	res, err := t.do(ctx, http.MethodGet, "/artifacts/"+url.PathEscape(d.Digest), nil, "")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := checkResponse(res); err != nil {
+		return fmt.Errorf("download artifact: %w", err)
+	}
+	if _, err := io.Copy(w, res.Body); err != nil {
+		return fmt.Errorf("download artifact: %w", err)
+	}
+	return nil
+}
+
+// upload uploads the archive of the artifact, unless the registry already has it.
+func (t *HTTPTarget) upload(ctx context.Context, a *Artifact) error {
+	path := "/artifacts/" + url.PathEscape(a.Digest)
+	res, err := t.do(ctx, http.MethodHead, path, nil, "")
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	if res.StatusCode == http.StatusOK {
+		return nil
+	}
+	if res.StatusCode != http.StatusNotFound {
+		return fmt.Errorf("check artifact: %w", checkResponse(res))
+	}
+
+	f, err := os.Open(a.Path)
+	if err != nil {
+		return fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+	res, err = t.do(ctx, http.MethodPut, path, f, a.MediaType)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := checkResponse(res); err != nil {
+		return fmt.Errorf("upload artifact: %w", err)
+	}
+	return nil
+}
+
+func (t *HTTPTarget) do(ctx context.Context, method, path string, body io.Reader, contentType string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, t.baseURL+path, body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if f, ok := body.(*os.File); ok {
+		// Registries may require the length of uploads.
+		info, err := f.Stat()
+		if err != nil {
+			return nil, fmt.Errorf("stat archive: %w", err)
+		}
+		req.ContentLength = info.Size()
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("User-Agent", "cti-deploy")
+	res, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request registry: %w", err)
+	}
+	return res, nil
+}
+
+// checkResponse returns an error with the message of the registry if the response is not successful.
+func checkResponse(res *http.Response) error {
+	if res.StatusCode/100 == 2 {
+		return nil
+	}
+	data, _ := io.ReadAll(io.LimitReader(res.Body, maxResponse))
+	var e struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &e) == nil && e.Error != "" {
+		return fmt.Errorf("registry responded with %s: %s", res.Status, e.Error)
+	}
+	return fmt.Errorf("registry responded with %s", res.Status)
+}
+
+func decodeDeployment(res *http.Response) (*Deployment, error) {
+	var d Deployment
+	if err := json.NewDecoder(io.LimitReader(res.Body, maxResponse)).Decode(&d); err != nil {
+		return nil, fmt.Errorf("decode deployment: %w", err)
+	}
+	return &d, nil
+}
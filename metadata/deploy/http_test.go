@@ -0,0 +1,158 @@
+package deploy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata/archiver/tgzwriter"
+)
+
+// fakeAPI implements the deployment API in memory.
+type fakeAPI struct {
+	token string
+
+	mu          sync.Mutex
+	artifacts   map[string][]byte
+	deployments map[string][]*Deployment
+	staged      map[string]*Deployment
+	uploads     int
+}
+
+func newFakeAPI(t *testing.T, token string) (*fakeAPI, *httptest.Server) {
+	t.Helper()
+
+	api := &fakeAPI{token: token, artifacts: map[string][]byte{}, deployments: map[string][]*Deployment{},
+		staged: map[string]*Deployment{}}
+	srv := httptest.NewServer(api)
+	t.Cleanup(srv.Close)
+	return api, srv
+}
+
+func (api *fakeAPI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	api.mu.Lock()
+	defer api.mu.Unlock()
+
+	if r.Header.Get("Authorization") != "Bearer "+api.token {
+		w.WriteHeader(http.StatusUnauthorized)
+		_, _ = w.Write([]byte(`{"error": "invalid token"}`))
+		return
+	}
+	kind, id, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	switch {
+	case kind == "artifacts" && r.Method == http.MethodHead:
+		if _, ok := api.artifacts[id]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case kind == "artifacts" && r.Method == http.MethodGet:
+		data, ok := api.artifacts[id]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	case kind == "artifacts" && r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		api.artifacts[id] = data
+		api.uploads++
+		w.WriteHeader(http.StatusCreated)
+	case kind == "deployments" && r.Method == http.MethodGet:
+		pkg, resource, _ := strings.Cut(id, "/")
+		var body any
+		switch resource {
+		case "history":
+			if deployments := api.deployments[pkg]; len(deployments) > 0 {
+				body = deployments
+			}
+		case "staged":
+			if d := api.staged[pkg]; d != nil {
+				body = d
+			}
+		default:
+			if active := unstaged(api.deployments[pkg]); len(active) > 0 {
+				body = active[0]
+			}
+		}
+		if body == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(body)
+	case kind == "deployments" && r.Method == http.MethodPut:
+		var d Deployment
+		if err := json.NewDecoder(r.Body).Decode(&d); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		if _, ok := api.artifacts[d.Digest]; !ok {
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error": "artifact ` + d.Digest + ` is not uploaded"}`))
+			return
+		}
+		d.Time = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC).Add(time.Duration(len(api.deployments[id])) * time.Hour)
+		api.deployments[id] = append([]*Deployment{&d}, api.deployments[id]...)
+		if d.Stage != nil {
+			api.staged[id] = &d
+		} else {
+			delete(api.staged, id)
+		}
+		_ = json.NewEncoder(w).Encode(d)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func Test_HTTPTarget(t *testing.T) {
+	ctx := context.Background()
+	api, srv := newFakeAPI(t, "secret")
+	target, err := NewTarget(srv.URL+"/", bearerClient("secret"))
+	require.NoError(t, err)
+	require.IsType(t, &HTTPTarget{}, target)
+
+	current, err := target.Current(ctx, "x.y")
+	require.NoError(t, err)
+	require.Nil(t, current)
+
+	now := time.Now()
+	a, err := OpenArtifact(writeBundle(t, tgzwriter.New(), testEntities, now), "1.0.0")
+	require.NoError(t, err)
+	res, err := Deploy(ctx, target, a)
+	require.NoError(t, err)
+	require.False(t, res.Unchanged)
+	require.Equal(t, &Deployment{Package: "x.y", Version: "1.0.0", Digest: a.Digest, Time: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+		res.Deployment)
+	require.Equal(t, 1, api.uploads)
+
+	repacked, err := OpenArtifact(writeBundle(t, tgzwriter.New(), testEntities, now.Add(time.Hour)), "1.0.1")
+	require.NoError(t, err)
+	res, err = Deploy(ctx, target, repacked)
+	require.NoError(t, err)
+	require.True(t, res.Unchanged)
+	require.Equal(t, "1.0.0", res.Deployment.Version, "the deployed version is kept")
+
+	changed, err := OpenArtifact(writeBundle(t, tgzwriter.New(), `[]`, now), "1.1.0")
+	require.NoError(t, err)
+	res, err = Deploy(ctx, target, changed)
+	require.NoError(t, err)
+	require.False(t, res.Unchanged)
+	require.Equal(t, 2, api.uploads)
+
+	// The artifact of 1.0.0 is already uploaded, so it is activated only.
+	res, err = Deploy(ctx, target, a)
+	require.NoError(t, err)
+	require.Equal(t, "1.0.0", res.Deployment.Version)
+	require.Equal(t, 2, api.uploads)
+
+	target, err = NewTarget(srv.URL, bearerClient("unknown"))
+	require.NoError(t, err)
+	_, err = Deploy(ctx, target, a)
+	require.EqualError(t, err, "get current deployment: registry responded with 401 Unauthorized: invalid token")
+}
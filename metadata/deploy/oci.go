@@ -0,0 +1,518 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Media types and annotations of OCI artifacts of bundles.
+const (
+	ArtifactTypeBundle  = "application/vnd.cti.bundle.v1"
+	ArtifactTypeHistory = "application/vnd.cti.history.v1"
+
+	mediaTypeManifest = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeEmpty    = "application/vnd.oci.empty.v1+json"
+	mediaTypeHistory  = "application/vnd.cti.history.v1+json"
+
+	AnnotationPackage      = "cti.bundle.package"
+	AnnotationDigest       = "cti.bundle.digest"
+	AnnotationStageLabel   = "cti.deploy.stage.label"
+	AnnotationStagePercent = "cti.deploy.stage.percent"
+	AnnotationVersion      = "org.opencontainers.image.version"
+	AnnotationCreated      = "org.opencontainers.image.created"
+	AnnotationTitle        = "org.opencontainers.image.title"
+	AnnotationVendor       = "org.opencontainers.image.vendor"
+)
+
+// DefaultTag is the tag of the active artifact in OCI repositories if the URL does not specify one.
+const DefaultTag = "latest"
+
+// Suffixes appended to the active tag to tag the history of deployments and the staged artifact.
+const (
+	historyTagSuffix = ".history"
+	stagedTagSuffix  = ".staged"
+)
+
+// maxHistory is the number of deployments kept in histories of OCI repositories.
+const maxHistory = 100
+
+// emptyConfig is the config of artifacts without configuration, see the OCI image specification.
+var emptyConfig = []byte("{}")
+
+var tagRe = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9._-]{0,127}$`)
+
+// OCITarget pushes artifacts to a repository of an OCI registry, e.g. oci://registry.example.com/cti/x.y:prod.
+// An artifact is tagged with its version and with the tag of the URL that marks the active artifact,
+// so environments sharing a repository are told apart by tags. The staged artifact is tagged with the tag of
+// the URL and the .staged suffix, its stage is annotated for clients that choose the artifact to pull.
+//
+// Layers of an artifact are the archive of the bundle, its SBOM (see SBOM) and, if the target has a signer,
+// the signature of both (see Signature), so registry replication and retention handle them together.
+type OCITarget struct {
+	baseURL *url.URL
+	repo    string
+	tag     string
+	client  *http.Client
+	signer  crypto.Signer
+	user    string
+}
+
+// NewOCITarget creates the target of the repository of the oci:// or oci+http:// URL.
+func NewOCITarget(u *url.URL, client *http.Client) (*OCITarget, error) {
+	scheme := "https"
+	if u.Scheme == "oci+http" {
+		scheme = "http"
+	}
+	repo, tag := strings.Trim(u.Path, "/"), DefaultTag
+	if i := strings.LastIndex(repo, ":"); i > strings.LastIndex(repo, "/") {
+		repo, tag = repo[:i], repo[i+1:]
+	}
+	if repo == "" {
+		return nil, fmt.Errorf("target url %s has no repository", u)
+	}
+	if !tagRe.MatchString(tag) {
+		return nil, fmt.Errorf("target url %s: invalid tag %s", u, tag)
+	}
+	return &OCITarget{baseURL: &url.URL{Scheme: scheme, Host: u.Host}, repo: repo, tag: tag, client: client}, nil
+}
+
+type ociDescriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+type ociManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        ociDescriptor     `json:"config"`
+	Layers        []ociDescriptor   `json:"layers"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+func (t *OCITarget) Current(ctx context.Context, packageID string) (*Deployment, error) {
+	return t.pullDeployment(ctx, t.tag, packageID)
+}
+
+// Staged pulls the manifest tagged with the active tag and the .staged suffix. The manifest of the active artifact
+// is tagged so when the staged deployment ends.
+func (t *OCITarget) Staged(ctx context.Context, packageID string) (*Deployment, error) {
+	d, err := t.pullDeployment(ctx, t.stagedTag(), packageID)
+	if err != nil || d == nil || d.Stage == nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// pullDeployment pulls the deployment of the package by the tag, nil if the tag does not exist.
+func (t *OCITarget) pullDeployment(ctx context.Context, tag, packageID string) (*Deployment, error) {
+	_, m, err := t.pullManifest(ctx, tag, ArtifactTypeBundle)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	if pkg := m.Annotations[AnnotationPackage]; pkg != packageID {
+		return nil, fmt.Errorf("%s:%s is a bundle of package %s", t.repo, tag, pkg)
+	}
+	d := &Deployment{Package: packageID, Version: m.Annotations[AnnotationVersion], Digest: m.Annotations[AnnotationDigest]}
+	if created, err := time.Parse(time.RFC3339, m.Annotations[AnnotationCreated]); err == nil {
+		d.Time = created
+	}
+	label, percent := m.Annotations[AnnotationStageLabel], m.Annotations[AnnotationStagePercent]
+	if label != "" || percent != "" {
+		d.Stage = &Stage{Label: label}
+		if percent != "" {
+			if d.Stage.Percent, err = strconv.Atoi(percent); err != nil {
+				return nil, fmt.Errorf("%s:%s has invalid stage percent %s", t.repo, tag, percent)
+			}
+		}
+	}
+	return d, nil
+}
+
+// History pulls the history artifact tagged with the active tag and the .history suffix. If the active artifact
+// was changed without recording the history, it is returned first.
+func (t *OCITarget) History(ctx context.Context, packageID string) ([]*Deployment, error) {
+	current, err := t.Current(ctx, packageID)
+	if err != nil {
+		return nil, err
+	}
+	_, m, err := t.pullManifest(ctx, t.historyTag(), ArtifactTypeHistory)
+	if err != nil {
+		return nil, err
+	}
+	var history []*Deployment
+	if m != nil {
+		if len(m.Layers) != 1 {
+			return nil, fmt.Errorf("%s:%s has no history", t.repo, t.historyTag())
+		}
+		var buf bytes.Buffer
+		if err := t.pullBlob(ctx, m.Layers[0].Digest, &buf); err != nil {
+			return nil, fmt.Errorf("pull history: %w", err)
+		}
+		if err := json.Unmarshal(buf.Bytes(), &history); err != nil {
+			return nil, fmt.Errorf("decode history: %w", err)
+		}
+	}
+	if active := unstaged(history); current != nil && (len(active) == 0 || active[0].Digest != current.Digest) {
+		history = append([]*Deployment{current}, history...)
+	}
+	return history, nil
+}
+
+// Download pulls the archive of the deployment by the tag of its version.
+func (t *OCITarget) Download(ctx context.Context, d *Deployment, w io.Writer) error {
+	_, m, err := t.pullBundle(ctx, d)
+	if err != nil {
+		return err
+	}
+	if err := t.pullBlob(ctx, m.Layers[0].Digest, w); err != nil {
+		return fmt.Errorf("pull archive: %w", err)
+	}
+	return nil
+}
+
+func (t *OCITarget) Deploy(ctx context.Context, a *Artifact, d *Deployment) (*Deployment, error) {
+	if !tagRe.MatchString(a.Version) {
+		return nil, fmt.Errorf("version %s is not a valid tag", a.Version)
+	}
+	archive, err := os.ReadFile(a.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read archive: %w", err)
+	}
+	config, err := t.pushBlob(ctx, mediaTypeEmpty, emptyConfig)
+	if err != nil {
+		return nil, fmt.Errorf("push config: %w", err)
+	}
+	layers, err := t.pushLayers(ctx, a, archive)
+	if err != nil {
+		return nil, err
+	}
+
+	d = &Deployment{Package: a.PackageID, Version: a.Version, Digest: a.Digest, Time: time.Now().UTC().Truncate(time.Second),
+		Stage: d.Stage, Justification: d.Justification, User: t.user}
+	vendor, _, _ := strings.Cut(a.PackageID, ".")
+	annotations := map[string]string{AnnotationTitle: a.PackageID, AnnotationVendor: vendor}
+	for k, v := range a.Annotations {
+		annotations[k] = v
+	}
+	annotations[AnnotationPackage] = d.Package
+	annotations[AnnotationDigest] = d.Digest
+	annotations[AnnotationVersion] = d.Version
+	annotations[AnnotationCreated] = d.Time.Format(time.RFC3339)
+	manifest, err := json.Marshal(ociManifest{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeManifest,
+		ArtifactType:  ArtifactTypeBundle,
+		Config:        config,
+		Layers:        layers,
+		Annotations:   annotations,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	// The version is tagged first, so the active and staged tags always refer to a version.
+	if err := t.putManifest(ctx, a.Version, manifest); err != nil {
+		return nil, err
+	}
+	return t.activate(ctx, d, manifest)
+}
+
+// pushLayers pushes the archive, the SBOM and the signature if the target has a signer.
+func (t *OCITarget) pushLayers(ctx context.Context, a *Artifact, archive []byte) ([]ociDescriptor, error) {
+	layer, err := t.pushBlob(ctx, a.MediaType, archive)
+	if err != nil {
+		return nil, fmt.Errorf("push archive: %w", err)
+	}
+	layer.Annotations = map[string]string{AnnotationTitle: filepath.Base(a.Path)}
+	sbom, err := SBOM(a)
+	if err != nil {
+		return nil, err
+	}
+	sbomLayer, err := t.pushBlob(ctx, MediaTypeSBOM, sbom)
+	if err != nil {
+		return nil, fmt.Errorf("push sbom: %w", err)
+	}
+	sbomLayer.Annotations = map[string]string{AnnotationTitle: "sbom.cdx.json"}
+	layers := []ociDescriptor{layer, sbomLayer}
+	if t.signer == nil {
+		return layers, nil
+	}
+
+	payload, err := json.Marshal(SignedBundle{Package: a.PackageID, Version: a.Version, Digest: a.Digest,
+		Layers: []string{layer.Digest, sbomLayer.Digest}})
+	if err != nil {
+		return nil, fmt.Errorf("encode signed bundle: %w", err)
+	}
+	sig, err := sign(t.signer, payload)
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(sig)
+	if err != nil {
+		return nil, fmt.Errorf("encode signature: %w", err)
+	}
+	sigLayer, err := t.pushBlob(ctx, MediaTypeSignature, data)
+	if err != nil {
+		return nil, fmt.Errorf("push signature: %w", err)
+	}
+	sigLayer.Annotations = map[string]string{AnnotationTitle: "signature.json"}
+	return append(layers, sigLayer), nil
+}
+
+// Activate tags the manifest of the version of the deployment with the active or staged tag again.
+func (t *OCITarget) Activate(ctx context.Context, d *Deployment) (*Deployment, error) {
+	manifest, _, err := t.pullBundle(ctx, d)
+	if err != nil {
+		return nil, err
+	}
+	return t.activate(ctx, &Deployment{Package: d.Package, Version: d.Version, Digest: d.Digest,
+		Time: time.Now().UTC().Truncate(time.Second), Rollback: d.Rollback, Stage: d.Stage, User: t.user}, manifest)
+}
+
+// activate tags the manifest with the active tag, or the manifest annotated with the stage with the staged tag,
+// and records the deployment in the history.
+func (t *OCITarget) activate(ctx context.Context, d *Deployment, manifest []byte) (*Deployment, error) {
+	history, err := t.History(ctx, d.Package)
+	if err != nil {
+		return nil, fmt.Errorf("get history: %w", err)
+	}
+	if d.Stage != nil {
+		staged, err := stageManifest(manifest, d.Stage)
+		if err != nil {
+			return nil, err
+		}
+		if err := t.putManifest(ctx, t.stagedTag(), staged); err != nil {
+			return nil, err
+		}
+	} else {
+		staged, err := t.Staged(ctx, d.Package)
+		if err != nil {
+			return nil, fmt.Errorf("get staged deployment: %w", err)
+		}
+		if err := t.putManifest(ctx, t.tag, manifest); err != nil {
+			return nil, err
+		}
+		if staged != nil {
+			if err := t.putManifest(ctx, t.stagedTag(), manifest); err != nil {
+				return nil, fmt.Errorf("end staged deployment: %w", err)
+			}
+		}
+	}
+
+	history = append([]*Deployment{d}, history...)
+	if len(history) > maxHistory {
+		history = history[:maxHistory]
+	}
+	data, err := json.Marshal(history)
+	if err != nil {
+		return nil, fmt.Errorf("encode history: %w", err)
+	}
+	config, err := t.pushBlob(ctx, mediaTypeEmpty, emptyConfig)
+	if err != nil {
+		return nil, fmt.Errorf("push history config: %w", err)
+	}
+	layer, err := t.pushBlob(ctx, mediaTypeHistory, data)
+	if err != nil {
+		return nil, fmt.Errorf("push history: %w", err)
+	}
+	historyManifest, err := json.Marshal(ociManifest{
+		SchemaVersion: 2,
+		MediaType:     mediaTypeManifest,
+		ArtifactType:  ArtifactTypeHistory,
+		Config:        config,
+		Layers:        []ociDescriptor{layer},
+		Annotations:   map[string]string{AnnotationPackage: d.Package},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("encode history manifest: %w", err)
+	}
+	if err := t.putManifest(ctx, t.historyTag(), historyManifest); err != nil {
+		return nil, fmt.Errorf("record history: %w", err)
+	}
+	return d, nil
+}
+
+func (t *OCITarget) historyTag() string {
+	return t.tag + historyTagSuffix
+}
+
+func (t *OCITarget) stagedTag() string {
+	return t.tag + stagedTagSuffix
+}
+
+// stageManifest annotates the bundle manifest with the stage.
+func stageManifest(manifest []byte, stage *Stage) ([]byte, error) {
+	var m ociManifest
+	if err := json.Unmarshal(manifest, &m); err != nil {
+		return nil, fmt.Errorf("decode manifest: %w", err)
+	}
+	annotations := make(map[string]string, len(m.Annotations)+2)
+	for k, v := range m.Annotations {
+		annotations[k] = v
+	}
+	if stage.Label != "" {
+		annotations[AnnotationStageLabel] = stage.Label
+	}
+	if stage.Percent != 0 {
+		annotations[AnnotationStagePercent] = strconv.Itoa(stage.Percent)
+	}
+	m.Annotations = annotations
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, fmt.Errorf("encode manifest: %w", err)
+	}
+	return data, nil
+}
+
+// pullBundle pulls the manifest tagged with the version of the deployment and checks that it is the bundle of the
+// deployment.
+func (t *OCITarget) pullBundle(ctx context.Context, d *Deployment) ([]byte, *ociManifest, error) {
+	data, m, err := t.pullManifest(ctx, d.Version, ArtifactTypeBundle)
+	if err != nil {
+		return nil, nil, err
+	}
+	if m == nil || m.Annotations[AnnotationDigest] != d.Digest || len(m.Layers) == 0 {
+		return nil, nil, fmt.Errorf("%s:%s is not the bundle %s", t.repo, d.Version, d.Digest)
+	}
+	return data, m, nil
+}
+
+// pullManifest pulls the manifest of the artifact type by the tag, nil if the tag does not exist.
+func (t *OCITarget) pullManifest(ctx context.Context, tag, artifactType string) ([]byte, *ociManifest, error) {
+	res, err := t.do(ctx, http.MethodGet, t.path("manifests", tag), nil, "", mediaTypeManifest)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode == http.StatusNotFound {
+		return nil, nil, nil
+	}
+	if err := checkResponse(res); err != nil {
+		return nil, nil, fmt.Errorf("pull manifest %s: %w", tag, err)
+	}
+	data, err := io.ReadAll(io.LimitReader(res.Body, maxResponse))
+	if err != nil {
+		return nil, nil, fmt.Errorf("pull manifest %s: %w", tag, err)
+	}
+	var m ociManifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, nil, fmt.Errorf("decode manifest: %w", err)
+	}
+	if m.ArtifactType != artifactType {
+		return nil, nil, fmt.Errorf("%s:%s is not %s", t.repo, tag, artifactType)
+	}
+	return data, &m, nil
+}
+
+func (t *OCITarget) pullBlob(ctx context.Context, digest string, w io.Writer) error {
+	res, err := t.do(ctx, http.MethodGet, t.path("blobs", digest), nil, "", "")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := checkResponse(res); err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, res.Body); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (t *OCITarget) putManifest(ctx context.Context, tag string, manifest []byte) error {
+	res, err := t.do(ctx, http.MethodPut, t.path("manifests", tag), bytes.NewReader(manifest), mediaTypeManifest, "")
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err := checkResponse(res); err != nil {
+		return fmt.Errorf("push manifest %s: %w", tag, err)
+	}
+	return nil
+}
+
+// pushBlob uploads the blob, unless the repository already has it, and returns its descriptor.
+func (t *OCITarget) pushBlob(ctx context.Context, mediaType string, data []byte) (ociDescriptor, error) {
+	sum := sha256.Sum256(data)
+	desc := ociDescriptor{MediaType: mediaType, Digest: "sha256:" + hex.EncodeToString(sum[:]), Size: int64(len(data))}
+
+	res, err := t.do(ctx, http.MethodHead, t.path("blobs", desc.Digest), nil, "", "")
+	if err != nil {
+		return desc, err
+	}
+	res.Body.Close()
+	if res.StatusCode == http.StatusOK {
+		return desc, nil
+	}
+
+	res, err = t.do(ctx, http.MethodPost, t.path("blobs", "uploads")+"/", nil, "", "")
+	if err != nil {
+		return desc, err
+	}
+	err = checkResponse(res)
+	res.Body.Close()
+	if err != nil {
+		return desc, fmt.Errorf("start upload: %w", err)
+	}
+	location, err := res.Location()
+	if err != nil {
+		return desc, fmt.Errorf("start upload: %w", err)
+	}
+	q := location.Query()
+	q.Set("digest", desc.Digest)
+	location.RawQuery = q.Encode()
+
+	res, err = t.do(ctx, http.MethodPut, location.String(), bytes.NewReader(data), "application/octet-stream", "")
+	if err != nil {
+		return desc, err
+	}
+	defer res.Body.Close()
+	if err := checkResponse(res); err != nil {
+		return desc, fmt.Errorf("upload: %w", err)
+	}
+	return desc, nil
+}
+
+func (t *OCITarget) path(kind, ref string) string {
+	return "/v2/" + t.repo + "/" + kind + "/" + ref
+}
+
+// do sends the request to the path of the registry or to the absolute URL, e.g. of an upload location.
+func (t *OCITarget) do(ctx context.Context, method, ref string, body io.Reader, contentType, accept string) (*http.Response, error) {
+	u, err := t.baseURL.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("parse url: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return nil, fmt.Errorf("create request: %w", err)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if accept != "" {
+		req.Header.Set("Accept", accept)
+	}
+	req.Header.Set("User-Agent", "cti-deploy")
+	res, err := t.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("request registry: %w", err)
+	}
+	return res, nil
+}
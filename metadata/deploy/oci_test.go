@@ -0,0 +1,157 @@
+package deploy
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata/archiver/tgzwriter"
+)
+
+// fakeOCI implements pushing and pulling of the OCI distribution specification in memory.
+type fakeOCI struct {
+	mu        sync.Mutex
+	blobs     map[string][]byte
+	manifests map[string][]byte
+	uploads   int
+}
+
+func newFakeOCI(t *testing.T) (*fakeOCI, *httptest.Server) {
+	t.Helper()
+
+	registry := &fakeOCI{blobs: map[string][]byte{}, manifests: map[string][]byte{}}
+	srv := httptest.NewServer(registry)
+	t.Cleanup(srv.Close)
+	return registry, srv
+}
+
+func (o *fakeOCI) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	switch {
+	case strings.HasSuffix(path, "/blobs/uploads/") && r.Method == http.MethodPost:
+		o.uploads++
+		w.Header().Set("Location", fmt.Sprintf("/v2/%supload-%d?state=s", strings.TrimSuffix(path, "blobs/uploads/"), o.uploads))
+		w.WriteHeader(http.StatusAccepted)
+	case strings.Contains(path, "/upload-") && r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		sum := sha256.Sum256(data)
+		digest := r.URL.Query().Get("digest")
+		if r.URL.Query().Get("state") != "s" || digest != "sha256:"+hex.EncodeToString(sum[:]) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		o.blobs[digest] = data
+		w.WriteHeader(http.StatusCreated)
+	case strings.Contains(path, "/blobs/") && r.Method == http.MethodHead:
+		if _, ok := o.blobs[path[strings.LastIndex(path, "/")+1:]]; !ok {
+			w.WriteHeader(http.StatusNotFound)
+		}
+	case strings.Contains(path, "/blobs/") && r.Method == http.MethodGet:
+		data, ok := o.blobs[path[strings.LastIndex(path, "/")+1:]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(data)
+	case strings.Contains(path, "/manifests/") && r.Method == http.MethodPut:
+		data, _ := io.ReadAll(r.Body)
+		var m ociManifest
+		if r.Header.Get("Content-Type") != mediaTypeManifest || json.Unmarshal(data, &m) != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		for _, desc := range append(m.Layers, m.Config) {
+			if _, ok := o.blobs[desc.Digest]; !ok {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+		}
+		o.manifests[path] = data
+		w.WriteHeader(http.StatusCreated)
+	case strings.Contains(path, "/manifests/") && r.Method == http.MethodGet:
+		data, ok := o.manifests[path]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Header().Set("Content-Type", mediaTypeManifest)
+		_, _ = w.Write(data)
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func Test_OCITarget(t *testing.T) {
+	ctx := context.Background()
+	registry, srv := newFakeOCI(t)
+	u, err := url.Parse(srv.URL)
+	require.NoError(t, err)
+	target, err := NewTarget("oci+http://"+u.Host+"/cti/x.y:prod", http.DefaultClient)
+	require.NoError(t, err)
+	require.IsType(t, &OCITarget{}, target)
+
+	current, err := target.Current(ctx, "x.y")
+	require.NoError(t, err)
+	require.Nil(t, current)
+
+	now := time.Now()
+	a, err := OpenArtifact(writeBundle(t, tgzwriter.New(), testEntities, now), "1.0.0")
+	require.NoError(t, err)
+	res, err := Deploy(ctx, target, a)
+	require.NoError(t, err)
+	require.False(t, res.Unchanged)
+	require.Equal(t, 4, registry.uploads, "config, archive, SBOM and history are uploaded")
+	require.Contains(t, registry.manifests, "cti/x.y/manifests/1.0.0")
+	require.Equal(t, registry.manifests["cti/x.y/manifests/1.0.0"], registry.manifests["cti/x.y/manifests/prod"])
+
+	var m ociManifest
+	require.NoError(t, json.Unmarshal(registry.manifests["cti/x.y/manifests/prod"], &m))
+	require.Equal(t, ArtifactTypeBundle, m.ArtifactType)
+	require.Equal(t, mediaTypeEmpty, m.Config.MediaType)
+	require.Len(t, m.Layers, 2)
+	require.Equal(t, MediaTypeTgz, m.Layers[0].MediaType)
+	require.Equal(t, "bundle.cti", m.Layers[0].Annotations[AnnotationTitle])
+	require.Equal(t, MediaTypeSBOM, m.Layers[1].MediaType)
+	require.Equal(t, "x.y", m.Annotations[AnnotationPackage])
+	require.Equal(t, "x.y", m.Annotations[AnnotationTitle])
+	require.Equal(t, "x", m.Annotations[AnnotationVendor])
+	require.Equal(t, a.Digest, m.Annotations[AnnotationDigest])
+
+	current, err = target.Current(ctx, "x.y")
+	require.NoError(t, err)
+	require.Equal(t, &Deployment{Package: "x.y", Version: "1.0.0", Digest: a.Digest, Time: res.Deployment.Time}, current)
+
+	repacked, err := OpenArtifact(writeBundle(t, tgzwriter.New(), testEntities, now.Add(time.Hour)), "1.0.1")
+	require.NoError(t, err)
+	res, err = Deploy(ctx, target, repacked)
+	require.NoError(t, err)
+	require.True(t, res.Unchanged)
+
+	changed, err := OpenArtifact(writeBundle(t, tgzwriter.New(), `[]`, now), "1.1.0")
+	require.NoError(t, err)
+	_, err = Deploy(ctx, target, changed)
+	require.NoError(t, err)
+	require.Equal(t, 7, registry.uploads, "the config is not uploaded again")
+
+	_, err = target.Current(ctx, "x.z")
+	require.EqualError(t, err, "cti/x.y:prod is a bundle of package x.y")
+
+	changed.Version = "1.1.0+build"
+	_, err = target.Deploy(ctx, changed, &Deployment{Package: changed.PackageID, Version: changed.Version, Digest: changed.Digest})
+	require.EqualError(t, err, "version 1.1.0+build is not a valid tag")
+}
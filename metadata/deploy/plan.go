@@ -0,0 +1,169 @@
+package deploy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+// Action is a change of the entity between the deployed and the new artifact.
+type Action string
+
+const (
+	ActionAdded   Action = "added"
+	ActionChanged Action = "changed"
+	ActionRemoved Action = "removed"
+)
+
+// Verdict tells whether clients of the deployed artifact are compatible with the new one.
+type Verdict string
+
+const (
+	// VerdictUnchanged means the artifact is already deployed.
+	VerdictUnchanged Verdict = "unchanged"
+	// VerdictCompatible means no change breaks clients, including the first deployment of the package.
+	VerdictCompatible Verdict = "compatible"
+	// VerdictBreaking means some entities are removed or their types are changed incompatibly.
+	VerdictBreaking Verdict = "breaking"
+)
+
+// Change is a change of the entity that the deployment would make.
+type Change struct {
+	Cti    string        `json:"cti"`
+	Kind   registry.Kind `json:"kind"`
+	Action Action        `json:"action"`
+	// Breaking holds reasons why the change breaks clients of the deployed artifact, empty if it does not.
+	Breaking []string `json:"breaking,omitempty"`
+}
+
+// Plan is what the deployment of the artifact would change in the target.
+type Plan struct {
+	// Current is the active deployment, nil if the package was never deployed.
+	Current *Deployment `json:"current,omitempty"`
+	// Changes are changes of entities of the package sorted by CTI.
+	Changes []Change `json:"changes"`
+	Verdict Verdict  `json:"verdict"`
+}
+
+// DryRun compares the artifact with the active deployment of the package without changing the target.
+// The archive of the active deployment is downloaded unless it has the same contents.
+func DryRun(ctx context.Context, t Target, a *Artifact) (*Plan, error) {
+	current, err := t.Current(ctx, a.PackageID)
+	if err != nil {
+		return nil, fmt.Errorf("get current deployment: %w", err)
+	}
+	if current != nil && current.Digest == a.Digest {
+		return &Plan{Current: current, Verdict: VerdictUnchanged}, nil
+	}
+
+	next, err := ctipackage.ReadBundle(a.Path)
+	if err != nil {
+		return nil, fmt.Errorf("read artifact: %w", err)
+	}
+	var deployed metadata.Entities
+	if current != nil {
+		if deployed, err = download(ctx, t, current); err != nil {
+			return nil, fmt.Errorf("download %s %s: %w", current.Package, current.Version, err)
+		}
+	}
+	changes, err := diff(deployed, next.Entities)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{Current: current, Changes: changes, Verdict: VerdictCompatible}
+	for _, c := range changes {
+		if len(c.Breaking) > 0 {
+			plan.Verdict = VerdictBreaking
+			break
+		}
+	}
+	return plan, nil
+}
+
+// download reads entities of the deployed artifact.
+func download(ctx context.Context, t Target, d *Deployment) (metadata.Entities, error) {
+	f, err := os.CreateTemp("", "cti-deployed-*.cti")
+	if err != nil {
+		return nil, fmt.Errorf("create temporary file: %w", err)
+	}
+	defer os.Remove(f.Name())
+	defer f.Close()
+
+	if err := t.Download(ctx, d, f); err != nil {
+		return nil, err
+	}
+	if err := f.Close(); err != nil {
+		return nil, fmt.Errorf("write archive: %w", err)
+	}
+	b, err := ctipackage.ReadBundle(f.Name())
+	if err != nil {
+		return nil, err
+	}
+	return b.Entities, nil
+}
+
+// diff returns changes of entities between the deployed and the new bundle. Source maps are not compared,
+// so entities moved within files are not reported as changed.
+func diff(deployed, entities metadata.Entities) ([]Change, error) {
+	prev := make(metadata.EntitiesMap, len(deployed))
+	for _, entity := range deployed {
+		prev[entity.Cti] = entity
+	}
+	next := make(metadata.EntitiesMap, len(entities))
+	for _, entity := range entities {
+		next[entity.Cti] = entity
+	}
+
+	var changes []Change
+	for id, entity := range prev {
+		if _, ok := next[id]; !ok {
+			changes = append(changes, Change{Cti: id, Kind: registry.KindOf(entity), Action: ActionRemoved,
+				Breaking: []string{"entity is removed"}})
+		}
+	}
+	for id, entity := range next {
+		old, ok := prev[id]
+		if !ok {
+			changes = append(changes, Change{Cti: id, Kind: registry.KindOf(entity), Action: ActionAdded})
+			continue
+		}
+		equal, err := sameEntities(old, entity)
+		if err != nil {
+			return nil, err
+		}
+		if equal {
+			continue
+		}
+		reasons, err := breakingChanges(old, entity)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, Change{Cti: id, Kind: registry.KindOf(entity), Action: ActionChanged, Breaking: reasons})
+	}
+	sort.Slice(changes, func(a, b int) bool {
+		return changes[a].Cti < changes[b].Cti
+	})
+	return changes, nil
+}
+
+func sameEntities(a, b *metadata.Entity) (bool, error) {
+	x, y := *a, *b
+	x.SourceMap, y.SourceMap = metadata.SourceMap{}, metadata.SourceMap{}
+	dataA, err := json.Marshal(x)
+	if err != nil {
+		return false, fmt.Errorf("encode %s: %w", a.Cti, err)
+	}
+	dataB, err := json.Marshal(y)
+	if err != nil {
+		return false, fmt.Errorf("encode %s: %w", b.Cti, err)
+	}
+	return bytes.Equal(dataA, dataB), nil
+}
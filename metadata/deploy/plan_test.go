@@ -0,0 +1,133 @@
+package deploy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata/archiver/tgzwriter"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+const (
+	deployedEntities = `[
+		{"cti": "cti.x.y.alert.v1.0", "schema": {"type": "object", "properties": {"threshold": {"type": "integer"}}}},
+		{"cti": "cti.x.y.alert.v1.0~x.y.disk_full.v1.0", "values": {"threshold": 90}},
+		{"cti": "cti.x.y.event.v1.0", "schema": {"type": "object"}},
+		{"cti": "cti.x.y.topic.v1.0", "final": true, "schema": {}}
+	]`
+	compatibleEntities = `[
+		{"cti": "cti.x.y.alert.v1.0", "schema": {"type": "object", "properties": {"threshold": {"type": "number"}}}},
+		{"cti": "cti.x.y.alert.v1.0~x.y.disk_full.v1.0", "values": {"threshold": 95}},
+		{"cti": "cti.x.y.event.v1.0", "schema": {"type": "object"}, "source_map": {"original_path": "moved.raml"}},
+		{"cti": "cti.x.y.metric.v1.0", "schema": {}},
+		{"cti": "cti.x.y.topic.v1.0", "final": true, "schema": {}}
+	]`
+	breakingEntities = `[
+		{"cti": "cti.x.y.alert.v1.0", "schema": {"type": "object", "properties": {"threshold": {"type": "string"}}}},
+		{"cti": "cti.x.y.topic.v1.0", "final": true, "schema": {}}
+	]`
+)
+
+func Test_DryRun(t *testing.T) {
+	ctx := context.Background()
+	for name, target := range testTargets(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			deployed, err := OpenArtifact(writeBundle(t, tgzwriter.New(), deployedEntities, now), "1.0.0")
+			require.NoError(t, err)
+
+			plan, err := DryRun(ctx, target, deployed)
+			require.NoError(t, err)
+			require.Nil(t, plan.Current)
+			require.Equal(t, VerdictCompatible, plan.Verdict)
+			require.Len(t, plan.Changes, 4)
+			for _, c := range plan.Changes {
+				require.Equal(t, ActionAdded, c.Action)
+			}
+			current, err := target.Current(ctx, "x.y")
+			require.NoError(t, err)
+			require.Nil(t, current, "dry run must not deploy")
+
+			_, err = Deploy(ctx, target, deployed)
+			require.NoError(t, err)
+
+			plan, err = DryRun(ctx, target, deployed)
+			require.NoError(t, err)
+			require.Equal(t, VerdictUnchanged, plan.Verdict)
+			require.Empty(t, plan.Changes)
+
+			compatible, err := OpenArtifact(writeBundle(t, tgzwriter.New(), compatibleEntities, now), "1.1.0")
+			require.NoError(t, err)
+			plan, err = DryRun(ctx, target, compatible)
+			require.NoError(t, err)
+			require.Equal(t, "1.0.0", plan.Current.Version)
+			require.Equal(t, VerdictCompatible, plan.Verdict)
+			require.Equal(t, []Change{
+				{Cti: "cti.x.y.alert.v1.0", Kind: registry.KindType, Action: ActionChanged},
+				{Cti: "cti.x.y.alert.v1.0~x.y.disk_full.v1.0", Kind: registry.KindInstance, Action: ActionChanged},
+				{Cti: "cti.x.y.metric.v1.0", Kind: registry.KindType, Action: ActionAdded},
+			}, plan.Changes)
+
+			breaking, err := OpenArtifact(writeBundle(t, tgzwriter.New(), breakingEntities, now), "2.0.0")
+			require.NoError(t, err)
+			plan, err = DryRun(ctx, target, breaking)
+			require.NoError(t, err)
+			require.Equal(t, VerdictBreaking, plan.Verdict)
+			require.Equal(t, []Change{
+				{Cti: "cti.x.y.alert.v1.0", Kind: registry.KindType, Action: ActionChanged,
+					Breaking: []string{".threshold: type is changed from integer to string"}},
+				{Cti: "cti.x.y.alert.v1.0~x.y.disk_full.v1.0", Kind: registry.KindInstance, Action: ActionRemoved,
+					Breaking: []string{"entity is removed"}},
+				{Cti: "cti.x.y.event.v1.0", Kind: registry.KindType, Action: ActionRemoved,
+					Breaking: []string{"entity is removed"}},
+			}, plan.Changes)
+
+			current, err = target.Current(ctx, "x.y")
+			require.NoError(t, err)
+			require.Equal(t, deployed.Digest, current.Digest, "dry run must not deploy")
+		})
+	}
+}
+
+func Test_DeployRequireCompat(t *testing.T) {
+	ctx := context.Background()
+	for name, target := range testTargets(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			deployed, err := OpenArtifact(writeBundle(t, tgzwriter.New(), deployedEntities, now), "1.0.0")
+			require.NoError(t, err)
+			res, err := Deploy(ctx, target, deployed, WithRequireCompat())
+			require.NoError(t, err)
+			require.Equal(t, VerdictCompatible, res.Plan.Verdict)
+
+			breaking, err := OpenArtifact(writeBundle(t, tgzwriter.New(), breakingEntities, now), "2.0.0")
+			require.NoError(t, err)
+			_, err = Deploy(ctx, target, breaking, WithRequireCompat())
+			require.EqualError(t, err, "deployment breaks clients of x.y 1.0.0")
+			var breakingErr *BreakingError
+			require.ErrorAs(t, err, &breakingErr)
+			require.Equal(t, VerdictBreaking, breakingErr.Plan.Verdict)
+			current, err := target.Current(ctx, "x.y")
+			require.NoError(t, err)
+			require.Equal(t, deployed.Digest, current.Digest, "breaking changes must not be deployed")
+
+			compatible, err := OpenArtifact(writeBundle(t, tgzwriter.New(), compatibleEntities, now), "1.1.0")
+			require.NoError(t, err)
+			res, err = Deploy(ctx, target, compatible, WithAllowBreaking("clients are migrated"))
+			require.NoError(t, err)
+			require.Empty(t, res.Deployment.Justification, "compatible deployments are not justified")
+
+			res, err = Deploy(ctx, target, breaking, WithAllowBreaking("clients are migrated"))
+			require.NoError(t, err)
+			require.Equal(t, VerdictBreaking, res.Plan.Verdict)
+			history, err := target.History(ctx, "x.y")
+			require.NoError(t, err)
+			require.Len(t, history, 3)
+			require.Equal(t, "2.0.0", history[0].Version)
+			require.Equal(t, "clients are migrated", history[0].Justification)
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package deploy
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// MediaTypeSBOM is the media type of SBOMs of bundles pushed to OCI registries.
+const MediaTypeSBOM = "application/vnd.cyclonedx+json"
+
+type cdxBOM struct {
+	BOMFormat    string          `json:"bomFormat"`
+	SpecVersion  string          `json:"specVersion"`
+	Version      int             `json:"version"`
+	Metadata     cdxMetadata     `json:"metadata"`
+	Components   []cdxComponent  `json:"components"`
+	Dependencies []cdxDependency `json:"dependencies"`
+}
+
+type cdxMetadata struct {
+	Component cdxComponent `json:"component"`
+}
+
+type cdxComponent struct {
+	Type       string        `json:"type"`
+	BOMRef     string        `json:"bom-ref"`
+	Name       string        `json:"name"`
+	Version    string        `json:"version"`
+	PURL       string        `json:"purl,omitempty"`
+	Hashes     []cdxHash     `json:"hashes,omitempty"`
+	Properties []cdxProperty `json:"properties,omitempty"`
+}
+
+type cdxHash struct {
+	Alg     string `json:"alg"`
+	Content string `json:"content"`
+}
+
+type cdxProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type cdxDependency struct {
+	Ref       string   `json:"ref"`
+	DependsOn []string `json:"dependsOn"`
+}
+
+// SBOM describes the bundle and its dependencies in the CycloneDX format. The SBOM depends on contents only,
+// so it has the same digest whenever the same bundle is pushed.
+func SBOM(a *Artifact) ([]byte, error) {
+	f, err := os.Open(a.Path)
+	if err != nil {
+		return nil, fmt.Errorf("open archive: %w", err)
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return nil, fmt.Errorf("read archive: %w", err)
+	}
+
+	bundle := cdxComponent{
+		Type:       "library",
+		BOMRef:     a.PackageID + "@" + a.Version,
+		Name:       a.PackageID,
+		Version:    a.Version,
+		PURL:       "pkg:generic/" + a.PackageID + "@" + a.Version,
+		Hashes:     []cdxHash{{Alg: "SHA-256", Content: hex.EncodeToString(h.Sum(nil))}},
+		Properties: []cdxProperty{{Name: AnnotationDigest, Value: a.Digest}},
+	}
+	bom := cdxBOM{
+		BOMFormat:    "CycloneDX",
+		SpecVersion:  "1.5",
+		Version:      1,
+		Metadata:     cdxMetadata{Component: bundle},
+		Components:   []cdxComponent{},
+		Dependencies: []cdxDependency{{Ref: bundle.BOMRef, DependsOn: []string{}}},
+	}
+	sources := make([]string, 0, len(a.Depends))
+	for source := range a.Depends {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+	for _, source := range sources {
+		c := cdxComponent{Type: "library", BOMRef: source + "@" + a.Depends[source], Name: source, Version: a.Depends[source]}
+		bom.Components = append(bom.Components, c)
+		bom.Dependencies[0].DependsOn = append(bom.Dependencies[0].DependsOn, c.BOMRef)
+	}
+
+	data, err := json.Marshal(bom)
+	if err != nil {
+		return nil, fmt.Errorf("encode sbom: %w", err)
+	}
+	return data, nil
+}
@@ -0,0 +1,38 @@
+package deploy
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata/archiver/tgzwriter"
+)
+
+func Test_SBOM(t *testing.T) {
+	a, err := OpenArtifact(writeBundle(t, tgzwriter.New(), testEntities, time.Now()), "1.2.0")
+	require.NoError(t, err)
+	a.Depends = map[string]string{"github.com/acronis/platform": "v2.1.0", "github.com/acronis/alerts": "v1.0.0"}
+
+	data, err := SBOM(a)
+	require.NoError(t, err)
+	again, err := SBOM(a)
+	require.NoError(t, err)
+	require.Equal(t, data, again, "SBOM must depend on contents only")
+
+	var bom cdxBOM
+	require.NoError(t, json.Unmarshal(data, &bom))
+	require.Equal(t, "CycloneDX", bom.BOMFormat)
+	require.Equal(t, "x.y@1.2.0", bom.Metadata.Component.BOMRef)
+	require.Equal(t, "pkg:generic/x.y@1.2.0", bom.Metadata.Component.PURL)
+	require.Equal(t, []cdxProperty{{Name: AnnotationDigest, Value: a.Digest}}, bom.Metadata.Component.Properties)
+	require.Len(t, bom.Metadata.Component.Hashes, 1)
+	require.Equal(t, []cdxComponent{
+		{Type: "library", BOMRef: "github.com/acronis/alerts@v1.0.0", Name: "github.com/acronis/alerts", Version: "v1.0.0"},
+		{Type: "library", BOMRef: "github.com/acronis/platform@v2.1.0", Name: "github.com/acronis/platform", Version: "v2.1.0"},
+	}, bom.Components)
+	require.Equal(t, []cdxDependency{
+		{Ref: "x.y@1.2.0", DependsOn: []string{"github.com/acronis/alerts@v1.0.0", "github.com/acronis/platform@v2.1.0"}},
+	}, bom.Dependencies)
+}
@@ -0,0 +1,134 @@
+package deploy
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+// MediaTypeSignature is the media type of signatures of bundles pushed to OCI registries, see Signature.
+const MediaTypeSignature = "application/vnd.cti.signature.v1+json"
+
+// Signature algorithms.
+const (
+	AlgorithmEd25519     = "ed25519"
+	AlgorithmECDSASHA256 = "ecdsa-sha256"
+)
+
+// SignedBundle is the signed payload of a bundle pushed to an OCI registry.
+type SignedBundle struct {
+	Package string `json:"package"`
+	Version string `json:"version"`
+	Digest  string `json:"digest"`
+	// Layers are digests of the archive and the SBOM of the bundle in the manifest.
+	Layers []string `json:"layers"`
+}
+
+// Signature is the signature layer of a bundle pushed to an OCI registry.
+type Signature struct {
+	// Payload is a SignedBundle encoded in JSON.
+	Payload   []byte `json:"payload"`
+	Algorithm string `json:"algorithm"`
+	// KeyID is the sha256 digest of the public key in PKIX form.
+	KeyID     string `json:"key_id"`
+	Signature []byte `json:"signature"`
+}
+
+// ParseSigningKey parses the PEM encoded ed25519 or ECDSA private key in PKCS #8 or SEC 1 form.
+func ParseSigningKey(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("signing key is not PEM encoded")
+	}
+	var key any
+	var err error
+	if block.Type == "EC PRIVATE KEY" {
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	} else {
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parse signing key: %w", err)
+	}
+	switch key := key.(type) {
+	case ed25519.PrivateKey:
+		return key, nil
+	case *ecdsa.PrivateKey:
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported signing key %T, expected ed25519 or ECDSA", key)
+	}
+}
+
+// sign signs the payload with the key.
+func sign(signer crypto.Signer, payload []byte) (*Signature, error) {
+	keyID, err := keyID(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	sig := &Signature{Payload: payload, KeyID: keyID}
+	switch signer.Public().(type) {
+	case ed25519.PublicKey:
+		sig.Algorithm = AlgorithmEd25519
+		sig.Signature, err = signer.Sign(rand.Reader, payload, crypto.Hash(0))
+	case *ecdsa.PublicKey:
+		sum := sha256.Sum256(payload)
+		sig.Algorithm = AlgorithmECDSASHA256
+		sig.Signature, err = signer.Sign(rand.Reader, sum[:], crypto.SHA256)
+	default:
+		return nil, fmt.Errorf("unsupported signing key %T, expected ed25519 or ECDSA", signer.Public())
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sign: %w", err)
+	}
+	return sig, nil
+}
+
+// VerifySignature verifies the signature layer with the public key and returns the signed bundle. Layers of the
+// signed bundle must be compared with layers of the manifest.
+func VerifySignature(data []byte, pub crypto.PublicKey) (*SignedBundle, error) {
+	var sig Signature
+	if err := json.Unmarshal(data, &sig); err != nil {
+		return nil, fmt.Errorf("decode signature: %w", err)
+	}
+	id, err := keyID(pub)
+	if err != nil {
+		return nil, err
+	}
+	if sig.KeyID != id {
+		return nil, fmt.Errorf("bundle is signed with another key %s", sig.KeyID)
+	}
+	var ok bool
+	switch pub := pub.(type) {
+	case ed25519.PublicKey:
+		ok = sig.Algorithm == AlgorithmEd25519 && ed25519.Verify(pub, sig.Payload, sig.Signature)
+	case *ecdsa.PublicKey:
+		sum := sha256.Sum256(sig.Payload)
+		ok = sig.Algorithm == AlgorithmECDSASHA256 && ecdsa.VerifyASN1(pub, sum[:], sig.Signature)
+	}
+	if !ok {
+		return nil, errors.New("invalid signature")
+	}
+	var bundle SignedBundle
+	if err := json.Unmarshal(sig.Payload, &bundle); err != nil {
+		return nil, fmt.Errorf("decode signed bundle: %w", err)
+	}
+	return &bundle, nil
+}
+
+func keyID(pub crypto.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("marshal public key: %w", err)
+	}
+	sum := sha256.Sum256(der)
+	return "sha256:" + hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,120 @@
+package deploy
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata/archiver/tgzwriter"
+)
+
+func Test_ParseSigningKey(t *testing.T) {
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name string
+		data []byte
+		err  string
+	}{
+		{name: "ed25519", data: pemKey(t, edKey)},
+		{name: "ecdsa", data: pemKey(t, ecKey)},
+		{name: "sec1", data: pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER})},
+		{name: "not pem", data: []byte("secret"), err: "signing key is not PEM encoded"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			signer, err := ParseSigningKey(tc.data)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.NotNil(t, signer)
+		})
+	}
+}
+
+func Test_OCITargetSignature(t *testing.T) {
+	ctx := context.Background()
+	edPub, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	for name, signer := range map[string]crypto.Signer{"ed25519": edKey, "ecdsa": ecKey} {
+		t.Run(name, func(t *testing.T) {
+			registry, srv := newFakeOCI(t)
+			u, err := url.Parse(srv.URL)
+			require.NoError(t, err)
+			target, err := NewTarget("oci+http://"+u.Host+"/cti/x.y:prod", http.DefaultClient, WithSigner(signer))
+			require.NoError(t, err)
+
+			a, err := OpenArtifact(writeBundle(t, tgzwriter.New(), testEntities, time.Now()), "1.0.0")
+			require.NoError(t, err)
+			a.Annotations = map[string]string{"org.opencontainers.image.source": "https://github.com/acronis/x-y",
+				AnnotationDigest: "sha256:forged"}
+			_, err = Deploy(ctx, target, a)
+			require.NoError(t, err)
+
+			var m ociManifest
+			require.NoError(t, json.Unmarshal(registry.manifests["cti/x.y/manifests/1.0.0"], &m))
+			require.Equal(t, "https://github.com/acronis/x-y", m.Annotations["org.opencontainers.image.source"])
+			require.Equal(t, a.Digest, m.Annotations[AnnotationDigest], "annotations of deployments are not overridden")
+			require.Len(t, m.Layers, 3)
+			require.Equal(t, MediaTypeSignature, m.Layers[2].MediaType)
+
+			bundle, err := VerifySignature(registry.blobs[m.Layers[2].Digest], signer.Public())
+			require.NoError(t, err)
+			require.Equal(t, &SignedBundle{Package: "x.y", Version: "1.0.0", Digest: a.Digest,
+				Layers: []string{m.Layers[0].Digest, m.Layers[1].Digest}}, bundle)
+
+			var other crypto.PublicKey = edPub
+			if name == "ed25519" {
+				other = ecKey.Public()
+			}
+			_, err = VerifySignature(registry.blobs[m.Layers[2].Digest], other)
+			require.ErrorContains(t, err, "bundle is signed with another key")
+		})
+	}
+
+	_, err = NewTarget("https://registry.example.com", http.DefaultClient, WithSigner(edKey))
+	require.EqualError(t, err, "target url https://registry.example.com: bundles are signed for OCI registries only")
+}
+
+func Test_VerifySignature(t *testing.T) {
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	sig, err := sign(key, []byte(`{"package": "x.y"}`))
+	require.NoError(t, err)
+	sig.Payload = []byte(`{"package": "x.z"}`)
+	data, err := json.Marshal(sig)
+	require.NoError(t, err)
+	_, err = VerifySignature(data, key.Public())
+	require.EqualError(t, err, "invalid signature")
+}
+
+func pemKey(t *testing.T, key any) []byte {
+	t.Helper()
+
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+}
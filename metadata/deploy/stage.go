@@ -0,0 +1,67 @@
+package deploy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Stage is a subset of clients of the registry that an artifact is served to before it is served to all of them.
+// Registries choose the clients of the stage, e.g. by a ring or tenants of the label or by the share in percent.
+type Stage struct {
+	Label   string `json:"label,omitempty"`
+	Percent int    `json:"percent,omitempty"`
+}
+
+func (s Stage) String() string {
+	switch {
+	case s.Label == "":
+		return strconv.Itoa(s.Percent) + "%"
+	case s.Percent == 0:
+		return s.Label
+	default:
+		return s.Label + " " + strconv.Itoa(s.Percent) + "%"
+	}
+}
+
+func (s Stage) validate() error {
+	if s.Label == "" && s.Percent == 0 {
+		return errors.New("stage has neither a label nor a percent")
+	}
+	if s.Percent < 0 || s.Percent > 99 {
+		return fmt.Errorf("stage percent %d is not between 1 and 99", s.Percent)
+	}
+	return nil
+}
+
+// Promote advances the staged deployment of the package to the stage, or serves it to all clients if the stage is
+// nil, which ends the staged deployment. Percents of stages only grow: the staged artifact is deployed again to
+// serve it to less clients.
+func Promote(ctx context.Context, t Target, packageID string, stage *Stage) (*Result, error) {
+	staged, err := t.Staged(ctx, packageID)
+	if err != nil {
+		return nil, fmt.Errorf("get staged deployment: %w", err)
+	}
+	if staged == nil {
+		return nil, fmt.Errorf("package %s has no staged deployment", packageID)
+	}
+	if stage != nil {
+		if err := stage.validate(); err != nil {
+			return nil, err
+		}
+		if *stage == *staged.Stage {
+			return &Result{Deployment: staged, Unchanged: true}, nil
+		}
+		if stage.Percent < staged.Stage.Percent {
+			return nil, fmt.Errorf("stage %s does not advance the staged deployment at %s", stage, staged.Stage)
+		}
+	}
+
+	d, err := t.Activate(ctx, &Deployment{Package: staged.Package, Version: staged.Version, Digest: staged.Digest,
+		Stage: stage})
+	if err != nil {
+		return nil, fmt.Errorf("activate %s %s: %w", staged.Package, staged.Version, err)
+	}
+	return &Result{Deployment: d}, nil
+}
@@ -0,0 +1,109 @@
+package deploy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata/archiver/tgzwriter"
+)
+
+func Test_Promote(t *testing.T) {
+	ctx := context.Background()
+	for name, target := range testTargets(t) {
+		t.Run(name, func(t *testing.T) {
+			now := time.Now()
+			var artifacts []*Artifact
+			for i, entities := range []string{testEntities, `[]`} {
+				a, err := OpenArtifact(writeBundle(t, tgzwriter.New(), entities, now), fmt.Sprintf("1.%d.0", i))
+				require.NoError(t, err)
+				artifacts = append(artifacts, a)
+			}
+
+			res, err := Deploy(ctx, target, artifacts[0], WithStage(&Stage{Label: "canary"}))
+			require.NoError(t, err)
+			require.Equal(t, &Stage{Label: "canary"}, res.Deployment.Stage)
+			current, err := target.Current(ctx, "x.y")
+			require.NoError(t, err)
+			require.Nil(t, current, "the first version may be staged")
+			_, err = Promote(ctx, target, "x.y", nil)
+			require.NoError(t, err)
+
+			_, err = Promote(ctx, target, "x.y", nil)
+			require.EqualError(t, err, "package x.y has no staged deployment")
+			res, err = Deploy(ctx, target, artifacts[0], WithStage(&Stage{Percent: 10}))
+			require.NoError(t, err)
+			require.True(t, res.Unchanged, "active contents are not staged")
+
+			res, err = Deploy(ctx, target, artifacts[1], WithStage(&Stage{Percent: 10}))
+			require.NoError(t, err)
+			require.False(t, res.Unchanged)
+			res, err = Deploy(ctx, target, artifacts[1], WithStage(&Stage{Percent: 10}))
+			require.NoError(t, err)
+			require.True(t, res.Unchanged)
+			current, err = target.Current(ctx, "x.y")
+			require.NoError(t, err)
+			require.Equal(t, "1.0.0", current.Version, "staged deployments are not active")
+			staged, err := target.Staged(ctx, "x.y")
+			require.NoError(t, err)
+			require.Equal(t, "1.1.0", staged.Version)
+			require.Equal(t, &Stage{Percent: 10}, staged.Stage)
+
+			_, err = Promote(ctx, target, "x.y", &Stage{Percent: 5})
+			require.EqualError(t, err, "stage 5% does not advance the staged deployment at 10%")
+			res, err = Promote(ctx, target, "x.y", &Stage{Percent: 10})
+			require.NoError(t, err)
+			require.True(t, res.Unchanged)
+			res, err = Promote(ctx, target, "x.y", &Stage{Label: "beta", Percent: 50})
+			require.NoError(t, err)
+			require.Equal(t, &Stage{Label: "beta", Percent: 50}, res.Deployment.Stage)
+			staged, err = target.Staged(ctx, "x.y")
+			require.NoError(t, err)
+			require.Equal(t, &Stage{Label: "beta", Percent: 50}, staged.Stage)
+
+			res, err = Promote(ctx, target, "x.y", nil)
+			require.NoError(t, err)
+			require.Nil(t, res.Deployment.Stage)
+			current, err = target.Current(ctx, "x.y")
+			require.NoError(t, err)
+			require.Equal(t, artifacts[1].Digest, current.Digest)
+			staged, err = target.Staged(ctx, "x.y")
+			require.NoError(t, err)
+			require.Nil(t, staged, "the staged deployment ends with the promotion")
+
+			history, err := target.History(ctx, "x.y")
+			require.NoError(t, err)
+			require.Len(t, history, 5)
+			res, err = Rollback(ctx, target, "x.y", "")
+			require.NoError(t, err)
+			require.Equal(t, "1.0.0", res.Deployment.Version, "staged deployments are skipped")
+		})
+	}
+}
+
+func Test_StageValidate(t *testing.T) {
+	testCases := []struct {
+		stage Stage
+		err   string
+	}{
+		{stage: Stage{}, err: "stage has neither a label nor a percent"},
+		{stage: Stage{Percent: 100}, err: "stage percent 100 is not between 1 and 99"},
+		{stage: Stage{Label: "canary", Percent: -1}, err: "stage percent -1 is not between 1 and 99"},
+		{stage: Stage{Label: "canary"}},
+		{stage: Stage{Percent: 99}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.stage.String(), func(t *testing.T) {
+			err := tc.stage.validate()
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,286 @@
+// Package docgen generates static documentation of CTI types and instances.
+package docgen
+
+import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	texttemplate "text/template"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+	"github.com/acronis/go-cti/metadata/validator"
+)
+
+// Format is an output format of the documentation.
+type Format string
+
+const (
+	FormatHTML     Format = "html"
+	FormatMarkdown Format = "markdown"
+)
+
+const (
+	// SearchIndexFile is a name of the search index written next to the pages.
+	SearchIndexFile = "search-index.json"
+
+	DefaultTitle = "CTI documentation"
+)
+
+//go:embed templates/*
+var templatesFS embed.FS
+
+type executor interface {
+	ExecuteTemplate(w io.Writer, name string, data any) error
+}
+
+type Generator struct {
+	registry  *registry.Registry
+	validator *validator.MetadataValidator
+	format    Format
+	title     string
+
+	// documented holds CTIs of entities that have pages or sections in the documentation.
+	documented map[string]bool
+}
+
+type Option func(*Generator) error
+
+// WithFormat sets the output format. HTML is used by default.
+func WithFormat(format Format) Option {
+	return func(g *Generator) error {
+		switch format {
+		case FormatHTML, FormatMarkdown:
+			g.format = format
+			return nil
+		default:
+			return fmt.Errorf("unsupported format %s", format)
+		}
+	}
+}
+
+// WithTitle sets the title of the documentation index.
+func WithTitle(title string) Option {
+	return func(g *Generator) error {
+		g.title = title
+		return nil
+	}
+}
+
+// New creates a documentation generator. The registry is used to resolve parents, children and cross-links
+// of documented entities and may contain entities that are not documented, e.g. of dependencies.
+func New(r *registry.Registry, opts ...Option) (*Generator, error) {
+	v := validator.MakeMetadataValidator()
+	v.LoadRegistry(r)
+
+	g := &Generator{
+		registry:  r,
+		validator: v,
+		format:    FormatHTML,
+		title:     DefaultTitle,
+	}
+	for _, opt := range opts {
+		if err := opt(g); err != nil {
+			return nil, fmt.Errorf("apply option: %w", err)
+		}
+	}
+	return g, nil
+}
+
+// Describe returns the page model of the type without rendering it, e.g. to inspect the type in a terminal.
+// Nothing is documented, so links of the page have no URLs.
+func (g *Generator) Describe(cti string) (*TypePage, error) {
+	typ, ok := g.registry.GetType(cti)
+	if !ok {
+		return nil, fmt.Errorf("type %s not found", cti)
+	}
+	return g.typePage(typ), nil
+}
+
+// Generate writes a page for each type of the entities, the index page and the search index to the directory.
+// Instances are documented on pages of their types. Types are arranged in the vendor and package navigation tree,
+// so entities of multiple packages may be documented together.
+func (g *Generator) Generate(dst string, entities metadata.Entities) error {
+	if len(entities) == 0 {
+		return errors.New("no entities to document")
+	}
+	tmpl, err := g.templates()
+	if err != nil {
+		return fmt.Errorf("parse templates: %w", err)
+	}
+	if err := os.MkdirAll(dst, os.ModePerm); err != nil {
+		return fmt.Errorf("create directory: %w", err)
+	}
+
+	g.documented = make(map[string]bool, len(entities))
+	for _, entity := range entities {
+		g.documented[entity.Cti] = true
+	}
+
+	index := &IndexPage{Title: g.title}
+	var pages []*TypePage
+	for _, entity := range sortedEntities(entities) {
+		if entity.IsInstance() {
+			if parent := metadata.GetParentCti(entity.Cti); !g.documented[parent] {
+				index.Instances = append(index.Instances, g.instanceDoc(entity))
+			}
+			continue
+		}
+		page := g.typePage(entity)
+		pages = append(pages, page)
+		index.Search = append(index.Search, searchEntries(page)...)
+	}
+	index.Vendors = navigation(pages)
+
+	for _, page := range pages {
+		page.Nav = index.Vendors
+		if err := g.render(tmpl, filepath.Join(dst, page.FileName), "type", page); err != nil {
+			return fmt.Errorf("render %s: %w", page.Cti, err)
+		}
+	}
+	for _, instance := range index.Instances {
+		index.Search = append(index.Search, SearchEntry{
+			ID:          instance.Cti,
+			Kind:        SearchKindInstance,
+			Title:       instance.Title,
+			Description: instance.Description,
+			URL:         g.indexName() + "#" + instance.Anchor,
+		})
+	}
+
+	if err := g.render(tmpl, filepath.Join(dst, g.indexName()), "index", index); err != nil {
+		return fmt.Errorf("render index: %w", err)
+	}
+
+	data, err := json.MarshalIndent(index.Search, "", "  ")
+	if err != nil {
+		return fmt.Errorf("serialize search index: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dst, SearchIndexFile), data, 0600); err != nil {
+		return fmt.Errorf("write search index: %w", err)
+	}
+	return nil
+}
+
+// navigation arranges the pages sorted by CTI into the vendor and package tree.
+func navigation(pages []*TypePage) []VendorDoc {
+	var vendors []VendorDoc
+	vendorIdx := make(map[string]int)
+	packageIdx := make(map[string]int)
+	for _, page := range pages {
+		vi, ok := vendorIdx[page.Vendor]
+		if !ok {
+			vi = len(vendors)
+			vendorIdx[page.Vendor] = vi
+			vendors = append(vendors, VendorDoc{Name: page.Vendor})
+		}
+		vendor := &vendors[vi]
+		pi, ok := packageIdx[page.Package]
+		if !ok {
+			pi = len(vendor.Packages)
+			packageIdx[page.Package] = pi
+			vendor.Packages = append(vendor.Packages, PackageDoc{Name: page.Package})
+		}
+		pkg := &vendor.Packages[pi]
+		pkg.Types = append(pkg.Types, TypeSummary{
+			Link:      Link{Cti: page.Cti, URL: page.FileName},
+			Title:     page.Title,
+			Instances: len(page.Instances),
+		})
+	}
+	sort.Slice(vendors, func(a, b int) bool {
+		return vendors[a].Name < vendors[b].Name
+	})
+	for _, vendor := range vendors {
+		sort.Slice(vendor.Packages, func(a, b int) bool {
+			return vendor.Packages[a].Name < vendor.Packages[b].Name
+		})
+	}
+	return vendors
+}
+
+func searchEntries(page *TypePage) []SearchEntry {
+	res := []SearchEntry{{
+		ID:          page.Cti,
+		Kind:        SearchKindType,
+		Title:       page.Title,
+		Description: page.Description,
+		URL:         page.FileName,
+	}}
+	for _, attr := range page.Attributes {
+		res = append(res, SearchEntry{
+			ID:          page.Cti + "@" + attr.Path,
+			Kind:        SearchKindAttribute,
+			Description: attr.Description,
+			URL:         page.FileName,
+		})
+	}
+	for _, instance := range page.Instances {
+		res = append(res, SearchEntry{
+			ID:          instance.Cti,
+			Kind:        SearchKindInstance,
+			Title:       instance.Title,
+			Description: instance.Description,
+			URL:         page.FileName + "#" + instance.Anchor,
+		})
+	}
+	return res
+}
+
+func (g *Generator) render(tmpl executor, fPath, name string, data any) error {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return fmt.Errorf("execute template: %w", err)
+	}
+	return os.WriteFile(fPath, buf.Bytes(), 0600)
+}
+
+func (g *Generator) templates() (executor, error) {
+	if g.format == FormatMarkdown {
+		return texttemplate.New("markdown").Funcs(texttemplate.FuncMap{
+			"cell": markdownCell,
+			"join": strings.Join,
+		}).ParseFS(templatesFS, "templates/*.md.tmpl")
+	}
+	return htmltemplate.New("html").Funcs(htmltemplate.FuncMap{
+		"join": strings.Join,
+	}).ParseFS(templatesFS, "templates/*.html.tmpl")
+}
+
+func (g *Generator) ext() string {
+	if g.format == FormatMarkdown {
+		return ".md"
+	}
+	return ".html"
+}
+
+func (g *Generator) pageName(cti string) string {
+	return cti + g.ext()
+}
+
+func (g *Generator) indexName() string {
+	return "index" + g.ext()
+}
+
+// markdownCell escapes the value to be used in a Markdown table cell.
+func markdownCell(s string) string {
+	s = strings.ReplaceAll(s, "|", `\|`)
+	return strings.ReplaceAll(s, "\n", "<br>")
+}
+
+func sortedEntities(entities metadata.Entities) metadata.Entities {
+	res := make(metadata.Entities, len(entities))
+	copy(res, entities)
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].Cti < res[b].Cti
+	})
+	return res
+}
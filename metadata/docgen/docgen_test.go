@@ -0,0 +1,201 @@
+package docgen
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func testRegistry(t *testing.T) *registry.Registry {
+	t.Helper()
+
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.x.y.event.v1.0": {
+			Cti: "cti.x.y.event.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Event",
+				"definitions": {
+					"Event": {
+						"type": "object",
+						"description": "Base event.",
+						"properties": {
+							"topic": {"type": "string"},
+							"tags": {"type": "array", "items": {"$ref": "#/definitions/Tag"}}
+						},
+						"required": ["topic"]
+					},
+					"Tag": {
+						"type": "object",
+						"properties": {"name": {"type": "string", "minLength": 1}}
+					}
+				}
+			}`),
+			Annotations: map[metadata.GJsonPath]metadata.Annotations{
+				".":      {Cti: "cti.x.y.event.v1.0"},
+				".topic": {Reference: "cti.x.y.topic.v1.0"},
+			},
+		},
+		"cti.x.y.event.v1.0~x.y.user_created.v1.0": {
+			Cti:         "cti.x.y.event.v1.0~x.y.user_created.v1.0",
+			DisplayName: "User created",
+			Final:       true,
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/UserCreated",
+				"definitions": {
+					"UserCreated": {
+						"type": "object",
+						"properties": {
+							"login": {"type": "string", "enum": ["admin", "user"]}
+						}
+					}
+				}
+			}`),
+			Annotations: map[metadata.GJsonPath]metadata.Annotations{
+				".": {Cti: "cti.x.y.event.v1.0~x.y.user_created.v1.0"},
+			},
+		},
+		"cti.x.y.topic.v1.0": {
+			Cti: "cti.x.y.topic.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Topic",
+				"definitions": {"Topic": {"type": "object", "properties": {"name": {"type": "string"}}}}
+			}`),
+		},
+		"cti.x.y.topic.v1.0~x.y.users.v1.0": {
+			Cti:         "cti.x.y.topic.v1.0~x.y.users.v1.0",
+			DisplayName: "Users",
+			Values:      []byte(`{"name": "users"}`),
+		},
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func Test_TypePage(t *testing.T) {
+	r := testRegistry(t)
+	g, err := New(r)
+	require.NoError(t, err)
+	g.documented = map[string]bool{"cti.x.y.event.v1.0": true, "cti.x.y.event.v1.0~x.y.user_created.v1.0": true}
+
+	typ, _ := r.Get("cti.x.y.event.v1.0~x.y.user_created.v1.0")
+	page := g.typePage(typ)
+
+	require.Equal(t, "User created", page.Title)
+	require.Equal(t, "Base event.", page.Description)
+	require.True(t, page.Final)
+	require.Equal(t, []Link{
+		{Cti: "cti.x.y.event.v1.0", URL: "cti.x.y.event.v1.0.html"},
+		{Cti: "cti.x.y.event.v1.0~x.y.user_created.v1.0", URL: "cti.x.y.event.v1.0~x.y.user_created.v1.0.html"},
+	}, page.Chain)
+	require.Equal(t, []Attribute{
+		{Path: "login", Type: "string", Constraints: []string{`enum: "admin", "user"`}},
+		{Path: "tags", Type: "array of Tag"},
+		{Path: "tags.#", Type: "object"},
+		{Path: "tags.#.name", Type: "string", Constraints: []string{"minLength: 1"}},
+		{
+			Path:        "topic",
+			Type:        "string",
+			Required:    true,
+			Annotations: []AnnotationDoc{{Name: metadata.Reference, Value: "cti.x.y.topic.v1.0", Link: &Link{Cti: "cti.x.y.topic.v1.0"}}},
+		},
+	}, page.Attributes)
+}
+
+func Test_Describe(t *testing.T) {
+	g, err := New(testRegistry(t))
+	require.NoError(t, err)
+
+	page, err := g.Describe("cti.x.y.event.v1.0~x.y.user_created.v1.0")
+	require.NoError(t, err)
+	require.Equal(t, []Link{
+		{Cti: "cti.x.y.event.v1.0"},
+		{Cti: "cti.x.y.event.v1.0~x.y.user_created.v1.0"},
+	}, page.Chain)
+	require.Len(t, page.Attributes, 5)
+
+	_, err = g.Describe("cti.x.y.topic.v1.0~x.y.users.v1.0")
+	require.ErrorContains(t, err, "type cti.x.y.topic.v1.0~x.y.users.v1.0 not found")
+}
+
+func Test_Generate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		format Format
+		index  string
+		page   string
+	}{
+		{name: "html", format: FormatHTML, index: "index.html", page: "cti.x.y.topic.v1.0.html"},
+		{name: "markdown", format: FormatMarkdown, index: "index.md", page: "cti.x.y.topic.v1.0.md"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			r := testRegistry(t)
+			g, err := New(r, WithFormat(tc.format), WithTitle("Sample"))
+			require.NoError(t, err)
+
+			dst := t.TempDir()
+			require.NoError(t, g.Generate(dst, r.Entities()))
+
+			index, err := os.ReadFile(filepath.Join(dst, tc.index))
+			require.NoError(t, err)
+			require.Contains(t, string(index), "Sample")
+			require.Contains(t, string(index), tc.page)
+
+			page, err := os.ReadFile(filepath.Join(dst, tc.page))
+			require.NoError(t, err)
+			require.Contains(t, string(page), "cti.x.y.topic.v1.0~x.y.users.v1.0")
+
+			data, err := os.ReadFile(filepath.Join(dst, SearchIndexFile))
+			require.NoError(t, err)
+			var entries []SearchEntry
+			require.NoError(t, json.Unmarshal(data, &entries))
+			require.Contains(t, entries, SearchEntry{
+				ID:    "cti.x.y.topic.v1.0~x.y.users.v1.0",
+				Kind:  SearchKindInstance,
+				Title: "Users",
+				URL:   tc.page + "#cti.x.y.topic.v1.0~x.y.users.v1.0",
+			})
+		})
+	}
+
+	t.Run("unsupported format", func(t *testing.T) {
+		_, err := New(testRegistry(t), WithFormat("pdf"))
+		require.ErrorContains(t, err, "unsupported format pdf")
+	})
+}
+
+func Test_Navigation(t *testing.T) {
+	pages := []*TypePage{
+		{Cti: "cti.b.p.event.v1.0", FileName: "cti.b.p.event.v1.0.html", Vendor: "b", Package: "b.p"},
+		{Cti: "cti.a.q.event.v1.0", FileName: "cti.a.q.event.v1.0.html", Vendor: "a", Package: "a.q", Title: "Event"},
+		{Cti: "cti.a.p.event.v1.0", FileName: "cti.a.p.event.v1.0.html", Vendor: "a", Package: "a.p", Instances: make([]InstanceDoc, 2)},
+		{Cti: "cti.a.p.topic.v1.0", FileName: "cti.a.p.topic.v1.0.html", Vendor: "a", Package: "a.p"},
+	}
+
+	require.Equal(t, []VendorDoc{
+		{Name: "a", Packages: []PackageDoc{
+			{Name: "a.p", Types: []TypeSummary{
+				{Link: Link{Cti: "cti.a.p.event.v1.0", URL: "cti.a.p.event.v1.0.html"}, Instances: 2},
+				{Link: Link{Cti: "cti.a.p.topic.v1.0", URL: "cti.a.p.topic.v1.0.html"}},
+			}},
+			{Name: "a.q", Types: []TypeSummary{
+				{Link: Link{Cti: "cti.a.q.event.v1.0", URL: "cti.a.q.event.v1.0.html"}, Title: "Event"},
+			}},
+		}},
+		{Name: "b", Packages: []PackageDoc{
+			{Name: "b.p", Types: []TypeSummary{
+				{Link: Link{Cti: "cti.b.p.event.v1.0", URL: "cti.b.p.event.v1.0.html"}},
+			}},
+		}},
+	}, navigation(pages))
+}
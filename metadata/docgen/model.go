@@ -0,0 +1,470 @@
+package docgen
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/merger"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+// Link is a cross-link to the documented entity. URL is empty if the entity is not documented.
+type Link struct {
+	Cti string
+	URL string
+}
+
+// TypePage describes a documentation page of a CTI type.
+type TypePage struct {
+	Cti         string
+	FileName    string
+	Vendor      string
+	Package     string
+	Title       string
+	Description string
+	Final       bool
+	// Chain is the inheritance chain from the root type to the type itself.
+	Chain       []Link
+	Children    []Link
+	Annotations []AnnotationDoc
+	Attributes  []Attribute
+	Instances   []InstanceDoc
+	// Nav is the navigation tree of all documented types.
+	Nav []VendorDoc
+}
+
+// Attribute is a flattened attribute of a type schema, e.g. "data.login" or "tags.#".
+type Attribute struct {
+	Path        string
+	Type        string
+	Required    bool
+	Description string
+	Constraints []string
+	Annotations []AnnotationDoc
+}
+
+// AnnotationDoc is a single value of the CTI annotation. Link is set if the value is a CTI of the documented entity.
+type AnnotationDoc struct {
+	Name  string
+	Value string
+	Link  *Link
+}
+
+// InstanceDoc describes a CTI instance listed on the page of its type.
+type InstanceDoc struct {
+	Cti         string
+	Anchor      string
+	Title       string
+	Description string
+	Values      string
+}
+
+// VendorDoc is a node of the navigation tree that holds documented packages of the vendor.
+type VendorDoc struct {
+	Name     string
+	Packages []PackageDoc
+}
+
+// PackageDoc lists documented types of the package, the key is in <vendor>.<package> form.
+type PackageDoc struct {
+	Name  string
+	Types []TypeSummary
+}
+
+// TypeSummary is an entry of the type on the index page.
+type TypeSummary struct {
+	Link
+	Title     string
+	Instances int
+}
+
+// IndexPage describes the documentation index.
+type IndexPage struct {
+	Title   string
+	Vendors []VendorDoc
+	// Instances are documented instances of types that are not documented, e.g. of types from dependencies.
+	Instances []InstanceDoc
+	Search    []SearchEntry
+}
+
+// SearchEntry is an entry of the search index.
+type SearchEntry struct {
+	ID          string `json:"id"`
+	Kind        string `json:"kind"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	URL         string `json:"url"`
+}
+
+const (
+	SearchKindType      = "type"
+	SearchKindInstance  = "instance"
+	SearchKindAttribute = "attribute"
+)
+
+func (g *Generator) typePage(typ *metadata.Entity) *TypePage {
+	schema := g.mergedSchema(typ)
+	vendor, pkg := metadata.GetVendorAndPackage(typ.Cti)
+
+	page := &TypePage{
+		Cti:         typ.Cti,
+		FileName:    g.pageName(typ.Cti),
+		Vendor:      vendor,
+		Package:     registry.PackageKey(vendor, pkg),
+		Title:       firstNonEmpty(typ.DisplayName, stringOf(schema["title"])),
+		Description: firstNonEmpty(typ.Description, stringOf(schema["description"])),
+		Final:       typ.Final,
+	}
+
+	ancestors := g.registry.Ancestors(typ.Cti)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		page.Chain = append(page.Chain, g.link(ancestors[i].Cti))
+	}
+	page.Chain = append(page.Chain, g.link(typ.Cti))
+
+	for _, child := range g.registry.Children(typ.Cti) {
+		if child.IsInstance() {
+			page.Instances = append(page.Instances, g.instanceDoc(child))
+			continue
+		}
+		page.Children = append(page.Children, g.link(child.Cti))
+	}
+
+	annotations := g.inheritedAnnotations(typ, ancestors)
+	page.Annotations = g.annotationDocs(annotations["."], true)
+
+	b := attributeBuilder{
+		gen:         g,
+		defs:        g.definitions(typ, ancestors),
+		annotations: annotations,
+		seen:        make(map[string]bool),
+	}
+	b.walk(schema, ".", false)
+	page.Attributes = b.attributes
+
+	return page
+}
+
+func (g *Generator) instanceDoc(instance *metadata.Entity) InstanceDoc {
+	doc := InstanceDoc{
+		Cti:         instance.Cti,
+		Anchor:      instance.Cti,
+		Title:       instance.DisplayName,
+		Description: instance.Description,
+	}
+	var values any
+	if err := json.Unmarshal(instance.Values, &values); err == nil {
+		if data, err := json.MarshalIndent(values, "", "  "); err == nil {
+			doc.Values = string(data)
+		}
+	}
+	return doc
+}
+
+// mergedSchema returns the type schema merged with schemas of all its ancestors.
+// The own schema of the type is used if some of the ancestors are not available.
+func (g *Generator) mergedSchema(typ *metadata.Entity) map[string]any {
+	schema, err := g.validator.GetMergedSchema(typ.Cti)
+	if err == nil {
+		return schema
+	}
+	slog.Warn("Failed to merge schema, ancestor attributes are not documented",
+		slog.String("cti", typ.Cti), slog.String("error", err.Error()))
+
+	var own map[string]any
+	if err := json.Unmarshal(typ.Schema, &own); err != nil {
+		return map[string]any{}
+	}
+	if schema, err = merger.ExtractSchemaDefinition(own); err != nil {
+		return map[string]any{}
+	}
+	return schema
+}
+
+// definitions collects schema definitions of the type and its ancestors to resolve local references.
+func (g *Generator) definitions(typ *metadata.Entity, ancestors metadata.Entities) map[string]any {
+	defs := make(map[string]any)
+	for _, entity := range append(metadata.Entities{typ}, ancestors...) {
+		var schema map[string]any
+		if err := json.Unmarshal(entity.Schema, &schema); err != nil {
+			continue
+		}
+		entityDefs, _ := schema["definitions"].(map[string]any)
+		for name, def := range entityDefs {
+			if _, ok := defs[name]; !ok {
+				defs[name] = def
+			}
+		}
+	}
+	return defs
+}
+
+// inheritedAnnotations returns annotations of the type attributes. Attributes of ancestors
+// keep annotations of the nearest ancestor that annotates them.
+func (g *Generator) inheritedAnnotations(typ *metadata.Entity, ancestors metadata.Entities) map[string]metadata.Annotations {
+	res := make(map[string]metadata.Annotations, len(typ.Annotations))
+	for _, entity := range append(metadata.Entities{typ}, ancestors...) {
+		for key, a := range entity.Annotations {
+			if _, ok := res[string(key)]; !ok {
+				res[string(key)] = a
+			}
+		}
+	}
+	if root, ok := typ.Annotations["."]; ok {
+		res["."] = root
+	} else {
+		delete(res, ".")
+	}
+	return res
+}
+
+// annotationDocs returns annotation values sorted by name. CTI of the type itself is omitted at the root.
+func (g *Generator) annotationDocs(a metadata.Annotations, root bool) []AnnotationDoc {
+	data, err := json.Marshal(a)
+	if err != nil {
+		return nil
+	}
+	var values map[string]any
+	if err := json.Unmarshal(data, &values); err != nil {
+		return nil
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		if root && name == metadata.Cti {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var res []AnnotationDoc
+	for _, name := range names {
+		switch val := values[name].(type) {
+		case []any:
+			for _, item := range val {
+				res = append(res, g.annotationDoc(name, item))
+			}
+		default:
+			res = append(res, g.annotationDoc(name, val))
+		}
+	}
+	return res
+}
+
+func (g *Generator) annotationDoc(name string, val any) AnnotationDoc {
+	doc := AnnotationDoc{Name: name}
+	if s, ok := val.(string); ok {
+		doc.Value = s
+		if _, ok := g.registry.Get(s); ok {
+			link := g.link(s)
+			doc.Link = &link
+		}
+		return doc
+	}
+	data, _ := json.Marshal(val)
+	doc.Value = string(data)
+	return doc
+}
+
+func (g *Generator) link(cti string) Link {
+	link := Link{Cti: cti}
+	entity, ok := g.registry.Get(cti)
+	if !ok {
+		return link
+	}
+	if entity.IsType() {
+		if g.documented[cti] {
+			link.URL = g.pageName(cti)
+		}
+		return link
+	}
+	if parent := metadata.GetParentCti(cti); g.documented[parent] {
+		link.URL = g.pageName(parent) + "#" + cti
+	} else if g.documented[cti] {
+		link.URL = g.indexName() + "#" + cti
+	}
+	return link
+}
+
+type attributeBuilder struct {
+	gen         *Generator
+	defs        map[string]any
+	annotations map[string]metadata.Annotations
+	// seen holds definitions on the current path to stop at recursive references.
+	seen       map[string]bool
+	attributes []Attribute
+}
+
+func (b *attributeBuilder) walk(schema map[string]any, key string, required bool) {
+	schema, ref := b.resolve(schema)
+	if ref != "" {
+		if b.seen[ref] {
+			return
+		}
+		b.seen[ref] = true
+		defer delete(b.seen, ref)
+	}
+
+	if key != "." {
+		a := b.annotations[key]
+		attr := Attribute{
+			Path:        strings.TrimPrefix(key, "."),
+			Type:        b.typeName(schema),
+			Required:    required,
+			Description: stringOf(schema["description"]),
+			Constraints: constraints(schema, a.ID != nil || a.Reference != nil),
+			Annotations: b.gen.annotationDocs(a, false),
+		}
+		b.attributes = append(b.attributes, attr)
+	}
+
+	prefix := key
+	if prefix != "." {
+		prefix += "."
+	}
+	if props, ok := schema["properties"].(map[string]any); ok {
+		requiredProps := requiredNames(schema["required"])
+		names := make([]string, 0, len(props))
+		for name := range props {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if prop, ok := props[name].(map[string]any); ok {
+				b.walk(prop, prefix+name, requiredProps[name])
+			}
+		}
+	}
+	if items, ok := schema["items"].(map[string]any); ok {
+		b.walk(items, prefix+"#", false)
+	}
+}
+
+// resolve follows local references of the schema and returns the referenced definition name.
+func (b *attributeBuilder) resolve(schema map[string]any) (map[string]any, string) {
+	ref, ok := schema["$ref"].(string)
+	if !ok {
+		return schema, ""
+	}
+	name := strings.TrimPrefix(ref, "#/definitions/")
+	def, ok := b.defs[name].(map[string]any)
+	if !ok {
+		return schema, ""
+	}
+	return def, name
+}
+
+func (b *attributeBuilder) typeName(schema map[string]any) string {
+	if members, ok := schema["anyOf"].([]any); ok {
+		names := make([]string, 0, len(members))
+		for _, m := range members {
+			if member, ok := m.(map[string]any); ok {
+				names = append(names, b.memberName(member))
+			}
+		}
+		return strings.Join(names, " | ")
+	}
+	switch t := schema["type"].(type) {
+	case string:
+		if t == "array" {
+			if items, ok := schema["items"].(map[string]any); ok {
+				return "array of " + b.memberName(items)
+			}
+		}
+		return t
+	case []any:
+		names := make([]string, 0, len(t))
+		for _, item := range t {
+			names = append(names, stringOf(item))
+		}
+		return strings.Join(names, " | ")
+	}
+	return "any"
+}
+
+// memberName returns the definition name of the referenced schema or its type name.
+func (b *attributeBuilder) memberName(schema map[string]any) string {
+	if def, name := b.resolve(schema); name != "" {
+		if b.seen[name] {
+			return name
+		}
+		b.seen[name] = true
+		defer delete(b.seen, name)
+		if t := b.typeName(def); t != "object" && t != "any" {
+			return t
+		}
+		return name
+	}
+	return b.typeName(schema)
+}
+
+var constraintKeys = []string{
+	"const", "enum", "format", "pattern", "minimum", "exclusiveMinimum", "maximum", "exclusiveMaximum", "multipleOf",
+	"minLength", "maxLength", "minItems", "maxItems", "uniqueItems", "minProperties", "maxProperties", "default",
+}
+
+// constraints returns human-readable facets of the schema. Patterns of CTI identifiers and references
+// are omitted since they are described by the annotations.
+func constraints(schema map[string]any, ctiValue bool) []string {
+	var res []string
+	for _, key := range constraintKeys {
+		val, ok := schema[key]
+		if !ok || (key == "pattern" && ctiValue) {
+			continue
+		}
+		switch v := val.(type) {
+		case string:
+			if key != "format" && key != "pattern" {
+				data, _ := json.Marshal(v)
+				res = append(res, fmt.Sprintf("%s: %s", key, data))
+				continue
+			}
+			res = append(res, fmt.Sprintf("%s: %s", key, v))
+		case []any:
+			items := make([]string, len(v))
+			for i, item := range v {
+				data, _ := json.Marshal(item)
+				items[i] = string(data)
+			}
+			res = append(res, fmt.Sprintf("%s: %s", key, strings.Join(items, ", ")))
+		default:
+			data, _ := json.Marshal(v)
+			res = append(res, fmt.Sprintf("%s: %s", key, data))
+		}
+	}
+	return res
+}
+
+// requiredNames returns a set of required properties. Merged schemas keep the list as []string.
+func requiredNames(val any) map[string]bool {
+	res := make(map[string]bool)
+	switch list := val.(type) {
+	case []string:
+		for _, name := range list {
+			res[name] = true
+		}
+	case []any:
+		for _, name := range list {
+			res[stringOf(name)] = true
+		}
+	}
+	return res
+}
+
+func stringOf(val any) string {
+	s, _ := val.(string)
+	return s
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
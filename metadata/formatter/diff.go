@@ -0,0 +1,33 @@
+package formatter
+
+import (
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// Diff returns the unified diff of the file and its formatted content with 3 lines of context.
+// The diff is empty if the contents are equal.
+func Diff(name string, src, formatted []byte) (string, error) {
+	return difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        splitLines(string(src)),
+		B:        splitLines(string(formatted)),
+		FromFile: "a/" + name,
+		ToFile:   "b/" + name,
+		Context:  3,
+	})
+}
+
+// splitLines splits the content into lines with line breaks. The last line of content
+// without the trailing line break gets one, so that it is not glued to the next line of the diff.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	lines := strings.SplitAfter(s, "\n")
+	if lines[len(lines)-1] == "" {
+		return lines[:len(lines)-1]
+	}
+	lines[len(lines)-1] += "\n"
+	return lines
+}
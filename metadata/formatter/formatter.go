@@ -0,0 +1,425 @@
+// Package formatter formats RAML files of CTI packages into the canonical style, so that diffs of entities
+// show changes of definitions rather than of formatting.
+//
+// The canonical style is:
+//   - keys of libraries and type declarations are ordered as in libraries generated by cti import,
+//     e.g. type, annotations, displayName, description, facets and properties; other keys keep their order;
+//   - libraries in uses are sorted by names, properties and annotations are sorted by names if configured;
+//   - mappings are indented by Style.Indent spaces and top-level sections are separated by blank lines;
+//   - strings are plain unless they must be quoted, multi-line strings are literal blocks;
+//   - anchors that are not referred to by aliases are removed;
+//   - keys of examples are sorted, examples holding JSON documents are formatted as JSON (see FormatJSON).
+//
+// Comments are preserved. Values of annotations are data and keep their order.
+package formatter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// Extension is an extension of formatted files.
+	Extension = ".raml"
+	// JSONExtension is an extension of serialized metadata files that are formatted by FormatJSON.
+	JSONExtension = ".json"
+)
+
+// QuoteStyle is a style of strings that cannot be written as plain scalars.
+type QuoteStyle string
+
+const (
+	QuoteDouble QuoteStyle = "double"
+	QuoteSingle QuoteStyle = "single"
+)
+
+// Style configures the canonical style.
+type Style struct {
+	// Indent is a number of spaces of indentation of nested mappings. Defaults to 2.
+	Indent int
+	// Quote is a style of strings that must be quoted. Defaults to QuoteDouble.
+	Quote QuoteStyle
+	// SortMembers sorts properties and facets of types by names.
+	SortMembers bool
+	// SortAnnotations sorts annotations of libraries and types by names.
+	SortAnnotations bool
+}
+
+// DefaultStyle returns the default canonical style.
+func DefaultStyle() Style {
+	return Style{Indent: 2, Quote: QuoteDouble}
+}
+
+// Check validates the style.
+func (s Style) Check() error {
+	if s.Indent < 2 || s.Indent > 8 {
+		return fmt.Errorf("indent must be between 2 and 8, got %d", s.Indent)
+	}
+	switch s.Quote {
+	case QuoteDouble, QuoteSingle:
+	default:
+		return fmt.Errorf("unknown quote style %q, allowed: %s, %s", s.Quote, QuoteDouble, QuoteSingle)
+	}
+	return nil
+}
+
+// Formatter formats documents in the style. It is safe for concurrent use.
+type Formatter struct {
+	style Style
+}
+
+// New creates a formatter. Zero fields of the style are set to defaults.
+func New(style Style) (*Formatter, error) {
+	def := DefaultStyle()
+	if style.Indent == 0 {
+		style.Indent = def.Indent
+	}
+	if style.Quote == "" {
+		style.Quote = def.Quote
+	}
+	if err := style.Check(); err != nil {
+		return nil, fmt.Errorf("invalid style: %w", err)
+	}
+	return &Formatter{style: style}, nil
+}
+
+// Format returns the RAML document in the canonical style.
+func (f *Formatter) Format(src []byte) ([]byte, error) {
+	header, body := splitHeader(src)
+
+	var doc yaml.Node
+	dec := yaml.NewDecoder(bytes.NewReader(body))
+	if err := dec.Decode(&doc); err != nil {
+		if errors.Is(err, io.EOF) {
+			return []byte(header + "\n"), nil
+		}
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	var extra yaml.Node
+	if err := dec.Decode(&extra); !errors.Is(err, io.EOF) {
+		return nil, errors.New("parse: multiple documents are not supported")
+	}
+
+	f.normalize(&doc)
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(f.style.Indent)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	if err := enc.Close(); err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+
+	var res strings.Builder
+	if header != "" {
+		res.WriteString(header)
+		res.WriteString("\n\n")
+	}
+	res.WriteString(separateSections(buf.String()))
+	return []byte(res.String()), nil
+}
+
+// FormatJSON formats the JSON document, e.g. serialized metadata: keys of objects are sorted, values
+// are indented by Style.Indent spaces and the document ends with a single line break. Numbers and strings
+// are kept as is, so the document is decoded into the same values.
+func (f *Formatter) FormatJSON(src []byte) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(src))
+	dec.UseNumber()
+	var v any
+	if err := dec.Decode(&v); err != nil {
+		return nil, fmt.Errorf("parse: %w", err)
+	}
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, errors.New("parse: unexpected data after the JSON document")
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", strings.Repeat(" ", f.style.Indent))
+	if err := enc.Encode(v); err != nil {
+		return nil, fmt.Errorf("encode: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// isJSONDocument reports whether the string holds a JSON object or array.
+func isJSONDocument(s string) bool {
+	s = strings.TrimSpace(s)
+	return (strings.HasPrefix(s, "{") || strings.HasPrefix(s, "[")) && json.Valid([]byte(s))
+}
+
+// splitHeader splits the RAML header, e.g. #%RAML 1.0 Library, from the document.
+// YAML parsers treat the header as a comment that would be moved or dropped. The header is replaced
+// with an empty line, so that lines of parse errors match lines of the file.
+func splitHeader(src []byte) (string, []byte) {
+	if !bytes.HasPrefix(src, []byte("#%RAML")) {
+		return "", src
+	}
+	line, rest, _ := bytes.Cut(src, []byte("\n"))
+	return strings.TrimRight(string(line), " \r"), append([]byte("\n"), rest...)
+}
+
+// separateSections inserts blank lines between top-level entries along with their head comments.
+func separateSections(s string) string {
+	lines := strings.SplitAfter(s, "\n")
+	var res strings.Builder
+	entries := 0
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		if line == "" || line == "\n" {
+			continue
+		}
+		if line[0] == ' ' || line[0] == '-' {
+			res.WriteString(line)
+			continue
+		}
+		// Head comments belong to the following entry.
+		j := i
+		for j < len(lines) && strings.HasPrefix(lines[j], "#") {
+			j++
+		}
+		if j < len(lines) && lines[j] != "" && lines[j][0] != ' ' {
+			if entries > 0 {
+				res.WriteString("\n")
+			}
+			entries++
+		}
+		for ; i < j; i++ {
+			res.WriteString(lines[i])
+		}
+		if i < len(lines) {
+			res.WriteString(lines[i])
+		}
+	}
+	return res.String()
+}
+
+func (f *Formatter) normalize(doc *yaml.Node) {
+	aliased := make(map[*yaml.Node]bool)
+	walk(doc, func(n *yaml.Node) {
+		if n.Kind == yaml.AliasNode {
+			aliased[n.Alias] = true
+		}
+	})
+	walk(doc, func(n *yaml.Node) {
+		if n.Anchor != "" && !aliased[n] {
+			n.Anchor = ""
+		}
+		if n.Kind == yaml.ScalarNode {
+			f.scalar(n)
+		}
+	})
+	if len(doc.Content) == 1 && doc.Content[0].Kind == yaml.MappingNode {
+		f.library(doc.Content[0])
+	}
+}
+
+// scalar sets the canonical style of the string scalar. Other scalars and scalars with custom tags,
+// e.g. !include, keep their style.
+func (f *Formatter) scalar(n *yaml.Node) {
+	if n.ShortTag() != "!!str" || n.Style&yaml.TaggedStyle != 0 {
+		return
+	}
+	switch {
+	case strings.Contains(n.Value, "\n"):
+		n.Style = yaml.LiteralStyle
+	case needsQuotes(n.Value):
+		if f.style.Quote == QuoteSingle {
+			n.Style = yaml.SingleQuotedStyle
+		} else {
+			n.Style = yaml.DoubleQuotedStyle
+		}
+	default:
+		n.Style = 0
+	}
+}
+
+// yaml11Booleans are plain scalars that are strings in YAML 1.2 but booleans for YAML 1.1 parsers.
+var yaml11Booleans = map[string]bool{"y": true, "yes": true, "n": true, "no": true, "on": true, "off": true}
+
+// needsQuotes reports whether the string cannot be written as a plain scalar, e.g. if it looks like
+// a number or starts with an indicator.
+func needsQuotes(s string) bool {
+	if yaml11Booleans[strings.ToLower(s)] {
+		return true
+	}
+	data, err := yaml.Marshal(&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s})
+	if err != nil {
+		return true
+	}
+	return len(data) != 0 && (data[0] == '"' || data[0] == '\'')
+}
+
+func walk(n *yaml.Node, fn func(*yaml.Node)) {
+	fn(n)
+	for _, c := range n.Content {
+		walk(c, fn)
+	}
+}
+
+// libraryOrder is the order of keys of libraries. Applications of annotations, e.g. instances,
+// are placed between declarations of annotation types and types.
+var libraryOrder = map[string]int{
+	"usage":           0,
+	"uses":            1,
+	"annotationTypes": 2,
+	"":                3, // annotations
+	"types":           4,
+}
+
+// typeOrder is the order of keys of type declarations. Other facets are placed after description.
+var typeOrder = map[string]int{
+	"type":                 0,
+	"":                     1, // annotations
+	"displayName":          2,
+	"description":          3,
+	"properties":           5,
+	"additionalProperties": 6,
+	"items":                6,
+	"discriminator":        7,
+	"discriminatorValue":   8,
+	"facets":               9,
+	"example":              10,
+	"examples":             10,
+}
+
+const (
+	otherLibraryKeys = 5
+	otherTypeKeys    = 4
+)
+
+func (f *Formatter) library(n *yaml.Node) {
+	sortKeys(n, f.byRank(libraryOrder, otherLibraryKeys))
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		switch n.Content[i].Value {
+		case "uses":
+			sortKeys(n.Content[i+1], byName)
+		case "types", "annotationTypes":
+			f.declarations(n.Content[i+1], false)
+		}
+	}
+}
+
+// declarations formats values of the mapping of type declarations. Members, i.e. properties and facets
+// of types, are sorted by names if configured.
+func (f *Formatter) declarations(n *yaml.Node, members bool) {
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+	if members && f.style.SortMembers {
+		sortKeys(n, byName)
+	}
+	for i := 1; i < len(n.Content); i += 2 {
+		f.typeDeclaration(n.Content[i])
+	}
+}
+
+func (f *Formatter) typeDeclaration(n *yaml.Node) {
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+	sortKeys(n, f.byRank(typeOrder, otherTypeKeys))
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		switch n.Content[i].Value {
+		case "properties", "facets":
+			f.declarations(n.Content[i+1], true)
+		case "items", "additionalProperties":
+			f.typeDeclaration(n.Content[i+1])
+		case "example", "examples":
+			f.example(n.Content[i+1])
+		}
+	}
+}
+
+// example normalizes the example value: keys of mappings are sorted, strings holding JSON documents
+// are formatted as JSON files. Included examples are not changed.
+func (f *Formatter) example(n *yaml.Node) {
+	switch n.Kind {
+	case yaml.MappingNode:
+		sortKeys(n, func(a, b *yaml.Node) bool {
+			return a.Value < b.Value
+		})
+		for i := 1; i < len(n.Content); i += 2 {
+			f.example(n.Content[i])
+		}
+	case yaml.SequenceNode:
+		for _, c := range n.Content {
+			f.example(c)
+		}
+	case yaml.ScalarNode:
+		if n.ShortTag() != "!!str" || n.Style&yaml.TaggedStyle != 0 || !isJSONDocument(n.Value) {
+			return
+		}
+		if res, err := f.FormatJSON([]byte(n.Value)); err == nil {
+			n.Value = string(res)
+			n.Style = yaml.LiteralStyle
+		}
+	}
+}
+
+// byRank orders keys by ranks. Annotations are sorted by names if configured, other keys of the same rank
+// keep their order.
+func (f *Formatter) byRank(order map[string]int, other int) func(a, b *yaml.Node) bool {
+	rank := func(key *yaml.Node) int {
+		if isAnnotation(key.Value) {
+			return order[""]
+		}
+		if r, ok := order[key.Value]; ok {
+			return r
+		}
+		return other
+	}
+	return func(a, b *yaml.Node) bool {
+		ra, rb := rank(a), rank(b)
+		if ra != rb {
+			return ra < rb
+		}
+		if f.style.SortAnnotations && isAnnotation(a.Value) {
+			return byName(a, b)
+		}
+		return false
+	}
+}
+
+// byName orders keys alphabetically by names of members, so that optional properties, e.g. name?,
+// are placed as required ones. Ties are broken by keys, equal keys keep their order.
+func byName(a, b *yaml.Node) bool {
+	na, nb := strings.TrimSuffix(a.Value, "?"), strings.TrimSuffix(b.Value, "?")
+	if na != nb {
+		return na < nb
+	}
+	return a.Value < b.Value
+}
+
+// sortKeys stably orders pairs of the mapping by keys.
+func sortKeys(n *yaml.Node, less func(a, b *yaml.Node) bool) {
+	if n.Kind != yaml.MappingNode {
+		return
+	}
+	pairs := make([][2]*yaml.Node, 0, len(n.Content)/2)
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		pairs = append(pairs, [2]*yaml.Node{n.Content[i], n.Content[i+1]})
+	}
+	sort.SliceStable(pairs, func(a, b int) bool {
+		return less(pairs[a][0], pairs[b][0])
+	})
+	n.Content = n.Content[:0]
+	for _, p := range pairs {
+		n.Content = append(n.Content, p[0], p[1])
+	}
+}
+
+// isAnnotation reports whether the key is an application of an annotation, e.g. (cti.cti).
+func isAnnotation(key string) bool {
+	return strings.HasPrefix(key, "(") && strings.HasSuffix(key, ")")
+}
@@ -0,0 +1,398 @@
+package formatter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Format(t *testing.T) {
+	testCases := []struct {
+		name     string
+		style    Style
+		src      string
+		expected string
+	}{
+		{
+			name: "library",
+			src: `#%RAML 1.0 Library
+types:
+    Event:
+        properties:
+            id: string
+        description: Base event.
+        (cti.cti): cti.x.y.event.v1.0
+        type: object
+uses:
+    cti: ../.ramlx/cti.raml
+`,
+			expected: `#%RAML 1.0 Library
+
+uses:
+  cti: ../.ramlx/cti.raml
+
+types:
+  Event:
+    type: object
+    (cti.cti): cti.x.y.event.v1.0
+    description: Base event.
+    properties:
+      id: string
+`,
+		},
+		{
+			name: "annotations and nested declarations",
+			src: `#%RAML 1.0 Library
+(Topics):
+- name: users
+  id: cti.x.y.topic.v1.0~x.y.users.v1.0
+annotationTypes:
+  Topics: Topic[]
+types:
+  Topic:
+    properties:
+      tags:
+        items:
+          properties:
+            name: string
+          type: object
+        type: array
+      name:
+        maxLength: 10
+        type: string
+        (cti.display_name): true
+`,
+			expected: `#%RAML 1.0 Library
+
+annotationTypes:
+  Topics: Topic[]
+
+(Topics):
+  - name: users
+    id: cti.x.y.topic.v1.0~x.y.users.v1.0
+
+types:
+  Topic:
+    properties:
+      tags:
+        type: array
+        items:
+          type: object
+          properties:
+            name: string
+      name:
+        type: string
+        (cti.display_name): true
+        maxLength: 10
+`,
+		},
+		{
+			name: "quotes",
+			src: `#%RAML 1.0 Library
+types:
+  Code:
+    type: string
+    pattern: '^\d+$'
+    default: '123'
+    enum: ['a', "b: c", 'yes']
+    description: "multi\nline"
+    example: !include example.json
+`,
+			expected: `#%RAML 1.0 Library
+
+types:
+  Code:
+    type: string
+    description: |-
+      multi
+      line
+    pattern: ^\d+$
+    default: "123"
+    enum: [a, "b: c", "yes"]
+    example: !include example.json
+`,
+		},
+		{
+			name:  "style",
+			style: Style{Indent: 4, Quote: QuoteSingle},
+			src: `#%RAML 1.0 Library
+types:
+  Code:
+    type: string
+    default: "123"
+`,
+			expected: `#%RAML 1.0 Library
+
+types:
+    Code:
+        type: string
+        default: '123'
+`,
+		},
+		{
+			name: "comments and anchors",
+			src: `#%RAML 1.0 Library
+# Types of events.
+types:
+  # Base type.
+  Base: &base
+    type: object # trailing
+  Other: &unused
+    type: Base
+  Copy: *base
+`,
+			expected: `#%RAML 1.0 Library
+
+# Types of events.
+types:
+  # Base type.
+  Base: &base
+    type: object # trailing
+  Other:
+    type: Base
+  Copy: *base
+`,
+		},
+		{
+			name: "uses are sorted",
+			src: `#%RAML 1.0 Library
+uses:
+  ev: events.raml
+  # Common types.
+  common: common.raml
+  cti: ../.ramlx/cti.raml
+types:
+  Topic:
+    (cti.final): false
+    (cti.cti): cti.x.y.topic.v1.0
+    properties:
+      name?: string
+      id: string
+`,
+			expected: `#%RAML 1.0 Library
+
+uses:
+  # Common types.
+  common: common.raml
+  cti: ../.ramlx/cti.raml
+  ev: events.raml
+
+types:
+  Topic:
+    (cti.final): false
+    (cti.cti): cti.x.y.topic.v1.0
+    properties:
+      name?: string
+      id: string
+`,
+		},
+		{
+			name:  "members and annotations are sorted",
+			style: Style{SortMembers: true, SortAnnotations: true},
+			src: `#%RAML 1.0 Library
+(Topics): []
+(Alerts): []
+types:
+  Topic:
+    (cti.final): false
+    (cti.cti): cti.x.y.topic.v1.0
+    facets:
+      z: string
+      a: string
+    properties:
+      name?: string
+      name: integer
+      id: string
+      data:
+        properties:
+          b: string
+          a: string
+`,
+			expected: `#%RAML 1.0 Library
+
+(Alerts): []
+
+(Topics): []
+
+types:
+  Topic:
+    (cti.cti): cti.x.y.topic.v1.0
+    (cti.final): false
+    properties:
+      data:
+        properties:
+          a: string
+          b: string
+      id: string
+      name: integer
+      name?: string
+    facets:
+      a: string
+      z: string
+`,
+		},
+		{
+			name: "examples",
+			src: `#%RAML 1.0 Library
+types:
+  Event:
+    type: object
+    example: '{"name": "created", "id": 1, "tags": ["a"]}'
+  Alert:
+    type: object
+    examples:
+      second:
+        severity: high
+        id: 2
+      first:
+        value: |
+          {"severity":"low","id":1.50}
+        description: Low alert.
+      included: !include examples/alert.json
+`,
+			expected: `#%RAML 1.0 Library
+
+types:
+  Event:
+    type: object
+    example: |
+      {
+        "id": 1,
+        "name": "created",
+        "tags": [
+          "a"
+        ]
+      }
+  Alert:
+    type: object
+    examples:
+      first:
+        description: Low alert.
+        value: |
+          {
+            "id": 1.50,
+            "severity": "low"
+          }
+      included: !include examples/alert.json
+      second:
+        id: 2
+        severity: high
+`,
+		},
+		{
+			name:     "empty",
+			src:      "#%RAML 1.0 Library\n",
+			expected: "#%RAML 1.0 Library\n",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f, err := New(tc.style)
+			require.NoError(t, err)
+
+			res, err := f.Format([]byte(tc.src))
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(res))
+
+			again, err := f.Format(res)
+			require.NoError(t, err)
+			require.Equal(t, string(res), string(again), "formatting is not idempotent")
+		})
+	}
+}
+
+func Test_FormatJSON(t *testing.T) {
+	f, err := New(Style{Indent: 4})
+	require.NoError(t, err)
+
+	res, err := f.FormatJSON([]byte(`[{"cti":"cti.x.y.event.v1.0","final":false,"values":{"b":"<a & b>","a":1e3}}]` + "\n\n"))
+	require.NoError(t, err)
+	require.Equal(t, `[
+    {
+        "cti": "cti.x.y.event.v1.0",
+        "final": false,
+        "values": {
+            "a": 1e3,
+            "b": "<a & b>"
+        }
+    }
+]
+`, string(res))
+
+	again, err := f.FormatJSON(res)
+	require.NoError(t, err)
+	require.Equal(t, string(res), string(again), "formatting is not idempotent")
+
+	_, err = f.FormatJSON([]byte(`{"a": 1} {"b": 2}`))
+	require.EqualError(t, err, "parse: unexpected data after the JSON document")
+
+	_, err = f.FormatJSON([]byte(`{"a": }`))
+	require.ErrorContains(t, err, "parse: invalid character")
+}
+
+func Test_FormatInvalid(t *testing.T) {
+	f, err := New(Style{})
+	require.NoError(t, err)
+
+	_, err = f.Format([]byte("#%RAML 1.0 Library\ntypes:\n  A: [\n"))
+	require.ErrorContains(t, err, "parse: yaml: line 3:")
+
+	_, err = f.Format([]byte("a: 1\n---\nb: 2\n"))
+	require.EqualError(t, err, "parse: multiple documents are not supported")
+}
+
+func Test_NewInvalidStyle(t *testing.T) {
+	_, err := New(Style{Indent: 1})
+	require.EqualError(t, err, "invalid style: indent must be between 2 and 8, got 1")
+
+	_, err = New(Style{Quote: "backtick"})
+	require.EqualError(t, err, `invalid style: unknown quote style "backtick", allowed: double, single`)
+}
+
+func Test_Diff(t *testing.T) {
+	diff, err := Diff("entities/events.raml",
+		[]byte("#%RAML 1.0 Library\ntypes:\n    Event:\n        type: object\n"),
+		[]byte("#%RAML 1.0 Library\n\ntypes:\n  Event:\n    type: object\n"))
+	require.NoError(t, err)
+	require.Equal(t, `--- a/entities/events.raml
++++ b/entities/events.raml
+@@ -1,4 +1,5 @@
+ #%RAML 1.0 Library
++
+ types:
+-    Event:
+-        type: object
++  Event:
++    type: object
+`, diff)
+
+	diff, err = Diff("events.raml", []byte("a\n"), []byte("a\n"))
+	require.NoError(t, err)
+	require.Empty(t, diff)
+}
+
+func Test_Verify(t *testing.T) {
+	f, err := New(Style{})
+	require.NoError(t, err)
+
+	res, err := f.Format([]byte("#%RAML 1.0 Library\ntypes:\n    Event: object\n"))
+	require.NoError(t, err)
+	require.NoError(t, Verify("events.raml", res, f.Format))
+
+	appendLine := func(src []byte) ([]byte, error) {
+		return append(src, "x\n"...), nil
+	}
+	err = Verify("events.raml", []byte("a\n"), appendLine)
+	var notIdempotent *NotIdempotentError
+	require.ErrorAs(t, err, &notIdempotent)
+	require.Equal(t, "events.raml", notIdempotent.Name)
+	require.Equal(t, `--- a/events.raml
++++ b/events.raml
+@@ -1 +1,2 @@
+ a
++x
+`, notIdempotent.Diff)
+
+	err = Verify("events.raml", []byte("a: ["), f.Format)
+	require.ErrorContains(t, err, "format formatted events.raml: parse:")
+}
@@ -0,0 +1,34 @@
+package formatter
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// NotIdempotentError is returned by Verify if formatting of the formatted content changes it, i.e. rules
+// of the formatter conflict. Diff holds changes made by the second formatting.
+type NotIdempotentError struct {
+	Name string
+	Diff string
+}
+
+func (e *NotIdempotentError) Error() string {
+	return fmt.Sprintf("formatting of %s is not idempotent:\n%s", e.Name, e.Diff)
+}
+
+// Verify formats the formatted content of the file again and fails with NotIdempotentError if it is changed.
+// The format function is the one that produced the content, e.g. Formatter.Format or Formatter.FormatJSON.
+func Verify(name string, formatted []byte, format func([]byte) ([]byte, error)) error {
+	again, err := format(formatted)
+	if err != nil {
+		return fmt.Errorf("format formatted %s: %w", name, err)
+	}
+	if bytes.Equal(formatted, again) {
+		return nil
+	}
+	diff, err := Diff(name, formatted, again)
+	if err != nil {
+		return fmt.Errorf("diff %s: %w", name, err)
+	}
+	return &NotIdempotentError{Name: name, Diff: diff}
+}
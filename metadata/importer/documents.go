@@ -0,0 +1,103 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+var errNotFound = errors.New("definition not found")
+
+// documentSet holds libraries loaded from source documents and resolves references between them.
+type documentSet struct {
+	libs   []*Library
+	byFile map[string]*Library
+	files  map[*Library]string
+	// byLocation holds definitions by the file name and JSON pointer, e.g. user.json#/definitions/Name.
+	byLocation map[string]*Definition
+}
+
+func newDocumentSet() *documentSet {
+	return &documentSet{
+		byFile:     make(map[string]*Library),
+		files:      make(map[*Library]string),
+		byLocation: make(map[string]*Definition),
+	}
+}
+
+// add puts the library loaded from the file into the set. Locations of definitions must start with the file name.
+func (s *documentSet) add(file string, lib *Library) error {
+	if _, ok := s.byFile[file]; ok {
+		return fmt.Errorf("duplicate file name %s", file)
+	}
+	if len(lib.Definitions) == 0 {
+		return errors.New("no definitions found")
+	}
+	s.byFile[file] = lib
+	s.files[lib] = file
+	for _, def := range lib.Definitions {
+		s.byLocation[def.Location] = def
+	}
+	s.libs = append(s.libs, lib)
+	return nil
+}
+
+// resolve supports local references (#/definitions/Name), references to roots of other documents (address.json)
+// and to their definitions (address.json#/definitions/Name).
+func (s *documentSet) resolve(from *Library, ref string) (*Library, *Definition, error) {
+	file, pointer, _ := strings.Cut(ref, "#")
+	if file == "" {
+		file = s.files[from]
+	}
+	lib, ok := s.byFile[filepath.Base(file)]
+	if !ok {
+		return nil, nil, fmt.Errorf("document %s is not imported", file)
+	}
+	def, ok := s.byLocation[filepath.Base(file)+"#"+strings.TrimSuffix(pointer, "/")]
+	if !ok {
+		return nil, nil, errNotFound
+	}
+	return lib, def, nil
+}
+
+// schemaFiles returns the file or files with one of the extensions from the directory sorted by name.
+func schemaFiles(path string, exts ...string) ([]string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return []string{path}, nil
+	}
+
+	var files []string
+	err = filepath.WalkDir(path, func(p string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != path && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		for _, ext := range exts {
+			if strings.HasSuffix(d.Name(), ext) {
+				files = append(files, p)
+				break
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("walk %s: %w", path, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no %s files found in %s", strings.Join(exts, ", "), path)
+	}
+	sort.Strings(files)
+	return files, nil
+}
@@ -0,0 +1,876 @@
+// Package importer converts type definitions of other formats into CTI types described in RAML.
+//
+// Definitions are converted through JSON Schema: importers of specific formats load definitions as JSON Schema
+// documents and resolve references between them, while the converter maps schemas onto RAML types
+// and generates identifiers of CTI types.
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+const (
+	// RamlExt is an extension of generated RAML libraries.
+	RamlExt = ".raml"
+
+	// DefaultVersion is a version of generated CTI types.
+	DefaultVersion = "v1.0"
+
+	ramlHeader = "#%RAML 1.0 Library\n"
+	ctiLibrary = "cti"
+
+	domainExtensionPrefix = "x-domainExt-"
+
+	// ctiMaxLength, ctiPattern and ctiWildcardPattern are facets of CTI and CTIWildcard types of the CTI library.
+	ctiMaxLength       = 1024
+	ctiPattern         = `^cti\.([a-z][a-z0-9_]*\.[a-z][a-z0-9_]*\.[a-z_][a-z0-9_.]*\.v[\d]+\.[\d]+)(~([a-z][a-z0-9_]*\.[a-z][a-z0-9_]*\.[a-z_][a-z0-9_.]*\.v[\d]+\.[\d]+))*(~[0-9a-f]{8}\b-[0-9a-f]{4}\b-[0-9a-f]{4}\b-[0-9a-f]{4}\b-[0-9a-f]{12})?$`
+	ctiWildcardPattern = `^cti((\.([a-z][a-z0-9_]*))|\.)?(\.([a-z][a-z0-9_]*))?(\.([a-z_][a-z0-9_.]*))?(\.v(\d+|\d*\.\d*|\d*\.)?)?(~(([a-z][a-z0-9_]*)|([a-z][a-z0-9_]*)\.)?(\.([a-z][a-z0-9_]*))?(\.([a-z_][a-z0-9_.]*))?(\.v(\d+|\d*\.\d*|\d*\.)?)?)*\*$|` + ctiPattern
+)
+
+// Definition is a named JSON Schema that is converted into a RAML type.
+type Definition struct {
+	Name   string
+	Schema map[string]any
+	// Entity tells whether the definition is converted into a CTI type. Definitions that are used
+	// as parents of CTI types are converted into CTI types as well.
+	Entity bool
+	// Cti is the identifier of the CTI type. It is generated from the name if empty.
+	Cti string
+	// TraitsSchema is the schema of traits of the CTI type and Traits are values of traits of its parent.
+	TraitsSchema map[string]any
+	Traits       any
+	// Location points to the definition in the source, e.g. user.json#/definitions/User.
+	Location string
+	// Notes are constructs of the source found by the importer that need manual attention.
+	// They are reported along with issues of the conversion.
+	Notes []string
+}
+
+// Library is a set of definitions written into a single RAML library.
+type Library struct {
+	// Name is a file name of the library without extension. It is also used as the library alias.
+	Name        string
+	Definitions []*Definition
+	Instances   []*Instance
+}
+
+// Instance is a CTI instance written into the library as an item of the annotation, e.g. (Topics).
+// Annotations of instances of the same type are declared as arrays of the type.
+type Instance struct {
+	Annotation string
+	Type       *Definition
+	Values     any
+}
+
+// RefResolver returns the definition referenced by $ref from the library.
+type RefResolver func(from *Library, ref string) (*Library, *Definition, error)
+
+// File is a generated RAML library.
+type File struct {
+	Name string
+	Data []byte
+}
+
+// Issue is a construct of the source that cannot be converted exactly and needs manual attention.
+type Issue struct {
+	Location string `json:"location"`
+	Message  string `json:"message"`
+}
+
+func (i Issue) String() string {
+	return i.Location + ": " + i.Message
+}
+
+type Importer struct {
+	vendor    string
+	pkg       string
+	ramlxPath string
+
+	issues []Issue
+}
+
+type Option func(*Importer) error
+
+// WithRamlxPath sets the path to the CTI RAML library relative to generated libraries.
+func WithRamlxPath(p string) Option {
+	return func(imp *Importer) error {
+		imp.ramlxPath = p
+		return nil
+	}
+}
+
+// New creates an importer that generates identifiers of CTI types in the package, e.g. x.y.
+func New(packageID string, opts ...Option) (*Importer, error) {
+	parts := strings.Split(packageID, ".")
+	if len(parts) != 2 || !isIdentifier(parts[0]) || !isIdentifier(parts[1]) {
+		return nil, fmt.Errorf("invalid package id %s, expected <vendor>.<package>", packageID)
+	}
+	imp := &Importer{
+		vendor:    parts[0],
+		pkg:       parts[1],
+		ramlxPath: "../.ramlx/cti.raml",
+	}
+	for _, opt := range opts {
+		if err := opt(imp); err != nil {
+			return nil, fmt.Errorf("apply option: %w", err)
+		}
+	}
+	return imp, nil
+}
+
+// PackageID returns the package of generated identifiers, e.g. x.y.
+func (imp *Importer) PackageID() string {
+	return imp.vendor + "." + imp.pkg
+}
+
+// Issues returns constructs found by the last conversion that need manual attention.
+func (imp *Importer) Issues() []Issue {
+	return imp.issues
+}
+
+// Convert converts the libraries into RAML. Each library is written into a separate file.
+func (imp *Importer) Convert(libs []*Library, resolve RefResolver) ([]File, error) {
+	imp.issues = nil
+	c := &conversion{
+		Importer: imp,
+		resolve:  resolve,
+		libs:     libs,
+		ids:      make(map[*Definition]string),
+		parents:  make(map[*Definition]*Definition),
+		derived:  make(map[*Definition]bool),
+		libOf:    make(map[*Definition]*Library),
+	}
+	for _, lib := range libs {
+		for _, def := range lib.Definitions {
+			c.libOf[def] = lib
+		}
+	}
+	if err := c.assignIdentifiers(); err != nil {
+		return nil, err
+	}
+
+	files := make([]File, 0, len(libs))
+	for _, lib := range libs {
+		data, err := c.library(lib)
+		if err != nil {
+			return nil, fmt.Errorf("convert %s: %w", lib.Name, err)
+		}
+		files = append(files, File{Name: lib.Name + RamlExt, Data: data})
+	}
+	return files, nil
+}
+
+type conversion struct {
+	*Importer
+	resolve RefResolver
+	libs    []*Library
+
+	ids     map[*Definition]string
+	parents map[*Definition]*Definition
+	// derived holds CTI types that have imported descendants.
+	derived map[*Definition]bool
+	libOf   map[*Definition]*Library
+}
+
+// assignIdentifiers finds parents of definitions, promotes parents of CTI types to CTI types and generates identifiers.
+func (c *conversion) assignIdentifiers() error {
+	for _, lib := range c.libs {
+		for _, def := range lib.Definitions {
+			if ref, _ := baseRef(def.Schema); ref != "" {
+				if _, parent, err := c.resolve(lib, ref); err == nil {
+					c.parents[def] = parent
+				}
+			}
+		}
+	}
+
+	var promote func(def *Definition, visiting map[*Definition]bool) error
+	promote = func(def *Definition, visiting map[*Definition]bool) error {
+		if visiting[def] {
+			return fmt.Errorf("%s: circular inheritance", def.Location)
+		}
+		visiting[def] = true
+		if parent, ok := c.parents[def]; ok {
+			parent.Entity = true
+			c.derived[parent] = true
+			return promote(parent, visiting)
+		}
+		return nil
+	}
+	for _, lib := range c.libs {
+		for _, def := range lib.Definitions {
+			if def.Entity {
+				if err := promote(def, make(map[*Definition]bool)); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	var identify func(def *Definition) string
+	identify = func(def *Definition) string {
+		if id, ok := c.ids[def]; ok {
+			return id
+		}
+		if def.Cti != "" {
+			c.ids[def] = def.Cti
+			return def.Cti
+		}
+		chunk := fmt.Sprintf("%s.%s.%s.%s", c.vendor, c.pkg, SnakeCase(def.Name), DefaultVersion)
+		id := "cti." + chunk
+		if parent, ok := c.parents[def]; ok {
+			id = identify(parent) + "~" + chunk
+		}
+		c.ids[def] = id
+		return id
+	}
+	owners := make(map[string]string)
+	for _, lib := range c.libs {
+		for _, def := range lib.Definitions {
+			if !def.Entity {
+				continue
+			}
+			id := identify(def)
+			if owner, ok := owners[id]; ok {
+				return fmt.Errorf("%s and %s have the same identifier %s, rename one of them", owner, def.Location, id)
+			}
+			owners[id] = def.Location
+		}
+	}
+	return nil
+}
+
+func (c *conversion) library(lib *Library) ([]byte, error) {
+	uses := &yaml.Node{Kind: yaml.MappingNode}
+	addPair(uses, ctiLibrary, str(c.ramlxPath))
+	usedLibs := make(map[string]bool)
+
+	types := &yaml.Node{Kind: yaml.MappingNode}
+	for _, def := range lib.Definitions {
+		t := &typeConversion{conversion: c, lib: lib, uses: usedLibs}
+		node := t.root(def)
+		key := str(def.Name)
+		for _, issue := range t.issues {
+			key.HeadComment += "TODO: " + issue.String() + "\n"
+		}
+		key.HeadComment = strings.TrimSuffix(key.HeadComment, "\n")
+		types.Content = append(types.Content, key, node)
+	}
+
+	annotationTypes, instances := c.instances(lib, usedLibs)
+
+	names := make([]string, 0, len(usedLibs))
+	for name := range usedLibs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		addPair(uses, name, str(name+RamlExt))
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString(ramlHeader)
+	for _, section := range []struct {
+		key  string
+		node *yaml.Node
+	}{{"uses", uses}, {"annotationTypes", annotationTypes}, {"", instances}, {"types", types}} {
+		if len(section.node.Content) == 0 {
+			continue
+		}
+		doc := section.node
+		if section.key != "" {
+			doc = &yaml.Node{Kind: yaml.MappingNode}
+			addPair(doc, section.key, section.node)
+		}
+		buf.WriteString("\n")
+		enc := yaml.NewEncoder(&buf)
+		enc.SetIndent(2)
+		if err := enc.Encode(doc); err != nil {
+			return nil, err
+		}
+		if err := enc.Close(); err != nil {
+			return nil, err
+		}
+	}
+	return buf.Bytes(), nil
+}
+
+// instances returns annotation types of instances of the library, e.g. Topics: Topic[], and annotations
+// that hold the instances.
+func (c *conversion) instances(lib *Library, usedLibs map[string]bool) (*yaml.Node, *yaml.Node) {
+	annotationTypes := &yaml.Node{Kind: yaml.MappingNode}
+	annotations := &yaml.Node{Kind: yaml.MappingNode}
+	items := make(map[string]*yaml.Node)
+	for _, instance := range lib.Instances {
+		list, ok := items[instance.Annotation]
+		if !ok {
+			typeName := instance.Type.Name
+			if typeLib := c.libOf[instance.Type]; typeLib != lib {
+				usedLibs[typeLib.Name] = true
+				typeName = typeLib.Name + "." + typeName
+			}
+			addPair(annotationTypes, instance.Annotation, str(typeName+"[]"))
+			list = &yaml.Node{Kind: yaml.SequenceNode}
+			addPair(annotations, "("+instance.Annotation+")", list)
+			items[instance.Annotation] = list
+		}
+		list.Content = append(list.Content, value(instance.Values))
+	}
+	return annotationTypes, annotations
+}
+
+// typeConversion converts a single definition and collects issues found in it.
+type typeConversion struct {
+	*conversion
+	lib    *Library
+	uses   map[string]bool
+	issues []Issue
+}
+
+func (t *typeConversion) report(location, format string, args ...any) {
+	issue := Issue{Location: location, Message: fmt.Sprintf(format, args...)}
+	t.issues = append(t.issues, issue)
+	t.Importer.issues = append(t.Importer.issues, issue)
+}
+
+func (t *typeConversion) root(def *Definition) *yaml.Node {
+	schema := def.Schema
+	if title, ok := schema["title"].(string); ok && TypeName(title) == def.Name && def.Cti == "" {
+		// Title is used as the type name.
+		schema = withoutKey(schema, "title")
+	}
+	if ref, rest := baseRef(schema); ref != "" {
+		merged := map[string]any{"$ref": ref}
+		for _, part := range rest {
+			for k, v := range part {
+				if k == "type" && v == "object" {
+					// Extensions of the parent are objects already.
+					continue
+				}
+				merged[k] = v
+			}
+		}
+		schema = merged
+	}
+
+	var header []*yaml.Node
+	// Annotations of types are kept in extensions of schemas converted from CTI metadata.
+	if def.Entity && !hasExtension(schema, metadata.Cti) {
+		header = append(header, str("("+metadata.Cti+")"), str(t.ids[def]))
+		if t.derived[def] && !hasExtension(schema, metadata.Final) {
+			header = append(header, str("("+metadata.Final+")"), &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: "false"})
+		}
+	}
+	for _, note := range def.Notes {
+		t.report(def.Location, "%s", note)
+	}
+	node := t.convert(schema, def.Location)
+	if node.Kind == yaml.ScalarNode {
+		node = &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{str("type"), node}}
+	}
+	node.Content = append(header, node.Content...)
+	if value, ok := t.discriminatorValue(def, schema); ok {
+		addPair(node, "discriminatorValue", str(value))
+	}
+	if def.TraitsSchema != nil {
+		facets := &yaml.Node{Kind: yaml.MappingNode}
+		addPair(facets, metadata.Traits, t.convert(def.TraitsSchema, def.Location+"/traits"))
+		addPair(node, "facets", facets)
+	}
+	if def.Traits != nil {
+		addPair(node, metadata.Traits, value(def.Traits))
+	}
+	return node
+}
+
+// discriminatorValue returns the value of the parent discriminator that selects the definition.
+// The value is taken from the discriminator mapping or defaults to the name of the definition in the source.
+func (t *typeConversion) discriminatorValue(def *Definition, schema map[string]any) (string, bool) {
+	if custom, ok := schema[metadata.CustomKey].(map[string]any); ok {
+		if value, ok := custom[metadata.DiscriminatorValueKey].(string); ok {
+			return value, true
+		}
+	}
+	parent, ok := t.parents[def]
+	if !ok {
+		return "", false
+	}
+	discriminator, ok := parent.Schema["discriminator"].(map[string]any)
+	if !ok {
+		return "", false
+	}
+	mapping, _ := discriminator["mapping"].(map[string]any)
+	for value, ref := range mapping {
+		refStr, _ := ref.(string)
+		if _, target, err := t.resolve(t.lib, refStr); err == nil && target == def {
+			return value, true
+		}
+	}
+	return def.Location[strings.LastIndex(def.Location, "/")+1:], true
+}
+
+// baseRef returns the reference to the parent of the schema: either $ref with sibling keywords
+// or the only reference in allOf. Other members of allOf are returned as the rest.
+func baseRef(schema map[string]any) (string, []map[string]any) {
+	if ref, ok := schema["$ref"].(string); ok {
+		rest := make(map[string]any, len(schema))
+		for k, v := range schema {
+			if k != "$ref" {
+				rest[k] = v
+			}
+		}
+		return ref, []map[string]any{rest}
+	}
+	allOf, ok := schema["allOf"].([]any)
+	if !ok {
+		return "", nil
+	}
+	var ref string
+	rest := []map[string]any{make(map[string]any)}
+	for k, v := range schema {
+		if k != "allOf" {
+			rest[0][k] = v
+		}
+	}
+	for _, item := range allOf {
+		member, ok := item.(map[string]any)
+		if !ok {
+			return "", nil
+		}
+		if r, ok := member["$ref"].(string); ok && len(member) == 1 {
+			if ref != "" {
+				return "", nil
+			}
+			ref = r
+			continue
+		}
+		rest = append(rest, member)
+	}
+	return ref, rest
+}
+
+var ignoredKeywords = map[string]bool{
+	"$schema": true, "$id": true, "id": true, "$comment": true, "definitions": true, "$defs": true,
+	"readOnly": true, "writeOnly": true, "deprecated": true, "examples": true, "example": true,
+	"xml": true, "externalDocs": true,
+}
+
+// numberFormats are formats of numeric types supported by RAML.
+var numberFormats = map[string]bool{
+	"int": true, "int8": true, "int16": true, "int32": true, "int64": true, "long": true, "float": true, "double": true,
+}
+
+// stringFormatPatterns are patterns that preserve string formats that have no RAML equivalent.
+var stringFormatPatterns = map[string]string{
+	"uuid": "^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$",
+}
+
+// convert returns the RAML type declaration of the schema. Scalar node is returned for type expressions.
+func (t *typeConversion) convert(schema map[string]any, location string) *yaml.Node {
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	handled := make(map[string]bool)
+	use := func(keys ...string) {
+		for _, k := range keys {
+			handled[k] = true
+		}
+	}
+
+	typeExpr := t.typeExpression(schema, location, use)
+	if nullable, _ := schema["nullable"].(bool); nullable && typeExpr != "" {
+		typeExpr += " | nil"
+	}
+	use("nullable")
+	if typeExpr != "" {
+		addPair(node, "type", str(typeExpr))
+	}
+	t.extensions(node, schema, location)
+	if format, ok := schema["format"].(string); ok && !handled["format"] {
+		use("format")
+		switch {
+		case (typeExpr == "integer" || typeExpr == "number") && numberFormats[format]:
+			addPair(node, "format", str(format))
+		case typeExpr == "string" && stringFormatPatterns[format] != "" && schema["pattern"] == nil:
+			addPair(node, "pattern", str(stringFormatPatterns[format]))
+		default:
+			t.report(location, "format %s has no RAML equivalent and was dropped", format)
+		}
+	}
+	if custom, ok := schema[metadata.CustomKey].(map[string]any); ok {
+		if name, ok := custom[metadata.DiscriminatorKey].(string); ok {
+			addPair(node, "discriminator", str(name))
+		}
+	}
+	if discriminator, ok := schema["discriminator"].(map[string]any); ok {
+		use("discriminator")
+		if name, ok := discriminator["propertyName"].(string); ok {
+			addPair(node, "discriminator", str(name))
+		}
+	}
+	if title, ok := schema["title"].(string); ok {
+		addPair(node, "displayName", str(title))
+		use("title")
+	}
+	if description, ok := schema["description"].(string); ok {
+		addPair(node, "description", str(description))
+		use("description")
+	}
+
+	for _, key := range []string{"pattern", "minLength", "maxLength", "minimum", "maximum", "multipleOf",
+		"minItems", "maxItems", "uniqueItems", "minProperties", "maxProperties"} {
+		if v, ok := schema[key]; ok && !handled[key] {
+			addPair(node, key, value(v))
+			use(key)
+		}
+	}
+	for _, key := range []string{"exclusiveMinimum", "exclusiveMaximum"} {
+		if _, ok := schema[key]; ok {
+			t.report(location, "%s has no RAML equivalent and was dropped", key)
+			use(key)
+		}
+	}
+	if enum, ok := schema["enum"].([]any); ok {
+		addPair(node, "enum", flow(value(enum)))
+		use("enum")
+	} else if c, ok := schema["const"]; ok {
+		addPair(node, "enum", flow(value([]any{c})))
+		use("const")
+	}
+	if v, ok := schema["default"]; ok {
+		addPair(node, "default", value(v))
+		use("default")
+	}
+	if examples, ok := schema["examples"].([]any); ok && len(examples) > 0 {
+		addPair(node, "example", value(examples[0]))
+	} else if example, ok := schema["example"]; ok {
+		addPair(node, "example", value(example))
+	}
+
+	if props := t.properties(schema, location, use); props != nil {
+		addPair(node, "properties", props)
+	}
+	if items, ok := schema["items"]; ok {
+		use("items")
+		if itemSchema, ok := items.(map[string]any); ok {
+			addPair(node, "items", t.convert(itemSchema, location+"/items"))
+		} else {
+			t.report(location, "tuple items are not supported by RAML, items were dropped")
+		}
+	}
+	if additional, ok := schema["additionalProperties"]; ok {
+		use("additionalProperties")
+		if b, ok := additional.(bool); ok {
+			addPair(node, "additionalProperties", value(b))
+		}
+	}
+
+	keys := make([]string, 0, len(schema))
+	for k := range schema {
+		if !handled[k] && !ignoredKeywords[k] && !strings.HasPrefix(k, "x-") {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		t.report(location, "keyword %s is not supported and was dropped", k)
+	}
+
+	// Shorthand declarations of types of the CTI library would inherit their descriptions.
+	if len(node.Content) == 2 && typeExpr != "" && !strings.HasPrefix(typeExpr, ctiLibrary+".") {
+		return node.Content[1]
+	}
+	return node
+}
+
+// typeExpression returns the RAML type expression of the schema, e.g. string, Address, common.Address or A | B.
+func (t *typeConversion) typeExpression(schema map[string]any, location string, use func(...string)) string {
+	if ref, ok := schema["$ref"].(string); ok {
+		use("$ref")
+		return t.refName(ref, location)
+	}
+	if ref, rest := baseRef(schema); ref != "" {
+		use("allOf")
+		for _, part := range rest[1:] {
+			// Members of allOf are merged into the type declaration.
+			for k, v := range part {
+				if _, ok := schema[k]; !ok {
+					schema[k] = v
+				}
+			}
+		}
+		return t.refName(ref, location)
+	}
+	if _, ok := schema["allOf"]; ok {
+		use("allOf")
+		t.report(location, "allOf with multiple references is not supported, merge the schemas manually")
+		return "any"
+	}
+	for _, key := range []string{"oneOf", "anyOf"} {
+		members, ok := schema[key].([]any)
+		if !ok {
+			continue
+		}
+		use(key)
+		names := make([]string, 0, len(members))
+		for i, m := range members {
+			member, _ := m.(map[string]any)
+			node := t.convert(member, fmt.Sprintf("%s/%s/%d", location, key, i))
+			if node.Kind != yaml.ScalarNode {
+				t.report(location, "%s with inline object schemas is not supported, declare members as named types", key)
+				return "any"
+			}
+			names = append(names, node.Value)
+		}
+		if key == "oneOf" {
+			t.report(location, "oneOf is converted into union that allows values matching multiple members")
+		}
+		return strings.Join(names, " | ")
+	}
+
+	switch typ := schema["type"].(type) {
+	case string:
+		use("type")
+		return t.scalarType(typ, schema, location, use)
+	case []any:
+		use("type")
+		names := make([]string, 0, len(typ))
+		for _, item := range typ {
+			s, _ := item.(string)
+			names = append(names, t.scalarType(s, schema, location, use))
+		}
+		return strings.Join(names, " | ")
+	}
+	if _, ok := schema["properties"]; ok {
+		return "object"
+	}
+	if _, ok := schema["enum"]; ok {
+		return ""
+	}
+	return "any"
+}
+
+var stringFormats = map[string]string{
+	"date-time": "datetime",
+	"date":      "date-only",
+	"time":      "time-only",
+}
+
+func (t *typeConversion) scalarType(typ string, schema map[string]any, location string, use func(...string)) string {
+	format, _ := schema["format"].(string)
+	switch typ {
+	case "string":
+		if ramlType, ok := stringFormats[format]; ok {
+			use("format")
+			return ramlType
+		}
+		if maxLength, _ := schema["maxLength"].(float64); maxLength == ctiMaxLength {
+			switch schema["pattern"] {
+			case ctiPattern:
+				use("pattern", "maxLength")
+				return ctiLibrary + ".CTI"
+			case ctiWildcardPattern:
+				use("pattern", "maxLength")
+				return ctiLibrary + ".CTIWildcard"
+			}
+		}
+		return "string"
+	case "null":
+		return "nil"
+	case "integer", "number", "boolean", "object", "array":
+		return typ
+	default:
+		t.report(location, "unknown type %s", typ)
+		return "any"
+	}
+}
+
+func (t *typeConversion) refName(ref, location string) string {
+	lib, def, err := t.resolve(t.lib, ref)
+	if err != nil {
+		t.report(location, "unresolved reference %s: %s", ref, err.Error())
+		return "any"
+	}
+	if lib == t.lib {
+		return def.Name
+	}
+	t.uses[lib.Name] = true
+	return lib.Name + "." + def.Name
+}
+
+func (t *typeConversion) properties(schema map[string]any, location string, use func(...string)) *yaml.Node {
+	props, hasProps := schema["properties"].(map[string]any)
+	patterns, hasPatterns := schema["patternProperties"].(map[string]any)
+	additional, hasAdditional := schema["additionalProperties"].(map[string]any)
+	if !hasProps && !hasPatterns && !hasAdditional {
+		return nil
+	}
+	use("properties", "required", "patternProperties")
+
+	required := make(map[string]bool)
+	if list, ok := schema["required"].([]any); ok {
+		for _, name := range list {
+			if s, ok := name.(string); ok {
+				required[s] = true
+			}
+		}
+	}
+
+	node := &yaml.Node{Kind: yaml.MappingNode}
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		prop, _ := props[name].(map[string]any)
+		key := name
+		if !required[name] {
+			key += "?"
+		}
+		addPair(node, key, t.convert(prop, location+"/properties/"+name))
+	}
+
+	patternNames := make([]string, 0, len(patterns))
+	for pattern := range patterns {
+		patternNames = append(patternNames, pattern)
+	}
+	sort.Strings(patternNames)
+	for _, pattern := range patternNames {
+		prop, _ := patterns[pattern].(map[string]any)
+		addPair(node, "/"+pattern+"/", t.convert(prop, location+"/patternProperties/"+pattern))
+	}
+	if hasAdditional {
+		use("additionalProperties")
+		addPair(node, "//", t.convert(additional, location+"/additionalProperties"))
+	}
+	return node
+}
+
+// SnakeCase converts the type name into the entity name of CTI, e.g. UserCreated into user_created.
+func SnakeCase(name string) string {
+	var b strings.Builder
+	runes := []rune(name)
+	for i, r := range runes {
+		switch {
+		case unicode.IsUpper(r):
+			if i > 0 && (unicode.IsLower(runes[i-1]) || unicode.IsDigit(runes[i-1]) ||
+				(i+1 < len(runes) && unicode.IsLower(runes[i+1]) && unicode.IsUpper(runes[i-1]))) {
+				b.WriteRune('_')
+			}
+			b.WriteRune(unicode.ToLower(r))
+		case unicode.IsLower(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	s := strings.Trim(b.String(), "_")
+	for strings.Contains(s, "__") {
+		s = strings.ReplaceAll(s, "__", "_")
+	}
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "_" + s
+	}
+	return s
+}
+
+// TypeName converts the name of the source definition into the RAML type name, e.g. user-created into UserCreated.
+func TypeName(name string) string {
+	var b strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	s := b.String()
+	if s == "" || unicode.IsDigit(rune(s[0])) {
+		s = "T" + s
+	}
+	return s
+}
+
+// extensions adds annotations kept in extensions of the schema, e.g. (cti.reference).
+func (t *typeConversion) extensions(node *yaml.Node, schema map[string]any, location string) {
+	custom, ok := schema[metadata.CustomKey].(map[string]any)
+	if !ok {
+		return
+	}
+	keys := make([]string, 0, len(custom))
+	for k := range custom {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		switch {
+		case strings.HasPrefix(k, domainExtensionPrefix):
+			addPair(node, "("+strings.TrimPrefix(k, domainExtensionPrefix)+")", value(custom[k]))
+		case k == metadata.DiscriminatorKey || k == metadata.DiscriminatorValueKey:
+		default:
+			t.report(location, "extension %s is not supported and was dropped", k)
+		}
+	}
+}
+
+// hasExtension tells whether the schema holds the annotation in its extensions.
+func hasExtension(schema map[string]any, name string) bool {
+	custom, _ := schema[metadata.CustomKey].(map[string]any)
+	_, ok := custom[domainExtensionPrefix+name]
+	return ok
+}
+
+func isIdentifier(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, r := range s {
+		if !(r >= 'a' && r <= 'z' || r == '_' || i > 0 && r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+func withoutKey(m map[string]any, key string) map[string]any {
+	res := make(map[string]any, len(m))
+	for k, v := range m {
+		if k != key {
+			res[k] = v
+		}
+	}
+	return res
+}
+
+func str(s string) *yaml.Node {
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: s}
+}
+
+func value(v any) *yaml.Node {
+	node := &yaml.Node{}
+	if err := node.Encode(v); err != nil {
+		return str(fmt.Sprint(v))
+	}
+	return node
+}
+
+func flow(node *yaml.Node) *yaml.Node {
+	node.Style = yaml.FlowStyle
+	return node
+}
+
+func addPair(node *yaml.Node, key string, val *yaml.Node) {
+	node.Content = append(node.Content, str(key), val)
+}
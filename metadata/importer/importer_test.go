@@ -0,0 +1,400 @@
+package importer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+func Test_ImportJSONSchema(t *testing.T) {
+	imp, err := New("x.y")
+	require.NoError(t, err)
+
+	files, err := imp.ImportJSONSchema(filepath.Join("testdata", "jsonschema"))
+	require.NoError(t, err)
+	require.Len(t, files, 3)
+	require.Equal(t, "user_created.raml", files[2].Name)
+	require.Equal(t, `#%RAML 1.0 Library
+
+uses:
+  cti: ../.ramlx/cti.raml
+  common: common.raml
+  event: event.raml
+
+types:
+  # TODO: user_created.json#/properties/cond: keyword not is not supported and was dropped
+  # TODO: user_created.json#/properties/kind: oneOf is converted into union that allows values matching multiple members
+  # TODO: user_created.json#/properties/score: exclusiveMinimum has no RAML equivalent and was dropped
+  UserCreated:
+    (cti.cti): cti.x.y.event.v1.0~x.y.user_created.v1.0
+    type: event.Event
+    properties:
+      address?: common.Address
+      cond?: any
+      kind?: string | integer
+      login:
+        type: string
+        minLength: 3
+      score?: number
+`, string(files[2].Data))
+
+	require.Equal(t, []Issue{
+		{Location: "event.json#/properties/contact", Message: "format email has no RAML equivalent and was dropped"},
+		{Location: "user_created.json#/properties/cond", Message: "keyword not is not supported and was dropped"},
+		{Location: "user_created.json#/properties/kind", Message: "oneOf is converted into union that allows values matching multiple members"},
+		{Location: "user_created.json#/properties/score", Message: "exclusiveMinimum has no RAML equivalent and was dropped"},
+	}, imp.Issues())
+}
+
+func Test_ImportJSONSchemaPromotesParents(t *testing.T) {
+	imp, err := New("x.y", WithRamlxPath(".ramlx/cti.raml"))
+	require.NoError(t, err)
+
+	files, err := imp.ImportJSONSchema(filepath.Join("testdata", "alert.json"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, `#%RAML 1.0 Library
+
+uses:
+  cti: .ramlx/cti.raml
+
+types:
+  DiskAlert:
+    (cti.cti): cti.x.y.alert.v1.0~x.y.disk_alert.v1.0
+    type: Alert
+    properties:
+      disk?: string
+  Alert:
+    (cti.cti): cti.x.y.alert.v1.0
+    (cti.final): false
+    type: object
+    properties:
+      threshold:
+        type: integer
+        minimum: 0
+        maximum: 100
+`, string(files[0].Data))
+	require.Empty(t, imp.Issues())
+}
+
+func Test_New(t *testing.T) {
+	for _, id := range []string{"", "x", "x.y.z", "X.y", "x.1y"} {
+		_, err := New(id)
+		require.ErrorContains(t, err, "invalid package id", id)
+	}
+}
+
+func Test_SnakeCase(t *testing.T) {
+	testCases := map[string]string{
+		"UserCreated": "user_created",
+		"HTTPServer":  "http_server",
+		"userID":      "user_id",
+		"user-event":  "user_event",
+		"V2Event":     "v2_event",
+		"2fa":         "_2fa",
+	}
+	for name, expected := range testCases {
+		require.Equal(t, expected, SnakeCase(name), name)
+	}
+}
+
+func Test_ImportOpenAPI(t *testing.T) {
+	imp, err := New("x.y")
+	require.NoError(t, err)
+
+	files, err := imp.ImportOpenAPI(filepath.Join("testdata", "openapi"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	require.Equal(t, "petstore.raml", files[0].Name)
+	require.Equal(t, `#%RAML 1.0 Library
+
+uses:
+  cti: ../.ramlx/cti.raml
+
+types:
+  Cat:
+    (cti.cti): cti.x.y.pet.v1.0~x.y.cat.v1.0
+    type: Pet
+    properties:
+      indoor?: boolean
+    discriminatorValue: kitty
+  # TODO: petstore.yaml#/components/schemas/Dog/properties/email: format email has no RAML equivalent and was dropped
+  Dog:
+    (cti.cti): cti.x.y.pet.v1.0~x.y.dog.v1.0
+    type: Pet
+    properties:
+      chipId?:
+        type: string
+        pattern: ^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$
+      email?: string
+    discriminatorValue: Dog
+  Pet:
+    (cti.cti): cti.x.y.pet.v1.0
+    (cti.final): false
+    type: object
+    discriminator: petType
+    description: A pet of the store.
+    properties:
+      id:
+        type: integer
+        format: int64
+      name?: string | nil
+      petType: string
+`, string(files[0].Data))
+	require.Equal(t, []Issue{
+		{Location: "petstore.yaml#/components/schemas/Dog/properties/email", Message: "format email has no RAML equivalent and was dropped"},
+	}, imp.Issues())
+}
+
+func Test_ImportOpenAPIInvalid(t *testing.T) {
+	imp, err := New("x.y")
+	require.NoError(t, err)
+
+	_, err = imp.ImportOpenAPI(filepath.Join("testdata", "alert.json"))
+	require.ErrorContains(t, err, "not an OpenAPI 3.x document")
+}
+
+func Test_ImportProto(t *testing.T) {
+	imp, err := New("x.y")
+	require.NoError(t, err)
+
+	files, err := imp.ImportProto(filepath.Join("testdata", "proto"))
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "common.raml", files[0].Name)
+	require.Equal(t, "user.raml", files[1].Name)
+	require.Equal(t, `#%RAML 1.0 Library
+
+uses:
+  cti: ../.ramlx/cti.raml
+  common: common.raml
+
+types:
+  # TODO: user.proto#/User: oneof contact is converted into optional properties, only one of them may be set
+  User:
+    (cti.cti): cti.x.y.user.v1.0
+    type: object
+    description: User of the service.
+    properties:
+      address?: common.Address
+      age?:
+        type: integer
+        format: int64
+        minimum: 0
+        maximum: 4294967295
+      avatar?: string
+      email?: string
+      logins?:
+        type: array
+        items: UserLogin
+      name?: string
+      nickname?: string | nil
+      phone?: string
+      quotas?:
+        type: object
+        properties:
+          //:
+            type: integer
+            format: int32
+      role?: UserRole
+      userId?:
+        type: integer
+        format: int64
+  UserLogin:
+    type: object
+    properties:
+      lastSeen?: datetime
+      name?: string
+  UserRole:
+    type: string
+    description: Role of the user.
+    enum: [ROLE_UNSPECIFIED, ROLE_ADMIN]
+`, string(files[1].Data))
+	require.Contains(t, string(files[0].Data), `      city?:
+        type: string
+        description: City name.
+`)
+	require.Equal(t, []Issue{
+		{Location: "user.proto#/User", Message: "oneof contact is converted into optional properties, only one of them may be set"},
+	}, imp.Issues())
+}
+
+func Test_ParseProto(t *testing.T) {
+	testCases := []struct {
+		name string
+		src  string
+		err  string
+	}{
+		{
+			name: "proto2 required",
+			src:  "syntax = \"proto2\";\nmessage A { required string a_b = 1; optional int32 c = 2 [default = 5]; }",
+		},
+		{
+			name: "unterminated message",
+			src:  "message A { string a = 1;",
+			err:  "unexpected end of file in message A",
+		},
+		{
+			name: "groups",
+			src:  "message A {\n optional group G = 1 { optional int32 a = 2; }\n}",
+			err:  "line 2: groups are not supported",
+		},
+		{
+			name: "unterminated comment",
+			src:  "/* message A {}",
+			err:  "line 1: unterminated comment",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			file, err := parseProto("a.proto", tc.src)
+			if tc.err != "" {
+				require.EqualError(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, file.messages, 1)
+			require.Equal(t, "required", file.messages[0].fields[0].label)
+			require.Equal(t, "aB", file.messages[0].fields[0].jsonName)
+		})
+	}
+}
+
+func Test_ImportMetadata(t *testing.T) {
+	entities := readEntities(t, filepath.Join("testdata", "metadata.json"))
+	imp, err := New("x.y")
+	require.NoError(t, err)
+
+	files, err := imp.ImportMetadata(entities)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+	require.Equal(t, "alerts.raml", files[0].Name)
+	require.Equal(t, "events.raml", files[1].Name)
+	require.Equal(t, `#%RAML 1.0 Library
+
+uses:
+  cti: ../.ramlx/cti.raml
+  events: events.raml
+
+annotationTypes:
+  Alerts: Alert[]
+
+(Alerts):
+  - id: cti.x.y.alert.v1.0~x.y.disk_full.v1.0
+    threshold: 90
+    title: Disk is full
+
+types:
+  Alert:
+    type: object
+    (cti.cti): cti.x.y.alert.v1.0
+    (cti.final): false
+    properties:
+      id:
+        type: cti.CTI
+        (cti.id): true
+      threshold?:
+        type: integer
+        minimum: 0
+        maximum: 100
+      title:
+        type: string
+        (cti.display_name): true
+  AlertRaised:
+    type: events.Event
+    (cti.cti): cti.x.y.event.v1.0~x.y.alert_raised.v1.0
+`, string(files[0].Data))
+	require.Contains(t, string(files[1].Data), `  AdminCreated:
+    type: UserCreated
+    (cti.cti): cti.x.y.event.v1.0~x.y.user_created.v1.0~x.y.admin_created.v1.0
+`)
+	require.Empty(t, imp.Issues())
+}
+
+func Test_ImportMetadataMissingType(t *testing.T) {
+	entities := readEntities(t, filepath.Join("testdata", "metadata.json"))
+	var instances metadata.Entities
+	for _, entity := range entities {
+		if entity.IsInstance() {
+			instances = append(instances, entity)
+		}
+	}
+	imp, err := New("x.y")
+	require.NoError(t, err)
+
+	_, err = imp.ImportMetadata(instances)
+	require.ErrorContains(t, err, "of the instance is not converted")
+}
+
+func Test_CompareEntities(t *testing.T) {
+	testCases := []struct {
+		name     string
+		modify   func(entity *metadata.Entity)
+		expected []Issue
+	}{
+		{
+			name:   "equal",
+			modify: func(*metadata.Entity) {},
+		},
+		{
+			name: "display name",
+			modify: func(entity *metadata.Entity) {
+				entity.DisplayName = "Alert Type"
+			},
+			expected: []Issue{{Location: "cti.x.y.alert.v1.0", Message: "display_name differs"}},
+		},
+		{
+			name: "order of required properties",
+			modify: func(entity *metadata.Entity) {
+				entity.Schema = json.RawMessage(strings.Replace(string(entity.Schema),
+					`"required":["id","title"]`, `"required":["title","id"]`, 1))
+			},
+		},
+		{
+			name: "schema",
+			modify: func(entity *metadata.Entity) {
+				entity.Schema = json.RawMessage(strings.Replace(string(entity.Schema),
+					`"maximum":100`, `"maximum":50`, 1))
+			},
+			expected: []Issue{{Location: "cti.x.y.alert.v1.0", Message: "schema.definitions.Alert.properties.threshold.maximum differs"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			expected := readEntities(t, filepath.Join("testdata", "metadata.json"))
+			actual := readEntities(t, filepath.Join("testdata", "metadata.json"))
+			for _, entity := range actual {
+				if entity.Cti == "cti.x.y.alert.v1.0" {
+					data, err := json.Marshal(entity.Schema)
+					require.NoError(t, err)
+					entity.Schema = data
+					tc.modify(entity)
+				}
+			}
+			require.Equal(t, tc.expected, CompareEntities(expected, actual))
+		})
+	}
+
+	expected := readEntities(t, filepath.Join("testdata", "metadata.json"))
+	require.Equal(t, []Issue{
+		{Location: expected[0].Cti, Message: "entity is missing"},
+	}, CompareEntities(expected, expected[1:]))
+	require.Equal(t, []Issue{
+		{Location: expected[0].Cti, Message: "entity is unexpected"},
+	}, CompareEntities(expected[1:], expected))
+}
+
+func readEntities(t *testing.T, path string) metadata.Entities {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	var entities metadata.Entities
+	require.NoError(t, json.Unmarshal(data, &entities))
+	return entities
+}
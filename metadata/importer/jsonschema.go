@@ -0,0 +1,85 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// jsonSchemaDefinitionKeys are keywords that hold named definitions in JSON Schema documents.
+var jsonSchemaDefinitionKeys = []string{"definitions", "$defs"}
+
+// ImportJSONSchema converts JSON Schema documents from the file or all *.json files from the directory.
+// Root schema of each document is converted into a CTI type named after its title or the file name.
+// Definitions of the document are converted into RAML types, except those that are used as parents of CTI types.
+func (imp *Importer) ImportJSONSchema(path string) ([]File, error) {
+	files, err := schemaFiles(path, ".json")
+	if err != nil {
+		return nil, err
+	}
+
+	set := newDocumentSet()
+	for _, f := range files {
+		if err := loadJSONSchema(set, f); err != nil {
+			return nil, fmt.Errorf("load %s: %w", f, err)
+		}
+	}
+	return imp.Convert(set.libs, set.resolve)
+}
+
+func loadJSONSchema(s *documentSet, fPath string) error {
+	data, err := os.ReadFile(fPath)
+	if err != nil {
+		return err
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("decode schema: %w", err)
+	}
+
+	base := filepath.Base(fPath)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	name = strings.TrimSuffix(name, ".schema")
+	lib := &Library{Name: SnakeCase(name)}
+
+	if isTypeSchema(doc) {
+		typeName := TypeName(name)
+		if title, ok := doc["title"].(string); ok && title != "" {
+			typeName = TypeName(title)
+		}
+		lib.Definitions = append(lib.Definitions, &Definition{Name: typeName, Schema: doc, Entity: true, Location: base + "#"})
+	}
+	for _, key := range jsonSchemaDefinitionKeys {
+		defs, _ := doc[key].(map[string]any)
+		names := make([]string, 0, len(defs))
+		for n := range defs {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			schema, ok := defs[n].(map[string]any)
+			if !ok {
+				continue
+			}
+			lib.Definitions = append(lib.Definitions, &Definition{
+				Name:     TypeName(n),
+				Schema:   schema,
+				Location: base + "#/" + key + "/" + n,
+			})
+		}
+	}
+	return s.add(base, lib)
+}
+
+// isTypeSchema tells whether the root of the document defines a type rather than only holds definitions.
+func isTypeSchema(doc map[string]any) bool {
+	for _, key := range []string{"type", "properties", "allOf", "anyOf", "oneOf", "$ref", "enum", "items"} {
+		if _, ok := doc[key]; ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,450 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+const (
+	definitionsRef = "#/definitions/"
+	// traitsScope is appended to the CTI of the type to scope definitions of its traits schema.
+	traitsScope = "/traits"
+)
+
+// ImportMetadata converts serialized entities, e.g. entities of the package archive, back into RAML.
+// Entities are written into libraries named after the RAML files they were defined in, or after their
+// package if the source is unknown. Identifiers and annotations of entities are preserved, instances
+// are written as items of annotations named as in the source, e.g. (Topics).
+func (imp *Importer) ImportMetadata(entities metadata.Entities) ([]File, error) {
+	set, err := loadMetadata(entities)
+	if err != nil {
+		return nil, err
+	}
+	return imp.Convert(set.libs, set.resolve)
+}
+
+// metadataSet holds libraries loaded from entities. Local references of schemas of entities are rewritten
+// into references scoped by the CTI of the entity, e.g. cti.x.y.event.v1.0#/definitions/Tag.
+type metadataSet struct {
+	libs       []*Library
+	byName     map[string]*Library
+	libOf      map[*Definition]*Library
+	byLocation map[string]*Definition
+	// types holds root definitions of CTI types by their CTI.
+	types map[string]*Definition
+	// names holds definitions of libraries by their names to share equal definitions of different entities
+	// compared before references are scoped.
+	names map[*Library]map[string]*Definition
+	raw   map[*Definition]map[string]any
+}
+
+func loadMetadata(entities metadata.Entities) (*metadataSet, error) {
+	s := &metadataSet{
+		byName:     make(map[string]*Library),
+		libOf:      make(map[*Definition]*Library),
+		byLocation: make(map[string]*Definition),
+		types:      make(map[string]*Definition),
+		names:      make(map[*Library]map[string]*Definition),
+		raw:        make(map[*Definition]map[string]any),
+	}
+	sorted := make(metadata.Entities, len(entities))
+	copy(sorted, entities)
+	sort.Slice(sorted, func(a, b int) bool {
+		return sorted[a].Cti < sorted[b].Cti
+	})
+
+	for _, entity := range sorted {
+		if entity.IsInstance() {
+			continue
+		}
+		if err := s.addType(entity); err != nil {
+			return nil, fmt.Errorf("load %s: %w", entity.Cti, err)
+		}
+	}
+	for _, entity := range sorted {
+		cti := entity.Cti
+		def, ok := s.types[cti]
+		if !ok {
+			continue
+		}
+		parent := metadata.GetParentCti(cti)
+		if parent == cti {
+			continue
+		}
+		parentDef, ok := s.types[parent]
+		if !ok {
+			def.Notes = append(def.Notes, fmt.Sprintf("parent %s is not converted, declare the parent type manually", parent))
+			continue
+		}
+		def.Schema = map[string]any{"allOf": []any{map[string]any{"$ref": parentDef.Location}, def.Schema}}
+	}
+	for _, entity := range sorted {
+		if !entity.IsInstance() {
+			continue
+		}
+		if err := s.addInstance(entity); err != nil {
+			return nil, fmt.Errorf("load %s: %w", entity.Cti, err)
+		}
+	}
+	return s, nil
+}
+
+// library returns the library of the entity named after the file it was defined in.
+func (s *metadataSet) library(entity *metadata.Entity) *Library {
+	name := ""
+	if p := entity.SourceMap.OriginalPath; p != "" {
+		base := path.Base(p)
+		name = SnakeCase(strings.TrimSuffix(base, path.Ext(base)))
+	} else {
+		vendor, pkg := metadata.GetVendorAndPackage(entity.Cti)
+		name = SnakeCase(registry.PackageKey(vendor, pkg))
+	}
+	lib, ok := s.byName[name]
+	if !ok {
+		lib = &Library{Name: name}
+		s.byName[name] = lib
+		s.names[lib] = make(map[string]*Definition)
+		s.libs = append(s.libs, lib)
+	}
+	return lib
+}
+
+func (s *metadataSet) addType(entity *metadata.Entity) error {
+	lib := s.library(entity)
+	doc, err := decodeSchema(entity.Schema)
+	if err != nil {
+		return fmt.Errorf("decode schema: %w", err)
+	}
+	defs, _ := doc["definitions"].(map[string]any)
+
+	// The root definition becomes the CTI type, other definitions become RAML types.
+	scope := entity.Cti
+	root, rootName, location := doc, entity.SourceMap.Name, scope+"#"
+	if ref, ok := doc["$ref"].(string); ok && strings.HasPrefix(ref, definitionsRef) {
+		rootName = strings.TrimPrefix(ref, definitionsRef)
+		if root, ok = defs[rootName].(map[string]any); !ok {
+			return fmt.Errorf("unresolved reference %s", ref)
+		}
+		location = scope + ref
+	}
+	if rootName == "" {
+		rootName = TypeName(entityName(entity.Cti))
+	}
+	if entity.DisplayName != "" && entity.DisplayName != rootName {
+		if _, ok := root["title"]; !ok {
+			root = withKey(root, "title", entity.DisplayName)
+		}
+	}
+
+	def := &Definition{
+		Name:     s.uniqueName(lib, rootName),
+		Schema:   withoutKeys(scopeRefs(root, scope).(map[string]any), "definitions", "$schema"),
+		Entity:   true,
+		Cti:      entity.Cti,
+		Location: location,
+	}
+	s.add(lib, def)
+	s.types[entity.Cti] = def
+	s.addDefinitions(lib, defs, scope, rootName)
+
+	if len(entity.TraitsSchema) > 0 {
+		traits, err := decodeSchema(entity.TraitsSchema)
+		if err != nil {
+			return fmt.Errorf("decode traits schema: %w", err)
+		}
+		traitsDefs, _ := traits["definitions"].(map[string]any)
+		s.addDefinitions(lib, traitsDefs, scope+traitsScope, "")
+		def.TraitsSchema = withoutKeys(scopeRefs(traits, scope+traitsScope).(map[string]any), "definitions", "$schema")
+	}
+	if len(entity.Traits) > 0 {
+		if err := json.Unmarshal(entity.Traits, &def.Traits); err != nil {
+			return fmt.Errorf("decode traits: %w", err)
+		}
+	}
+	return nil
+}
+
+// addDefinitions adds definitions of the schema document to the library except the root definition.
+// Definitions equal to already added ones with the same name are shared.
+func (s *metadataSet) addDefinitions(lib *Library, defs map[string]any, scope string, rootName string) {
+	names := make([]string, 0, len(defs))
+	for name := range defs {
+		if name != rootName {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		schema, ok := defs[name].(map[string]any)
+		if !ok {
+			continue
+		}
+		location := scope + definitionsRef + name
+		if existing, ok := s.names[lib][name]; ok && !existing.Entity && reflect.DeepEqual(s.raw[existing], schema) {
+			s.byLocation[location] = existing
+			continue
+		}
+		def := &Definition{Name: s.uniqueName(lib, name), Schema: scopeRefs(schema, scope).(map[string]any), Location: location}
+		s.raw[def] = schema
+		s.add(lib, def)
+	}
+}
+
+func (s *metadataSet) addInstance(entity *metadata.Entity) error {
+	typeCti := metadata.GetParentCti(entity.Cti)
+	typ, ok := s.types[typeCti]
+	if !ok {
+		return fmt.Errorf("type %s of the instance is not converted", typeCti)
+	}
+	var values any
+	if err := json.Unmarshal(entity.Values, &values); err != nil {
+		return fmt.Errorf("decode values: %w", err)
+	}
+
+	lib := s.library(entity)
+	annotation := typ.Name + "Instances"
+	if at := entity.SourceMap.AnnotationType; at != nil && at.Name != "" {
+		annotation = at.Name
+	}
+	// Annotations of instances of different types in the library must have different names.
+	for i := 2; ; i++ {
+		conflict := false
+		for _, instance := range lib.Instances {
+			if instance.Annotation == annotation && instance.Type != typ {
+				conflict = true
+				break
+			}
+		}
+		if !conflict {
+			break
+		}
+		annotation = strings.TrimRight(annotation, "0123456789") + strconv.Itoa(i)
+	}
+	lib.Instances = append(lib.Instances, &Instance{Annotation: annotation, Type: typ, Values: values})
+	return nil
+}
+
+func (s *metadataSet) add(lib *Library, def *Definition) {
+	lib.Definitions = append(lib.Definitions, def)
+	s.names[lib][def.Name] = def
+	s.libOf[def] = lib
+	s.byLocation[def.Location] = def
+}
+
+// uniqueName returns the name with a numeric suffix if the library already has a definition with the name.
+func (s *metadataSet) uniqueName(lib *Library, name string) string {
+	res := name
+	for i := 2; s.names[lib][res] != nil; i++ {
+		res = name + strconv.Itoa(i)
+	}
+	return res
+}
+
+func (s *metadataSet) resolve(_ *Library, ref string) (*Library, *Definition, error) {
+	def, ok := s.byLocation[ref]
+	if !ok {
+		return nil, nil, errNotFound
+	}
+	return s.libOf[def], def, nil
+}
+
+// scopeRefs returns a copy of the value with local references prefixed by the scope.
+func scopeRefs(v any, scope string) any {
+	return mapRefs(v, func(ref string) string {
+		if strings.HasPrefix(ref, definitionsRef) {
+			return scope + ref
+		}
+		return ref
+	})
+}
+
+// mapRefs returns a copy of the value with values of $ref replaced by results of the function.
+func mapRefs(v any, fn func(string) string) any {
+	switch v := v.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for k, item := range v {
+			if ref, ok := item.(string); ok && k == "$ref" {
+				res[k] = fn(ref)
+			} else {
+				res[k] = mapRefs(item, fn)
+			}
+		}
+		return res
+	case []any:
+		res := make([]any, len(v))
+		for i, item := range v {
+			res[i] = mapRefs(item, fn)
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+func decodeSchema(data json.RawMessage) (map[string]any, error) {
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// entityName returns the name of the entity in its CTI, e.g. user_created for cti.x.y.event.v1.0~x.y.user_created.v1.0.
+func entityName(cti string) string {
+	chunk := strings.TrimPrefix(cti[strings.LastIndex(cti, "~")+1:], "cti.")
+	parts := strings.Split(chunk, ".")
+	if len(parts) < 5 {
+		return chunk
+	}
+	return strings.Join(parts[2:len(parts)-2], "_")
+}
+
+func withKey(m map[string]any, key string, v any) map[string]any {
+	res := make(map[string]any, len(m)+1)
+	for k, item := range m {
+		res[k] = item
+	}
+	res[key] = v
+	return res
+}
+
+func withoutKeys(m map[string]any, keys ...string) map[string]any {
+	res := m
+	for _, key := range keys {
+		if _, ok := res[key]; ok {
+			res = withoutKey(res, key)
+		}
+	}
+	return res
+}
+
+// CompareEntities compares entities converted back from RAML with the original ones and returns differences
+// that would be lost by the conversion. Source maps are not compared, order of required properties is ignored.
+func CompareEntities(expected, actual metadata.Entities) []Issue {
+	byCti := make(map[string]*metadata.Entity, len(actual))
+	for _, entity := range actual {
+		byCti[entity.Cti] = entity
+	}
+	var issues []Issue
+	seen := make(map[string]bool, len(expected))
+	for _, want := range expected {
+		seen[want.Cti] = true
+		got, ok := byCti[want.Cti]
+		if !ok {
+			issues = append(issues, Issue{Location: want.Cti, Message: "entity is missing"})
+			continue
+		}
+		for _, field := range []struct {
+			name      string
+			want, got any
+		}{
+			{"final", want.Final, got.Final},
+			{"display_name", want.DisplayName, got.DisplayName},
+			{"description", want.Description, got.Description},
+			{"values", want.Values, got.Values},
+			{"schema", want.Schema, got.Schema},
+			{"traits_schema", want.TraitsSchema, got.TraitsSchema},
+			{"traits", want.Traits, got.Traits},
+			{"annotations", want.Annotations, got.Annotations},
+			{"traits_annotations", want.TraitsAnnotations, got.TraitsAnnotations},
+		} {
+			if path, ok := difference(normalize(field.want), normalize(field.got), field.name); ok {
+				issues = append(issues, Issue{Location: want.Cti, Message: path + " differs"})
+			}
+		}
+	}
+	for _, entity := range actual {
+		if !seen[entity.Cti] {
+			issues = append(issues, Issue{Location: entity.Cti, Message: "entity is unexpected"})
+		}
+	}
+	sort.SliceStable(issues, func(a, b int) bool {
+		return issues[a].Location < issues[b].Location
+	})
+	return issues
+}
+
+// normalize decodes the value into generic JSON values with sorted lists of required properties.
+func normalize(v any) any {
+	var data []byte
+	switch v := v.(type) {
+	case json.RawMessage:
+		data = v
+	default:
+		data, _ = json.Marshal(v)
+	}
+	var res any
+	if len(data) == 0 || json.Unmarshal(data, &res) != nil {
+		return nil
+	}
+	var walk func(v any) any
+	walk = func(v any) any {
+		switch v := v.(type) {
+		case map[string]any:
+			if len(v) == 0 {
+				return nil
+			}
+			for k, item := range v {
+				if list, ok := item.([]any); ok && k == "required" {
+					sort.Slice(list, func(a, b int) bool {
+						return fmt.Sprint(list[a]) < fmt.Sprint(list[b])
+					})
+				}
+				v[k] = walk(item)
+			}
+		case []any:
+			for i, item := range v {
+				v[i] = walk(item)
+			}
+		}
+		return v
+	}
+	return walk(res)
+}
+
+// difference returns the path to the first difference between the values.
+func difference(want, got any, p string) (string, bool) {
+	wantMap, wantIsMap := want.(map[string]any)
+	gotMap, gotIsMap := got.(map[string]any)
+	if wantIsMap && gotIsMap {
+		keys := make(map[string]bool, len(wantMap)+len(gotMap))
+		for k := range wantMap {
+			keys[k] = true
+		}
+		for k := range gotMap {
+			keys[k] = true
+		}
+		sorted := make([]string, 0, len(keys))
+		for k := range keys {
+			sorted = append(sorted, k)
+		}
+		sort.Strings(sorted)
+		for _, k := range sorted {
+			if res, ok := difference(wantMap[k], gotMap[k], p+"."+k); ok {
+				return res, true
+			}
+		}
+		return "", false
+	}
+	wantList, wantIsList := want.([]any)
+	gotList, gotIsList := got.([]any)
+	if wantIsList && gotIsList && len(wantList) == len(gotList) {
+		for i := range wantList {
+			if res, ok := difference(wantList[i], gotList[i], p+"["+strconv.Itoa(i)+"]"); ok {
+				return res, true
+			}
+		}
+		return "", false
+	}
+	return p, !reflect.DeepEqual(want, got)
+}
@@ -0,0 +1,71 @@
+package importer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ImportOpenAPI converts component schemas of OpenAPI 3.x documents from the file or all *.json, *.yaml and *.yml
+// files from the directory. Each component schema is converted into a CTI type named after the component.
+// Descriptions, formats, nullable properties and discriminators are preserved.
+func (imp *Importer) ImportOpenAPI(path string) ([]File, error) {
+	files, err := schemaFiles(path, ".json", ".yaml", ".yml")
+	if err != nil {
+		return nil, err
+	}
+
+	set := newDocumentSet()
+	for _, f := range files {
+		if err := loadOpenAPI(set, f); err != nil {
+			return nil, fmt.Errorf("load %s: %w", f, err)
+		}
+	}
+	return imp.Convert(set.libs, set.resolve)
+}
+
+func loadOpenAPI(s *documentSet, fPath string) error {
+	data, err := os.ReadFile(fPath)
+	if err != nil {
+		return err
+	}
+	// JSON is a subset of YAML, so both forms of the document are decoded the same way.
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("decode document: %w", err)
+	}
+	version, _ := doc["openapi"].(string)
+	if !strings.HasPrefix(version, "3.") {
+		return errors.New("not an OpenAPI 3.x document")
+	}
+
+	base := filepath.Base(fPath)
+	name := strings.TrimSuffix(base, filepath.Ext(base))
+	lib := &Library{Name: SnakeCase(name)}
+
+	components, _ := doc["components"].(map[string]any)
+	schemas, _ := components["schemas"].(map[string]any)
+	names := make([]string, 0, len(schemas))
+	for n := range schemas {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	for _, n := range names {
+		schema, ok := schemas[n].(map[string]any)
+		if !ok {
+			continue
+		}
+		lib.Definitions = append(lib.Definitions, &Definition{
+			Name:     TypeName(n),
+			Schema:   schema,
+			Entity:   true,
+			Location: base + "#/components/schemas/" + n,
+		})
+	}
+	return s.add(base, lib)
+}
@@ -0,0 +1,611 @@
+package importer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode"
+)
+
+// ProtoExt is an extension of Protocol Buffers definition files.
+const ProtoExt = ".proto"
+
+// protoScalars maps scalar types of Protocol Buffers onto JSON Schema following the canonical JSON mapping.
+var protoScalars = map[string]func() map[string]any{
+	"double":   func() map[string]any { return map[string]any{"type": "number", "format": "double"} },
+	"float":    func() map[string]any { return map[string]any{"type": "number", "format": "float"} },
+	"int32":    func() map[string]any { return map[string]any{"type": "integer", "format": "int32"} },
+	"sint32":   func() map[string]any { return map[string]any{"type": "integer", "format": "int32"} },
+	"sfixed32": func() map[string]any { return map[string]any{"type": "integer", "format": "int32"} },
+	"int64":    func() map[string]any { return map[string]any{"type": "integer", "format": "int64"} },
+	"sint64":   func() map[string]any { return map[string]any{"type": "integer", "format": "int64"} },
+	"sfixed64": func() map[string]any { return map[string]any{"type": "integer", "format": "int64"} },
+	"uint32": func() map[string]any {
+		return map[string]any{"type": "integer", "format": "int64", "minimum": 0, "maximum": 4294967295}
+	},
+	"fixed32": func() map[string]any {
+		return map[string]any{"type": "integer", "format": "int64", "minimum": 0, "maximum": 4294967295}
+	},
+	"uint64":  func() map[string]any { return map[string]any{"type": "integer", "format": "int64", "minimum": 0} },
+	"fixed64": func() map[string]any { return map[string]any{"type": "integer", "format": "int64", "minimum": 0} },
+	"bool":    func() map[string]any { return map[string]any{"type": "boolean"} },
+	"string":  func() map[string]any { return map[string]any{"type": "string"} },
+	// Bytes are represented as base64-encoded strings.
+	"bytes": func() map[string]any { return map[string]any{"type": "string"} },
+}
+
+// protoWellKnown maps well-known types of Protocol Buffers onto JSON Schema following the canonical JSON mapping.
+var protoWellKnown = map[string]func() map[string]any{
+	"google.protobuf.Timestamp": func() map[string]any { return map[string]any{"type": "string", "format": "date-time"} },
+	"google.protobuf.Duration": func() map[string]any {
+		return map[string]any{"type": "string", "pattern": `^-?[0-9]+(\.[0-9]+)?s$`}
+	},
+	"google.protobuf.FieldMask": func() map[string]any { return map[string]any{"type": "string"} },
+	"google.protobuf.Struct":    func() map[string]any { return map[string]any{"type": "object"} },
+	"google.protobuf.Any":       func() map[string]any { return map[string]any{"type": "object"} },
+	"google.protobuf.Empty":     func() map[string]any { return map[string]any{"type": "object"} },
+	"google.protobuf.Value":     func() map[string]any { return map[string]any{} },
+	"google.protobuf.ListValue": func() map[string]any { return map[string]any{"type": "array", "items": map[string]any{}} },
+	"google.protobuf.DoubleValue": func() map[string]any {
+		return map[string]any{"type": "number", "format": "double", "nullable": true}
+	},
+	"google.protobuf.FloatValue": func() map[string]any {
+		return map[string]any{"type": "number", "format": "float", "nullable": true}
+	},
+	"google.protobuf.Int32Value": func() map[string]any {
+		return map[string]any{"type": "integer", "format": "int32", "nullable": true}
+	},
+	"google.protobuf.Int64Value": func() map[string]any {
+		return map[string]any{"type": "integer", "format": "int64", "nullable": true}
+	},
+	"google.protobuf.UInt32Value": func() map[string]any {
+		return map[string]any{"type": "integer", "format": "int64", "minimum": 0, "maximum": 4294967295, "nullable": true}
+	},
+	"google.protobuf.UInt64Value": func() map[string]any {
+		return map[string]any{"type": "integer", "format": "int64", "minimum": 0, "nullable": true}
+	},
+	"google.protobuf.BoolValue":   func() map[string]any { return map[string]any{"type": "boolean", "nullable": true} },
+	"google.protobuf.StringValue": func() map[string]any { return map[string]any{"type": "string", "nullable": true} },
+	"google.protobuf.BytesValue":  func() map[string]any { return map[string]any{"type": "string", "nullable": true} },
+}
+
+// ImportProto converts messages and enums of Protocol Buffers definitions from the file or all *.proto files
+// from the directory. Top-level messages are converted into CTI types, nested messages and enums into RAML types.
+// Fields are named after their JSON names and converted following the canonical JSON mapping of Protocol Buffers.
+func (imp *Importer) ImportProto(path string) ([]File, error) {
+	paths, err := schemaFiles(path, ProtoExt)
+	if err != nil {
+		return nil, err
+	}
+
+	files := make([]*protoFile, 0, len(paths))
+	for _, p := range paths {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", p, err)
+		}
+		file, err := parseProto(filepath.Base(p), string(data))
+		if err != nil {
+			return nil, fmt.Errorf("parse %s: %w", p, err)
+		}
+		files = append(files, file)
+	}
+
+	set := newDocumentSet()
+	b := &protoSchemaBuilder{symbols: make(map[string]string)}
+	for _, file := range files {
+		for _, m := range file.messages {
+			b.symbols[file.qualify(m.name)] = file.name + "#/" + m.name
+		}
+		for _, e := range file.enums {
+			b.symbols[file.qualify(e.name)] = file.name + "#/" + e.name
+		}
+	}
+	for _, file := range files {
+		lib := &Library{Name: SnakeCase(strings.TrimSuffix(file.name, ProtoExt))}
+		for _, m := range file.messages {
+			lib.Definitions = append(lib.Definitions, &Definition{
+				Name:     TypeName(m.name),
+				Schema:   b.message(file, m),
+				Entity:   !strings.Contains(m.name, "."),
+				Location: file.name + "#/" + m.name,
+				Notes:    protoNotes(m),
+			})
+		}
+		for _, e := range file.enums {
+			lib.Definitions = append(lib.Definitions, &Definition{
+				Name:     TypeName(e.name),
+				Schema:   protoEnumSchema(e),
+				Location: file.name + "#/" + e.name,
+			})
+		}
+		if err := set.add(file.name, lib); err != nil {
+			return nil, fmt.Errorf("load %s: %w", file.name, err)
+		}
+	}
+	return imp.Convert(set.libs, set.resolve)
+}
+
+// protoSchemaBuilder converts messages into JSON Schema and resolves names of field types.
+type protoSchemaBuilder struct {
+	// symbols holds locations of messages and enums by their fully qualified names.
+	symbols map[string]string
+}
+
+func (b *protoSchemaBuilder) message(file *protoFile, m *protoMessage) map[string]any {
+	schema := map[string]any{"type": "object"}
+	if m.comment != "" {
+		schema["description"] = m.comment
+	}
+	props := make(map[string]any, len(m.fields))
+	var required []any
+	for _, f := range m.fields {
+		props[f.jsonName] = b.field(file, m, f)
+		if f.label == "required" {
+			required = append(required, f.jsonName)
+		}
+	}
+	if len(props) > 0 {
+		schema["properties"] = props
+	}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func (b *protoSchemaBuilder) field(file *protoFile, m *protoMessage, f *protoField) map[string]any {
+	schema := b.typeSchema(file, m, f.typ)
+	switch {
+	case f.mapKey != "":
+		schema = map[string]any{"type": "object", "additionalProperties": schema}
+	case f.label == "repeated":
+		schema = map[string]any{"type": "array", "items": schema}
+	}
+	if f.comment != "" {
+		schema["description"] = f.comment
+	}
+	return schema
+}
+
+// typeSchema returns the schema of the field type. Names are resolved from the innermost scope outwards,
+// the same way the protobuf compiler does.
+func (b *protoSchemaBuilder) typeSchema(file *protoFile, m *protoMessage, typ string) map[string]any {
+	if scalar, ok := protoScalars[typ]; ok {
+		return scalar()
+	}
+	if strings.HasPrefix(typ, ".") {
+		return b.ref(strings.TrimPrefix(typ, "."))
+	}
+	scope := file.qualify(m.name)
+	for scope != "" {
+		if loc, ok := b.symbols[scope+"."+typ]; ok {
+			return map[string]any{"$ref": loc}
+		}
+		i := strings.LastIndex(scope, ".")
+		if i < 0 {
+			break
+		}
+		scope = scope[:i]
+	}
+	return b.ref(typ)
+}
+
+func (b *protoSchemaBuilder) ref(name string) map[string]any {
+	if loc, ok := b.symbols[name]; ok {
+		return map[string]any{"$ref": loc}
+	}
+	if wellKnown, ok := protoWellKnown[name]; ok {
+		return wellKnown()
+	}
+	// Types of files that are not imported are left unresolved and reported by the converter.
+	return map[string]any{"$ref": "#/" + name}
+}
+
+func protoNotes(m *protoMessage) []string {
+	var notes []string
+	for _, oneof := range m.oneofs {
+		notes = append(notes, fmt.Sprintf("oneof %s is converted into optional properties, only one of them may be set", oneof))
+	}
+	return notes
+}
+
+// protoEnumSchema converts the enum into a string enum of value names as they are represented in JSON.
+func protoEnumSchema(e *protoEnum) map[string]any {
+	values := make([]any, 0, len(e.values))
+	for _, v := range e.values {
+		values = append(values, v)
+	}
+	schema := map[string]any{"type": "string", "enum": values}
+	if e.comment != "" {
+		schema["description"] = e.comment
+	}
+	return schema
+}
+
+// protoFile is a parsed Protocol Buffers definition file. Nested messages and enums are listed along with
+// top-level ones and named by their path in the file, e.g. Outer.Inner.
+type protoFile struct {
+	name     string
+	pkg      string
+	messages []*protoMessage
+	enums    []*protoEnum
+}
+
+func (f *protoFile) qualify(name string) string {
+	if f.pkg == "" {
+		return name
+	}
+	return f.pkg + "." + name
+}
+
+type protoMessage struct {
+	name    string
+	comment string
+	fields  []*protoField
+	oneofs  []string
+}
+
+type protoField struct {
+	name     string
+	jsonName string
+	label    string
+	typ      string
+	mapKey   string
+	comment  string
+}
+
+type protoEnum struct {
+	name    string
+	comment string
+	values  []string
+}
+
+type protoToken struct {
+	text    string
+	line    int
+	comment string
+	// trailing is a comment that follows the token on the same line.
+	trailing string
+	quoted   bool
+}
+
+// parseProto parses messages and enums of the file. Services, extensions and options are skipped.
+func parseProto(name, src string) (*protoFile, error) {
+	tokens, err := tokenizeProto(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &protoParser{tokens: tokens, file: &protoFile{name: name}}
+	if err := p.parseFile(); err != nil {
+		return nil, err
+	}
+	return p.file, nil
+}
+
+type protoParser struct {
+	tokens []protoToken
+	pos    int
+	file   *protoFile
+}
+
+func (p *protoParser) parseFile() error {
+	for !p.eof() {
+		tok := p.next()
+		switch tok.text {
+		case ";":
+		case "package":
+			p.file.pkg = p.next().text
+			if err := p.expect(";"); err != nil {
+				return err
+			}
+		case "message":
+			if err := p.parseMessage("", tok.comment); err != nil {
+				return err
+			}
+		case "enum":
+			if err := p.parseEnum("", tok.comment); err != nil {
+				return err
+			}
+		case "syntax", "edition", "import", "option", "service", "extend":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		default:
+			return p.unexpected(tok)
+		}
+	}
+	return nil
+}
+
+func (p *protoParser) parseMessage(scope, comment string) error {
+	m := &protoMessage{name: scope + p.next().text, comment: comment}
+	p.file.messages = append(p.file.messages, m)
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	return p.parseMessageBody(m, "")
+}
+
+// parseMessageBody parses fields of the message until the closing brace. Fields of oneof are parsed
+// by the same function with the name of oneof.
+func (p *protoParser) parseMessageBody(m *protoMessage, oneof string) error {
+	for {
+		if p.eof() {
+			return fmt.Errorf("unexpected end of file in message %s", m.name)
+		}
+		tok := p.peek()
+		switch tok.text {
+		case "}":
+			p.next()
+			return nil
+		case ";":
+			p.next()
+		case "message":
+			p.next()
+			if err := p.parseMessage(m.name+".", tok.comment); err != nil {
+				return err
+			}
+		case "enum":
+			p.next()
+			if err := p.parseEnum(m.name+".", tok.comment); err != nil {
+				return err
+			}
+		case "oneof":
+			p.next()
+			name := p.next().text
+			m.oneofs = append(m.oneofs, name)
+			if err := p.expect("{"); err != nil {
+				return err
+			}
+			if err := p.parseMessageBody(m, name); err != nil {
+				return err
+			}
+		case "option", "reserved", "extensions", "extend":
+			p.next()
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		default:
+			f, err := p.parseField()
+			if err != nil {
+				return err
+			}
+			m.fields = append(m.fields, f)
+		}
+	}
+}
+
+func (p *protoParser) parseField() (*protoField, error) {
+	f := &protoField{}
+	tok := p.next()
+	f.comment = tok.comment
+	switch tok.text {
+	case "repeated", "optional", "required":
+		f.label = tok.text
+		tok = p.next()
+	}
+	if tok.quoted {
+		return nil, p.unexpected(tok)
+	}
+	f.typ = tok.text
+	switch f.typ {
+	case "group":
+		return nil, fmt.Errorf("line %d: groups are not supported", tok.line)
+	case "map":
+		if err := p.expect("<"); err != nil {
+			return nil, err
+		}
+		f.mapKey = p.next().text
+		if err := p.expect(","); err != nil {
+			return nil, err
+		}
+		f.typ = p.next().text
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+	}
+	f.name = p.next().text
+	f.jsonName = protoJSONName(f.name)
+	if err := p.expect("="); err != nil {
+		return nil, err
+	}
+	p.next()
+	if p.peek().text == "[" {
+		p.next()
+		for !p.eof() && p.peek().text != "]" {
+			opt := p.next()
+			if opt.text == "json_name" && p.peek().text == "=" {
+				p.next()
+				f.jsonName = p.next().text
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+	end := p.peek()
+	if err := p.expect(";"); err != nil {
+		return nil, err
+	}
+	if f.comment == "" {
+		f.comment = end.trailing
+	}
+	return f, nil
+}
+
+func (p *protoParser) parseEnum(scope, comment string) error {
+	e := &protoEnum{name: scope + p.next().text, comment: comment}
+	p.file.enums = append(p.file.enums, e)
+	if err := p.expect("{"); err != nil {
+		return err
+	}
+	for {
+		if p.eof() {
+			return fmt.Errorf("unexpected end of file in enum %s", e.name)
+		}
+		tok := p.next()
+		switch tok.text {
+		case "}":
+			return nil
+		case ";":
+		case "option", "reserved":
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		default:
+			e.values = append(e.values, tok.text)
+			if err := p.skipStatement(); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// skipStatement skips tokens until the end of the statement: a semicolon or a closing brace of the block.
+func (p *protoParser) skipStatement() error {
+	depth := 0
+	for !p.eof() {
+		switch p.next().text {
+		case "{":
+			depth++
+		case "}":
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		case ";":
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unexpected end of file")
+}
+
+func (p *protoParser) eof() bool {
+	return p.pos >= len(p.tokens)
+}
+
+func (p *protoParser) peek() protoToken {
+	if p.eof() {
+		return protoToken{}
+	}
+	return p.tokens[p.pos]
+}
+
+func (p *protoParser) next() protoToken {
+	tok := p.peek()
+	p.pos++
+	return tok
+}
+
+func (p *protoParser) expect(text string) error {
+	if p.eof() {
+		return fmt.Errorf("expected %s, got end of file", text)
+	}
+	if tok := p.next(); tok.text != text || tok.quoted {
+		return fmt.Errorf("line %d: expected %s, got %s", tok.line, text, tok.text)
+	}
+	return nil
+}
+
+func (p *protoParser) unexpected(tok protoToken) error {
+	return fmt.Errorf("line %d: unexpected %s", tok.line, tok.text)
+}
+
+// tokenizeProto splits the source into identifiers, literals and symbols. Comments are attached
+// to the following token, or to the preceding one if they are on the same line.
+func tokenizeProto(src string) ([]protoToken, error) {
+	var tokens []protoToken
+	var comment []string
+	line := 1
+	runes := []rune(src)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '/':
+			start := i + 2
+			for i < len(runes) && runes[i] != '\n' {
+				i++
+			}
+			text := strings.TrimSpace(string(runes[start:i]))
+			if n := len(tokens); n > 0 && tokens[n-1].line == line && len(comment) == 0 {
+				tokens[n-1].trailing = text
+			} else {
+				comment = append(comment, text)
+			}
+		case r == '/' && i+1 < len(runes) && runes[i+1] == '*':
+			start := i + 2
+			end := start
+			for end+1 < len(runes) && !(runes[end] == '*' && runes[end+1] == '/') {
+				end++
+			}
+			if end+1 >= len(runes) {
+				return nil, fmt.Errorf("line %d: unterminated comment", line)
+			}
+			text := string(runes[start:end])
+			line += strings.Count(text, "\n")
+			for _, l := range strings.Split(text, "\n") {
+				comment = append(comment, strings.TrimSpace(strings.TrimLeft(strings.TrimSpace(l), "*")))
+			}
+			i = end + 2
+		case r == '"' || r == '\'':
+			start := i + 1
+			i++
+			for i < len(runes) && runes[i] != r {
+				if runes[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i >= len(runes) {
+				return nil, fmt.Errorf("line %d: unterminated string", line)
+			}
+			tokens = append(tokens, protoToken{text: string(runes[start:i]), line: line, comment: joinComment(comment), quoted: true})
+			comment = nil
+			i++
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) ||
+				runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, protoToken{text: string(runes[start:i]), line: line, comment: joinComment(comment)})
+			comment = nil
+		default:
+			tokens = append(tokens, protoToken{text: string(r), line: line, comment: joinComment(comment)})
+			comment = nil
+			i++
+		}
+	}
+	return tokens, nil
+}
+
+func joinComment(lines []string) string {
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// protoJSONName returns the JSON name of the field as generated by the protobuf compiler, e.g. user_id becomes userId.
+func protoJSONName(name string) string {
+	var b strings.Builder
+	upper := false
+	for _, r := range name {
+		if r == '_' {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
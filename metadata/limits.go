@@ -0,0 +1,55 @@
+package metadata
+
+import "fmt"
+
+// LimitError is returned when a schema or an instance exceeds a processing limit,
+// e.g., a nesting depth or a size, that guards against pathological definitions.
+type LimitError struct {
+	// Limit is a name of the exceeded limit.
+	Limit string
+	// Max is the configured value of the limit.
+	Max int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s limit of %d exceeded", e.Limit, e.Max)
+}
+
+// CheckLimits walks the decoded JSON value and returns LimitError
+// if its nesting depth or its number of values exceeds the limit. Zero limit is not checked.
+func CheckLimits(node any, what string, maxDepth, maxSize int) error {
+	c := limitChecker{what: what, maxDepth: maxDepth, maxSize: maxSize}
+	return c.walk(node, 1)
+}
+
+type limitChecker struct {
+	what     string
+	maxDepth int
+	maxSize  int
+	size     int
+}
+
+func (c *limitChecker) walk(node any, depth int) error {
+	if c.maxDepth > 0 && depth > c.maxDepth {
+		return &LimitError{Limit: c.what + " depth", Max: c.maxDepth}
+	}
+	c.size++
+	if c.maxSize > 0 && c.size > c.maxSize {
+		return &LimitError{Limit: c.what + " size", Max: c.maxSize}
+	}
+	switch v := node.(type) {
+	case map[string]any:
+		for _, item := range v {
+			if err := c.walk(item, depth+1); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range v {
+			if err := c.walk(item, depth+1); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -4,12 +4,18 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"reflect"
+	"sort"
 	"strings"
 )
 
 const (
+	allOfKey       = "allOf"
 	anyOfKey       = "anyOf"
+	constKey       = "const"
 	definitionsKey = "definitions"
+	enumKey        = "enum"
+	formatKey      = "format"
 	itemsKey       = "items"
 	propertiesKey  = "properties"
 	refKey         = "$ref"
@@ -22,8 +28,8 @@ type merger func(source, target map[string]any) (map[string]any, error)
 var errInvalidSchemaError = errors.New("invalid schema")
 
 var propertiesToMerge = [...]string{
-	"title", "description", "default", "pattern", "format", "enum", "additionalProperties",
-	"minimum", "maximum", "multipleOf", "maxLength", "minLength", "minItems", "maxItems",
+	"title", "description", "default", "pattern", "format", "enum", "const", "additionalProperties",
+	"minimum", "maximum", "exclusiveMinimum", "exclusiveMaximum", "multipleOf", "maxLength", "minLength", "minItems", "maxItems",
 	"uniqueItems", "minProperties", "maxProperties",
 }
 
@@ -56,6 +62,13 @@ func mergeObjects(source, target map[string]any) (map[string]any, error) {
 		}
 	}
 
+	if err := checkAllowedValues(source, target); err != nil {
+		return nil, err
+	}
+	if err := mergeFacets(source, target); err != nil {
+		return nil, err
+	}
+
 	for _, key := range propertiesToMerge {
 		if source[key] != nil {
 			target[key] = source[key]
@@ -93,6 +106,121 @@ func mergeObjects(source, target map[string]any) (map[string]any, error) {
 	return mergerFn(source, target)
 }
 
+// checkAllowedValues checks that enum and const of the source do not allow values that are not allowed by the target.
+func checkAllowedValues(source, target map[string]any) error {
+	targetEnum, hasTargetEnum := target[enumKey].([]any)
+	targetConst, hasTargetConst := target[constKey]
+
+	allowed := func(val any) bool {
+		if hasTargetConst && !reflect.DeepEqual(val, targetConst) {
+			return false
+		}
+		if hasTargetEnum && !containsValue(targetEnum, val) {
+			return false
+		}
+		return true
+	}
+	if sourceEnum, ok := source[enumKey].([]any); ok {
+		for _, val := range sourceEnum {
+			if !allowed(val) {
+				return fmt.Errorf("enum value %v is not allowed by parent, allowed values: %s", val, FormatAllowedValues(target))
+			}
+		}
+	}
+	if sourceConst, ok := source[constKey]; ok && !allowed(sourceConst) {
+		return fmt.Errorf("const value %v is not allowed by parent, allowed values: %s", sourceConst, FormatAllowedValues(target))
+	}
+	return nil
+}
+
+var (
+	lowerBoundFacets = [...]string{"minimum", "exclusiveMinimum", "minLength", "minItems", "minProperties"}
+	upperBoundFacets = [...]string{"maximum", "exclusiveMaximum", "maxLength", "maxItems", "maxProperties"}
+	// combinedFacets cannot be narrowed by a single value, so parent values are kept in allOf.
+	combinedFacets = [...]string{"pattern", "multipleOf"}
+)
+
+// mergeFacets checks that the source does not widen the bounds of the target
+// and keeps the target pattern and multipleOf facets that would be overridden by the source.
+func mergeFacets(source, target map[string]any) error {
+	for _, key := range lowerBoundFacets {
+		if src, trg, ok := numericFacets(source, target, key); ok && src < trg {
+			return fmt.Errorf("%s %v is less than parent %s %v", key, src, key, trg)
+		}
+	}
+	for _, key := range upperBoundFacets {
+		if src, trg, ok := numericFacets(source, target, key); ok && src > trg {
+			return fmt.Errorf("%s %v is greater than parent %s %v", key, src, key, trg)
+		}
+	}
+	if src, ok := source[formatKey]; ok {
+		if trg, ok := target[formatKey]; ok && src != trg {
+			return fmt.Errorf("format %v conflicts with parent format %v", src, trg)
+		}
+	}
+	for _, key := range combinedFacets {
+		src, ok := source[key]
+		if !ok {
+			continue
+		}
+		if trg, ok := target[key]; ok && !reflect.DeepEqual(src, trg) {
+			allOf, _ := target[allOfKey].([]any)
+			target[allOfKey] = append(allOf, map[string]any{key: trg})
+		}
+	}
+	return nil
+}
+
+func numericFacets(source, target map[string]any, key string) (float64, float64, bool) {
+	src, ok := toFloat(source[key])
+	if !ok {
+		return 0, 0, false
+	}
+	trg, ok := toFloat(target[key])
+	if !ok {
+		return 0, 0, false
+	}
+	return src, trg, true
+}
+
+func toFloat(val any) (float64, bool) {
+	switch v := val.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case json.Number:
+		f, err := v.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// FormatAllowedValues returns a human-readable list of values allowed by enum or const of the schema.
+func FormatAllowedValues(schema map[string]any) string {
+	var values []any
+	if val, ok := schema[constKey]; ok {
+		values = []any{val}
+	} else if enum, ok := schema[enumKey].([]any); ok {
+		values = enum
+	}
+	str := make([]string, len(values))
+	for i, val := range values {
+		b, _ := json.Marshal(val)
+		str[i] = string(b)
+	}
+	return "[" + strings.Join(str, ", ") + "]"
+}
+
+func containsValue(values []any, val any) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, val) {
+			return true
+		}
+	}
+	return false
+}
+
 // overrideUnionType does what?
 func overrideUnionType(source, target map[string]any) (map[string]any, error) {
 	for _, val := range target[anyOfKey].([]any) {
@@ -111,26 +239,41 @@ func overrideUnionType(source, target map[string]any) (map[string]any, error) {
 // mergeRequired merges two "required" arrays
 func mergeRequired(source, target map[string]any) ([]string, error) {
 	// Use maps to simulate sets
-	requiredSet := make(map[any]struct{})
-
-	requiredSrc, _ := source[requiredKey].([]any)
-	for _, item := range requiredSrc {
+	requiredSet := make(map[string]struct{})
+	for _, item := range requiredList(source[requiredKey]) {
 		requiredSet[item] = struct{}{}
 	}
-
-	requiredTrg, _ := target[requiredKey].([]any)
-	for _, item := range requiredTrg {
+	for _, item := range requiredList(target[requiredKey]) {
 		requiredSet[item] = struct{}{}
 	}
 
 	targetRequired := make([]string, 0, len(requiredSet))
 	for key := range requiredSet {
-		targetRequired = append(targetRequired, key.(string))
+		targetRequired = append(targetRequired, key)
 	}
+	sort.Strings(targetRequired)
 
 	return targetRequired, nil
 }
 
+// requiredList returns names of required properties. Decoded schemas hold []any,
+// while schemas merged before hold []string.
+func requiredList(v any) []string {
+	switch v := v.(type) {
+	case []string:
+		return v
+	case []any:
+		res := make([]string, 0, len(v))
+		for _, item := range v {
+			if name, ok := item.(string); ok {
+				res = append(res, name)
+			}
+		}
+		return res
+	}
+	return nil
+}
+
 func mergeItems(source, target map[string]any) (map[string]any, error) {
 	if target[itemsKey] == nil {
 		target[itemsKey] = source[itemsKey]
@@ -66,6 +66,10 @@ type SourceMap struct {
 
 	// OriginalPath is a relative path to RAML fragment where the CTI entity is defined.
 	OriginalPath string `json:"$originalPath,omitempty"`
+
+	// Line and Column point to the definition of the CTI entity in the RAML fragment.
+	Line   int `json:"$line,omitempty"`
+	Column int `json:"$column,omitempty"`
 }
 
 func (a *SourceMap) ToBytes() []byte {
@@ -132,3 +136,53 @@ func (k GJsonPath) GetValue(obj []byte) gjson.Result {
 func (k GJsonPath) String() string {
 	return string(k)
 }
+
+// Has reports whether the annotation with the specified name (e.g. cti.reference) is set.
+func (a Annotations) Has(name string) bool {
+	switch name {
+	case Cti:
+		return a.Cti != nil
+	case ID:
+		return a.ID != nil
+	case DisplayName:
+		return a.DisplayName != nil
+	case Description:
+		return a.Description != nil
+	case Reference:
+		return a.Reference != nil
+	case Overridable:
+		return a.Overridable != nil
+	case Final:
+		return a.Final != nil
+	case Asset:
+		return a.Asset != nil
+	case L10n:
+		return a.L10N != nil
+	case Schema:
+		return a.Schema != nil
+	case Meta:
+		return a.Meta != ""
+	case PropertyNames:
+		return a.PropertyNames != nil
+	}
+	return false
+}
+
+// IsKnownAnnotation reports whether the annotation is supported by the metadata model.
+func IsKnownAnnotation(name string) bool {
+	switch name {
+	case Cti, ID, DisplayName, Description, Reference, Overridable, Final, Asset, L10n, Schema, Meta, PropertyNames:
+		return true
+	}
+	return false
+}
+
+// IsType reports whether the entity is a CTI type.
+func (e *Entity) IsType() bool {
+	return e.Values == nil && e.Schema != nil
+}
+
+// IsInstance reports whether the entity is a CTI instance.
+func (e *Entity) IsInstance() bool {
+	return e.Values != nil
+}
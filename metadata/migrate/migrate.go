@@ -0,0 +1,443 @@
+// Package migrate bootstraps packages from existing repositories of RAML and JSON Schema files, e.g. schemas
+// maintained before the repository became a CTI package.
+//
+// Files are scanned as YAML and JSON documents rather than parsed as RAML, so broken files are reported instead of
+// failing the scan. The result is a best-effort index of the package along with issues that need manual fixes.
+package migrate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ramlHeader    = "#%RAML 1.0"
+	fragmentLib   = "Library"
+	ctiLibrary    = "cti.raml"
+	examplesDir   = "examples"
+	ctiAnnotation = "(cti.cti)"
+)
+
+var (
+	// ctiRe matches identifiers of CTI types and instances, expressions are cut at the first character that is not
+	// a part of identifiers.
+	ctiRe = regexp.MustCompile(`\bcti\.[a-z][a-z0-9_]*\.[a-z][a-z0-9_]*\.[a-z0-9_.~-]+`)
+	// segmentRe matches segments of identifiers with the vendor and the package, e.g. x.y.topic.v1.0.
+	segmentRe = regexp.MustCompile(`^([a-z][a-z0-9_]*)\.([a-z][a-z0-9_]*)\.[a-z_][a-z0-9_.]*\.v\d+\.\d+$`)
+)
+
+// Issue is an item of the repository that needs a manual fix.
+type Issue struct {
+	File    string `json:"file"`
+	Line    int    `json:"line,omitempty"`
+	Message string `json:"message"`
+}
+
+func (i Issue) String() string {
+	if i.Line == 0 {
+		return i.File + ": " + i.Message
+	}
+	return fmt.Sprintf("%s:%d: %s", i.File, i.Line, i.Message)
+}
+
+// Result is the inferred content of the package. Paths are relative to the scanned directory.
+type Result struct {
+	PackageID string   `json:"package_id"`
+	Apis      []string `json:"apis"`
+	Entities  []string `json:"entities"`
+	Examples  []string `json:"examples"`
+	// Dependencies are identifiers of packages whose entities are referenced. Sources of packages are not known,
+	// so dependencies are added manually.
+	Dependencies []string `json:"dependencies"`
+	// Schemas are JSON Schema documents that are not referenced by RAML files and are imported into CTI types
+	// manually, see importer.
+	Schemas []string `json:"schemas"`
+	Issues  []Issue  `json:"issues"`
+}
+
+type Option func(*scanner) error
+
+// WithPackageID sets the identifier of the package instead of inferring it from identifiers of CTI types.
+func WithPackageID(id string) Option {
+	return func(s *scanner) error {
+		if err := ctipackage.ValidateID(id); err != nil {
+			return err
+		}
+		s.packageID = id
+		return nil
+	}
+}
+
+type scanner struct {
+	dir       string
+	packageID string
+
+	raml map[string]*ramlFile
+	json []string
+	// used are files used or included by RAML files.
+	used map[string]bool
+	// deps are packages whose libraries are used from the dependencies directory.
+	deps   map[string]bool
+	issues []Issue
+}
+
+type ramlFile struct {
+	path     string
+	fragment string
+	// types is the number of declared types, ctiTypes are identifiers of CTI types with lines of their annotations.
+	types     int
+	ctiTypes  map[string]int
+	instances bool
+	// ctiLibrary is set if the file uses the CTI library, ctiAnnotations if it uses annotations of the library.
+	ctiLibrary     bool
+	ctiAnnotations int
+	// refs are identifiers referenced by the file with lines of their first occurrences.
+	refs map[string]int
+}
+
+// Scan scans RAML and JSON files of the directory. Hidden directories, e.g. .git, .dep and .ramlx, are skipped.
+func Scan(dir string, opts ...Option) (*Result, error) {
+	s := &scanner{dir: dir, raml: map[string]*ramlFile{}, used: map[string]bool{}, deps: map[string]bool{}}
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.walk(); err != nil {
+		return nil, err
+	}
+	if s.packageID == "" {
+		id, err := s.inferPackageID()
+		if err != nil {
+			return nil, err
+		}
+		s.packageID = id
+	}
+	return s.result(), nil
+}
+
+func (s *scanner) walk() error {
+	err := filepath.WalkDir(s.dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if p != s.dir && (strings.HasPrefix(d.Name(), ".") || d.Name() == "node_modules") {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		rel, err := filepath.Rel(s.dir, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		switch strings.ToLower(path.Ext(rel)) {
+		case ctipackage.RAMLExt:
+			return s.scanRAML(rel)
+		case ".json":
+			if rel != ctipackage.IndexFileName && rel != ctipackage.IndexLockFileName {
+				return s.scanJSON(rel)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("scan %s: %w", s.dir, err)
+	}
+	return nil
+}
+
+func (s *scanner) report(file string, line int, format string, args ...any) {
+	s.issues = append(s.issues, Issue{File: file, Line: line, Message: fmt.Sprintf(format, args...)})
+}
+
+func (s *scanner) scanRAML(rel string) error {
+	data, err := os.ReadFile(filepath.Join(s.dir, rel))
+	if err != nil {
+		return fmt.Errorf("read %s: %w", rel, err)
+	}
+	header, _, _ := strings.Cut(string(data), "\n")
+	header = strings.TrimSpace(header)
+	if !strings.HasPrefix(header, ramlHeader) {
+		s.report(rel, 1, "not a RAML 1.0 document, the file is skipped")
+		return nil
+	}
+	f := &ramlFile{
+		path:     rel,
+		fragment: strings.TrimSpace(strings.TrimPrefix(header, ramlHeader)),
+		ctiTypes: map[string]int{},
+		refs:     map[string]int{},
+	}
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		s.report(rel, 0, "invalid YAML, the file is skipped: %v", err)
+		return nil
+	}
+	if len(doc.Content) != 1 || doc.Content[0].Kind != yaml.MappingNode {
+		s.report(rel, 1, "document is not a mapping, the file is skipped")
+		return nil
+	}
+	root := doc.Content[0]
+	for i := 0; i+1 < len(root.Content); i += 2 {
+		key, value := root.Content[i], root.Content[i+1]
+		switch {
+		case key.Value == "uses":
+			s.scanUses(f, value)
+		case key.Value == "types" && value.Kind == yaml.MappingNode:
+			s.scanTypes(f, value)
+		case strings.HasPrefix(key.Value, "(") && !strings.HasPrefix(key.Value, "(cti."):
+			f.instances = true
+		}
+	}
+	s.scanValues(f, root)
+	s.raml[rel] = f
+	return nil
+}
+
+// scanUses records libraries used by the file. The CTI library is expected to be used from .ramlx.
+func (s *scanner) scanUses(f *ramlFile, uses *yaml.Node) {
+	if uses.Kind != yaml.MappingNode {
+		return
+	}
+	ramlx := path.Join(ctipackage.RamlxDirName, ctiLibrary)
+	for i := 0; i+1 < len(uses.Content); i += 2 {
+		value := uses.Content[i+1]
+		if strings.Contains(value.Value, "://") {
+			s.report(f.path, value.Line, "library %s is used by URL, install its package as a dependency", value.Value)
+			continue
+		}
+		used := path.Clean(path.Join(path.Dir(f.path), value.Value))
+		switch {
+		case used == ramlx:
+			f.ctiLibrary = true
+		case path.Base(used) == ctiLibrary:
+			f.ctiLibrary = true
+			s.used[used] = true
+			s.report(f.path, value.Line, "CTI library %s is replaced by the specification of the package, use %s instead",
+				value.Value, relPath(f.path, ramlx))
+		case strings.HasPrefix(used, ctipackage.DependencyDirName+"/"):
+			// Libraries of dependencies are installed with dependencies, e.g. .dep/x.y/entities.raml.
+			if segs := strings.Split(used, "/"); len(segs) > 2 {
+				s.deps[segs[1]] = true
+			}
+		default:
+			// Libraries used by APIs only are kept in entities, since APIs are not parsed with entities.
+			if f.fragment == fragmentLib {
+				s.used[used] = true
+			}
+			if _, err := os.Stat(filepath.Join(s.dir, filepath.FromSlash(used))); err != nil {
+				s.report(f.path, value.Line, "library %s is not found", value.Value)
+			}
+		}
+	}
+}
+
+func (s *scanner) scanTypes(f *ramlFile, types *yaml.Node) {
+	for i := 0; i+1 < len(types.Content); i += 2 {
+		f.types++
+		t := types.Content[i+1]
+		if t.Kind != yaml.MappingNode {
+			continue
+		}
+		for j := 0; j+1 < len(t.Content); j += 2 {
+			if t.Content[j].Value != ctiAnnotation {
+				continue
+			}
+			value := t.Content[j+1]
+			ids := []*yaml.Node{value}
+			if value.Kind == yaml.SequenceNode {
+				ids = value.Content
+			}
+			for _, id := range ids {
+				f.ctiTypes[id.Value] = id.Line
+			}
+		}
+	}
+}
+
+// scanValues records identifiers, annotations of the CTI library and files included by the file.
+func (s *scanner) scanValues(f *ramlFile, node *yaml.Node) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		if node.Tag == "!include" {
+			s.used[path.Clean(path.Join(path.Dir(f.path), node.Value))] = true
+			return
+		}
+		if strings.HasSuffix(node.Value, ".json") && !strings.ContainsAny(node.Value, " \n") {
+			s.used[path.Clean(path.Join(path.Dir(f.path), node.Value))] = true
+		}
+		for _, id := range ctiRe.FindAllString(node.Value, -1) {
+			if _, ok := f.refs[id]; !ok {
+				f.refs[id] = node.Line
+			}
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if strings.HasPrefix(node.Content[i].Value, "(cti.") {
+				f.ctiAnnotations = node.Content[i].Line
+			}
+			s.scanValues(f, node.Content[i+1])
+		}
+	default:
+		for _, n := range node.Content {
+			s.scanValues(f, n)
+		}
+	}
+}
+
+// scanJSON records JSON Schema documents, other JSON files are ignored.
+func (s *scanner) scanJSON(rel string) error {
+	data, err := os.ReadFile(filepath.Join(s.dir, rel))
+	if err != nil {
+		return fmt.Errorf("read %s: %w", rel, err)
+	}
+	var doc map[string]any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		s.report(rel, 0, "invalid JSON, the file is skipped: %v", err)
+		return nil
+	}
+	for _, key := range []string{"$schema", "definitions", "$defs", "properties"} {
+		if _, ok := doc[key]; ok {
+			s.json = append(s.json, rel)
+			return nil
+		}
+	}
+	return nil
+}
+
+// inferPackageID returns the package that owns most of CTI types declared in the directory.
+func (s *scanner) inferPackageID() (string, error) {
+	counts := map[string]int{}
+	for _, f := range s.raml {
+		for id := range f.ctiTypes {
+			if pkgs := packagesOf(id); len(pkgs) != 0 {
+				counts[pkgs[len(pkgs)-1]]++
+			}
+		}
+	}
+	res := ""
+	for id, n := range counts {
+		if res == "" || n > counts[res] || n == counts[res] && id < res {
+			res = id
+		}
+	}
+	if res == "" {
+		return "", fmt.Errorf("package id is not inferred, %s declares no CTI types", s.dir)
+	}
+	return res, nil
+}
+
+func (s *scanner) result() *Result {
+	r := &Result{PackageID: s.packageID}
+	deps := s.deps
+	for _, rel := range sortedKeys(s.raml) {
+		f := s.raml[rel]
+		for _, id := range sortedKeys(f.refs) {
+			for _, pkg := range packagesOf(id) {
+				if pkg != s.packageID {
+					deps[pkg] = true
+				}
+			}
+		}
+		for _, id := range sortedKeys(f.ctiTypes) {
+			pkgs := packagesOf(id)
+			switch {
+			case len(pkgs) == 0:
+				s.report(rel, f.ctiTypes[id], "invalid identifier %s of the CTI type", id)
+			case pkgs[len(pkgs)-1] != s.packageID:
+				s.report(rel, f.ctiTypes[id], "type %s belongs to package %s instead of %s", id, pkgs[len(pkgs)-1], s.packageID)
+			}
+		}
+		if f.ctiAnnotations != 0 && !f.ctiLibrary {
+			s.report(rel, f.ctiAnnotations, "annotations of CTI are used without the CTI library, add cti: %s to uses",
+				relPath(rel, path.Join(ctipackage.RamlxDirName, ctiLibrary)))
+		}
+
+		switch f.fragment {
+		case "":
+			r.Apis = append(r.Apis, rel)
+		case fragmentLib:
+			s.classifyLibrary(r, f)
+		default:
+			s.report(rel, 1, "RAML fragment %s is not supported, declare its types in a library", f.fragment)
+		}
+	}
+	delete(deps, s.packageID)
+	r.Dependencies = sortedKeys(deps)
+	for _, rel := range s.json {
+		if !s.used[rel] {
+			r.Schemas = append(r.Schemas, rel)
+			s.report(rel, 0, "JSON Schema is not used by RAML files, import it with cti import jsonschema %s", rel)
+		}
+	}
+	sort.SliceStable(s.issues, func(i, j int) bool {
+		if s.issues[i].File != s.issues[j].File {
+			return s.issues[i].File < s.issues[j].File
+		}
+		return s.issues[i].Line < s.issues[j].Line
+	})
+	r.Issues = s.issues
+	return r
+}
+
+// classifyLibrary adds libraries that are not used by other files to entities, or to examples if they are in
+// the examples directory. Used libraries are parsed with entities that use them.
+func (s *scanner) classifyLibrary(r *Result, f *ramlFile) {
+	if s.used[f.path] {
+		return
+	}
+	if f.types == 0 && !f.instances {
+		s.report(f.path, 0, "library declares neither types nor instances and is not used, the file is skipped")
+		return
+	}
+	if len(f.ctiTypes) == 0 && f.types != 0 {
+		s.report(f.path, 0, "library declares no CTI types, annotate entities with %s", ctiAnnotation)
+	}
+	if strings.HasPrefix(f.path, examplesDir+"/") || strings.Contains(f.path, "/"+examplesDir+"/") {
+		r.Examples = append(r.Examples, f.path)
+		return
+	}
+	r.Entities = append(r.Entities, f.path)
+}
+
+// packagesOf returns packages of segments of the identifier, e.g. [a.b c.d] for cti.a.b.x.v1.0~c.d.y.v1.0.
+// Segments of anonymous instances are skipped.
+func packagesOf(id string) []string {
+	var res []string
+	for _, seg := range strings.Split(strings.TrimPrefix(id, "cti."), "~") {
+		if m := segmentRe.FindStringSubmatch(seg); m != nil {
+			res = append(res, m[1]+"."+m[2])
+		}
+	}
+	return res
+}
+
+// relPath returns the path of the target relative to the directory of the file.
+func relPath(file, target string) string {
+	rel, err := filepath.Rel(filepath.FromSlash(path.Dir(file)), filepath.FromSlash(target))
+	if err != nil {
+		return target
+	}
+	return filepath.ToSlash(rel)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
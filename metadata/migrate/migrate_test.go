@@ -0,0 +1,56 @@
+package migrate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Scan(t *testing.T) {
+	r, err := Scan(filepath.Join("testdata", "legacy"))
+	require.NoError(t, err)
+
+	require.Equal(t, "acme.shop", r.PackageID)
+	require.Equal(t, []string{"api.raml"}, r.Apis)
+	require.Equal(t, []string{"types/events.raml", "types/legacy.raml", "types/orders.raml"}, r.Entities)
+	require.Equal(t, []string{"examples/sample.raml"}, r.Examples)
+	require.Equal(t, []string{"base.core", "other.pkg"}, r.Dependencies)
+	require.Equal(t, []string{"schemas/order.json"}, r.Schemas)
+	require.Equal(t, []Issue{
+		{File: "fragments/user.raml", Line: 1, Message: "RAML fragment DataType is not supported, declare its types in a library"},
+		{File: "notes.raml", Line: 1, Message: "not a RAML 1.0 document, the file is skipped"},
+		{File: "schemas/order.json", Message: "JSON Schema is not used by RAML files, import it with cti import jsonschema schemas/order.json"},
+		{File: "types/events.raml", Line: 3, Message: "CTI library ../specs/cti.raml is replaced by the specification of the package, use ../.ramlx/cti.raml instead"},
+		{File: "types/legacy.raml", Message: "library declares no CTI types, annotate entities with (cti.cti)"},
+		{File: "types/legacy.raml", Line: 4, Message: "annotations of CTI are used without the CTI library, add cti: ../.ramlx/cti.raml to uses"},
+		{File: "types/orders.raml", Line: 5, Message: "library missing.raml is not found"},
+		{File: "types/orders.raml", Line: 11, Message: "type cti.other.pkg.thing.v1.0 belongs to package other.pkg instead of acme.shop"},
+	}, r.Issues)
+}
+
+func Test_ScanWithPackageID(t *testing.T) {
+	r, err := Scan(filepath.Join("testdata", "legacy"), WithPackageID("other.pkg"))
+	require.NoError(t, err)
+	require.Equal(t, "other.pkg", r.PackageID)
+	require.Equal(t, []string{"acme.shop", "base.core"}, r.Dependencies)
+
+	_, err = Scan(filepath.Join("testdata", "legacy"), WithPackageID("invalid"))
+	require.ErrorContains(t, err, "invalid package ID")
+}
+
+func Test_ScanWithoutTypes(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "types.raml"), []byte("#%RAML 1.0 Library\ntypes:\n  A: string\n"), 0600))
+
+	_, err := Scan(dir)
+	require.ErrorContains(t, err, "package id is not inferred")
+}
+
+func Test_PackagesOf(t *testing.T) {
+	require.Equal(t, []string{"a.b"}, packagesOf("cti.a.b.x.v1.0"))
+	require.Equal(t, []string{"a.b", "c.d"}, packagesOf("cti.a.b.x.v1.0~c.d.y.v1.0"))
+	require.Equal(t, []string{"a.b"}, packagesOf("cti.a.b.x.v1.0~0f0e2a9c-0d3c-4f1a-9b5e-2f6a1c3e4d5b"))
+	require.Empty(t, packagesOf("cti.a.b"))
+}
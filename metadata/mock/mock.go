@@ -0,0 +1,200 @@
+// Package mock generates example instances of CTI types for demos, tests and API mocking.
+//
+// Values are generated from merged schemas of the types and respect required properties, enums, formats,
+// patterns and ranges. Generation is deterministic for the same seed and registry.
+package mock
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/xeipuuv/gojsonschema"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+	"github.com/acronis/go-cti/metadata/validator"
+)
+
+const (
+	// DefaultMaxDepth is a depth of nested objects after which optional properties are not generated.
+	DefaultMaxDepth = 4
+
+	// maxAttempts is a number of attempts to generate values that satisfy the schema.
+	maxAttempts = 20
+
+	definitionsRef        = "#/definitions/"
+	domainExtensionPrefix = "x-domainExt-"
+)
+
+type Generator struct {
+	registry  *registry.Registry
+	validator *validator.MetadataValidator
+	rand      *rand.Rand
+	seed      int64
+	seeded    bool
+	maxDepth  int
+}
+
+type Option func(*Generator)
+
+// WithSeed makes generation deterministic. A seed derived from the current time is used by default.
+func WithSeed(seed int64) Option {
+	return func(g *Generator) {
+		g.seed = seed
+		g.seeded = true
+	}
+}
+
+// WithMaxDepth sets the depth of nested objects after which only required properties are generated.
+func WithMaxDepth(depth int) Option {
+	return func(g *Generator) {
+		g.maxDepth = depth
+	}
+}
+
+// New creates a generator of instances of types of the registry. Instances refer to entities
+// of the registry in values of cti.reference properties.
+func New(r *registry.Registry, opts ...Option) *Generator {
+	v := validator.MakeMetadataValidator()
+	v.LoadRegistry(r)
+	g := &Generator{registry: r, validator: v, maxDepth: DefaultMaxDepth}
+	for _, opt := range opts {
+		opt(g)
+	}
+	if !g.seeded {
+		g.seed = time.Now().UnixNano()
+	}
+	g.rand = rand.New(rand.NewSource(g.seed)) //nolint:gosec // mock values are not secrets
+	return g
+}
+
+// Instance generates an anonymous instance of the type identified by a random UUID, e.g.
+// cti.x.y.event.v1.0~3f2a.... Values are validated against the schema of the type, generation is retried
+// if they are not valid, e.g. if the pattern of a string conflicts with its length.
+func (g *Generator) Instance(typeCti string) (*metadata.Entity, error) {
+	s, err := g.prepare(typeCti)
+	if err != nil {
+		return nil, err
+	}
+	return g.instance(s, g.rand)
+}
+
+// Instances generates count instances of the type. Unlike Instance, the instances depend only on the seed
+// and the type, so the same instances are generated regardless of other generated types and the first
+// instances are the same for any count. This allows to regenerate datasets reproducibly.
+func (g *Generator) Instances(typeCti string, count int) (metadata.Entities, error) {
+	s, err := g.prepare(typeCti)
+	if err != nil {
+		return nil, err
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(typeCti))
+	rnd := rand.New(rand.NewSource(g.seed ^ int64(h.Sum64()))) //nolint:gosec // mock values are not secrets
+
+	res := make(metadata.Entities, 0, count)
+	for range count {
+		instance, err := g.instance(s, rnd)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, instance)
+	}
+	return res, nil
+}
+
+// typeSchema is the merged schema of the type with definitions to resolve its local references.
+type typeSchema struct {
+	typ    *metadata.Entity
+	schema map[string]any
+	defs   map[string]any
+	loader gojsonschema.JSONLoader
+}
+
+func (g *Generator) prepare(typeCti string) (*typeSchema, error) {
+	typ, ok := g.registry.GetType(typeCti)
+	if !ok {
+		return nil, fmt.Errorf("type %s not found", typeCti)
+	}
+	if typ.Final {
+		return nil, fmt.Errorf("type %s is final", typeCti)
+	}
+	schema, err := g.validator.GetMergedSchema(typ.Cti)
+	if err != nil {
+		return nil, fmt.Errorf("merge schema of %s: %w", typ.Cti, err)
+	}
+	defs, err := g.definitions(typ)
+	if err != nil {
+		return nil, err
+	}
+	return &typeSchema{
+		typ:    typ,
+		schema: schema,
+		defs:   defs,
+		loader: gojsonschema.NewGoLoader(withDefinitions(schema, defs)),
+	}, nil
+}
+
+func (g *Generator) instance(s *typeSchema, rnd *rand.Rand) (*metadata.Entity, error) {
+	var lastErr error
+	for range maxAttempts {
+		id, err := uuid.NewRandomFromReader(rnd)
+		if err != nil {
+			return nil, fmt.Errorf("generate identifier: %w", err)
+		}
+		instance := &metadata.Entity{Cti: s.typ.Cti + "~" + id.String()}
+		vg := &valueGenerator{g: g, rand: rnd, defs: s.defs, id: instance.Cti}
+		values, err := json.Marshal(vg.value(s.schema, 0))
+		if err != nil {
+			return nil, fmt.Errorf("serialize values: %w", err)
+		}
+		res, err := gojsonschema.Validate(s.loader, gojsonschema.NewBytesLoader(values))
+		if err != nil {
+			return nil, fmt.Errorf("validate values: %w", err)
+		}
+		if res.Valid() {
+			instance.Values = values
+			return instance, nil
+		}
+		msgs := make([]string, 0, len(res.Errors()))
+		for _, resErr := range res.Errors() {
+			msgs = append(msgs, resErr.String())
+		}
+		lastErr = errors.New(strings.Join(msgs, "; "))
+	}
+	return nil, fmt.Errorf("generate valid values of %s: %w", s.typ.Cti, lastErr)
+}
+
+// definitions collects definitions of schemas of the type and its ancestors to resolve local references
+// of the merged schema. Definitions of the type take precedence over ones of ancestors.
+func (g *Generator) definitions(typ *metadata.Entity) (map[string]any, error) {
+	res := make(map[string]any)
+	for _, entity := range append(metadata.Entities{typ}, g.registry.Ancestors(typ.Cti)...) {
+		var own map[string]any
+		if err := json.Unmarshal(entity.Schema, &own); err != nil {
+			return nil, fmt.Errorf("decode schema of %s: %w", entity.Cti, err)
+		}
+		defs, _ := own["definitions"].(map[string]any)
+		for name, def := range defs {
+			if _, ok := res[name]; !ok {
+				res[name] = def
+			}
+		}
+	}
+	return res, nil
+}
+
+// withDefinitions returns a copy of the schema with the definitions, so local references can be resolved.
+func withDefinitions(schema, defs map[string]any) map[string]any {
+	res := make(map[string]any, len(schema)+1)
+	for k, v := range schema {
+		res[k] = v
+	}
+	res["definitions"] = defs
+	return res
+}
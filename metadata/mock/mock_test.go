@@ -0,0 +1,204 @@
+package mock
+
+import (
+	"encoding/json"
+	"math/rand"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/registry"
+)
+
+func testRegistry(t *testing.T) *registry.Registry {
+	t.Helper()
+
+	r, err := registry.New(metadata.EntitiesMap{
+		"cti.x.y.event.v1.0": {
+			Cti: "cti.x.y.event.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Event",
+				"definitions": {
+					"Event": {
+						"type": "object",
+						"properties": {
+							"id": {"type": "string", "pattern": "^cti\\.", "x-custom": {"x-domainExt-cti.id": true}},
+							"topic": {"type": "string", "x-custom": {"x-domainExt-cti.reference": "cti.x.y.topic.v1.0"}},
+							"severity": {"type": "string", "enum": ["low", "high"]},
+							"created_at": {"type": "string", "format": "date-time"},
+							"code": {"type": "string", "pattern": "^[A-Z]{3}-\\d{4}$"},
+							"count": {"type": "integer", "minimum": 10, "maximum": 50, "multipleOf": 5},
+							"ratio": {"type": "number", "minimum": 0, "exclusiveMaximum": 1},
+							"tags": {"type": "array", "items": {"$ref": "#/definitions/Tag"}, "minItems": 1, "uniqueItems": true},
+							"labels": {"type": "object", "additionalProperties": {"type": "boolean"}, "minProperties": 1},
+							"owner": {"anyOf": [{"type": "null"}, {"$ref": "#/definitions/Person"}]}
+						},
+						"required": ["id", "topic", "severity", "created_at", "code", "count", "ratio", "tags", "labels", "owner"]
+					},
+					"Tag": {
+						"type": "object",
+						"properties": {"name": {"type": "string", "minLength": 3, "maxLength": 5}},
+						"required": ["name"]
+					},
+					"Person": {
+						"type": "object",
+						"properties": {
+							"email": {"type": "string", "format": "email"},
+							"manager": {"$ref": "#/definitions/Person"}
+						},
+						"required": ["email"]
+					}
+				}
+			}`),
+		},
+		"cti.x.y.event.v1.0~x.y.closed.v1.0": {
+			Cti:   "cti.x.y.event.v1.0~x.y.closed.v1.0",
+			Final: true,
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Closed",
+				"definitions": {"Closed": {"type": "object", "properties": {}}}
+			}`),
+		},
+		"cti.x.y.topic.v1.0": {
+			Cti: "cti.x.y.topic.v1.0",
+			Schema: []byte(`{
+				"$schema": "http://json-schema.org/draft-07/schema",
+				"$ref": "#/definitions/Topic",
+				"definitions": {"Topic": {"type": "object", "properties": {"name": {"type": "string"}}}}
+			}`),
+		},
+		"cti.x.y.topic.v1.0~x.y.users.v1.0": {
+			Cti:    "cti.x.y.topic.v1.0~x.y.users.v1.0",
+			Values: []byte(`{"name": "users"}`),
+		},
+	})
+	require.NoError(t, err)
+	return r
+}
+
+func Test_Instance(t *testing.T) {
+	r := testRegistry(t)
+	code := regexp.MustCompile(`^[A-Z]{3}-\d{4}$`)
+
+	for seed := int64(0); seed < 50; seed++ {
+		instance, err := New(r, WithSeed(seed)).Instance("cti.x.y.event.v1.0")
+		require.NoError(t, err)
+		require.True(t, strings.HasPrefix(instance.Cti, "cti.x.y.event.v1.0~"))
+
+		var values map[string]any
+		require.NoError(t, json.Unmarshal(instance.Values, &values))
+		require.Equal(t, instance.Cti, values["id"])
+		require.Equal(t, "cti.x.y.topic.v1.0~x.y.users.v1.0", values["topic"])
+		require.Contains(t, []any{"low", "high"}, values["severity"])
+		_, err = time.Parse(time.RFC3339, values["created_at"].(string))
+		require.NoError(t, err)
+		require.Regexp(t, code, values["code"])
+		require.Zero(t, int(values["count"].(float64))%5)
+		require.GreaterOrEqual(t, values["count"], 10.0)
+		require.LessOrEqual(t, values["count"], 50.0)
+		require.GreaterOrEqual(t, values["ratio"], 0.0)
+		require.Less(t, values["ratio"], 1.0)
+		require.NotEmpty(t, values["tags"])
+		require.NotEmpty(t, values["labels"])
+		require.Contains(t, values["owner"], "email")
+	}
+}
+
+func Test_InstanceDeterministic(t *testing.T) {
+	r := testRegistry(t)
+
+	first, err := New(r, WithSeed(42)).Instance("cti.x.y.event.v1.0")
+	require.NoError(t, err)
+	second, err := New(r, WithSeed(42)).Instance("cti.x.y.event.v1.0")
+	require.NoError(t, err)
+	require.Equal(t, first, second)
+
+	other, err := New(r, WithSeed(43)).Instance("cti.x.y.event.v1.0")
+	require.NoError(t, err)
+	require.NotEqual(t, first.Cti, other.Cti)
+}
+
+func Test_Instances(t *testing.T) {
+	r := testRegistry(t)
+
+	all, err := New(r, WithSeed(42)).Instances("cti.x.y.event.v1.0", 5)
+	require.NoError(t, err)
+	require.Len(t, all, 5)
+	ids := make(map[string]bool)
+	for _, instance := range all {
+		ids[instance.Cti] = true
+	}
+	require.Len(t, ids, 5)
+
+	g := New(r, WithSeed(42))
+	_, err = g.Instance("cti.x.y.event.v1.0")
+	require.NoError(t, err)
+	first, err := g.Instances("cti.x.y.event.v1.0", 2)
+	require.NoError(t, err)
+	require.Equal(t, all[:2], first)
+
+	_, err = g.Instances("cti.x.y.unknown.v1.0", 1)
+	require.ErrorContains(t, err, "type cti.x.y.unknown.v1.0 not found")
+}
+
+func Test_InstanceDepth(t *testing.T) {
+	r := testRegistry(t)
+
+	instance, err := New(r, WithSeed(1), WithMaxDepth(0)).Instance("cti.x.y.event.v1.0")
+	require.NoError(t, err)
+	var values map[string]any
+	require.NoError(t, json.Unmarshal(instance.Values, &values))
+	require.Len(t, values["tags"], 1)
+	require.Len(t, values["labels"], 1)
+	require.Equal(t, []string{"email"}, keys(values["owner"].(map[string]any)))
+}
+
+func Test_InstanceErrors(t *testing.T) {
+	r := testRegistry(t)
+	g := New(r)
+
+	_, err := g.Instance("cti.x.y.unknown.v1.0")
+	require.ErrorContains(t, err, "type cti.x.y.unknown.v1.0 not found")
+	_, err = g.Instance("cti.x.y.topic.v1.0~x.y.users.v1.0")
+	require.ErrorContains(t, err, "type cti.x.y.topic.v1.0~x.y.users.v1.0 not found")
+	_, err = g.Instance("cti.x.y.event.v1.0~x.y.closed.v1.0")
+	require.ErrorContains(t, err, "type cti.x.y.event.v1.0~x.y.closed.v1.0 is final")
+}
+
+func Test_Matching(t *testing.T) {
+	testCases := []struct {
+		name    string
+		pattern string
+	}{
+		{name: "literal", pattern: `^abc$`},
+		{name: "classes", pattern: `^[a-f0-9]{8}-\w+\s\S$`},
+		{name: "alternation", pattern: `^(foo|bar)+baz?$`},
+		{name: "unanchored", pattern: `x{2,}`},
+		{name: "any", pattern: `^.{3}$`},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vg := &valueGenerator{rand: rand.New(rand.NewSource(7))} //nolint:gosec // test values
+			for range 20 {
+				s, ok := vg.matching(tc.pattern)
+				require.True(t, ok)
+				require.Regexp(t, tc.pattern, s)
+			}
+		})
+	}
+}
+
+func keys(m map[string]any) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	return res
+}
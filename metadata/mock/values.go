@@ -0,0 +1,464 @@
+package mock
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/rand"
+	"regexp"
+	"regexp/syntax"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+const (
+	letters = "abcdefghijklmnopqrstuvwxyz"
+
+	// maxRepeat limits repetitions of unbounded quantifiers of patterns.
+	maxRepeat = 3
+	// numberRange is a range of numbers generated if the schema bounds them from one side or not at all.
+	numberRange = 100
+)
+
+// baseTime is the earliest generated date and time, values are within a year after it.
+var baseTime = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+var words = []string{
+	"alpha", "bravo", "charlie", "delta", "echo", "foxtrot", "golf", "hotel", "india", "juliet", "kilo", "lima",
+	"mike", "november", "oscar", "papa", "quebec", "romeo", "sierra", "tango", "uniform", "victor", "whiskey",
+}
+
+// valueGenerator generates values of a single instance.
+type valueGenerator struct {
+	g    *Generator
+	rand *rand.Rand
+	defs map[string]any
+	// id is the CTI of the generated instance, it is the value of properties annotated with cti.id.
+	id string
+}
+
+func (vg *valueGenerator) value(schema map[string]any, depth int) any {
+	if ref, ok := schema["$ref"].(string); ok {
+		if def, ok := vg.defs[strings.TrimPrefix(ref, definitionsRef)].(map[string]any); ok {
+			return vg.value(def, depth)
+		}
+		return nil
+	}
+	if v, ok := vg.annotated(schema); ok {
+		return v
+	}
+	if v, ok := schema["const"]; ok {
+		return v
+	}
+	if values, ok := schema["enum"].([]any); ok && len(values) > 0 {
+		return values[vg.rand.Intn(len(values))]
+	}
+	if examples, ok := schema["examples"].([]any); ok && len(examples) > 0 {
+		return examples[vg.rand.Intn(len(examples))]
+	}
+	for _, key := range []string{"anyOf", "oneOf"} {
+		if members, ok := schema[key].([]any); ok && len(members) > 0 {
+			member, _ := vg.pick(members, func(m any) bool {
+				s, _ := m.(map[string]any)
+				return s["type"] != "null"
+			}).(map[string]any)
+			return vg.value(member, depth)
+		}
+	}
+
+	switch typ := schema["type"].(type) {
+	case string:
+		return vg.typed(typ, schema, depth)
+	case []any:
+		t, _ := vg.pick(typ, func(t any) bool { return t != "null" }).(string)
+		return vg.typed(t, schema, depth)
+	}
+	if _, ok := schema["properties"]; ok {
+		return vg.typed("object", schema, depth)
+	}
+	return vg.word()
+}
+
+// annotated returns values of properties annotated with cti.id and cti.reference: the CTI of the instance
+// and CTIs of entities of the registry the reference allows respectively.
+func (vg *valueGenerator) annotated(schema map[string]any) (any, bool) {
+	custom, _ := schema[metadata.CustomKey].(map[string]any)
+	if v, ok := custom[domainExtensionPrefix+metadata.ID]; ok && v != false {
+		return vg.id, true
+	}
+	ref, ok := custom[domainExtensionPrefix+metadata.Reference]
+	if !ok || ref == false {
+		return nil, false
+	}
+	var candidates []string
+	switch ref := ref.(type) {
+	case string:
+		candidates = vg.referable(ref)
+	case []any:
+		for _, r := range ref {
+			if s, ok := r.(string); ok {
+				candidates = append(candidates, vg.referable(s)...)
+			}
+		}
+	default:
+		for _, entity := range vg.g.registry.Entities() {
+			candidates = append(candidates, entity.Cti)
+		}
+		sort.Strings(candidates)
+	}
+	if len(candidates) == 0 {
+		return nil, false
+	}
+	cti := candidates[vg.rand.Intn(len(candidates))]
+	if schema["type"] == "array" {
+		return []any{cti}, true
+	}
+	return cti, true
+}
+
+// referable returns CTIs of descendants of the referenced entity, e.g. instances of the referenced type,
+// or the reference itself if it has no descendants in the registry.
+func (vg *valueGenerator) referable(ref string) []string {
+	var res []string
+	for _, entity := range vg.g.registry.Descendants(ref) {
+		res = append(res, entity.Cti)
+	}
+	if len(res) == 0 {
+		return []string{ref}
+	}
+	sort.Strings(res)
+	return res
+}
+
+// pick returns a random item preferring items that satisfy the predicate.
+func (vg *valueGenerator) pick(items []any, preferred func(any) bool) any {
+	var candidates []any
+	for _, item := range items {
+		if preferred(item) {
+			candidates = append(candidates, item)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = items
+	}
+	return candidates[vg.rand.Intn(len(candidates))]
+}
+
+func (vg *valueGenerator) typed(typ string, schema map[string]any, depth int) any {
+	switch typ {
+	case "string":
+		return vg.string(schema)
+	case "integer":
+		return vg.integer(schema)
+	case "number":
+		return vg.number(schema)
+	case "boolean":
+		return vg.rand.Intn(2) == 1
+	case "array":
+		return vg.array(schema, depth)
+	case "object":
+		return vg.object(schema, depth)
+	default:
+		return nil
+	}
+}
+
+func (vg *valueGenerator) object(schema map[string]any, depth int) any {
+	res := make(map[string]any)
+	props, _ := schema["properties"].(map[string]any)
+	required := make(map[string]bool)
+	for _, name := range requiredNames(schema) {
+		required[name] = true
+	}
+	minProps, _ := intFacet(schema, "minProperties")
+	names := make([]string, 0, len(props))
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var optional []string
+	for _, name := range names {
+		if !required[name] {
+			optional = append(optional, name)
+			continue
+		}
+		prop, _ := props[name].(map[string]any)
+		res[name] = vg.value(prop, depth+1)
+	}
+	for _, name := range optional {
+		// Optional properties of deeply nested objects are skipped to stop at recursive references.
+		if len(res) >= minProps && (depth >= vg.g.maxDepth || vg.rand.Intn(2) == 0) {
+			continue
+		}
+		prop, _ := props[name].(map[string]any)
+		res[name] = vg.value(prop, depth+1)
+	}
+
+	if additional, ok := schema["additionalProperties"].(map[string]any); ok && len(props) == 0 {
+		count := minProps
+		if count == 0 && depth < vg.g.maxDepth {
+			count = 1 + vg.rand.Intn(2)
+		}
+		for i := 1; len(res) < count; i++ {
+			res[fmt.Sprintf("%s%d", vg.word(), i)] = vg.value(additional, depth+1)
+		}
+	}
+	return res
+}
+
+func (vg *valueGenerator) array(schema map[string]any, depth int) any {
+	items, _ := schema["items"].(map[string]any)
+	minItems, _ := intFacet(schema, "minItems")
+	maxItems, hasMax := intFacet(schema, "maxItems")
+	count := minItems
+	if depth < vg.g.maxDepth {
+		lo, hi := max(minItems, 1), minItems+2
+		if hasMax {
+			hi = min(hi, maxItems)
+		}
+		if lo <= hi {
+			count = lo + vg.rand.Intn(hi-lo+1)
+		}
+	}
+	unique, _ := schema["uniqueItems"].(bool)
+
+	res := make([]any, 0, count)
+	seen := make(map[string]bool, count)
+	for len(res) < count {
+		var item any
+		for range maxAttempts {
+			item = vg.value(items, depth+1)
+			if !unique {
+				break
+			}
+			data, _ := json.Marshal(item)
+			if !seen[string(data)] {
+				seen[string(data)] = true
+				break
+			}
+		}
+		res = append(res, item)
+	}
+	return res
+}
+
+func (vg *valueGenerator) integer(schema map[string]any) any {
+	lo, hi := vg.bounds(schema)
+	lo, hi = math.Ceil(lo), math.Floor(hi)
+	if m, ok := schema["multipleOf"].(float64); ok && m > 0 {
+		return vg.multiple(lo, hi, m)
+	}
+	if hi < lo {
+		return int64(lo)
+	}
+	return int64(lo) + vg.rand.Int63n(int64(hi-lo)+1)
+}
+
+func (vg *valueGenerator) number(schema map[string]any) any {
+	lo, hi := vg.bounds(schema)
+	if m, ok := schema["multipleOf"].(float64); ok && m > 0 {
+		return vg.multiple(lo, hi, m)
+	}
+	// Numbers are rounded to hundredths, so they look like amounts.
+	v := math.Round((lo+vg.rand.Float64()*(hi-lo))*100) / 100
+	return math.Max(lo, math.Min(hi, v))
+}
+
+// multiple returns a random multiple of m between lo and hi.
+func (vg *valueGenerator) multiple(lo, hi, m float64) float64 {
+	first, last := math.Ceil(lo/m), math.Floor(hi/m)
+	if last < first {
+		return first * m
+	}
+	return (first + float64(vg.rand.Int63n(int64(last-first)+1))) * m
+}
+
+// bounds returns inclusive bounds of numbers of the schema. Exclusive bounds of draft-04 are booleans.
+func (vg *valueGenerator) bounds(schema map[string]any) (float64, float64) {
+	const step = 0.01
+	lo, hasLo := schema["minimum"].(float64)
+	hi, hasHi := schema["maximum"].(float64)
+	switch exclusive := schema["exclusiveMinimum"].(type) {
+	case float64:
+		lo, hasLo = exclusive+step, true
+	case bool:
+		if exclusive && hasLo {
+			lo += step
+		}
+	}
+	switch exclusive := schema["exclusiveMaximum"].(type) {
+	case float64:
+		hi, hasHi = exclusive-step, true
+	case bool:
+		if exclusive && hasHi {
+			hi -= step
+		}
+	}
+	switch {
+	case hasLo && !hasHi:
+		hi = lo + numberRange
+	case !hasLo && hasHi:
+		lo = math.Min(0, hi-numberRange)
+	case !hasLo && !hasHi:
+		lo, hi = 0, numberRange
+	case hi-lo > math.MaxInt32:
+		// Wide ranges, e.g. of int64, are narrowed to keep values readable.
+		hi = lo + numberRange
+	}
+	return lo, hi
+}
+
+// formats generate values of string formats checked by the validator and common in CTI schemas.
+var formats = map[string]func(vg *valueGenerator) string{
+	"date-time": func(vg *valueGenerator) string { return vg.time().Format(time.RFC3339) },
+	"date":      func(vg *valueGenerator) string { return vg.time().Format(time.DateOnly) },
+	"time":      func(vg *valueGenerator) string { return vg.time().Format("15:04:05Z07:00") },
+	"email":     func(vg *valueGenerator) string { return vg.word() + "@example.com" },
+	"hostname":  func(vg *valueGenerator) string { return vg.word() + ".example.com" },
+	"uri":       func(vg *valueGenerator) string { return "https://example.com/" + vg.word() },
+	"ipv4": func(vg *valueGenerator) string {
+		return fmt.Sprintf("192.0.2.%d", 1+vg.rand.Intn(254))
+	},
+	"ipv6": func(vg *valueGenerator) string {
+		return fmt.Sprintf("2001:db8::%x", 1+vg.rand.Intn(0xfffe))
+	},
+	"uuid": func(vg *valueGenerator) string {
+		id, err := uuid.NewRandomFromReader(vg.rand)
+		if err != nil {
+			return uuid.Nil.String()
+		}
+		return id.String()
+	},
+}
+
+func (vg *valueGenerator) string(schema map[string]any) any {
+	format, _ := schema["format"].(string)
+	if gen, ok := formats[format]; ok {
+		return gen(vg)
+	}
+	minLength, _ := intFacet(schema, "minLength")
+	maxLength, hasMax := intFacet(schema, "maxLength")
+	if pattern, ok := schema["pattern"].(string); ok {
+		if s, ok := vg.matching(pattern); ok {
+			return s
+		}
+	}
+
+	s := vg.word()
+	for len(s) < minLength {
+		s += "-" + vg.word()
+	}
+	if hasMax && len(s) > maxLength {
+		s = s[:maxLength]
+	}
+	return s
+}
+
+// matching generates a string that matches the pattern.
+func (vg *valueGenerator) matching(pattern string) (string, bool) {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return "", false
+	}
+	var b strings.Builder
+	vg.regexp(re.Simplify(), &b)
+	matched, err := regexp.MatchString(pattern, b.String())
+	return b.String(), err == nil && matched
+}
+
+func (vg *valueGenerator) regexp(re *syntax.Regexp, b *strings.Builder) {
+	switch re.Op {
+	case syntax.OpLiteral:
+		b.WriteString(string(re.Rune))
+	case syntax.OpCharClass:
+		b.WriteRune(vg.class(re.Rune))
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		b.WriteByte(letters[vg.rand.Intn(len(letters))])
+	case syntax.OpCapture:
+		vg.regexp(re.Sub[0], b)
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			vg.regexp(sub, b)
+		}
+	case syntax.OpAlternate:
+		vg.regexp(re.Sub[vg.rand.Intn(len(re.Sub))], b)
+	case syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		lo, hi := re.Min, re.Max
+		switch re.Op {
+		case syntax.OpStar:
+			lo, hi = 0, maxRepeat
+		case syntax.OpPlus:
+			lo, hi = 1, maxRepeat
+		case syntax.OpQuest:
+			lo, hi = 0, 1
+		}
+		if hi < 0 {
+			hi = lo + maxRepeat
+		}
+		for range lo + vg.rand.Intn(hi-lo+1) {
+			vg.regexp(re.Sub[0], b)
+		}
+	}
+}
+
+// class returns a random rune of the character class given as pairs of ranges. Printable ASCII runes
+// are preferred.
+func (vg *valueGenerator) class(ranges []rune) rune {
+	type span struct{ lo, hi rune }
+	var printable, all []span
+	for i := 0; i+1 < len(ranges); i += 2 {
+		s := span{ranges[i], ranges[i+1]}
+		all = append(all, s)
+		if lo, hi := max(s.lo, '!'), min(s.hi, '~'); lo <= hi {
+			printable = append(printable, span{lo, hi})
+		}
+	}
+	if len(printable) > 0 {
+		all = printable
+	}
+	if len(all) == 0 {
+		return 'a'
+	}
+	s := all[vg.rand.Intn(len(all))]
+	return s.lo + rune(vg.rand.Intn(int(s.hi-s.lo)+1))
+}
+
+func (vg *valueGenerator) word() string {
+	return words[vg.rand.Intn(len(words))]
+}
+
+func (vg *valueGenerator) time() time.Time {
+	return baseTime.Add(time.Duration(vg.rand.Int63n(int64(365*24*time.Hour/time.Second))) * time.Second)
+}
+
+// requiredNames returns names of required properties of the schema. Merged schemas keep them as []string.
+func requiredNames(schema map[string]any) []string {
+	switch required := schema["required"].(type) {
+	case []string:
+		return required
+	case []any:
+		res := make([]string, 0, len(required))
+		for _, name := range required {
+			if s, ok := name.(string); ok {
+				res = append(res, s)
+			}
+		}
+		return res
+	default:
+		return nil
+	}
+}
+
+func intFacet(schema map[string]any, key string) (int, bool) {
+	v, ok := schema[key].(float64)
+	if !ok || v < 0 {
+		return 0, false
+	}
+	return int(v), true
+}
@@ -0,0 +1,73 @@
+package pacman
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+
+	"github.com/otiai10/copy"
+)
+
+// checkpointFiles are files and directories of the package that the package manager changes.
+var checkpointFiles = []string{ctipackage.IndexFileName, ctipackage.IndexLockFileName, ctipackage.DependencyDirName}
+
+// Checkpoint is a copy of the index, the lock and installed dependencies of the package, so changes of the package
+// manager are rolled back if adding dependencies fails, e.g. if the package does not pass validation with them.
+type Checkpoint struct {
+	baseDir string
+	dir     string
+}
+
+// NewCheckpoint copies the index, the lock and installed dependencies of the package directory.
+func NewCheckpoint(baseDir string) (*Checkpoint, error) {
+	dir, err := os.MkdirTemp("", ".cti-checkpoint-")
+	if err != nil {
+		return nil, fmt.Errorf("create checkpoint dir: %w", err)
+	}
+	c := &Checkpoint{baseDir: baseDir, dir: dir}
+	for _, name := range checkpointFiles {
+		src := filepath.Join(baseDir, name)
+		if _, err := os.Stat(src); errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			_ = c.Release()
+			return nil, fmt.Errorf("stat %s: %w", src, err)
+		}
+		if err := copy.Copy(src, filepath.Join(dir, name)); err != nil {
+			_ = c.Release()
+			return nil, fmt.Errorf("copy %s: %w", name, err)
+		}
+	}
+	return c, nil
+}
+
+// Rollback restores the index, the lock and installed dependencies of the package directory, and removes them if
+// they did not exist. Packages read before the rollback must be read again.
+func (c *Checkpoint) Rollback() error {
+	for _, name := range checkpointFiles {
+		dst := filepath.Join(c.baseDir, name)
+		if err := os.RemoveAll(dst); err != nil {
+			return fmt.Errorf("remove %s: %w", dst, err)
+		}
+		src := filepath.Join(c.dir, name)
+		if _, err := os.Stat(src); errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		// The copy may be on another device, so it is copied back instead of being moved.
+		if err := copy.Copy(src, dst); err != nil {
+			return fmt.Errorf("restore %s: %w", name, err)
+		}
+	}
+	return c.Release()
+}
+
+// Release removes the copy, keeping the changes.
+func (c *Checkpoint) Release() error {
+	if err := os.RemoveAll(c.dir); err != nil {
+		return fmt.Errorf("remove checkpoint dir: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package pacman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/filesys"
+	"github.com/stretchr/testify/require"
+)
+
+func readPackage(t *testing.T, dir string) *ctipackage.Package {
+	t.Helper()
+	pkg, err := ctipackage.New(dir)
+	require.NoError(t, err)
+	require.NoError(t, pkg.Read())
+	return pkg
+}
+
+func Test_Checkpoint(t *testing.T) {
+	packagePath := t.TempDir()
+	pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()), WithOffline(false))
+	require.NoError(t, err)
+
+	pkg, err := ctipackage.New(packagePath, ctipackage.WithID("xyz.mock"))
+	require.NoError(t, err)
+	require.NoError(t, pkg.Initialize())
+	require.NoError(t, pm.Add(pkg, map[string]string{"mock@b1": "v1.0.0"}))
+
+	hash, err := filesys.ComputeDirectoryHash(packagePath)
+	require.NoError(t, err)
+
+	checkpoint, err := NewCheckpoint(packagePath)
+	require.NoError(t, err)
+	// The second package fails to download after the first one is installed.
+	err = pm.Get(readPackage(t, packagePath), map[string]string{"mock@b3": "v3.4.5", "mock@b4": "v1.0.0"}, nil)
+	require.ErrorContains(t, err, "mock@b4")
+	require.NoError(t, checkpoint.Rollback())
+
+	rolledBack, err := filesys.ComputeDirectoryHash(packagePath)
+	require.NoError(t, err)
+	require.Equal(t, hash, rolledBack)
+	require.Equal(t, map[string]string{"mock@b1": "v1.0.0"}, readPackage(t, packagePath).Index.Depends)
+
+	checkpoint, err = NewCheckpoint(packagePath)
+	require.NoError(t, err)
+	require.NoError(t, pm.Get(readPackage(t, packagePath), map[string]string{"mock@b3": "v3.4.5"}, nil))
+	require.NoError(t, checkpoint.Release())
+	require.Equal(t, map[string]string{"mock@b1": "v1.0.0", "mock@b3": "v3.4.5"},
+		readPackage(t, packagePath).Index.Depends)
+}
+
+func Test_CheckpointOfNewFiles(t *testing.T) {
+	packagePath := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(packagePath, ctipackage.IndexFileName),
+		[]byte(`{"package_id": "xyz.mock"}`), 0600))
+
+	checkpoint, err := NewCheckpoint(packagePath)
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Join(packagePath, ctipackage.DependencyDirName, "mock.package1"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(packagePath, ctipackage.IndexLockFileName), []byte(`{}`), 0600))
+	require.NoError(t, checkpoint.Rollback())
+
+	require.FileExists(t, filepath.Join(packagePath, ctipackage.IndexFileName))
+	require.NoFileExists(t, filepath.Join(packagePath, ctipackage.IndexLockFileName))
+	require.NoDirExists(t, filepath.Join(packagePath, ctipackage.DependencyDirName))
+}
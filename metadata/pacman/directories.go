@@ -9,6 +9,9 @@ import (
 const (
 	AppEnvironVar = "CTIROOT"
 	AppUserDir    = ".cti"
+	// CacheDirEnvVar overrides the directory of downloaded packages and remote schemas, src of the root directory
+	// by default.
+	CacheDirEnvVar = "CTI_CACHE_DIR"
 )
 
 /*
@@ -23,6 +26,8 @@ const (
 				@v/ - version cache directory
 					<version>.index.json - index file
 					<version>.info - integrity info
+		schema/
+			<host>/<path> - remote JSON schema documents referenced with $ref
 	<package id>/
 		@<version>/ - package directory
 */
@@ -48,6 +53,20 @@ func (pm *packageManager) getPackageInfoPath(pkgId string, version string) strin
 }
 
 func GetRootDir() (string, error) {
+	rootDir, err := rootDirPath()
+	if err != nil {
+		return "", err
+	}
+	if _, err := os.Stat(rootDir); err != nil {
+		err := os.Mkdir(rootDir, 0755)
+		if err != nil {
+			return "", fmt.Errorf("create root dir: %w", err)
+		}
+	}
+	return rootDir, nil
+}
+
+func rootDirPath() (string, error) {
 	rootDir := os.Getenv(AppEnvironVar)
 	if rootDir == "" {
 		userDir, err := os.UserHomeDir()
@@ -56,25 +75,42 @@ func GetRootDir() (string, error) {
 		}
 		rootDir = filepath.Join(userDir, AppUserDir)
 	}
-	if _, err := os.Stat(rootDir); err != nil {
-		err := os.Mkdir(rootDir, 0755)
-		if err != nil {
-			return "", fmt.Errorf("create root dir: %w", err)
-		}
-	}
 	return rootDir, nil
 }
 
 func GetCtiPackagesCacheDir() (string, error) {
-	rootDir, err := GetRootDir()
+	if os.Getenv(CacheDirEnvVar) == "" {
+		if _, err := GetRootDir(); err != nil {
+			return "", fmt.Errorf("get root dir: %w", err)
+		}
+	}
+	pkgCacheDir, err := CacheDirPath()
 	if err != nil {
 		return "", fmt.Errorf("get root dir: %w", err)
 	}
-	pkgCacheDir := filepath.Join(rootDir, "src")
-	if _, err := os.Stat(pkgCacheDir); err != nil {
-		if err := os.Mkdir(pkgCacheDir, os.ModePerm); err != nil {
-			return "", fmt.Errorf("create package cache dir: %w", err)
-		}
+	if err := os.MkdirAll(pkgCacheDir, os.ModePerm); err != nil {
+		return "", fmt.Errorf("create package cache dir: %w", err)
 	}
 	return pkgCacheDir, nil
 }
+
+// CacheDirPath returns the directory of GetCtiPackagesCacheDir without creating it.
+func CacheDirPath() (string, error) {
+	if cacheDir := os.Getenv(CacheDirEnvVar); cacheDir != "" {
+		return cacheDir, nil
+	}
+	rootDir, err := rootDirPath()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(rootDir, "src"), nil
+}
+
+// GetSchemaCacheDir returns a directory where remote JSON schema documents are cached.
+func GetSchemaCacheDir() (string, error) {
+	pkgCacheDir, err := GetCtiPackagesCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(pkgCacheDir, ".cache", "schema"), nil
+}
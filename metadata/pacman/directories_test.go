@@ -0,0 +1,47 @@
+package pacman
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_CacheDirPath(t *testing.T) {
+	root := filepath.Join(t.TempDir(), "root")
+	cacheDir := filepath.Join(t.TempDir(), "cache")
+
+	testcases := map[string]struct {
+		cacheDir string
+		expected string
+	}{
+		"root directory": {
+			expected: filepath.Join(root, "src"),
+		},
+		"cache directory": {
+			cacheDir: cacheDir,
+			expected: cacheDir,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv(AppEnvironVar, root)
+			t.Setenv(CacheDirEnvVar, tc.cacheDir)
+
+			dir, err := CacheDirPath()
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, dir)
+			require.NoDirExists(t, dir)
+
+			dir, err = GetCtiPackagesCacheDir()
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, dir)
+			require.DirExists(t, dir)
+
+			schemaDir, err := GetSchemaCacheDir()
+			require.NoError(t, err)
+			require.Equal(t, filepath.Join(tc.expected, ".cache", "schema"), schemaDir)
+		})
+	}
+}
@@ -4,12 +4,18 @@ import (
 	"fmt"
 	"log/slog"
 	"os"
+	"path/filepath"
 
 	"github.com/acronis/go-cti/metadata/ctipackage"
 	"github.com/acronis/go-cti/metadata/filesys"
+	"github.com/acronis/go-cti/metadata/storage"
 )
 
 func (pm *packageManager) downloadDependency(source, version string) (CachedDependencyInfo, error) {
+	if pm.Offline {
+		return pm.cachedDependency(source, version)
+	}
+
 	info, err := pm.Storage.Discover(source, version)
 	if err != nil {
 		return CachedDependencyInfo{}, fmt.Errorf("discover source %s version %s: %w", source, version, err)
@@ -79,3 +85,40 @@ func (pm *packageManager) downloadDependency(source, version string) (CachedDepe
 		Index:     *movedIndex,
 	}, nil
 }
+
+// cachedDependency returns the package of the source downloaded before, so it is installed without network access.
+func (pm *packageManager) cachedDependency(source, version string) (CachedDependencyInfo, error) {
+	infos, err := filepath.Glob(filepath.Join(pm.getPackageCacheDir(), "*", "@v", version+".info"))
+	if err != nil {
+		return CachedDependencyInfo{}, fmt.Errorf("find cached packages: %w", err)
+	}
+	for _, infoPath := range infos {
+		pkgID := filepath.Base(filepath.Dir(filepath.Dir(infoPath)))
+		info := PackageIntegrityInfo{}
+		if err := info.Read(pm, pkgID, version); err != nil {
+			return CachedDependencyInfo{}, fmt.Errorf("read package info: %w", err)
+		}
+		if info.Source != source {
+			continue
+		}
+
+		targetDir := pm.getPackageDir(pkgID, version)
+		idx, err := ctipackage.ReadIndex(targetDir)
+		if err != nil {
+			return CachedDependencyInfo{}, fmt.Errorf("read index.json: %w", err)
+		}
+		hash, err := filesys.ComputeDirectoryHash(targetDir)
+		if err != nil {
+			return CachedDependencyInfo{}, fmt.Errorf("compute directory hash: %w", err)
+		}
+		slog.Info("Found dependency in the cache", slog.String("package", source), slog.String("version", version))
+		return CachedDependencyInfo{
+			Path:      targetDir,
+			Source:    source,
+			Version:   version,
+			Integrity: hash,
+			Index:     *idx,
+		}, nil
+	}
+	return CachedDependencyInfo{}, fmt.Errorf("%s %s is not in the cache: %w", source, version, storage.ErrOffline)
+}
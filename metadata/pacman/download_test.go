@@ -3,6 +3,7 @@ package pacman
 import (
 	"testing"
 
+	"github.com/acronis/go-cti/metadata/storage"
 	"github.com/stretchr/testify/require"
 )
 
@@ -15,3 +16,34 @@ func Test_Download(t *testing.T) {
 
 	require.Len(t, res, 1)
 }
+
+func Test_DownloadOffline(t *testing.T) {
+	cacheDir := t.TempDir()
+	depends := map[string]string{"mock@b1": "v1.0.0"}
+
+	offline, err := New(WithStorage(&mockStorage{}), WithPackagesCache(cacheDir), WithOffline(true))
+	require.NoError(t, err)
+	_, err = offline.Download(depends)
+	require.ErrorIs(t, err, storage.ErrOffline)
+
+	online, err := New(WithStorage(&mockStorage{}), WithPackagesCache(cacheDir), WithOffline(false))
+	require.NoError(t, err)
+	downloaded, err := online.Download(depends)
+	require.NoError(t, err)
+
+	cached, err := offline.Download(depends)
+	require.NoError(t, err)
+	require.Equal(t, downloaded, cached)
+
+	_, err = offline.Download(map[string]string{"mock@b1": "v2.0.0"})
+	require.ErrorIs(t, err, storage.ErrOffline)
+}
+
+func Test_OfflineEnvVar(t *testing.T) {
+	t.Setenv(storage.OfflineEnvVar, "1")
+	pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()))
+	require.NoError(t, err)
+
+	_, err = pm.Download(map[string]string{"mock@b1": "v1.0.0"})
+	require.ErrorIs(t, err, storage.ErrOffline)
+}
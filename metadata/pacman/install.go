@@ -4,6 +4,9 @@ import (
 	"fmt"
 	"log/slog"
 	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
 
 	"github.com/acronis/go-cti/metadata/ctipackage"
 	"github.com/acronis/go-cti/metadata/filesys"
@@ -23,7 +26,16 @@ func (pm *packageManager) installDependencies(pkg *ctipackage.Package, depends m
 		return fmt.Errorf("sync package: %w", err)
 	}
 
-	installed, err := pm.Download(depends)
+	// Local directories replace sources in the whole graph of dependencies.
+	replace := make(map[string]string, len(pkg.Index.Replace))
+	for source, dir := range pkg.Index.Replace {
+		if !filepath.IsAbs(dir) {
+			dir = filepath.Join(pkg.BaseDir, dir)
+		}
+		replace[source] = dir
+	}
+
+	installed, err := pm.download(depends, []CachedDependencyInfo{}, replace, 1)
 	if err != nil {
 		return fmt.Errorf("download dependencies: %w", err)
 	}
@@ -73,8 +85,19 @@ func (pm *packageManager) installFromCache(target *ctipackage.Package, depends [
 			return fmt.Errorf("read package: %w", err)
 		}
 
+		// Dependencies skipped due to the depth limit leave references to their entities unresolved.
+		missing := missingDependencies(info, depends)
+		if len(missing) != 0 {
+			slog.Warn("Dependency depends on packages that are not installed, references to their entities are unresolved",
+				slog.String("package", info.Index.PackageID),
+				slog.Any("missing", missing))
+		}
 		if err := pkg.Parse(); err != nil {
-			return fmt.Errorf("parse package: %w", err)
+			if len(missing) == 0 || !unresolvedReference(err, target.BaseDir, depends) {
+				return fmt.Errorf("parse package: %w", err)
+			}
+			slog.Warn("Dependency is not parsed", slog.String("package", info.Index.PackageID),
+				slog.String("error", err.Error()))
 		}
 
 		checksum, err := filesys.ComputeDirectoryHash(depPath)
@@ -89,7 +112,49 @@ func (pm *packageManager) installFromCache(target *ctipackage.Package, depends [
 			Integrity: checksum,
 			Source:    info.Source,
 			Depends:   info.Index.Depends,
+			Replace:   target.Index.Replace[info.Source],
 		}
 	}
 	return nil
 }
+
+// missingDependencies returns sources of dependencies of the package that are not installed.
+func missingDependencies(info CachedDependencyInfo, installed []CachedDependencyInfo) []string {
+	var missing []string
+	for source := range info.Index.Depends {
+		if !slices.ContainsFunc(installed, func(i CachedDependencyInfo) bool { return i.Source == source }) {
+			missing = append(missing, source)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
+
+// unresolvedReference reports whether the parse error is caused by a reference to a file of a dependency that is not
+// installed in the target package. The RAML parser does not wrap errors of files, so the path of the last file that
+// was not opened is taken from the message.
+func unresolvedReference(err error, targetDir string, installed []CachedDependencyInfo) bool {
+	msg := err.Error()
+	i := strings.LastIndex(msg, "open ")
+	if i == -1 {
+		return false
+	}
+	file, _, ok := strings.Cut(msg[i+len("open "):], ": ")
+	if !ok {
+		return false
+	}
+	depsDir, err := filepath.Abs(filepath.Join(targetDir, ctipackage.DependencyDirName))
+	if err != nil {
+		return false
+	}
+	file, err = filepath.Abs(file)
+	if err != nil {
+		return false
+	}
+	rel, err := filepath.Rel(depsDir, file)
+	if err != nil || !filepath.IsLocal(rel) {
+		return false
+	}
+	packageID, _, _ := strings.Cut(filepath.ToSlash(rel), "/")
+	return !slices.ContainsFunc(installed, func(i CachedDependencyInfo) bool { return i.Index.PackageID == packageID })
+}
@@ -0,0 +1,118 @@
+package pacman
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_MissingDependencies(t *testing.T) {
+	installed := []CachedDependencyInfo{
+		{Source: "mock@b1", Index: ctipackage.Index{PackageID: "mock.package1"}},
+		{Source: "mock@b2", Index: ctipackage.Index{PackageID: "mock.package2"}},
+	}
+	testcases := map[string]struct {
+		depends map[string]string
+		missing []string
+	}{
+		"no dependencies": {},
+		"installed":       {depends: map[string]string{"mock@b1": "v1.0.0", "mock@b2": "v2.0.0"}},
+		"missing": {
+			depends: map[string]string{"mock@b4": "v4.0.0", "mock@b1": "v1.0.0", "mock@b3": "v3.4.5"},
+			missing: []string{"mock@b3", "mock@b4"},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			info := CachedDependencyInfo{Index: ctipackage.Index{PackageID: "mock.package5", Depends: tc.depends}}
+			require.Equal(t, tc.missing, missingDependencies(info, installed))
+		})
+	}
+}
+
+func Test_UnresolvedReference(t *testing.T) {
+	installed := []CachedDependencyInfo{{Source: "mock@b4", Index: ctipackage.Index{PackageID: "mock.package4"}}}
+	testcases := map[string]struct {
+		err        string
+		unresolved bool
+	}{
+		"not installed": {
+			err: "parse uses library: loading: /pkg/.dep/mock.package2/foo/bar.raml:1: open fragment file: open file: " +
+				"open /pkg/.dep/mock.package2/foo/bar.raml: no such file or directory",
+			unresolved: true,
+		},
+		"installed": {
+			err: "parse uses library: loading: /pkg/.dep/mock.package4/lib.raml:1: open fragment file: open file: " +
+				"open /pkg/.dep/mock.package4/lib.raml: no such file or directory",
+		},
+		"outside of dependencies": {
+			err: "open file: open /pkg/lib/missing.raml: no such file or directory",
+		},
+		"not a file": {
+			err: "parse types: cti.mock.package2.bar.v1.0: parent type is not found",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, tc.unresolved, unresolvedReference(errors.New(tc.err), "/pkg", installed))
+		})
+	}
+}
+
+func Test_InstallDepth(t *testing.T) {
+	testcases := map[string]struct {
+		depth     int
+		depends   map[string]string
+		installed []string
+		err       string
+	}{
+		"unresolved references of direct only": {
+			depth:     1,
+			depends:   map[string]string{"mock@b4": "v4.0.0"},
+			installed: []string{"mock.package4"},
+		},
+		"unresolved references resolved": {
+			depends:   map[string]string{"mock@b4": "v4.0.0"},
+			installed: []string{"mock.package1", "mock.package2", "mock.package4"},
+		},
+		"broken reference of direct only": {
+			depth:   1,
+			depends: map[string]string{"mock@b5": "v5.0.0"},
+			err:     "lib/missing.raml",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			packagePath := t.TempDir()
+			pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()), WithOffline(false),
+				WithDepth(tc.depth))
+			require.NoError(t, err)
+
+			pkg, err := ctipackage.New(packagePath, ctipackage.WithID("xyz.mock"))
+			require.NoError(t, err)
+			require.NoError(t, pkg.Initialize())
+			err = pm.Add(pkg, tc.depends)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+
+			entries, err := os.ReadDir(filepath.Join(packagePath, ctipackage.DependencyDirName))
+			require.NoError(t, err)
+			var installed []string
+			for _, e := range entries {
+				installed = append(installed, e.Name())
+			}
+			require.Equal(t, tc.installed, installed)
+			require.Len(t, pkg.IndexLock.SourceInfo, len(tc.installed))
+		})
+	}
+}
@@ -4,10 +4,12 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/acronis/go-cti/metadata/storage"
 
 	"github.com/otiai10/copy"
+	"golang.org/x/mod/module"
 )
 
 type mockStorage struct {
@@ -58,3 +60,28 @@ func (m *mockStorage) Discover(name string, version string) (storage.Origin, err
 		Version: version,
 	}, nil
 }
+
+func (m *mockStorage) Versions(name string) ([]string, error) {
+	entries, err := os.ReadDir(filepath.Join("fixtures", "storage", name))
+	if err != nil {
+		return nil, fmt.Errorf("read versions of %s: %w", name, err)
+	}
+	versions := make([]string, 0, len(entries))
+	for _, e := range entries {
+		versions = append(versions, e.Name())
+	}
+	return versions, nil
+}
+
+func (m *mockStorage) PseudoVersion(name string, rev string) (string, error) {
+	versions, err := m.Versions(name)
+	if err != nil {
+		return "", err
+	}
+	for _, v := range versions {
+		if r, err := module.PseudoVersionRev(v); err == nil && strings.HasPrefix(r, rev) {
+			return v, nil
+		}
+	}
+	return "", fmt.Errorf("commit %s of %s not found", rev, name)
+}
@@ -11,12 +11,25 @@ import (
 )
 
 type PackageManager interface {
-	// Add new dependencies to index.lock
+	// Add new dependencies to index.lock. Versions may be queries, see ResolveVersion.
 	Add(pkg *ctipackage.Package, depends map[string]string) error
 	// Install dependencies from index.lock
 	Install(pkg *ctipackage.Package) error
-	// Download dependencies and their sub-dependencies
+	// Replace installs the package of the local directory as a dependency, see ctipackage.Index.Replace.
+	Replace(pkg *ctipackage.Package, dir string) error
+	// Get adds dependencies of sources and of local directories at once, updating the index and the lock once.
+	// Changes are not rolled back if a dependency fails to install, see Checkpoint.
+	Get(pkg *ctipackage.Package, depends map[string]string, dirs []string) error
+	// Update updates direct dependencies to the highest compatible versions, see packageManager.Update.
+	Update(pkg *ctipackage.Package, sources []string, patch bool) error
+	// Download dependencies and their sub-dependencies. Versions may be queries, see ResolveVersion.
 	Download(depends map[string]string) ([]CachedDependencyInfo, error)
+	// ResolveVersion resolves the version query of the source, e.g. latest or v1.2, to a version.
+	ResolveVersion(source string, query string) (string, error)
+	// Status compares installed dependencies with the latest published versions and with the lock.
+	Status(pkg *ctipackage.Package) ([]DependencyStatus, error)
+	// Verify compares files of installed dependencies with the lock and the cache, see packageManager.Verify.
+	Verify(pkg *ctipackage.Package) ([]DependencyDrift, error)
 }
 
 type Option func(*packageManager)
@@ -24,10 +37,14 @@ type Option func(*packageManager)
 type packageManager struct {
 	PackagesDir string
 	Storage     storage.Storage
+	// Offline installs dependencies from the cache only, see storage.OfflineEnvVar.
+	Offline bool
+	// Depth limits levels of installed dependencies, 1 installs direct dependencies only. Unlimited if zero.
+	Depth int
 }
 
 func New(options ...Option) (PackageManager, error) {
-	pm := &packageManager{}
+	pm := &packageManager{Offline: storage.IsOffline()}
 
 	for _, o := range options {
 		o(pm)
@@ -59,19 +76,67 @@ func WithPackagesCache(cacheDir string) Option {
 	}
 }
 
+// WithOffline installs dependencies from the cache only, without discovering and downloading them.
+// Offline mode is enabled by storage.OfflineEnvVar by default.
+func WithOffline(offline bool) Option {
+	return func(pm *packageManager) {
+		pm.Offline = offline
+	}
+}
+
+// WithDepth installs dependencies up to the depth: 1 installs direct dependencies only, 2 installs their dependencies
+// too and so on. Zero installs all transitive dependencies.
+func WithDepth(depth int) Option {
+	return func(pm *packageManager) {
+		pm.Depth = depth
+	}
+}
+
 func (pm *packageManager) Add(pkg *ctipackage.Package, depends map[string]string) error {
+	return pm.Get(pkg, depends, nil)
+}
+
+func (pm *packageManager) Get(pkg *ctipackage.Package, depends map[string]string, dirs []string) error {
+	depends, err := pm.resolveVersions(depends)
+	if err != nil {
+		return err
+	}
+	// Sources that are added explicitly are downloaded again instead of being replaced.
+	for source := range depends {
+		if dir, ok := pkg.Index.Replace[source]; ok {
+			slog.Info("Dropped replacement of dependency", slog.String("package", source), slog.String("dir", dir))
+			delete(pkg.Index.Replace, source)
+		}
+	}
+	if pkg.Index.Depends == nil {
+		pkg.Index.Depends = map[string]string{}
+	}
+
+	install := make(map[string]string, len(depends)+len(dirs))
+	for source, version := range depends {
+		install[source] = version
+	}
+	for _, dir := range dirs {
+		source, err := pm.replace(pkg, dir)
+		if err != nil {
+			return err
+		}
+		if _, ok := depends[source]; ok {
+			return fmt.Errorf("%s is both downloaded and replaced with %s", source, dir)
+		}
+		install[source] = pkg.Index.Depends[source]
+	}
+
 	// Validate dependencies
-	if err := pm.installDependencies(pkg, depends); err != nil {
+	if err := pm.installDependencies(pkg, install); err != nil {
 		return fmt.Errorf("install dependencies: %w", err)
 	}
 
 	for source, version := range depends {
-		if _, ok := pkg.Index.Depends[source]; ok {
+		if v, ok := pkg.Index.Depends[source]; !ok || v != version {
 			slog.Info("Added direct dependency", slog.String("package", source), slog.String("version", version))
 			pkg.Index.Depends[source] = version
 		}
-		// TODO check if depends version were updated
-		// is possible?
 	}
 
 	if err := pkg.SaveIndex(); err != nil {
@@ -95,10 +160,17 @@ func (pm *packageManager) Install(pkg *ctipackage.Package) error {
 	return nil
 }
 
-func (pm *packageManager) download(depends map[string]string, installed []CachedDependencyInfo) ([]CachedDependencyInfo, error) {
+func (pm *packageManager) download(depends map[string]string, installed []CachedDependencyInfo,
+	replace map[string]string, level int) ([]CachedDependencyInfo, error) {
 	subDepends := map[string]string{}
 	for source, version := range depends {
-		info, err := pm.downloadDependency(source, version)
+		var info CachedDependencyInfo
+		var err error
+		if dir, ok := replace[source]; ok {
+			info, err = pm.localDependency(source, version, dir)
+		} else {
+			info, err = pm.downloadDependency(source, version)
+		}
 		if err != nil {
 			return nil, fmt.Errorf("download dependency %s %s: %w", source, version, err)
 		}
@@ -158,9 +230,12 @@ func (pm *packageManager) download(depends map[string]string, installed []Cached
 	}
 
 	// Recursively download sub-dependencies
-	if len(subDepends) != 0 {
+	if len(subDepends) != 0 && pm.Depth > 0 && level >= pm.Depth {
+		slog.Warn("Transitive dependencies are not installed due to the depth limit",
+			slog.Int("depth", pm.Depth), slog.Any("packages", subDepends))
+	} else if len(subDepends) != 0 {
 		slog.Info("Download sub-dependencies")
-		inst, err := pm.download(subDepends, installed)
+		inst, err := pm.download(subDepends, installed, replace, level+1)
 		if err != nil {
 			return nil, fmt.Errorf("download sub-dependencies: %w", err)
 		}
@@ -171,5 +246,26 @@ func (pm *packageManager) download(depends map[string]string, installed []Cached
 }
 
 func (pm *packageManager) Download(depends map[string]string) ([]CachedDependencyInfo, error) {
-	return pm.download(depends, []CachedDependencyInfo{})
+	depends, err := pm.resolveVersions(depends)
+	if err != nil {
+		return nil, err
+	}
+	return pm.download(depends, []CachedDependencyInfo{}, nil, 1)
+}
+
+// resolveVersions resolves version queries of dependencies to versions.
+func (pm *packageManager) resolveVersions(depends map[string]string) (map[string]string, error) {
+	resolved := make(map[string]string, len(depends))
+	for source, query := range depends {
+		version, err := pm.ResolveVersion(source, query)
+		if err != nil {
+			return nil, fmt.Errorf("resolve version: %w", err)
+		}
+		if version != query {
+			slog.Info("Resolved version", slog.String("package", source), slog.String("query", query),
+				slog.String("version", version))
+		}
+		resolved[source] = version
+	}
+	return resolved, nil
 }
@@ -57,3 +57,49 @@ func Test_Add(t *testing.T) {
 		})
 	}
 }
+
+func Test_AddDepth(t *testing.T) {
+	testcases := map[string]struct {
+		depth     int
+		depends   map[string]string
+		installed []string
+	}{
+		"direct only": {
+			depth:     1,
+			depends:   map[string]string{"mock@b2": "v0.0.0-20210101120000-abcdef123456"},
+			installed: []string{"mock.package2"},
+		},
+		"two levels": {
+			depth:     2,
+			depends:   map[string]string{"mock@b3": "v3.4.5"},
+			installed: []string{"mock.package2", "mock.package3"},
+		},
+		"unlimited": {
+			depends:   map[string]string{"mock@b3": "v3.4.5"},
+			installed: []string{"mock.package1", "mock.package2", "mock.package3"},
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			packagePath := t.TempDir()
+			pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()), WithOffline(false),
+				WithDepth(tc.depth))
+			require.NoError(t, err)
+
+			pkg, err := ctipackage.New(packagePath, ctipackage.WithID("xyz.mock"))
+			require.NoError(t, err)
+			require.NoError(t, pkg.Initialize())
+			require.NoError(t, pm.Add(pkg, tc.depends))
+
+			entries, err := os.ReadDir(filepath.Join(packagePath, ctipackage.DependencyDirName))
+			require.NoError(t, err)
+			var installed []string
+			for _, e := range entries {
+				installed = append(installed, e.Name())
+			}
+			require.Equal(t, tc.installed, installed)
+			require.Len(t, pkg.IndexLock.SourceInfo, len(tc.installed))
+		})
+	}
+}
@@ -0,0 +1,92 @@
+package pacman
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/filesys"
+)
+
+// LocalVersion is the version of dependencies that are only available in local directories, like the zero version
+// of modules that go.mod requires to replace them.
+const LocalVersion = "v0.0.0"
+
+// localPackageVersion is the version of the package directory of local dependencies in the cache.
+const localPackageVersion = "local"
+
+// Replace installs the package of the local directory as a dependency instead of downloading it, see replace.
+func (pm *packageManager) Replace(pkg *ctipackage.Package, dir string) error {
+	return pm.Get(pkg, nil, []string{dir})
+}
+
+// replace records the local directory in the index of the package and returns the source it replaces: the source
+// of the installed package with the same id, or the id of the package added as a dependency with LocalVersion.
+func (pm *packageManager) replace(pkg *ctipackage.Package, dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", fmt.Errorf("get absolute path of %s: %w", dir, err)
+	}
+	idx, err := ctipackage.ReadIndex(absDir)
+	if err != nil {
+		return "", fmt.Errorf("read index of %s: %w", dir, err)
+	}
+	if idx.PackageID == pkg.Index.PackageID {
+		return "", fmt.Errorf("package %s cannot depend on itself", idx.PackageID)
+	}
+
+	source := idx.PackageID
+	if s, ok := pkg.IndexLock.DependentPackages[idx.PackageID]; ok {
+		source = s
+	}
+	relDir, err := filepath.Rel(pkg.BaseDir, absDir)
+	if err != nil {
+		relDir = absDir
+	}
+
+	if _, ok := pkg.Index.Depends[source]; !ok {
+		pkg.Index.Depends[source] = LocalVersion
+	}
+	if pkg.Index.Replace == nil {
+		pkg.Index.Replace = map[string]string{}
+	}
+	pkg.Index.Replace[source] = filepath.ToSlash(relDir)
+	slog.Info("Replaced dependency with local directory", slog.String("package", source), slog.String("dir", relDir))
+	return source, nil
+}
+
+// localDependency copies the package of the local directory to the cache and patches its links like downloaded
+// packages, so the directory itself is not modified.
+func (pm *packageManager) localDependency(source, version, dir string) (CachedDependencyInfo, error) {
+	idx, err := ctipackage.ReadIndex(dir)
+	if err != nil {
+		return CachedDependencyInfo{}, fmt.Errorf("read index.json of %s: %w", dir, err)
+	}
+
+	targetDir := pm.getPackageDir(idx.PackageID, localPackageVersion)
+	if err := filesys.ReplaceWithCopy(dir, targetDir); err != nil {
+		return CachedDependencyInfo{}, fmt.Errorf("copy package %s from %s: %w", idx.PackageID, dir, err)
+	}
+	if err := patchRelativeLinks(targetDir); err != nil {
+		return CachedDependencyInfo{}, fmt.Errorf("patch dependency links: %w", err)
+	}
+
+	movedIndex, err := ctipackage.ReadIndex(targetDir)
+	if err != nil {
+		return CachedDependencyInfo{}, fmt.Errorf("read index.json: %w", err)
+	}
+	hash, err := filesys.ComputeDirectoryHash(targetDir)
+	if err != nil {
+		return CachedDependencyInfo{}, fmt.Errorf("compute directory hash: %w", err)
+	}
+
+	slog.Info("Copied dependency from local directory", slog.String("package", source), slog.String("dir", dir))
+	return CachedDependencyInfo{
+		Path:      targetDir,
+		Source:    source,
+		Version:   version,
+		Integrity: hash,
+		Index:     *movedIndex,
+	}, nil
+}
@@ -0,0 +1,77 @@
+package pacman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/filesys"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Replace(t *testing.T) {
+	localDir, err := filepath.Abs(filepath.Join("fixtures", "storage", "mock@b1", "v1.0.0"))
+	require.NoError(t, err)
+	localHash, err := filesys.ComputeDirectoryHash(localDir)
+	require.NoError(t, err)
+
+	packagePath := t.TempDir()
+	pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()), WithOffline(false))
+	require.NoError(t, err)
+
+	pkg, err := ctipackage.New(packagePath, ctipackage.WithID("xyz.mock"))
+	require.NoError(t, err)
+	require.NoError(t, pkg.Initialize())
+
+	require.NoError(t, pm.Replace(pkg, localDir))
+
+	relDir, err := filepath.Rel(packagePath, localDir)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"mock.package1": LocalVersion}, pkg.Index.Depends)
+	require.Equal(t, map[string]string{"mock.package1": filepath.ToSlash(relDir)}, pkg.Index.Replace)
+	require.Equal(t, filepath.ToSlash(relDir), pkg.IndexLock.SourceInfo["mock.package1"].Replace)
+	require.DirExists(t, filepath.Join(packagePath, ctipackage.DependencyDirName, "mock.package1"))
+
+	// The local directory is not modified by patching of links.
+	hash, err := filesys.ComputeDirectoryHash(localDir)
+	require.NoError(t, err)
+	require.Equal(t, localHash, hash)
+
+	// The replacement is kept in the index, so dependencies are installed from the directory again.
+	require.NoError(t, os.RemoveAll(filepath.Join(packagePath, ctipackage.DependencyDirName)))
+	pkg, err = ctipackage.New(packagePath)
+	require.NoError(t, err)
+	require.NoError(t, pkg.Read())
+	require.NoError(t, pm.Install(pkg))
+	require.DirExists(t, filepath.Join(packagePath, ctipackage.DependencyDirName, "mock.package1"))
+}
+
+func Test_ReplaceSelf(t *testing.T) {
+	packagePath := t.TempDir()
+	pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()))
+	require.NoError(t, err)
+
+	pkg, err := ctipackage.New(packagePath, ctipackage.WithID("xyz.mock"))
+	require.NoError(t, err)
+	require.NoError(t, pkg.Initialize())
+
+	require.ErrorContains(t, pm.Replace(pkg, packagePath), "cannot depend on itself")
+}
+
+func Test_GetWithReplace(t *testing.T) {
+	localDir, err := filepath.Abs(filepath.Join("fixtures", "storage", "mock@b1", "v1.0.0"))
+	require.NoError(t, err)
+
+	packagePath := t.TempDir()
+	pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()), WithOffline(false))
+	require.NoError(t, err)
+
+	pkg, err := ctipackage.New(packagePath, ctipackage.WithID("xyz.mock"))
+	require.NoError(t, err)
+	require.NoError(t, pkg.Initialize())
+
+	require.NoError(t, pm.Get(pkg, map[string]string{"mock@b3": "v3.4.5"}, []string{localDir}))
+	require.Equal(t, map[string]string{"mock.package1": LocalVersion, "mock@b3": "v3.4.5"}, pkg.Index.Depends)
+	require.Contains(t, pkg.Index.Replace, "mock.package1")
+}
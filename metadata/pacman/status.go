@@ -0,0 +1,115 @@
+package pacman
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/filesys"
+
+	"golang.org/x/mod/semver"
+)
+
+// VersionStatus compares the installed version of a dependency with the latest version published by its storage.
+type VersionStatus string
+
+const (
+	StatusUpToDate VersionStatus = "up-to-date"
+	StatusOutdated VersionStatus = "outdated"
+	// StatusAhead is reported if the installed version is higher than the latest one, e.g. a pseudo-version of
+	// a commit after the latest tag or a pre-release of the next version.
+	StatusAhead VersionStatus = "ahead"
+	// StatusLocal is reported for dependencies installed from local directories, see ctipackage.Index.Replace.
+	StatusLocal        VersionStatus = "local"
+	StatusNotInstalled VersionStatus = "not-installed"
+	// StatusUnknown is reported if the latest version is not resolved, see DependencyStatus.Error.
+	StatusUnknown VersionStatus = "unknown"
+)
+
+// DependencyStatus is the state of a direct or transitive dependency of the package.
+type DependencyStatus struct {
+	Source    string        `json:"source" yaml:"source"`
+	PackageID string        `json:"package_id,omitempty" yaml:"package_id,omitempty"`
+	Version   string        `json:"version,omitempty" yaml:"version,omitempty"`
+	Latest    string        `json:"latest,omitempty" yaml:"latest,omitempty"`
+	Status    VersionStatus `json:"status" yaml:"status"`
+	// Modified is set if files of the installed dependency do not match the integrity recorded in the lock.
+	Modified bool   `json:"modified" yaml:"modified"`
+	Error    string `json:"error,omitempty" yaml:"error,omitempty"`
+}
+
+// Status compares installed dependencies of the package with the latest versions published by the storage,
+// and files of installed dependencies with the integrity recorded in the lock. Dependencies are sorted by sources.
+// Failures to resolve latest versions of single dependencies are reported in their statuses.
+func (pm *packageManager) Status(pkg *ctipackage.Package) ([]DependencyStatus, error) {
+	sources := map[string]bool{}
+	for source := range pkg.Index.Depends {
+		sources[source] = true
+	}
+	for source := range pkg.IndexLock.SourceInfo {
+		sources[source] = true
+	}
+
+	res := make([]DependencyStatus, 0, len(sources))
+	for source := range sources {
+		status := DependencyStatus{Source: source}
+		info, ok := pkg.IndexLock.SourceInfo[source]
+		if !ok {
+			status.Status = StatusNotInstalled
+			res = append(res, status)
+			continue
+		}
+		status.PackageID = info.PackageID
+		status.Version = info.Version
+
+		modified, err := isModified(filepath.Join(pkg.BaseDir, ctipackage.DependencyDirName, info.PackageID),
+			info.Integrity)
+		if err != nil {
+			return nil, fmt.Errorf("check integrity of %s: %w", source, err)
+		}
+		status.Modified = modified
+
+		if info.Replace != "" {
+			status.Status = StatusLocal
+			res = append(res, status)
+			continue
+		}
+		latest, err := pm.ResolveVersion(source, QueryLatest)
+		if err != nil {
+			status.Status = StatusUnknown
+			status.Error = err.Error()
+			res = append(res, status)
+			continue
+		}
+		status.Latest = latest
+		switch c := semver.Compare(info.Version, latest); {
+		case c < 0:
+			status.Status = StatusOutdated
+		case c > 0:
+			status.Status = StatusAhead
+		default:
+			status.Status = StatusUpToDate
+		}
+		res = append(res, status)
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Source < res[j].Source })
+	return res, nil
+}
+
+// isModified reports whether the hash of the installed dependency differs from the integrity. Removed dependencies
+// are modified too.
+func isModified(depDir string, integrity string) (bool, error) {
+	if _, err := os.Stat(depDir); errors.Is(err, os.ErrNotExist) {
+		return true, nil
+	} else if err != nil {
+		return false, fmt.Errorf("stat %s: %w", depDir, err)
+	}
+	hash, err := filesys.ComputeDirectoryHash(depDir)
+	if err != nil {
+		return false, fmt.Errorf("compute directory hash: %w", err)
+	}
+	return hash != integrity, nil
+}
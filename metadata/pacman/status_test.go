@@ -0,0 +1,90 @@
+package pacman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Status(t *testing.T) {
+	testcases := map[string]struct {
+		versions []string
+		status   VersionStatus
+		latest   string
+	}{
+		"up to date": {versions: []string{"v1.0.0", "v0.9.0"}, status: StatusUpToDate, latest: "v1.0.0"},
+		"outdated":   {versions: []string{"v1.0.0", "v1.2.0"}, status: StatusOutdated, latest: "v1.2.0"},
+		"ahead":      {versions: []string{"v0.9.0"}, status: StatusAhead, latest: "v0.9.0"},
+		"unknown":    {status: StatusUnknown},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			st := &versionsStorage{versions: []string{"v1.0.0"}}
+			pm, err := New(WithStorage(st), WithPackagesCache(t.TempDir()), WithOffline(false))
+			require.NoError(t, err)
+
+			pkg, err := ctipackage.New(t.TempDir(), ctipackage.WithID("xyz.mock"))
+			require.NoError(t, err)
+			require.NoError(t, pkg.Initialize())
+			require.NoError(t, pm.Add(pkg, map[string]string{"mock@b1": "v1.0.0"}))
+
+			st.versions = tc.versions
+			res, err := pm.Status(pkg)
+			require.NoError(t, err)
+			require.Len(t, res, 1)
+			require.Equal(t, "mock@b1", res[0].Source)
+			require.Equal(t, "mock.package1", res[0].PackageID)
+			require.Equal(t, "v1.0.0", res[0].Version)
+			require.Equal(t, tc.latest, res[0].Latest)
+			require.Equal(t, tc.status, res[0].Status)
+			require.False(t, res[0].Modified)
+			if tc.status == StatusUnknown {
+				require.Contains(t, res[0].Error, "no versions of mock@b1 match latest")
+			}
+		})
+	}
+}
+
+func Test_StatusModified(t *testing.T) {
+	pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()), WithOffline(false))
+	require.NoError(t, err)
+
+	pkg, err := ctipackage.New(t.TempDir(), ctipackage.WithID("xyz.mock"))
+	require.NoError(t, err)
+	require.NoError(t, pkg.Initialize())
+	require.NoError(t, pm.Add(pkg, map[string]string{"mock@b1": "v1.0.0"}))
+	pkg.Index.Depends["mock@b3"] = "v3.4.5"
+
+	depFile := filepath.Join(pkg.BaseDir, ctipackage.DependencyDirName, "mock.package1", "foo.raml")
+	require.NoError(t, os.WriteFile(depFile, []byte("#%RAML 1.0 Library\n"), 0600))
+
+	res, err := pm.Status(pkg)
+	require.NoError(t, err)
+	require.Equal(t, []DependencyStatus{
+		{Source: "mock@b1", PackageID: "mock.package1", Version: "v1.0.0", Latest: "v1.0.0", Status: StatusUpToDate, Modified: true},
+		{Source: "mock@b3", Status: StatusNotInstalled},
+	}, res)
+}
+
+func Test_StatusLocal(t *testing.T) {
+	localDir, err := filepath.Abs(filepath.Join("fixtures", "storage", "mock@b1", "v1.0.0"))
+	require.NoError(t, err)
+
+	pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()), WithOffline(false))
+	require.NoError(t, err)
+
+	pkg, err := ctipackage.New(t.TempDir(), ctipackage.WithID("xyz.mock"))
+	require.NoError(t, err)
+	require.NoError(t, pkg.Initialize())
+	require.NoError(t, pm.Replace(pkg, localDir))
+
+	res, err := pm.Status(pkg)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, StatusLocal, res[0].Status)
+	require.Empty(t, res[0].Latest)
+}
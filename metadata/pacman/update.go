@@ -0,0 +1,64 @@
+package pacman
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+
+	"golang.org/x/mod/semver"
+)
+
+// Update updates direct dependencies of the sources, or all direct dependencies if no sources are given, to the
+// highest versions with the same major version, or with the same minor version if patch is set, like go get -u.
+// Dependencies are never downgraded, dependencies replaced with local directories or without matching versions are
+// kept. Updated dependencies are installed with their dependencies, see Get.
+func (pm *packageManager) Update(pkg *ctipackage.Package, sources []string, patch bool) error {
+	if len(sources) == 0 {
+		for source := range pkg.Index.Depends {
+			sources = append(sources, source)
+		}
+		sort.Strings(sources)
+	}
+
+	updates := map[string]string{}
+	for _, source := range sources {
+		current, ok := pkg.Index.Depends[source]
+		if !ok {
+			return fmt.Errorf("%s is not a dependency", source)
+		}
+		if dir, ok := pkg.Index.Replace[source]; ok {
+			slog.Info("Dependency is replaced with a local directory, skip", slog.String("package", source),
+				slog.String("dir", dir))
+			continue
+		}
+		version, err := pm.ResolveVersion(source, updateQuery(current, patch))
+		if errors.Is(err, errNoVersions) {
+			slog.Warn("No versions to update to, keep the current version", slog.String("package", source),
+				slog.String("version", current), slog.String("error", err.Error()))
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("update %s: %w", source, err)
+		}
+		if semver.Compare(version, current) <= 0 {
+			slog.Debug("Dependency is up to date", slog.String("package", source), slog.String("version", current))
+			continue
+		}
+		updates[source] = version
+	}
+	if len(updates) == 0 {
+		return nil
+	}
+	return pm.Get(pkg, updates, nil)
+}
+
+// updateQuery returns the query of versions the version is updated to, e.g. v1 or v1.2 for v1.2.3.
+func updateQuery(version string, patch bool) string {
+	if patch {
+		return semver.MajorMinor(version)
+	}
+	return semver.Major(version)
+}
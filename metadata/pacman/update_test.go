@@ -0,0 +1,76 @@
+package pacman
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/storage"
+	"github.com/stretchr/testify/require"
+)
+
+// upgradeStorage advertises the versions and serves the fixture of v1.0.0 for each of them.
+type upgradeStorage struct {
+	versionsStorage
+}
+
+func (s *upgradeStorage) Discover(name string, _ string) (storage.Origin, error) {
+	return &mockInfo{Name: name, Version: "v1.0.0"}, nil
+}
+
+func Test_Update(t *testing.T) {
+	testcases := map[string]struct {
+		versions []string
+		patch    bool
+		expected string
+	}{
+		"minor":        {versions: []string{"v1.0.0", "v1.0.3", "v1.2.0", "v2.0.0"}, expected: "v1.2.0"},
+		"patch":        {versions: []string{"v1.0.0", "v1.0.3", "v1.2.0", "v2.0.0"}, patch: true, expected: "v1.0.3"},
+		"up to date":   {versions: []string{"v1.0.0", "v2.0.0"}, expected: "v1.0.0"},
+		"no downgrade": {versions: []string{"v0.9.0"}, expected: "v1.0.0"},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			st := &upgradeStorage{versionsStorage{versions: []string{"v1.0.0"}}}
+			pm, err := New(WithStorage(st), WithPackagesCache(t.TempDir()), WithOffline(false))
+			require.NoError(t, err)
+
+			pkg, err := ctipackage.New(t.TempDir(), ctipackage.WithID("xyz.mock"))
+			require.NoError(t, err)
+			require.NoError(t, pkg.Initialize())
+			require.NoError(t, pm.Add(pkg, map[string]string{"mock@b1": "v1.0.0"}))
+
+			st.versions = tc.versions
+			require.NoError(t, pm.Update(pkg, nil, tc.patch))
+			require.Equal(t, tc.expected, pkg.Index.Depends["mock@b1"])
+			require.Equal(t, tc.expected, pkg.IndexLock.SourceInfo["mock@b1"].Version)
+
+			idx, err := ctipackage.ReadIndex(pkg.BaseDir)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, idx.Depends["mock@b1"])
+		})
+	}
+}
+
+func Test_UpdateErrors(t *testing.T) {
+	pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()), WithOffline(false))
+	require.NoError(t, err)
+
+	pkg, err := ctipackage.New(t.TempDir(), ctipackage.WithID("xyz.mock"))
+	require.NoError(t, err)
+	require.NoError(t, pkg.Initialize())
+	require.ErrorContains(t, pm.Update(pkg, []string{"mock@b1"}, false), "mock@b1 is not a dependency")
+
+	localDir, err := filepath.Abs(filepath.Join("fixtures", "storage", "mock@b1", "v1.0.0"))
+	require.NoError(t, err)
+	require.NoError(t, pm.Replace(pkg, localDir))
+	require.NoError(t, pm.Update(pkg, nil, false))
+	require.Contains(t, pkg.Index.Replace, "mock.package1")
+}
+
+func Test_UpdateQuery(t *testing.T) {
+	require.Equal(t, "v1", updateQuery("v1.2.3", false))
+	require.Equal(t, "v1.2", updateQuery("v1.2.3", true))
+	require.Equal(t, "v0", updateQuery("v0.0.0-20210101120000-abcdef123456", false))
+}
@@ -0,0 +1,176 @@
+package pacman
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+
+	"golang.org/x/mod/sumdb/dirhash"
+)
+
+// DriftStatus compares files of an installed dependency with the integrity recorded in the lock.
+type DriftStatus string
+
+const (
+	DriftNone DriftStatus = "ok"
+	// DriftModified is reported if files of the installed dependency were changed after installation.
+	DriftModified DriftStatus = "modified"
+	// DriftStale is reported if files of the installed dependency match the cached package, but not the integrity
+	// recorded in the lock, e.g. the lock was written for other contents of the same version.
+	DriftStale   DriftStatus = "stale"
+	DriftMissing DriftStatus = "missing"
+	// DriftExtraneous is reported for directories of installed dependencies that are not recorded in the lock.
+	DriftExtraneous DriftStatus = "extraneous"
+)
+
+// FileChange is the change of a file of the installed dependency compared with the cached package.
+type FileChange string
+
+const (
+	FileModified FileChange = "modified"
+	FileAdded    FileChange = "added"
+	FileRemoved  FileChange = "removed"
+)
+
+// FileDrift is the changed file of the installed dependency, the path is relative to the dependency directory.
+type FileDrift struct {
+	Path   string     `json:"path" yaml:"path"`
+	Change FileChange `json:"change" yaml:"change"`
+}
+
+// DependencyDrift is the state of files of the installed dependency.
+type DependencyDrift struct {
+	Source    string      `json:"source,omitempty" yaml:"source,omitempty"`
+	PackageID string      `json:"package_id" yaml:"package_id"`
+	Version   string      `json:"version,omitempty" yaml:"version,omitempty"`
+	Status    DriftStatus `json:"status" yaml:"status"`
+	// Files are nil if the cached package the dependency was installed from is not available.
+	Files []FileDrift `json:"files,omitempty" yaml:"files,omitempty"`
+}
+
+// Verify compares files of installed dependencies with the integrity recorded in the lock, and files of modified
+// dependencies with the cached packages they were installed from. Dependencies are sorted by package ids.
+func (pm *packageManager) Verify(pkg *ctipackage.Package) ([]DependencyDrift, error) {
+	depsDir := filepath.Join(pkg.BaseDir, ctipackage.DependencyDirName)
+	locked := map[string]bool{}
+
+	res := make([]DependencyDrift, 0, len(pkg.IndexLock.SourceInfo))
+	for source, info := range pkg.IndexLock.SourceInfo {
+		locked[info.PackageID] = true
+		drift := DependencyDrift{Source: source, PackageID: info.PackageID, Version: info.Version}
+
+		depDir := filepath.Join(depsDir, info.PackageID)
+		if _, err := os.Stat(depDir); errors.Is(err, os.ErrNotExist) {
+			drift.Status = DriftMissing
+			res = append(res, drift)
+			continue
+		}
+		modified, err := isModified(depDir, info.Integrity)
+		if err != nil {
+			return nil, fmt.Errorf("check integrity of %s: %w", source, err)
+		}
+		if !modified {
+			drift.Status = DriftNone
+		} else {
+			version := info.Version
+			if info.Replace != "" {
+				version = localPackageVersion
+			}
+			drift.Status = DriftModified
+			if drift.Files, err = pm.diffCached(info.PackageID, version, depDir); err != nil {
+				return nil, fmt.Errorf("compare %s with the cache: %w", source, err)
+			}
+			if drift.Files != nil && len(drift.Files) == 0 {
+				drift.Status = DriftStale
+			}
+		}
+		res = append(res, drift)
+	}
+
+	entries, err := os.ReadDir(depsDir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("read %s: %w", depsDir, err)
+	}
+	for _, e := range entries {
+		if e.IsDir() && !locked[e.Name()] {
+			res = append(res, DependencyDrift{PackageID: e.Name(), Status: DriftExtraneous})
+		}
+	}
+
+	sort.Slice(res, func(i, j int) bool { return res[i].PackageID < res[j].PackageID })
+	return res, nil
+}
+
+// diffCached returns files of the installed dependency that differ from the cached package, or nil if the package
+// is not in the cache. Files generated on installation are not compared, see generated.
+func (pm *packageManager) diffCached(pkgID, version, depDir string) ([]FileDrift, error) {
+	cachedDir := pm.getPackageDir(pkgID, version)
+	if _, err := os.Stat(cachedDir); errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("stat %s: %w", cachedDir, err)
+	}
+
+	cached, err := dirhash.DirFiles(cachedDir, "")
+	if err != nil {
+		return nil, fmt.Errorf("list files of %s: %w", cachedDir, err)
+	}
+	installed, err := dirhash.DirFiles(depDir, "")
+	if err != nil {
+		return nil, fmt.Errorf("list files of %s: %w", depDir, err)
+	}
+	remaining := make(map[string]bool, len(installed))
+	for _, f := range installed {
+		if !generated(f) {
+			remaining[f] = true
+		}
+	}
+
+	files := []FileDrift{}
+	for _, f := range cached {
+		if generated(f) {
+			continue
+		}
+		if !remaining[f] {
+			files = append(files, FileDrift{Path: f, Change: FileRemoved})
+			continue
+		}
+		delete(remaining, f)
+		same, err := sameContents(filepath.Join(cachedDir, filepath.FromSlash(f)), filepath.Join(depDir, filepath.FromSlash(f)))
+		if err != nil {
+			return nil, err
+		}
+		if !same {
+			files = append(files, FileDrift{Path: f, Change: FileModified})
+		}
+	}
+	for f := range remaining {
+		files = append(files, FileDrift{Path: f, Change: FileAdded})
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].Path < files[j].Path })
+	return files, nil
+}
+
+// generated reports whether the file is written to installed dependencies on installation: the RAMLx spec and the
+// metadata cache of the parser.
+func generated(file string) bool {
+	return file == ctipackage.MetadataCacheFile || strings.HasPrefix(file, ctipackage.RamlxDirName+"/")
+}
+
+func sameContents(a, b string) (bool, error) {
+	dataA, err := os.ReadFile(a)
+	if err != nil {
+		return false, fmt.Errorf("read %s: %w", a, err)
+	}
+	dataB, err := os.ReadFile(b)
+	if err != nil {
+		return false, fmt.Errorf("read %s: %w", b, err)
+	}
+	return bytes.Equal(dataA, dataB), nil
+}
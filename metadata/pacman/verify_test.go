@@ -0,0 +1,107 @@
+package pacman
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_Verify(t *testing.T) {
+	testcases := map[string]struct {
+		change func(t *testing.T, pkg *ctipackage.Package, depDir string)
+		status DriftStatus
+		files  []FileDrift
+	}{
+		"ok": {
+			change: func(*testing.T, *ctipackage.Package, string) {},
+			status: DriftNone,
+		},
+		"modified": {
+			change: func(t *testing.T, _ *ctipackage.Package, depDir string) {
+				require.NoError(t, os.WriteFile(filepath.Join(depDir, "foo.raml"), []byte("#%RAML 1.0 Library\n"), 0600))
+				require.NoError(t, os.WriteFile(filepath.Join(depDir, "bar.raml"), []byte("#%RAML 1.0 Library\n"), 0600))
+				require.NoError(t, os.Remove(filepath.Join(depDir, "index.json")))
+			},
+			status: DriftModified,
+			files: []FileDrift{
+				{Path: "bar.raml", Change: FileAdded},
+				{Path: "foo.raml", Change: FileModified},
+				{Path: "index.json", Change: FileRemoved},
+			},
+		},
+		"stale": {
+			change: func(_ *testing.T, pkg *ctipackage.Package, _ string) {
+				info := pkg.IndexLock.SourceInfo["mock@b1"]
+				info.Integrity = "xxh3:stale"
+				pkg.IndexLock.SourceInfo["mock@b1"] = info
+			},
+			status: DriftStale,
+			files:  []FileDrift{},
+		},
+		"missing": {
+			change: func(t *testing.T, _ *ctipackage.Package, depDir string) {
+				require.NoError(t, os.RemoveAll(depDir))
+			},
+			status: DriftMissing,
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()), WithOffline(false))
+			require.NoError(t, err)
+
+			pkg, err := ctipackage.New(t.TempDir(), ctipackage.WithID("xyz.mock"))
+			require.NoError(t, err)
+			require.NoError(t, pkg.Initialize())
+			require.NoError(t, pm.Add(pkg, map[string]string{"mock@b1": "v1.0.0"}))
+
+			tc.change(t, pkg, filepath.Join(pkg.BaseDir, ctipackage.DependencyDirName, "mock.package1"))
+			res, err := pm.Verify(pkg)
+			require.NoError(t, err)
+			require.Equal(t, []DependencyDrift{
+				{Source: "mock@b1", PackageID: "mock.package1", Version: "v1.0.0", Status: tc.status, Files: tc.files},
+			}, res)
+		})
+	}
+}
+
+func Test_VerifyExtraneous(t *testing.T) {
+	pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()), WithOffline(false))
+	require.NoError(t, err)
+
+	pkg, err := ctipackage.New(t.TempDir(), ctipackage.WithID("xyz.mock"))
+	require.NoError(t, err)
+	require.NoError(t, pkg.Initialize())
+	require.NoError(t, pm.Add(pkg, map[string]string{"mock@b1": "v1.0.0"}))
+	require.NoError(t, os.MkdirAll(filepath.Join(pkg.BaseDir, ctipackage.DependencyDirName, "mock.removed"), 0755))
+
+	res, err := pm.Verify(pkg)
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	require.Equal(t, DriftNone, res[0].Status)
+	require.Equal(t, DependencyDrift{PackageID: "mock.removed", Status: DriftExtraneous}, res[1])
+}
+
+func Test_VerifyNotCached(t *testing.T) {
+	cacheDir := t.TempDir()
+	pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(cacheDir), WithOffline(false))
+	require.NoError(t, err)
+
+	pkg, err := ctipackage.New(t.TempDir(), ctipackage.WithID("xyz.mock"))
+	require.NoError(t, err)
+	require.NoError(t, pkg.Initialize())
+	require.NoError(t, pm.Add(pkg, map[string]string{"mock@b1": "v1.0.0"}))
+	depFile := filepath.Join(pkg.BaseDir, ctipackage.DependencyDirName, "mock.package1", "foo.raml")
+	require.NoError(t, os.WriteFile(depFile, []byte("#%RAML 1.0 Library\n"), 0600))
+	require.NoError(t, os.RemoveAll(filepath.Join(cacheDir, "mock.package1")))
+
+	res, err := pm.Verify(pkg)
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, DriftModified, res[0].Status)
+	require.Nil(t, res[0].Files)
+}
@@ -1 +1,90 @@
 package pacman
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata/storage"
+
+	"golang.org/x/mod/module"
+	"golang.org/x/mod/semver"
+)
+
+// QueryLatest resolves to the highest release version of the source, or to the highest pre-release if the source has
+// no releases.
+const QueryLatest = "latest"
+
+var revisionRe = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// errNoVersions is returned if no versions of the source match the query.
+var errNoVersions = errors.New("no versions")
+
+// ResolveVersion resolves the version query of the source to a version:
+//   - latest resolves to the highest version advertised by the storage, see QueryLatest;
+//   - a version prefix, e.g. v1 or v1.2, resolves to the highest version with the same major or minor version;
+//   - a complete version, e.g. v1.2.3 or a pseudo-version, resolves to itself;
+//   - a commit hash resolves to the pseudo-version of the commit, e.g. v0.0.0-20210101120000-abcdef123456.
+func (pm *packageManager) ResolveVersion(source string, query string) (string, error) {
+	switch {
+	case query == QueryLatest:
+		return pm.matchVersion(source, query, func(string) bool { return true })
+	case semver.IsValid(query) && isCompleteVersion(query):
+		return query, nil
+	case semver.IsValid(query):
+		return pm.matchVersion(source, query, func(v string) bool {
+			return strings.HasPrefix(v, query+".")
+		})
+	case revisionRe.MatchString(query):
+		if pm.Offline {
+			return "", fmt.Errorf("resolve commit %s of %s: %w", query, source, storage.ErrOffline)
+		}
+		version, err := pm.Storage.PseudoVersion(source, query)
+		if err != nil {
+			return "", fmt.Errorf("resolve commit %s of %s: %w", query, source, err)
+		}
+		return version, nil
+	default:
+		return "", fmt.Errorf("invalid version query %s of %s: should be latest, a version, e.g. v1, v1.2 or v1.2.3, "+
+			"or a commit hash", query, source)
+	}
+}
+
+// matchVersion returns the highest release version of the source that matches, or the highest matching pre-release
+// if no release matches. Pseudo-versions are not advertised, so they never match.
+func (pm *packageManager) matchVersion(source string, query string, match func(string) bool) (string, error) {
+	if pm.Offline {
+		return "", fmt.Errorf("list versions of %s: %w", source, storage.ErrOffline)
+	}
+	versions, err := pm.Storage.Versions(source)
+	if err != nil {
+		return "", fmt.Errorf("list versions of %s: %w", source, err)
+	}
+	var releases, prereleases []string
+	for _, v := range versions {
+		if !semver.IsValid(v) || module.IsPseudoVersion(v) || !match(v) {
+			continue
+		}
+		if semver.Prerelease(v) == "" {
+			releases = append(releases, v)
+		} else {
+			prereleases = append(prereleases, v)
+		}
+	}
+	if len(releases) == 0 {
+		releases = prereleases
+	}
+	if len(releases) == 0 {
+		return "", fmt.Errorf("%w of %s match %s", errNoVersions, source, query)
+	}
+	sort.Slice(releases, func(i, j int) bool { return semver.Compare(releases[i], releases[j]) < 0 })
+	return releases[len(releases)-1], nil
+}
+
+// isCompleteVersion reports whether the valid semantic version has the major, minor and patch versions, since
+// semver.IsValid accepts shorthands like v1 and v1.2 too.
+func isCompleteVersion(version string) bool {
+	return strings.Count(strings.SplitN(strings.SplitN(version, "-", 2)[0], "+", 2)[0], ".") == 2
+}
@@ -3,6 +3,8 @@ package pacman
 import (
 	"testing"
 
+	"github.com/acronis/go-cti/metadata/storage"
+	"github.com/stretchr/testify/require"
 	"golang.org/x/mod/semver"
 )
 
@@ -16,3 +18,104 @@ func checkVersion(version string) error {
 func Test_Version(t *testing.T) {
 
 }
+
+// versionsStorage advertises the versions instead of the versions of fixtures.
+type versionsStorage struct {
+	mockStorage
+	versions []string
+}
+
+func (s *versionsStorage) Versions(string) ([]string, error) {
+	return s.versions, nil
+}
+
+func Test_ResolveVersion(t *testing.T) {
+	testcases := map[string]struct {
+		versions []string
+		query    string
+		expected string
+		err      string
+	}{
+		"latest": {
+			versions: []string{"v1.0.0", "v1.10.0", "v1.2.0", "v2.0.0-rc.1", "v0.0.0-20210101120000-abcdef123456"},
+			query:    "latest",
+			expected: "v1.10.0",
+		},
+		"latest pre-release": {
+			versions: []string{"v2.0.0-rc.1", "v2.0.0-rc.2", "not-a-version"},
+			query:    "latest",
+			expected: "v2.0.0-rc.2",
+		},
+		"major": {
+			versions: []string{"v1.0.0", "v1.3.1", "v2.0.0", "v10.0.0"},
+			query:    "v1",
+			expected: "v1.3.1",
+		},
+		"minor": {
+			versions: []string{"v1.2.0", "v1.2.5", "v1.20.0", "v1.3.0"},
+			query:    "v1.2",
+			expected: "v1.2.5",
+		},
+		"complete version": {
+			query:    "v1.2.3",
+			expected: "v1.2.3",
+		},
+		"pre-release version": {
+			query:    "v1.2.3-rc.1",
+			expected: "v1.2.3-rc.1",
+		},
+		"no match": {
+			versions: []string{"v1.0.0"},
+			query:    "v2",
+			err:      "no versions of mock@b1 match v2",
+		},
+		"invalid query": {
+			query: "master",
+			err:   "invalid version query master",
+		},
+	}
+
+	for name, tc := range testcases {
+		t.Run(name, func(t *testing.T) {
+			pm, err := New(WithStorage(&versionsStorage{versions: tc.versions}), WithPackagesCache(t.TempDir()),
+				WithOffline(false))
+			require.NoError(t, err)
+
+			version, err := pm.ResolveVersion("mock@b1", tc.query)
+			if tc.err != "" {
+				require.ErrorContains(t, err, tc.err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, version)
+		})
+	}
+}
+
+func Test_ResolveVersionCommit(t *testing.T) {
+	pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()), WithOffline(false))
+	require.NoError(t, err)
+
+	version, err := pm.ResolveVersion("mock@b2", "abcdef1")
+	require.NoError(t, err)
+	require.Equal(t, "v0.0.0-20210101120000-abcdef123456", version)
+
+	version, err = pm.ResolveVersion("mock@b3", "latest")
+	require.NoError(t, err)
+	require.Equal(t, "v3.4.5", version)
+
+	offline, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()), WithOffline(true))
+	require.NoError(t, err)
+	_, err = offline.ResolveVersion("mock@b3", "latest")
+	require.ErrorIs(t, err, storage.ErrOffline)
+}
+
+func Test_DownloadQuery(t *testing.T) {
+	pm, err := New(WithStorage(&mockStorage{}), WithPackagesCache(t.TempDir()), WithOffline(false))
+	require.NoError(t, err)
+
+	res, err := pm.Download(map[string]string{"mock@b1": "v1"})
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	require.Equal(t, "v1.0.0", res[0].Version)
+}
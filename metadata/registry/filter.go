@@ -0,0 +1,86 @@
+package registry
+
+import (
+	"github.com/acronis/go-cti/metadata"
+)
+
+// Kind is a kind of CTI entity.
+type Kind string
+
+const (
+	KindType     Kind = "type"
+	KindInstance Kind = "instance"
+)
+
+// KindOf returns the kind of the entity.
+func KindOf(entity *metadata.Entity) Kind {
+	if entity.IsInstance() {
+		return KindInstance
+	}
+	return KindType
+}
+
+// Filter defines criteria to select entities from the registry.
+// Empty fields are not taken into account.
+type Filter struct {
+	Kind Kind
+	// Vendor is a vendor that owns the entity.
+	Vendor string
+	// Package is a package that owns the entity in <vendor>.<package> form.
+	Package string
+	// Annotation is a name of the annotation (e.g. cti.reference) that the entity must define.
+	Annotation string
+}
+
+// Match reports whether the entity satisfies the filter.
+func (f Filter) Match(entity *metadata.Entity) bool {
+	if f.Kind != "" && KindOf(entity) != f.Kind {
+		return false
+	}
+	if f.Vendor != "" || f.Package != "" {
+		vendor, pkg := metadata.GetVendorAndPackage(entity.Cti)
+		if f.Vendor != "" && vendor != f.Vendor {
+			return false
+		}
+		if f.Package != "" && PackageKey(vendor, pkg) != f.Package {
+			return false
+		}
+	}
+	if f.Annotation != "" && !hasAnnotation(entity, f.Annotation) {
+		return false
+	}
+	return true
+}
+
+// Find returns entities that satisfy the filter sorted by CTI.
+func (r *Registry) Find(f Filter) metadata.Entities {
+	source := r.entities
+	switch {
+	case f.Package != "":
+		source = r.byPackage[f.Package]
+	case f.Vendor != "":
+		source = r.byVendor[f.Vendor]
+	}
+
+	var res metadata.Entities
+	for _, entity := range sorted(source) {
+		if f.Match(entity) {
+			res = append(res, entity)
+		}
+	}
+	return res
+}
+
+func hasAnnotation(entity *metadata.Entity, name string) bool {
+	for _, a := range entity.Annotations {
+		if a.Has(name) {
+			return true
+		}
+	}
+	for _, a := range entity.TraitsAnnotations {
+		if a.Has(name) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,199 @@
+package registry
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+// Registry is an in-memory index of CTI entities.
+// It keeps entities indexed by full CTI, by vendor and package of the owner and by parent,
+// so that lookups performed during validation and queries do not require scanning over all entities.
+type Registry struct {
+	entities  metadata.EntitiesMap
+	types     metadata.EntitiesMap
+	instances metadata.EntitiesMap
+
+	byVendor  map[string]metadata.EntitiesMap
+	byPackage map[string]metadata.EntitiesMap
+	children  map[string]metadata.EntitiesMap
+}
+
+// NewEmpty creates a registry without entities.
+func NewEmpty() *Registry {
+	return &Registry{
+		entities:  make(metadata.EntitiesMap),
+		types:     make(metadata.EntitiesMap),
+		instances: make(metadata.EntitiesMap),
+		byVendor:  make(map[string]metadata.EntitiesMap),
+		byPackage: make(map[string]metadata.EntitiesMap),
+		children:  make(map[string]metadata.EntitiesMap),
+	}
+}
+
+// New creates a registry and indexes the specified entities.
+func New(entities metadata.EntitiesMap) (*Registry, error) {
+	r := NewEmpty()
+	for _, entity := range entities {
+		if err := r.Add(entity); err != nil {
+			return nil, err
+		}
+	}
+	return r, nil
+}
+
+// Add puts the entity into all indexes. Entities without values and schema are neither types nor instances,
+// they are indexed by CTI, owner and parent only, so the validator reports them instead of the registry.
+func (r *Registry) Add(entity *metadata.Entity) error {
+	if _, ok := r.entities[entity.Cti]; ok {
+		return fmt.Errorf("duplicate cti entity %s", entity.Cti)
+	}
+
+	switch {
+	case entity.Values != nil:
+		r.instances[entity.Cti] = entity
+	case entity.Schema != nil:
+		r.types[entity.Cti] = entity
+	}
+	r.entities[entity.Cti] = entity
+
+	vendor, pkg := metadata.GetVendorAndPackage(entity.Cti)
+	put(r.byVendor, vendor, entity)
+	put(r.byPackage, PackageKey(vendor, pkg), entity)
+
+	if parentCti := metadata.GetParentCti(entity.Cti); parentCti != entity.Cti {
+		put(r.children, parentCti, entity)
+	}
+	return nil
+}
+
+// PackageKey returns the key used to index entities by package.
+func PackageKey(vendor, pkg string) string {
+	return vendor + "." + pkg
+}
+
+// Len returns the number of indexed entities.
+func (r *Registry) Len() int {
+	return len(r.entities)
+}
+
+// Get returns the entity by its CTI.
+func (r *Registry) Get(cti string) (*metadata.Entity, bool) {
+	entity, ok := r.entities[cti]
+	return entity, ok
+}
+
+// GetType returns the type by its CTI.
+func (r *Registry) GetType(cti string) (*metadata.Entity, bool) {
+	entity, ok := r.types[cti]
+	return entity, ok
+}
+
+// GetInstance returns the instance by its CTI.
+func (r *Registry) GetInstance(cti string) (*metadata.Entity, bool) {
+	entity, ok := r.instances[cti]
+	return entity, ok
+}
+
+// Entities returns all indexed entities sorted by CTI.
+func (r *Registry) Entities() metadata.Entities {
+	return sorted(r.entities)
+}
+
+// Types returns all indexed types sorted by CTI.
+func (r *Registry) Types() metadata.Entities {
+	return sorted(r.types)
+}
+
+// Instances returns all indexed instances sorted by CTI.
+func (r *Registry) Instances() metadata.Entities {
+	return sorted(r.instances)
+}
+
+// Vendors returns sorted names of all vendors that own indexed entities.
+func (r *Registry) Vendors() []string {
+	return sortedKeys(r.byVendor)
+}
+
+// Packages returns sorted keys (<vendor>.<package>) of all packages that own indexed entities.
+func (r *Registry) Packages() []string {
+	return sortedKeys(r.byPackage)
+}
+
+// ByVendor returns entities owned by the vendor sorted by CTI.
+func (r *Registry) ByVendor(vendor string) metadata.Entities {
+	return sorted(r.byVendor[vendor])
+}
+
+// ByPackage returns entities owned by the vendor package sorted by CTI.
+func (r *Registry) ByPackage(vendor, pkg string) metadata.Entities {
+	return sorted(r.byPackage[PackageKey(vendor, pkg)])
+}
+
+// Parent returns the parent entity of the CTI.
+func (r *Registry) Parent(cti string) (*metadata.Entity, bool) {
+	parentCti := metadata.GetParentCti(cti)
+	if parentCti == cti {
+		return nil, false
+	}
+	return r.Get(parentCti)
+}
+
+// Ancestors returns the chain of indexed ancestors of the CTI starting from the direct parent.
+// The chain stops at the first ancestor that is not indexed.
+func (r *Registry) Ancestors(cti string) metadata.Entities {
+	var res metadata.Entities
+	for {
+		parent, ok := r.Parent(cti)
+		if !ok {
+			return res
+		}
+		res = append(res, parent)
+		cti = parent.Cti
+	}
+}
+
+// Children returns direct descendants of the CTI sorted by CTI.
+func (r *Registry) Children(cti string) metadata.Entities {
+	return sorted(r.children[cti])
+}
+
+// Descendants returns all descendants of the CTI in depth-first order.
+func (r *Registry) Descendants(cti string) metadata.Entities {
+	var res metadata.Entities
+	for _, child := range r.Children(cti) {
+		res = append(res, child)
+		res = append(res, r.Descendants(child.Cti)...)
+	}
+	return res
+}
+
+func put(index map[string]metadata.EntitiesMap, key string, entity *metadata.Entity) {
+	m, ok := index[key]
+	if !ok {
+		m = make(metadata.EntitiesMap)
+		index[key] = m
+	}
+	m[entity.Cti] = entity
+}
+
+func sorted(m metadata.EntitiesMap) metadata.Entities {
+	res := make(metadata.Entities, 0, len(m))
+	for _, entity := range m {
+		res = append(res, entity)
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].Cti < res[b].Cti
+	})
+	return res
+}
+
+func sortedKeys(m map[string]metadata.EntitiesMap) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
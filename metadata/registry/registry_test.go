@@ -0,0 +1,123 @@
+package registry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+func makeTestRegistry(t *testing.T) *Registry {
+	t.Helper()
+
+	entities := metadata.EntitiesMap{}
+	for _, id := range []string{
+		"cti.a.p.base.v1.0",
+		"cti.a.p.base.v1.0~a.p.child.v1.0",
+		"cti.a.p.base.v1.0~x.y.child.v1.0",
+		"cti.a.p.base.v1.0~x.y.child.v1.0~x.z.grandchild.v1.0",
+		"cti.x.y.other.v1.0",
+	} {
+		entities[id] = &metadata.Entity{Cti: id, Schema: []byte(`{}`)}
+	}
+	for _, id := range []string{
+		"cti.a.p.base.v1.0~x.y.child.v1.0~x.y._.v1.0",
+		"cti.a.p.base.v1.0~0f8a0a6e-2b8f-4f1a-9e5f-5d1a6c1c2b3d",
+	} {
+		entities[id] = &metadata.Entity{Cti: id, Values: []byte(`{}`)}
+	}
+
+	r, err := New(entities)
+	require.NoError(t, err)
+	return r
+}
+
+func ctis(entities metadata.Entities) []string {
+	res := make([]string, len(entities))
+	for i, entity := range entities {
+		res[i] = entity.Cti
+	}
+	return res
+}
+
+func Test_Lookup(t *testing.T) {
+	r := makeTestRegistry(t)
+
+	require.Equal(t, 7, r.Len())
+	require.Len(t, r.Types(), 5)
+	require.Len(t, r.Instances(), 2)
+
+	_, ok := r.GetType("cti.a.p.base.v1.0")
+	require.True(t, ok)
+	_, ok = r.GetInstance("cti.a.p.base.v1.0")
+	require.False(t, ok)
+
+	require.Equal(t, []string{"a", "x"}, r.Vendors())
+	require.Equal(t, []string{"a.p", "x.y", "x.z"}, r.Packages())
+	require.Equal(t, []string{
+		"cti.a.p.base.v1.0",
+		"cti.a.p.base.v1.0~0f8a0a6e-2b8f-4f1a-9e5f-5d1a6c1c2b3d",
+		"cti.a.p.base.v1.0~a.p.child.v1.0",
+	}, ctis(r.ByPackage("a", "p")))
+	require.Equal(t, []string{
+		"cti.a.p.base.v1.0~x.y.child.v1.0",
+		"cti.a.p.base.v1.0~x.y.child.v1.0~x.y._.v1.0",
+		"cti.a.p.base.v1.0~x.y.child.v1.0~x.z.grandchild.v1.0",
+		"cti.x.y.other.v1.0",
+	}, ctis(r.ByVendor("x")))
+}
+
+func Test_Hierarchy(t *testing.T) {
+	r := makeTestRegistry(t)
+
+	parent, ok := r.Parent("cti.a.p.base.v1.0~x.y.child.v1.0")
+	require.True(t, ok)
+	require.Equal(t, "cti.a.p.base.v1.0", parent.Cti)
+
+	_, ok = r.Parent("cti.a.p.base.v1.0")
+	require.False(t, ok)
+
+	require.Equal(t, []string{
+		"cti.a.p.base.v1.0~x.y.child.v1.0",
+		"cti.a.p.base.v1.0",
+	}, ctis(r.Ancestors("cti.a.p.base.v1.0~x.y.child.v1.0~x.z.grandchild.v1.0")))
+
+	require.Equal(t, []string{
+		"cti.a.p.base.v1.0~0f8a0a6e-2b8f-4f1a-9e5f-5d1a6c1c2b3d",
+		"cti.a.p.base.v1.0~a.p.child.v1.0",
+		"cti.a.p.base.v1.0~x.y.child.v1.0",
+	}, ctis(r.Children("cti.a.p.base.v1.0")))
+
+	require.Equal(t, []string{
+		"cti.a.p.base.v1.0~x.y.child.v1.0~x.y._.v1.0",
+		"cti.a.p.base.v1.0~x.y.child.v1.0~x.z.grandchild.v1.0",
+	}, ctis(r.Descendants("cti.a.p.base.v1.0~x.y.child.v1.0")))
+}
+
+func Test_AddDuplicate(t *testing.T) {
+	r := makeTestRegistry(t)
+
+	err := r.Add(&metadata.Entity{Cti: "cti.x.y.other.v1.0", Schema: []byte(`{}`)})
+	require.ErrorContains(t, err, "duplicate cti entity cti.x.y.other.v1.0")
+}
+
+func Test_AddWithoutSchemaAndValues(t *testing.T) {
+	r := makeTestRegistry(t)
+
+	require.NoError(t, r.Add(&metadata.Entity{Cti: "cti.a.p.base.v1.0~a.p.empty.v1.0"}))
+	_, ok := r.Get("cti.a.p.base.v1.0~a.p.empty.v1.0")
+	require.True(t, ok)
+	_, ok = r.GetType("cti.a.p.base.v1.0~a.p.empty.v1.0")
+	require.False(t, ok)
+	_, ok = r.GetInstance("cti.a.p.base.v1.0~a.p.empty.v1.0")
+	require.False(t, ok)
+	require.Contains(t, ctis(r.Children("cti.a.p.base.v1.0")), "cti.a.p.base.v1.0~a.p.empty.v1.0")
+}
+
+func Test_NewEmpty(t *testing.T) {
+	r := NewEmpty()
+	require.Zero(t, r.Len())
+	require.NoError(t, r.Add(&metadata.Entity{Cti: "cti.a.p.base.v1.0", Schema: []byte(`{}`)}))
+	require.Equal(t, 1, r.Len())
+}
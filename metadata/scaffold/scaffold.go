@@ -0,0 +1,255 @@
+// Package scaffold generates files of new packages from templates: the layout of directories, example entities,
+// validation profiles and test cases.
+package scaffold
+
+import (
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/acronis/go-cti/metadata/storage"
+)
+
+// TemplateExt marks files whose contents are executed as text templates, the extension is removed from names of
+// generated files. Other files are copied as is. Paths of all files may contain template actions, e.g.
+// entities/{{.Package}}.raml.tmpl.
+const TemplateExt = ".tmpl"
+
+// Built-in templates.
+const (
+	TemplateLibrary      = "library"
+	TemplateService      = "service"
+	TemplateDomainEvents = "domain-events"
+)
+
+// Built-in templates include dot files, e.g. .cti.yaml, so the directory is embedded with the all: prefix.
+//
+//go:embed all:templates
+var templatesFS embed.FS
+
+// Data are values available to templates.
+type Data struct {
+	// PackageID is the identifier of the package in <vendor>.<package> form.
+	PackageID    string
+	Vendor       string
+	Package      string
+	RamlxVersion string
+}
+
+// NewData returns values of templates for the package identifier.
+func NewData(packageID string, ramlxVersion string) Data {
+	vendor, pkg, _ := strings.Cut(packageID, ".")
+	return Data{PackageID: packageID, Vendor: vendor, Package: pkg, RamlxVersion: ramlxVersion}
+}
+
+// Template is a set of files of a new package.
+type Template struct {
+	Name string
+	fsys fs.FS
+	// cloneDir is the temporary directory of the cloned git repository of the template, removed by Close.
+	cloneDir string
+}
+
+// Builtin returns names of built-in templates.
+func Builtin() []string {
+	return []string{TemplateDomainEvents, TemplateLibrary, TemplateService}
+}
+
+// Open opens the template by one of:
+//   - the name of a built-in template, see Builtin;
+//   - the URL of a git repository, optionally with a branch or a tag after #, e.g. https://github.com/acme/tmpl.git#v1;
+//   - the path of a directory, if the name contains a path separator or starts with a dot;
+//   - the name of a directory of userDir, e.g. ~/.cti/templates.
+//
+// Templates of git repositories are cloned to a temporary directory, so the template must be closed.
+func Open(name string, userDir string) (*Template, error) {
+	switch {
+	case isBuiltin(name):
+		sub, err := fs.Sub(templatesFS, path.Join("templates", name))
+		if err != nil {
+			return nil, fmt.Errorf("open built-in template %s: %w", name, err)
+		}
+		return &Template{Name: name, fsys: sub}, nil
+	case isGitURL(name):
+		return clone(name)
+	case strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, "."):
+		return openDir(name, name)
+	case userDir != "":
+		dir := filepath.Join(userDir, name)
+		if _, err := os.Stat(dir); err == nil {
+			return openDir(name, dir)
+		}
+	}
+	return nil, fmt.Errorf("unknown template %s, built-in templates: %s", name, strings.Join(Builtin(), ", "))
+}
+
+func isBuiltin(name string) bool {
+	for _, b := range Builtin() {
+		if b == name {
+			return true
+		}
+	}
+	return false
+}
+
+func isGitURL(name string) bool {
+	for _, prefix := range []string{"https://", "http://", "ssh://", "git://", "file://", "git@"} {
+		if strings.HasPrefix(name, prefix) {
+			return true
+		}
+	}
+	u, _, _ := strings.Cut(name, "#")
+	return strings.HasSuffix(u, ".git")
+}
+
+func openDir(name string, dir string) (*Template, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, fmt.Errorf("open template %s: %w", name, err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("open template %s: %s is not a directory", name, dir)
+	}
+	return &Template{Name: name, fsys: os.DirFS(dir)}, nil
+}
+
+// clone clones the git repository of the template without history.
+func clone(name string) (*Template, error) {
+	if storage.IsOffline() {
+		return nil, fmt.Errorf("clone template %s: %w", name, storage.ErrOffline)
+	}
+	dir, err := os.MkdirTemp("", ".cti-template-")
+	if err != nil {
+		return nil, fmt.Errorf("create temp dir: %w", err)
+	}
+	args := []string{"clone", "--quiet", "--depth", "1"}
+	u, ref, _ := strings.Cut(name, "#")
+	if ref != "" {
+		args = append(args, "--branch", ref)
+	}
+	cmd := exec.Command("git", append(args, u, dir)...)
+	slog.Info("Executing", slog.String("command", cmd.String()))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		_ = os.RemoveAll(dir)
+		return nil, fmt.Errorf("git clone %s: %w: %s", u, err, strings.TrimSpace(string(out)))
+	}
+	return &Template{Name: name, fsys: os.DirFS(dir), cloneDir: dir}, nil
+}
+
+// Close removes the clone of the git repository of the template.
+func (t *Template) Close() error {
+	if t.cloneDir == "" {
+		return nil
+	}
+	if err := os.RemoveAll(t.cloneDir); err != nil {
+		return fmt.Errorf("remove template clone: %w", err)
+	}
+	return nil
+}
+
+// file is a file of the template and the path of the generated file relative to the package.
+type file struct {
+	src      string
+	dst      string
+	template bool
+}
+
+// Execute generates files of the template in the directory and returns their paths relative to the directory,
+// sorted. Nothing is written if any of the files exists, so existing files are never overwritten.
+func (t *Template) Execute(dir string, data Data) ([]string, error) {
+	files, err := t.files(data)
+	if err != nil {
+		return nil, err
+	}
+	var existing []string
+	for _, f := range files {
+		if _, err := os.Stat(filepath.Join(dir, f.dst)); err == nil {
+			existing = append(existing, f.dst)
+		}
+	}
+	if len(existing) != 0 {
+		return nil, fmt.Errorf("files of template %s already exist: %s", t.Name, strings.Join(existing, ", "))
+	}
+
+	res := make([]string, 0, len(files))
+	for _, f := range files {
+		content, err := fs.ReadFile(t.fsys, f.src)
+		if err != nil {
+			return nil, fmt.Errorf("read %s: %w", f.src, err)
+		}
+		if f.template {
+			if content, err = execute(f.src, string(content), data); err != nil {
+				return nil, err
+			}
+		}
+		dst := filepath.Join(dir, filepath.FromSlash(f.dst))
+		if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+			return nil, fmt.Errorf("create directory of %s: %w", f.dst, err)
+		}
+		if err := os.WriteFile(dst, content, 0644); err != nil {
+			return nil, fmt.Errorf("write %s: %w", f.dst, err)
+		}
+		res = append(res, f.dst)
+	}
+	return res, nil
+}
+
+// files lists files of the template with executed paths. Files of the .git directory of cloned templates are
+// skipped.
+func (t *Template) files(data Data) ([]file, error) {
+	var files []file
+	err := fs.WalkDir(t.fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			if d.Name() == ".git" {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		dst, err := execute(p, p, data)
+		if err != nil {
+			return err
+		}
+		f := file{src: p, dst: strings.TrimSuffix(string(dst), TemplateExt), template: strings.HasSuffix(p, TemplateExt)}
+		if !fs.ValidPath(f.dst) {
+			return fmt.Errorf("invalid path %s of template file %s", f.dst, p)
+		}
+		files = append(files, f)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list files of template %s: %w", t.Name, err)
+	}
+	if len(files) == 0 {
+		return nil, errors.New("template " + t.Name + " has no files")
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].dst < files[j].dst })
+	return files, nil
+}
+
+func execute(name string, text string, data Data) ([]byte, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("parse template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("execute template %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
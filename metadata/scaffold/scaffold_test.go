@@ -0,0 +1,134 @@
+package scaffold
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata/ctipackage"
+	"github.com/acronis/go-cti/metadata/formatter"
+)
+
+func Test_Builtin(t *testing.T) {
+	for _, name := range Builtin() {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := Open(name, "")
+			require.NoError(t, err)
+			defer tmpl.Close()
+
+			dir := t.TempDir()
+			files, err := tmpl.Execute(dir, NewData("acme.shop", "1.0"))
+			require.NoError(t, err)
+			require.Contains(t, files, ctipackage.IndexFileName)
+			require.Contains(t, files, ".cti.yaml")
+
+			idx, err := ctipackage.ReadIndex(dir)
+			require.NoError(t, err)
+			require.Equal(t, "acme.shop", idx.PackageID)
+			require.Equal(t, "1.0", idx.RamlxVersion)
+			for _, entity := range idx.Entities {
+				require.FileExists(t, filepath.Join(dir, entity))
+			}
+		})
+	}
+}
+
+// Test_BuiltinFormatted checks that packages initialized from builtin templates pass cti fmt --check.
+func Test_BuiltinFormatted(t *testing.T) {
+	f, err := formatter.New(formatter.DefaultStyle())
+	require.NoError(t, err)
+
+	for _, name := range Builtin() {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := Open(name, "")
+			require.NoError(t, err)
+			defer tmpl.Close()
+
+			dir := t.TempDir()
+			files, err := tmpl.Execute(dir, NewData("acme.shop", "1.0"))
+			require.NoError(t, err)
+			for _, file := range files {
+				if filepath.Ext(file) != formatter.Extension {
+					continue
+				}
+				src, err := os.ReadFile(filepath.Join(dir, file))
+				require.NoError(t, err)
+				res, err := f.Format(src)
+				require.NoError(t, err, file)
+				require.Equal(t, string(res), string(src), file)
+			}
+		})
+	}
+}
+
+func Test_Execute(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(src, "entities"), 0755))
+	require.NoError(t, os.MkdirAll(filepath.Join(src, ".git"), 0755))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "entities", "{{.Package}}.raml.tmpl"),
+		[]byte("(cti.cti): cti.{{.Vendor}}.{{.Package}}.type.v1.0\n"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(src, "static.txt"), []byte("{{.Package}}"), 0600))
+	require.NoError(t, os.WriteFile(filepath.Join(src, ".git", "HEAD"), []byte("ref"), 0600))
+
+	tmpl, err := Open(src, "")
+	require.NoError(t, err)
+
+	dst := t.TempDir()
+	files, err := tmpl.Execute(dst, NewData("acme.shop", "1.0"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"entities/shop.raml", "static.txt"}, files)
+
+	data, err := os.ReadFile(filepath.Join(dst, "entities", "shop.raml"))
+	require.NoError(t, err)
+	require.Equal(t, "(cti.cti): cti.acme.shop.type.v1.0\n", string(data))
+	data, err = os.ReadFile(filepath.Join(dst, "static.txt"))
+	require.NoError(t, err)
+	require.Equal(t, "{{.Package}}", string(data))
+
+	_, err = tmpl.Execute(dst, NewData("acme.shop", "1.0"))
+	require.ErrorContains(t, err, "already exist: entities/shop.raml, static.txt")
+}
+
+func Test_ExecuteInvalid(t *testing.T) {
+	src := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(src, "a.txt.tmpl"), []byte("{{.Unknown}}"), 0600))
+
+	tmpl, err := Open(src, "")
+	require.NoError(t, err)
+	dst := t.TempDir()
+	_, err = tmpl.Execute(dst, NewData("acme.shop", "1.0"))
+	require.ErrorContains(t, err, "execute template a.txt.tmpl")
+	require.NoFileExists(t, filepath.Join(dst, "a.txt"))
+}
+
+func Test_Open(t *testing.T) {
+	userDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(userDir, "corp"), 0755))
+
+	tmpl, err := Open("corp", userDir)
+	require.NoError(t, err)
+	require.Equal(t, "corp", tmpl.Name)
+
+	_, err = Open("missing", userDir)
+	require.ErrorContains(t, err, "unknown template missing, built-in templates: domain-events, library, service")
+
+	_, err = Open("./missing", userDir)
+	require.ErrorContains(t, err, "open template ./missing")
+}
+
+func Test_IsGitURL(t *testing.T) {
+	for name, expected := range map[string]bool{
+		"https://github.com/acme/template":      true,
+		"git@github.com:acme/template.git":      true,
+		"github.com/acme/template.git#v1":       true,
+		"file:///tmp/template":                  true,
+		"library":                               false,
+		"./templates/library":                   false,
+		"github.com/acme/template":              false,
+		"/home/user/.cti/templates/domain.tmpl": false,
+	} {
+		require.Equal(t, expected, isGitURL(name), name)
+	}
+}
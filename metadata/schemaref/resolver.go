@@ -0,0 +1,333 @@
+package schemaref
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/storage"
+)
+
+const (
+	refKey = "$ref"
+
+	defaultTimeout  = 30 * time.Second
+	maxDocumentSize = 16 << 20
+
+	// DefaultMaxDepth is a default maximum nesting depth of a resolved schema.
+	DefaultMaxDepth = 128
+	// DefaultMaxSize is a default maximum number of JSON values in a resolved schema.
+	DefaultMaxSize = 1 << 20
+)
+
+// Resolver resolves JSON Schema $ref targets that are not local to the schema document.
+// Relative and file:// references are resolved against the referencing document, starting from the base directory.
+// HTTPS references are downloaded and stored in the cache directory, which is used as a fallback when offline.
+// Local references (i.e., "#/definitions/X") of the root schema are left as is.
+type Resolver struct {
+	baseDir  string
+	cacheDir string
+	offline  bool
+	client   *http.Client
+	maxDepth int
+	maxSize  int
+
+	// Resolutions are serialized, since they share loaded documents and the size of the resolved schema.
+	mu        sync.Mutex
+	documents map[string]any
+	size      int
+}
+
+type Option func(*Resolver)
+
+// WithCacheDir sets a directory where downloaded documents are cached.
+func WithCacheDir(dir string) Option {
+	return func(r *Resolver) {
+		r.cacheDir = dir
+	}
+}
+
+// WithOffline disables downloading of remote documents. Only cached documents are used.
+// Downloading is disabled by storage.OfflineEnvVar by default.
+func WithOffline(offline bool) Option {
+	return func(r *Resolver) {
+		r.offline = offline
+	}
+}
+
+// WithHTTPClient sets HTTP client used to download remote documents.
+func WithHTTPClient(client *http.Client) Option {
+	return func(r *Resolver) {
+		r.client = client
+	}
+}
+
+// WithLimits sets the maximum nesting depth and the maximum number of JSON values of a resolved schema.
+// Inlining of references may grow the schema exponentially, so resolution fails once a limit is exceeded.
+// Zero value disables the limit.
+func WithLimits(maxDepth, maxSize int) Option {
+	return func(r *Resolver) {
+		r.maxDepth = maxDepth
+		r.maxSize = maxSize
+	}
+}
+
+// New creates a new resolver that resolves relative references against the base directory.
+func New(baseDir string, opts ...Option) *Resolver {
+	r := &Resolver{
+		baseDir:   baseDir,
+		offline:   storage.IsOffline(),
+		client:    &http.Client{Timeout: defaultTimeout},
+		maxDepth:  DefaultMaxDepth,
+		maxSize:   DefaultMaxSize,
+		documents: make(map[string]any),
+	}
+	for _, opt := range opts {
+		opt(r)
+	}
+	return r
+}
+
+// Resolve returns a copy of the schema with all non-local references replaced by their targets.
+// It is safe for concurrent use.
+func (r *Resolver) Resolve(schema map[string]any) (map[string]any, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	base := &url.URL{Scheme: "file", Path: filepath.ToSlash(r.baseDir) + "/"}
+	r.size = 0
+	res, err := r.resolve(schema, base, false, nil, 1)
+	if err != nil {
+		return nil, err
+	}
+	return res.(map[string]any), nil
+}
+
+// resolve walks the node and replaces references. Local references are resolved only within external documents
+// since they point to the document that is inlined into another one.
+func (r *Resolver) resolve(node any, base *url.URL, external bool, stack []string, depth int) (any, error) {
+	switch v := node.(type) {
+	case map[string]any:
+		if ref, ok := v[refKey].(string); ok && (external || !strings.HasPrefix(ref, "#")) {
+			return r.resolveRef(v, ref, base, stack, depth)
+		}
+		if err := r.grow(depth); err != nil {
+			return nil, err
+		}
+		res := make(map[string]any, len(v))
+		for key, item := range v {
+			resolved, err := r.resolve(item, base, external, stack, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			res[key] = resolved
+		}
+		return res, nil
+	case []any:
+		if err := r.grow(depth); err != nil {
+			return nil, err
+		}
+		res := make([]any, len(v))
+		for i, item := range v {
+			resolved, err := r.resolve(item, base, external, stack, depth+1)
+			if err != nil {
+				return nil, err
+			}
+			res[i] = resolved
+		}
+		return res, nil
+	}
+	if err := r.grow(depth); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// grow accounts a value of the resolved schema at the specified depth and checks the limits.
+func (r *Resolver) grow(depth int) error {
+	if r.maxDepth > 0 && depth > r.maxDepth {
+		return &metadata.LimitError{Limit: "schema depth", Max: r.maxDepth}
+	}
+	r.size++
+	if r.maxSize > 0 && r.size > r.maxSize {
+		return &metadata.LimitError{Limit: "schema size", Max: r.maxSize}
+	}
+	return nil
+}
+
+func (r *Resolver) resolveRef(node map[string]any, ref string, base *url.URL, stack []string, depth int) (any, error) {
+	refURL, err := base.Parse(ref)
+	if err != nil {
+		return nil, fmt.Errorf("parse $ref %s: %w", ref, err)
+	}
+	id := refURL.String()
+	for i, item := range stack {
+		if item == id {
+			return nil, fmt.Errorf("cyclic $ref: %s", strings.Join(append(stack[i:], id), " -> "))
+		}
+	}
+
+	docURL := *refURL
+	docURL.Fragment = ""
+	doc, err := r.load(&docURL)
+	if err != nil {
+		return nil, fmt.Errorf("resolve $ref %s: %w", ref, err)
+	}
+	target, err := pointer(doc, refURL.Fragment)
+	if err != nil {
+		return nil, fmt.Errorf("resolve $ref %s: %w", ref, err)
+	}
+	resolved, err := r.resolve(target, &docURL, true, append(stack[:len(stack):len(stack)], id), depth)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keywords next to $ref (i.e., annotations) are preserved unless the target defines them.
+	if m, ok := resolved.(map[string]any); ok {
+		for key, val := range node {
+			if _, ok := m[key]; !ok && key != refKey {
+				m[key] = val
+			}
+		}
+	}
+	return resolved, nil
+}
+
+func (r *Resolver) load(u *url.URL) (any, error) {
+	key := u.String()
+	if doc, ok := r.documents[key]; ok {
+		return doc, nil
+	}
+
+	var data []byte
+	var err error
+	switch u.Scheme {
+	case "file":
+		data, err = os.ReadFile(filepath.FromSlash(u.Path))
+	case "https":
+		data, err = r.download(u)
+	default:
+		return nil, fmt.Errorf("unsupported $ref scheme: %s", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("decode %s: %w", key, err)
+	}
+	r.documents[key] = doc
+	return doc, nil
+}
+
+func (r *Resolver) download(u *url.URL) ([]byte, error) {
+	cachePath := r.cachePath(u)
+	if r.offline {
+		if cachePath == "" {
+			return nil, fmt.Errorf("%s is not available offline", u)
+		}
+		data, err := os.ReadFile(cachePath)
+		if err != nil {
+			return nil, fmt.Errorf("%s is not available offline: %w", u, err)
+		}
+		return data, nil
+	}
+
+	data, err := r.fetch(u)
+	if err != nil {
+		if cachePath != "" {
+			if cached, cacheErr := os.ReadFile(cachePath); cacheErr == nil {
+				return cached, nil
+			}
+		}
+		return nil, err
+	}
+	if cachePath != "" {
+		if err := os.MkdirAll(filepath.Dir(cachePath), 0755); err != nil {
+			return nil, fmt.Errorf("create cache dir: %w", err)
+		}
+		if err := os.WriteFile(cachePath, data, 0644); err != nil {
+			return nil, fmt.Errorf("write cache: %w", err)
+		}
+	}
+	return data, nil
+}
+
+func (r *Resolver) fetch(u *url.URL) ([]byte, error) {
+	resp, err := r.client.Get(u.String())
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", u, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("download %s: unexpected status %s", u, resp.Status)
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxDocumentSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("download %s: %w", u, err)
+	}
+	if len(data) > maxDocumentSize {
+		return nil, fmt.Errorf("download %s: document exceeds %d bytes", u, maxDocumentSize)
+	}
+	return data, nil
+}
+
+// cachePath returns a path of the cached document, i.e., <cache dir>/<host>/<path>.
+// Documents with query are stored under a hash of the query.
+func (r *Resolver) cachePath(u *url.URL) string {
+	if r.cacheDir == "" {
+		return ""
+	}
+	p := path.Clean("/" + u.Path)
+	if p == "/" {
+		p = "/index.json"
+	}
+	if u.RawQuery != "" {
+		sum := sha256.Sum256([]byte(u.RawQuery))
+		p += "@" + hex.EncodeToString(sum[:8])
+	}
+	return filepath.Join(r.cacheDir, u.Host, filepath.FromSlash(p))
+}
+
+// pointer evaluates JSON pointer fragment against the document.
+func pointer(doc any, fragment string) (any, error) {
+	if fragment == "" || fragment == "/" {
+		return doc, nil
+	}
+	if !strings.HasPrefix(fragment, "/") {
+		return nil, fmt.Errorf("unsupported fragment: %s", fragment)
+	}
+	cur := doc
+	for _, token := range strings.Split(fragment[1:], "/") {
+		token = strings.ReplaceAll(strings.ReplaceAll(token, "~1", "/"), "~0", "~")
+		switch v := cur.(type) {
+		case map[string]any:
+			next, ok := v[token]
+			if !ok {
+				return nil, fmt.Errorf("pointer %s: %s not found", fragment, token)
+			}
+			cur = next
+		case []any:
+			var idx int
+			if _, err := fmt.Sscanf(token, "%d", &idx); err != nil || idx < 0 || idx >= len(v) {
+				return nil, fmt.Errorf("pointer %s: invalid index %s", fragment, token)
+			}
+			cur = v[idx]
+		default:
+			return nil, fmt.Errorf("pointer %s: cannot descend into scalar", fragment)
+		}
+	}
+	return cur, nil
+}
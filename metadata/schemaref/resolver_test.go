@@ -0,0 +1,119 @@
+package schemaref
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/storage"
+)
+
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(filepath.Dir(path), 0755))
+	require.NoError(t, os.WriteFile(path, []byte(content), 0600))
+}
+
+func Test_ResolveFile(t *testing.T) {
+	baseDir := t.TempDir()
+	writeFile(t, filepath.Join(baseDir, ".dep", "x.y", "schemas", "address.json"), `{
+		"definitions": {
+			"Address": {"type": "object", "properties": {"country": {"$ref": "#/definitions/Country"}}},
+			"Country": {"type": "string", "maxLength": 2}
+		}
+	}`)
+
+	r := New(baseDir)
+	res, err := r.Resolve(map[string]any{
+		"$ref": "#/definitions/User",
+		"definitions": map[string]any{
+			"User": map[string]any{
+				"type": "object",
+				"properties": map[string]any{
+					"address": map[string]any{"$ref": ".dep/x.y/schemas/address.json#/definitions/Address", "description": "Home address"},
+				},
+			},
+		},
+	})
+	require.NoError(t, err)
+	require.Equal(t, "#/definitions/User", res["$ref"])
+
+	address := res["definitions"].(map[string]any)["User"].(map[string]any)["properties"].(map[string]any)["address"].(map[string]any)
+	require.Equal(t, "Home address", address["description"])
+	require.Equal(t, map[string]any{"type": "string", "maxLength": float64(2)}, address["properties"].(map[string]any)["country"])
+}
+
+func Test_ResolveCycle(t *testing.T) {
+	baseDir := t.TempDir()
+	writeFile(t, filepath.Join(baseDir, "a.json"), `{"properties": {"b": {"$ref": "b.json"}}}`)
+	writeFile(t, filepath.Join(baseDir, "b.json"), `{"properties": {"a": {"$ref": "a.json"}}}`)
+
+	_, err := New(baseDir).Resolve(map[string]any{"$ref": "a.json"})
+	require.ErrorContains(t, err, "cyclic $ref")
+}
+
+func Test_ResolveRemote(t *testing.T) {
+	available := true
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(`{"type": "string", "format": "email"}`))
+	}))
+	defer srv.Close()
+
+	cacheDir := t.TempDir()
+	schema := map[string]any{"$ref": srv.URL + "/schemas/email.json"}
+	expected := map[string]any{"type": "string", "format": "email"}
+
+	res, err := New(t.TempDir(), WithCacheDir(cacheDir), WithHTTPClient(srv.Client())).Resolve(schema)
+	require.NoError(t, err)
+	require.Equal(t, expected, res)
+
+	// Cached copy is used when the server is not available.
+	available = false
+	res, err = New(t.TempDir(), WithCacheDir(cacheDir), WithHTTPClient(srv.Client())).Resolve(schema)
+	require.NoError(t, err)
+	require.Equal(t, expected, res)
+
+	res, err = New(t.TempDir(), WithCacheDir(cacheDir), WithOffline(true)).Resolve(schema)
+	require.NoError(t, err)
+	require.Equal(t, expected, res)
+
+	_, err = New(t.TempDir(), WithCacheDir(t.TempDir()), WithOffline(true)).Resolve(schema)
+	require.ErrorContains(t, err, "is not available offline")
+
+	t.Setenv(storage.OfflineEnvVar, "true")
+	available = true
+	_, err = New(t.TempDir(), WithCacheDir(t.TempDir()), WithHTTPClient(srv.Client())).Resolve(schema)
+	require.ErrorContains(t, err, "is not available offline")
+}
+
+func Test_ResolveLimits(t *testing.T) {
+	// Every document references the next one twice, so the resolved schema doubles with every level.
+	baseDir := t.TempDir()
+	for i := 0; i < 24; i++ {
+		writeFile(t, filepath.Join(baseDir, fmt.Sprintf("%d.json", i)),
+			fmt.Sprintf(`{"properties": {"a": {"$ref": "%[1]d.json"}, "b": {"$ref": "%[1]d.json"}}}`, i+1))
+	}
+	writeFile(t, filepath.Join(baseDir, "24.json"), `{"type": "string"}`)
+
+	var limitErr *metadata.LimitError
+	_, err := New(baseDir, WithLimits(0, 1000)).Resolve(map[string]any{"$ref": "0.json"})
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, "schema size", limitErr.Limit)
+
+	_, err = New(baseDir, WithLimits(8, 0)).Resolve(map[string]any{"$ref": "0.json"})
+	require.ErrorAs(t, err, &limitErr)
+	require.Equal(t, "schema depth", limitErr.Limit)
+
+	_, err = New(baseDir, WithLimits(0, 0)).Resolve(map[string]any{"$ref": "20.json"})
+	require.NoError(t, err)
+}
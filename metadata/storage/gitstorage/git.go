@@ -6,9 +6,12 @@ import (
 	"log/slog"
 	"net/http"
 	"net/url"
+	"os"
 	"os/exec"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
 )
 
 var (
@@ -36,6 +39,59 @@ func gitLsRemote(remote string, ref string) (string, error) {
 	return refData[0], nil
 }
 
+// gitRef is a branch or a tag of a remote repository.
+type gitRef struct {
+	Hash string
+	Name string
+}
+
+// gitLsRemoteRefs lists refs of the remote repository, e.g. only tags with --tags.
+// Peeled tags are listed with the hash of the commit and the name of the tag.
+func gitLsRemoteRefs(remote string, args ...string) ([]gitRef, error) {
+	cmd := exec.Command("git", append(append([]string{"ls-remote"}, args...), remote)...)
+	slog.Info("Executing", slog.String("command", cmd.String()))
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("git ls-remote: %w", err)
+	}
+	var refs []gitRef
+	for _, line := range strings.Split(string(out), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) != 2 {
+			continue
+		}
+		refs = append(refs, gitRef{Hash: fields[0], Name: strings.TrimSuffix(fields[1], "^{}")})
+	}
+	return refs, nil
+}
+
+// gitCommitTime returns the time of the commit of the ref of the remote repository. Only the commit is fetched.
+func gitCommitTime(remote string, ref string) (time.Time, error) {
+	dir, err := os.MkdirTemp("", ".cti-git-")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	if _, err := exec.Command("git", "init", "--quiet", dir).Output(); err != nil {
+		return time.Time{}, fmt.Errorf("git init: %w", err)
+	}
+	cmd := exec.Command("git", "-C", dir, "fetch", "--quiet", "--depth", "1", remote, ref)
+	slog.Info("Executing", slog.String("command", cmd.String()))
+	if _, err := cmd.Output(); err != nil {
+		return time.Time{}, fmt.Errorf("git fetch: %w", err)
+	}
+	out, err := exec.Command("git", "-C", dir, "log", "-1", "--format=%ct", "FETCH_HEAD").Output()
+	if err != nil {
+		return time.Time{}, fmt.Errorf("git log: %w", err)
+	}
+	sec, err := strconv.ParseInt(strings.TrimSpace(string(out)), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse commit time: %w", err)
+	}
+	return time.Unix(sec, 0).UTC(), nil
+}
+
 func parseGoQuery(goQuery string) (string, string, string) {
 	parts := strings.Split(goQuery, " ")
 	return parts[0], parts[1], parts[2]
@@ -2,9 +2,12 @@ package gitstorage
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/acronis/go-cti/metadata/storage"
 
+	"golang.org/x/mod/module"
 	"golang.org/x/mod/semver"
 )
 
@@ -23,22 +26,31 @@ func (g *storageImpl) Discover(name string, version string) (storage.Origin, err
 	if !semver.IsValid(version) {
 		return nil, fmt.Errorf("invalid version %s", version)
 	}
-
-	source := fmt.Sprintf("https://%s", name)
-	body, err := discoverSource(source)
-	if err != nil {
-		return nil, fmt.Errorf("discover source at %s: %w", source, err)
+	if storage.IsOffline() {
+		return nil, fmt.Errorf("discover %s: %w", name, storage.ErrOffline)
 	}
 
-	m := goImportRe.FindStringSubmatch(string(body))
-	if len(m) == 0 {
-		return nil, fmt.Errorf("find go-import at %s", source)
-	}
-	_, _, sourceLocation := parseGoQuery(m[len(m)-1])
-	// TODO: use module.PseudoVersion() to get commit hash
-	commitHash, err := gitLsRemote(sourceLocation, version)
+	sourceLocation, err := discoverLocation(name)
 	if err != nil {
-		return nil, fmt.Errorf("git ls-remote: %w", err)
+		return nil, err
+	}
+	var commitHash string
+	if module.IsPseudoVersion(version) {
+		// Pseudo-versions are not tags, so the commit is looked up by the revision of the version.
+		rev, err := module.PseudoVersionRev(version)
+		if err != nil {
+			return nil, fmt.Errorf("parse pseudo-version %s: %w", version, err)
+		}
+		ref, err := findRevision(sourceLocation, rev)
+		if err != nil {
+			return nil, err
+		}
+		commitHash = ref.Hash
+	} else {
+		commitHash, err = gitLsRemote(sourceLocation, version)
+		if err != nil {
+			return nil, fmt.Errorf("git ls-remote: %w", err)
+		}
 	}
 	if commitHash == "" {
 		return nil, fmt.Errorf("failed to find %s %s", sourceLocation, version)
@@ -51,3 +63,77 @@ func (g *storageImpl) Discover(name string, version string) (storage.Origin, err
 		Ref:  version,
 	}, nil
 }
+
+// Versions lists tags of the repository of the source that are semantic versions.
+func (g *storageImpl) Versions(name string) ([]string, error) {
+	if storage.IsOffline() {
+		return nil, fmt.Errorf("list versions of %s: %w", name, storage.ErrOffline)
+	}
+	sourceLocation, err := discoverLocation(name)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := gitLsRemoteRefs(sourceLocation, "--tags")
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, ref := range refs {
+		version := strings.TrimPrefix(ref.Name, "refs/tags/")
+		if semver.IsValid(version) && !slices.Contains(versions, version) {
+			versions = append(versions, version)
+		}
+	}
+	return versions, nil
+}
+
+// PseudoVersion returns the pseudo-version of the commit of the repository of the source. The commit may be
+// abbreviated, but it must be the head of a branch or a tag, since only advertised commits can be fetched.
+func (g *storageImpl) PseudoVersion(name string, rev string) (string, error) {
+	if storage.IsOffline() {
+		return "", fmt.Errorf("resolve %s %s: %w", name, rev, storage.ErrOffline)
+	}
+	sourceLocation, err := discoverLocation(name)
+	if err != nil {
+		return "", err
+	}
+	ref, err := findRevision(sourceLocation, rev)
+	if err != nil {
+		return "", err
+	}
+	commitTime, err := gitCommitTime(sourceLocation, ref.Name)
+	if err != nil {
+		return "", fmt.Errorf("get time of commit %s: %w", ref.Hash, err)
+	}
+	return module.PseudoVersion("", "", commitTime, ref.Hash[:12]), nil
+}
+
+// discoverLocation returns the URL of the git repository of the source from its go-import meta tag.
+func discoverLocation(name string) (string, error) {
+	source := fmt.Sprintf("https://%s", name)
+	body, err := discoverSource(source)
+	if err != nil {
+		return "", fmt.Errorf("discover source at %s: %w", source, err)
+	}
+
+	m := goImportRe.FindStringSubmatch(string(body))
+	if len(m) == 0 {
+		return "", fmt.Errorf("find go-import at %s", source)
+	}
+	_, _, sourceLocation := parseGoQuery(m[len(m)-1])
+	return sourceLocation, nil
+}
+
+// findRevision returns the branch or the tag whose commit starts with the revision.
+func findRevision(sourceLocation string, rev string) (gitRef, error) {
+	refs, err := gitLsRemoteRefs(sourceLocation)
+	if err != nil {
+		return gitRef{}, err
+	}
+	for _, ref := range refs {
+		if strings.HasPrefix(ref.Hash, rev) && ref.Name != "HEAD" {
+			return ref, nil
+		}
+	}
+	return gitRef{}, fmt.Errorf("commit %s is not the head of a branch or a tag of %s", rev, sourceLocation)
+}
@@ -7,6 +7,8 @@ import (
 
 	"github.com/acronis/go-cti/metadata/filesys"
 	"github.com/acronis/go-cti/metadata/storage"
+
+	"golang.org/x/mod/module"
 )
 
 type gitInfo struct {
@@ -44,7 +46,11 @@ func (i *gitInfo) Download(cacheDir string) (string, error) {
 	cacheZip := filepath.Join(cacheDir, filepath.Dir(i.Name), filename)
 
 	// TODO: download by commit hash not by ref
-	if err := gitArchive(i.URL, i.Ref, cacheZip); err != nil {
+	ref := i.Ref
+	if module.IsPseudoVersion(ref) {
+		ref = i.Hash
+	}
+	if err := gitArchive(i.URL, ref, cacheZip); err != nil {
 		return "", err
 	}
 
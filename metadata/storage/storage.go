@@ -1,5 +1,23 @@
 package storage
 
+import (
+	"errors"
+	"os"
+	"strconv"
+)
+
+// OfflineEnvVar disables network access of storages if set to a true value, e.g. 1 or true.
+const OfflineEnvVar = "CTI_OFFLINE"
+
+// ErrOffline is returned by storages that cannot discover sources without network access.
+var ErrOffline = errors.New("network access is disabled with " + OfflineEnvVar)
+
+// IsOffline reports whether network access is disabled with OfflineEnvVar.
+func IsOffline() bool {
+	offline, _ := strconv.ParseBool(os.Getenv(OfflineEnvVar))
+	return offline
+}
+
 type Origin interface {
 	Validate(Origin) error
 	Download(string) (string, error)
@@ -8,4 +26,8 @@ type Origin interface {
 type Storage interface {
 	Origin() Origin
 	Discover(string, string) (Origin, error)
+	// Versions lists versions of the source advertised by the storage, e.g. tags of a git repository.
+	Versions(string) ([]string, error)
+	// PseudoVersion returns the pseudo-version of the revision of the source, e.g. of a commit of a git repository.
+	PseudoVersion(string, string) (string, error)
 }
@@ -15,3 +15,22 @@ func GetBaseCti(cti string) string {
 	}
 	return cti
 }
+
+// GetVendorAndPackage returns the vendor and package of the entity that owns the CTI,
+// i.e. the vendor and package of the last inheritance chunk. Anonymous entities are owned by their parent.
+func GetVendorAndPackage(cti string) (string, string) {
+	chunk := strings.TrimPrefix(cti, "cti.")
+	if pos := strings.LastIndex(chunk, "~"); pos != -1 {
+		tail := chunk[pos+1:]
+		if !strings.Contains(tail, ".") {
+			// Anonymous entity identified by UUID.
+			return GetVendorAndPackage(chunk[:pos])
+		}
+		chunk = tail
+	}
+	parts := strings.SplitN(chunk, ".", 3)
+	if len(parts) < 2 {
+		return "", ""
+	}
+	return parts[0], parts[1]
+}
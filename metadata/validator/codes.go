@@ -0,0 +1,277 @@
+package validator
+
+import (
+	"sort"
+)
+
+// Code is a stable identifier of a validation diagnostic.
+// Codes are never reused or renumbered, so they can be used to suppress rules and to look up documentation.
+type Code string
+
+const (
+	CodeInvalidIdentifier     Code = "CTI1001"
+	CodeParentNotFound        Code = "CTI1002"
+	CodeFinalParent           Code = "CTI1003"
+	CodeInstanceOfNonType     Code = "CTI1004"
+	CodeInvalidInheritance    Code = "CTI1005"
+	CodeMissingAnnotations    Code = "CTI1006"
+	CodeBaseTypeNotFound      Code = "CTI1007"
+	CodeTraitsNotDefined      Code = "CTI1008"
+	CodeInvalidSchema         Code = "CTI1010"
+	CodeSchemaMergeFailed     Code = "CTI1011"
+	CodeUnresolvedRef         Code = "CTI1012"
+	CodeLimitExceeded         Code = "CTI1013"
+	CodeInvalidValues         Code = "CTI1020"
+	CodeInvalidTraits         Code = "CTI1021"
+	CodeValueNotAllowed       Code = "CTI1022"
+	CodeInvalidFormat         Code = "CTI1023"
+	CodeReferenceMismatch     Code = "CTI1030"
+	CodeInvalidReference      Code = "CTI1031"
+	CodeReferenceWidened      Code = "CTI1032"
+	CodeReferenceIncompatible Code = "CTI1033"
+	CodeDuplicateEntity       Code = "CTI1040"
+	CodeIdentifierFormat      Code = "CTI1050"
+	CodeUnknownAnnotation     Code = "CTI1060"
+	CodeUnknownKeyword        Code = "CTI1061"
+	CodeAnnotationPlacement   Code = "CTI1062"
+)
+
+// Severity is a severity of a diagnostic in permissive mode. Strict mode reports all diagnostics as errors.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+)
+
+// Rule describes a diagnostic that can be reported by the validator.
+type Rule struct {
+	Code        Code     `json:"code"`
+	Name        string   `json:"name"`
+	Title       string   `json:"title"`
+	Category    Category `json:"category"`
+	Severity    Severity `json:"severity"`
+	Description string   `json:"description"`
+	Example     string   `json:"example,omitempty"`
+	Remediation string   `json:"remediation"`
+}
+
+var rules = map[Code]Rule{
+	CodeInvalidIdentifier: {
+		Name:        "invalid-identifier",
+		Category:    CategoryParse,
+		Title:       "Entity identifier is not a valid CTI",
+		Description: "The identifier of a type or an instance does not conform to the CTI syntax and cannot be parsed.",
+		Example:     "(cti.cti): cti.x.y.My-Type.v1",
+		Remediation: "Use the cti.<vendor>.<package>.<entity>.v<major>.<minor> form with lowercase letters, digits and underscores.",
+	},
+	CodeParentNotFound: {
+		Name:        "parent-not-found",
+		Category:    CategoryReference,
+		Title:       "Parent type is not found",
+		Description: "The entity is derived from a parent CTI that is not defined by the package or any of its dependencies.",
+		Example:     "(cti.cti): cti.x.y.event.v1.0~x.y.created.v1.0 # cti.x.y.event.v1.0 is not defined",
+		Remediation: "Define the parent type or add the package that defines it as a dependency with 'cti pkg get'.",
+	},
+	CodeFinalParent: {
+		Name:        "final-parent",
+		Category:    CategoryInheritance,
+		Title:       "Entity is derived from a final type",
+		Description: "Types are final by default and cannot be extended unless they explicitly allow it.",
+		Example:     "(cti.cti): cti.x.y.alert.v1.0 # (cti.final) is not set to false",
+		Remediation: "Set (cti.final): false on the parent type or derive the entity from another type.",
+	},
+	CodeInstanceOfNonType: {
+		Name:        "instance-of-non-type",
+		Category:    CategoryInheritance,
+		Title:       "Instance is derived from an entity that is not a type",
+		Description: "Instances can be created only from CTI types that define a schema.",
+		Remediation: "Make sure the parent CTI of the instance points to a type and not to another instance.",
+	},
+	CodeInvalidInheritance: {
+		Name:        "invalid-inheritance",
+		Category:    CategoryInheritance,
+		Title:       "Instance identifier does not match its parent type",
+		Description: "The identifier of the instance does not extend the identifier of the type it is declared for.",
+		Example:     "(Alerts): [{id: cti.x.y.topic.v1.0~x.y.disk_full.v1.0}] # Alerts are defined by cti.x.y.alert.v1.0",
+		Remediation: "Prefix the instance identifier with the CTI of its type.",
+	},
+	CodeMissingAnnotations: {
+		Name:        "missing-annotations",
+		Category:    CategoryDefinition,
+		Title:       "Parent type of the instance does not define annotations",
+		Description: "A type used to declare instances must carry CTI annotations (at least cti.cti and cti.id).",
+		Remediation: "Annotate the type with (cti.cti) and mark the identifier property with (cti.id): true.",
+	},
+	CodeBaseTypeNotFound: {
+		Name:        "base-type-not-found",
+		Category:    CategoryReference,
+		Title:       "Base type is not found",
+		Description: "The entity defines traits, but the root type of its inheritance chain cannot be found.",
+		Remediation: "Define the base type or add the package that defines it as a dependency.",
+	},
+	CodeTraitsNotDefined: {
+		Name:        "traits-not-defined",
+		Category:    CategoryInheritance,
+		Title:       "Traits are set for a type that does not define traits schema",
+		Description: "Trait values can only be specified for types derived from a type that declares a traits schema.",
+		Example:     "cti-traits: {topic: users} # base type has no cti-traits facet",
+		Remediation: "Declare the traits schema in the base type or remove the trait values.",
+	},
+	CodeInvalidSchema: {
+		Name:        "invalid-schema",
+		Category:    CategoryParse,
+		Title:       "Schema is not a valid JSON Schema",
+		Description: "The JSON Schema produced from the RAML type (or its traits) cannot be loaded by a JSON Schema validator.",
+		Remediation: "Check the RAML type definition for unsupported facets, malformed patterns or invalid defaults.",
+	},
+	CodeSchemaMergeFailed: {
+		Name:        "schema-merge-failed",
+		Category:    CategoryInheritance,
+		Title:       "Schema cannot be merged with the parent schema",
+		Description: "The schema of a derived type is incompatible with the schema of one of its ancestors.",
+		Example:     "A property declared as string in the parent is redeclared as integer in the child, or enum of the child allows values the parent does not.",
+		Remediation: "Make the derived type narrow the parent type instead of changing property types.",
+	},
+	CodeUnresolvedRef: {
+		Name:        "unresolved-ref",
+		Category:    CategoryReference,
+		Title:       "Schema $ref cannot be resolved",
+		Description: "A $ref in the schema points to a file or an HTTPS URL that cannot be loaded, or references form a cycle. Downloaded documents are cached and used when the network is not available.",
+		Example:     `{"$ref": "https://example.com/schemas/address.json#/definitions/Address"}`,
+		Remediation: "Check that the referenced document exists and is reachable, or run validation online once to populate the cache.",
+	},
+	CodeLimitExceeded: {
+		Name:        "limit-exceeded",
+		Category:    CategoryParse,
+		Title:       "Schema or instance exceeds a processing limit",
+		Description: "A schema after resolving references, a schema merged with its ancestors, or instance values are nested deeper or contain more values than allowed. Limits guard against definitions that would exhaust memory or stack.",
+		Example:     `{"$ref": "#/definitions/A"} # A references B twice, B references C twice, ...`,
+		Remediation: "Simplify the definition, or raise the limits with --max-schema-depth, --max-schema-size or --max-instance-depth if the definition is intentional.",
+	},
+	CodeInvalidValues: {
+		Name:        "invalid-values",
+		Category:    CategoryConstraint,
+		Title:       "Instance values do not conform to the type schema",
+		Description: "The values of an instance are validated against the merged schema of its type and all of its ancestors.",
+		Remediation: "Fix the instance values according to the reported schema violations.",
+	},
+	CodeInvalidTraits: {
+		Name:        "invalid-traits",
+		Category:    CategoryConstraint,
+		Title:       "Trait values do not conform to the traits schema",
+		Description: "The trait values of a type are validated against the traits schema declared by its base type.",
+		Remediation: "Fix the trait values according to the reported schema violations.",
+	},
+	CodeValueNotAllowed: {
+		Name:        "value-not-allowed",
+		Category:    CategoryConstraint,
+		Title:       "Instance value is not in the allowed value set",
+		Description: "A value of an instance violates enum or const constraints of its type or its ancestors, or the discriminator property does not hold the discriminator value of the type.",
+		Example:     "severity: critical # enum: [low, medium, high]",
+		Remediation: "Use one of the allowed values listed in the error message.",
+	},
+	CodeInvalidFormat: {
+		Name:        "invalid-format",
+		Category:    CategoryConstraint,
+		Title:       "Instance value does not match the string format",
+		Description: "A string value of an instance does not conform to the format of its type or its ancestors, e.g., date-time, email, uri or uuid.",
+		Example:     "created_at: yesterday # type: datetime",
+		Remediation: "Use a value in the format required by the type.",
+	},
+	CodeReferenceMismatch: {
+		Name:        "reference-mismatch",
+		Category:    CategoryReference,
+		Title:       "Referenced CTI does not match cti.reference",
+		Description: "A value of a property annotated with cti.reference points to an entity that does not match the reference expression.",
+		Example:     "topic: cti.x.y.alert.v1.0~x.y.disk_full.v1.0 # (cti.reference): cti.x.y.topic.v1.0",
+		Remediation: "Use an identifier of an entity that matches the reference expression.",
+	},
+	CodeInvalidReference: {
+		Name:        "invalid-reference",
+		Category:    CategoryReference,
+		Title:       "cti.reference is not a valid CTI expression",
+		Description: "The expression specified in the cti.reference annotation cannot be parsed.",
+		Remediation: "Set cti.reference to true or to a valid CTI expression.",
+	},
+	CodeReferenceWidened: {
+		Name:        "reference-widened",
+		Category:    CategoryReference,
+		Title:       "cti.reference is widened by a derived type",
+		Description: "A parent type restricts a reference to specific CTI, but the derived type allows references to any entity.",
+		Remediation: "Use the parent reference expression or a more specific one in the derived type.",
+	},
+	CodeReferenceIncompatible: {
+		Name:        "reference-incompatible",
+		Category:    CategoryReference,
+		Title:       "cti.reference is incompatible with the parent reference",
+		Description: "The reference expression of a derived type does not match the reference expression of its parent.",
+		Remediation: "Narrow the parent reference expression instead of replacing it.",
+	},
+	CodeDuplicateEntity: {
+		Name:        "duplicate-entity",
+		Category:    CategoryDefinition,
+		Title:       "Entity is defined more than once",
+		Description: "The same CTI is defined by more than one type or instance within the package and its dependencies.",
+		Remediation: "Remove one of the definitions, give the entities different identifiers or drop the dependency that redefines the entity.",
+	},
+	CodeIdentifierFormat: {
+		Name:        "identifier-format",
+		Category:    CategoryConstraint,
+		Title:       "Identifier segment does not follow the required format",
+		Description: "Packages may declare identifier_rules in index.json that require identifiers derived from their types to be UUIDs, ULIDs or to match a regular expression.",
+		Example:     `cti.x.y.topic.v1.0~x.y.users.v1.0 # {"parent": "cti.x.y.topic.v1.0", "segment": "tail", "format": "uuid"}`,
+		Remediation: "Rename the entity according to the rule. Use 'cti init --generate-id <parent>' to generate a compliant identifier.",
+	},
+	CodeUnknownAnnotation: {
+		Name:        "unknown-annotation",
+		Category:    CategoryDefinition,
+		Title:       "Annotation is not supported",
+		Severity:    SeverityWarning,
+		Description: "The type uses an annotation from the cti namespace that is not supported by the metadata model and is ignored.",
+		Example:     "(cti.embed): true",
+		Remediation: "Remove the annotation or upgrade the tool to a version that supports it.",
+	},
+	CodeUnknownKeyword: {
+		Name:        "unknown-keyword",
+		Category:    CategoryDefinition,
+		Title:       "Schema contains a keyword that is not defined by JSON Schema",
+		Severity:    SeverityWarning,
+		Description: "The JSON Schema produced from the RAML type contains a keyword that is not part of draft-07 and is ignored by validators. Extension keywords prefixed with x- are allowed.",
+		Remediation: "Remove the facet from the RAML type or prefix custom keywords with x-.",
+	},
+	CodeAnnotationPlacement: {
+		Name:        "annotation-placement",
+		Category:    CategoryDefinition,
+		Title:       "Annotation is used on a target it is not declared for",
+		Description: "Every known annotation declares the targets it may be used on: the type itself, its attributes, traits attributes. Annotations that define instance fields may be used on top-level attributes only. Instances may not have annotations.",
+		Example:     "properties: {data: {properties: {id: {type: cti.CTI, (cti.id): true}}}} # cti.id is allowed on attributes at depth up to 1",
+		Remediation: "Move the annotation to an allowed target listed in the error message or remove it. See the annotation description in the RAMLx specification.",
+	},
+}
+
+// Explain returns the rule description for the specified diagnostic code.
+func Explain(code Code) (Rule, bool) {
+	rule, ok := rules[code]
+	if !ok {
+		return Rule{}, false
+	}
+	rule.Code = code
+	if rule.Severity == "" {
+		rule.Severity = SeverityError
+	}
+	return rule, true
+}
+
+// Rules returns descriptions of all diagnostics sorted by code.
+func Rules() []Rule {
+	res := make([]Rule, 0, len(rules))
+	for code := range rules {
+		rule, _ := Explain(code)
+		res = append(res, rule)
+	}
+	sort.Slice(res, func(a, b int) bool {
+		return res[a].Code < res[b].Code
+	})
+	return res
+}
@@ -0,0 +1,129 @@
+package validator
+
+import (
+	"fmt"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+// Category groups diagnostics by the kind of failure.
+type Category string
+
+const (
+	// CategoryParse is a category of identifiers and schemas that cannot be parsed or processed.
+	CategoryParse Category = "parse"
+	// CategoryReference is a category of references to entities or documents that cannot be resolved or do not match.
+	CategoryReference Category = "reference"
+	// CategoryInheritance is a category of entities that are derived from incompatible parents.
+	CategoryInheritance Category = "inheritance"
+	// CategoryConstraint is a category of values and identifiers that violate constraints of their types.
+	CategoryConstraint Category = "constraint"
+	// CategoryDefinition is a category of entities that are defined incorrectly, e.g., twice or with unknown annotations.
+	CategoryDefinition Category = "definition"
+)
+
+// Position is a location of the entity definition.
+type Position struct {
+	// File is a path to the RAML file as recorded in the entity source map.
+	File   string `json:"file,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+func (p Position) String() string {
+	if p.Line == 0 {
+		return p.File
+	}
+	return fmt.Sprintf("%s:%d:%d", p.File, p.Line, p.Column)
+}
+
+func positionOf(entity *metadata.Entity) Position {
+	return Position{
+		File:   entity.SourceMap.OriginalPath,
+		Line:   entity.SourceMap.Line,
+		Column: entity.SourceMap.Column,
+	}
+}
+
+// Error is a validation diagnostic identified by a stable code.
+// Use errors.As with ParseError, ReferenceError, InheritanceError, ConstraintError or DefinitionError
+// to handle a category of diagnostics.
+type Error struct {
+	Code     Code
+	Severity Severity
+	// ID is the CTI of the entity that failed validation.
+	ID string
+	// Path is a path to the property of the values or the annotated schema node, if applicable.
+	Path string
+	// Position is a location of the entity definition, if known.
+	Position Position
+	Msg      string
+}
+
+// Error implements "error" interface.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Msg)
+}
+
+// Category returns the category of the diagnostic.
+func (e *Error) Category() Category {
+	rule, _ := Explain(e.Code)
+	return rule.Category
+}
+
+// As allows errors.As to match the diagnostic against its category error type.
+func (e *Error) As(target any) bool {
+	switch t := target.(type) {
+	case **ParseError:
+		if e.Category() == CategoryParse {
+			*t = (*ParseError)(e)
+			return true
+		}
+	case **ReferenceError:
+		if e.Category() == CategoryReference {
+			*t = (*ReferenceError)(e)
+			return true
+		}
+	case **InheritanceError:
+		if e.Category() == CategoryInheritance {
+			*t = (*InheritanceError)(e)
+			return true
+		}
+	case **ConstraintError:
+		if e.Category() == CategoryConstraint {
+			*t = (*ConstraintError)(e)
+			return true
+		}
+	case **DefinitionError:
+		if e.Category() == CategoryDefinition {
+			*t = (*DefinitionError)(e)
+			return true
+		}
+	}
+	return false
+}
+
+// ParseError is a diagnostic of an identifier or a schema that cannot be parsed or processed.
+type ParseError Error
+
+func (e *ParseError) Error() string { return (*Error)(e).Error() }
+
+// ReferenceError is a diagnostic of a parent, a cti.reference or a $ref that cannot be resolved or does not match.
+type ReferenceError Error
+
+func (e *ReferenceError) Error() string { return (*Error)(e).Error() }
+
+// InheritanceError is a diagnostic of an entity that is incompatible with its parent.
+type InheritanceError Error
+
+func (e *InheritanceError) Error() string { return (*Error)(e).Error() }
+
+// ConstraintError is a diagnostic of values or an identifier that violate constraints of the type.
+type ConstraintError Error
+
+func (e *ConstraintError) Error() string { return (*Error)(e).Error() }
+
+// DefinitionError is a diagnostic of an entity that is defined incorrectly.
+type DefinitionError Error
+
+func (e *DefinitionError) Error() string { return (*Error)(e).Error() }
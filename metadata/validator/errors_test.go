@@ -0,0 +1,66 @@
+package validator
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+func Test_ErrorCategories(t *testing.T) {
+	base := makeType("cti.x.y.event.v1.0", "Event", `{
+		"type": "object",
+		"properties": {"severity": {"type": "string", "enum": ["low", "high"]}}
+	}`)
+	base.SourceMap = metadata.SourceMap{OriginalPath: "events.raml", Line: 3, Column: 2}
+
+	instance := &metadata.Entity{
+		Cti:       base.Cti + "~x.y.item.v1.0",
+		Values:    []byte(`{"severity": "critical"}`),
+		SourceMap: metadata.SourceMap{OriginalPath: "events.raml", Line: 10, Column: 4},
+	}
+	orphan := &metadata.Entity{Cti: "cti.x.y.missing.v1.0~x.y.item.v1.0", Values: []byte(`{}`)}
+
+	v := MakeMetadataValidator()
+	require.NoError(t, v.AddEntities(metadata.Entities{base, instance, orphan}))
+
+	err := v.Validate(instance)
+	var constraintErr *ConstraintError
+	require.ErrorAs(t, err, &constraintErr)
+	require.Equal(t, CodeValueNotAllowed, constraintErr.Code)
+	require.Equal(t, instance.Cti, constraintErr.ID)
+	require.Equal(t, "severity", constraintErr.Path)
+	require.Equal(t, Position{File: "events.raml", Line: 10, Column: 4}, constraintErr.Position)
+	require.Equal(t, "events.raml:10:4", constraintErr.Position.String())
+
+	var referenceErr *ReferenceError
+	require.False(t, errors.As(err, &referenceErr))
+
+	err = v.Validate(orphan)
+	require.ErrorAs(t, err, &referenceErr)
+	require.Equal(t, CodeParentNotFound, referenceErr.Code)
+	require.Equal(t, CategoryReference, (*Error)(referenceErr).Category())
+
+	err = v.AddEntities(metadata.Entities{base})
+	var definitionErr *DefinitionError
+	require.ErrorAs(t, err, &definitionErr)
+	require.Equal(t, "events.raml:3:2", definitionErr.Position.String())
+}
+
+func Test_ValidateAllErrors(t *testing.T) {
+	base := makeType("cti.x.y.event.v1.0", "Event", `{"type": "object", "properties": {"count": {"type": "integer"}}}`)
+	instance := &metadata.Entity{Cti: base.Cti + "~x.y.item.v1.0", Values: []byte(`{"count": "many"}`)}
+
+	v := MakeMetadataValidator()
+	require.NoError(t, v.AddEntities(metadata.Entities{base, instance}))
+	require.Error(t, v.ValidateAll())
+
+	errs := v.Errors()
+	require.Len(t, errs, 1)
+	var constraintErr *ConstraintError
+	require.ErrorAs(t, errs[0], &constraintErr)
+	require.Equal(t, CodeInvalidValues, constraintErr.Code)
+	require.Equal(t, "count", constraintErr.Path)
+}
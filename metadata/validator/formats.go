@@ -0,0 +1,154 @@
+package validator
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const formatKey = "format"
+
+// FormatChecker reports whether the string value conforms to a format.
+type FormatChecker func(value string) bool
+
+var timeRe = regexp.MustCompile(`^([01][0-9]|2[0-3]):[0-5][0-9]:([0-5][0-9]|60)(\.[0-9]+)?(Z|[+-]([01][0-9]|2[0-3]):[0-5][0-9])?$`)
+
+// DefaultFormatCheckers returns checkers for formats that are produced from RAML types or commonly used in CTI schemas.
+func DefaultFormatCheckers() map[string]FormatChecker {
+	return map[string]FormatChecker{
+		"date-time": func(value string) bool {
+			_, err := time.Parse(time.RFC3339Nano, value)
+			return err == nil
+		},
+		"date": func(value string) bool {
+			_, err := time.Parse(time.DateOnly, value)
+			return err == nil
+		},
+		"time": func(value string) bool {
+			return timeRe.MatchString(value)
+		},
+		"email": func(value string) bool {
+			addr, err := mail.ParseAddress(value)
+			return err == nil && addr.Address == value
+		},
+		"uri": func(value string) bool {
+			u, err := url.Parse(value)
+			return err == nil && u.Scheme != ""
+		},
+		"uuid": func(value string) bool {
+			_, err := uuid.Parse(value)
+			return err == nil && len(value) == 36
+		},
+	}
+}
+
+// WithFormatChecker registers a checker for the format. It replaces the default checker with the same name.
+// Values of formats without a registered checker are not checked.
+func WithFormatChecker(name string, checker FormatChecker) Option {
+	return func(v *MetadataValidator) {
+		v.formats[name] = checker
+	}
+}
+
+type formatError struct {
+	path   string
+	format string
+	value  string
+}
+
+// checkFormats walks the value along with the schema and checks string values against format checkers.
+// Only properties, patternProperties, additionalProperties, items and allOf are followed
+// since the remaining applicators do not guarantee that a subschema applies to the value.
+func (v *MetadataValidator) checkFormats(schema map[string]any, value any, path string) []formatError {
+	var errs []formatError
+	if name, ok := schema[formatKey].(string); ok {
+		if str, ok := value.(string); ok {
+			if checker, ok := v.formats[name]; ok && !checker(str) {
+				errs = append(errs, formatError{path: path, format: name, value: str})
+			}
+		}
+	}
+	if allOf, ok := schema["allOf"].([]any); ok {
+		for _, item := range allOf {
+			if sub, ok := item.(map[string]any); ok {
+				errs = append(errs, v.checkFormats(sub, value, path)...)
+			}
+		}
+	}
+
+	switch val := value.(type) {
+	case map[string]any:
+		properties, _ := schema["properties"].(map[string]any)
+		patternProperties, _ := schema["patternProperties"].(map[string]any)
+		additional, _ := schema["additionalProperties"].(map[string]any)
+		keys := make([]string, 0, len(val))
+		for key := range val {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			matched := false
+			if sub, ok := properties[key].(map[string]any); ok {
+				errs = append(errs, v.checkFormats(sub, val[key], joinPath(path, key))...)
+				matched = true
+			}
+			for pattern, item := range patternProperties {
+				re, err := regexp.Compile(pattern)
+				if err != nil || !re.MatchString(key) {
+					continue
+				}
+				if sub, ok := item.(map[string]any); ok {
+					errs = append(errs, v.checkFormats(sub, val[key], joinPath(path, key))...)
+					matched = true
+				}
+			}
+			if !matched && additional != nil {
+				errs = append(errs, v.checkFormats(additional, val[key], joinPath(path, key))...)
+			}
+		}
+	case []any:
+		if items, ok := schema["items"].(map[string]any); ok {
+			for i, item := range val {
+				errs = append(errs, v.checkFormats(items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// withoutFormats returns a copy of the schema without format keywords
+// since formats are checked by the validator format checkers.
+func withoutFormats(node any) any {
+	switch v := node.(type) {
+	case map[string]any:
+		res := make(map[string]any, len(v))
+		for key, item := range v {
+			if key == formatKey {
+				if _, ok := item.(string); ok {
+					continue
+				}
+			}
+			res[key] = withoutFormats(item)
+		}
+		return res
+	case []any:
+		res := make([]any, len(v))
+		for i, item := range v {
+			res[i] = withoutFormats(item)
+		}
+		return res
+	}
+	return node
+}
@@ -0,0 +1,124 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+func Test_ValidateFacets(t *testing.T) {
+	base := makeType("cti.x.y.user.v1.0", "User", `{
+		"type": "object",
+		"properties": {
+			"age": {"type": "integer", "minimum": 0, "maximum": 150},
+			"name": {"type": "string", "minLength": 1, "maxLength": 32, "pattern": "^[a-z_]+$"},
+			"email": {"type": "string", "format": "email"},
+			"created_at": {"type": "string", "format": "date-time"},
+			"tags": {"type": "array", "items": {"type": "string", "format": "uuid"}}
+		}
+	}`)
+	derived := makeType("cti.x.y.user.v1.0~x.y.admin.v1.0", "Admin", `{
+		"type": "object",
+		"properties": {
+			"age": {"type": "integer", "minimum": 18},
+			"name": {"type": "string", "pattern": "^admin_"}
+		}
+	}`)
+	widened := makeType("cti.x.y.user.v1.0~x.y.widened.v1.0", "Widened", `{
+		"type": "object",
+		"properties": {"name": {"type": "string", "maxLength": 64}}
+	}`)
+	reformatted := makeType("cti.x.y.user.v1.0~x.y.reformatted.v1.0", "Reformatted", `{
+		"type": "object",
+		"properties": {"email": {"type": "string", "format": "uri"}}
+	}`)
+
+	testCases := []struct {
+		name   string
+		parent string
+		values string
+		codes  []Code
+	}{
+		{
+			name:   "valid",
+			parent: derived.Cti,
+			values: `{"age": 30, "name": "admin_", "email": "admin@example.com", "created_at": "2024-01-02T03:04:05Z", "tags": ["5e5ee2d4-3ee7-4b7c-9e7b-2d1c3a7b8f10"]}`,
+		},
+		{
+			name:   "minimum of derived type",
+			parent: derived.Cti,
+			values: `{"age": 17}`,
+			codes:  []Code{CodeInvalidValues},
+		},
+		{
+			name:   "maximum inherited from ancestor",
+			parent: derived.Cti,
+			values: `{"age": 151}`,
+			codes:  []Code{CodeInvalidValues},
+		},
+		{
+			name:   "pattern inherited from ancestor",
+			parent: derived.Cti,
+			values: `{"name": "admin_X"}`,
+			codes:  []Code{CodeInvalidValues},
+		},
+		{
+			name:   "format inherited from ancestor",
+			parent: derived.Cti,
+			values: `{"email": "not an email", "created_at": "yesterday", "tags": ["x"]}`,
+			codes:  []Code{CodeInvalidFormat, CodeInvalidFormat, CodeInvalidFormat},
+		},
+		{
+			name:   "maxLength widened by derived type",
+			parent: widened.Cti,
+			values: `{"name": "abc"}`,
+			codes:  []Code{CodeSchemaMergeFailed},
+		},
+		{
+			name:   "format changed by derived type",
+			parent: reformatted.Cti,
+			values: `{"email": "https://example.com"}`,
+			codes:  []Code{CodeSchemaMergeFailed},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := &metadata.Entity{Cti: tc.parent + "~x.y.item.v1.0", Values: []byte(tc.values)}
+
+			v := MakeMetadataValidator()
+			require.NoError(t, v.AddEntities(metadata.Entities{base, derived, widened, reformatted, instance}))
+
+			var codes []Code
+			for _, err := range v.validate(instance) {
+				codes = append(codes, err.Code)
+			}
+			require.Equal(t, tc.codes, codes)
+		})
+	}
+}
+
+func Test_WithFormatChecker(t *testing.T) {
+	base := makeType("cti.x.y.host.v1.0", "Host", `{
+		"type": "object",
+		"properties": {
+			"name": {"type": "string", "format": "hostname-lower"},
+			"id": {"type": "string", "format": "uuid"}
+		}
+	}`)
+	instance := &metadata.Entity{Cti: base.Cti + "~x.y.item.v1.0", Values: []byte(`{"name": "Example.com", "id": "any"}`)}
+
+	v := MakeMetadataValidator(
+		WithFormatChecker("hostname-lower", func(value string) bool { return value == strings.ToLower(value) }),
+		WithFormatChecker("uuid", func(string) bool { return true }),
+	)
+	require.NoError(t, v.AddEntities(metadata.Entities{base, instance}))
+
+	errs := v.validate(instance)
+	require.Len(t, errs, 1)
+	require.Equal(t, CodeInvalidFormat, errs[0].Code)
+	require.Contains(t, errs[0].Msg, `@name: value "Example.com" does not match format hostname-lower`)
+}
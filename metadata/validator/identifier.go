@@ -0,0 +1,190 @@
+package validator
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+// IdentifierFormat is a format that an identifier segment must follow.
+type IdentifierFormat string
+
+const (
+	IdentifierFormatUUID  IdentifierFormat = "uuid"
+	IdentifierFormatULID  IdentifierFormat = "ulid"
+	IdentifierFormatRegex IdentifierFormat = "regex"
+)
+
+// IdentifierSegment is a part of CTI that is checked by IdentifierRule.
+type IdentifierSegment string
+
+const (
+	// IdentifierSegmentTail is the last inheritance chunk of CTI, i.e. anonymous entity UUID or <vendor>.<package>.<name>.v<version>.
+	IdentifierSegmentTail IdentifierSegment = "tail"
+	// IdentifierSegmentName is the entity name of the last inheritance chunk of CTI.
+	IdentifierSegmentName IdentifierSegment = "name"
+)
+
+var ulidRe = regexp.MustCompile(`^_?[0-7][0-9a-hjkmnp-tv-z]{25}$`)
+
+// IdentifierRule requires identifiers of entities derived from Parent to follow the specified format.
+// ULID entity names are lowercase and may be prefixed with underscore since CTI entity names cannot start with a digit.
+type IdentifierRule struct {
+	// Parent is the CTI of the type whose descendants are checked. Empty value matches all entities.
+	Parent string `json:"parent,omitempty"`
+	// Kind limits the rule to "type" or "instance" entities. Empty value matches both.
+	Kind    string            `json:"kind,omitempty"`
+	Segment IdentifierSegment `json:"segment"`
+	Format  IdentifierFormat  `json:"format"`
+	// Pattern is a regular expression used with IdentifierFormatRegex.
+	Pattern string `json:"pattern,omitempty"`
+}
+
+// Check checks that the rule is well-formed.
+func (r IdentifierRule) Check() error {
+	switch r.Segment {
+	case IdentifierSegmentTail, IdentifierSegmentName:
+	default:
+		return fmt.Errorf("invalid identifier segment: %s", r.Segment)
+	}
+	switch r.Format {
+	case IdentifierFormatUUID, IdentifierFormatULID:
+	case IdentifierFormatRegex:
+		if _, err := regexp.Compile(r.Pattern); err != nil {
+			return fmt.Errorf("invalid identifier pattern: %w", err)
+		}
+	default:
+		return fmt.Errorf("invalid identifier format: %s", r.Format)
+	}
+	switch r.Kind {
+	case "", "type", "instance":
+	default:
+		return fmt.Errorf("invalid entity kind: %s", r.Kind)
+	}
+	return nil
+}
+
+// Applies reports whether the rule applies to the entity.
+func (r IdentifierRule) Applies(entity *metadata.Entity) bool {
+	if r.Parent != "" && !strings.HasPrefix(entity.Cti, r.Parent+"~") {
+		return false
+	}
+	switch r.Kind {
+	case "type":
+		return entity.IsType()
+	case "instance":
+		return entity.IsInstance()
+	}
+	return true
+}
+
+// Validate checks that the CTI follows the rule.
+func (r IdentifierRule) Validate(cti string) error {
+	value := identifierSegment(cti, r.Segment)
+	switch r.Format {
+	case IdentifierFormatUUID:
+		if _, err := uuid.Parse(value); err != nil || len(value) != 36 {
+			return fmt.Errorf("%s segment %q is not a UUID", r.Segment, value)
+		}
+	case IdentifierFormatULID:
+		if !ulidRe.MatchString(value) {
+			return fmt.Errorf("%s segment %q is not a lowercase ULID", r.Segment, value)
+		}
+	case IdentifierFormatRegex:
+		re, err := regexp.Compile(r.Pattern)
+		if err != nil {
+			return fmt.Errorf("invalid identifier pattern: %w", err)
+		}
+		if !re.MatchString(value) {
+			return fmt.Errorf("%s segment %q does not match %q", r.Segment, value, r.Pattern)
+		}
+	}
+	return nil
+}
+
+// Generate generates a new identifier derived from the parent that follows the rule.
+// Vendor and package are used for the new chunk when the rule requires a named entity.
+func (r IdentifierRule) Generate(parent, vendor, pkg string) (string, error) {
+	switch {
+	case r.Format == IdentifierFormatUUID && r.Segment == IdentifierSegmentTail:
+		return parent + "~" + uuid.NewString(), nil
+	case r.Format == IdentifierFormatULID && r.Segment == IdentifierSegmentName:
+		return fmt.Sprintf("%s~%s.%s._%s.v1.0", parent, vendor, pkg, NewULID()), nil
+	}
+	return "", fmt.Errorf("cannot generate %s identifier for %s segment", r.Format, r.Segment)
+}
+
+func identifierSegment(cti string, segment IdentifierSegment) string {
+	tail := strings.TrimPrefix(cti, "cti.")
+	if pos := strings.LastIndex(tail, "~"); pos != -1 {
+		tail = tail[pos+1:]
+	}
+	if segment == IdentifierSegmentTail {
+		return tail
+	}
+	// <vendor>.<package>.<name>.v<major>.<minor>
+	parts := strings.Split(tail, ".")
+	if len(parts) < 5 {
+		return ""
+	}
+	return strings.Join(parts[2:len(parts)-2], ".")
+}
+
+const crockfordAlphabet = "0123456789abcdefghjkmnpqrstvwxyz"
+
+// NewULID returns a new lowercase ULID.
+func NewULID() string {
+	var buf [16]byte
+	binary.BigEndian.PutUint64(buf[:8], uint64(time.Now().UnixMilli())<<16)
+	_, _ = rand.Read(buf[6:])
+
+	// 128 bits are encoded as 26 characters, 5 bits each, with 2 leading zero bits.
+	hi := binary.BigEndian.Uint64(buf[:8])
+	lo := binary.BigEndian.Uint64(buf[8:])
+	res := make([]byte, 26)
+	for i := 25; i >= 0; i-- {
+		res[i] = crockfordAlphabet[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(res)
+}
+
+// GenerateIdentifier generates a new identifier derived from the parent that follows all applicable rules.
+func GenerateIdentifier(rules []IdentifierRule, parent, vendor, pkg string) (string, error) {
+	var applicable []IdentifierRule
+	for _, rule := range rules {
+		if rule.Parent == "" || parent == rule.Parent || strings.HasPrefix(parent, rule.Parent+"~") {
+			applicable = append(applicable, rule)
+		}
+	}
+	if len(applicable) == 0 {
+		return "", fmt.Errorf("no identifier rules apply to %s", parent)
+	}
+	var errs []error
+	for _, rule := range applicable {
+		id, err := rule.Generate(parent, vendor, pkg)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, other := range applicable {
+			if err = other.Validate(id); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			return id, nil
+		}
+		errs = append(errs, err)
+	}
+	return "", fmt.Errorf("generate identifier for %s: %w", parent, errors.Join(errs...))
+}
@@ -0,0 +1,90 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_IdentifierRule(t *testing.T) {
+	testCases := []struct {
+		name  string
+		rule  IdentifierRule
+		cti   string
+		valid bool
+	}{
+		{
+			name:  "uuid tail",
+			rule:  IdentifierRule{Segment: IdentifierSegmentTail, Format: IdentifierFormatUUID},
+			cti:   "cti.x.y.topic.v1.0~0f8a0a6e-2b8f-4f1a-9e5f-5d1a6c1c2b3d",
+			valid: true,
+		},
+		{
+			name: "named tail is not uuid",
+			rule: IdentifierRule{Segment: IdentifierSegmentTail, Format: IdentifierFormatUUID},
+			cti:  "cti.x.y.topic.v1.0~x.y.users.v1.0",
+		},
+		{
+			name:  "ulid name",
+			rule:  IdentifierRule{Segment: IdentifierSegmentName, Format: IdentifierFormatULID},
+			cti:   "cti.x.y.topic.v1.0~x.y._01arz3ndektsv4rrffq69g5fav.v1.0",
+			valid: true,
+		},
+		{
+			name: "name is not ulid",
+			rule: IdentifierRule{Segment: IdentifierSegmentName, Format: IdentifierFormatULID},
+			cti:  "cti.x.y.topic.v1.0~x.y.users.v1.0",
+		},
+		{
+			name:  "regex name",
+			rule:  IdentifierRule{Segment: IdentifierSegmentName, Format: IdentifierFormatRegex, Pattern: `^[a-z]+(_[a-z]+)*$`},
+			cti:   "cti.x.y.event.v1.0~x.y.user_created.v1.0",
+			valid: true,
+		},
+		{
+			name: "regex name mismatch",
+			rule: IdentifierRule{Segment: IdentifierSegmentName, Format: IdentifierFormatRegex, Pattern: `^[a-z]+(_[a-z]+)*$`},
+			cti:  "cti.x.y.event.v1.0~x.y.user.created.v1.0",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.NoError(t, tc.rule.Check())
+			err := tc.rule.Validate(tc.cti)
+			if tc.valid {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+func Test_IdentifierRuleGenerate(t *testing.T) {
+	for _, rule := range []IdentifierRule{
+		{Segment: IdentifierSegmentTail, Format: IdentifierFormatUUID},
+		{Segment: IdentifierSegmentName, Format: IdentifierFormatULID},
+	} {
+		id, err := rule.Generate("cti.x.y.topic.v1.0", "x", "y")
+		require.NoError(t, err)
+		require.NoError(t, rule.Validate(id))
+	}
+
+	_, err := IdentifierRule{Segment: IdentifierSegmentName, Format: IdentifierFormatRegex, Pattern: ".*"}.Generate("cti.x.y.topic.v1.0", "x", "y")
+	require.Error(t, err)
+}
+
+func Test_GenerateIdentifier(t *testing.T) {
+	rules := []IdentifierRule{
+		{Parent: "cti.x.y.topic.v1.0", Segment: IdentifierSegmentTail, Format: IdentifierFormatUUID},
+		{Parent: "cti.x.y.event.v1.0", Segment: IdentifierSegmentName, Format: IdentifierFormatULID},
+	}
+
+	id, err := GenerateIdentifier(rules, "cti.x.y.event.v1.0", "a", "b")
+	require.NoError(t, err)
+	require.Regexp(t, `^cti\.x\.y\.event\.v1\.0~a\.b\._[0-9a-z]{26}\.v1\.0$`, id)
+
+	_, err = GenerateIdentifier(rules, "cti.x.y.alert.v1.0", "a", "b")
+	require.ErrorContains(t, err, "no identifier rules apply")
+}
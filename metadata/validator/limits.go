@@ -0,0 +1,45 @@
+package validator
+
+import (
+	"errors"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/schemaref"
+)
+
+// Limits guard schema and instance processing against pathological definitions.
+// Zero value of a limit disables it.
+type Limits struct {
+	// MaxSchemaDepth is a maximum nesting depth of an entity schema after references are resolved.
+	MaxSchemaDepth int
+	// MaxSchemaSize is a maximum number of JSON values in an entity schema or a schema merged with its ancestors.
+	MaxSchemaSize int
+	// MaxInstanceDepth is a maximum nesting depth of instance values and traits.
+	MaxInstanceDepth int
+}
+
+// DefaultLimits returns limits used by the validator unless other limits are set with WithLimits.
+func DefaultLimits() Limits {
+	return Limits{
+		MaxSchemaDepth:   schemaref.DefaultMaxDepth,
+		MaxSchemaSize:    schemaref.DefaultMaxSize,
+		MaxInstanceDepth: 64,
+	}
+}
+
+// WithLimits sets limits of schema and instance processing.
+func WithLimits(limits Limits) Option {
+	return func(v *MetadataValidator) {
+		v.limits = limits
+	}
+}
+
+// addLoadError reports the error of schema loading or merging with the specified code,
+// unless the error is caused by an exceeded limit.
+func (d *diagnostics) addLoadError(code Code, id string, err error) {
+	var limitErr *metadata.LimitError
+	if errors.As(err, &limitErr) {
+		code = CodeLimitExceeded
+	}
+	d.add(code, "%s: %s", id, err.Error())
+}
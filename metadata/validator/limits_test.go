@@ -0,0 +1,77 @@
+package validator
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+func Test_Limits(t *testing.T) {
+	nested := func(depth int) string {
+		return strings.Repeat(`{"a": `, depth) + "1" + strings.Repeat("}", depth)
+	}
+	nestedSchema := func(depth int) string {
+		return strings.Repeat(`{"type": "object", "properties": {"a": `, depth) + `{"type": "string"}` + strings.Repeat("}}", depth)
+	}
+	base := makeType("cti.x.y.config.v1.0", "Config", `{"type": "object"}`)
+	deep := makeType("cti.x.y.deep.v1.0", "Deep", nestedSchema(10))
+	derived := makeType("cti.x.y.config.v1.0~x.y.derived.v1.0", "Derived", `{
+		"type": "object",
+		"properties": {"a": {"type": "string"}, "b": {"type": "string"}, "c": {"type": "string"}}
+	}`)
+
+	testCases := []struct {
+		name   string
+		limits Limits
+		entity *metadata.Entity
+		codes  []Code
+	}{
+		{
+			name:   "instance within limits",
+			limits: Limits{MaxInstanceDepth: 4},
+			entity: &metadata.Entity{Cti: base.Cti + "~x.y.item.v1.0", Values: []byte(nested(3))},
+		},
+		{
+			name:   "instance depth",
+			limits: Limits{MaxInstanceDepth: 4},
+			entity: &metadata.Entity{Cti: base.Cti + "~x.y.item.v1.0", Values: []byte(nested(4))},
+			codes:  []Code{CodeLimitExceeded},
+		},
+		{
+			name:   "schema depth",
+			limits: Limits{MaxSchemaDepth: 10},
+			entity: deep,
+			codes:  []Code{CodeLimitExceeded},
+		},
+		{
+			name:   "parent schema size",
+			limits: Limits{MaxSchemaSize: 12},
+			entity: &metadata.Entity{Cti: derived.Cti + "~x.y.item.v1.0", Values: []byte(`{}`)},
+			codes:  []Code{CodeLimitExceeded},
+		},
+		{
+			name:   "disabled limits",
+			entity: deep,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := MakeMetadataValidator(WithLimits(tc.limits))
+			entities := metadata.Entities{base, deep, derived}
+			if tc.entity != deep {
+				entities = append(entities, tc.entity)
+			}
+			require.NoError(t, v.AddEntities(entities))
+
+			var codes []Code
+			for _, err := range v.validate(tc.entity) {
+				codes = append(codes, err.Code)
+			}
+			require.Equal(t, tc.codes, codes)
+		})
+	}
+}
@@ -0,0 +1,148 @@
+package validator
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+// AnnotationTarget is a kind of place where an annotation may be used.
+type AnnotationTarget string
+
+const (
+	// TargetType is the root of a CTI type.
+	TargetType AnnotationTarget = "type"
+	// TargetAttribute is a property or array items of a CTI type.
+	TargetAttribute AnnotationTarget = "attribute"
+	// TargetTraits is a property of a traits schema.
+	TargetTraits AnnotationTarget = "traits"
+	// TargetInstance is a CTI instance. No annotation may be used on instances.
+	TargetInstance AnnotationTarget = "instance"
+)
+
+// AnnotationContract declares where an annotation may be used.
+type AnnotationContract struct {
+	Targets []AnnotationTarget
+	// MaxDepth is a maximum nesting depth of the annotated attribute. Zero means any depth.
+	MaxDepth int
+}
+
+func (c AnnotationContract) String() string {
+	targets := make([]string, len(c.Targets))
+	for i, t := range c.Targets {
+		targets[i] = string(t)
+	}
+	s := "allowed on " + strings.Join(targets, ", ")
+	if c.MaxDepth > 0 {
+		s += fmt.Sprintf(" at depth up to %d", c.MaxDepth)
+	}
+	return s
+}
+
+// allows reports whether the annotation may be used on the target at the specified depth.
+func (c AnnotationContract) allows(target AnnotationTarget, depth int) bool {
+	for _, t := range c.Targets {
+		if t != target {
+			continue
+		}
+		return target == TargetType || c.MaxDepth == 0 || depth <= c.MaxDepth
+	}
+	return false
+}
+
+// annotationContracts follow allowedTargets and descriptions of annotation types in the RAMLx specification.
+// Annotations that define instance fields, i.e., cti.id, cti.display_name and cti.description,
+// are read from top-level properties only.
+var annotationContracts = map[string]AnnotationContract{
+	metadata.Cti:           {Targets: []AnnotationTarget{TargetType}},
+	metadata.Final:         {Targets: []AnnotationTarget{TargetType}},
+	metadata.ID:            {Targets: []AnnotationTarget{TargetAttribute}, MaxDepth: 1},
+	metadata.DisplayName:   {Targets: []AnnotationTarget{TargetAttribute}, MaxDepth: 1},
+	metadata.Description:   {Targets: []AnnotationTarget{TargetAttribute}, MaxDepth: 1},
+	metadata.Reference:     {Targets: []AnnotationTarget{TargetAttribute, TargetTraits}},
+	metadata.Overridable:   {Targets: []AnnotationTarget{TargetAttribute}},
+	metadata.Asset:         {Targets: []AnnotationTarget{TargetAttribute, TargetTraits}},
+	metadata.L10n:          {Targets: []AnnotationTarget{TargetAttribute, TargetTraits}},
+	metadata.Schema:        {Targets: []AnnotationTarget{TargetAttribute, TargetTraits}},
+	metadata.Meta:          {Targets: []AnnotationTarget{TargetType, TargetAttribute}},
+	metadata.PropertyNames: {Targets: []AnnotationTarget{TargetType, TargetAttribute, TargetTraits}},
+}
+
+// LookupAnnotationContract returns the contract of the known annotation.
+func LookupAnnotationContract(name string) (AnnotationContract, bool) {
+	c, ok := annotationContracts[name]
+	return c, ok
+}
+
+// annotationDepth returns the nesting depth of the annotated node, e.g., 0 for ".", 1 for ".id" and 2 for ".tags.#".
+func annotationDepth(path metadata.GJsonPath) int {
+	p := strings.TrimPrefix(string(path), ".")
+	if p == "" {
+		return 0
+	}
+	return strings.Count(p, ".") + 1
+}
+
+// relativeDepth returns the depth of the annotated node relative to the nearest enclosing CTI type.
+// Attributes typed with another CTI type carry annotations of that type, so they are checked as its root.
+func relativeDepth(annotations map[metadata.GJsonPath]metadata.Annotations, path metadata.GJsonPath) int {
+	depth := annotationDepth(path)
+	for p := string(path); p != "." && p != ""; {
+		if annotations[metadata.GJsonPath(p)].Cti != nil {
+			return depth - annotationDepth(metadata.GJsonPath(p))
+		}
+		i := strings.LastIndex(p, ".")
+		if i <= 0 {
+			break
+		}
+		p = p[:i]
+	}
+	return depth
+}
+
+func (v *MetadataValidator) validateAnnotationPlacement(d *diagnostics, current *metadata.Entity) {
+	check := func(annotations map[metadata.GJsonPath]metadata.Annotations, target func(depth int) AnnotationTarget) {
+		paths := make([]string, 0, len(annotations))
+		for path := range annotations {
+			paths = append(paths, string(path))
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			a := annotations[metadata.GJsonPath(path)]
+			depth := relativeDepth(annotations, metadata.GJsonPath(path))
+			t := target(depth)
+			for _, name := range sortedAnnotationNames() {
+				if !a.Has(name) {
+					continue
+				}
+				if c := annotationContracts[name]; !c.allows(t, depth) {
+					d.addPath(CodeAnnotationPlacement, path, "%s@%s: annotation %s cannot be used on %s at depth %d, %s",
+						current.Cti, path, name, t, depth, c)
+				}
+			}
+		}
+	}
+
+	if current.IsInstance() {
+		check(current.Annotations, func(int) AnnotationTarget { return TargetInstance })
+		return
+	}
+	check(current.Annotations, func(depth int) AnnotationTarget {
+		if depth == 0 {
+			return TargetType
+		}
+		return TargetAttribute
+	})
+	check(current.TraitsAnnotations, func(int) AnnotationTarget { return TargetTraits })
+}
+
+func sortedAnnotationNames() []string {
+	names := make([]string, 0, len(annotationContracts))
+	for name := range annotationContracts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,101 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+func Test_AnnotationPlacement(t *testing.T) {
+	yes := true
+	id := "cti.x.y.wrapper.v1.0"
+
+	testCases := []struct {
+		name        string
+		annotations map[metadata.GJsonPath]metadata.Annotations
+		traits      map[metadata.GJsonPath]metadata.Annotations
+		values      []byte
+		paths       []string
+	}{
+		{
+			name: "valid",
+			annotations: map[metadata.GJsonPath]metadata.Annotations{
+				".":            {Cti: id, Final: &yes},
+				".id":          {ID: &yes},
+				".name":        {DisplayName: &yes},
+				".data.topic":  {Reference: "cti.x.y.topic.v1.0"},
+				".data.tags.#": {L10N: &yes},
+			},
+			traits: map[metadata.GJsonPath]metadata.Annotations{".topic": {Reference: true}},
+		},
+		{
+			name: "attribute of embedded cti type",
+			annotations: map[metadata.GJsonPath]metadata.Annotations{
+				".":            {Cti: id},
+				".topic":       {Cti: "cti.x.y.topic.v1.0", Final: &yes},
+				".topic.id":    {ID: &yes},
+				".topics.#":    {Cti: "cti.x.y.topic.v1.0"},
+				".topics.#.id": {ID: &yes},
+			},
+		},
+		{
+			name: "type annotation on attribute",
+			annotations: map[metadata.GJsonPath]metadata.Annotations{
+				".":     {Cti: id},
+				".flag": {Final: &yes},
+			},
+			paths: []string{".flag"},
+		},
+		{
+			name: "instance field annotation too deep",
+			annotations: map[metadata.GJsonPath]metadata.Annotations{
+				".":         {Cti: id},
+				".data.key": {ID: &yes, Description: &yes},
+			},
+			paths: []string{".data.key", ".data.key"},
+		},
+		{
+			name: "attribute annotation on type",
+			annotations: map[metadata.GJsonPath]metadata.Annotations{
+				".": {Cti: id, Reference: true},
+			},
+			paths: []string{"."},
+		},
+		{
+			name:   "traits",
+			traits: map[metadata.GJsonPath]metadata.Annotations{".": {Cti: id}, ".overridable": {Overridable: &yes}},
+			annotations: map[metadata.GJsonPath]metadata.Annotations{
+				".": {Cti: id},
+			},
+			paths: []string{".", ".overridable"},
+		},
+		{
+			name:        "instance",
+			annotations: map[metadata.GJsonPath]metadata.Annotations{".name": {DisplayName: &yes}},
+			values:      []byte(`{}`),
+			paths:       []string{".name"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			entity := &metadata.Entity{Cti: id, Annotations: tc.annotations, TraitsAnnotations: tc.traits, Values: tc.values}
+			if tc.values == nil {
+				entity.Schema = []byte(`{}`)
+			}
+
+			v := MakeMetadataValidator()
+			d := &diagnostics{v: v, id: entity.Cti}
+			v.validateAnnotationPlacement(d, entity)
+
+			var paths []string
+			for _, err := range d.errs {
+				require.Equal(t, CodeAnnotationPlacement, err.Code)
+				paths = append(paths, err.Path)
+			}
+			require.Equal(t, tc.paths, paths)
+		})
+	}
+}
@@ -0,0 +1,98 @@
+package validator
+
+import (
+	"sort"
+)
+
+// ReportVersion is a version of the report document. It is incremented on incompatible changes only.
+const ReportVersion = 1
+
+// Report is a machine-readable result of the validation.
+type Report struct {
+	Version int           `json:"version"`
+	Valid   bool          `json:"valid"`
+	Summary ReportSummary `json:"summary"`
+	// Files lists diagnostics grouped by the file where the entity is defined, sorted by path.
+	Files []ReportFile `json:"files"`
+}
+
+type ReportSummary struct {
+	Entities int          `json:"entities"`
+	Errors   int          `json:"errors"`
+	Warnings int          `json:"warnings"`
+	Codes    map[Code]int `json:"codes"`
+}
+
+type ReportFile struct {
+	// Path is a path to the file. Empty for entities without a known definition site.
+	Path        string             `json:"path"`
+	Diagnostics []ReportDiagnostic `json:"diagnostics"`
+}
+
+type ReportDiagnostic struct {
+	Code     Code     `json:"code"`
+	Severity Severity `json:"severity"`
+	Category Category `json:"category"`
+	ID       string   `json:"id"`
+	Path     string   `json:"path,omitempty"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Message  string   `json:"message"`
+}
+
+// NewReport makes a report of the diagnostics found in the specified number of entities.
+func NewReport(entities int, diagnostics ...*Error) *Report {
+	report := &Report{
+		Version: ReportVersion,
+		Summary: ReportSummary{Entities: entities, Codes: make(map[Code]int)},
+		Files:   []ReportFile{},
+	}
+	files := make(map[string][]ReportDiagnostic)
+	for _, d := range diagnostics {
+		if d.Severity == SeverityWarning {
+			report.Summary.Warnings++
+		} else {
+			report.Summary.Errors++
+		}
+		report.Summary.Codes[d.Code]++
+		files[d.Position.File] = append(files[d.Position.File], ReportDiagnostic{
+			Code:     d.Code,
+			Severity: d.Severity,
+			Category: d.Category(),
+			ID:       d.ID,
+			Path:     d.Path,
+			Line:     d.Position.Line,
+			Column:   d.Position.Column,
+			Message:  d.Msg,
+		})
+	}
+	report.Valid = report.Summary.Errors == 0
+
+	for path, items := range files {
+		sort.SliceStable(items, func(a, b int) bool {
+			if items[a].Line != items[b].Line {
+				return items[a].Line < items[b].Line
+			}
+			if items[a].Column != items[b].Column {
+				return items[a].Column < items[b].Column
+			}
+			if items[a].ID != items[b].ID {
+				return items[a].ID < items[b].ID
+			}
+			return items[a].Code < items[b].Code
+		})
+		report.Files = append(report.Files, ReportFile{Path: path, Diagnostics: items})
+	}
+	sort.Slice(report.Files, func(a, b int) bool {
+		return report.Files[a].Path < report.Files[b].Path
+	})
+	return report
+}
+
+// Report returns the report of diagnostics found by the last ValidateAll call.
+func (v *MetadataValidator) Report() *Report {
+	diagnostics := make([]*Error, 0, len(v.errors)+len(v.warnings))
+	diagnostics = append(diagnostics, v.errors...)
+	diagnostics = append(diagnostics, v.warnings...)
+	return NewReport(len(v.registry.Entities()), diagnostics...)
+}
@@ -0,0 +1,45 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func Test_NewReport(t *testing.T) {
+	report := NewReport(3,
+		&Error{Code: CodeInvalidValues, Severity: SeverityError, ID: "cti.x.y.b.v1.0~x.y.c.v1.0", Path: "count",
+			Position: Position{File: "b.raml", Line: 7, Column: 3}, Msg: "invalid"},
+		&Error{Code: CodeUnknownKeyword, Severity: SeverityWarning, ID: "cti.x.y.b.v1.0",
+			Position: Position{File: "b.raml", Line: 2, Column: 1}, Msg: "unknown keyword"},
+		&Error{Code: CodeParentNotFound, Severity: SeverityError, ID: "cti.x.y.a.v1.0~x.y.b.v1.0",
+			Position: Position{File: "a.raml", Line: 1, Column: 1}, Msg: "parent not found"},
+	)
+
+	require.Equal(t, &Report{
+		Version: ReportVersion,
+		Valid:   false,
+		Summary: ReportSummary{
+			Entities: 3,
+			Errors:   2,
+			Warnings: 1,
+			Codes:    map[Code]int{CodeInvalidValues: 1, CodeUnknownKeyword: 1, CodeParentNotFound: 1},
+		},
+		Files: []ReportFile{
+			{Path: "a.raml", Diagnostics: []ReportDiagnostic{
+				{Code: CodeParentNotFound, Severity: SeverityError, Category: CategoryReference, ID: "cti.x.y.a.v1.0~x.y.b.v1.0",
+					Line: 1, Column: 1, Message: "parent not found"},
+			}},
+			{Path: "b.raml", Diagnostics: []ReportDiagnostic{
+				{Code: CodeUnknownKeyword, Severity: SeverityWarning, Category: CategoryDefinition, ID: "cti.x.y.b.v1.0",
+					Line: 2, Column: 1, Message: "unknown keyword"},
+				{Code: CodeInvalidValues, Severity: SeverityError, Category: CategoryConstraint, ID: "cti.x.y.b.v1.0~x.y.c.v1.0",
+					Path: "count", Line: 7, Column: 3, Message: "invalid"},
+			}},
+		},
+	}, report)
+
+	report = NewReport(1)
+	require.True(t, report.Valid)
+	require.Empty(t, report.Files)
+}
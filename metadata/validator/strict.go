@@ -0,0 +1,125 @@
+package validator
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/collector"
+)
+
+// Mode defines how strictly the validator treats the metadata.
+type Mode string
+
+const (
+	// ModePermissive reports warnings without failing validation.
+	ModePermissive Mode = "permissive"
+	// ModeStrict reports all diagnostics, including warnings, as errors.
+	ModeStrict Mode = "strict"
+)
+
+const (
+	extensionPrefix       = "x-"
+	domainExtensionPrefix = "x-domainExt-"
+)
+
+// draft07Keywords is the draft-07 JSON Schema vocabulary.
+var draft07Keywords = map[string]struct{}{
+	"$schema": {}, "$id": {}, "$ref": {}, "$comment": {}, "definitions": {},
+	"title": {}, "description": {}, "default": {}, "readOnly": {}, "writeOnly": {}, "examples": {},
+	"multipleOf": {}, "maximum": {}, "exclusiveMaximum": {}, "minimum": {}, "exclusiveMinimum": {},
+	"maxLength": {}, "minLength": {}, "pattern": {},
+	"additionalItems": {}, "items": {}, "maxItems": {}, "minItems": {}, "uniqueItems": {}, "contains": {},
+	"maxProperties": {}, "minProperties": {}, "required": {}, "properties": {}, "patternProperties": {},
+	"additionalProperties": {}, "dependencies": {}, "propertyNames": {},
+	"const": {}, "enum": {}, "type": {}, "format": {}, "contentMediaType": {}, "contentEncoding": {},
+	"if": {}, "then": {}, "else": {}, "allOf": {}, "anyOf": {}, "oneOf": {}, "not": {},
+}
+
+// schemaVisitor collects unknown keywords and annotations from a JSON schema.
+type schemaVisitor struct {
+	keywords    map[string]struct{}
+	annotations map[string]struct{}
+}
+
+func (sv *schemaVisitor) visit(schema any) {
+	s, ok := schema.(map[string]any)
+	if !ok {
+		return
+	}
+	for k, v := range s {
+		switch {
+		case k == metadata.CustomKey:
+			sv.visitCustom(v)
+			continue
+		case strings.HasPrefix(k, extensionPrefix):
+			continue
+		}
+		if _, ok := draft07Keywords[k]; !ok {
+			sv.keywords[k] = struct{}{}
+			continue
+		}
+		switch k {
+		case "properties", "patternProperties", "definitions", "dependencies":
+			if m, ok := v.(map[string]any); ok {
+				for _, item := range m {
+					sv.visit(item)
+				}
+			}
+		case "items", "allOf", "anyOf", "oneOf":
+			if a, ok := v.([]any); ok {
+				for _, item := range a {
+					sv.visit(item)
+				}
+				continue
+			}
+			sv.visit(v)
+		case "additionalProperties", "additionalItems", "contains", "propertyNames", "not", "if", "then", "else":
+			sv.visit(v)
+		}
+	}
+}
+
+func (sv *schemaVisitor) visitCustom(v any) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return
+	}
+	for k := range m {
+		name := strings.TrimPrefix(k, domainExtensionPrefix)
+		if strings.HasPrefix(name, collector.MetadataPrefix) && !metadata.IsKnownAnnotation(name) {
+			sv.annotations[name] = struct{}{}
+		}
+	}
+}
+
+func (v *MetadataValidator) validateSchemaVocabulary(d *diagnostics, current *metadata.Entity) {
+	sv := &schemaVisitor{keywords: map[string]struct{}{}, annotations: map[string]struct{}{}}
+	for _, raw := range []json.RawMessage{current.Schema, current.TraitsSchema} {
+		if raw == nil {
+			continue
+		}
+		var schema any
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			// Reported by validateSchemas.
+			continue
+		}
+		sv.visit(schema)
+	}
+	for _, name := range sortedKeys(sv.annotations) {
+		d.add(CodeUnknownAnnotation, "%s uses unknown annotation %s", current.Cti, name)
+	}
+	for _, name := range sortedKeys(sv.keywords) {
+		d.add(CodeUnknownKeyword, "%s schema contains unknown keyword %s", current.Cti, name)
+	}
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	res := make([]string, 0, len(m))
+	for k := range m {
+		res = append(res, k)
+	}
+	sort.Strings(res)
+	return res
+}
@@ -0,0 +1,76 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+func Test_ValidationModes(t *testing.T) {
+	entity := &metadata.Entity{
+		Cti: "cti.x.y.alert.v1.0",
+		Schema: []byte(`{
+			"$schema": "http://json-schema.org/draft-07/schema",
+			"$ref": "#/definitions/Alert",
+			"definitions": {
+				"Alert": {
+					"type": "object",
+					"properties": {
+						"title": {"type": "string", "x-custom": {"x-domainExt-cti.embed": "Dict"}},
+						"threshold": {"type": "integer", "fileTypes": ["*.txt"], "x-vendor": true}
+					},
+					"x-custom": {"x-domainExt-cti.cti": "cti.x.y.alert.v1.0"}
+				}
+			}
+		}`),
+	}
+
+	testCases := []struct {
+		name     string
+		opts     []Option
+		errors   []Code
+		warnings []Code
+	}{
+		{
+			name:     "permissive",
+			opts:     []Option{WithMode(ModePermissive)},
+			warnings: []Code{CodeUnknownAnnotation, CodeUnknownKeyword},
+		},
+		{
+			name:   "strict",
+			opts:   []Option{WithMode(ModeStrict)},
+			errors: []Code{CodeUnknownAnnotation, CodeUnknownKeyword},
+		},
+		{
+			name:     "severity override",
+			opts:     []Option{WithSeverity(CodeUnknownAnnotation, SeverityError)},
+			errors:   []Code{CodeUnknownAnnotation},
+			warnings: []Code{CodeUnknownKeyword},
+		},
+		{
+			name:   "severity override in strict mode",
+			opts:   []Option{WithMode(ModeStrict), WithSeverity(CodeUnknownKeyword, SeverityWarning)},
+			errors: []Code{CodeUnknownAnnotation, CodeUnknownKeyword},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			v := MakeMetadataValidator(tc.opts...)
+			require.NoError(t, v.AddEntities(metadata.Entities{entity}))
+
+			var errs, warnings []Code
+			for _, err := range v.validate(entity) {
+				if err.Severity == SeverityWarning {
+					warnings = append(warnings, err.Code)
+				} else {
+					errs = append(errs, err.Code)
+				}
+			}
+			require.Equal(t, tc.errors, errs)
+			require.Equal(t, tc.warnings, warnings)
+		})
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
+	"reflect"
 
 	"github.com/xeipuuv/gojsonschema"
 
@@ -12,6 +12,8 @@ import (
 	"github.com/acronis/go-cti/metadata"
 	"github.com/acronis/go-cti/metadata/collector"
 	"github.com/acronis/go-cti/metadata/merger"
+	"github.com/acronis/go-cti/metadata/registry"
+	"github.com/acronis/go-cti/metadata/schemaref"
 	"github.com/acronis/go-stacktrace"
 )
 
@@ -20,41 +22,145 @@ const (
 )
 
 type MetadataValidator struct {
-	index     metadata.EntitiesMap
-	ctiParser *cti.Parser
+	registry   *registry.Registry
+	ctiParser  *cti.Parser
+	suppressed map[Code]struct{}
+	severities map[Code]Severity
+	idRules    []IdentifierRule
+	mode       Mode
+	errors     []*Error
+	warnings   []*Error
+	resolver   *schemaref.Resolver
+	formats    map[string]FormatChecker
+	limits     Limits
+	// loadErr is the error of LoadFromRegistry, reported by ValidateAll.
+	loadErr error
 }
 
-func MakeMetadataValidator() *MetadataValidator {
-	return &MetadataValidator{
-		ctiParser: cti.NewParser(),
-		index:     make(metadata.EntitiesMap),
+type Option func(*MetadataValidator)
+
+// WithSuppressed disables reporting of diagnostics with the specified codes.
+func WithSuppressed(codes ...Code) Option {
+	return func(v *MetadataValidator) {
+		for _, code := range codes {
+			v.suppressed[code] = struct{}{}
+		}
+	}
+}
+
+// WithSeverity overrides the default severity of diagnostics with the specified code.
+// Strict mode reports all diagnostics as errors regardless of the override.
+func WithSeverity(code Code, severity Severity) Option {
+	return func(v *MetadataValidator) {
+		v.severities[code] = severity
+	}
+}
+
+// WithMode sets the validation mode. Default mode is ModePermissive.
+func WithMode(mode Mode) Option {
+	return func(v *MetadataValidator) {
+		v.mode = mode
+	}
+}
+
+// WithRefResolver enables resolution of non-local $ref targets in entity schemas.
+// Without a resolver, only local references are supported.
+func WithRefResolver(r *schemaref.Resolver) Option {
+	return func(v *MetadataValidator) {
+		v.resolver = r
 	}
 }
 
+// WithIdentifierRules enables validation of identifier segments according to the specified rules.
+func WithIdentifierRules(rules ...IdentifierRule) Option {
+	return func(v *MetadataValidator) {
+		v.idRules = append(v.idRules, rules...)
+	}
+}
+
+func MakeMetadataValidator(opts ...Option) *MetadataValidator {
+	v := &MetadataValidator{
+		ctiParser:  cti.NewParser(cti.WithAllowAnonymousEntity(true)),
+		registry:   registry.NewEmpty(),
+		suppressed: make(map[Code]struct{}),
+		severities: make(map[Code]Severity),
+		mode:       ModePermissive,
+		formats:    DefaultFormatCheckers(),
+		limits:     DefaultLimits(),
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// LoadFromRegistry loads entities of the collector registry. Errors of indexing are returned by ValidateAll,
+// use LoadEntities to handle them on load.
 func (v *MetadataValidator) LoadFromRegistry(entities *collector.MetadataRegistry) {
-	v.index = entities.Index
+	v.loadErr = v.LoadEntities(entities.Index)
+}
+
+// LoadEntities replaces entities of the validator with the specified entities. It clears the error of a previous
+// LoadFromRegistry, the error of indexing is returned instead.
+func (v *MetadataValidator) LoadEntities(entities metadata.EntitiesMap) error {
+	v.loadErr = nil
+	r, err := registry.New(entities)
+	if err != nil {
+		return fmt.Errorf("index entities: %w", err)
+	}
+	v.registry = r
+	return nil
+}
+
+func (v *MetadataValidator) LoadRegistry(r *registry.Registry) {
+	v.registry = r
+	v.loadErr = nil
 }
 
 func (v *MetadataValidator) AddEntities(entities metadata.Entities) error {
 	for _, entity := range entities {
-		if _, ok := v.index[entity.Cti]; ok {
-			return fmt.Errorf("attempting to add duplicate cti %s", entity.Cti)
+		if _, ok := v.registry.Get(entity.Cti); ok {
+			return &Error{
+				Code:     CodeDuplicateEntity,
+				Severity: SeverityError,
+				ID:       entity.Cti,
+				Position: positionOf(entity),
+				Msg:      fmt.Sprintf("attempting to add duplicate cti %s", entity.Cti),
+			}
+		}
+		if err := v.registry.Add(entity); err != nil {
+			return err
 		}
-		v.index[entity.Cti] = entity
 	}
 	return nil
 }
 
 func (v *MetadataValidator) Reset() {
-	v.ctiParser = cti.NewParser()
-	v.index = make(metadata.EntitiesMap)
+	v.ctiParser = cti.NewParser(cti.WithAllowAnonymousEntity(true))
+	v.registry = registry.NewEmpty()
+	v.loadErr = nil
+	v.errors = nil
+	v.warnings = nil
 }
 
+// ValidateAll validates all entities of the registry and returns all found errors.
+// Warnings do not fail validation and are available with Warnings.
 func (v *MetadataValidator) ValidateAll() error {
 	st := stacktrace.StackTrace{}
-	for _, entity := range v.index {
-		if err := v.Validate(entity); err != nil {
-			_ = st.Append(stacktrace.NewWrapped("validation failed", err, stacktrace.WithInfo("cti", entity.Cti), stacktrace.WithType("validation")))
+	v.errors = nil
+	v.warnings = nil
+	if v.loadErr != nil {
+		return v.loadErr
+	}
+	for _, entity := range v.registry.Entities() {
+		for _, err := range v.validate(entity) {
+			if err.Severity == SeverityWarning {
+				v.warnings = append(v.warnings, err)
+				continue
+			}
+			v.errors = append(v.errors, err)
+			_ = st.Append(stacktrace.NewWrapped("validation failed", err,
+				stacktrace.WithInfo("cti", entity.Cti), stacktrace.WithInfo("code", string(err.Code)), stacktrace.WithType("validation")))
 		}
 	}
 	if len(st.List) > 0 {
@@ -64,151 +170,366 @@ func (v *MetadataValidator) ValidateAll() error {
 	return nil
 }
 
+// Errors returns errors found by the last ValidateAll call.
+// Use errors.As on the items to handle categories of errors.
+func (v *MetadataValidator) Errors() []*Error {
+	return v.errors
+}
+
+// Warnings returns warnings found by the last ValidateAll call.
+func (v *MetadataValidator) Warnings() []*Error {
+	return v.warnings
+}
+
+// Validate validates the entity against its ancestors and returns all found errors joined.
+// Warnings are ignored.
 func (v *MetadataValidator) Validate(current *metadata.Entity) error {
+	var res []error
+	for _, err := range v.validate(current) {
+		if err.Severity == SeverityError {
+			res = append(res, err)
+		}
+	}
+	return errors.Join(res...)
+}
+
+// Diagnostics validates the entity against its ancestors and returns all found errors and warnings.
+// The entity does not have to be in the registry, e.g. instances received at runtime.
+func (v *MetadataValidator) Diagnostics(current *metadata.Entity) []*Error {
+	return v.validate(current)
+}
+
+// diagnostics collects validation errors of a single entity, skipping suppressed codes.
+type diagnostics struct {
+	v    *MetadataValidator
+	id   string
+	pos  Position
+	errs []*Error
+}
+
+func (d *diagnostics) add(code Code, format string, args ...any) {
+	d.addPath(code, "", format, args...)
+}
+
+// addPath reports the diagnostic of the property or the annotated schema node at the path.
+func (d *diagnostics) addPath(code Code, path string, format string, args ...any) {
+	if _, ok := d.v.suppressed[code]; ok {
+		return
+	}
+	severity := SeverityError
+	if d.v.mode != ModeStrict {
+		if s, ok := d.v.severities[code]; ok {
+			severity = s
+		} else if rule, ok := Explain(code); ok {
+			severity = rule.Severity
+		}
+	}
+	d.errs = append(d.errs, &Error{
+		Code:     code,
+		Severity: severity,
+		ID:       d.id,
+		Path:     path,
+		Position: d.pos,
+		Msg:      fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *MetadataValidator) validate(current *metadata.Entity) []*Error {
+	d := &diagnostics{v: v, id: current.Cti, pos: positionOf(current)}
+
 	// TODO: Pre-parse all CTIs into expressions
 	currentCtiExpr, err := v.ctiParser.Parse(current.Cti)
 	if err != nil {
-		return fmt.Errorf("%s %s", current.Cti, err.Error())
+		d.add(CodeInvalidIdentifier, "%s %s", current.Cti, err.Error())
+		return d.errs
 	}
 
-	parentCti := metadata.GetParentCti(current.Cti)
-	if parentCti == current.Cti {
-		if current.Schema != nil {
-			schema := []byte(current.Schema)
-			if err := validateBytesJsonSchema(schema); err != nil {
-				return fmt.Errorf("%s contains invalid schema: %s", current.Cti, err)
-			}
+	if parentCti := metadata.GetParentCti(current.Cti); parentCti != current.Cti {
+		v.validateDerived(d, current, parentCti, currentCtiExpr)
+	}
+	v.validateIdentifier(d, current)
+	v.validateSchemas(d, current)
+	v.validateSchemaVocabulary(d, current)
+	v.validateAnnotationPlacement(d, current)
+	v.validateReferenceAnnotations(d, current)
+	return d.errs
+}
+
+func (v *MetadataValidator) validateIdentifier(d *diagnostics, current *metadata.Entity) {
+	for _, rule := range v.idRules {
+		if !rule.Applies(current) {
+			continue
 		}
-		if current.TraitsSchema != nil {
-			schema := []byte(current.TraitsSchema)
-			if err := validateBytesJsonSchema(schema); err != nil {
-				return fmt.Errorf("%s contains invalid schema: %s", current.Cti, err)
-			}
+		if err := rule.Validate(current.Cti); err != nil {
+			d.add(CodeIdentifierFormat, "%s: %s", current.Cti, err.Error())
 		}
-		return nil
 	}
+}
 
-	parent, ok := v.index[parentCti]
+func (v *MetadataValidator) validateDerived(d *diagnostics, current *metadata.Entity, parentCti string, currentCtiExpr cti.Expression) {
+	parent, ok := v.registry.Get(parentCti)
 	if !ok {
-		return fmt.Errorf("%s failed to find parent type", current.Cti)
+		d.add(CodeParentNotFound, "%s failed to find parent type", current.Cti)
+		return
 	}
 	if parent.Final {
-		return fmt.Errorf("%s is derived from final type", current.Cti)
+		d.add(CodeFinalParent, "%s is derived from final type", current.Cti)
 	}
 	// TODO: Need to memoize validated schemas and values for better performance
 	if current.Values != nil {
-		if parent.Schema == nil {
-			return fmt.Errorf("%s instance is derived from non-type CTI", current.Cti)
+		v.validateInstance(d, current, parent, currentCtiExpr)
+	}
+	if current.Traits != nil {
+		v.validateTraits(d, current, parentCti)
+	}
+}
+
+func (v *MetadataValidator) validateInstance(d *diagnostics, current, parent *metadata.Entity, currentCtiExpr cti.Expression) {
+	if parent.Schema == nil {
+		d.add(CodeInstanceOfNonType, "%s instance is derived from non-type CTI", current.Cti)
+		return
+	}
+	mergedSchema, err := v.GetMergedSchema(parent.Cti)
+	if err != nil {
+		d.addLoadError(CodeSchemaMergeFailed, current.Cti, err)
+		return
+	}
+	values := []byte(current.Values)
+	if err := v.checkInstanceLimits(values); err != nil {
+		d.add(CodeLimitExceeded, "%s: values: %s", current.Cti, err.Error())
+		return
+	}
+	v.validateValues(d, current, mergedSchema, values)
+	v.validateDiscriminator(d, current, parent, values)
+	if parent.Annotations == nil {
+		d.add(CodeMissingAnnotations, "%s does not have any annotations", current.Cti)
+		return
+	}
+	// Anonymous entity UUID never matches the parent expression, but the parent is derived from the identifier itself.
+	if parentExpr, err := v.ctiParser.Parse(parent.Cti); err == nil && !currentCtiExpr.AnonymousEntityUUID.Valid {
+		if ok, err := parentExpr.Match(currentCtiExpr); !ok {
+			if err != nil {
+				d.add(CodeInvalidInheritance, "%s: invalid inheritance. Reason: %s", current.Cti, err.Error())
+			} else {
+				d.add(CodeInvalidInheritance, "%s: invalid inheritance", current.Cti)
+			}
+		}
+	}
+	// TODO: Ensure correct cti.id field is used
+	for key, annotation := range parent.Annotations {
+		ref := annotation.ReadReference()
+		if ref == "" || ref == TrueStr {
+			continue
 		}
-		mergedSchema, err := v.GetMergedSchema(parent.Cti)
+		refExpr, err := v.ctiParser.Parse(ref)
 		if err != nil {
-			return err
+			d.addPath(CodeInvalidReference, string(key), "%s@%s: failed to parse cti.reference. Reason: %s", current.Cti, key, err.Error())
+			continue
 		}
-		values := []byte(current.Values)
-		if err := validateGoJsonValues(mergedSchema, values); err != nil {
-			return fmt.Errorf("%s contains invalid values: %s", current.Cti, err)
-		}
-		if parent.Annotations != nil {
-			// TODO: Ensure correct cti.id field is used
-			for key, annotation := range parent.Annotations {
-				// if ctis := annotation.ReadCti(); len(ctis) > 0 {
-				// 	for _, id := range ctis {
-				// 		fmt.Printf("key: [%s][cti.cti]: %s", key, id)
-				// 	}
-				// }
-				if parent, err := v.ctiParser.Parse(parent.Cti); err == nil {
-					if ok, err := parent.Match(currentCtiExpr); !ok {
-						if err != nil {
-							return fmt.Errorf("%s: invalid inheritance. Reason: %s", current.Cti, err.Error())
-						}
-
-						return fmt.Errorf("%s: invalid inheritance", current.Cti)
-					}
-				}
-				if ref := annotation.ReadReference(); ref != "" && ref != TrueStr {
-					value := key.GetValue(values)
-					if ref, err := v.ctiParser.Parse(ref); err == nil {
-						for _, val := range value.Array() {
-							err := v.matchCti(&ref, val.Str)
-							if err != nil {
-								return fmt.Errorf("%s@%s: %s in %s", current.Cti, key, err.Error(), val.Str)
-							}
-						}
-					} else {
-						return fmt.Errorf("%s@%s: failed to parse cti.reference. Reason: %s", current.Cti, key, err.Error())
-					}
-				}
-				// if l10n := annotation.L10N; l10n != nil {
-				// 	fmt.Printf("key: [%s][cti.l10n]: %t\n", key, *l10n)
-				// }
+		for _, val := range key.GetValue(values).Array() {
+			if err := v.matchCti(&refExpr, val.Str); err != nil {
+				d.addPath(CodeReferenceMismatch, string(key), "%s@%s: %s in %s", current.Cti, key, err.Error(), val.Str)
 			}
-		} else {
-			return fmt.Errorf("%s does not have any annotations", current.Cti)
 		}
 	}
-	if current.Traits != nil {
-		id := metadata.GetBaseCti(parentCti)
-		base, ok := v.index[id]
-		if !ok {
-			return fmt.Errorf("%s failed to find base type", current.Cti)
+}
+
+// validateValues validates instance values against the merged schema.
+// Values that are not allowed by enum or const are reported separately with the allowed value set.
+// String formats are checked with the validator format checkers instead of the JSON Schema library.
+func (v *MetadataValidator) validateValues(d *diagnostics, current *metadata.Entity, schema map[string]any, values []byte) {
+	res, err := gojsonschema.Validate(gojsonschema.NewGoLoader(withoutFormats(schema)), gojsonschema.NewBytesLoader(values))
+	if err != nil {
+		d.add(CodeInvalidValues, "%s contains invalid values: %s", current.Cti, err)
+		return
+	}
+	for _, resErr := range res.Errors() {
+		switch resErr.Type() {
+		case "enum", "const":
+			d.addPath(CodeValueNotAllowed, resErr.Field(), "%s@%s: value %v is not allowed, allowed values: [%v]",
+				current.Cti, resErr.Field(), formatValue(resErr.Value()), resErr.Details()["allowed"])
+		default:
+			d.addResultError(CodeInvalidValues, current.Cti, resErr)
+		}
+	}
+
+	var doc any
+	if err := json.Unmarshal(values, &doc); err != nil {
+		return
+	}
+	for _, fe := range v.checkFormats(schema, doc, "") {
+		d.addPath(CodeInvalidFormat, fe.path, "%s@%s: value %s does not match format %s", current.Cti, fe.path, formatValue(fe.value), fe.format)
+	}
+}
+
+// validateDiscriminator checks that the discriminator property of the instance holds the discriminator value of its type.
+func (v *MetadataValidator) validateDiscriminator(d *diagnostics, current, parent *metadata.Entity, values []byte) {
+	schema, err := v.loadSchema(parent.Schema)
+	if err != nil {
+		return
+	}
+	schema, err = merger.ExtractSchemaDefinition(schema)
+	if err != nil {
+		return
+	}
+	custom, _ := schema[metadata.CustomKey].(map[string]any)
+	property, ok := custom[metadata.DiscriminatorKey].(string)
+	if !ok {
+		return
+	}
+	expected := custom[metadata.DiscriminatorValueKey]
+	var obj map[string]any
+	if err := json.Unmarshal(values, &obj); err != nil {
+		return
+	}
+	if actual, ok := obj[property]; !ok || !reflect.DeepEqual(actual, expected) {
+		d.addPath(CodeValueNotAllowed, property, "%s@%s: discriminator value %s does not match type %s, allowed values: [%s]",
+			current.Cti, property, formatValue(actual), parent.Cti, formatValue(expected))
+	}
+}
+
+func formatValue(val any) string {
+	b, err := json.Marshal(val)
+	if err != nil {
+		return fmt.Sprintf("%v", val)
+	}
+	return string(b)
+}
+
+func (v *MetadataValidator) validateTraits(d *diagnostics, current *metadata.Entity, parentCti string) {
+	id := metadata.GetBaseCti(parentCti)
+	base, ok := v.registry.Get(id)
+	if !ok {
+		d.add(CodeBaseTypeNotFound, "%s failed to find base type", current.Cti)
+		return
+	}
+	// FIXME: Need to obtain traits from the parent
+	if base.TraitsSchema == nil {
+		d.add(CodeTraitsNotDefined, "%s type is derived from type that does not define traits", current.Cti)
+		return
+	}
+	schema, err := v.loadSchema(base.TraitsSchema)
+	if err != nil {
+		d.addLoadError(CodeUnresolvedRef, current.Cti, err)
+		return
+	}
+	if err := v.checkInstanceLimits(current.Traits); err != nil {
+		d.add(CodeLimitExceeded, "%s: traits: %s", current.Cti, err.Error())
+		return
+	}
+	res, err := gojsonschema.Validate(gojsonschema.NewGoLoader(schema), gojsonschema.NewBytesLoader([]byte(current.Traits)))
+	if err != nil {
+		d.add(CodeInvalidTraits, "%s contains invalid values: %s", current.Cti, err)
+		return
+	}
+	for _, resErr := range res.Errors() {
+		d.addResultError(CodeInvalidTraits, current.Cti, resErr)
+	}
+}
+
+// addResultError reports the JSON Schema validation error of the property.
+// Summary errors of allOf are skipped since the errors of the subschemas are reported.
+func (d *diagnostics) addResultError(code Code, id string, resErr gojsonschema.ResultError) {
+	if resErr.Type() == "number_all_of" {
+		return
+	}
+	if resErr.Field() == gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+		d.add(code, "%s contains invalid values: %s", id, resErr.Description())
+		return
+	}
+	d.addPath(code, resErr.Field(), "%s@%s: %s", id, resErr.Field(), resErr.Description())
+}
+
+func (v *MetadataValidator) validateSchemas(d *diagnostics, current *metadata.Entity) {
+	for _, raw := range []json.RawMessage{current.Schema, current.TraitsSchema} {
+		if raw == nil {
+			continue
 		}
-		// FIXME: Need to obtain traits from the parent
-		if base.TraitsSchema == nil {
-			return fmt.Errorf("%s type is derived from type that does not define traits", current.Cti)
+		var schema map[string]any
+		if err := json.Unmarshal(raw, &schema); err != nil {
+			d.add(CodeInvalidSchema, "%s contains invalid schema: %s", current.Cti, err)
+			continue
 		}
-		schema, values := []byte(base.TraitsSchema), []byte(current.Traits)
-		if err := validateBytesJsonValues(schema, values); err != nil {
-			return fmt.Errorf("%s contains invalid values: %s", current.Cti, err)
+		schema, err := v.resolveRefs(schema)
+		if err != nil {
+			d.addLoadError(CodeUnresolvedRef, current.Cti, err)
+			continue
 		}
-	}
-	if current.Schema != nil {
-		schema := []byte(current.Schema)
-		if err := validateBytesJsonSchema(schema); err != nil {
-			return fmt.Errorf("%s contains invalid schema: %s", current.Cti, err)
+		if err := validateGoJsonSchema(schema); err != nil {
+			d.add(CodeInvalidSchema, "%s contains invalid schema: %s", current.Cti, err)
 		}
 	}
-	if current.TraitsSchema != nil {
-		schema := []byte(current.TraitsSchema)
-		if err := validateBytesJsonSchema(schema); err != nil {
-			return fmt.Errorf("%s contains invalid schema: %s", current.Cti, err)
+}
+
+// loadSchema decodes the schema and resolves its non-local references.
+func (v *MetadataValidator) loadSchema(raw json.RawMessage) (map[string]any, error) {
+	var schema map[string]any
+	if err := json.Unmarshal(raw, &schema); err != nil {
+		return nil, err
+	}
+	return v.resolveRefs(schema)
+}
+
+// resolveRefs resolves non-local references of the schema and checks the schema limits.
+func (v *MetadataValidator) resolveRefs(schema map[string]any) (map[string]any, error) {
+	if v.resolver != nil {
+		var err error
+		if schema, err = v.resolver.Resolve(schema); err != nil {
+			return nil, err
 		}
 	}
-	if current.Annotations != nil {
-		for key, annotation := range current.Annotations {
-			currentRef := annotation.ReadReference()
-			if currentRef == "" {
-				continue
-			}
-			parentAnnotations := v.FindInheritedAnnotation(current.Cti, key, func(a *metadata.Annotations) bool { return a.Reference != nil })
-			if parentAnnotations == nil {
-				if currentRef == TrueStr {
-					continue
-				}
-				if _, err := v.ctiParser.Parse(currentRef); err != nil {
-					return fmt.Errorf("%s@%s: %s", current.Cti, key, err.Error())
-				}
-				continue
-			}
-			parentRef := parentAnnotations.ReadReference()
-			if parentRef != TrueStr && currentRef == TrueStr {
-				return fmt.Errorf("%s@%s: parent cti.reference defines a specific CTI, but child specifies true", current.Cti, key)
-			}
+	if err := metadata.CheckLimits(schema, "schema", v.limits.MaxSchemaDepth, v.limits.MaxSchemaSize); err != nil {
+		return nil, err
+	}
+	return schema, nil
+}
+
+// checkInstanceLimits checks the nesting depth of instance values or traits.
+// Values that cannot be decoded are reported by the JSON Schema validation.
+func (v *MetadataValidator) checkInstanceLimits(values []byte) error {
+	var doc any
+	if err := json.Unmarshal(values, &doc); err != nil {
+		return nil
+	}
+	return metadata.CheckLimits(doc, "instance", v.limits.MaxInstanceDepth, 0)
+}
+
+func (v *MetadataValidator) validateReferenceAnnotations(d *diagnostics, current *metadata.Entity) {
+	for key, annotation := range current.Annotations {
+		currentRef := annotation.ReadReference()
+		if currentRef == "" {
+			continue
+		}
+		parentAnnotations := v.FindInheritedAnnotation(current.Cti, key, func(a *metadata.Annotations) bool { return a.Reference != nil })
+		if parentAnnotations == nil {
 			if currentRef == TrueStr {
 				continue
 			}
-			expr, err := v.ctiParser.Parse(currentRef)
-			if err != nil {
-				return fmt.Errorf("%s@%s: %s", current.Cti, key, err.Error())
-			}
-			if parentRef == TrueStr {
-				continue
-			}
-			if err := v.matchCti(&expr, parentRef); err != nil {
-				return fmt.Errorf("%s@%s: %s", current.Cti, key, err.Error())
+			if _, err := v.ctiParser.Parse(currentRef); err != nil {
+				d.addPath(CodeInvalidReference, string(key), "%s@%s: %s", current.Cti, key, err.Error())
 			}
+			continue
+		}
+		parentRef := parentAnnotations.ReadReference()
+		if parentRef != TrueStr && currentRef == TrueStr {
+			d.addPath(CodeReferenceWidened, string(key), "%s@%s: parent cti.reference defines a specific CTI, but child specifies true", current.Cti, key)
+			continue
+		}
+		if currentRef == TrueStr {
+			continue
+		}
+		expr, err := v.ctiParser.Parse(currentRef)
+		if err != nil {
+			d.addPath(CodeInvalidReference, string(key), "%s@%s: %s", current.Cti, key, err.Error())
+			continue
+		}
+		if parentRef == TrueStr {
+			continue
+		}
+		if err := v.matchCti(&expr, parentRef); err != nil {
+			d.addPath(CodeReferenceIncompatible, string(key), "%s@%s: %s", current.Cti, key, err.Error())
 		}
 	}
-	return nil
 }
 
 func (v *MetadataValidator) matchCti(ref *cti.Expression, id string) error {
@@ -229,13 +550,12 @@ func (v *MetadataValidator) matchCti(ref *cti.Expression, id string) error {
 func (v *MetadataValidator) GetMergedSchema(id string) (map[string]interface{}, error) {
 	root := id
 
-	entity, ok := v.index[root]
+	entity, ok := v.registry.Get(root)
 	if !ok {
 		return nil, fmt.Errorf("failed to find cti %s", root)
 	}
-	var err error
-	var schema map[string]any
-	if err = json.Unmarshal([]byte(entity.Schema), &schema); err != nil {
+	schema, err := v.loadSchema(entity.Schema)
+	if err != nil {
 		return nil, err
 	}
 	schema, err = merger.ExtractSchemaDefinition(schema)
@@ -250,12 +570,12 @@ func (v *MetadataValidator) GetMergedSchema(id string) (map[string]interface{},
 		}
 		root = parentCti
 
-		entity, ok := v.index[parentCti]
+		entity, ok := v.registry.Get(parentCti)
 		if !ok {
 			return nil, fmt.Errorf("failed to find cti parent %s", parentCti)
 		}
-		var parentSchema map[string]any
-		if err := json.Unmarshal([]byte(entity.Schema), &parentSchema); err != nil {
+		parentSchema, err := v.loadSchema(entity.Schema)
+		if err != nil {
 			return nil, err
 		}
 		parentSchema, err = merger.ExtractSchemaDefinition(parentSchema)
@@ -268,6 +588,9 @@ func (v *MetadataValidator) GetMergedSchema(id string) (map[string]interface{},
 		if err != nil {
 			return nil, err
 		}
+		if err := metadata.CheckLimits(schema, "merged schema", 0, v.limits.MaxSchemaSize); err != nil {
+			return nil, err
+		}
 	}
 	return schema, nil
 }
@@ -275,63 +598,23 @@ func (v *MetadataValidator) GetMergedSchema(id string) (map[string]interface{},
 func (v *MetadataValidator) FindInheritedAnnotation(
 	id string, key metadata.GJsonPath, predicate func(*metadata.Annotations) bool,
 ) *metadata.Annotations {
-	root := id
-	for {
-		parentCti := metadata.GetParentCti(root)
-
-		entity, ok := v.index[parentCti]
-		if !ok {
-			return nil
+	// Root entities are their own parents, so their annotations are inherited too.
+	ancestors := v.registry.Ancestors(id)
+	if metadata.GetParentCti(id) == id {
+		if entity, ok := v.registry.Get(id); ok {
+			ancestors = []*metadata.Entity{entity}
 		}
+	}
+	for _, entity := range ancestors {
 		if val, ok := entity.Annotations[key]; ok && predicate(&val) {
 			return &val
 		}
-		if parentCti == entity.Cti {
-			break
-		}
-		root = parentCti
 	}
 	return nil
 }
 
-func validateBytesJsonSchema(schema []byte) error {
+func validateGoJsonSchema(schema map[string]interface{}) error {
 	sl := gojsonschema.NewSchemaLoader()
 	sl.Validate = true
-	return sl.AddSchemas(gojsonschema.NewBytesLoader(schema))
-}
-
-func validateBytesJsonValues(schema []byte, document []byte) error {
-	sl := gojsonschema.NewBytesLoader(schema)
-	dl := gojsonschema.NewBytesLoader(document)
-	res, err := gojsonschema.Validate(sl, dl)
-	if err != nil {
-		return err
-	}
-	if !res.Valid() {
-		errs := res.Errors()
-		str := make([]string, len(res.Errors()))
-		for i, err := range errs {
-			str[i] = err.Description()
-		}
-		return errors.New(strings.Join(str, "\n-"))
-	}
-	return nil
-}
-
-func validateGoJsonValues(schema map[string]interface{}, document []byte) error {
-	sl := gojsonschema.NewGoLoader(schema)
-	dl := gojsonschema.NewBytesLoader(document)
-	res, err := gojsonschema.Validate(sl, dl)
-	if err != nil {
-		return err
-	}
-	if !res.Valid() {
-		errs := res.Errors()
-		str := make([]string, len(res.Errors()))
-		for i, err := range errs {
-			str[i] = err.Description()
-		}
-		return errors.New(strings.Join(str, "\n-"))
-	}
-	return nil
+	return sl.AddSchemas(gojsonschema.NewGoLoader(schema))
 }
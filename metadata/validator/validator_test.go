@@ -0,0 +1,61 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+	"github.com/acronis/go-cti/metadata/collector"
+)
+
+func Test_MakeMetadataValidator(t *testing.T) {
+	v := MakeMetadataValidator()
+	require.NoError(t, v.AddEntities(metadata.Entities{makeType("cti.x.y.event.v1.0", "Event", `{"type": "object"}`)}))
+	require.NoError(t, v.ValidateAll())
+
+	v.Reset()
+	require.NoError(t, v.AddEntities(metadata.Entities{makeType("cti.x.y.event.v1.0", "Event", `{"type": "object"}`)}))
+}
+
+func Test_LoadFromRegistry(t *testing.T) {
+	base := makeType("cti.x.y.event.v1.0", "Event", `{"type": "object"}`)
+	// Entities without values and schema are loaded, the registry does not reject them.
+	empty := &metadata.Entity{Cti: "cti.x.y.event.v1.0~x.y.empty.v1.0"}
+
+	v := MakeMetadataValidator()
+	v.LoadFromRegistry(&collector.MetadataRegistry{Index: metadata.EntitiesMap{base.Cti: base, empty.Cti: empty}})
+	require.NoError(t, v.LoadEntities(metadata.EntitiesMap{base.Cti: base, empty.Cti: empty}))
+}
+
+func Test_LoadFromRegistryError(t *testing.T) {
+	base := makeType("cti.x.y.event.v1.0", "Event", `{"type": "object"}`)
+	index := metadata.EntitiesMap{base.Cti: base, "cti.x.y.alias.v1.0": base}
+
+	v := MakeMetadataValidator()
+	require.ErrorContains(t, v.LoadEntities(index), "duplicate cti entity")
+
+	v.LoadFromRegistry(&collector.MetadataRegistry{Index: index})
+	require.ErrorContains(t, v.ValidateAll(), "duplicate cti entity")
+
+	// Entities loaded successfully replace the failed load.
+	require.NoError(t, v.LoadEntities(metadata.EntitiesMap{base.Cti: base}))
+	require.NoError(t, v.ValidateAll())
+}
+
+func Test_FindInheritedAnnotation(t *testing.T) {
+	base := makeType("cti.x.y.event.v1.0", "Event", `{"type": "object"}`)
+	base.Annotations[".kind"] = metadata.Annotations{Reference: true}
+	derived := makeType("cti.x.y.event.v1.0~x.y.alert.v1.0", "Alert", `{"type": "object"}`)
+	derived.Annotations[".severity"] = metadata.Annotations{Reference: true}
+
+	v := MakeMetadataValidator()
+	require.NoError(t, v.AddEntities(metadata.Entities{base, derived}))
+	isReference := func(a *metadata.Annotations) bool { return a.Reference != nil }
+
+	// Root entities are checked themselves.
+	require.NotNil(t, v.FindInheritedAnnotation(base.Cti, ".kind", isReference))
+	require.NotNil(t, v.FindInheritedAnnotation(derived.Cti, ".kind", isReference))
+	// Derived entities are not checked themselves, only their ancestors.
+	require.Nil(t, v.FindInheritedAnnotation(derived.Cti, ".severity", isReference))
+}
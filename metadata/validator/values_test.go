@@ -0,0 +1,94 @@
+package validator
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/acronis/go-cti/metadata"
+)
+
+func makeType(id, name, definition string) *metadata.Entity {
+	return &metadata.Entity{
+		Cti:         id,
+		Annotations: map[metadata.GJsonPath]metadata.Annotations{".": {Cti: id}},
+		Schema:      []byte(`{"$schema": "http://json-schema.org/draft-07/schema", "$ref": "#/definitions/` + name + `", "definitions": {"` + name + `": ` + definition + `}}`),
+	}
+}
+
+func Test_ValidateValues(t *testing.T) {
+	base := makeType("cti.x.y.event.v1.0", "Event", `{
+		"type": "object",
+		"properties": {
+			"kind": {"type": "string"},
+			"severity": {"type": "string", "enum": ["low", "medium", "high"]},
+			"version": {"type": "integer", "const": 1}
+		},
+		"x-custom": {"x-discriminator": "kind", "x-discriminatorValue": "Event"}
+	}`)
+	derived := makeType("cti.x.y.event.v1.0~x.y.alert.v1.0", "Alert", `{
+		"type": "object",
+		"properties": {
+			"kind": {"type": "string"},
+			"severity": {"type": "string", "enum": ["medium", "high"]},
+			"version": {"type": "integer", "const": 1}
+		},
+		"x-custom": {"x-discriminator": "kind", "x-discriminatorValue": "Alert"}
+	}`)
+	widened := makeType("cti.x.y.event.v1.0~x.y.widened.v1.0", "Widened", `{
+		"type": "object",
+		"properties": {"severity": {"type": "string", "enum": ["low", "critical"]}}
+	}`)
+
+	testCases := []struct {
+		name   string
+		parent string
+		values string
+		codes  []Code
+	}{
+		{
+			name:   "valid",
+			parent: derived.Cti,
+			values: `{"kind": "Alert", "severity": "high", "version": 1}`,
+		},
+		{
+			name:   "enum inherited from ancestor",
+			parent: derived.Cti,
+			values: `{"kind": "Alert", "severity": "low", "version": 1}`,
+			codes:  []Code{CodeValueNotAllowed},
+		},
+		{
+			name:   "const",
+			parent: derived.Cti,
+			values: `{"kind": "Alert", "severity": "high", "version": 2}`,
+			codes:  []Code{CodeValueNotAllowed},
+		},
+		{
+			name:   "discriminator",
+			parent: derived.Cti,
+			values: `{"kind": "Event", "severity": "high", "version": 1}`,
+			codes:  []Code{CodeValueNotAllowed},
+		},
+		{
+			name:   "enum widened by derived type",
+			parent: widened.Cti,
+			values: `{"severity": "low"}`,
+			codes:  []Code{CodeSchemaMergeFailed},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			instance := &metadata.Entity{Cti: tc.parent + "~x.y.item.v1.0", Values: []byte(tc.values)}
+
+			v := MakeMetadataValidator()
+			require.NoError(t, v.AddEntities(metadata.Entities{base, derived, widened, instance}))
+
+			var codes []Code
+			for _, err := range v.validate(instance) {
+				codes = append(codes, err.Code)
+			}
+			require.Equal(t, tc.codes, codes)
+		})
+	}
+}